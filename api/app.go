@@ -7,6 +7,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -264,6 +265,47 @@ func appInfo(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 	return json.NewEncoder(w).Encode(&a)
 }
 
+// title: app dependency graph
+// path: /apps/{app}/graph
+// method: GET
+// produce: application/json, text/vnd.graphviz
+// responses:
+//   200: OK
+//   400: Invalid format
+//   401: Unauthorized
+//   404: Not found
+func appGraph(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	a, err := getAppFromContext(r.URL.Query().Get(":app"), r)
+	if err != nil {
+		return err
+	}
+	canRead := permission.Check(t, permission.PermAppRead,
+		contextsForApp(&a)...,
+	)
+	if !canRead {
+		return permission.ErrUnauthorized
+	}
+	graph, err := app.AppGraph(&a)
+	if err != nil {
+		return err
+	}
+	return writeGraph(w, r, graph)
+}
+
+func writeGraph(w http.ResponseWriter, r *http.Request, graph *app.Graph) error {
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(graph)
+	case "dot":
+		w.Header().Set("Content-Type", "text/vnd.graphviz")
+		_, err := io.WriteString(w, graph.DOT())
+		return err
+	default:
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid graph format %q, must be json or dot", format)}
+	}
+}
+
 type inputApp struct {
 	TeamOwner   string
 	Platform    string
@@ -1281,6 +1323,10 @@ func bindServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token) (
 		}
 		return err
 	}
+	err = a.ValidateServicePlan(serviceName, instance.PlanName)
+	if err != nil {
+		return err
+	}
 	evt, err := event.New(&event.Opts{
 		Target:     appTarget(appName),
 		Kind:       permission.PermAppUpdateBind,
@@ -1291,7 +1337,7 @@ func bindServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token) (
 	if err != nil {
 		return err
 	}
-	defer func() { evt.Done(err) }()
+	defer func() { doneServiceEvent(evt, err) }()
 	w.Header().Set("Content-Type", "application/x-json-stream")
 	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
 	defer keepAliveWriter.Stop()
@@ -1370,7 +1416,7 @@ func unbindServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token)
 	if err != nil {
 		return err
 	}
-	defer func() { evt.Done(err) }()
+	defer func() { doneServiceEvent(evt, err) }()
 	w.Header().Set("Content-Type", "application/x-json-stream")
 	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
 	defer keepAliveWriter.Stop()
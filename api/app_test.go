@@ -6264,3 +6264,51 @@ func (s *S) TestListCertificates(c *check.C) {
 		},
 	})
 }
+
+func (s *S) TestAppGraph(c *check.C) {
+	a := app.App{Name: "graph-app", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = s.conn.ServiceInstances().Insert(service.ServiceInstance{Name: "db", ServiceName: "mysql", TeamOwner: s.team.Name, Apps: []string{a.Name}})
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", "/apps/"+a.Name+"/graph", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var graph app.Graph
+	err = json.Unmarshal(recorder.Body.Bytes(), &graph)
+	c.Assert(err, check.IsNil)
+	c.Assert(graph.NodesByKind(app.GraphNodeApp), check.DeepEquals, []string{a.Name})
+	c.Assert(graph.NodesByKind(app.GraphNodeInstance), check.DeepEquals, []string{"mysql/db"})
+	c.Assert(graph.Edges, check.Not(check.HasLen), 0)
+}
+
+func (s *S) TestAppGraphDOT(c *check.C) {
+	a := app.App{Name: "graph-app", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", "/apps/"+a.Name+"/graph?format=dot", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "text/vnd.graphviz")
+	c.Assert(recorder.Body.String(), check.Matches, `(?s)digraph tsuru \{\n  "app:graph-app" \[label="graph-app", shape=box\];.*\}\n`)
+}
+
+func (s *S) TestAppGraphInvalidFormat(c *check.C) {
+	a := app.App{Name: "graph-app", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", "/apps/"+a.Name+"/graph?format=svg", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "invalid graph format \"svg\", must be json or dot\n")
+}
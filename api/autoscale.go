@@ -65,6 +65,7 @@ func autoScaleListRules(w http.ResponseWriter, r *http.Request, t auth.Token) er
 // path: /autoscale/rules
 // method: POST
 // consume: application/x-www-form-urlencoded
+// request: autoscale.Rule
 // responses:
 //   200: Ok
 //   400: Invalid data
@@ -14,7 +14,9 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/api/context"
 	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/auth"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
@@ -97,6 +99,37 @@ func build(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 	return err
 }
 
+// title: app build cache purge
+// path: /apps/{appname}/build/cache
+// method: DELETE
+// responses:
+//   200: OK
+//   401: Unauthorized
+//   404: Not found
+func buildCachePurge(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	canPurge := permission.Check(t, permission.PermAppUpdateBuildCache, contextsForApp(instance)...)
+	if !canPurge {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:      appTarget(appName),
+		Kind:        permission.PermAppUpdateBuildCache,
+		Owner:       t,
+		Allowed:     event.Allowed(permission.PermAppReadEvents, contextsForApp(instance)...),
+		DisableLock: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	return image.PurgeAppBuildCache(appName)
+}
+
 func prepareToBuild(r *http.Request) (opts app.DeployOptions, err error) {
 	var file multipart.File
 	var fileSize int64
@@ -133,10 +166,33 @@ func prepareToBuild(r *http.Request) (opts app.DeployOptions, err error) {
 			}
 		}
 	}
+	var allowPlatformEOL bool
+	if allowString := r.FormValue("allow-platform-eol"); allowString != "" {
+		allowPlatformEOL, err = strconv.ParseBool(allowString)
+		if err != nil {
+			return opts, &tsuruErrors.HTTP{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+	}
+	var noCache bool
+	if noCacheString := r.FormValue("no-cache"); noCacheString != "" {
+		noCache, err = strconv.ParseBool(noCacheString)
+		if err != nil {
+			return opts, &tsuruErrors.HTTP{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+	}
 	opts.FileSize = fileSize
 	opts.File = file
 	opts.ArchiveURL = archiveURL
 	opts.Image = image
 	opts.Build = build
+	opts.AllowPlatformEOL = allowPlatformEOL
+	opts.NoCache = noCache
+	opts.TraceID = context.GetTraceID(r)
 	return
 }
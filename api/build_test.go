@@ -14,6 +14,7 @@ import (
 
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/auth"
 	"github.com/tsuru/tsuru/builder"
 	"github.com/tsuru/tsuru/db"
@@ -255,3 +256,45 @@ func (s *BuildSuite) TestBuildWithoutTag(c *check.C) {
 	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
 	c.Assert(recorder.Body.String(), check.Equals, "you must specify the image tag.\n")
 }
+
+func (s *BuildSuite) TestBuildCachePurge(c *check.C) {
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.SetAppBuildCacheImageName(a.Name, "tsuruteam/app-otherapp:v1-builder")
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/build/cache", a.Name)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	cacheImage, err := image.AppBuildCacheImageName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(cacheImage, check.Equals, "")
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "app.update.build-cache",
+	}, eventtest.HasEvent)
+}
+
+func (s *BuildSuite) TestBuildCachePurgeWithoutPermission(c *check.C) {
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermAppRead,
+		Context: permission.Context(permTypes.CtxApp, a.Name),
+	})
+	url := fmt.Sprintf("/apps/%s/build/cache", a.Name)
+	request, err := http.NewRequest(http.MethodDelete, url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+}
@@ -7,6 +7,7 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/ajg/form"
 	"github.com/pkg/errors"
@@ -14,6 +15,8 @@ import (
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/cluster"
 	"github.com/tsuru/tsuru/provision/pool"
 	"github.com/tsuru/tsuru/servicemanager"
 	provTypes "github.com/tsuru/tsuru/types/provision"
@@ -24,6 +27,7 @@ import (
 // method: POST
 // consume: application/x-www-form-urlencoded
 // produce: application/json
+// request: provTypes.Cluster
 // responses:
 //   200: Ok
 //   400: Invalid data
@@ -92,6 +96,7 @@ func createCluster(w http.ResponseWriter, r *http.Request, t auth.Token) (err er
 // method: POST
 // consume: application/x-www-form-urlencoded
 // produce: application/json
+// request: provTypes.Cluster
 // responses:
 //   200: Ok
 //   400: Invalid data
@@ -163,6 +168,7 @@ func updateCluster(w http.ResponseWriter, r *http.Request, t auth.Token) (err er
 // method: GET
 // consume: application/x-www-form-urlencoded
 // produce: application/json
+// response: []provTypes.Cluster
 // responses:
 //   200: Ok
 //   204: No Content
@@ -222,3 +228,130 @@ func deleteCluster(w http.ResponseWriter, r *http.Request, t auth.Token) (err er
 	}
 	return nil
 }
+
+// title: provisioner cluster status
+// path: /provisioner/clusters/{name}/status
+// method: GET
+// produce: application/json
+// response: provTypes.ClusterStatus
+// responses:
+//   200: Ok
+//   401: Unauthorized
+//   404: Cluster not found
+func clusterStatus(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	allowed := permission.Check(t, permission.PermClusterRead)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	provCluster, err := servicemanager.Cluster.FindByName(r.URL.Query().Get(":name"))
+	if err != nil {
+		if err == provTypes.ErrClusterNotFound {
+			return &tsuruErrors.HTTP{
+				Code:    http.StatusNotFound,
+				Message: err.Error(),
+			}
+		}
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(cluster.Status(provCluster))
+}
+
+// title: rotate provisioner cluster credentials
+// path: /provisioner/clusters/{name}/rotate
+// method: POST
+// consume: application/x-www-form-urlencoded
+// produce: application/json
+// response: provTypes.ClusterStatus
+// responses:
+//   200: Ok
+//   400: Invalid data
+//   401: Unauthorized
+//   404: Cluster not found
+func rotateClusterCredentials(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	allowed := permission.Check(t, permission.PermClusterUpdateCredentials)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	dec := form.NewDecoder(nil)
+	dec.IgnoreCase(true)
+	dec.IgnoreUnknownKeys(true)
+	var creds struct {
+		CaCert     []byte
+		ClientCert []byte
+		ClientKey  []byte
+	}
+	err = r.ParseForm()
+	if err == nil {
+		err = dec.DecodeValues(&creds, r.Form)
+	}
+	if err != nil {
+		return &tsuruErrors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+	for k := range r.Form {
+		if strings.EqualFold(k, "clientkey") {
+			delete(r.Form, k)
+		}
+	}
+	clusterName := r.URL.Query().Get(":name")
+	evt, err := event.New(&event.Opts{
+		Target:     event.Target{Type: event.TargetTypeCluster, Value: clusterName},
+		Kind:       permission.PermClusterUpdateCredentials,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermClusterReadEvents),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	provCluster, err := servicemanager.Cluster.FindByName(clusterName)
+	if err != nil {
+		if err == provTypes.ErrClusterNotFound {
+			return &tsuruErrors.HTTP{
+				Code:    http.StatusNotFound,
+				Message: err.Error(),
+			}
+		}
+		return err
+	}
+	if len(creds.CaCert) == 0 && len(creds.ClientCert) == 0 && len(creds.ClientKey) == 0 {
+		return &tsuruErrors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: "at least one of cacert, clientcert or clientkey is required",
+		}
+	}
+	if len(creds.CaCert) > 0 {
+		provCluster.CaCert = creds.CaCert
+	}
+	if len(creds.ClientCert) > 0 {
+		provCluster.ClientCert = creds.ClientCert
+	}
+	if len(creds.ClientKey) > 0 {
+		provCluster.ClientKey = creds.ClientKey
+	}
+	err = cluster.ValidateCredentials(provCluster)
+	if err != nil {
+		return err
+	}
+	err = servicemanager.Cluster.Update(*provCluster)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	prov, err := provision.Get(provCluster.Provisioner)
+	if err != nil {
+		return err
+	}
+	if updater, ok := prov.(provision.ClusterCredentialsProvisioner); ok {
+		err = updater.ClusterCredentialsUpdated(provCluster)
+		if err != nil {
+			return err
+		}
+	}
+	status := cluster.CheckStatus(provCluster)
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(status)
+}
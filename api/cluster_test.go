@@ -6,11 +6,15 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 
 	"github.com/ajg/form"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
 	"github.com/tsuru/tsuru/types/provision"
 	"gopkg.in/check.v1"
 )
@@ -194,3 +198,137 @@ func (s *S) TestDeleteCluster(c *check.C) {
 	s.testServer.ServeHTTP(recorder, request)
 	c.Assert(recorder.Code, check.Equals, http.StatusOK, check.Commentf("body: %q", recorder.Body.String()))
 }
+
+func (s *S) TestClusterStatus(c *check.C) {
+	s.mockService.Cluster.OnFindByName = func(name string) (*provision.Cluster, error) {
+		c.Assert(name, check.Equals, "c-status")
+		return &provision.Cluster{Name: "c-status", Provisioner: "fake", Default: true}, nil
+	}
+	s.provisioner.SetClusterStatus("c-status", provision.ClusterStatus{
+		Version: "v1.10.1",
+		Nodes:   provision.ClusterNodesStatus{Total: 2, Ready: 1},
+	})
+	request, err := http.NewRequest(http.MethodGet, "/1.7/provisioner/clusters/c-status/status", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var status provision.ClusterStatus
+	err = json.NewDecoder(recorder.Body).Decode(&status)
+	c.Assert(err, check.IsNil)
+	c.Assert(status.Name, check.Equals, "c-status")
+	c.Assert(status.Healthy, check.Equals, true)
+	c.Assert(status.Version, check.Equals, "v1.10.1")
+	c.Assert(status.Nodes, check.Equals, provision.ClusterNodesStatus{Total: 2, Ready: 1})
+}
+
+func (s *S) TestClusterStatusNotFound(c *check.C) {
+	s.mockService.Cluster.OnFindByName = func(name string) (*provision.Cluster, error) {
+		return nil, provision.ErrClusterNotFound
+	}
+	request, err := http.NewRequest(http.MethodGet, "/1.7/provisioner/clusters/c1/status", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound, check.Commentf("body: %q", recorder.Body.String()))
+}
+
+func (s *S) TestRotateClusterCredentials(c *check.C) {
+	caCert, err := ioutil.ReadFile("testdata/cert.pem")
+	c.Assert(err, check.IsNil)
+	s.mockService.Cluster.OnFindByName = func(name string) (*provision.Cluster, error) {
+		c.Assert(name, check.Equals, "c1")
+		return &provision.Cluster{Name: "c1", Provisioner: "fake", Default: true, CaCert: []byte("old")}, nil
+	}
+	var updated *provision.Cluster
+	s.mockService.Cluster.OnUpdate = func(clust provision.Cluster) error {
+		updated = &clust
+		return nil
+	}
+	encoded, err := form.EncodeToString(provision.Cluster{CaCert: caCert})
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest(http.MethodPost, "/1.7/provisioner/clusters/c1/rotate", strings.NewReader(encoded))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(updated, check.NotNil)
+	c.Assert(updated.CaCert, check.DeepEquals, caCert)
+	c.Assert(updated.Default, check.Equals, true)
+	c.Assert(s.provisioner.CredentialsUpdatedClusters(), check.DeepEquals, []string{"c1"})
+	var status provision.ClusterStatus
+	err = json.NewDecoder(recorder.Body).Decode(&status)
+	c.Assert(err, check.IsNil)
+	c.Assert(status.Healthy, check.Equals, true)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeCluster, Value: "c1"},
+		Owner:  s.token.GetUserName(),
+		Kind:   "cluster.update.credentials",
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestRotateClusterCredentialsExpiredCertificate(c *check.C) {
+	cert, err := ioutil.ReadFile("testdata/cert.pem")
+	c.Assert(err, check.IsNil)
+	key, err := ioutil.ReadFile("testdata/key.pem")
+	c.Assert(err, check.IsNil)
+	s.mockService.Cluster.OnFindByName = func(name string) (*provision.Cluster, error) {
+		return &provision.Cluster{Name: "c1", Provisioner: "fake", Default: true}, nil
+	}
+	s.mockService.Cluster.OnUpdate = func(clust provision.Cluster) error {
+		c.Fatal("cluster should not be updated with invalid credentials")
+		return nil
+	}
+	encoded, err := form.EncodeToString(provision.Cluster{ClientCert: cert, ClientKey: key})
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest(http.MethodPost, "/1.7/provisioner/clusters/c1/rotate", strings.NewReader(encoded))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(recorder.Body.String(), check.Matches, "client certificate expired at .*\n")
+}
+
+func (s *S) TestRotateClusterCredentialsUnreachableCluster(c *check.C) {
+	caCert, err := ioutil.ReadFile("testdata/cert.pem")
+	c.Assert(err, check.IsNil)
+	s.mockService.Cluster.OnFindByName = func(name string) (*provision.Cluster, error) {
+		return &provision.Cluster{Name: "c1", Provisioner: "fake", Default: true}, nil
+	}
+	s.mockService.Cluster.OnUpdate = func(clust provision.Cluster) error {
+		c.Fatal("cluster should not be updated with invalid credentials")
+		return nil
+	}
+	s.provisioner.PrepareFailure("ClusterStatus", errors.New("x509: certificate signed by unknown authority"))
+	encoded, err := form.EncodeToString(provision.Cluster{CaCert: caCert})
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest(http.MethodPost, "/1.7/provisioner/clusters/c1/rotate", strings.NewReader(encoded))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(recorder.Body.String(), check.Equals, "unable to connect to cluster: x509: certificate signed by unknown authority\n")
+}
+
+func (s *S) TestRotateClusterCredentialsNoCredentials(c *check.C) {
+	s.mockService.Cluster.OnFindByName = func(name string) (*provision.Cluster, error) {
+		return &provision.Cluster{Name: "c1", Provisioner: "fake", Default: true}, nil
+	}
+	request, err := http.NewRequest(http.MethodPost, "/1.7/provisioner/clusters/c1/rotate", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(recorder.Body.String(), check.Equals, "at least one of cacert, clientcert or clientkey is required\n")
+}
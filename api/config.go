@@ -0,0 +1,50 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/config"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// The reload only applies to the tsurud replica handling the request, whose
+// hostname is returned in the result. Every replica must be reloaded.
+//
+// title: reload config
+// path: /config/reload
+// method: POST
+// produce: application/json
+// response: config.ReloadResult
+// responses:
+//   200: OK
+//   401: Unauthorized
+func configReload(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	if !permission.Check(t, permission.PermConfigReload) {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:      event.Target{Type: event.TargetTypeGlobal},
+		Kind:        permission.PermConfigReload,
+		Owner:       t,
+		DisableLock: true,
+		Allowed:     event.Allowed(permission.PermConfigReadEvents),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	result, err := config.Reload()
+	if err != nil {
+		return err
+	}
+	evt.SetOtherCustomData(result)
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,83 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/tsuru/config"
+	internalConfig "github.com/tsuru/tsuru/config"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	check "gopkg.in/check.v1"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func (s *S) writeConfigFile(c *check.C, extra map[string]interface{}) string {
+	data, err := config.Bytes()
+	c.Assert(err, check.IsNil)
+	values := map[string]interface{}{}
+	err = yaml.Unmarshal(data, &values)
+	c.Assert(err, check.IsNil)
+	for k, v := range extra {
+		values[k] = v
+	}
+	data, err = yaml.Marshal(values)
+	c.Assert(err, check.IsNil)
+	f, err := ioutil.TempFile("", "tsuru-config")
+	c.Assert(err, check.IsNil)
+	defer f.Close()
+	_, err = f.Write(data)
+	c.Assert(err, check.IsNil)
+	return f.Name()
+}
+
+func (s *S) TestConfigReload(c *check.C) {
+	path := s.writeConfigFile(c, map[string]interface{}{"config-reload-test": "value"})
+	defer os.Remove(path)
+	internalConfig.SetFilePath(path)
+	defer internalConfig.SetFilePath("")
+	defer config.Unset("config-reload-test")
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermConfigReload,
+		Context: permission.Context(permTypes.CtxGlobal, ""),
+	})
+	request, err := http.NewRequest("POST", "/config/reload", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var result internalConfig.ReloadResult
+	err = json.Unmarshal(recorder.Body.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Changed, check.DeepEquals, []string{"config-reload-test"})
+	c.Assert(result.RestartRequired, check.DeepEquals, []string{"config-reload-test"})
+	value, err := config.GetString("config-reload-test")
+	c.Assert(err, check.IsNil)
+	c.Assert(value, check.Equals, "value")
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeGlobal},
+		Owner:  token.GetUserName(),
+		Kind:   "config.reload",
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestConfigReloadWithoutPermission(c *check.C) {
+	token := userWithPermission(c)
+	request, err := http.NewRequest("POST", "/config/reload", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+}
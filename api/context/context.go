@@ -23,6 +23,8 @@ const (
 	delayedHandlerKey
 	preventUnlockKey
 	appContextKey
+	routeTemplateKey
+	traceIDKey
 )
 
 func Clear(r *http.Request) {
@@ -100,6 +102,40 @@ func GetDelayedHandler(r *http.Request) http.Handler {
 	return nil
 }
 
+func SetRouteTemplate(r *http.Request, template string) {
+	newReq := r.WithContext(context.WithValue(r.Context(), routeTemplateKey, template))
+	*r = *newReq
+}
+
+// GetRouteTemplate returns the path template of the route matched by the
+// request, like /apps/{app}, or an empty string if no route matched.
+func GetRouteTemplate(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if v, ok := r.Context().Value(routeTemplateKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
+func SetTraceID(r *http.Request, traceID string) {
+	newReq := r.WithContext(context.WithValue(r.Context(), traceIDKey, traceID))
+	*r = *newReq
+}
+
+// GetTraceID returns the ID of the trace started for the request, or an empty
+// string if the request isn't traced.
+func GetTraceID(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	if v, ok := r.Context().Value(traceIDKey).(string); ok {
+		return v
+	}
+	return ""
+}
+
 func SetPreventUnlock(r *http.Request) {
 	newReq := r.WithContext(context.WithValue(r.Context(), preventUnlockKey, true))
 	*r = *newReq
@@ -134,3 +134,19 @@ func (s *S) TestRequestID(c *check.C) {
 	id = GetRequestID(r, "Request-ID")
 	c.Assert(id, check.Equals, "test")
 }
+
+func (s *S) TestGetRouteTemplate(c *check.C) {
+	r, err := http.NewRequest("GET", "/apps/myapp", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(GetRouteTemplate(r), check.Equals, "")
+	SetRouteTemplate(r, "/apps/{app}")
+	c.Assert(GetRouteTemplate(r), check.Equals, "/apps/{app}")
+}
+
+func (s *S) TestGetTraceID(c *check.C) {
+	r, err := http.NewRequest("GET", "/apps/myapp", nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(GetTraceID(r), check.Equals, "")
+	SetTraceID(r, "abc123")
+	c.Assert(GetTraceID(r), check.Equals, "abc123")
+}
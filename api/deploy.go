@@ -7,15 +7,19 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
+	"github.com/tsuru/tsuru/api/context"
 	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/auth"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
 	tsuruIo "github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/log"
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/repository"
 )
@@ -94,6 +98,15 @@ func deploy(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 			return &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: "User does not have permission to do this action in this app"}
 		}
 	}
+	req, err := requestDeployApproval(opts)
+	if err != nil {
+		return err
+	}
+	if req != nil {
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprintf(w, "Deploy request %s created, waiting for %d approval(s) before deploying.\n", req.ID.Hex(), req.RequiredApprovals)
+		return nil
+	}
 	var imageID string
 	evt, err := event.New(&event.Opts{
 		Target:        appTarget(appName),
@@ -120,6 +133,33 @@ func deploy(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 	return err
 }
 
+// requestDeployApproval creates a deploy request when the app is in a pool
+// requiring deploy approvals. A nil request means the deploy may run right
+// away.
+func requestDeployApproval(opts app.DeployOptions) (*app.DeployRequest, error) {
+	required, err := app.DeployApprovalsRequired(opts.App)
+	if err != nil || required == 0 {
+		return nil, err
+	}
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       appTarget(opts.App.Name),
+		InternalKind: app.DeployRequestEventKind,
+		RawOwner:     event.Owner{Type: event.OwnerTypeUser, Name: opts.User},
+		CustomData:   opts,
+		DisableLock:  true,
+		Allowed:      event.Allowed(permission.PermAppReadEvents, contextsForApp(opts.App)...),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := app.NewDeployRequest(opts, evt.UniqueID.Hex())
+	if err == app.ErrDeployRequestUpload {
+		err = &tsuruErrors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	evt.DoneCustomData(err, req)
+	return req, err
+}
+
 func permSchemeForDeploy(opts app.DeployOptions) *permission.PermissionScheme {
 	switch opts.GetKind() {
 	case app.DeployGit:
@@ -173,6 +213,12 @@ func diffDeploy(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 	})
 }
 
+// Rollbacks that change the processes, exposed port or tsuru.yaml of the
+// app must be confirmed with confirm=true. Calling it with dry-run=true
+// previews those changes, returning the comparison without deploying.
+// Rollbacks fail when the image can't be compared with the current one,
+// unless the app has no deployed image.
+//
 // title: rollback
 // path: /apps/{appname}/deploy/rollback
 // method: POST
@@ -205,23 +251,54 @@ func deployRollback(w http.ResponseWriter, r *http.Request, t auth.Token) error
 			}
 		}
 	}
-	w.Header().Set("Content-Type", "application/x-json-stream")
-	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
-	defer keepAliveWriter.Stop()
-	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
 	opts := app.DeployOptions{
-		App:          instance,
-		OutputStream: writer,
-		Image:        image,
-		User:         t.GetUserName(),
-		Origin:       origin,
-		Rollback:     true,
+		App:       instance,
+		Image:     image,
+		User:      t.GetUserName(),
+		Origin:    origin,
+		Rollback:  true,
+		TraceID:   context.GetTraceID(r),
 	}
 	opts.GetKind()
 	canRollback := permission.Check(t, permSchemeForDeploy(opts), contextsForApp(instance)...)
 	if !canRollback {
 		return &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: permission.ErrUnauthorized.Error()}
 	}
+	comparison, compareErr := app.CompareDeploys(instance, "", image)
+	if compareErr != nil && compareErr != app.ErrNoCurrentDeploy {
+		return deployCompareError(compareErr)
+	}
+	if dryRun, _ := strconv.ParseBool(r.FormValue("dry-run")); dryRun {
+		if compareErr != nil {
+			return deployCompareError(compareErr)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		return json.NewEncoder(w).Encode(comparison)
+	}
+	confirm, _ := strconv.ParseBool(r.FormValue("confirm"))
+	if compareErr == nil && comparison.HasChanges() && !confirm {
+		return &tsuruErrors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: comparison.String() + "Review the changes with dry-run=true and rollback with confirm=true to apply them.",
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	opts.OutputStream = writer
+	if compareErr == nil && comparison.HasChanges() {
+		fmt.Fprint(writer, comparison.String())
+	}
+	req, err := requestDeployApproval(opts)
+	if err != nil {
+		writer.Encode(tsuruIo.SimpleJsonMessage{Error: err.Error()})
+		return nil
+	}
+	if req != nil {
+		fmt.Fprintf(writer, "Rollback request %s created, waiting for %d approval(s) before deploying.\n", req.ID.Hex(), req.RequiredApprovals)
+		return nil
+	}
 	var imageID string
 	evt, err := event.New(&event.Opts{
 		Target:        appTarget(appName),
@@ -244,10 +321,55 @@ func deployRollback(w http.ResponseWriter, r *http.Request, t auth.Token) error
 	return nil
 }
 
+// title: deploy compare
+// path: /apps/{appname}/deploys/compare
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   400: Invalid data
+//   401: Unauthorized
+//   404: Not found
+func deployCompare(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: fmt.Sprintf("App %s not found.", appName)}
+	}
+	canRead := permission.Check(t, permission.PermAppReadDeploy, contextsForApp(instance)...)
+	if !canRead {
+		return permission.ErrUnauthorized
+	}
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		return &tsuruErrors.HTTP{Code: http.StatusBadRequest, Message: "you must specify the deploy to compare with"}
+	}
+	comparison, err := app.CompareDeploys(instance, from, to)
+	if err != nil {
+		return deployCompareError(err)
+	}
+	w.Header().Add("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(comparison)
+}
+
+func deployCompareError(err error) error {
+	switch err.(type) {
+	case *image.ImageNotFoundErr, *image.InvalidVersionErr:
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	switch err {
+	case event.ErrEventNotFound, image.ErrNoImagesAvailable, app.ErrNoCurrentDeploy:
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	return err
+}
+
 // title: deploy list
 // path: /deploys
 // method: GET
 // produce: application/json
+// response: []app.DeployData
 // responses:
 //   200: OK
 //   204: No content
@@ -279,6 +401,7 @@ func deploysList(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 // path: /deploys/{deploy}
 // method: GET
 // produce: application/json
+// response: app.DeployData
 // responses:
 //   200: OK
 //   401: Unauthorized
@@ -337,6 +460,7 @@ func deployRebuild(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 		User:         t.GetUserName(),
 		Origin:       origin,
 		Kind:         app.DeployRebuild,
+		TraceID:      context.GetTraceID(r),
 	}
 	canDeploy := permission.Check(t, permSchemeForDeploy(opts), contextsForApp(instance)...)
 	if !canDeploy {
@@ -432,3 +556,203 @@ func deployRollbackUpdate(w http.ResponseWriter, r *http.Request, t auth.Token)
 	}
 	return err
 }
+
+// title: deploy request list
+// path: /apps/{appname}/deploy/requests
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+//   404: Not found
+func deployRequestList(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: fmt.Sprintf("App %s not found.", appName)}
+	}
+	canRead := permission.Check(t, permission.PermAppReadDeploy, contextsForApp(instance)...)
+	if !canRead {
+		return permission.ErrUnauthorized
+	}
+	status := app.DeployRequestStatus(r.URL.Query().Get("status"))
+	reqs, err := app.ListDeployRequests(appName, status)
+	if err != nil {
+		return err
+	}
+	if len(reqs) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(reqs)
+}
+
+// title: deploy request info
+// path: /apps/{appname}/deploy/requests/{id}
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   401: Unauthorized
+//   404: Not found
+func deployRequestInfo(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: fmt.Sprintf("App %s not found.", appName)}
+	}
+	canRead := permission.Check(t, permission.PermAppReadDeploy, contextsForApp(instance)...)
+	if !canRead {
+		return permission.ErrUnauthorized
+	}
+	req, err := app.GetDeployRequest(appName, r.URL.Query().Get(":id"))
+	if err == app.ErrDeployRequestNotFound {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(req)
+}
+
+// title: deploy request approve
+// path: /apps/{appname}/deploy/requests/{id}/approve
+// method: POST
+// produce: application/x-json-stream
+// responses:
+//   200: OK
+//   401: Unauthorized
+//   403: Forbidden
+//   404: Not found
+//   409: Request not pending
+func deployRequestApprove(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: fmt.Sprintf("App %s not found.", appName)}
+	}
+	canApprove := permission.Check(t, permission.PermAppDeployApprove, contextsForApp(instance)...)
+	if !canApprove {
+		return permission.ErrUnauthorized
+	}
+	req, err := app.GetDeployRequest(appName, r.URL.Query().Get(":id"))
+	if err == app.ErrDeployRequestNotFound {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	approveEvt, err := event.New(&event.Opts{
+		Target:      appTarget(appName),
+		Kind:        permission.PermAppDeployApprove,
+		Owner:       t,
+		CustomData:  map[string]string{"request": req.ID.Hex()},
+		DisableLock: true,
+		Allowed:     event.Allowed(permission.PermAppReadEvents, contextsForApp(instance)...),
+	})
+	if err != nil {
+		return err
+	}
+	ready, err := req.Approve(t.GetUserName(), approveEvt.UniqueID.Hex())
+	approveEvt.DoneCustomData(err, req)
+	switch err {
+	case nil:
+	case app.ErrDeployRequestSelfApproval:
+		return &tsuruErrors.HTTP{Code: http.StatusForbidden, Message: err.Error()}
+	case app.ErrDeployRequestNotPending, app.ErrDeployRequestExpired, app.ErrDeployRequestAlreadyApproved:
+		return &tsuruErrors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+	default:
+		return err
+	}
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	if !ready {
+		fmt.Fprintf(writer, "Deploy request %s approved, %d of %d approval(s) received.\n", req.ID.Hex(), len(req.Approvals), req.RequiredApprovals)
+		return nil
+	}
+	fmt.Fprintf(writer, "Deploy request %s approved, deploying.\n", req.ID.Hex())
+	err = runDeployRequest(writer, req, instance)
+	if err != nil {
+		writer.Encode(tsuruIo.SimpleJsonMessage{Error: err.Error()})
+	}
+	return nil
+}
+
+// title: deploy request retry
+// path: /apps/{appname}/deploy/requests/{id}/retry
+// method: POST
+// produce: application/x-json-stream
+// responses:
+//   200: OK
+//   401: Unauthorized
+//   404: Not found
+//   409: Request not failed
+func deployRequestRetry(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	appName := r.URL.Query().Get(":appname")
+	instance, err := app.GetByName(appName)
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: fmt.Sprintf("App %s not found.", appName)}
+	}
+	canRetry := permission.Check(t, permission.PermAppDeployApprove, contextsForApp(instance)...)
+	if !canRetry {
+		return permission.ErrUnauthorized
+	}
+	req, err := app.GetDeployRequest(appName, r.URL.Query().Get(":id"))
+	if err == app.ErrDeployRequestNotFound {
+		return &tsuruErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	err = req.Retry()
+	if err == app.ErrDeployRequestNotFailed {
+		return &tsuruErrors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	fmt.Fprintf(writer, "Retrying deploy request %s.\n", req.ID.Hex())
+	err = runDeployRequest(writer, req, instance)
+	if err != nil {
+		writer.Encode(tsuruIo.SimpleJsonMessage{Error: err.Error()})
+	}
+	return nil
+}
+
+// runDeployRequest deploys an approved request, marking it as failed when
+// the deploy cannot be started or fails, so it can be retried.
+func runDeployRequest(w io.Writer, req *app.DeployRequest, instance *app.App) (err error) {
+	defer func() {
+		if err == nil {
+			return
+		}
+		if failErr := req.Fail(err); failErr != nil {
+			log.Errorf("[deploy requests] unable to mark request %s as failed: %v", req.ID.Hex(), failErr)
+		}
+	}()
+	var imageID string
+	evt, err := event.New(&event.Opts{
+		Target:        appTarget(instance.Name),
+		Kind:          permSchemeForDeploy(req.Options),
+		RawOwner:      event.Owner{Type: event.OwnerTypeUser, Name: req.User},
+		CustomData:    req.Options,
+		Allowed:       event.Allowed(permission.PermAppReadEvents, contextsForApp(instance)...),
+		AllowedCancel: event.Allowed(permission.PermAppUpdateEvents, contextsForApp(instance)...),
+		Cancelable:    true,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.DoneCustomData(err, map[string]string{"image": imageID}) }()
+	imageID, err = req.Deploy(w, evt)
+	return err
+}
@@ -189,6 +189,43 @@ func (s *DeploySuite) TestDeployHandler(c *check.C) {
 	}, eventtest.HasEvent)
 }
 
+func (s *DeploySuite) TestDeployHandlerNoCache(c *check.C) {
+	var builderCalled bool
+	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
+		builderCalled = true
+		c.Assert(opts.NoCache, check.Equals, true)
+		return "tsuruteam/app-otherapp:mytag", nil
+	}
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy", a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("archive-url=http://something.tar.gz&no-cache=true"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(builderCalled, check.Equals, true)
+}
+
+func (s *DeploySuite) TestDeployHandlerInvalidNoCache(c *check.C) {
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy", a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("archive-url=http://something.tar.gz&no-cache=maybe"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+}
+
 func (s *DeploySuite) TestDeployOriginDragAndDrop(c *check.C) {
 	s.builder.OnBuild = func(p provision.BuilderDeploy, app provision.App, evt *event.Event, opts *builder.BuildOpts) (string, error) {
 		c.Assert(opts.ArchiveFile, check.NotNil)
@@ -1112,6 +1149,8 @@ func (s *DeploySuite) TestDeployRollbackHandler(c *check.C) {
 	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name}
 	err := app.CreateApp(&a, s.user)
 	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.Name, "my-image-123:v1")
+	c.Assert(err, check.IsNil)
 	v := url.Values{}
 	v.Set("origin", "rollback")
 	v.Set("image", "my-image-123:v1")
@@ -1153,6 +1192,8 @@ func (s *DeploySuite) TestDeployRollbackHandlerWithCompleteImage(c *check.C) {
 	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name}
 	err := app.CreateApp(&a, s.user)
 	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.Name, "127.0.0.1:5000/tsuru/app-tsuru-dashboard:v1")
+	c.Assert(err, check.IsNil)
 	v := url.Values{}
 	v.Set("origin", "rollback")
 	v.Set("image", "127.0.0.1:5000/tsuru/app-tsuru-dashboard:v1")
@@ -1262,12 +1303,12 @@ func (s *DeploySuite) TestDeployRollbackHandlerWithInexistVersion(c *check.C) {
 	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
 	server := RunServer(true)
 	server.ServeHTTP(recorder, request)
-	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
-	c.Assert(recorder.Code, check.Equals, http.StatusOK)
-	var body map[string]string
-	err = json.Unmarshal(recorder.Body.Bytes(), &body)
-	c.Assert(err, check.IsNil)
-	c.Assert(body, check.DeepEquals, map[string]string{"Message": "", "Error": "Invalid version: v3"})
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+	c.Assert(recorder.Body.String(), check.Equals, "Invalid version: v3\n")
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Kind:   "app.deploy",
+	}, check.Not(eventtest.HasEvent))
 }
 
 func (s *DeploySuite) TestDiffDeploy(c *check.C) {
@@ -1563,3 +1604,376 @@ func (s *DeploySuite) TestRollbackUpdateErrNoPerms(c *check.C) {
 	c.Assert(recorder.Body.String(), check.Equals, "User does not have permission to do this action in this app\n")
 	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
 }
+
+func (s *DeploySuite) createCompareImages(c *check.C) *app.App {
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	for i, port := range []string{"8888/tcp", "8080/tcp"} {
+		img := fmt.Sprintf("127.0.0.1:5000/tsuru/app-otherapp:v%d", i+1)
+		err = image.AppendAppImageName(a.Name, img)
+		c.Assert(err, check.IsNil)
+		err = image.SaveImageCustomData(img, map[string]interface{}{
+			"processes":   map[string]interface{}{"web": "run v" + fmt.Sprint(i+1)},
+			"exposedPort": port,
+		})
+		c.Assert(err, check.IsNil)
+	}
+	return &a
+}
+
+func (s *DeploySuite) TestDeployCompare(c *check.C) {
+	a := s.createCompareImages(c)
+	u := fmt.Sprintf("/apps/%s/deploys/compare?from=v1&to=v2", a.Name)
+	request, err := http.NewRequest("GET", u, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var comparison app.DeployComparison
+	err = json.Unmarshal(recorder.Body.Bytes(), &comparison)
+	c.Assert(err, check.IsNil)
+	c.Assert(comparison, check.DeepEquals, app.DeployComparison{
+		App:         a.Name,
+		From:        "127.0.0.1:5000/tsuru/app-otherapp:v1",
+		To:          "127.0.0.1:5000/tsuru/app-otherapp:v2",
+		Processes:   []app.DeployChange{{Field: "web", From: "run v1", To: "run v2"}},
+		ExposedPort: &app.DeployChange{Field: "exposedPort", From: "8888/tcp", To: "8080/tcp"},
+		TsuruYaml:   []app.DeployChange{},
+	})
+}
+
+func (s *DeploySuite) TestDeployCompareMissingTo(c *check.C) {
+	a := s.createCompareImages(c)
+	u := fmt.Sprintf("/apps/%s/deploys/compare?from=v1", a.Name)
+	request, err := http.NewRequest("GET", u, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+}
+
+func (s *DeploySuite) TestDeployCompareInvalidVersion(c *check.C) {
+	a := s.createCompareImages(c)
+	u := fmt.Sprintf("/apps/%s/deploys/compare?from=v1&to=v9", a.Name)
+	request, err := http.NewRequest("GET", u, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
+
+func (s *DeploySuite) TestDeployCompareForbidden(c *check.C) {
+	a := s.createCompareImages(c)
+	token := userWithPermission(c)
+	u := fmt.Sprintf("/apps/%s/deploys/compare?from=v1&to=v2", a.Name)
+	request, err := http.NewRequest("GET", u, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+}
+
+func (s *DeploySuite) TestDeployRollbackHandlerDryRun(c *check.C) {
+	a := s.createCompareImages(c)
+	v := url.Values{}
+	v.Set("image", "v1")
+	v.Set("dry-run", "true")
+	u := fmt.Sprintf("/apps/%s/deploy/rollback", a.Name)
+	request, err := http.NewRequest("POST", u, strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var comparison app.DeployComparison
+	err = json.Unmarshal(recorder.Body.Bytes(), &comparison)
+	c.Assert(err, check.IsNil)
+	c.Assert(comparison.From, check.Equals, "127.0.0.1:5000/tsuru/app-otherapp:v2")
+	c.Assert(comparison.To, check.Equals, "127.0.0.1:5000/tsuru/app-otherapp:v1")
+	c.Assert(comparison.Processes, check.DeepEquals, []app.DeployChange{{Field: "web", From: "run v2", To: "run v1"}})
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Kind:   "app.deploy",
+	}, check.Not(eventtest.HasEvent))
+}
+
+func (s *DeploySuite) TestDeployRollbackHandlerShowsChanges(c *check.C) {
+	a := s.createCompareImages(c)
+	v := url.Values{}
+	v.Set("image", "v1")
+	v.Set("confirm", "true")
+	u := fmt.Sprintf("/apps/%s/deploy/rollback", a.Name)
+	request, err := http.NewRequest("POST", u, strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*Changes from 127.0.0.1:5000/tsuru/app-otherapp:v2 to 127.0.0.1:5000/tsuru/app-otherapp:v1.*process web.*exposed port.*Rollback deploy called.*`)
+}
+
+func (s *DeploySuite) TestDeployRollbackHandlerWithChangesRequiresConfirm(c *check.C) {
+	a := s.createCompareImages(c)
+	v := url.Values{}
+	v.Set("image", "v1")
+	u := fmt.Sprintf("/apps/%s/deploy/rollback", a.Name)
+	request, err := http.NewRequest("POST", u, strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Matches, `(?s)Changes from 127.0.0.1:5000/tsuru/app-otherapp:v2 to 127.0.0.1:5000/tsuru/app-otherapp:v1.*confirm=true.*`)
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Kind:   "app.deploy",
+	}, check.Not(eventtest.HasEvent))
+}
+
+func (s *DeploySuite) TestDeployToProtectedPoolCreatesRequest(c *check.C) {
+	approvals := 1
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name, Router: "fake"}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy", a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("image=127.0.0.1:5000/tsuru/otherapp"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusAccepted)
+	reqs, err := app.ListDeployRequests(a.Name, app.DeployRequestPending)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 1)
+	c.Assert(reqs[0].User, check.Equals, s.token.GetUserName())
+	c.Assert(reqs[0].Options.Image, check.Equals, "127.0.0.1:5000/tsuru/otherapp")
+	c.Assert(recorder.Body.String(), check.Equals, fmt.Sprintf("Deploy request %s created, waiting for 1 approval(s) before deploying.\n", reqs[0].ID.Hex()))
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  s.token.GetUserName(),
+		Kind:   "deploy-request",
+	}, eventtest.HasEvent)
+}
+
+func (s *DeploySuite) TestDeployWithTokenForInternalAppNameToProtectedPoolCreatesRequest(c *check.C) {
+	approvals := 1
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	token, err := nativeScheme.AppLogin(app.InternalAppName)
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name, Router: "fake"}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy", a.Name)
+	request, err := http.NewRequest("POST", url, strings.NewReader("archive-url=http://something.tar.gz&user=fulano&commit=123"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusAccepted)
+	reqs, err := app.ListDeployRequests(a.Name, app.DeployRequestPending)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 1)
+	c.Assert(reqs[0].User, check.Equals, "fulano")
+	c.Assert(reqs[0].Options.Commit, check.Equals, "123")
+	c.Assert(recorder.Body.String(), check.Equals, fmt.Sprintf("Deploy request %s created, waiting for 1 approval(s) before deploying.\n", reqs[0].ID.Hex()))
+}
+
+func (s *DeploySuite) TestDeployRequestApprove(c *check.C) {
+	approvals := 1
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name, Router: "fake"}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	req, err := app.NewDeployRequest(app.DeployOptions{App: &a, Image: "myimage", User: s.token.GetUserName()}, "")
+	c.Assert(err, check.IsNil)
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "approver", permission.Permission{
+		Scheme:  permission.PermAppDeployApprove,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	url := fmt.Sprintf("/apps/%s/deploy/requests/%s/approve", a.Name, req.ID.Hex())
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/x-json-stream")
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*Deploy request \w+ approved, deploying.*Builder deploy called.*`)
+	dbReq, err := app.GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Status, check.Equals, app.DeployRequestApproved)
+	c.Assert(dbReq.Approvals, check.HasLen, 1)
+	c.Assert(dbReq.Approvals[0].User, check.Equals, token.GetUserName())
+	c.Assert(eventtest.EventDesc{
+		Target: appTarget(a.Name),
+		Owner:  token.GetUserName(),
+		Kind:   "app.deploy-approve",
+		StartCustomData: map[string]interface{}{
+			"request": req.ID.Hex(),
+		},
+	}, eventtest.HasEvent)
+	c.Assert(eventtest.EventDesc{
+		Target:     appTarget(a.Name),
+		Owner:      s.token.GetUserName(),
+		Kind:       "app.deploy.image",
+		LogMatches: `Builder deploy called`,
+	}, eventtest.HasEvent)
+}
+
+func (s *DeploySuite) TestDeployRequestApproveDeployFailureMarksRequestFailed(c *check.C) {
+	approvals := 1
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name, Router: "fake"}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	req, err := app.NewDeployRequest(app.DeployOptions{App: &a, Image: "myimage", User: s.token.GetUserName()}, "")
+	c.Assert(err, check.IsNil)
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "approver", permission.Permission{
+		Scheme:  permission.PermAppDeployApprove,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	s.provisioner.PrepareFailure("Deploy", fmt.Errorf("deploy failed"))
+	url := fmt.Sprintf("/apps/%s/deploy/requests/%s/approve", a.Name, req.ID.Hex())
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*deploy failed.*`)
+	dbReq, err := app.GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Status, check.Equals, app.DeployRequestFailed)
+	c.Assert(dbReq.Error, check.Equals, "deploy failed")
+	url = fmt.Sprintf("/apps/%s/deploy/requests/%s/retry", a.Name, req.ID.Hex())
+	request, err = http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*Retrying deploy request \w+.*Builder deploy called.*`)
+	dbReq, err = app.GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Status, check.Equals, app.DeployRequestApproved)
+	c.Assert(dbReq.Error, check.Equals, "")
+	c.Assert(eventtest.EventDesc{
+		Target:       appTarget(a.Name),
+		Owner:        s.token.GetUserName(),
+		Kind:         "app.deploy.image",
+		ErrorMatches: `deploy failed`,
+	}, eventtest.HasEvent)
+}
+
+func (s *DeploySuite) TestDeployRequestRetryNotFailed(c *check.C) {
+	approvals := 1
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name, Router: "fake"}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	req, err := app.NewDeployRequest(app.DeployOptions{App: &a, Image: "myimage", User: "someone@tsuru.io"}, "")
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy/requests/%s/retry", a.Name, req.ID.Hex())
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusConflict)
+	c.Assert(recorder.Body.String(), check.Equals, app.ErrDeployRequestNotFailed.Error()+"\n")
+}
+
+func (s *DeploySuite) TestDeployRequestApproveOwnRequest(c *check.C) {
+	approvals := 1
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name, Router: "fake"}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "approver", permission.Permission{
+		Scheme:  permission.PermAppDeployApprove,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	req, err := app.NewDeployRequest(app.DeployOptions{App: &a, Image: "myimage", User: token.GetUserName()}, "")
+	c.Assert(err, check.IsNil)
+	url := fmt.Sprintf("/apps/%s/deploy/requests/%s/approve", a.Name, req.ID.Hex())
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+	c.Assert(recorder.Body.String(), check.Equals, app.ErrDeployRequestSelfApproval.Error()+"\n")
+}
+
+func (s *DeploySuite) TestDeployRequestApproveWithDeployPermission(c *check.C) {
+	approvals := 1
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name, Router: "fake"}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	req, err := app.NewDeployRequest(app.DeployOptions{App: &a, Image: "myimage", User: "someone@tsuru.io"}, "")
+	c.Assert(err, check.IsNil)
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "deployer", permission.Permission{
+		Scheme:  permission.PermAppDeploy,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	url := fmt.Sprintf("/apps/%s/deploy/requests/%s/approve", a.Name, req.ID.Hex())
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+	dbReq, err := app.GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Approvals, check.HasLen, 0)
+}
+
+func (s *DeploySuite) TestDeployRequestApproveWithoutPermission(c *check.C) {
+	approvals := 1
+	err := pool.PoolUpdate("pool1", pool.UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "otherapp", Platform: "python", TeamOwner: s.team.Name, Router: "fake"}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	req, err := app.NewDeployRequest(app.DeployOptions{App: &a, Image: "myimage", User: "someone@tsuru.io"}, "")
+	c.Assert(err, check.IsNil)
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "reader", permission.Permission{
+		Scheme:  permission.PermAppReadDeploy,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	url := fmt.Sprintf("/apps/%s/deploy/requests/%s/approve", a.Name, req.ID.Hex())
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+}
@@ -0,0 +1,32 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/diagnostic"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// title: diagnostics report
+// path: /diagnostics
+// method: GET
+// produce: application/json
+// response: diagnostic.Report
+// responses:
+//   200: OK
+//   401: Unauthorized
+func diagnosticsReport(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	if !permission.Check(t, permission.PermDiagnosticsRead) {
+		return permission.ErrUnauthorized
+	}
+	r.ParseForm()
+	report := diagnostic.Run(r.Form["check"]...)
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(report)
+}
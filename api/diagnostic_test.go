@@ -0,0 +1,53 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/tsuru/diagnostic"
+	"github.com/tsuru/tsuru/permission"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestDiagnosticsReport(c *check.C) {
+	err := s.conn.Apps().Insert(bson.M{"name": "orphan-app", "pool": "removed-pool"})
+	c.Assert(err, check.IsNil)
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermDiagnosticsRead,
+		Context: permission.Context(permTypes.CtxGlobal, ""),
+	})
+	request, err := http.NewRequest("GET", "/diagnostics?check=orphaned-apps", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var report diagnostic.Report
+	err = json.Unmarshal(recorder.Body.Bytes(), &report)
+	c.Assert(err, check.IsNil)
+	c.Assert(report.Checks, check.DeepEquals, []string{"orphaned-apps"})
+	c.Assert(report.Findings, check.DeepEquals, []diagnostic.Finding{{
+		Check:    "orphaned-apps",
+		Severity: diagnostic.SeverityError,
+		Target:   &diagnostic.Target{Type: "app", Value: "orphan-app"},
+		Message:  `app pool "removed-pool" not found`,
+	}})
+}
+
+func (s *S) TestDiagnosticsReportWithoutPermission(c *check.C) {
+	token := userWithPermission(c)
+	request, err := http.NewRequest("GET", "/diagnostics", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+}
@@ -22,13 +22,31 @@ import (
 // path: /events
 // method: GET
 // produce: application/json
+// response: []event.Event
 // responses:
 //   200: OK
 //   204: No content
 func eventList(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	filter, err := eventFilterFromRequest(r, t)
+	if err != nil {
+		return err
+	}
+	events, err := event.List(filter)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Add("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(events)
+}
+
+func eventFilterFromRequest(r *http.Request, t auth.Token) (*event.Filter, error) {
 	err := r.ParseForm()
 	if err != nil {
-		return &errors.HTTP{Code: http.StatusBadRequest, Message: fmt.Sprintf("unable to parse event filters: %s", err)}
+		return nil, &errors.HTTP{Code: http.StatusBadRequest, Message: fmt.Sprintf("unable to parse event filters: %s", err)}
 	}
 	var filter *event.Filter
 	dec := form.NewDecoder(nil)
@@ -36,30 +54,22 @@ func eventList(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 	dec.IgnoreCase(true)
 	err = dec.DecodeValues(&filter, r.Form)
 	if err != nil {
-		return &errors.HTTP{Code: http.StatusBadRequest, Message: fmt.Sprintf("unable to parse event filters: %s", err)}
+		return nil, &errors.HTTP{Code: http.StatusBadRequest, Message: fmt.Sprintf("unable to parse event filters: %s", err)}
 	}
 	filter.LoadKindNames(r.Form)
 	filter.PruneUserValues()
 	filter.Permissions, err = t.Permissions()
 	if err != nil {
-		return err
-	}
-	events, err := event.List(filter)
-	if err != nil {
-		return err
+		return nil, err
 	}
-	if len(events) == 0 {
-		w.WriteHeader(http.StatusNoContent)
-		return nil
-	}
-	w.Header().Add("Content-Type", "application/json")
-	return json.NewEncoder(w).Encode(events)
+	return filter, nil
 }
 
 // title: kind list
 // path: /events/kinds
 // method: GET
 // produce: application/json
+// response: []event.Kind
 // responses:
 //   200: OK
 //   204: No content
@@ -80,6 +90,7 @@ func kindList(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 // path: /events/{uuid}
 // method: GET
 // produce: application/json
+// response: event.Event
 // responses:
 //   200: OK
 //   400: Invalid uuid
@@ -155,6 +166,7 @@ func eventCancel(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 // path: /events/blocks
 // method: GET
 // produce: application/json
+// response: []event.Block
 // responses:
 //   200: OK
 //   204: No content
@@ -188,6 +200,7 @@ func eventBlockList(w http.ResponseWriter, r *http.Request, t auth.Token) error
 // path: /events/blocks
 // method: POST
 // consume: application/x-www-form-urlencoded
+// request: event.Block
 // responses:
 //   200: OK
 //   400: Invalid data or empty reason
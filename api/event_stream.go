@@ -0,0 +1,193 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+)
+
+var (
+	eventStreamPollInterval = 2 * time.Second
+	eventStreamKeepAlive    = 30 * time.Second
+	eventStreamBatchSize    = 100
+)
+
+type eventStreamSender interface {
+	send(kind, id string, data []byte) error
+	keepAlive() error
+}
+
+type sseEventSender struct {
+	w http.ResponseWriter
+}
+
+func (s *sseEventSender) send(kind, id string, data []byte) error {
+	var buf bytes.Buffer
+	if id != "" {
+		fmt.Fprintf(&buf, "id: %s\n", id)
+	}
+	fmt.Fprintf(&buf, "event: %s\n", kind)
+	for _, line := range strings.Split(string(data), "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+	_, err := s.w.Write(buf.Bytes())
+	return err
+}
+
+func (s *sseEventSender) keepAlive() error {
+	_, err := s.w.Write([]byte(": keep-alive\n\n"))
+	return err
+}
+
+type wsEventMessage struct {
+	ID    string          `json:"id,omitempty"`
+	Type  string          `json:"type"`
+	Event json.RawMessage `json:"event,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+type wsEventSender struct {
+	ws *websocket.Conn
+}
+
+func (s *wsEventSender) send(kind, id string, data []byte) error {
+	msg := wsEventMessage{ID: id, Type: kind}
+	if kind == "error" {
+		msg.Error = string(data)
+	} else {
+		msg.Event = data
+	}
+	s.ws.SetWriteDeadline(time.Now().Add(pongWait))
+	return s.ws.WriteJSON(msg)
+}
+
+func (s *wsEventSender) keepAlive() error {
+	return s.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(2*time.Second))
+}
+
+func eventStreamKind(evt *event.Event) string {
+	if evt.UpdateTime.Equal(evt.StartTime) {
+		return "created"
+	}
+	return "updated"
+}
+
+// streamEvents polls the event log for events changed after cursor, sending
+// them until done is closed or the client goes away.
+func streamEvents(sender eventStreamSender, filter *event.Filter, cursor event.StreamCursor, done <-chan struct{}) {
+	ticker := time.NewTicker(eventStreamPollInterval)
+	defer ticker.Stop()
+	lastSent := time.Now()
+	stream := event.NewStream(filter, cursor)
+	for {
+		evts, err := stream.Next(eventStreamBatchSize)
+		if err != nil {
+			log.Errorf("[event stream] unable to list events: %v", err)
+			sender.send("error", "", []byte(err.Error()))
+			return
+		}
+		for i := range evts {
+			data, err := json.Marshal(&evts[i])
+			if err != nil {
+				log.Errorf("[event stream] unable to encode event %s: %v", evts[i].UniqueID.Hex(), err)
+				continue
+			}
+			if evtCursor := event.CursorFor(&evts[i]); evtCursor.After(cursor) {
+				cursor = evtCursor
+			}
+			err = sender.send(eventStreamKind(&evts[i]), cursor.String(), data)
+			if err != nil {
+				return
+			}
+			lastSent = time.Now()
+		}
+		if len(evts) < eventStreamBatchSize && time.Since(lastSent) >= eventStreamKeepAlive {
+			if sender.keepAlive() != nil {
+				return
+			}
+			lastSent = time.Now()
+		}
+		select {
+		case <-done:
+			return
+		default:
+		}
+		if len(evts) == eventStreamBatchSize {
+			continue
+		}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// title: event stream
+// path: /events/stream
+// method: GET
+// produce: text/event-stream or Websocket connection upgrade
+// responses:
+//   101: Switch Protocol to websocket
+//   200: OK
+//   400: Invalid filter or last event id
+//   401: Unauthorized
+//   404: Last event not found
+func eventStream(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	filter, err := eventFilterFromRequest(r, t)
+	if err != nil {
+		return err
+	}
+	lastID := r.Header.Get("Last-Event-ID")
+	if lastID == "" {
+		lastID = r.FormValue("last_id")
+	}
+	cursor, err := event.ParseStreamCursor(lastID)
+	if err != nil {
+		if err == event.ErrEventNotFound {
+			return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+		}
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	if cursor.IsZero() {
+		cursor.UpdateTime = time.Now().UTC()
+	}
+	if websocket.IsWebSocketUpgrade(r) {
+		ws, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return nil
+		}
+		defer ws.Close()
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for {
+				if _, _, err := ws.NextReader(); err != nil {
+					return
+				}
+			}
+		}()
+		streamEvents(&wsEventSender{ws: ws}, filter, cursor, done)
+		ws.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		return nil
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	streamEvents(&sseEventSender{w: w}, filter, cursor, r.Context().Done())
+	return nil
+}
@@ -5,11 +5,13 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"time"
 
 	"github.com/ajg/form"
 	"github.com/globalsign/mgo/bson"
@@ -598,6 +600,64 @@ func (s *EventSuite) TestEventBlockAddWithoutReason(c *check.C) {
 	c.Assert(len(blocks), check.Equals, 0)
 }
 
+func (s *EventSuite) TestEventBlockAddScheduled(c *check.C) {
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "myuser", permission.Permission{
+		Scheme:  permission.PermEventBlockAdd,
+		Context: permTypes.PermissionContext{CtxType: permTypes.CtxGlobal},
+	})
+	start := time.Date(2018, time.June, 1, 18, 0, 0, 0, time.UTC)
+	block := &event.Block{
+		KindName:    "app.deploy",
+		Reason:      "weekend freeze",
+		WindowStart: start,
+		WindowEnd:   start.Add(62 * time.Hour),
+		Recurrence:  event.BlockRecurrenceWeekly,
+		Timezone:    "America/Sao_Paulo",
+		ExceptTeams: []string{"sre"},
+	}
+	values, err := form.EncodeToValues(block)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("POST", "/events/blocks", strings.NewReader(values.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	blocks, err := event.ListBlocks(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(blocks), check.Equals, 1)
+	c.Assert(blocks[0].WindowStart.Equal(start), check.Equals, true)
+	c.Assert(blocks[0].Recurrence, check.Equals, event.BlockRecurrenceWeekly)
+	c.Assert(blocks[0].Timezone, check.Equals, "America/Sao_Paulo")
+	c.Assert(blocks[0].ExceptTeams, check.DeepEquals, []string{"sre"})
+	c.Assert(blocks[0].Windows, check.HasLen, 3)
+}
+
+func (s *EventSuite) TestEventBlockAddInvalidSchedule(c *check.C) {
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "myuser", permission.Permission{
+		Scheme:  permission.PermEventBlockAdd,
+		Context: permTypes.PermissionContext{CtxType: permTypes.CtxGlobal},
+	})
+	start := time.Date(2018, time.June, 1, 18, 0, 0, 0, time.UTC)
+	block := &event.Block{KindName: "app.deploy", Reason: "freeze", WindowStart: start, WindowEnd: start.Add(-time.Hour)}
+	values, err := form.EncodeToValues(block)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("POST", "/events/blocks", strings.NewReader(values.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "window end must be after window start\n")
+	blocks, err := event.ListBlocks(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(len(blocks), check.Equals, 0)
+}
+
 func (s *EventSuite) TestEventBlockRemove(c *check.C) {
 	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "myuser", permission.Permission{
 		Scheme:  permission.PermEventBlockRemove,
@@ -682,3 +742,125 @@ func addBlocks(c *check.C) []*event.Block {
 	}
 	return blocks
 }
+
+func (s *EventSuite) streamEvents(c *check.C, u string, token auth.Token, header http.Header) *httptest.ResponseRecorder {
+	oldInterval := eventStreamPollInterval
+	eventStreamPollInterval = 10 * time.Millisecond
+	defer func() { eventStreamPollInterval = oldInterval }()
+	request, err := http.NewRequest("GET", u, nil)
+	c.Assert(err, check.IsNil)
+	for k, v := range header {
+		request.Header[k] = v
+	}
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	ctx, cancel := context.WithTimeout(request.Context(), 200*time.Millisecond)
+	defer cancel()
+	request = request.WithContext(ctx)
+	recorder := httptest.NewRecorder()
+	server := RunServer(true)
+	server.ServeHTTP(recorder, request)
+	return recorder
+}
+
+func parseEventStream(c *check.C, body string) (ids []string, kinds []string, evts []event.Event) {
+	for _, msg := range strings.Split(body, "\n\n") {
+		for _, line := range strings.Split(msg, "\n") {
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				ids = append(ids, strings.TrimPrefix(line, "id: "))
+			case strings.HasPrefix(line, "event: "):
+				kinds = append(kinds, strings.TrimPrefix(line, "event: "))
+			case strings.HasPrefix(line, "data: "):
+				var evt event.Event
+				err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &evt)
+				c.Assert(err, check.IsNil)
+				evts = append(evts, evt)
+			}
+		}
+	}
+	return ids, kinds, evts
+}
+
+func (s *EventSuite) TestEventStream(c *check.C) {
+	first, err := event.New(&event.Opts{
+		Target:  event.Target{Type: event.TargetTypeApp, Value: "app-first"},
+		Owner:   s.token,
+		Kind:    permission.PermAppDeploy,
+		Allowed: event.Allowed(permission.PermAppReadEvents, permission.Context(permTypes.CtxTeam, s.team.Name)),
+	})
+	c.Assert(err, check.IsNil)
+	evts, err := s.insertEvents("app", nil, c)
+	c.Assert(err, check.IsNil)
+	u := fmt.Sprintf("/events/stream?last_id=%s", first.UniqueID.Hex())
+	recorder := s.streamEvents(c, u, s.token, nil)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "text/event-stream")
+	ids, kinds, result := parseEventStream(c, recorder.Body.String())
+	c.Assert(result, check.HasLen, 10)
+	c.Assert(ids, check.HasLen, 10)
+	c.Assert(kinds, check.HasLen, 10)
+	var updated int
+	for i := range result {
+		if kinds[i] == "updated" {
+			updated++
+			c.Assert(result[i].UniqueID, check.Equals, evts[1].UniqueID)
+		}
+	}
+	c.Assert(updated, check.Equals, 1)
+	recorder = s.streamEvents(c, "/events/stream", s.token, http.Header{"Last-Event-Id": {ids[9]}})
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	_, _, result = parseEventStream(c, recorder.Body.String())
+	c.Assert(result, check.HasLen, 0)
+	err = evts[0].Done(nil)
+	c.Assert(err, check.IsNil)
+	recorder = s.streamEvents(c, "/events/stream", s.token, http.Header{"Last-Event-Id": {ids[9]}})
+	ids, kinds, result = parseEventStream(c, recorder.Body.String())
+	c.Assert(result, check.HasLen, 1)
+	c.Assert(result[0].UniqueID, check.Equals, evts[0].UniqueID)
+	c.Assert(kinds, check.DeepEquals, []string{"updated"})
+	c.Assert(ids, check.HasLen, 1)
+}
+
+func (s *EventSuite) TestEventStreamFromNow(c *check.C) {
+	_, err := s.insertEvents("app", nil, c)
+	c.Assert(err, check.IsNil)
+	recorder := s.streamEvents(c, "/events/stream", s.token, nil)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	_, _, result := parseEventStream(c, recorder.Body.String())
+	c.Assert(result, check.HasLen, 0)
+}
+
+func (s *EventSuite) TestEventStreamFilterAndPermission(c *check.C) {
+	first, err := event.New(&event.Opts{
+		Target:  event.Target{Type: event.TargetTypeApp, Value: "app-first"},
+		Owner:   s.token,
+		Kind:    permission.PermAppDeploy,
+		Allowed: event.Allowed(permission.PermAppReadEvents, permission.Context(permTypes.CtxTeam, s.team.Name)),
+	})
+	c.Assert(err, check.IsNil)
+	_, err = s.insertEvents("app", nil, c)
+	c.Assert(err, check.IsNil)
+	u := fmt.Sprintf("/events/stream?target.value=app-3&last_id=%s", first.UniqueID.Hex())
+	recorder := s.streamEvents(c, u, s.token, nil)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	_, _, result := parseEventStream(c, recorder.Body.String())
+	c.Assert(result, check.HasLen, 1)
+	c.Assert(result[0].Target.Value, check.Equals, "app-3")
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "myuser", permission.Permission{
+		Scheme:  permission.PermAppRead,
+		Context: permission.Context(permTypes.CtxTeam, "some-other-team"),
+	})
+	u = fmt.Sprintf("/events/stream?last_id=%s", first.UniqueID.Hex())
+	recorder = s.streamEvents(c, u, token, nil)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	_, _, result = parseEventStream(c, recorder.Body.String())
+	c.Assert(result, check.HasLen, 0)
+}
+
+func (s *EventSuite) TestEventStreamInvalidLastID(c *check.C) {
+	recorder := s.streamEvents(c, "/events/stream?last_id=abc-123", s.token, nil)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	u := fmt.Sprintf("/events/stream?last_id=%s", bson.NewObjectId().Hex())
+	recorder = s.streamEvents(c, u, s.token, nil)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
@@ -0,0 +1,29 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"flag"
+	"io/ioutil"
+	"log"
+
+	"github.com/tsuru/tsuru/api/specgen"
+)
+
+func main() {
+	out := flag.String("o", "", "output file")
+	flag.Parse()
+	data, err := specgen.Generate("github.com/tsuru/tsuru/api", []specgen.Package{
+		{ImportPath: "github.com/tsuru/tsuru/api", Dir: "."},
+		{ImportPath: "github.com/tsuru/tsuru/provision/docker", Dir: "../provision/docker"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = ioutil.WriteFile(*out, data, 0644)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,2372 @@
+// AUTOMATICALLY GENERATED FILE - DO NOT EDIT!
+// Please run 'go generate' to update this file.
+//
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"reflect"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/autoscale"
+	"github.com/tsuru/tsuru/config"
+	"github.com/tsuru/tsuru/diagnostic"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/iaas"
+	"github.com/tsuru/tsuru/migration"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision/nodecontainer"
+	"github.com/tsuru/tsuru/router"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	authTypes "github.com/tsuru/tsuru/types/auth"
+	eventTypes "github.com/tsuru/tsuru/types/event"
+	provTypes "github.com/tsuru/tsuru/types/provision"
+	"github.com/tsuru/tsuru/volume"
+)
+
+var handlerSpecs = map[string]*handlerSpec{
+	"github.com/tsuru/tsuru/api.addAppRouter": {
+		Title:       "add app router",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateRouterAdd},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 404, Description: "App or router not found"},
+			{Code: 400, Description: "Invalid request"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addDefaultRole": {
+		Title:       "add default role",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermRoleDefaultCreate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addKeyToUser": {
+		Title:       "add key",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermUserUpdateKeyAdd},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Key already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addLog": {
+		Title:       "app log",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateLog},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addLogs": {
+		Title:    "add logs",
+		Consumes: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 101, Description: "Switching protocols"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addNodeHandler": {
+		Title:       "add node",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addPermissions": {
+		Title:       "add permissions",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermRoleUpdatePermissionAdd},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Permission not allowed"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addPlan": {
+		Title:       "plan create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermPlanCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Plan created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Plan already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addPoolHandler": {
+		Title:       "pool create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermPoolCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Pool created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Pool already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addRole": {
+		Title:       "role create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermRoleCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Role created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Role already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addTeamToPoolHandler": {
+		Title:       "add team too pool",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermPoolUpdateTeamAdd},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Pool updated"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 404, Description: "Pool not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.addUnits": {
+		Title:       "add units",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateUnitAdd},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Units added"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.appDelete": {
+		Title:       "remove app",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "App removed"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.appGraph": {
+		Title:       "app dependency graph",
+		Produces:    []string{"application/json", "text/vnd.graphviz"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid format"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.appInfo": {
+		Title:       "app info",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.appList": {
+		Title:    "app list",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List apps"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.appLog": {
+		Title:       "app log",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadLog},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.appMetricEnvs": {
+		Title:       "metric envs",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadMetric},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.appRebuildRoutes": {
+		Title:       "rebuild routes",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppAdminRoutes},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.assignRole": {
+		Title:       "assign role to user",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermRoleUpdateAssign},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Role not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.assignRoleToToken": {
+		Title:       "assign role to token",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermRoleUpdateAssign},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Role or team token not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.authScheme": {
+		Title:    "get auth scheme",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.autoScaleDeleteRule": {
+		Title:       "delete autoscale rule",
+		Permissions: []*permission.PermissionScheme{permission.PermNodeAutoscale},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.autoScaleGetConfig": {
+		Title:       "get autoscale config",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeAutoscaleRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.autoScaleHistoryHandler": {
+		Title:       "list autoscale history",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeAutoscale},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.autoScaleListRules": {
+		Title:       "autoscale rules list",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeAutoscaleRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.autoScaleRunHandler": {
+		Title:       "autoscale run",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeAutoscaleUpdateRun},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.autoScaleSetRule": {
+		Title:       "autoscale set rule",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Request:     reflect.TypeOf((*autoscale.Rule)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermNodeAutoscaleUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.bindServiceInstance": {
+		Title:       "bind service instance",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceUpdateBind, permission.PermAppUpdateBind},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.build": {
+		Title:       "app build",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppBuild},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.buildCachePurge": {
+		Title:       "app build cache purge",
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateBuildCache},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.changeAppQuota": {
+		Title:       "update application quota",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppAdminQuota},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Quota updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Limit lower than allocated"},
+			{Code: 404, Description: "Application not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.changePassword": {
+		Title:    "change password",
+		Consumes: []string{"application/x-www-form-urlencoded"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.changeServiceInstanceQuota": {
+		Title:       "update team service instance quota",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermTeamAdminQuota},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Quota updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Limit lower than allocated"},
+			{Code: 404, Description: "Team not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.changeUserQuota": {
+		Title:       "update user quota",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermUserUpdateQuota},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Quota updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Limit lower than allocated value"},
+			{Code: 404, Description: "User not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.clusterStatus": {
+		Title:       "provisioner cluster status",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*provTypes.ClusterStatus)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermClusterRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Cluster not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.cmdlineHandler": {
+		Title:       "profile cmdline handler",
+		Permissions: []*permission.PermissionScheme{permission.PermDebug},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.configReload": {
+		Title:       "reload config",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*config.ReloadResult)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermConfigReload},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.createApp": {
+		Title:       "app create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppCreate, permission.PermPlatformUpdate, permission.PermPlatformCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "App created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Quota exceeded"},
+			{Code: 409, Description: "App already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.createCluster": {
+		Title:       "create provisioner cluster",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json"},
+		Request:     reflect.TypeOf((*provTypes.Cluster)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermClusterCreate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Pool does not exist"},
+			{Code: 409, Description: "Cluster already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.createServiceInstance": {
+		Title:       "service instance create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceCreate, permission.PermServiceRead},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Service created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Quota exceeded"},
+			{Code: 409, Description: "Service already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.createTeam": {
+		Title:       "team create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermTeamCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Team created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Team already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.createUser": {
+		Title:       "user create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermUserCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "User created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 409, Description: "User already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deleteCluster": {
+		Title:       "delete provisioner cluster",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermClusterDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Cluster not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deploy": {
+		Title:    "app deploy",
+		Consumes: []string{"application/x-www-form-urlencoded"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deployCompare": {
+		Title:       "deploy compare",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadDeploy},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deployInfo": {
+		Title:       "deploy info",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*app.DeployData)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadDeploy},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deployRebuild": {
+		Title:    "rebuild",
+		Consumes: []string{"application/x-www-form-urlencoded"},
+		Produces: []string{"application/x-json-stream"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deployRequestApprove": {
+		Title:       "deploy request approve",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppDeployApprove},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+			{Code: 409, Description: "Request not pending"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deployRequestInfo": {
+		Title:       "deploy request info",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadDeploy},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deployRequestList": {
+		Title:       "deploy request list",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadDeploy},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deployRequestRetry": {
+		Title:       "deploy request retry",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppDeployApprove},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+			{Code: 409, Description: "Request not failed"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deployRollback": {
+		Title:    "rollback",
+		Consumes: []string{"application/x-www-form-urlencoded"},
+		Produces: []string{"application/x-json-stream"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deployRollbackUpdate": {
+		Title:       "rollback update",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateDeployRollback},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Rollback updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 403, Description: "Forbidden"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.deploysList": {
+		Title:    "deploy list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]app.DeployData)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.diagnosticsReport": {
+		Title:       "diagnostics report",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*diagnostic.Report)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermDiagnosticsRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.diffDeploy": {
+		Title:       "deploy diff",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadDeploy},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.dissociateRole": {
+		Title:       "dissociate role from user",
+		Permissions: []*permission.PermissionScheme{permission.PermRoleUpdateDissociate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Role not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.dissociateRoleFromToken": {
+		Title:       "dissociate role from token",
+		Permissions: []*permission.PermissionScheme{permission.PermRoleUpdateDissociate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Role or team token not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.dumpGoroutines": {
+		Title:       "dump goroutines",
+		Permissions: []*permission.PermissionScheme{permission.PermDebug},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.eventBlockAdd": {
+		Title:       "add event block",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Request:     reflect.TypeOf((*event.Block)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermEventBlockAdd},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data or empty reason"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.eventBlockList": {
+		Title:       "event block list",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*[]event.Block)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermEventBlockRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.eventBlockRemove": {
+		Title:       "remove event block",
+		Permissions: []*permission.PermissionScheme{permission.PermEventBlockRemove},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid uuid"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Active block with provided uuid not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.eventCancel": {
+		Title:    "event cancel",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 204, Description: "OK"},
+			{Code: 400, Description: "Invalid uuid or empty reason"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.eventInfo": {
+		Title:    "event info",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*event.Event)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid uuid"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.eventList": {
+		Title:    "event list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]event.Event)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.eventStream": {
+		Title:    "event stream",
+		Produces: []string{"text/event-stream"},
+		Responses: []responseSpec{
+			{Code: 101, Description: "Switch Protocol to websocket"},
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid filter or last event id"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Last event not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.forceDeleteLock": {
+		Title:       "app unlock",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppAdminUnlock},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.getAppQuota": {
+		Title:       "application quota",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Application not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.getEnv": {
+		Title:       "get envs",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadEnv},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.getUserQuota": {
+		Title:       "user quota",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermUserUpdateQuota},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "User not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.grantAppAccess": {
+		Title:       "grant access to app",
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateGrant},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Access granted"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App or team not found"},
+			{Code: 409, Description: "Grant already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.grantServiceAccess": {
+		Title:       "grant access to a service",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceUpdateGrantAccess},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Service updated"},
+			{Code: 400, Description: "Team not found"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service not found"},
+			{Code: 409, Description: "Team already has access to this service"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.healingHistoryHandler": {
+		Title:       "docker healing history",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermHealingRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 204, Description: "No content"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.healthcheck": {
+		Title: "healthcheck",
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 500, Description: "Internal server error"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.index": {
+		Title: "index",
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.indexHandler": {
+		Title:       "profile index handler",
+		Permissions: []*permission.PermissionScheme{permission.PermDebug},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.info": {
+		Title:    "api info",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.infoNodeHandler": {
+		Title:       "node info",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.installHostAdd": {
+		Title:       "add install host",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermInstallManage},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Host added"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.installHostInfo": {
+		Title:       "install host info",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermInstallManage},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not Found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.installHostList": {
+		Title:       "list install hosts",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermInstallManage},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.kindList": {
+		Title:    "kind list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]event.Kind)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listAppRouters": {
+		Title:       "list app routers",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadRouter},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listCertificates": {
+		Title:       "list app certificates",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppReadCertificate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listClusters": {
+		Title:       "list provisioner clusters",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*[]provTypes.Cluster)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermClusterRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 204, Description: "No Content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listDefaultRoles": {
+		Title:       "list default roles",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermRoleDefaultCreate, permission.PermRoleDefaultDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listKeys": {
+		Title:    "list keys",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listNodesHandler": {
+		Title:    "list nodes",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 204, Description: "No content"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listPermissions": {
+		Title:       "list permissions",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermRoleUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listPlans": {
+		Title:    "plan list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]appTypes.Plan)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listRoles": {
+		Title:       "role list",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*[]permission.Role)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermRoleUpdate, permission.PermRoleUpdateAssign, permission.PermRoleUpdateDissociate, permission.PermRoleCreate, permission.PermRoleDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listRouters": {
+		Title:    "router list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]router.PlanRouter)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listServiceInstanceQuotas": {
+		Title:       "team service instance quotas",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermTeamRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Team not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listUnitsByApp": {
+		Title:       "list units by app",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listUnitsByNode": {
+		Title:       "list units by node",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.listUsers": {
+		Title:       "user list",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermUserUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.login": {
+		Title:    "login",
+		Consumes: []string{"application/x-www-form-urlencoded"},
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.logout": {
+		Title: "logout",
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.machineDestroy": {
+		Title:       "machine destroy",
+		Permissions: []*permission.PermissionScheme{permission.PermMachineDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.machinesAudit": {
+		Title:    "machine audit",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.machinesAuditCleanup": {
+		Title:       "machine audit cleanup",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermMachineDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.machinesList": {
+		Title:    "machine list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]iaas.Machine)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.migrationStatus": {
+		Title:       "migration status",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*[]migration.MigrationStatus)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermMigrationRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.nodeContainerCreate": {
+		Title:       "node container create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodecontainerCreate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invald data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.nodeContainerDelete": {
+		Title:       "remove node container",
+		Permissions: []*permission.PermissionScheme{permission.PermNodecontainerDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.nodeContainerInfo": {
+		Title:    "node container info",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.nodeContainerList": {
+		Title:    "remove node container list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]nodecontainer.NodeContainerConfigGroup)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.nodeContainerUpdate": {
+		Title:       "node container update",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodecontainerUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invald data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.nodeContainerUpgrade": {
+		Title:       "node container upgrade",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodecontainerUpdateUpgrade},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invald data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.nodeHealingDelete": {
+		Title:       "remove node healing",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermHealingDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.nodeHealingRead": {
+		Title:    "node healing info",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.nodeHealingUpdate": {
+		Title:       "node healing update",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermHealingUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.openAPISpec": {
+		Title:    "openapi spec",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*openAPIDoc)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.platformAdd": {
+		Title:       "add platform",
+		Consumes:    []string{"multipart/form-data"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermPlatformCreate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Platform created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.platformInfo": {
+		Title:       "platform info",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermPlatformUpdate, permission.PermPlatformCreate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Platform info"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "NotFound"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.platformList": {
+		Title:       "platform list",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*[]appTypes.Platform)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermPlatformUpdate, permission.PermPlatformCreate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List platforms"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.platformRemove": {
+		Title:       "remove platform",
+		Permissions: []*permission.PermissionScheme{permission.PermPlatformDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Platform removed"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.platformRollback": {
+		Title:       "rollback platform",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermPlatformUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "BadRequest"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.platformUpdate": {
+		Title:       "update platform",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermPlatformUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Platform updated"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.platformVersionList": {
+		Title:       "platform version list",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermPlatformUpdate, permission.PermPlatformCreate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List platform versions and the apps using them"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.platformVersionUpdate": {
+		Title:       "platform version update",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermPlatformUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Platform version updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.poolConstraintList": {
+		Title:       "pool constraints list",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermPoolReadConstraints},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.poolConstraintSet": {
+		Title:       "set a pool constraint",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermPoolUpdateConstraintsSet},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.poolList": {
+		Title:    "pool list",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.poolUpdateHandler": {
+		Title:       "pool update",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermPoolUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Pool updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Pool not found"},
+			{Code: 409, Description: "Default pool already defined"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.profileHandler": {
+		Title:       "profile handler",
+		Permissions: []*permission.PermissionScheme{permission.PermDebug},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.rebalanceNodesHandler": {
+		Title:       "rebalance units in nodes",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeUpdateRebalance},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.regenerateAPIToken": {
+		Title:       "regenerate token",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermUserUpdateToken},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "User not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.registerUnit": {
+		Title:       "register unit",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateUnitRegister},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.remoteShellHandler": {
+		Title:       "app shell",
+		Permissions: []*permission.PermissionScheme{permission.PermAppRunShell},
+		Responses: []responseSpec{
+			{Code: 101, Description: "Switch Protocol to websocket"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeAppRouter": {
+		Title:       "delete app router",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateRouterRemove},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 404, Description: "App or router not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeDefaultRole": {
+		Title:       "remove default role",
+		Permissions: []*permission.PermissionScheme{permission.PermRoleDefaultDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeKeyFromUser": {
+		Title:       "remove key",
+		Permissions: []*permission.PermissionScheme{permission.PermUserUpdateKeyRemove},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeNodeHandler": {
+		Title:       "remove node",
+		Permissions: []*permission.PermissionScheme{permission.PermNodeDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removePermissions": {
+		Title:       "remove permission",
+		Permissions: []*permission.PermissionScheme{permission.PermRoleUpdatePermissionRemove},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Permission removed"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removePlan": {
+		Title:       "remove plan",
+		Permissions: []*permission.PermissionScheme{permission.PermPlanDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Plan removed"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Plan not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removePoolHandler": {
+		Title:       "remove pool",
+		Permissions: []*permission.PermissionScheme{permission.PermPoolDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Pool removed"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Pool still has apps"},
+			{Code: 404, Description: "Pool not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeRole": {
+		Title:       "remove role",
+		Permissions: []*permission.PermissionScheme{permission.PermRoleDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Role removed"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Role not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeServiceInstance": {
+		Title:       "remove service instance",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Service removed"},
+			{Code: 400, Description: "Bad request"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service instance not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeTeam": {
+		Title:       "remove team",
+		Permissions: []*permission.PermissionScheme{permission.PermTeamDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Team removed"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeTeamToPoolHandler": {
+		Title:       "remove team from pool",
+		Permissions: []*permission.PermissionScheme{permission.PermPoolUpdateTeamRemove},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Pool updated"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 404, Description: "Pool not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeUnits": {
+		Title:       "remove units",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateUnitRemove},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Units removed"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Not enough reserved units"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.removeUser": {
+		Title:       "remove user",
+		Permissions: []*permission.PermissionScheme{permission.PermUserDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "User removed"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.resetPassword": {
+		Title: "reset password",
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.restart": {
+		Title:       "app restart",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateRestart},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.revokeAppAccess": {
+		Title:       "revoke access to app",
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateRevoke},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Access revoked"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden"},
+			{Code: 404, Description: "App or team not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.revokeServiceAccess": {
+		Title:       "revoke access to a service",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceUpdateRevokeAccess},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Access revoked"},
+			{Code: 400, Description: "Team not found"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service not found"},
+			{Code: 409, Description: "Team does not has access to this service"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.roleInfo": {
+		Title:       "role info",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*permission.Role)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermRoleUpdate, permission.PermRoleUpdateAssign, permission.PermRoleUpdateDissociate, permission.PermRoleCreate, permission.PermRoleDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Role not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.roleUpdate": {
+		Title: "updates a role",
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.rotateClusterCredentials": {
+		Title:       "rotate provisioner cluster credentials",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*provTypes.ClusterStatus)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermClusterUpdateCredentials},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Cluster not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.runCommand": {
+		Title:       "run commands",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppRun},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.samlCallbackLogin": {
+		Title: "saml callback",
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.samlMetadata": {
+		Title:    "saml metadata",
+		Produces: []string{"application/xml"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceAddDoc": {
+		Title:       "change service documentation",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceUpdateDoc},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Documentation updated"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden (team is not the owner or service with instances)"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceBrokerAdd": {
+		Title:       "Add service broker",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceBrokerCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Service broker created"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Broker already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceBrokerDelete": {
+		Title:       "Delete service broker",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceBrokerDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Service broker deleted"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not Found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceBrokerList": {
+		Title:       "service broker list",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceBrokerRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List service brokers"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceBrokerUpdate": {
+		Title:       "Update service broker",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceBrokerUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Service broker updated"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not Found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceCreate": {
+		Title:       "service create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Service created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Service already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceDelete": {
+		Title:       "service delete",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Service removed"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden (team is not the owner or service with instances)"},
+			{Code: 404, Description: "Service not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceDoc": {
+		Title:       "service doc",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceReadDoc},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceInfo": {
+		Title:    "service info",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceInstance": {
+		Title:       "service instance info",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service instance not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceInstanceGrantTeam": {
+		Title:       "grant access to service instance",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceUpdateGrant},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Access granted"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service instance not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceInstanceGraph": {
+		Title:       "service instance dependency graph",
+		Produces:    []string{"application/json", "text/vnd.graphviz"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid format"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service instance not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceInstanceProxy": {
+		Title:       "service instance proxy",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceUpdateProxy},
+		Responses: []responseSpec{
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Instance not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceInstanceRevokeTeam": {
+		Title:       "revoke access to service instance",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceUpdateRevoke},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Access revoked"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service instance not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceInstanceRotate": {
+		Title:       "rotate service instance credentials",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceUpdateRotate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Credentials rotated"},
+			{Code: 400, Description: "Invalid data or rotation not supported"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service instance not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceInstanceStatus": {
+		Title:       "service instance status",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceReadStatus},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List services instances"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service instance not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceInstances": {
+		Title:    "service instance list",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List services instances"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceList": {
+		Title:    "service list",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List services"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.servicePlans": {
+		Title:       "service plans",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceReadPlans},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceProxy": {
+		Title:       "service proxy",
+		Permissions: []*permission.PermissionScheme{permission.PermServiceUpdateProxy},
+		Responses: []responseSpec{
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Service not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.serviceUpdate": {
+		Title:       "service update",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Service updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Forbidden (team is not the owner)"},
+			{Code: 404, Description: "Service not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.setCName": {
+		Title:       "set cname",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateCnameAdd},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.setCertificate": {
+		Title:       "set app certificate",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateCertificateSet},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.setEnv": {
+		Title:       "set envs",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateEnvSet},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Envs updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.setNodeStatus": {
+		Title:    "set node status",
+		Consumes: []string{"application/x-www-form-urlencoded"},
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App or unit not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.setUnitStatus": {
+		Title:       "set unit status",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateUnitStatus},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App or unit not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.showAPIToken": {
+		Title:       "show token",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermUserUpdateToken},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "User not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.sleep": {
+		Title:       "app sleep",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateSleep},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.start": {
+		Title:       "app start",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateStart},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.stop": {
+		Title:       "app stop",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateStop},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.swap": {
+		Title:       "app swap",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateSwap},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+			{Code: 409, Description: "App locked"},
+			{Code: 412, Description: "Number of units or platform don't match"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.symbolHandler": {
+		Title:       "profile symbol handler",
+		Permissions: []*permission.PermissionScheme{permission.PermDebug},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.teamInfo": {
+		Title:       "team info",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermTeamRead, permission.PermTeam},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Info team"},
+			{Code: 404, Description: "Not found"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.teamList": {
+		Title:    "team list",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List teams"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.templateCreate": {
+		Title:       "template create",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Request:     reflect.TypeOf((*iaas.Template)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermMachineTemplateCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Template created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Existent template"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.templateDestroy": {
+		Title:       "template destroy",
+		Permissions: []*permission.PermissionScheme{permission.PermMachineTemplateDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.templateUpdate": {
+		Title:       "template update",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermMachineTemplateUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.templateValidate": {
+		Title:       "template validate",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermMachineTemplateRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.templatesList": {
+		Title:    "machine template list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]iaas.Template)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.tokenCreate": {
+		Title:       "token create",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermTeamTokenCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Token created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Token already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.tokenDelete": {
+		Title:       "token delete",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermTeamTokenDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Token created"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Token not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.tokenList": {
+		Title:    "token list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]authTypes.TeamToken)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "List tokens"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.tokenUpdate": {
+		Title:       "token update",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermTeamTokenUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Token updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Token not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.traceHandler": {
+		Title:       "profile trace handler",
+		Permissions: []*permission.PermissionScheme{permission.PermDebug},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.unbindServiceInstance": {
+		Title:       "unbind service instance",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermServiceInstanceUpdateUnbind, permission.PermAppUpdateUnbind, permission.PermServiceUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.unsetCName": {
+		Title:       "unset cname",
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateCnameRemove},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.unsetCertificate": {
+		Title:       "unset app certificate",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateCertificateUnset},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.unsetEnv": {
+		Title:       "unset envs",
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateEnvUnset},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Envs removed"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "App not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.updateApp": {
+		Title:       "app update",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermPlatformUpdate, permission.PermPlatformCreate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "App updated"},
+			{Code: 400, Description: "Invalid new pool"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.updateAppRouter": {
+		Title:       "update app router",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermAppUpdateRouterUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 404, Description: "App or router not found"},
+			{Code: 400, Description: "Invalid request"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.updateCluster": {
+		Title:       "update provisioner cluster",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json"},
+		Request:     reflect.TypeOf((*provTypes.Cluster)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermClusterUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Cluster not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.updateNodeHandler": {
+		Title:       "update nodes",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.updateServiceInstance": {
+		Title:    "service instance update",
+		Consumes: []string{"application/x-www-form-urlencoded"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Service instance updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 403, Description: "Quota exceeded"},
+			{Code: 404, Description: "Service instance not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.updateTeam": {
+		Title:       "team update",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Permissions: []*permission.PermissionScheme{permission.PermTeamUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Team updated"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Team not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.userInfo": {
+		Title:    "user info",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeBind": {
+		Title:       "volume bind",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeUpdateBind, permission.PermAppUpdateBindVolume},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Volume binded"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Volume not found"},
+			{Code: 409, Description: "Volume bind already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeCreate": {
+		Title:       "volume create",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeCreate},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Volume created"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 409, Description: "Volume already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeDelete": {
+		Title:       "volume delete",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Volume deleted"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Volume not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeInfo": {
+		Title:       "volume info",
+		Produces:    []string{"application/json"},
+		Response:    reflect.TypeOf((*volume.Volume)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Show volume"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Volume not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumePlansList": {
+		Title:    "volume plan list",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List volume plans"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeResize": {
+		Title:       "volume resize",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeUpdateResize},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Volume resized"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Volume not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeRestore": {
+		Title:       "volume restore",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeUpdateRestore},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Volume restored"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Volume or snapshot not found"},
+			{Code: 409, Description: "Volume has binds"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeSnapshotCreate": {
+		Title:       "volume snapshot create",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeUpdateSnapshot},
+		Responses: []responseSpec{
+			{Code: 201, Description: "Snapshot created"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Volume not found"},
+			{Code: 409, Description: "Snapshot already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeSnapshotList": {
+		Title:       "volume snapshot list",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "List snapshots"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Volume not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeUnbind": {
+		Title:       "volume unbind",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeUpdateUnbind, permission.PermAppUpdateUnbindVolume},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Volume unbinded"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Volume not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumeUpdate": {
+		Title:       "volume update",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermVolumeUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Volume updated"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Volume not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.volumesList": {
+		Title:    "volume list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]volume.Volume)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "List volumes"},
+			{Code: 204, Description: "No content"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.webhookCreate": {
+		Title:       "webhook create",
+		Permissions: []*permission.PermissionScheme{permission.PermWebhookCreate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Webhook created"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 400, Description: "Invalid webhook"},
+			{Code: 409, Description: "Webhook already exists"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.webhookDelete": {
+		Title:       "webhook delete",
+		Permissions: []*permission.PermissionScheme{permission.PermWebhookDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Webhook deleted"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Webhook not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.webhookInfo": {
+		Title:       "webhook info",
+		Produces:    []string{"application/json"},
+		Permissions: []*permission.PermissionScheme{permission.PermWebhookRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Get webhook"},
+			{Code: 404, Description: "Not found"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.webhookList": {
+		Title:    "webhook list",
+		Produces: []string{"application/json"},
+		Response: reflect.TypeOf((*[]eventTypes.Webhook)(nil)).Elem(),
+		Responses: []responseSpec{
+			{Code: 200, Description: "List webhooks"},
+			{Code: 204, Description: "No content"},
+		},
+	},
+	"github.com/tsuru/tsuru/api.webhookUpdate": {
+		Title:       "webhook update",
+		Permissions: []*permission.PermissionScheme{permission.PermWebhookUpdate},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Webhook updated"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 400, Description: "Invalid webhook"},
+			{Code: 404, Description: "Webhook not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/provision/docker.bsConfigGetHandler": {
+		Title: "bs config get (deprecated)",
+		Responses: []responseSpec{
+			{Code: 500, Description: "Route deprecated"},
+		},
+	},
+	"github.com/tsuru/tsuru/provision/docker.bsEnvSetHandler": {
+		Title: "bs env set (deprecated)",
+		Responses: []responseSpec{
+			{Code: 500, Description: "Route deprecated"},
+		},
+	},
+	"github.com/tsuru/tsuru/provision/docker.bsUpgradeHandler": {
+		Title: "bs upgrade (deprecated)",
+		Responses: []responseSpec{
+			{Code: 500, Description: "Route deprecated"},
+		},
+	},
+	"github.com/tsuru/tsuru/provision/docker.logsConfigGetHandler": {
+		Title:    "logs config",
+		Produces: []string{"application/json"},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/provision/docker.logsConfigSetHandler": {
+		Title:       "logs config set",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermPoolUpdateLogs},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+		},
+	},
+	"github.com/tsuru/tsuru/provision/docker.moveContainerHandler": {
+		Title:       "move container",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeUpdateMoveContainer},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+	"github.com/tsuru/tsuru/provision/docker.moveContainersHandler": {
+		Title:       "move containers",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/x-json-stream"},
+		Permissions: []*permission.PermissionScheme{permission.PermNodeUpdateMoveContainers},
+		Responses: []responseSpec{
+			{Code: 200, Description: "Ok"},
+			{Code: 400, Description: "Invalid data"},
+			{Code: 401, Description: "Unauthorized"},
+			{Code: 404, Description: "Not found"},
+		},
+	},
+}
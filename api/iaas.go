@@ -16,6 +16,7 @@ import (
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/iaas"
 	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
 	permTypes "github.com/tsuru/tsuru/types/permission"
 )
 
@@ -23,6 +24,7 @@ import (
 // path: /iaas/machines
 // method: GET
 // produce: application/json
+// response: []iaas.Machine
 // responses:
 //   200: OK
 //   401: Unauthorized
@@ -31,17 +33,7 @@ func machinesList(w http.ResponseWriter, r *http.Request, token auth.Token) erro
 	if err != nil {
 		return err
 	}
-	contexts := permission.ContextsForPermission(token, permission.PermMachineRead)
-	allowedIaaS := map[string]struct{}{}
-	for _, c := range contexts {
-		if c.CtxType == permTypes.CtxGlobal {
-			allowedIaaS = nil
-			break
-		}
-		if c.CtxType == permTypes.CtxIaaS {
-			allowedIaaS[c.Value] = struct{}{}
-		}
-	}
+	allowedIaaS := allowedIaaSForPermission(token, permission.PermMachineRead)
 	for i := 0; allowedIaaS != nil && i < len(machines); i++ {
 		if _, ok := allowedIaaS[machines[i].Iaas]; !ok {
 			machines = append(machines[:i], machines[i+1:]...)
@@ -92,10 +84,121 @@ func machineDestroy(w http.ResponseWriter, r *http.Request, token auth.Token) (e
 	return m.Destroy()
 }
 
+func allowedIaaSForPermission(token auth.Token, perm *permission.PermissionScheme) map[string]struct{} {
+	contexts := permission.ContextsForPermission(token, perm)
+	allowedIaaS := map[string]struct{}{}
+	for _, c := range contexts {
+		if c.CtxType == permTypes.CtxGlobal {
+			return nil
+		}
+		if c.CtxType == permTypes.CtxIaaS {
+			allowedIaaS[c.Value] = struct{}{}
+		}
+	}
+	return allowedIaaS
+}
+
+func auditMachines(token auth.Token) (*iaas.MachineAudit, error) {
+	if len(permission.ContextsForPermission(token, permission.PermMachineRead)) == 0 {
+		return nil, permission.ErrUnauthorized
+	}
+	provs, err := provision.Registry()
+	if err != nil {
+		return nil, err
+	}
+	var nodes []provision.Node
+	for _, prov := range provs {
+		nodeProv, ok := prov.(provision.NodeProvisioner)
+		if !ok {
+			continue
+		}
+		provNodes, err := nodeProv.ListNodes(nil)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, provNodes...)
+	}
+	audit, err := iaas.AuditMachines(nodes)
+	if err != nil {
+		return nil, err
+	}
+	allowedIaaS := allowedIaaSForPermission(token, permission.PermMachineRead)
+	if allowedIaaS != nil {
+		audit.Filter(func(name string) bool {
+			_, ok := allowedIaaS[name]
+			return ok
+		})
+	}
+	return audit, nil
+}
+
+// title: machine audit
+// path: /iaas/machines/audit
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   401: Unauthorized
+func machinesAudit(w http.ResponseWriter, r *http.Request, token auth.Token) error {
+	audit, err := auditMachines(token)
+	if err != nil {
+		return err
+	}
+	w.Header().Add("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(audit)
+}
+
+// title: machine audit cleanup
+// path: /iaas/machines/audit/cleanup
+// method: POST
+// produce: application/json
+// responses:
+//   200: OK
+//   401: Unauthorized
+func machinesAuditCleanup(w http.ResponseWriter, r *http.Request, token auth.Token) (err error) {
+	r.ParseForm()
+	audit, err := auditMachines(token)
+	if err != nil {
+		return err
+	}
+	missingByIaaS := map[string][]iaas.Machine{}
+	for _, m := range audit.Missing {
+		missingByIaaS[m.Iaas] = append(missingByIaaS[m.Iaas], m)
+	}
+	for iaasName := range missingByIaaS {
+		iaasCtx := permission.Context(permTypes.CtxIaaS, iaasName)
+		if !permission.Check(token, permission.PermMachineDelete, iaasCtx) {
+			return permission.ErrUnauthorized
+		}
+	}
+	for iaasName, machines := range missingByIaaS {
+		iaasCtx := permission.Context(permTypes.CtxIaaS, iaasName)
+		var evt *event.Event
+		evt, err = event.New(&event.Opts{
+			Target:     event.Target{Type: event.TargetTypeIaas, Value: iaasName},
+			Kind:       permission.PermMachineDelete,
+			Owner:      token,
+			CustomData: event.FormToCustomData(r.Form),
+			Allowed:    event.Allowed(permission.PermMachineReadEvents, iaasCtx),
+		})
+		if err != nil {
+			return err
+		}
+		err = iaas.RemoveMissingMachines(&iaas.MachineAudit{Missing: machines})
+		evt.Done(err)
+		if err != nil {
+			return err
+		}
+	}
+	w.Header().Add("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(audit)
+}
+
 // title: machine template list
 // path: /iaas/templates
 // method: GET
 // produce: application/json
+// response: []iaas.Template
 // responses:
 //   200: OK
 //   401: Unauthorized
@@ -129,6 +232,7 @@ func templatesList(w http.ResponseWriter, r *http.Request, token auth.Token) err
 // path: /iaas/templates
 // method: POST
 // consume: application/x-www-form-urlencoded
+// request: iaas.Template
 // responses:
 //   201: Template created
 //   400: Invalid data
@@ -147,6 +251,17 @@ func templateCreate(w http.ResponseWriter, r *http.Request, token auth.Token) (e
 	if err != nil {
 		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
 	}
+	if paramTemplate.IaaSName == "" && paramTemplate.Parent != "" {
+		var parent *iaas.Template
+		parent, err = iaas.FindTemplate(paramTemplate.Parent)
+		if err != nil {
+			if err == mgo.ErrNotFound {
+				return &errors.HTTP{Code: http.StatusBadRequest, Message: iaas.ErrTemplateParentNotFound.Error()}
+			}
+			return err
+		}
+		paramTemplate.IaaSName = parent.IaaSName
+	}
 	iaasCtx := permission.Context(permTypes.CtxIaaS, paramTemplate.IaaSName)
 	allowed := permission.Check(token, permission.PermMachineTemplateCreate, iaasCtx)
 	if !allowed {
@@ -172,7 +287,7 @@ func templateCreate(w http.ResponseWriter, r *http.Request, token auth.Token) (e
 	}
 	err = paramTemplate.Save()
 	if err != nil {
-		return err
+		return templateError(err)
 	}
 	w.WriteHeader(http.StatusCreated)
 	return nil
@@ -211,7 +326,7 @@ func templateDestroy(w http.ResponseWriter, r *http.Request, token auth.Token) (
 		return err
 	}
 	defer func() { evt.Done(err) }()
-	return iaas.DestroyTemplate(templateName)
+	return templateError(iaas.DestroyTemplate(templateName))
 }
 
 // title: template update
@@ -247,6 +362,9 @@ func templateUpdate(w http.ResponseWriter, r *http.Request, token auth.Token) (e
 	if r.Form.Get("IaaSName") != "" {
 		dbTpl.IaaSName = r.Form.Get("IaaSName")
 	}
+	if _, ok := r.Form["Parent"]; ok {
+		dbTpl.Parent = r.Form.Get("Parent")
+	}
 	iaasCtx := permission.Context(permTypes.CtxIaaS, dbTpl.IaaSName)
 	allowed := permission.Check(token, permission.PermMachineTemplateUpdate, iaasCtx)
 	if !allowed {
@@ -263,5 +381,63 @@ func templateUpdate(w http.ResponseWriter, r *http.Request, token auth.Token) (e
 		return err
 	}
 	defer func() { evt.Done(err) }()
-	return dbTpl.Update(&paramTemplate)
+	return templateError(dbTpl.Update(&paramTemplate))
+}
+
+// title: template validate
+// path: /iaas/templates/{template_name}/validate
+// method: POST
+// consume: application/x-www-form-urlencoded
+// produce: application/json
+// responses:
+//   200: OK
+//   400: Invalid data
+//   401: Unauthorized
+//   404: Not found
+func templateValidate(w http.ResponseWriter, r *http.Request, token auth.Token) error {
+	err := r.ParseForm()
+	if err != nil {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	templateName := r.URL.Query().Get(":template_name")
+	t, err := iaas.FindTemplate(templateName)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return &errors.HTTP{Code: http.StatusNotFound, Message: "template not found"}
+		}
+		return err
+	}
+	allowed := permission.Check(token, permission.PermMachineTemplateRead,
+		permission.Context(permTypes.CtxIaaS, t.IaaSName),
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	params := map[string]string{}
+	for k := range r.Form {
+		params[k] = r.Form.Get(k)
+	}
+	expanded, warnings, err := iaas.ValidateTemplate(templateName, params)
+	if err != nil {
+		return templateError(err)
+	}
+	w.Header().Add("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(templateValidation{Params: expanded, Warnings: warnings})
+}
+
+type templateValidation struct {
+	Params   map[string]string `json:"params"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+func templateError(err error) error {
+	switch err.(type) {
+	case *iaas.InvalidParamsError:
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	switch err {
+	case iaas.ErrTemplateParentNotFound, iaas.ErrTemplateCycle, iaas.ErrTemplateHasChildren, iaas.ErrTemplateIaaSChange:
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	return err
 }
@@ -8,6 +8,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"sort"
 	"strings"
 
@@ -95,6 +96,79 @@ func (s *S) TestMachinesDestroy(c *check.C) {
 	}, eventtest.HasEvent)
 }
 
+type listableTestIaaS struct {
+	TestIaaS
+}
+
+func (listableTestIaaS) ListMachines() ([]iaas.CloudMachine, error) {
+	return []iaas.CloudMachine{{Id: "myid1"}, {Id: "unknown1"}}, nil
+}
+
+func (s *S) TestMachinesAudit(c *check.C) {
+	iaas.RegisterIaasProvider("listable-iaas", func(string) iaas.IaaS { return listableTestIaaS{} })
+	for _, id := range []string{"myid1", "myid2"} {
+		_, err := iaas.CreateMachineForIaaS("listable-iaas", map[string]string{"id": id})
+		c.Assert(err, check.IsNil)
+		defer (&iaas.Machine{Id: id}).Destroy()
+	}
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/iaas/machines/audit", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var audit iaas.MachineAudit
+	err = json.NewDecoder(recorder.Body).Decode(&audit)
+	c.Assert(err, check.IsNil)
+	c.Assert(audit.Unknown, check.DeepEquals, []iaas.CloudMachine{{Id: "unknown1", Iaas: "listable-iaas"}})
+	c.Assert(audit.Missing, check.HasLen, 1)
+	c.Assert(audit.Missing[0].Id, check.Equals, "myid2")
+	c.Assert(audit.Unregistered, check.HasLen, 2)
+	_, err = iaas.FindMachineById("myid2")
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestMachinesAuditUnauthorized(c *check.C) {
+	token := userWithPermission(c)
+	for _, method := range []string{"GET", "POST"} {
+		path := "/iaas/machines/audit"
+		if method == "POST" {
+			path += "/cleanup"
+		}
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest(method, path, nil)
+		c.Assert(err, check.IsNil)
+		request.Header.Set("Authorization", "bearer "+token.GetValue())
+		s.testServer.ServeHTTP(recorder, request)
+		c.Assert(recorder.Code, check.Equals, http.StatusUnauthorized, check.Commentf("%s %s", method, path))
+	}
+}
+
+func (s *S) TestMachinesAuditCleanup(c *check.C) {
+	iaas.RegisterIaasProvider("listable-iaas", func(string) iaas.IaaS { return listableTestIaaS{} })
+	for _, id := range []string{"myid1", "myid2"} {
+		_, err := iaas.CreateMachineForIaaS("listable-iaas", map[string]string{"id": id})
+		c.Assert(err, check.IsNil)
+	}
+	defer (&iaas.Machine{Id: "myid1"}).Destroy()
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/iaas/machines/audit/cleanup", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	_, err = iaas.FindMachineById("myid2")
+	c.Assert(err, check.Equals, iaas.ErrMachineNotFound)
+	_, err = iaas.FindMachineById("myid1")
+	c.Assert(err, check.IsNil)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeIaas, Value: "listable-iaas"},
+		Owner:  s.token.GetUserName(),
+		Kind:   "machine.delete",
+	}, eventtest.HasEvent)
+}
+
 func (s *S) TestMachinesDestroyError(c *check.C) {
 	recorder := httptest.NewRecorder()
 	request, err := http.NewRequest("DELETE", "/iaas/machines/myid1", nil)
@@ -438,3 +512,189 @@ func (s *S) TestTemplateUpdateNotRegistered(c *check.C) {
 	s.testServer.ServeHTTP(recorder, request)
 	c.Assert(recorder.Body.String(), check.Equals, "IaaS provider \"not-registered\" based on \"not-registered\" not registered\n")
 }
+
+type TestSchemaIaaS struct {
+	TestIaaS
+}
+
+func (TestSchemaIaaS) ParamsSchema() []iaas.TemplateParam {
+	return []iaas.TemplateParam{
+		{Name: "image", Required: true},
+		{Name: "size", Required: true},
+	}
+}
+
+func (s *S) TestTemplateCreateWithParent(c *check.C) {
+	iaas.RegisterIaasProvider("my-iaas", newTestIaaS)
+	parent := iaas.Template{
+		Name:     "base-tpl",
+		IaaSName: "my-iaas",
+		Data:     iaas.TemplateDataList{{Name: "x", Value: "y"}},
+	}
+	err := parent.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("base-tpl")
+	defer iaas.DestroyTemplate("my-tpl")
+	v := url.Values{"Name": []string{"my-tpl"}, "Parent": []string{"base-tpl"}}
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/iaas/templates", strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusCreated)
+	tpl, err := iaas.FindTemplate("my-tpl")
+	c.Assert(err, check.IsNil)
+	c.Assert(tpl.Parent, check.Equals, "base-tpl")
+	c.Assert(tpl.IaaSName, check.Equals, "my-iaas")
+}
+
+func (s *S) TestTemplateCreateParentNotFound(c *check.C) {
+	v := url.Values{"Name": []string{"my-tpl"}, "Parent": []string{"base-tpl"}}
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/iaas/templates", strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "parent template not found\n")
+}
+
+func (s *S) TestTemplateCreateParentDifferentIaaS(c *check.C) {
+	iaas.RegisterIaasProvider("my-iaas", newTestIaaS)
+	iaas.RegisterIaasProvider("other-iaas", newTestIaaS)
+	parent := iaas.Template{Name: "base-tpl", IaaSName: "my-iaas"}
+	err := parent.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("base-tpl")
+	v := url.Values{"Name": []string{"my-tpl"}, "Parent": []string{"base-tpl"}, "IaaSName": []string{"other-iaas"}}
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/iaas/templates", strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "template IaaS \"other-iaas\" differs from parent template IaaS \"my-iaas\"\n")
+}
+
+func (s *S) TestTemplateDestroyWithChildren(c *check.C) {
+	iaas.RegisterIaasProvider("my-iaas", newTestIaaS)
+	parent := iaas.Template{Name: "base-tpl", IaaSName: "my-iaas"}
+	err := parent.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("base-tpl")
+	child := iaas.Template{Name: "my-tpl", Parent: "base-tpl"}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("my-tpl")
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("DELETE", "/iaas/templates/base-tpl", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "template is extended by other templates\n")
+}
+
+func (s *S) TestTemplateUpdateIaaSWithChildren(c *check.C) {
+	iaas.RegisterIaasProvider("my-iaas", newTestIaaS)
+	iaas.RegisterIaasProvider("other-iaas", newTestIaaS)
+	parent := iaas.Template{Name: "base-tpl", IaaSName: "my-iaas"}
+	err := parent.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("base-tpl")
+	child := iaas.Template{Name: "my-tpl", Parent: "base-tpl"}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("my-tpl")
+	v := url.Values{"IaaSName": []string{"other-iaas"}}
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("PUT", "/iaas/templates/base-tpl", strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "cannot change the IaaS of a template extended by other templates\n")
+}
+
+func (s *S) TestTemplateUpdateUnsetInheritedParam(c *check.C) {
+	iaas.RegisterIaasProvider("my-iaas", newTestIaaS)
+	parent := iaas.Template{
+		Name:     "base-tpl",
+		IaaSName: "my-iaas",
+		Data:     iaas.TemplateDataList{{Name: "x", Value: "y"}},
+	}
+	err := parent.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("base-tpl")
+	child := iaas.Template{Name: "my-tpl", Parent: "base-tpl"}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("my-tpl")
+	v := url.Values{"Unset.0": []string{"x"}}
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("PUT", "/iaas/templates/my-tpl", strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	tpl, err := iaas.FindTemplate("my-tpl")
+	c.Assert(err, check.IsNil)
+	c.Assert(tpl.Unset, check.DeepEquals, []string{"x"})
+	params, err := iaas.ExpandTemplate("my-tpl", map[string]string{})
+	c.Assert(err, check.IsNil)
+	c.Assert(params, check.DeepEquals, map[string]string{"iaas": "my-iaas"})
+}
+
+func (s *S) TestTemplateValidate(c *check.C) {
+	iaas.RegisterIaasProvider("schema-iaas", func(string) iaas.IaaS { return TestSchemaIaaS{} })
+	tpl := iaas.Template{
+		Name:     "my-tpl",
+		IaaSName: "schema-iaas",
+		Data:     iaas.TemplateDataList{{Name: "image", Value: "img"}},
+	}
+	err := tpl.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("my-tpl")
+	v := url.Values{"size": []string{"small"}, "imagee": []string{"other"}}
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/iaas/templates/my-tpl/validate", strings.NewReader(v.Encode()))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	var result templateValidation
+	err = json.Unmarshal(recorder.Body.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Params, check.DeepEquals, map[string]string{"image": "img", "imagee": "other", "size": "small", "iaas": "schema-iaas"})
+	c.Assert(result.Warnings, check.DeepEquals, []string{`unknown param "imagee", did you mean "image"?`})
+}
+
+func (s *S) TestTemplateValidateMissingParams(c *check.C) {
+	iaas.RegisterIaasProvider("schema-iaas", func(string) iaas.IaaS { return TestSchemaIaaS{} })
+	tpl := iaas.Template{Name: "my-tpl", IaaSName: "schema-iaas"}
+	err := tpl.Save()
+	c.Assert(err, check.IsNil)
+	defer iaas.DestroyTemplate("my-tpl")
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/iaas/templates/my-tpl/validate", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "invalid params: param \"image\" is required; param \"size\" is required\n")
+}
+
+func (s *S) TestTemplateValidateNotFound(c *check.C) {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/iaas/templates/unknown/validate", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
@@ -0,0 +1,97 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/codegangsta/negroni"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tsuru/tsuru/api/context"
+	"github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/tracing"
+)
+
+const unknownRoute = "unknown"
+
+var (
+	apiRequestLatencies = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "tsuru_api_request_duration_seconds",
+		Help:    "The API requests latency distributions, by route and status class.",
+		Buckets: append(prometheus.DefBuckets, []float64{15, 30, 60, 120, 300}...),
+	}, []string{"method", "route", "status"})
+
+	apiRequestsInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_api_requests_in_flight",
+		Help: "The number of API requests currently being served, by route.",
+	}, []string{"method", "route"})
+)
+
+func init() {
+	prometheus.MustRegister(apiRequestLatencies)
+	prometheus.MustRegister(apiRequestsInFlight)
+}
+
+func routeLabel(r *http.Request) string {
+	route := context.GetRouteTemplate(r)
+	if route == "" {
+		return unknownRoute
+	}
+	return route
+}
+
+func responseStatus(w http.ResponseWriter) int {
+	if flushing, ok := w.(*io.FlushingWriter); ok {
+		w = flushing.ResponseWriter
+	}
+	var status int
+	if rw, ok := w.(negroni.ResponseWriter); ok {
+		status = rw.Status()
+	}
+	if status == 0 {
+		status = http.StatusOK
+	}
+	return status
+}
+
+// metricsMiddleware records the latency and the number of in flight requests
+// for each route registered in the API router.
+func metricsMiddleware(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	route := routeLabel(r)
+	inFlight := apiRequestsInFlight.WithLabelValues(r.Method, route)
+	inFlight.Inc()
+	start := time.Now()
+	defer func() {
+		inFlight.Dec()
+		status := fmt.Sprintf("%dxx", responseStatus(w)/100)
+		apiRequestLatencies.WithLabelValues(r.Method, route, status).Observe(time.Since(start).Seconds())
+	}()
+	next(w, r)
+}
+
+// tracingMiddleware starts the root span of the request trace and stores the
+// trace ID in the request context. The request ID, when available, is only
+// used to find the trace from components that don't receive the request.
+func tracingMiddleware(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	requestID := requestIDHeader(r)
+	span := tracing.StartTrace(r.Method+" "+routeLabel(r), requestID)
+	if span == nil {
+		next(w, r)
+		return
+	}
+	context.SetTraceID(r, span.TraceID)
+	if requestID != "" {
+		span.SetTag("request_id", requestID)
+	}
+	span.SetTag("http.method", r.Method)
+	span.SetTag("http.path", r.URL.Path)
+	next(w, r)
+	status := responseStatus(w)
+	span.SetTag("http.status_code", strconv.Itoa(status))
+	span.Finish(context.GetRequestError(r))
+}
@@ -0,0 +1,123 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/codegangsta/negroni"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/context"
+	"github.com/tsuru/tsuru/tracing"
+	"github.com/tsuru/tsuru/tracing/tracingtest"
+	"gopkg.in/check.v1"
+)
+
+func histogramCount(c *check.C, labels ...string) uint64 {
+	var dtoMetric dto.Metric
+	err := apiRequestLatencies.WithLabelValues(labels...).(prometheus.Histogram).Write(&dtoMetric)
+	c.Assert(err, check.IsNil)
+	return dtoMetric.Histogram.GetSampleCount()
+}
+
+func (s *S) TestMetricsMiddleware(c *check.C) {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("PUT", "/apps/myapp/env", nil)
+	c.Assert(err, check.IsNil)
+	context.SetRouteTemplate(request, "/apps/{app}/env")
+	before := histogramCount(c, "PUT", "/apps/{app}/env", "4xx")
+	var inFlight float64
+	h := func(w http.ResponseWriter, r *http.Request) {
+		var dtoMetric dto.Metric
+		apiRequestsInFlight.WithLabelValues("PUT", "/apps/{app}/env").Write(&dtoMetric)
+		inFlight = dtoMetric.Gauge.GetValue()
+		w.WriteHeader(http.StatusNotFound)
+	}
+	metricsMiddleware(negroni.NewResponseWriter(recorder), request, h)
+	c.Assert(inFlight, check.Equals, 1.0)
+	c.Assert(histogramCount(c, "PUT", "/apps/{app}/env", "4xx"), check.Equals, before+1)
+	var dtoMetric dto.Metric
+	apiRequestsInFlight.WithLabelValues("PUT", "/apps/{app}/env").Write(&dtoMetric)
+	c.Assert(dtoMetric.Gauge.GetValue(), check.Equals, 0.0)
+}
+
+func (s *S) TestMetricsMiddlewareUnknownRoute(c *check.C) {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/unknown/path", nil)
+	c.Assert(err, check.IsNil)
+	before := histogramCount(c, "GET", unknownRoute, "2xx")
+	h, _ := doHandler()
+	metricsMiddleware(negroni.NewResponseWriter(recorder), request, h)
+	c.Assert(histogramCount(c, "GET", unknownRoute, "2xx"), check.Equals, before+1)
+}
+
+func (s *S) TestTracingMiddleware(c *check.C) {
+	config.Set("request-id-header", "Request-ID")
+	defer config.Unset("request-id-header")
+	exporter := tracingtest.New()
+	defer tracing.SetExporter(nil)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("POST", "/apps/myapp/deploy", nil)
+	c.Assert(err, check.IsNil)
+	context.SetRequestID(request, "Request-ID", "my-rid")
+	context.SetRouteTemplate(request, "/apps/{appname}/deploy")
+	var traceID string
+	h := func(w http.ResponseWriter, r *http.Request) {
+		traceID = context.GetTraceID(r)
+		c.Assert(tracing.TraceForRequest("my-rid"), check.Equals, traceID)
+		span := tracing.StartSpan(traceID, "app.Deploy")
+		span.Finish(nil)
+		w.WriteHeader(http.StatusInternalServerError)
+		context.AddRequestError(r, errors.New("deploy failed"))
+	}
+	tracingMiddleware(negroni.NewResponseWriter(recorder), request, h)
+	c.Assert(traceID, check.Not(check.Equals), "")
+	c.Assert(traceID, check.Not(check.Equals), "my-rid")
+	c.Assert(tracing.TraceForRequest("my-rid"), check.Equals, "")
+	spans := exporter.Spans()
+	c.Assert(spans, check.HasLen, 2)
+	c.Assert(spans[1].TraceID, check.Equals, traceID)
+	c.Assert(spans[1].Operation, check.Equals, "POST /apps/{appname}/deploy")
+	c.Assert(spans[1].Error, check.Equals, "deploy failed")
+	c.Assert(spans[1].Tags, check.DeepEquals, map[string]string{
+		"http.method":      "POST",
+		"http.path":        "/apps/myapp/deploy",
+		"http.status_code": "500",
+		"request_id":       "my-rid",
+	})
+	c.Assert(spans[0].Operation, check.Equals, "app.Deploy")
+	c.Assert(spans[0].ParentID, check.Equals, spans[1].ID)
+}
+
+func (s *S) TestTracingMiddlewareWithoutRequestID(c *check.C) {
+	exporter := tracingtest.New()
+	defer tracing.SetExporter(nil)
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/apps", nil)
+	c.Assert(err, check.IsNil)
+	var traceID string
+	h := func(w http.ResponseWriter, r *http.Request) {
+		traceID = context.GetTraceID(r)
+	}
+	tracingMiddleware(negroni.NewResponseWriter(recorder), request, h)
+	spans := exporter.Spans()
+	c.Assert(spans, check.HasLen, 1)
+	c.Assert(spans[0].TraceID, check.Equals, traceID)
+	c.Assert(spans[0].Tags["request_id"], check.Equals, "")
+}
+
+func (s *S) TestTracingMiddlewareDisabled(c *check.C) {
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/apps", nil)
+	c.Assert(err, check.IsNil)
+	h, handlerLog := doHandler()
+	tracingMiddleware(negroni.NewResponseWriter(recorder), request, h)
+	c.Assert(handlerLog.called, check.Equals, true)
+	c.Assert(context.GetTraceID(request), check.Equals, "")
+}
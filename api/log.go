@@ -33,6 +33,12 @@ func getDispatcher() *app.LogDispatcher {
 	return globalDispatcher
 }
 
+// title: add logs
+// path: /logs
+// method: GET
+// consume: application/json
+// responses:
+//   101: Switching protocols
 func addLogs(ws *websocket.Conn) {
 	var err error
 	defer func() {
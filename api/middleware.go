@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	stdLog "log"
+	"math"
 	"net/http"
 	"os"
 	"reflect"
@@ -28,6 +29,7 @@ import (
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/io"
 	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/ratelimit"
 	"github.com/tsuru/tsuru/servicemanager"
 	appTypes "github.com/tsuru/tsuru/types/app"
 )
@@ -161,6 +163,29 @@ func authTokenMiddleware(w http.ResponseWriter, r *http.Request, next http.Handl
 	next(w, r)
 }
 
+// rateLimitMiddleware rejects requests exceeding the rate limits of their
+// token, user or route group. Failures to check the limits don't block
+// requests.
+func rateLimitMiddleware(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	allowed, retryAfter, err := ratelimit.Allow(context.GetAuthToken(r), context.GetRouteTemplate(r))
+	if err != nil {
+		log.Errorf("unable to check rate limit for %s %s: %v", r.Method, r.URL.Path, err)
+	}
+	if allowed {
+		next(w, r)
+		return
+	}
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	context.AddRequestError(r, &tsuruErrors.HTTP{
+		Code:    http.StatusTooManyRequests,
+		Message: fmt.Sprintf("rate limit exceeded, retry in %d seconds", seconds),
+	})
+}
+
 type appLockMiddleware struct {
 	excludedHandlers []http.Handler
 }
@@ -24,9 +24,11 @@ import (
 	"github.com/tsuru/tsuru/cmd"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/io"
+	"github.com/tsuru/tsuru/ratelimit"
 	"github.com/tsuru/tsuru/servicemanager"
 	appTypes "github.com/tsuru/tsuru/types/app"
 	authTypes "github.com/tsuru/tsuru/types/auth"
+	ratelimitTypes "github.com/tsuru/tsuru/types/ratelimit"
 	"gopkg.in/check.v1"
 )
 
@@ -254,6 +256,64 @@ func (s *S) TestAuthTokenMiddlewareWithTeamToken(c *check.C) {
 	c.Assert(t.GetAppName(), check.Equals, "")
 }
 
+func (s *S) TestRateLimitMiddleware(c *check.C) {
+	ratelimit.SetLimiter(&ratelimit.Limiter{
+		Storage: ratelimit.NewMemoryStorage(),
+		Groups: []ratelimit.Group{
+			{Name: "logs", Routes: []string{"/apps/{app}/log"}, Limit: ratelimitTypes.Limit{RequestsPerMinute: 1, Burst: 1}},
+		},
+	})
+	defer ratelimit.SetLimiter(nil)
+	request, err := http.NewRequest("GET", "/apps/myapp/log", nil)
+	c.Assert(err, check.IsNil)
+	context.SetAuthToken(request, s.token)
+	context.SetRouteTemplate(request, "/apps/{app}/log")
+	h, log := doHandler()
+	rateLimitMiddleware(httptest.NewRecorder(), request, h)
+	c.Assert(log.called, check.Equals, true)
+	c.Assert(context.GetRequestError(request), check.IsNil)
+	recorder := httptest.NewRecorder()
+	h, log = doHandler()
+	rateLimitMiddleware(recorder, request, h)
+	c.Assert(log.called, check.Equals, false)
+	c.Assert(recorder.Header().Get("Retry-After"), check.Equals, "60")
+	err = context.GetRequestError(request)
+	c.Assert(err, check.DeepEquals, &tsuruErrors.HTTP{
+		Code:    http.StatusTooManyRequests,
+		Message: "rate limit exceeded, retry in 60 seconds",
+	})
+}
+
+func (s *S) TestRateLimitMiddlewareTeamToken(c *check.C) {
+	ratelimit.SetLimiter(&ratelimit.Limiter{
+		Storage: ratelimit.NewMemoryStorage(),
+		Token:   ratelimitTypes.Limit{RequestsPerMinute: 600, Burst: 3},
+	})
+	defer ratelimit.SetLimiter(nil)
+	token, err := servicemanager.TeamToken.Create(authTypes.TeamTokenCreateArgs{
+		Team:              s.team.Name,
+		RequestsPerMinute: 60,
+		Burst:             2,
+	}, s.token)
+	c.Assert(err, check.IsNil)
+	server := RunServer(true)
+	codes := make([]int, 3)
+	for i := range codes {
+		request, err := http.NewRequest("GET", "/teams", nil)
+		c.Assert(err, check.IsNil)
+		request.Header.Set("Authorization", "bearer "+token.Token)
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		codes[i] = recorder.Code
+		if recorder.Code == http.StatusTooManyRequests {
+			c.Assert(recorder.Header().Get("Retry-After"), check.Equals, "1")
+		}
+	}
+	c.Assert(codes[2], check.Equals, http.StatusTooManyRequests)
+	c.Assert(codes[0], check.Not(check.Equals), http.StatusTooManyRequests)
+	c.Assert(codes[1], check.Not(check.Equals), http.StatusTooManyRequests)
+}
+
 func (s *S) TestAuthTokenMiddlewareWithInvalidToken(c *check.C) {
 	recorder := httptest.NewRecorder()
 	request, err := http.NewRequest("GET", "/", nil)
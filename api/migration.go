@@ -0,0 +1,39 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/tsuru/tsuru/auth"
+	"github.com/tsuru/tsuru/migration"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// title: migration status
+// path: /migrations
+// method: GET
+// produce: application/json
+// response: []migration.MigrationStatus
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+func migrationStatus(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	if !permission.Check(t, permission.PermMigrationRead) {
+		return permission.ErrUnauthorized
+	}
+	status, err := migration.Status()
+	if err != nil {
+		return err
+	}
+	if len(status) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,62 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/tsuru/tsuru/migration"
+	"github.com/tsuru/tsuru/permission"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestMigrationStatus(c *check.C) {
+	err := migration.RegisterMigration(migration.Migration{
+		Name: "api-test-migration",
+		Up:   func() error { return nil },
+		Down: func() error { return nil },
+	})
+	c.Assert(err, check.IsNil)
+	err = migration.Run(migration.RunArgs{Writer: &bytes.Buffer{}})
+	c.Assert(err, check.IsNil)
+	token := userWithPermission(c, permission.Permission{
+		Scheme:  permission.PermMigrationRead,
+		Context: permission.Context(permTypes.CtxGlobal, ""),
+	})
+	request, err := http.NewRequest("GET", "/migrations", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var status []migration.MigrationStatus
+	err = json.Unmarshal(recorder.Body.Bytes(), &status)
+	c.Assert(err, check.IsNil)
+	var found bool
+	for _, st := range status {
+		if st.Name == "api-test-migration" {
+			found = true
+			c.Assert(st.State, check.Equals, migration.StateRan)
+			c.Assert(st.Reversible, check.Equals, true)
+			c.Assert(st.StartTime.IsZero(), check.Equals, false)
+		}
+	}
+	c.Assert(found, check.Equals, true)
+}
+
+func (s *S) TestMigrationStatusWithoutPermission(c *check.C) {
+	token := userWithPermission(c)
+	request, err := http.NewRequest("GET", "/migrations", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+}
@@ -28,6 +28,7 @@ import (
 // path: /docker/nodecontainers
 // method: GET
 // produce: application/json
+// response: []nodecontainer.NodeContainerConfigGroup
 // responses:
 //   200: Ok
 //   401: Unauthorized
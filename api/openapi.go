@@ -0,0 +1,417 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate bash -c "rm -f handlerspecs.go && go run ./generator/main.go -o handlerspecs.go"
+
+package api
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	apiRouter "github.com/tsuru/tsuru/api/router"
+	"github.com/tsuru/tsuru/permission"
+)
+
+// handlerSpec describes a handler in the OpenAPI document. Specs for
+// documented handlers are generated from their doc comments, see
+// handlerspecs.go.
+type handlerSpec struct {
+	Title       string
+	Consumes    []string
+	Produces    []string
+	Request     reflect.Type
+	Response    reflect.Type
+	Permissions []*permission.PermissionScheme
+	Responses   []responseSpec
+}
+
+type responseSpec struct {
+	Code        int
+	Description string
+}
+
+// routeSpecs holds the specs of routes whose handlers are not documented
+// functions, keyed by method and path.
+var routeSpecs = map[string]*handlerSpec{
+	"GET /metrics": {
+		Title:     "prometheus metrics",
+		Produces:  []string{"text/plain"},
+		Responses: []responseSpec{{Code: http.StatusOK, Description: "OK"}},
+	},
+}
+
+// openAPIRoutes holds the routes registered by RunServer, used to build the
+// document served in /openapi.json.
+var openAPIRoutes []apiRouter.RouteInfo
+
+var (
+	pathVarRegexp     = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+type openAPIDoc struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary,omitempty"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+	Security    []map[string][]string      `json:"security,omitempty"`
+	Permissions []string                   `json:"x-tsuru-permissions,omitempty"`
+}
+
+type openAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required"`
+	Schema   *openAPISchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Content map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema,omitempty"`
+}
+
+type openAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+}
+
+// title: openapi spec
+// path: /openapi.json
+// method: GET
+// produce: application/json
+// response: openAPIDoc
+// responses:
+//   200: OK
+func openAPISpec(w http.ResponseWriter, r *http.Request) error {
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(buildOpenAPI(openAPIRoutes))
+}
+
+func handlerName(h http.Handler) string {
+	v := reflect.ValueOf(h)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return ""
+	}
+	fn := runtime.FuncForPC(v.Pointer())
+	if fn == nil {
+		return ""
+	}
+	return fn.Name()
+}
+
+func specForRoute(route apiRouter.RouteInfo, method string) *handlerSpec {
+	if spec, ok := routeSpecs[method+" "+route.Path]; ok {
+		return spec
+	}
+	return handlerSpecs[handlerName(route.Handler)]
+}
+
+// routesWithoutSpec returns the routes whose handlers have no spec, in the
+// form "METHOD /version/path".
+func routesWithoutSpec(routes []apiRouter.RouteInfo) []string {
+	var missing []string
+	for _, route := range routes {
+		for _, method := range route.Methods {
+			method = strings.ToUpper(method)
+			if specForRoute(route, method) == nil {
+				missing = append(missing, fmt.Sprintf("%s /%s%s", method, route.Version, route.Path))
+			}
+		}
+	}
+	return missing
+}
+
+func buildOpenAPI(routes []apiRouter.RouteInfo) *openAPIDoc {
+	doc := &openAPIDoc{
+		OpenAPI: "3.0.0",
+		Info:    openAPIInfo{Title: "Tsuru", Version: Version},
+		Paths:   map[string]map[string]*openAPIOperation{},
+		Components: openAPIComponents{
+			SecuritySchemes: map[string]openAPISecurityScheme{
+				"Bearer": {Type: "http", Scheme: "bearer"},
+			},
+		},
+	}
+	schemas := newSchemaBuilder("json")
+	formSchemas := newSchemaBuilder("form")
+	operationIDs := map[string]int{}
+	for _, route := range routes {
+		path := "/" + route.Version + pathVarRegexp.ReplaceAllString(route.Path, "{$1}")
+		for _, method := range route.Methods {
+			method = strings.ToUpper(method)
+			spec := specForRoute(route, method)
+			if spec == nil {
+				spec = &handlerSpec{}
+			}
+			op := &openAPIOperation{
+				OperationID: operationID(route.Handler, spec, operationIDs),
+				Summary:     spec.Title,
+				Responses:   map[string]openAPIResponse{},
+			}
+			if tag := strings.SplitN(strings.TrimPrefix(route.Path, "/"), "/", 2)[0]; tag != "" {
+				op.Tags = []string{tag}
+			}
+			for _, m := range pathVarRegexp.FindAllStringSubmatch(route.Path, -1) {
+				op.Parameters = append(op.Parameters, openAPIParameter{
+					Name:     m[1],
+					In:       "path",
+					Required: true,
+					Schema:   &openAPISchema{Type: "string"},
+				})
+			}
+			if method != "GET" && method != "HEAD" {
+				op.RequestBody = requestBody(spec, schemas, formSchemas)
+			}
+			for _, resp := range spec.Responses {
+				op.Responses[strconv.Itoa(resp.Code)] = response(spec, resp, schemas)
+			}
+			if len(op.Responses) == 0 {
+				op.Responses["default"] = openAPIResponse{Description: "Response"}
+			}
+			if _, ok := route.Handler.(AuthorizationRequiredHandler); ok {
+				op.Security = []map[string][]string{{"Bearer": {}}}
+			}
+			for _, perm := range spec.Permissions {
+				op.Permissions = append(op.Permissions, perm.FullName())
+			}
+			if doc.Paths[path] == nil {
+				doc.Paths[path] = map[string]*openAPIOperation{}
+			}
+			doc.Paths[path][strings.ToLower(method)] = op
+		}
+	}
+	if len(schemas.schemas) > 0 {
+		doc.Components.Schemas = schemas.schemas
+	}
+	return doc
+}
+
+func operationID(h http.Handler, spec *handlerSpec, used map[string]int) string {
+	id := handlerName(h)
+	if idx := strings.LastIndex(id, "."); idx >= 0 {
+		id = id[idx+1:]
+	}
+	if id == "" {
+		for i, word := range strings.Fields(spec.Title) {
+			if i > 0 {
+				word = strings.ToUpper(word[:1]) + word[1:]
+			}
+			id += word
+		}
+	}
+	used[id]++
+	if n := used[id]; n > 1 {
+		id = fmt.Sprintf("%s%d", id, n)
+	}
+	return id
+}
+
+func requestBody(spec *handlerSpec, schemas, formSchemas *schemaBuilder) *openAPIRequestBody {
+	consumes := spec.Consumes
+	if len(consumes) == 0 && spec.Request != nil {
+		consumes = []string{"application/json"}
+	}
+	if len(consumes) == 0 {
+		return nil
+	}
+	body := &openAPIRequestBody{Content: map[string]openAPIMediaType{}}
+	for _, mediaType := range consumes {
+		var media openAPIMediaType
+		if spec.Request != nil && mediaType == "application/x-www-form-urlencoded" {
+			media.Schema = formSchemas.schemaFor(spec.Request)
+		} else if spec.Request != nil {
+			media.Schema = schemas.schemaFor(spec.Request)
+		}
+		body.Content[mediaType] = media
+	}
+	return body
+}
+
+func response(spec *handlerSpec, resp responseSpec, schemas *schemaBuilder) openAPIResponse {
+	result := openAPIResponse{Description: resp.Description}
+	if result.Description == "" {
+		result.Description = http.StatusText(resp.Code)
+	}
+	if resp.Code < 200 || resp.Code >= 300 || resp.Code == http.StatusNoContent {
+		return result
+	}
+	produces := spec.Produces
+	if len(produces) == 0 && spec.Response != nil {
+		produces = []string{"application/json"}
+	}
+	if len(produces) > 0 {
+		result.Content = map[string]openAPIMediaType{}
+	}
+	for _, mediaType := range produces {
+		var media openAPIMediaType
+		if spec.Response != nil {
+			media.Schema = schemas.schemaFor(spec.Response)
+		}
+		result.Content[mediaType] = media
+	}
+	return result
+}
+
+// schemaBuilder converts Go types to OpenAPI schemas, naming fields after
+// the given struct tag. Builders using the json tag follow the rules of
+// encoding/json, adding named structs to the components of the document and
+// referencing them by name. Builders using the form tag describe
+// form-encoded bodies, where every struct is inlined.
+type schemaBuilder struct {
+	tag      string
+	schemas  map[string]*openAPISchema
+	visiting map[reflect.Type]bool
+}
+
+func newSchemaBuilder(tag string) *schemaBuilder {
+	b := &schemaBuilder{tag: tag, visiting: map[reflect.Type]bool{}}
+	if tag == "json" {
+		b.schemas = map[string]*openAPISchema{}
+	}
+	return b
+}
+
+func (b *schemaBuilder) schemaFor(t reflect.Type) *openAPISchema {
+	switch {
+	case t == timeType:
+		return &openAPISchema{Type: "string", Format: "date-time"}
+	case t == rawMessageType:
+		return &openAPISchema{}
+	case b.tag == "json" && t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface &&
+		(t.Implements(jsonMarshalerType) || reflect.PtrTo(t).Implements(jsonMarshalerType)):
+		return &openAPISchema{}
+	case t.Kind() != reflect.Ptr && t.Kind() != reflect.Interface &&
+		(t.Implements(textMarshalerType) || reflect.PtrTo(t).Implements(textMarshalerType)):
+		return &openAPISchema{Type: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return b.schemaFor(t.Elem())
+	case reflect.Bool:
+		return &openAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &openAPISchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: "number"}
+	case reflect.String:
+		return &openAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: "string", Format: "byte"}
+		}
+		return &openAPISchema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &openAPISchema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" || b.schemas == nil {
+			schema := &openAPISchema{Type: "object"}
+			if !b.visiting[t] {
+				b.visiting[t] = true
+				b.addFields(schema, t)
+				delete(b.visiting, t)
+			}
+			return schema
+		}
+		name := schemaName(t)
+		if _, ok := b.schemas[name]; !ok {
+			schema := &openAPISchema{Type: "object"}
+			b.schemas[name] = schema
+			b.addFields(schema, t)
+		}
+		return &openAPISchema{Ref: "#/components/schemas/" + name}
+	}
+	return &openAPISchema{}
+}
+
+func (b *schemaBuilder) addFields(schema *openAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get(b.tag)
+		if tag == "-" {
+			continue
+		}
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			b.addFields(schema, fieldType)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if schema.Properties == nil {
+			schema.Properties = map[string]*openAPISchema{}
+		}
+		if b.tag == "json" && len(parts) > 1 && parts[1] == "string" {
+			schema.Properties[name] = &openAPISchema{Type: "string"}
+			continue
+		}
+		schema.Properties[name] = b.schemaFor(field.Type)
+	}
+}
+
+func schemaName(t reflect.Type) string {
+	pkgPath := t.PkgPath()
+	if idx := strings.LastIndex(pkgPath, "/vendor/"); idx >= 0 {
+		pkgPath = pkgPath[idx+len("/vendor/"):]
+	}
+	pkgPath = strings.TrimPrefix(pkgPath, "github.com/tsuru/tsuru/")
+	return strings.Replace(pkgPath, "/", ".", -1) + "." + t.Name()
+}
@@ -0,0 +1,122 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"time"
+
+	apiRouter "github.com/tsuru/tsuru/api/router"
+	"gopkg.in/check.v1"
+)
+
+type openAPIEmbedded struct {
+	ID string `json:"id"`
+}
+
+type openAPITestType struct {
+	openAPIEmbedded
+	Name     string            `json:"name"`
+	Count    int               `json:"count,string"`
+	Tags     []string          `json:"tags,omitempty"`
+	Data     []byte            `json:"data"`
+	Labels   map[string]string `json:"labels"`
+	Created  time.Time         `json:"created"`
+	Parent   *openAPITestType  `json:"parent"`
+	Ignored  string            `json:"-"`
+	Untagged bool
+	private  string
+}
+
+func (s *S) TestAllRoutesHaveSpecs(c *check.C) {
+	c.Assert(openAPIRoutes, check.Not(check.HasLen), 0)
+	missing := routesWithoutSpec(openAPIRoutes)
+	c.Assert(missing, check.HasLen, 0, check.Commentf("routes without spec, document their handlers and run go generate in the api directory: %v", missing))
+}
+
+func (s *S) TestRoutesWithoutSpec(c *check.C) {
+	routes := []apiRouter.RouteInfo{
+		{Version: "1.0", Methods: []string{"Get"}, Path: "/undocumented", Handler: Handler(func(w http.ResponseWriter, r *http.Request) error { return nil })},
+		{Version: "1.7", Methods: []string{"GET"}, Path: "/migrations", Handler: AuthorizationRequiredHandler(migrationStatus)},
+		{Version: "1.2", Methods: []string{"GET"}, Path: "/metrics", Handler: http.NotFoundHandler()},
+	}
+	c.Assert(routesWithoutSpec(routes), check.DeepEquals, []string{"GET /1.0/undocumented"})
+}
+
+func (s *S) TestOpenAPISpec(c *check.C) {
+	request, err := http.NewRequest("GET", "/openapi.json", nil)
+	c.Assert(err, check.IsNil)
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var doc openAPIDoc
+	err = json.Unmarshal(recorder.Body.Bytes(), &doc)
+	c.Assert(err, check.IsNil)
+	c.Assert(doc.OpenAPI, check.Equals, "3.0.0")
+	c.Assert(doc.Info.Version, check.Equals, Version)
+	op := doc.Paths["/1.7/migrations"]["get"]
+	c.Assert(op, check.NotNil)
+	c.Assert(op.OperationID, check.Equals, "migrationStatus")
+	c.Assert(op.Summary, check.Equals, "migration status")
+	c.Assert(op.Tags, check.DeepEquals, []string{"migrations"})
+	c.Assert(op.Permissions, check.DeepEquals, []string{"migration.read"})
+	c.Assert(op.Security, check.DeepEquals, []map[string][]string{{"Bearer": {}}})
+	c.Assert(op.Responses["200"].Content["application/json"].Schema, check.DeepEquals, &openAPISchema{
+		Type:  "array",
+		Items: &openAPISchema{Ref: "#/components/schemas/migration.MigrationStatus"},
+	})
+	c.Assert(doc.Components.Schemas["migration.MigrationStatus"].Properties["startTime"], check.DeepEquals, &openAPISchema{
+		Type:   "string",
+		Format: "date-time",
+	})
+	op = doc.Paths["/1.6/node/{address}"]["get"]
+	c.Assert(op, check.NotNil)
+	c.Assert(op.Parameters, check.DeepEquals, []openAPIParameter{
+		{Name: "address", In: "path", Required: true, Schema: &openAPISchema{Type: "string"}},
+	})
+	op = doc.Paths["/1.7/openapi.json"]["get"]
+	c.Assert(op, check.NotNil)
+	c.Assert(op.Security, check.IsNil)
+}
+
+func (s *S) TestOpenAPIJSONSchema(c *check.C) {
+	b := newSchemaBuilder("json")
+	schema := b.schemaFor(reflect.TypeOf([]openAPITestType{}))
+	c.Assert(schema, check.DeepEquals, &openAPISchema{
+		Type:  "array",
+		Items: &openAPISchema{Ref: "#/components/schemas/api.openAPITestType"},
+	})
+	c.Assert(b.schemas, check.DeepEquals, map[string]*openAPISchema{
+		"api.openAPITestType": {
+			Type: "object",
+			Properties: map[string]*openAPISchema{
+				"id":       {Type: "string"},
+				"name":     {Type: "string"},
+				"count":    {Type: "string"},
+				"tags":     {Type: "array", Items: &openAPISchema{Type: "string"}},
+				"data":     {Type: "string", Format: "byte"},
+				"labels":   {Type: "object", AdditionalProperties: &openAPISchema{Type: "string"}},
+				"created":  {Type: "string", Format: "date-time"},
+				"parent":   {Ref: "#/components/schemas/api.openAPITestType"},
+				"Untagged": {Type: "boolean"},
+			},
+		},
+	})
+}
+
+func (s *S) TestOpenAPIFormSchema(c *check.C) {
+	b := newSchemaBuilder("form")
+	schema := b.schemaFor(reflect.TypeOf(openAPITestType{}))
+	c.Assert(schema.Type, check.Equals, "object")
+	c.Assert(schema.Ref, check.Equals, "")
+	c.Assert(schema.Properties["Ignored"], check.DeepEquals, &openAPISchema{Type: "string"})
+	c.Assert(schema.Properties["Count"], check.DeepEquals, &openAPISchema{Type: "integer"})
+	c.Assert(schema.Properties["Parent"], check.DeepEquals, &openAPISchema{Type: "object"})
+	c.Assert(b.schemas, check.IsNil)
+}
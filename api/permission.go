@@ -111,6 +111,7 @@ func removeRole(w http.ResponseWriter, r *http.Request, t auth.Token) (err error
 // path: /roles
 // method: GET
 // produce: application/json
+// response: []permission.Role
 // responses:
 //   200: OK
 //   401: Unauthorized
@@ -139,6 +140,7 @@ func listRoles(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 // path: /roles/{name}
 // method: GET
 // produce: application/json
+// response: permission.Role
 // responses:
 //   200: OK
 //   401: Unauthorized
@@ -513,7 +515,7 @@ func listPermissions(w http.ResponseWriter, r *http.Request, t auth.Token) error
 // title: add default role
 // path: /role/default
 // method: POST
-// consme: application/x-www-form-urlencoded
+// consume: application/x-www-form-urlencoded
 // responses:
 //   200: Ok
 //   400: Invalid data
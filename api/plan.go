@@ -76,6 +76,7 @@ func addPlan(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 // path: /plans
 // method: GET
 // produce: application/json
+// response: []appTypes.Plan
 // responses:
 //   200: OK
 //   204: No content
@@ -7,10 +7,15 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app"
 	"github.com/tsuru/tsuru/auth"
 	tErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
@@ -18,6 +23,7 @@ import (
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/servicemanager"
 	appTypes "github.com/tsuru/tsuru/types/app"
+	imageTypes "github.com/tsuru/tsuru/types/app/image"
 )
 
 // title: add platform
@@ -51,6 +57,15 @@ func platformAdd(w http.ResponseWriter, r *http.Request, t auth.Token) (err erro
 	if !canCreatePlatform {
 		return permission.ErrUnauthorized
 	}
+	opts := appTypes.PlatformOptions{
+		Name: name,
+		Args: args,
+		Data: data,
+	}
+	err = parsePlatformBuildOptions(r, &opts)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "application/x-json-stream")
 	keepAliveWriter := io.NewKeepAliveWriter(w, 30*time.Second, "")
 	defer keepAliveWriter.Stop()
@@ -68,13 +83,9 @@ func platformAdd(w http.ResponseWriter, r *http.Request, t auth.Token) (err erro
 	defer func() { evt.Done(err) }()
 	evt.SetLogWriter(writer)
 	ctx, cancel := evt.CancelableContext(context.Background())
-	err = servicemanager.Platform.Create(appTypes.PlatformOptions{
-		Name:   name,
-		Args:   args,
-		Data:   data,
-		Output: evt,
-		Ctx:    ctx,
-	})
+	opts.Output = evt
+	opts.Ctx = ctx
+	err = servicemanager.Platform.Create(opts)
 	cancel()
 	if err != nil {
 		return err
@@ -108,6 +119,15 @@ func platformUpdate(w http.ResponseWriter, r *http.Request, t auth.Token) (err e
 	if !canUpdatePlatform {
 		return permission.ErrUnauthorized
 	}
+	opts := appTypes.PlatformOptions{
+		Name:  name,
+		Args:  args,
+		Input: file,
+	}
+	err = parsePlatformBuildOptions(r, &opts)
+	if err != nil {
+		return err
+	}
 	w.Header().Set("Content-Type", "application/x-json-stream")
 	keepAliveWriter := io.NewKeepAliveWriter(w, 30*time.Second, "")
 	defer keepAliveWriter.Stop()
@@ -125,13 +145,9 @@ func platformUpdate(w http.ResponseWriter, r *http.Request, t auth.Token) (err e
 	defer func() { evt.Done(err) }()
 	evt.SetLogWriter(writer)
 	ctx, cancel := evt.CancelableContext(context.Background())
-	err = servicemanager.Platform.Update(appTypes.PlatformOptions{
-		Name:   name,
-		Args:   args,
-		Input:  file,
-		Output: evt,
-		Ctx:    ctx,
-	})
+	opts.Output = evt
+	opts.Ctx = ctx
+	err = servicemanager.Platform.Update(opts)
 	cancel()
 	if err == appTypes.ErrPlatformNotFound {
 		return &tErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
@@ -143,6 +159,31 @@ func platformUpdate(w http.ResponseWriter, r *http.Request, t auth.Token) (err e
 	return nil
 }
 
+// parsePlatformBuildOptions fills the Dockerfile build options from the
+// request. Build args are sent as multiple "build-arg" values in the
+// KEY=VALUE format.
+func parsePlatformBuildOptions(r *http.Request, opts *appTypes.PlatformOptions) error {
+	for _, arg := range r.Form["build-arg"] {
+		parts := strings.SplitN(arg, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return &tErrors.HTTP{Code: http.StatusBadRequest, Message: fmt.Sprintf("invalid build arg %q, must be in the KEY=VALUE format", arg)}
+		}
+		if opts.BuildArgs == nil {
+			opts.BuildArgs = make(map[string]string)
+		}
+		opts.BuildArgs[parts[0]] = parts[1]
+	}
+	opts.Target = r.FormValue("target")
+	if noCache := r.FormValue("no-cache"); noCache != "" {
+		var err error
+		opts.NoCache, err = strconv.ParseBool(noCache)
+		if err != nil {
+			return &tErrors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+	}
+	return nil
+}
+
 // title: remove platform
 // path: /platforms/{name}
 // method: DELETE
@@ -181,6 +222,7 @@ func platformRemove(w http.ResponseWriter, r *http.Request, t auth.Token) (err e
 // path: /platforms
 // method: GET
 // produce: application/json
+// response: []appTypes.Platform
 // responses:
 //   200: List platforms
 //   204: No content
@@ -229,9 +271,14 @@ func platformInfo(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 	if err != nil {
 		return err
 	}
+	versions, err := servicemanager.PlatformImage.ListVersions(name)
+	if err != nil {
+		return err
+	}
 	msg := map[string]interface{}{
 		"platform": platform,
 		"images":   images,
+		"versions": versions,
 	}
 	w.Header().Set("Content-Type", "application/json")
 	return json.NewEncoder(w).Encode(msg)
@@ -289,9 +336,94 @@ func platformRollback(w http.ResponseWriter, r *http.Request, t auth.Token) (err
 	if err == appTypes.ErrPlatformNotFound {
 		return &tErrors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
 	}
+	if errors.Cause(err) == imageTypes.ErrPlatformVersionEOL {
+		return &tErrors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
 	if err != nil {
 		return err
 	}
 	writer.Write([]byte("Platform successfully updated!\n"))
 	return nil
 }
+
+// title: platform version list
+// path: /platforms/{name}/versions
+// method: GET
+// produce: application/json
+// responses:
+//   200: List platform versions and the apps using them
+//   401: Unauthorized
+//   404: Not found
+func platformVersionList(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	name := r.URL.Query().Get(":name")
+	canUsePlat := permission.Check(t, permission.PermPlatformUpdate) ||
+		permission.Check(t, permission.PermPlatformCreate)
+	if !canUsePlat {
+		return permission.ErrUnauthorized
+	}
+	report, err := app.PlatformVersionsReport(name)
+	if err == appTypes.ErrInvalidPlatform {
+		return &tErrors.HTTP{Code: http.StatusNotFound, Message: appTypes.ErrPlatformNotFound.Error()}
+	}
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(report)
+}
+
+// title: platform version update
+// path: /platforms/{name}/versions/{version}
+// method: PUT
+// consume: application/x-www-form-urlencoded
+// responses:
+//   200: Platform version updated
+//   400: Invalid data
+//   401: Unauthorized
+//   404: Not found
+func platformVersionUpdate(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	if err = r.ParseForm(); err != nil {
+		return err
+	}
+	name := r.URL.Query().Get(":name")
+	versionName := r.URL.Query().Get(":version")
+	canUpdatePlatform := permission.Check(t, permission.PermPlatformUpdate)
+	if !canUpdatePlatform {
+		return permission.ErrUnauthorized
+	}
+	version, err := servicemanager.PlatformImage.FindVersion(name, versionName)
+	if err == imageTypes.ErrPlatformVersionNotFound {
+		var img string
+		img, err = servicemanager.PlatformImage.FindImage(name, versionName)
+		if err != nil {
+			return &tErrors.HTTP{Code: http.StatusNotFound, Message: imageTypes.ErrPlatformVersionNotFound.Error()}
+		}
+		version = &imageTypes.PlatformVersion{Platform: name, Version: versionName, Image: img}
+	} else if err != nil {
+		return err
+	}
+	if status := r.FormValue("status"); status != "" {
+		version.Status = imageTypes.PlatformVersionStatus(status)
+		if !version.Status.Valid() {
+			return &tErrors.HTTP{Code: http.StatusBadRequest, Message: imageTypes.ErrInvalidPlatformVersion.Error()}
+		}
+	}
+	if runtime, ok := r.Form["runtime"]; ok {
+		version.Runtime = runtime[0]
+	}
+	if changelog, ok := r.Form["changelog"]; ok {
+		version.Changelog = changelog[0]
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     event.Target{Type: event.TargetTypePlatform, Value: name},
+		Kind:       permission.PermPlatformUpdate,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermPlatformReadEvents),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	return servicemanager.PlatformImage.SetVersion(*version)
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/tsuru/tsuru/repository/repositorytest"
 	servicemock "github.com/tsuru/tsuru/servicemanager/mock"
 	appTypes "github.com/tsuru/tsuru/types/app"
+	imageTypes "github.com/tsuru/tsuru/types/app/image"
 	permTypes "github.com/tsuru/tsuru/types/permission"
 	"github.com/tsuru/tsuru/types/quota"
 	"gopkg.in/check.v1"
@@ -122,6 +123,56 @@ func (s *PlatformSuite) TestPlatformAdd(c *check.C) {
 	}, eventtest.HasEvent)
 }
 
+func (s *PlatformSuite) TestPlatformAddWithBuildOptions(c *check.C) {
+	s.mockService.Platform.OnCreate = func(opts appTypes.PlatformOptions) error {
+		c.Assert(opts.BuildArgs, check.DeepEquals, map[string]string{"PYTHON_VERSION": "3.6", "EXTRA": "a=b"})
+		c.Assert(opts.Target, check.Equals, "runtime")
+		c.Assert(opts.NoCache, check.Equals, true)
+		return nil
+	}
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	writer.WriteField("name", "test")
+	writer.WriteField("build-arg", "PYTHON_VERSION=3.6")
+	writer.WriteField("build-arg", "EXTRA=a=b")
+	writer.WriteField("target", "runtime")
+	writer.WriteField("no-cache", "true")
+	fileWriter, err := writer.CreateFormFile("dockerfile_content", "Dockerfile")
+	c.Assert(err, check.IsNil)
+	fileWriter.Write([]byte("FROM tsuru/java"))
+	writer.Close()
+	request, _ := http.NewRequest("POST", "/platforms", &buf)
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+	token := createToken(c)
+	request.Header.Set("Authorization", "b "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+}
+
+func (s *PlatformSuite) TestPlatformAddInvalidBuildArg(c *check.C) {
+	s.mockService.Platform.OnCreate = func(opts appTypes.PlatformOptions) error {
+		c.Errorf("service not expected to be called.")
+		return nil
+	}
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	writer.WriteField("name", "test")
+	writer.WriteField("build-arg", "PYTHON_VERSION")
+	fileWriter, err := writer.CreateFormFile("dockerfile_content", "Dockerfile")
+	c.Assert(err, check.IsNil)
+	fileWriter.Write([]byte("FROM tsuru/java"))
+	writer.Close()
+	request, _ := http.NewRequest("POST", "/platforms", &buf)
+	request.Header.Add("Content-Type", writer.FormDataContentType())
+	token := createToken(c)
+	request.Header.Set("Authorization", "b "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "invalid build arg \"PYTHON_VERSION\", must be in the KEY=VALUE format\n")
+}
+
 func (s *PlatformSuite) TestPlatformAddError(c *check.C) {
 	name := "Invalid_Name"
 	dockerfileURL := "http://localhost/Dockerfile"
@@ -576,3 +627,117 @@ func (s *PlatformSuite) TestPlatformRollbackError(c *check.C) {
 	s.testServer.ServeHTTP(recorder, request)
 	c.Assert(recorder.Code, check.Equals, http.StatusInternalServerError)
 }
+
+func (s *PlatformSuite) TestPlatformVersionList(c *check.C) {
+	s.mockService.PlatformImage.OnListImagesOrDefault = func(name string) ([]string, error) {
+		c.Assert(name, check.Equals, "python")
+		return []string{"tsuru/python:v1", "tsuru/python:v2"}, nil
+	}
+	s.mockService.PlatformImage.OnCurrentImage = func(name string) (string, error) {
+		return "tsuru/python:v2", nil
+	}
+	s.mockService.PlatformImage.OnListVersions = func(name string) ([]imageTypes.PlatformVersion, error) {
+		return []imageTypes.PlatformVersion{
+			{Platform: "python", Version: "v1", Image: "tsuru/python:v1", Runtime: "2.7", Status: imageTypes.PlatformVersionEOL},
+		}, nil
+	}
+	err := s.conn.Apps().Insert(
+		app.App{Name: "app1", Platform: "python", PlatformVersion: "v1"},
+		app.App{Name: "app2", Platform: "python"},
+		app.App{Name: "app3", Platform: "go"},
+	)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", "/platforms/python/versions", nil)
+	c.Assert(err, check.IsNil)
+	token := createToken(c)
+	request.Header.Set("Authorization", "b "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var got []app.PlatformVersionReport
+	err = json.NewDecoder(recorder.Body).Decode(&got)
+	c.Assert(err, check.IsNil)
+	c.Assert(got, check.DeepEquals, []app.PlatformVersionReport{
+		{
+			PlatformVersion: imageTypes.PlatformVersion{Platform: "python", Version: "v1", Image: "tsuru/python:v1", Runtime: "2.7", Status: imageTypes.PlatformVersionEOL},
+			Apps:            []string{"app1"},
+		},
+		{
+			PlatformVersion: imageTypes.PlatformVersion{Platform: "python", Version: "v2", Image: "tsuru/python:v2", Status: imageTypes.PlatformVersionActive},
+			Apps:            []string{"app2"},
+		},
+	})
+}
+
+func (s *PlatformSuite) TestPlatformVersionUpdate(c *check.C) {
+	s.mockService.PlatformImage.OnFindVersion = func(name, version string) (*imageTypes.PlatformVersion, error) {
+		c.Assert(name, check.Equals, "python")
+		c.Assert(version, check.Equals, "v1")
+		return &imageTypes.PlatformVersion{Platform: name, Version: version, Runtime: "2.7", Status: imageTypes.PlatformVersionActive}, nil
+	}
+	var saved imageTypes.PlatformVersion
+	s.mockService.PlatformImage.OnSetVersion = func(v imageTypes.PlatformVersion) error {
+		saved = v
+		return nil
+	}
+	body := strings.NewReader("status=eol&changelog=python 2 is gone")
+	request, err := http.NewRequest("PUT", "/platforms/python/versions/v1", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	token := createToken(c)
+	request.Header.Set("Authorization", "b "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(saved, check.DeepEquals, imageTypes.PlatformVersion{
+		Platform:  "python",
+		Version:   "v1",
+		Runtime:   "2.7",
+		Changelog: "python 2 is gone",
+		Status:    imageTypes.PlatformVersionEOL,
+	})
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypePlatform, Value: "python"},
+		Owner:  token.GetUserName(),
+		Kind:   "platform.update",
+		StartCustomData: []map[string]interface{}{
+			{"name": "status", "value": "eol"},
+			{"name": "changelog", "value": "python 2 is gone"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *PlatformSuite) TestPlatformVersionUpdateInvalidStatus(c *check.C) {
+	s.mockService.PlatformImage.OnFindVersion = func(name, version string) (*imageTypes.PlatformVersion, error) {
+		return &imageTypes.PlatformVersion{Platform: name, Version: version}, nil
+	}
+	s.mockService.PlatformImage.OnSetVersion = func(v imageTypes.PlatformVersion) error {
+		c.Errorf("service not expected to be called.")
+		return nil
+	}
+	body := strings.NewReader("status=retired")
+	request, err := http.NewRequest("PUT", "/platforms/python/versions/v1", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	token := createToken(c)
+	request.Header.Set("Authorization", "b "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+}
+
+func (s *PlatformSuite) TestPlatformVersionUpdateNotFound(c *check.C) {
+	s.mockService.PlatformImage.OnFindImage = func(name, image string) (string, error) {
+		return "", imageTypes.ErrPlatformImageNotFound
+	}
+	body := strings.NewReader("status=eol")
+	request, err := http.NewRequest("PUT", "/platforms/python/versions/v9", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	token := createToken(c)
+	request.Header.Set("Authorization", "b "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
@@ -272,6 +272,7 @@ func removeTeamToPoolHandler(w http.ResponseWriter, r *http.Request, t auth.Toke
 // consume: application/x-www-form-urlencoded
 // responses:
 //   200: Pool updated
+//   400: Invalid data
 //   401: Unauthorized
 //   404: Pool not found
 //   409: Default pool already defined
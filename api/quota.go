@@ -13,6 +13,7 @@ import (
 	"github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/service"
 	"github.com/tsuru/tsuru/servicemanager"
 	authTypes "github.com/tsuru/tsuru/types/auth"
 	permTypes "github.com/tsuru/tsuru/types/permission"
@@ -170,3 +171,106 @@ func changeAppQuota(w http.ResponseWriter, r *http.Request, t auth.Token) (err e
 	}
 	return err
 }
+
+// title: team service instance quotas
+// path: /teams/{name}/service-instance-quotas
+// method: GET
+// produce: application/json
+// responses:
+//   200: OK
+//   204: No content
+//   401: Unauthorized
+//   404: Team not found
+func listServiceInstanceQuotas(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	teamName := r.URL.Query().Get(":name")
+	allowed := permission.Check(t, permission.PermTeamRead, permission.Context(permTypes.CtxTeam, teamName))
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	_, err := servicemanager.Team.FindByName(teamName)
+	if err == authTypes.ErrTeamNotFound {
+		return &errors.HTTP{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		}
+	}
+	if err != nil {
+		return err
+	}
+	quotas, err := service.ListInstanceQuotas(teamName)
+	if err != nil {
+		return err
+	}
+	if len(quotas) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(quotas)
+}
+
+// title: update team service instance quota
+// path: /teams/{name}/service-instance-quotas
+// method: PUT
+// consume: application/x-www-form-urlencoded
+// responses:
+//   200: Quota updated
+//   400: Invalid data
+//   401: Unauthorized
+//   403: Limit lower than allocated
+//   404: Team not found
+func changeServiceInstanceQuota(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	r.ParseForm()
+	teamName := r.URL.Query().Get(":name")
+	allowed := permission.Check(t, permission.PermTeamAdminQuota, permission.Context(permTypes.CtxTeam, teamName))
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	_, err = servicemanager.Team.FindByName(teamName)
+	if err == authTypes.ErrTeamNotFound {
+		return &errors.HTTP{
+			Code:    http.StatusNotFound,
+			Message: err.Error(),
+		}
+	}
+	if err != nil {
+		return err
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     event.Target{Type: event.TargetTypeTeam, Value: teamName},
+		Kind:       permission.PermTeamAdminQuota,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermTeamReadEvents, permission.Context(permTypes.CtxTeam, teamName)),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	limit, err := strconv.Atoi(r.FormValue("limit"))
+	if err != nil {
+		return &errors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: "Invalid limit",
+		}
+	}
+	err = service.SetInstanceQuota(service.InstanceQuota{
+		Team:    teamName,
+		Service: r.FormValue("service"),
+		Plan:    r.FormValue("plan"),
+		Limit:   limit,
+	})
+	switch err {
+	case quota.ErrLimitLowerThanAllocated:
+		return &errors.HTTP{
+			Code:    http.StatusForbidden,
+			Message: err.Error(),
+		}
+	case service.ErrInstanceQuotaPlanWithoutService:
+		return &errors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+	return err
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/permission/permissiontest"
 	"github.com/tsuru/tsuru/repository/repositorytest"
+	"github.com/tsuru/tsuru/service"
 	servicemock "github.com/tsuru/tsuru/servicemanager/mock"
 	_ "github.com/tsuru/tsuru/storage/mongodb"
 	appTypes "github.com/tsuru/tsuru/types/app"
@@ -484,3 +485,75 @@ func (s *QuotaSuite) TestChangeAppQuotaLimitLowerThanAllocated(c *check.C) {
 		ErrorMatches: `New limit is less than the current allocated value`,
 	}, eventtest.HasEvent)
 }
+
+func (s *QuotaSuite) TestChangeServiceInstanceQuota(c *check.C) {
+	s.mockService.Team.OnFindByName = func(name string) (*authTypes.Team, error) {
+		c.Assert(name, check.Equals, s.team.Name)
+		return s.team, nil
+	}
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "siquotauser", permission.Permission{
+		Scheme:  permission.PermTeamAdminQuota,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	}, permission.Permission{
+		Scheme:  permission.PermTeamRead,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	body := bytes.NewBufferString("service=mysql&plan=large&limit=2")
+	request, _ := http.NewRequest("PUT", "/teams/superteam/service-instance-quotas", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeTeam, Value: s.team.Name},
+		Owner:  token.GetUserName(),
+		Kind:   "team.admin.quota",
+		StartCustomData: []map[string]interface{}{
+			{"name": ":name", "value": s.team.Name},
+			{"name": "service", "value": "mysql"},
+			{"name": "plan", "value": "large"},
+			{"name": "limit", "value": "2"},
+		},
+	}, eventtest.HasEvent)
+	request, _ = http.NewRequest("GET", "/teams/superteam/service-instance-quotas", nil)
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder = httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	var quotas []service.InstanceQuota
+	err := json.NewDecoder(recorder.Body).Decode(&quotas)
+	c.Assert(err, check.IsNil)
+	c.Assert(quotas, check.DeepEquals, []service.InstanceQuota{
+		{Team: s.team.Name, Service: "mysql", Plan: "large", Limit: 2},
+	})
+}
+
+func (s *QuotaSuite) TestChangeServiceInstanceQuotaPlanWithoutService(c *check.C) {
+	s.mockService.Team.OnFindByName = func(name string) (*authTypes.Team, error) {
+		return s.team, nil
+	}
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "siquotauser", permission.Permission{
+		Scheme:  permission.PermTeamAdminQuota,
+		Context: permission.Context(permTypes.CtxTeam, s.team.Name),
+	})
+	body := bytes.NewBufferString("plan=large&limit=2")
+	request, _ := http.NewRequest("PUT", "/teams/superteam/service-instance-quotas", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, service.ErrInstanceQuotaPlanWithoutService.Error()+"\n")
+}
+
+func (s *QuotaSuite) TestChangeServiceInstanceQuotaRequiresPermission(c *check.C) {
+	token := userWithPermission(c)
+	body := bytes.NewBufferString("limit=2")
+	request, _ := http.NewRequest("PUT", "/teams/superteam/service-instance-quotas", body)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusForbidden)
+}
@@ -23,6 +23,7 @@ import (
 // path: /routers
 // method: GET
 // produce: application/json
+// response: []router.PlanRouter
 // responses:
 //   200: OK
 //   204: No content
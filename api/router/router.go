@@ -20,6 +20,17 @@ const versionMatcher = "/{version:[0-9.]+}"
 type Route struct {
 	route   *mux.Route
 	version string
+	path    string
+	methods []string
+	handler http.Handler
+}
+
+// RouteInfo describes a versioned route registered in the router.
+type RouteInfo struct {
+	Version string
+	Methods []string
+	Path    string
+	Handler http.Handler
 }
 
 func NewRouter() *DelayedRouter {
@@ -30,8 +41,9 @@ func NewRouter() *DelayedRouter {
 }
 
 type DelayedRouter struct {
-	mux    *mux.Router
-	routes map[*mux.Route]*Route
+	mux       *mux.Router
+	routes    map[*mux.Route]*Route
+	versioned []*Route
 }
 
 func (r *DelayedRouter) registerVars(req *http.Request, vars map[string]string) {
@@ -44,14 +56,16 @@ func (r *DelayedRouter) registerVars(req *http.Request, vars map[string]string)
 
 func (r *DelayedRouter) addRoute(version, path string, h http.Handler, methods ...string) *mux.Route {
 	muxRoute := r.mux.NewRoute().Handler(h).Methods(methods...)
-	route := &Route{route: muxRoute, version: version}
+	route := &Route{route: muxRoute, version: version, path: path, methods: methods, handler: h}
 	r.routes[muxRoute] = route
+	r.versioned = append(r.versioned, route)
 	versionRegexp := regexp.MustCompile("/(?P<version>[0-9.]+)/")
 	muxRoute.MatcherFunc(func(httpRequest *http.Request, rm *mux.RouteMatch) bool {
 		d := versionRegexp.FindStringSubmatch(httpRequest.URL.Path)
 		return len(d) > 1 && r.routes[muxRoute].version == d[1]
 	}).PathPrefix(versionMatcher).Path(path)
-	r.mux.NewRoute().Path(path).Handler(h).Methods(methods...)
+	unversionedRoute := r.mux.NewRoute().Path(path).Handler(h).Methods(methods...)
+	r.routes[unversionedRoute] = &Route{route: unversionedRoute, path: path}
 	return muxRoute
 }
 
@@ -64,6 +78,20 @@ func (r *DelayedRouter) AddAll(version, path string, h http.Handler) *mux.Route
 	return r.addRoute(version, path, h, "GET", "POST", "PUT", "DELETE")
 }
 
+// Routes returns the versioned routes in the order they were registered.
+func (r *DelayedRouter) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(r.versioned))
+	for i, route := range r.versioned {
+		routes[i] = RouteInfo{
+			Version: route.version,
+			Methods: route.methods,
+			Path:    route.path,
+			Handler: route.handler,
+		}
+	}
+	return routes
+}
+
 func (r *DelayedRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	var match mux.RouteMatch
 	if !r.mux.Match(req, &match) {
@@ -71,5 +99,8 @@ func (r *DelayedRouter) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	r.registerVars(req, match.Vars)
+	if route, ok := r.routes[match.Route]; ok {
+		context.SetRouteTemplate(req, route.path)
+	}
 	context.SetDelayedHandler(req, match.Handler)
 }
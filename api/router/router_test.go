@@ -108,3 +108,38 @@ func (s *S) TestDelayedRouterAddAll(c *check.C) {
 		called = false
 	}
 }
+
+func (s *S) TestRouteTemplate(c *check.C) {
+	router := NewRouter()
+	router.Add("1.0", "GET", "/dream/{world}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for _, path := range []string{"/1.0/dream/tel'aran'rhiod", "/dream/tel'aran'rhiod"} {
+		recorder := httptest.NewRecorder()
+		request, err := http.NewRequest("GET", path, nil)
+		c.Assert(err, check.IsNil)
+		router.ServeHTTP(recorder, request)
+		c.Assert(context.GetRouteTemplate(request), check.Equals, "/dream/{world}")
+	}
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest("GET", "/nightmare", nil)
+	c.Assert(err, check.IsNil)
+	router.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+	c.Assert(context.GetRouteTemplate(request), check.Equals, "")
+}
+
+func (s *S) TestRoutes(c *check.C) {
+	router := NewRouter()
+	h1 := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	h2 := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	router.Add("1.0", "GET", "/dream/{world}", h1)
+	router.AddAll("1.1", "/dreams", h2)
+	routes := router.Routes()
+	c.Assert(routes, check.HasLen, 2)
+	c.Assert(routes[0].Version, check.Equals, "1.0")
+	c.Assert(routes[0].Methods, check.DeepEquals, []string{"GET"})
+	c.Assert(routes[0].Path, check.Equals, "/dream/{world}")
+	c.Assert(routes[0].Handler, check.NotNil)
+	c.Assert(routes[1].Version, check.Equals, "1.1")
+	c.Assert(routes[1].Methods, check.DeepEquals, []string{"GET", "POST", "PUT", "DELETE"})
+	c.Assert(routes[1].Path, check.Equals, "/dreams")
+}
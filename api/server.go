@@ -39,12 +39,15 @@ import (
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/provision/cluster"
 	"github.com/tsuru/tsuru/provision/nodecontainer"
+	"github.com/tsuru/tsuru/ratelimit"
 	"github.com/tsuru/tsuru/router"
 	"github.com/tsuru/tsuru/router/rebuild"
 	"github.com/tsuru/tsuru/service"
 	"github.com/tsuru/tsuru/servicemanager"
 	"github.com/tsuru/tsuru/storage"
+	"github.com/tsuru/tsuru/tracing"
 	appTypes "github.com/tsuru/tsuru/types/app"
+	"github.com/tsuru/tsuru/volume"
 	"golang.org/x/net/websocket"
 )
 
@@ -181,6 +184,8 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.0", "Put", "/services/{service}/instances/{instance}/{app}", AuthorizationRequiredHandler(bindServiceInstance))
 	m.Add("1.0", "Delete", "/services/{service}/instances/{instance}/{app}", AuthorizationRequiredHandler(unbindServiceInstance))
 	m.Add("1.0", "Get", "/services/{service}/instances/{instance}/status", AuthorizationRequiredHandler(serviceInstanceStatus))
+	m.Add("1.7", "Get", "/services/{service}/instances/{instance}/graph", AuthorizationRequiredHandler(serviceInstanceGraph))
+	m.Add("1.7", "Post", "/services/{service}/instances/{instance}/rotate", AuthorizationRequiredHandler(serviceInstanceRotate))
 	m.Add("1.0", "Put", "/services/{service}/instances/permission/{instance}/{team}", AuthorizationRequiredHandler(serviceInstanceGrantTeam))
 	m.Add("1.0", "Delete", "/services/{service}/instances/permission/{instance}/{team}", AuthorizationRequiredHandler(serviceInstanceRevokeTeam))
 
@@ -202,6 +207,7 @@ func RunServer(dry bool) http.Handler {
 
 	m.Add("1.0", "Delete", "/apps/{app}", AuthorizationRequiredHandler(appDelete))
 	m.Add("1.0", "Get", "/apps/{app}", AuthorizationRequiredHandler(appInfo))
+	m.Add("1.7", "Get", "/apps/{app}/graph", AuthorizationRequiredHandler(appGraph))
 	m.Add("1.0", "Post", "/apps/{app}/cname", AuthorizationRequiredHandler(setCName))
 	m.Add("1.0", "Delete", "/apps/{app}/cname", AuthorizationRequiredHandler(unsetCName))
 	runHandler := AuthorizationRequiredHandler(runCommand)
@@ -234,6 +240,11 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.0", "Post", "/apps/{appname}/deploy/rollback", AuthorizationRequiredHandler(deployRollback))
 	m.Add("1.4", "Put", "/apps/{appname}/deploy/rollback/update", AuthorizationRequiredHandler(deployRollbackUpdate))
 	m.Add("1.3", "Post", "/apps/{appname}/deploy/rebuild", AuthorizationRequiredHandler(deployRebuild))
+	m.Add("1.7", "Get", "/apps/{appname}/deploys/compare", AuthorizationRequiredHandler(deployCompare))
+	m.Add("1.7", "Get", "/apps/{appname}/deploy/requests", AuthorizationRequiredHandler(deployRequestList))
+	m.Add("1.7", "Get", "/apps/{appname}/deploy/requests/{id}", AuthorizationRequiredHandler(deployRequestInfo))
+	m.Add("1.7", "Post", "/apps/{appname}/deploy/requests/{id}/approve", AuthorizationRequiredHandler(deployRequestApprove))
+	m.Add("1.7", "Post", "/apps/{appname}/deploy/requests/{id}/retry", AuthorizationRequiredHandler(deployRequestRetry))
 	m.Add("1.0", "Get", "/apps/{app}/metric/envs", AuthorizationRequiredHandler(appMetricEnvs))
 	m.Add("1.0", "Post", "/apps/{app}/routes", AuthorizationRequiredHandler(appRebuildRoutes))
 	m.Add("1.2", "Get", "/apps/{app}/certificate", AuthorizationRequiredHandler(listCertificates))
@@ -255,6 +266,7 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.3", "Post", "/events/blocks", AuthorizationRequiredHandler(eventBlockAdd))
 	m.Add("1.3", "Delete", "/events/blocks/{uuid}", AuthorizationRequiredHandler(eventBlockRemove))
 	m.Add("1.1", "Get", "/events/kinds", AuthorizationRequiredHandler(kindList))
+	m.Add("1.7", "Get", "/events/stream", AuthorizationRequiredHandler(eventStream))
 	m.Add("1.1", "Get", "/events/{uuid}", AuthorizationRequiredHandler(eventInfo))
 	m.Add("1.1", "Post", "/events/{uuid}/cancel", AuthorizationRequiredHandler(eventCancel))
 
@@ -270,6 +282,8 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.0", "Delete", "/platforms/{name}", AuthorizationRequiredHandler(platformRemove))
 	m.Add("1.6", "Get", "/platforms/{name}", AuthorizationRequiredHandler(platformInfo))
 	m.Add("1.6", "Post", "/platforms/{name}/rollback", AuthorizationRequiredHandler(platformRollback))
+	m.Add("1.7", "Get", "/platforms/{name}/versions", AuthorizationRequiredHandler(platformVersionList))
+	m.Add("1.7", "Put", "/platforms/{name}/versions/{version}", AuthorizationRequiredHandler(platformVersionUpdate))
 
 	// These handlers don't use {app} on purpose. Using :app means that only
 	// the token generate for the given app is valid, but these handlers
@@ -279,6 +293,7 @@ func RunServer(dry bool) http.Handler {
 	diffDeployHandler := AuthorizationRequiredHandler(diffDeploy)
 	m.Add("1.0", "Post", "/apps/{appname}/diff", diffDeployHandler)
 	m.Add("1.5", "Post", "/apps/{appname}/build", AuthorizationRequiredHandler(build))
+	m.Add("1.7", "Delete", "/apps/{appname}/build/cache", AuthorizationRequiredHandler(buildCachePurge))
 
 	// Shell also doesn't use {app} on purpose. Middlewares don't play well
 	// with websocket.
@@ -313,6 +328,8 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.0", "Delete", "/teams/{name}", AuthorizationRequiredHandler(removeTeam))
 	m.Add("1.6", "Put", "/teams/{name}", AuthorizationRequiredHandler(updateTeam))
 	m.Add("1.4", "Get", "/teams/{name}", AuthorizationRequiredHandler(teamInfo))
+	m.Add("1.7", "Get", "/teams/{name}/service-instance-quotas", AuthorizationRequiredHandler(listServiceInstanceQuotas))
+	m.Add("1.7", "Put", "/teams/{name}/service-instance-quotas", AuthorizationRequiredHandler(changeServiceInstanceQuota))
 
 	m.Add("1.0", "Post", "/swap", AuthorizationRequiredHandler(swap))
 
@@ -320,11 +337,14 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.0", "Get", "/healthcheck", http.HandlerFunc(healthcheck))
 
 	m.Add("1.0", "Get", "/iaas/machines", AuthorizationRequiredHandler(machinesList))
+	m.Add("1.7", "Get", "/iaas/machines/audit", AuthorizationRequiredHandler(machinesAudit))
+	m.Add("1.7", "Post", "/iaas/machines/audit/cleanup", AuthorizationRequiredHandler(machinesAuditCleanup))
 	m.Add("1.0", "Delete", "/iaas/machines/{machine_id}", AuthorizationRequiredHandler(machineDestroy))
 	m.Add("1.0", "Get", "/iaas/templates", AuthorizationRequiredHandler(templatesList))
 	m.Add("1.0", "Post", "/iaas/templates", AuthorizationRequiredHandler(templateCreate))
 	m.Add("1.0", "Put", "/iaas/templates/{template_name}", AuthorizationRequiredHandler(templateUpdate))
 	m.Add("1.0", "Delete", "/iaas/templates/{template_name}", AuthorizationRequiredHandler(templateDestroy))
+	m.Add("1.7", "Post", "/iaas/templates/{template_name}/validate", AuthorizationRequiredHandler(templateValidate))
 
 	m.Add("1.0", "Get", "/plans", AuthorizationRequiredHandler(listPlans))
 	m.Add("1.0", "Post", "/plans", AuthorizationRequiredHandler(addPlan))
@@ -395,6 +415,12 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.2", "GET", "/install/hosts", AuthorizationRequiredHandler(installHostList))
 	m.Add("1.2", "GET", "/install/hosts/{name}", AuthorizationRequiredHandler(installHostInfo))
 
+	m.Add("1.7", "GET", "/migrations", AuthorizationRequiredHandler(migrationStatus))
+
+	m.Add("1.7", "POST", "/config/reload", AuthorizationRequiredHandler(configReload))
+
+	m.Add("1.7", "GET", "/diagnostics", AuthorizationRequiredHandler(diagnosticsReport))
+
 	m.Add("1.2", "GET", "/healing/node", AuthorizationRequiredHandler(nodeHealingRead))
 	m.Add("1.2", "POST", "/healing/node", AuthorizationRequiredHandler(nodeHealingUpdate))
 	m.Add("1.2", "DELETE", "/healing/node", AuthorizationRequiredHandler(nodeHealingDelete))
@@ -406,6 +432,8 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.4", "POST", "/provisioner/clusters/{name}", AuthorizationRequiredHandler(updateCluster))
 	m.Add("1.3", "GET", "/provisioner/clusters", AuthorizationRequiredHandler(listClusters))
 	m.Add("1.3", "DELETE", "/provisioner/clusters/{name}", AuthorizationRequiredHandler(deleteCluster))
+	m.Add("1.7", "GET", "/provisioner/clusters/{name}/status", AuthorizationRequiredHandler(clusterStatus))
+	m.Add("1.7", "POST", "/provisioner/clusters/{name}/rotate", AuthorizationRequiredHandler(rotateClusterCredentials))
 
 	m.Add("1.4", "GET", "/volumes", AuthorizationRequiredHandler(volumesList))
 	m.Add("1.4", "GET", "/volumes/{name}", AuthorizationRequiredHandler(volumeInfo))
@@ -414,6 +442,10 @@ func RunServer(dry bool) http.Handler {
 	m.Add("1.4", "POST", "/volumes/{name}", AuthorizationRequiredHandler(volumeUpdate))
 	m.Add("1.4", "POST", "/volumes/{name}/bind", AuthorizationRequiredHandler(volumeBind))
 	m.Add("1.4", "DELETE", "/volumes/{name}/bind", AuthorizationRequiredHandler(volumeUnbind))
+	m.Add("1.7", "POST", "/volumes/{name}/snapshots", AuthorizationRequiredHandler(volumeSnapshotCreate))
+	m.Add("1.7", "GET", "/volumes/{name}/snapshots", AuthorizationRequiredHandler(volumeSnapshotList))
+	m.Add("1.7", "POST", "/volumes/{name}/restore", AuthorizationRequiredHandler(volumeRestore))
+	m.Add("1.7", "POST", "/volumes/{name}/resize", AuthorizationRequiredHandler(volumeResize))
 	m.Add("1.4", "GET", "/volumeplans", AuthorizationRequiredHandler(volumePlansList))
 
 	m.Add("1.6", "GET", "/tokens", AuthorizationRequiredHandler(tokenList))
@@ -458,6 +490,9 @@ func RunServer(dry bool) http.Handler {
 
 	m.Add("1.0", "GET", "/plans/routers", AuthorizationRequiredHandler(listRouters))
 
+	m.Add("1.7", "GET", "/openapi.json", Handler(openAPISpec))
+	openAPIRoutes = m.Routes()
+
 	n := negroni.New()
 	n.Use(negroni.NewRecovery())
 	n.Use(negroni.HandlerFunc(contextClearerMiddleware))
@@ -467,9 +502,12 @@ func RunServer(dry bool) http.Handler {
 	n.UseHandler(m)
 	n.Use(negroni.HandlerFunc(flushingWriterMiddleware))
 	n.Use(negroni.HandlerFunc(setRequestIDHeaderMiddleware))
+	n.Use(negroni.HandlerFunc(metricsMiddleware))
+	n.Use(negroni.HandlerFunc(tracingMiddleware))
 	n.Use(negroni.HandlerFunc(errorHandlingMiddleware))
 	n.Use(negroni.HandlerFunc(setVersionHeadersMiddleware))
 	n.Use(negroni.HandlerFunc(authTokenMiddleware))
+	n.Use(negroni.HandlerFunc(rateLimitMiddleware))
 	n.Use(&contentHijackMiddleware{excludedHandlers: []http.Handler{
 		proxyInstanceHandler,
 		proxyServiceHandler,
@@ -541,6 +579,14 @@ func bindAppsLister() ([]bind.App, error) {
 	return bindApps, nil
 }
 
+func bindAppGetter(name string) (bind.App, error) {
+	a, err := app.GetByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
 func startServer(handler http.Handler) error {
 	srvConf, err := createServers(handler)
 	if err != nil {
@@ -561,6 +607,14 @@ func startServer(handler http.Handler) error {
 	if err != nil {
 		return err
 	}
+	err = tracing.Initialize()
+	if err != nil {
+		return err
+	}
+	err = ratelimit.Initialize()
+	if err != nil {
+		return err
+	}
 	routers, err := router.List()
 	if err != nil {
 		return err
@@ -629,6 +683,22 @@ func startServer(handler http.Handler) error {
 	if err != nil {
 		return err
 	}
+	err = service.InitializeBrokerOperations(bindAppGetter)
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize broker operations poller")
+	}
+	err = volume.InitializeUsageCollector()
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize volume usage collector")
+	}
+	err = cluster.InitializeHealthMonitor()
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize cluster health monitor")
+	}
+	err = app.InitializeDeployRequestsExpiration()
+	if err != nil {
+		return errors.Wrap(err, "unable to initialize deploy requests expiration")
+	}
 	fmt.Println("Checking components status:")
 	results := hc.Check("all")
 	for _, result := range results {
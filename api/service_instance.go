@@ -19,6 +19,8 @@ import (
 	"github.com/pkg/errors"
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/api/context"
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/bind"
 	"github.com/tsuru/tsuru/auth"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
@@ -44,6 +46,7 @@ func serviceIntancePermName(serviceName, instanceName string) string {
 //   201: Service created
 //   400: Invalid data
 //   401: Unauthorized
+//   403: Quota exceeded
 //   409: Service already exists
 func createServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 	serviceName := r.URL.Query().Get(":service")
@@ -102,7 +105,7 @@ func createServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token)
 	if err != nil {
 		return err
 	}
-	defer func() { evt.Done(err) }()
+	defer func() { doneServiceEvent(evt, err) }()
 	requestID := requestIDHeader(r)
 	err = service.CreateServiceInstance(instance, &srv, evt, requestID)
 	if err == service.ErrInstanceNameAlreadyExists {
@@ -117,6 +120,12 @@ func createServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token)
 			Message: err.Error(),
 		}
 	}
+	if _, ok := err.(*service.InstanceQuotaExceededError); ok {
+		return &tsuruErrors.HTTP{
+			Code:    http.StatusForbidden,
+			Message: err.Error(),
+		}
+	}
 	if err == nil {
 		w.WriteHeader(http.StatusCreated)
 	}
@@ -131,6 +140,7 @@ func createServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token)
 //   200: Service instance updated
 //   400: Invalid data
 //   401: Unauthorized
+//   403: Quota exceeded
 //   404: Service instance not found
 func updateServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
 	err = r.ParseForm()
@@ -200,9 +210,16 @@ func updateServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token)
 	if err != nil {
 		return err
 	}
-	defer func() { evt.Done(err) }()
+	defer func() { doneServiceEvent(evt, err) }()
 	requestID := requestIDHeader(r)
-	return si.Update(srv, *si, evt, requestID)
+	err = si.Update(srv, *si, evt, requestID)
+	if _, ok := err.(*service.InstanceQuotaExceededError); ok {
+		return &tsuruErrors.HTTP{
+			Code:    http.StatusForbidden,
+			Message: err.Error(),
+		}
+	}
+	return err
 }
 
 // title: remove service instance
@@ -223,16 +240,20 @@ func removeServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token)
 	if err != nil {
 		return err
 	}
-	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
-	defer keepAliveWriter.Stop()
-	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
-	w.Header().Set("Content-Type", "application/x-json-stream")
 	allowed := permission.Check(t, permission.PermServiceInstanceDelete,
 		contextsForServiceInstance(serviceInstance, serviceName)...,
 	)
 	if !allowed {
 		return permission.ErrUnauthorized
 	}
+	unbindAllBool, _ := strconv.ParseBool(unbindAll)
+	if dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dryrun")); dryRun {
+		return serviceInstanceDeleteImpact(w, t, serviceInstance, unbindAllBool)
+	}
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	w.Header().Set("Content-Type", "application/x-json-stream")
 	evt, err := event.New(&event.Opts{
 		Target:     serviceInstanceTarget(serviceName, instanceName),
 		Kind:       permission.PermServiceInstanceDelete,
@@ -245,9 +266,8 @@ func removeServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token)
 		return err
 	}
 	evt.SetLogWriter(writer)
-	defer func() { evt.Done(err) }()
+	defer func() { doneServiceEvent(evt, err) }()
 	requestID := requestIDHeader(r)
-	unbindAllBool, _ := strconv.ParseBool(unbindAll)
 	if unbindAllBool {
 		if len(serviceInstance.Apps) > 0 {
 			for _, appName := range serviceInstance.Apps {
@@ -288,6 +308,66 @@ func removeServiceInstance(w http.ResponseWriter, r *http.Request, t auth.Token)
 	return nil
 }
 
+type serviceInstanceDeleteReport struct {
+	*service.DeleteInstanceReport
+	Units []string   `json:"units"`
+	Graph *app.Graph `json:"graph"`
+}
+
+func serviceInstanceDeleteImpact(w http.ResponseWriter, t auth.Token, si *service.ServiceInstance, unbindAll bool) error {
+	report, err := service.DeleteInstanceDryRun(si, unbindAll)
+	if err != nil {
+		return err
+	}
+	graph, err := app.InstanceGraph(si, canReadApp(t))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(serviceInstanceDeleteReport{
+		DeleteInstanceReport: report,
+		Units:                graph.NodesByKind(app.GraphNodeUnit),
+		Graph:                graph,
+	})
+}
+
+// title: service instance dependency graph
+// path: /services/{service}/instances/{instance}/graph
+// method: GET
+// produce: application/json, text/vnd.graphviz
+// responses:
+//   200: OK
+//   400: Invalid format
+//   401: Unauthorized
+//   404: Service instance not found
+func serviceInstanceGraph(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	instanceName := r.URL.Query().Get(":instance")
+	serviceName := r.URL.Query().Get(":service")
+	serviceInstance, err := getServiceInstanceOrError(serviceName, instanceName)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermServiceInstanceRead,
+		contextsForServiceInstance(serviceInstance, serviceName)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	graph, err := app.InstanceGraph(serviceInstance, canReadApp(t))
+	if err != nil {
+		return err
+	}
+	return writeGraph(w, r, graph)
+}
+
+// canReadApp returns the filter used to hide the apps t cannot read from
+// service instance graphs.
+func canReadApp(t auth.Token) func(*app.App) bool {
+	return func(a *app.App) bool {
+		return permission.Check(t, permission.PermAppRead, contextsForApp(a)...)
+	}
+}
+
 func readableInstances(t auth.Token, contexts []permTypes.PermissionContext, appName, serviceName string) ([]service.ServiceInstance, error) {
 	teams := []string{}
 	instanceNames := []string{}
@@ -414,6 +494,79 @@ func serviceInstanceStatus(w http.ResponseWriter, r *http.Request, t auth.Token)
 	return err
 }
 
+// title: rotate service instance credentials
+// path: /services/{service}/instances/{instance}/rotate
+// method: POST
+// produce: application/x-json-stream
+// responses:
+//   200: Credentials rotated
+//   400: Invalid data or rotation not supported
+//   401: Unauthorized
+//   404: Service instance not found
+func serviceInstanceRotate(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	err = r.ParseForm()
+	if err != nil {
+		return &tsuruErrors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	serviceName := r.URL.Query().Get(":service")
+	instanceName := r.URL.Query().Get(":instance")
+	serviceInstance, err := getServiceInstanceOrError(serviceName, instanceName)
+	if err != nil {
+		return err
+	}
+	allowed := permission.Check(t, permission.PermServiceInstanceUpdateRotate,
+		contextsForServiceInstance(serviceInstance, serviceName)...,
+	)
+	if !allowed {
+		return permission.ErrUnauthorized
+	}
+	// bound apps are locked during the rotation, so their service envs are not
+	// changed by other operations while they're replaced
+	extraTargets := make([]event.ExtraTarget, len(serviceInstance.Apps))
+	for i, appName := range serviceInstance.Apps {
+		extraTargets[i] = event.ExtraTarget{Target: appTarget(appName), Lock: true}
+	}
+	evt, err := event.New(&event.Opts{
+		Target:       serviceInstanceTarget(serviceName, instanceName),
+		ExtraTargets: extraTargets,
+		Kind:         permission.PermServiceInstanceUpdateRotate,
+		Owner:        t,
+		CustomData:   event.FormToCustomData(r.Form),
+		Allowed: event.Allowed(permission.PermServiceInstanceReadEvents,
+			contextsForServiceInstance(serviceInstance, serviceName)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	apps := make([]bind.App, len(serviceInstance.Apps))
+	for i, appName := range serviceInstance.Apps {
+		a, appErr := app.GetByName(appName)
+		if appErr != nil {
+			return appErr
+		}
+		apps[i] = a
+	}
+	w.Header().Set("Content-Type", "application/x-json-stream")
+	keepAliveWriter := tsuruIo.NewKeepAliveWriter(w, 30*time.Second, "")
+	defer keepAliveWriter.Stop()
+	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
+	evt.SetLogWriter(writer)
+	err = serviceInstance.RotateCredentials(service.RotateCredentialsArgs{
+		Apps:      apps,
+		Writer:    evt,
+		Event:     evt,
+		RequestID: requestIDHeader(r),
+	})
+	if errors.Cause(err) == service.ErrRotationNotSupported {
+		return &tsuruErrors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
+	return err
+}
+
 type serviceInstanceInfo struct {
 	Apps            []string
 	Teams           []string
@@ -735,3 +888,14 @@ func requestIDHeader(r *http.Request) string {
 	requestIDHeader, _ := config.GetString("request-id-header")
 	return context.GetRequestID(r, requestIDHeader)
 }
+
+// doneServiceEvent finishes evt unless it was handed off to an asynchronous
+// broker operation, in which case only the log written since is stored and the
+// event is finished when the operation completes.
+func doneServiceEvent(evt *event.Event, err error) {
+	if err == nil && evt.Pending {
+		evt.SetPending()
+		return
+	}
+	evt.Done(err)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/tsuru/tsuru/db"
 	"github.com/tsuru/tsuru/db/dbtest"
 	"github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/event/eventtest"
 	"github.com/tsuru/tsuru/io"
 	"github.com/tsuru/tsuru/permission"
@@ -2208,3 +2209,209 @@ func (s *ServiceInstanceSuite) TestGrantRevokeServiceToTeamWithManyInstanceName(
 	c.Assert(err, check.IsNil)
 	c.Assert(sinst.Teams, check.DeepEquals, []string{s.team.Name})
 }
+
+func (s *ServiceInstanceSuite) TestRemoveServiceInstanceDryRun(c *check.C) {
+	var called int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.StoreInt32(&called, 1)
+	}))
+	defer ts.Close()
+	srvc := service.Service{Name: "mysqlremove", Endpoint: map[string]string{"production": ts.URL}, Password: "abcde", OwnerTeams: []string{s.team.Name}}
+	err := service.Create(srvc)
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "app", Platform: "zend", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = s.provisioner.AddUnits(&a, 1, "web", nil)
+	c.Assert(err, check.IsNil)
+	instance := service.ServiceInstance{
+		Name:        "my-mysql",
+		ServiceName: "mysqlremove",
+		Teams:       []string{s.team.Name},
+		Apps:        []string{"app"},
+	}
+	err = s.conn.ServiceInstances().Insert(instance)
+	c.Assert(err, check.IsNil)
+	for _, unbindAll := range []bool{false, true} {
+		url := fmt.Sprintf("/services/mysqlremove/instances/my-mysql?dryrun=true&unbindall=%v", unbindAll)
+		request, err := http.NewRequest("DELETE", url, nil)
+		c.Assert(err, check.IsNil)
+		request.Header.Set("Authorization", "b "+s.token.GetValue())
+		recorder := httptest.NewRecorder()
+		s.testServer.ServeHTTP(recorder, request)
+		c.Assert(recorder.Code, check.Equals, http.StatusOK)
+		c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+		var report struct {
+			service.DeleteInstanceReport
+			Units []string
+		}
+		err = json.Unmarshal(recorder.Body.Bytes(), &report)
+		c.Assert(err, check.IsNil)
+		c.Assert(report.Service, check.Equals, "mysqlremove")
+		c.Assert(report.Instance, check.Equals, "my-mysql")
+		c.Assert(report.Units, check.HasLen, 1)
+		if unbindAll {
+			c.Assert(report.UnbindApps, check.DeepEquals, []string{"app"})
+			c.Assert(report.Error, check.Equals, "")
+		} else {
+			c.Assert(report.UnbindApps, check.DeepEquals, []string{})
+			c.Assert(report.Error, check.Equals, service.ErrServiceInstanceBound.Error())
+		}
+	}
+	c.Assert(atomic.LoadInt32(&called), check.Equals, int32(0))
+	_, err = service.GetServiceInstance("mysqlremove", "my-mysql")
+	c.Assert(err, check.IsNil)
+	c.Assert(eventtest.EventDesc{
+		Target: serviceInstanceTarget("mysqlremove", "my-mysql"),
+		Kind:   "service-instance.delete",
+	}, check.Not(eventtest.HasEvent))
+}
+
+func (s *ServiceInstanceSuite) TestRemoveServiceInstanceDryRunOnlyReadableApps(c *check.C) {
+	srvc := service.Service{Name: "mysqlremove", Endpoint: map[string]string{"production": "http://localhost:1234"}, Password: "abcde", OwnerTeams: []string{s.team.Name}}
+	err := service.Create(srvc)
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "app", Platform: "zend", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = s.provisioner.AddUnits(&a, 1, "web", nil)
+	c.Assert(err, check.IsNil)
+	instance := service.ServiceInstance{
+		Name:        "my-mysql",
+		ServiceName: "mysqlremove",
+		Teams:       []string{s.team.Name},
+		Apps:        []string{"app"},
+	}
+	err = s.conn.ServiceInstances().Insert(instance)
+	c.Assert(err, check.IsNil)
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "myuser", permission.Permission{
+		Scheme:  permission.PermServiceInstanceDelete,
+		Context: permission.Context(permTypes.CtxServiceInstance, serviceIntancePermName("mysqlremove", "my-mysql")),
+	})
+	request, err := http.NewRequest("DELETE", "/services/mysqlremove/instances/my-mysql?dryrun=true&unbindall=true", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	var report struct {
+		Units []string
+		Graph *app.Graph
+	}
+	err = json.Unmarshal(recorder.Body.Bytes(), &report)
+	c.Assert(err, check.IsNil)
+	c.Assert(report.Units, check.HasLen, 0)
+	c.Assert(report.Graph.NodesByKind(app.GraphNodeApp), check.HasLen, 0)
+	c.Assert(report.Graph.NodesByKind(app.GraphNodeUnit), check.HasLen, 0)
+	c.Assert(report.Graph.NodesByKind(app.GraphNodeInstance), check.DeepEquals, []string{"mysqlremove/my-mysql"})
+}
+
+func (s *ServiceInstanceSuite) TestServiceInstanceGraph(c *check.C) {
+	a := app.App{Name: "app", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	instance := service.ServiceInstance{
+		Name:        "my-mysql",
+		ServiceName: "mysql",
+		Teams:       []string{s.team.Name},
+		Apps:        []string{"app"},
+	}
+	err = s.conn.ServiceInstances().Insert(instance)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("GET", "/services/mysql/instances/my-mysql/graph?format=dot", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(recorder.Body.String(), check.Matches, `(?s)digraph tsuru \{\n  "service-instance:mysql/my-mysql" .*"app:app" -> "service-instance:mysql/my-mysql" \[label="bind"\];.*`)
+}
+
+func (s *ServiceInstanceSuite) TestServiceInstanceGraphOnlyReadableApps(c *check.C) {
+	a := app.App{Name: "app", Platform: "zend", TeamOwner: s.team.Name}
+	err := app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	instance := service.ServiceInstance{
+		Name:        "my-mysql",
+		ServiceName: "mysql",
+		Teams:       []string{s.team.Name},
+		Apps:        []string{"app"},
+	}
+	err = s.conn.ServiceInstances().Insert(instance)
+	c.Assert(err, check.IsNil)
+	_, token := permissiontest.CustomUserWithPermission(c, nativeScheme, "myuser", permission.Permission{
+		Scheme:  permission.PermServiceInstanceRead,
+		Context: permission.Context(permTypes.CtxServiceInstance, serviceIntancePermName("mysql", "my-mysql")),
+	})
+	request, err := http.NewRequest("GET", "/services/mysql/instances/my-mysql/graph", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "b "+token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	var graph app.Graph
+	err = json.NewDecoder(recorder.Body).Decode(&graph)
+	c.Assert(err, check.IsNil)
+	c.Assert(graph.NodesByKind(app.GraphNodeApp), check.HasLen, 0)
+	c.Assert(graph.NodesByKind(app.GraphNodeInstance), check.DeepEquals, []string{"mysql/my-mysql"})
+}
+
+func (s *ServiceInstanceSuite) TestServiceInstanceRotateInvalidForm(c *check.C) {
+	si := service.ServiceInstance{
+		Name:        "my-mysql",
+		ServiceName: "mysql",
+		Teams:       []string{s.team.Name},
+		TeamOwner:   s.team.Name,
+	}
+	err := s.conn.ServiceInstances().Insert(si)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("POST", "/1.7/services/mysql/instances/my-mysql/rotate", strings.NewReader("a=%%"))
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*invalid URL escape.*`)
+}
+
+func (s *ServiceInstanceSuite) TestServiceInstanceRotateNotSupported(c *check.C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	srv := service.Service{Name: "mysql", Endpoint: map[string]string{"production": ts.URL}, Password: "abcde", OwnerTeams: []string{s.team.Name}}
+	err := service.Create(srv)
+	c.Assert(err, check.IsNil)
+	s.pool = "test1"
+	err = pool.AddPool(pool.AddPoolOptions{Name: "test1", Default: true})
+	c.Assert(err, check.IsNil)
+	a := app.App{Name: "app-instance", Platform: "zend", TeamOwner: s.team.Name}
+	err = app.CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	si := service.ServiceInstance{
+		Name:        "my-mysql",
+		ServiceName: "mysql",
+		Teams:       []string{s.team.Name},
+		TeamOwner:   s.team.Name,
+		Apps:        []string{"app-instance"},
+	}
+	err = s.conn.ServiceInstances().Insert(si)
+	c.Assert(err, check.IsNil)
+	request, err := http.NewRequest("POST", "/1.7/services/mysql/instances/my-mysql/rotate", nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest, check.Commentf("body: %q", recorder.Body.String()))
+	c.Assert(recorder.Body.String(), check.Matches, `(?s).*credentials rotation is not supported by the service.*`)
+	c.Assert(eventtest.EventDesc{
+		Target: serviceInstanceTarget("mysql", "my-mysql"),
+		ExtraTargets: []event.ExtraTarget{
+			{Target: appTarget("app-instance"), Lock: true},
+		},
+		Owner:        s.token.GetUserName(),
+		Kind:         "service-instance.update.rotate",
+		ErrorMatches: `.*credentials rotation is not supported by the service.*`,
+	}, eventtest.HasEvent)
+}
@@ -0,0 +1,425 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package specgen generates the handler specs used by the api package to
+// build its OpenAPI document. Specs are read from the doc comment of each
+// handler, in the format:
+//
+//   // title: app list
+//   // path: /apps
+//   // method: GET
+//   // consume: application/x-www-form-urlencoded
+//   // produce: application/json
+//   // request: app.App
+//   // response: []app.App
+//   // responses:
+//   //   200: List apps
+//   //   204: No content
+//
+// The request and response lines are optional Go type expressions, resolved
+// using the imports of the file declaring the handler. Permissions are taken
+// from the permission.Perm* schemes passed to permission.Check and
+// permission.CheckFromPermList in the body of the handler.
+package specgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"go/types"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const permissionImportPath = "github.com/tsuru/tsuru/permission"
+
+var (
+	permRegexp     = regexp.MustCompile(`^Perm[A-Z]`)
+	responseRegexp = regexp.MustCompile(`^(\d{3}):\s*(.*)$`)
+
+	// checkFuncs are the permission functions whose scheme argument is
+	// required by a handler.
+	checkFuncs = map[string]bool{"Check": true, "CheckFromPermList": true}
+)
+
+// Package is a Go package whose handlers are documented.
+type Package struct {
+	ImportPath string
+	Dir        string
+}
+
+// Response is a documented response of a handler.
+type Response struct {
+	Code        int
+	Description string
+}
+
+// Handler is the spec of a handler, as read from its doc comment.
+type Handler struct {
+	Name        string
+	Title       string
+	Path        string
+	Method      string
+	Consumes    []string
+	Produces    []string
+	Request     ast.Expr
+	Response    ast.Expr
+	Permissions []string
+	Responses   []Response
+}
+
+type generator struct {
+	target  string
+	fset    *token.FileSet
+	imports map[string]string
+	aliases map[string]string
+}
+
+// Parse reads the specs of all handlers in pkg.
+func Parse(pkg Package) ([]Handler, error) {
+	g := &generator{fset: token.NewFileSet()}
+	return g.parse(pkg)
+}
+
+// Generate returns the source of a Go file in the package named by target,
+// declaring the handlerSpecs map with the specs of all handlers in pkgs.
+func Generate(target string, pkgs []Package) ([]byte, error) {
+	g := &generator{
+		target:  target,
+		fset:    token.NewFileSet(),
+		imports: map[string]string{},
+		aliases: map[string]string{},
+	}
+	var handlers []Handler
+	for _, pkg := range pkgs {
+		pkgHandlers, err := g.parse(pkg)
+		if err != nil {
+			return nil, err
+		}
+		handlers = append(handlers, pkgHandlers...)
+	}
+	sort.Slice(handlers, func(i, j int) bool {
+		return handlers[i].Name < handlers[j].Name
+	})
+	var body bytes.Buffer
+	body.WriteString("var handlerSpecs = map[string]*handlerSpec{\n")
+	for _, h := range handlers {
+		err := g.writeHandler(&body, h)
+		if err != nil {
+			return nil, err
+		}
+	}
+	body.WriteString("}\n")
+	var out bytes.Buffer
+	out.WriteString(`// AUTOMATICALLY GENERATED FILE - DO NOT EDIT!
+// Please run 'go generate' to update this file.
+//
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+`)
+	fmt.Fprintf(&out, "package %s\n\n", path.Base(target))
+	if len(g.imports) > 0 {
+		var std, others []string
+		for p := range g.imports {
+			if strings.Contains(strings.SplitN(p, "/", 2)[0], ".") {
+				others = append(others, p)
+			} else {
+				std = append(std, p)
+			}
+		}
+		sort.Strings(std)
+		sort.Strings(others)
+		out.WriteString("import (\n")
+		for i, group := range [][]string{std, others} {
+			if i > 0 && len(std) > 0 && len(others) > 0 {
+				out.WriteString("\n")
+			}
+			for _, p := range group {
+				alias := g.imports[p]
+				if alias == path.Base(p) {
+					fmt.Fprintf(&out, "\t%q\n", p)
+				} else {
+					fmt.Fprintf(&out, "\t%s %q\n", alias, p)
+				}
+			}
+		}
+		out.WriteString(")\n\n")
+	}
+	out.Write(body.Bytes())
+	return format.Source(out.Bytes())
+}
+
+func (g *generator) parse(pkg Package) ([]Handler, error) {
+	filter := func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}
+	parsed, err := parser.ParseDir(g.fset, pkg.Dir, filter, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	var handlers []Handler
+	for _, p := range parsed {
+		for _, f := range p.Files {
+			fileImports := importsForFile(f)
+			for _, decl := range f.Decls {
+				fn, ok := decl.(*ast.FuncDecl)
+				if !ok || fn.Recv != nil || fn.Doc == nil {
+					continue
+				}
+				h, err := parseDoc(fn.Doc)
+				if err != nil {
+					return nil, errors.Wrapf(err, "invalid doc for handler %s", fn.Name.Name)
+				}
+				if h == nil {
+					continue
+				}
+				h.Name = pkg.ImportPath + "." + fn.Name.Name
+				if g.imports != nil {
+					for _, expr := range []*ast.Expr{&h.Request, &h.Response} {
+						if *expr == nil {
+							continue
+						}
+						*expr, err = g.resolveType(*expr, pkg, fileImports)
+						if err != nil {
+							return nil, errors.Wrapf(err, "invalid type in doc for handler %s", fn.Name.Name)
+						}
+					}
+				}
+				h.Permissions = permissionsUsed(fn.Body, fileImports)
+				handlers = append(handlers, *h)
+			}
+		}
+	}
+	return handlers, nil
+}
+
+func parseDoc(doc *ast.CommentGroup) (*Handler, error) {
+	var h Handler
+	var inResponses bool
+	for _, c := range doc.List {
+		line := strings.TrimPrefix(c.Text, "//")
+		trimmed := strings.TrimSpace(line)
+		if inResponses {
+			if m := responseRegexp.FindStringSubmatch(trimmed); m != nil {
+				code, _ := strconv.Atoi(m[1])
+				h.Responses = append(h.Responses, Response{Code: code, Description: m[2]})
+				continue
+			}
+			inResponses = false
+		}
+		parts := strings.SplitN(trimmed, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		value := strings.TrimSpace(parts[1])
+		switch parts[0] {
+		case "title":
+			h.Title = value
+		case "path":
+			h.Path = value
+		case "method":
+			h.Method = strings.ToUpper(value)
+		case "consume":
+			h.Consumes = mediaTypes(value)
+		case "produce":
+			h.Produces = mediaTypes(value)
+		case "request", "response":
+			expr, err := parser.ParseExpr(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid %s type %q", parts[0], value)
+			}
+			if parts[0] == "request" {
+				h.Request = expr
+			} else {
+				h.Response = expr
+			}
+		case "responses":
+			inResponses = true
+		}
+	}
+	if h.Title == "" {
+		return nil, nil
+	}
+	return &h, nil
+}
+
+func mediaTypes(value string) []string {
+	var result []string
+	for _, part := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if strings.Contains(part, "/") {
+			result = append(result, part)
+		}
+	}
+	return result
+}
+
+func importsForFile(f *ast.File) map[string]string {
+	imports := map[string]string{}
+	for _, spec := range f.Imports {
+		p, _ := strconv.Unquote(spec.Path.Value)
+		name := packageName(p)
+		if spec.Name != nil {
+			name = spec.Name.Name
+		}
+		imports[name] = p
+	}
+	return imports
+}
+
+func packageName(importPath string) string {
+	name := path.Base(importPath)
+	if idx := strings.Index(name, "."); idx > 0 && strings.HasPrefix(importPath, "gopkg.in/") {
+		name = name[:idx]
+	}
+	return strings.Replace(name, "-", "_", -1)
+}
+
+func permissionsUsed(body *ast.BlockStmt, fileImports map[string]string) []string {
+	if body == nil {
+		return nil
+	}
+	var perms []string
+	seen := map[string]bool{}
+	ast.Inspect(body, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || len(call.Args) < 2 || !isPermissionSelector(call.Fun, fileImports, checkFuncs) {
+			return true
+		}
+		scheme, ok := call.Args[1].(*ast.SelectorExpr)
+		if !ok || !isPermissionSelector(scheme, fileImports, nil) || !permRegexp.MatchString(scheme.Sel.Name) {
+			return true
+		}
+		if !seen[scheme.Sel.Name] {
+			seen[scheme.Sel.Name] = true
+			perms = append(perms, scheme.Sel.Name)
+		}
+		return true
+	})
+	return perms
+}
+
+// isPermissionSelector returns whether expr selects a name from the
+// permission package, restricted to names when it's not nil.
+func isPermissionSelector(expr ast.Expr, fileImports map[string]string, names map[string]bool) bool {
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok {
+		return false
+	}
+	ident, ok := sel.X.(*ast.Ident)
+	if !ok || fileImports[ident.Name] != permissionImportPath {
+		return false
+	}
+	return names == nil || names[sel.Sel.Name]
+}
+
+// resolveType rewrites the package qualifiers in expr to the aliases used by
+// the generated file, registering the required imports.
+func (g *generator) resolveType(expr ast.Expr, pkg Package, fileImports map[string]string) (ast.Expr, error) {
+	var err error
+	ast.Inspect(expr, func(n ast.Node) bool {
+		if err != nil {
+			return false
+		}
+		switch n := n.(type) {
+		case *ast.SelectorExpr:
+			ident, ok := n.X.(*ast.Ident)
+			if !ok {
+				err = errors.Errorf("unsupported type selector %v", n.X)
+				return false
+			}
+			importPath, ok := fileImports[ident.Name]
+			if !ok {
+				err = errors.Errorf("unknown package %q", ident.Name)
+				return false
+			}
+			ident.Name = g.importAlias(importPath, ident.Name)
+			return false
+		case *ast.Ident:
+			if types.Universe.Lookup(n.Name) == nil && pkg.ImportPath != g.target {
+				err = errors.Errorf("type %q must be qualified", n.Name)
+				return false
+			}
+		}
+		return true
+	})
+	return expr, err
+}
+
+func (g *generator) importAlias(importPath, preferred string) string {
+	if alias, ok := g.imports[importPath]; ok {
+		return alias
+	}
+	alias := preferred
+	for i := 2; g.aliases[alias] != ""; i++ {
+		alias = fmt.Sprintf("%s%d", preferred, i)
+	}
+	g.imports[importPath] = alias
+	g.aliases[alias] = importPath
+	return alias
+}
+
+func (g *generator) typeOf(expr ast.Expr) (string, error) {
+	var buf bytes.Buffer
+	err := printer.Fprint(&buf, token.NewFileSet(), expr)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.TypeOf((*%s)(nil)).Elem()", g.importAlias("reflect", "reflect"), buf.String()), nil
+}
+
+func (g *generator) writeHandler(w *bytes.Buffer, h Handler) error {
+	fmt.Fprintf(w, "%q: {\n", h.Name)
+	fmt.Fprintf(w, "Title: %q,\n", h.Title)
+	if len(h.Consumes) > 0 {
+		fmt.Fprintf(w, "Consumes: %#v,\n", h.Consumes)
+	}
+	if len(h.Produces) > 0 {
+		fmt.Fprintf(w, "Produces: %#v,\n", h.Produces)
+	}
+	for _, t := range []struct {
+		field string
+		expr  ast.Expr
+	}{{"Request", h.Request}, {"Response", h.Response}} {
+		if t.expr == nil {
+			continue
+		}
+		typeOf, err := g.typeOf(t.expr)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "%s: %s,\n", t.field, typeOf)
+	}
+	if len(h.Permissions) > 0 {
+		alias := g.importAlias(permissionImportPath, "permission")
+		fmt.Fprintf(w, "Permissions: []*%s.PermissionScheme{", alias)
+		for i, p := range h.Permissions {
+			if i > 0 {
+				w.WriteString(", ")
+			}
+			fmt.Fprintf(w, "%s.%s", alias, p)
+		}
+		w.WriteString("},\n")
+	}
+	if len(h.Responses) > 0 {
+		w.WriteString("Responses: []responseSpec{\n")
+		for _, r := range h.Responses {
+			fmt.Fprintf(w, "{Code: %d, Description: %q},\n", r.Code, r.Description)
+		}
+		w.WriteString("},\n")
+	}
+	w.WriteString("},\n")
+	return nil
+}
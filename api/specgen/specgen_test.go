@@ -0,0 +1,103 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package specgen
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"gopkg.in/check.v1"
+)
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+var testPackage = Package{ImportPath: "github.com/tsuru/tsuru/api/specgen/testdata", Dir: "testdata"}
+
+func (s *S) TestParse(c *check.C) {
+	handlers, err := Parse(testPackage)
+	c.Assert(err, check.IsNil)
+	c.Assert(handlers, check.HasLen, 2)
+	c.Assert(handlers[0].Name, check.Equals, "github.com/tsuru/tsuru/api/specgen/testdata.planList")
+	c.Assert(handlers[0].Title, check.Equals, "plan list")
+	c.Assert(handlers[0].Path, check.Equals, "/plans")
+	c.Assert(handlers[0].Method, check.Equals, "GET")
+	c.Assert(handlers[0].Consumes, check.DeepEquals, []string{"application/x-www-form-urlencoded"})
+	c.Assert(handlers[0].Produces, check.DeepEquals, []string{"application/json", "text/plain"})
+	c.Assert(handlers[0].Request, check.NotNil)
+	c.Assert(handlers[0].Response, check.NotNil)
+	c.Assert(handlers[0].Permissions, check.DeepEquals, []string{"PermPlanRead"})
+	c.Assert(handlers[0].Responses, check.DeepEquals, []Response{
+		{Code: 200, Description: "OK"},
+		{Code: 204, Description: "No content"},
+	})
+	c.Assert(handlers[1].Name, check.Equals, "github.com/tsuru/tsuru/api/specgen/testdata.planRemove")
+	c.Assert(handlers[1].Method, check.Equals, "DELETE")
+	c.Assert(handlers[1].Request, check.IsNil)
+	c.Assert(handlers[1].Permissions, check.DeepEquals, []string{"PermPlanDelete"})
+}
+
+func (s *S) TestGenerate(c *check.C) {
+	data, err := Generate(testPackage.ImportPath, []Package{testPackage})
+	c.Assert(err, check.IsNil)
+	expected := `// AUTOMATICALLY GENERATED FILE - DO NOT EDIT!
+// Please run 'go generate' to update this file.
+//
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testdata
+
+import (
+	"reflect"
+
+	"github.com/tsuru/tsuru/permission"
+	appTypes "github.com/tsuru/tsuru/types/app"
+)
+
+var handlerSpecs = map[string]*handlerSpec{
+	"github.com/tsuru/tsuru/api/specgen/testdata.planList": {
+		Title:       "plan list",
+		Consumes:    []string{"application/x-www-form-urlencoded"},
+		Produces:    []string{"application/json", "text/plain"},
+		Request:     reflect.TypeOf((*planFilter)(nil)).Elem(),
+		Response:    reflect.TypeOf((*[]appTypes.Plan)(nil)).Elem(),
+		Permissions: []*permission.PermissionScheme{permission.PermPlanRead},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+			{Code: 204, Description: "No content"},
+		},
+	},
+	"github.com/tsuru/tsuru/api/specgen/testdata.planRemove": {
+		Title:       "plan remove",
+		Permissions: []*permission.PermissionScheme{permission.PermPlanDelete},
+		Responses: []responseSpec{
+			{Code: 200, Description: "OK"},
+		},
+	},
+}
+`
+	c.Assert(string(data), check.Equals, expected)
+}
+
+func (s *S) TestGenerateUnqualifiedTypeFromOtherPackage(c *check.C) {
+	_, err := Generate("github.com/tsuru/tsuru/api", []Package{testPackage})
+	c.Assert(err, check.ErrorMatches, `invalid type in doc for handler planList: type "planFilter" must be qualified`)
+}
+
+func (s *S) TestHandlerSpecsUpToDate(c *check.C) {
+	data, err := Generate("github.com/tsuru/tsuru/api", []Package{
+		{ImportPath: "github.com/tsuru/tsuru/api", Dir: ".."},
+		{ImportPath: "github.com/tsuru/tsuru/provision/docker", Dir: "../../provision/docker"},
+	})
+	c.Assert(err, check.IsNil)
+	current, err := ioutil.ReadFile("../handlerspecs.go")
+	c.Assert(err, check.IsNil)
+	c.Assert(string(data), check.Equals, string(current), check.Commentf("api/handlerspecs.go is outdated, run go generate in the api directory"))
+}
@@ -0,0 +1,51 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package testdata
+
+import (
+	"net/http"
+
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	appTypes "github.com/tsuru/tsuru/types/app"
+)
+
+type planFilter struct {
+	Name string
+}
+
+// title: plan list
+// path: /plans
+// method: GET
+// consume: application/x-www-form-urlencoded
+// produce: application/json or text/plain
+// request: planFilter
+// response: []appTypes.Plan
+// responses:
+//   200: OK
+//   204: No content
+func planList(w http.ResponseWriter, r *http.Request) error {
+	if !permission.Check(nil, permission.PermPlanRead) {
+		return permission.ErrUnauthorized
+	}
+	permission.Check(nil, permission.PermPlanRead)
+	return nil
+}
+
+// title: plan remove
+// path: /plans/{name}
+// method: delete
+// responses:
+//   200: OK
+func planRemove(w http.ResponseWriter, r *http.Request) error {
+	if !permission.CheckFromPermList(nil, permission.PermPlanDelete) {
+		return permission.ErrUnauthorized
+	}
+	event.Allowed(permission.PermPlanReadEvents)
+	return nil
+}
+
+// helper is not a handler.
+func helper() {}
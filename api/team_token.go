@@ -13,15 +13,18 @@ import (
 	"github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/ratelimit"
 	"github.com/tsuru/tsuru/servicemanager"
 	authTypes "github.com/tsuru/tsuru/types/auth"
 	permTypes "github.com/tsuru/tsuru/types/permission"
+	ratelimitTypes "github.com/tsuru/tsuru/types/ratelimit"
 )
 
 // title: token list
 // path: /tokens
 // method: GET
 // produce: application/json
+// response: []authTypes.TeamToken
 // responses:
 //   200: List tokens
 //   204: No content
@@ -45,6 +48,7 @@ func tokenList(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 // produce: application/json
 // responses:
 //   201: Token created
+//   400: Invalid data
 //   401: Unauthorized
 //   409: Token already exists
 func tokenCreate(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
@@ -69,6 +73,10 @@ func tokenCreate(w http.ResponseWriter, r *http.Request, t auth.Token) (err erro
 	if !allowed {
 		return permission.ErrUnauthorized
 	}
+	err = checkTokenRateLimit(args.RequestsPerMinute, args.Burst)
+	if err != nil {
+		return err
+	}
 	evt, err := event.New(&event.Opts{
 		Target:     teamTarget(args.Team),
 		Kind:       permission.PermTeamTokenCreate,
@@ -82,14 +90,17 @@ func tokenCreate(w http.ResponseWriter, r *http.Request, t auth.Token) (err erro
 	defer func() { evt.Done(err) }()
 	token, err := servicemanager.TeamToken.Create(args, t)
 	if err != nil {
-		return err
-	}
-	if err != nil {
-		if err == authTypes.ErrTeamTokenAlreadyExists {
+		switch err {
+		case authTypes.ErrTeamTokenAlreadyExists:
 			return &errors.HTTP{
 				Code:    http.StatusConflict,
 				Message: err.Error(),
 			}
+		case authTypes.ErrTeamTokenInvalidLimit:
+			return &errors.HTTP{
+				Code:    http.StatusBadRequest,
+				Message: err.Error(),
+			}
 		}
 		return err
 	}
@@ -97,12 +108,26 @@ func tokenCreate(w http.ResponseWriter, r *http.Request, t auth.Token) (err erro
 	return json.NewEncoder(w).Encode(token)
 }
 
+// checkTokenRateLimit ensures team tokens can only lower the configured per
+// token rate limit, never raise it.
+func checkTokenRateLimit(requestsPerMinute, burst int) error {
+	limit := ratelimitTypes.Limit{RequestsPerMinute: requestsPerMinute, Burst: burst}
+	if limit.Enabled() && limit.Exceeds(ratelimit.TokenLimit()) {
+		return &errors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: authTypes.ErrTeamTokenLimitTooHigh.Error(),
+		}
+	}
+	return nil
+}
+
 // title: token update
 // path: /tokens/{token_id}
 // method: PUT
 // produce: application/json
 // responses:
 //   200: Token updated
+//   400: Invalid data
 //   401: Unauthorized
 //   404: Token not found
 func tokenUpdate(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
@@ -132,6 +157,10 @@ func tokenUpdate(w http.ResponseWriter, r *http.Request, t auth.Token) (err erro
 	if !allowed {
 		return permission.ErrUnauthorized
 	}
+	err = checkTokenRateLimit(args.RequestsPerMinute, args.Burst)
+	if err != nil {
+		return err
+	}
 	evt, err := event.New(&event.Opts{
 		Target:     teamTarget(teamToken.Team),
 		Kind:       permission.PermTeamTokenUpdate,
@@ -150,6 +179,12 @@ func tokenUpdate(w http.ResponseWriter, r *http.Request, t auth.Token) (err erro
 			Message: err.Error(),
 		}
 	}
+	if err == authTypes.ErrTeamTokenInvalidLimit {
+		return &errors.HTTP{
+			Code:    http.StatusBadRequest,
+			Message: err.Error(),
+		}
+	}
 	if err != nil {
 		return err
 	}
@@ -15,9 +15,11 @@ import (
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/event/eventtest"
 	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/ratelimit"
 	"github.com/tsuru/tsuru/servicemanager"
 	authTypes "github.com/tsuru/tsuru/types/auth"
 	permTypes "github.com/tsuru/tsuru/types/permission"
+	ratelimitTypes "github.com/tsuru/tsuru/types/ratelimit"
 	check "gopkg.in/check.v1"
 )
 
@@ -157,6 +159,25 @@ func (s *S) TestTeamTokenCreateNoPermission(c *check.C) {
 	c.Assert(recorder.Code, check.Equals, http.StatusForbidden, check.Commentf("body: %q", recorder.Body.String()))
 }
 
+func (s *S) TestTeamTokenCreateRateLimitAboveTokenLimit(c *check.C) {
+	ratelimit.SetLimiter(&ratelimit.Limiter{
+		Storage: ratelimit.NewMemoryStorage(),
+		Token:   ratelimitTypes.Limit{RequestsPerMinute: 60, Burst: 10},
+	})
+	defer ratelimit.SetLimiter(nil)
+	body := strings.NewReader(`token_id=t1&requests_per_minute=120&team=` + s.team.Name)
+	request, err := http.NewRequest("POST", "/1.6/tokens", body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Authorization", "bearer "+s.token.GetValue())
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, authTypes.ErrTeamTokenLimitTooHigh.Error()+"\n")
+	_, err = servicemanager.TeamToken.FindByTokenID("t1")
+	c.Assert(err, check.Equals, authTypes.ErrTeamTokenNotFound)
+}
+
 func (s *S) TestTeamTokenDelete(c *check.C) {
 	_, err := servicemanager.TeamToken.Create(authTypes.TeamTokenCreateArgs{
 		Team:    s.team.Name,
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/ajg/form"
+	pkgErrors "github.com/pkg/errors"
 	"github.com/tsuru/tsuru/auth"
 	"github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
@@ -50,6 +51,7 @@ func contextsForVolume(v *volume.Volume) []permTypes.PermissionContext {
 // path: /volumes
 // method: GET
 // produce: application/json
+// response: []volume.Volume
 // responses:
 //   200: List volumes
 //   204: No content
@@ -76,6 +78,7 @@ func volumesList(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 // path: /volumes/{name}
 // method: GET
 // produce: application/json
+// response: volume.Volume
 // responses:
 //   200: Show volume
 //   401: Unauthorized
@@ -384,3 +387,188 @@ func volumeUnbind(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 	writer := &tsuruIo.SimpleJsonMessageEncoderWriter{Encoder: json.NewEncoder(keepAliveWriter)}
 	return a.Restart("", writer)
 }
+
+// title: volume snapshot create
+// path: /volumes/{name}/snapshots
+// method: POST
+// produce: application/json
+// responses:
+//   201: Snapshot created
+//   400: Invalid data
+//   401: Unauthorized
+//   404: Volume not found
+//   409: Snapshot already exists
+func volumeSnapshotCreate(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	err = r.ParseForm()
+	if err != nil {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	dbVolume, err := volume.Load(r.URL.Query().Get(":name"))
+	if err != nil {
+		if err == volume.ErrVolumeNotFound {
+			return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+		}
+		return err
+	}
+	canSnapshot := permission.Check(t, permission.PermVolumeUpdateSnapshot, contextsForVolume(dbVolume)...)
+	if !canSnapshot {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     event.Target{Type: event.TargetTypeVolume, Value: dbVolume.Name},
+		Kind:       permission.PermVolumeUpdateSnapshot,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermVolumeReadEvents, contextsForVolume(dbVolume)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	snap, err := dbVolume.CreateSnapshot(r.FormValue("name"), evt.UniqueID.Hex())
+	if err != nil {
+		switch pkgErrors.Cause(err) {
+		case volume.ErrVolumeSnapshotAlreadyExists:
+			return &errors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+		case volume.ErrVolumeSnapshotNotSupported, volume.ErrVolumeSnapshotDriverNotSupported:
+			return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+		}
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	return json.NewEncoder(w).Encode(snap)
+}
+
+// title: volume snapshot list
+// path: /volumes/{name}/snapshots
+// method: GET
+// produce: application/json
+// responses:
+//   200: List snapshots
+//   204: No content
+//   401: Unauthorized
+//   404: Volume not found
+func volumeSnapshotList(w http.ResponseWriter, r *http.Request, t auth.Token) error {
+	dbVolume, err := volume.Load(r.URL.Query().Get(":name"))
+	if err != nil {
+		if err == volume.ErrVolumeNotFound {
+			return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+		}
+		return err
+	}
+	canRead := permission.Check(t, permission.PermVolumeRead, contextsForVolume(dbVolume)...)
+	if !canRead {
+		return permission.ErrUnauthorized
+	}
+	snapshots, err := dbVolume.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(snapshots)
+}
+
+// title: volume restore
+// path: /volumes/{name}/restore
+// method: POST
+// produce: application/json
+// responses:
+//   200: Volume restored
+//   400: Invalid data
+//   401: Unauthorized
+//   404: Volume or snapshot not found
+//   409: Volume has binds
+func volumeRestore(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	err = r.ParseForm()
+	if err != nil {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	snapshotName := r.FormValue("snapshot")
+	if snapshotName == "" {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: "snapshot name is required"}
+	}
+	dbVolume, err := volume.Load(r.URL.Query().Get(":name"))
+	if err != nil {
+		if err == volume.ErrVolumeNotFound {
+			return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+		}
+		return err
+	}
+	canRestore := permission.Check(t, permission.PermVolumeUpdateRestore, contextsForVolume(dbVolume)...)
+	if !canRestore {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     event.Target{Type: event.TargetTypeVolume, Value: dbVolume.Name},
+		Kind:       permission.PermVolumeUpdateRestore,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermVolumeReadEvents, contextsForVolume(dbVolume)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = dbVolume.RestoreSnapshot(snapshotName)
+	switch pkgErrors.Cause(err) {
+	case volume.ErrVolumeSnapshotNotFound:
+		return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+	case volume.ErrVolumeRestoreWithBinds:
+		return &errors.HTTP{Code: http.StatusConflict, Message: err.Error()}
+	case volume.ErrVolumeSnapshotNotSupported, volume.ErrVolumeSnapshotDriverNotSupported:
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	return err
+}
+
+// title: volume resize
+// path: /volumes/{name}/resize
+// method: POST
+// produce: application/json
+// responses:
+//   200: Volume resized
+//   400: Invalid data
+//   401: Unauthorized
+//   404: Volume not found
+func volumeResize(w http.ResponseWriter, r *http.Request, t auth.Token) (err error) {
+	err = r.ParseForm()
+	if err != nil {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	capacity := r.FormValue("capacity")
+	if capacity == "" {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: "capacity is required"}
+	}
+	dbVolume, err := volume.Load(r.URL.Query().Get(":name"))
+	if err != nil {
+		if err == volume.ErrVolumeNotFound {
+			return &errors.HTTP{Code: http.StatusNotFound, Message: err.Error()}
+		}
+		return err
+	}
+	canResize := permission.Check(t, permission.PermVolumeUpdateResize, contextsForVolume(dbVolume)...)
+	if !canResize {
+		return permission.ErrUnauthorized
+	}
+	evt, err := event.New(&event.Opts{
+		Target:     event.Target{Type: event.TargetTypeVolume, Value: dbVolume.Name},
+		Kind:       permission.PermVolumeUpdateResize,
+		Owner:      t,
+		CustomData: event.FormToCustomData(r.Form),
+		Allowed:    event.Allowed(permission.PermVolumeReadEvents, contextsForVolume(dbVolume)...),
+	})
+	if err != nil {
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	err = dbVolume.Resize(capacity)
+	if err == volume.ErrVolumeResizeNotSupported {
+		return &errors.HTTP{Code: http.StatusBadRequest, Message: err.Error()}
+	}
+	return err
+}
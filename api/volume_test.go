@@ -13,9 +13,14 @@ import (
 
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/app"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/permission/permissiontest"
+	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/provision/pool"
+	"github.com/tsuru/tsuru/provision/provisiontest"
 	authTypes "github.com/tsuru/tsuru/types/auth"
 	permTypes "github.com/tsuru/tsuru/types/permission"
 	"github.com/tsuru/tsuru/volume"
@@ -555,3 +560,246 @@ func (s *S) TestVolumeUnbindNoRestart(c *check.C) {
 	c.Assert(recorder.Code, check.Equals, http.StatusOK)
 	c.Assert(recorder.Body.String(), check.Equals, "")
 }
+
+func (s *S) TestVolumeSnapshotCreate(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	url := "/1.7/volumes/v1/snapshots"
+	body := strings.NewReader(`name=snap1`)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusCreated)
+	c.Assert(recorder.Header().Get("Content-Type"), check.Equals, "application/json")
+	var result volume.VolumeSnapshot
+	err = json.Unmarshal(recorder.Body.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result.ID, check.Equals, volume.VolumeSnapshotID{Volume: "v1", Name: "snap1"})
+	c.Assert(result.Size, check.Equals, int64(provisiontest.FakeSnapshotSize))
+	c.Assert(result.EventID, check.Not(check.Equals), "")
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeVolume, Value: "v1"},
+		Owner:  s.token.GetUserName(),
+		Kind:   "volume.update.snapshot",
+		StartCustomData: []map[string]interface{}{
+			{"name": "name", "value": "snap1"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestVolumeSnapshotCreateConflict(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	_, err = v1.CreateSnapshot("snap1", "")
+	c.Assert(err, check.IsNil)
+	url := "/1.7/volumes/v1/snapshots"
+	body := strings.NewReader(`name=snap1`)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusConflict)
+}
+
+func (s *S) TestVolumeSnapshotList(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	_, err = v1.CreateSnapshot("snap1", "evt1")
+	c.Assert(err, check.IsNil)
+	url := "/1.7/volumes/v1/snapshots"
+	request, err := http.NewRequest("GET", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	var result []volume.VolumeSnapshot
+	err = json.Unmarshal(recorder.Body.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.HasLen, 1)
+	c.Assert(result[0].ID.Name, check.Equals, "snap1")
+	c.Assert(result[0].Size, check.Equals, int64(provisiontest.FakeSnapshotSize))
+	c.Assert(result[0].EventID, check.Equals, "evt1")
+}
+
+func (s *S) TestVolumeSnapshotListEmpty(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	url := "/1.7/volumes/v1/snapshots"
+	request, err := http.NewRequest("GET", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNoContent)
+}
+
+func (s *S) TestVolumeRestore(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	_, err = v1.CreateSnapshot("snap1", "")
+	c.Assert(err, check.IsNil)
+	url := "/1.7/volumes/v1/restore"
+	body := strings.NewReader(`snapshot=snap1`)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(s.provisioner.RestoredSnapshot("v1"), check.Equals, "snap1")
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeVolume, Value: "v1"},
+		Owner:  s.token.GetUserName(),
+		Kind:   "volume.update.restore",
+		StartCustomData: []map[string]interface{}{
+			{"name": "snapshot", "value": "snap1"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestVolumeRestoreSnapshotNotFound(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	url := "/1.7/volumes/v1/restore"
+	body := strings.NewReader(`snapshot=snap1`)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusNotFound)
+}
+
+func (s *S) TestVolumeRestoreWithBinds(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	_, err = v1.CreateSnapshot("snap1", "")
+	c.Assert(err, check.IsNil)
+	err = v1.BindApp("myapp", "/mnt1", false)
+	c.Assert(err, check.IsNil)
+	url := "/1.7/volumes/v1/restore"
+	body := strings.NewReader(`snapshot=snap1`)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusConflict)
+}
+
+func (s *S) TestVolumeResize(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	url := "/1.7/volumes/v1/resize"
+	body := strings.NewReader(`capacity=30Gi`)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	c.Assert(s.provisioner.ResizedVolume("v1"), check.Equals, "30Gi")
+	dbVolume, err := volume.Load("v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbVolume.Opts["capacity"], check.Equals, "30Gi")
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeVolume, Value: "v1"},
+		Owner:  s.token.GetUserName(),
+		Kind:   "volume.update.resize",
+		StartCustomData: []map[string]interface{}{
+			{"name": "capacity", "value": "30Gi"},
+		},
+	}, eventtest.HasEvent)
+}
+
+func (s *S) TestVolumeResizeMissingCapacity(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	url := "/1.7/volumes/v1/resize"
+	request, err := http.NewRequest("POST", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+}
+
+func (s *S) TestVolumeResizeInvalidCapacity(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	s.provisioner.PrepareFailure("ResizeVolume", &tsuruErrors.ValidationError{Message: "new capacity 10Gi must be greater than current capacity 20Gi"})
+	url := "/1.7/volumes/v1/resize"
+	body := strings.NewReader(`capacity=10Gi`)
+	request, err := http.NewRequest("POST", url, body)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusBadRequest)
+	c.Assert(recorder.Body.String(), check.Equals, "new capacity 10Gi must be greater than current capacity 20Gi\n")
+}
+
+func (s *S) TestVolumeInfoWithUsage(c *check.C) {
+	config.Set("volume-plans:nfs:fake:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v1 := volume.Volume{Name: "v1", Pool: s.Pool, TeamOwner: s.team.Name, Plan: volume.VolumePlan{Name: "nfs"}}
+	err := v1.Create()
+	c.Assert(err, check.IsNil)
+	s.provisioner.SetVolumeUsage("v1", provision.VolumeUsage{Used: 10, Available: 30})
+	err = volume.CollectUsage()
+	c.Assert(err, check.IsNil)
+	url := "/1.4/volumes/v1"
+	request, err := http.NewRequest("GET", url, nil)
+	c.Assert(err, check.IsNil)
+	request.Header.Set("authorization", "bearer "+s.token.GetValue())
+	recorder := httptest.NewRecorder()
+	s.testServer.ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, check.Equals, http.StatusOK)
+	var result volume.Volume
+	err = json.Unmarshal(recorder.Body.Bytes(), &result)
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Usage, check.NotNil)
+	c.Assert(result.Usage.Used, check.Equals, int64(10))
+	c.Assert(result.Usage.Available, check.Equals, int64(30))
+}
@@ -23,6 +23,7 @@ import (
 // path: /events/webhooks
 // method: GET
 // produce: application/json
+// response: []eventTypes.Webhook
 // responses:
 //   200: List webhooks
 //   204: No content
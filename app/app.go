@@ -1199,6 +1199,12 @@ func (app *App) validatePool() error {
 		if err != nil {
 			return err
 		}
+		for _, instance := range instances {
+			err = pool.ValidateServicePlan(instance.ServiceName, instance.PlanName)
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	return pool.ValidateRouters(app.GetRouters())
@@ -1223,6 +1229,16 @@ func (app *App) validateTeamOwner(p *pool.Pool) error {
 	return &tsuruErrors.ValidationError{Message: msg}
 }
 
+// ValidateServicePlan checks whether the plan of the service is available in
+// the app's pool.
+func (app *App) ValidateServicePlan(service, plan string) error {
+	pool, err := pool.GetPoolByName(app.Pool)
+	if err != nil {
+		return err
+	}
+	return pool.ValidateServicePlan(service, plan)
+}
+
 func (app *App) ValidateService(services ...string) error {
 	pool, err := pool.GetPoolByName(app.Pool)
 	if err != nil {
@@ -1720,6 +1736,33 @@ func (app *App) RemoveInstance(removeArgs bind.RemoveInstanceArgs) error {
 	return nil
 }
 
+func (app *App) ReplaceInstance(replaceArgs bind.ReplaceInstanceArgs) error {
+	envs := make([]bind.ServiceEnvVar, 0, len(app.ServiceEnvs)+len(replaceArgs.Envs))
+	for _, se := range app.ServiceEnvs {
+		if se.ServiceName != replaceArgs.ServiceName || se.InstanceName != replaceArgs.InstanceName {
+			envs = append(envs, se)
+		}
+	}
+	envs = append(envs, replaceArgs.Envs...)
+	if replaceArgs.Writer != nil {
+		fmt.Fprintf(replaceArgs.Writer, "---- Replacing %d environment variables ----\n", len(replaceArgs.Envs))
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.Apps().Update(bson.M{"name": app.Name}, bson.M{"$set": bson.M{"serviceenvs": envs}})
+	if err != nil {
+		return err
+	}
+	app.ServiceEnvs = envs
+	if replaceArgs.ShouldRestart {
+		return app.restartIfUnits(replaceArgs.Writer)
+	}
+	return nil
+}
+
 // Log adds a log message to the app. Specifying a good source is good so the
 // user can filter where the message come from.
 func (app *App) Log(message, source, unit string) error {
@@ -2151,6 +2151,41 @@ func (s *S) TestAddInstanceAndRemoveInstanceMultipleServices(c *check.C) {
 	})
 }
 
+func (s *S) TestReplaceInstance(c *check.C) {
+	a := &App{Name: "crimson", TeamOwner: s.team.Name}
+	err := CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	err = a.AddInstance(bind.AddInstanceArgs{
+		Envs: []bind.ServiceEnvVar{
+			{EnvVar: bind.EnvVar{Name: "DATABASE_HOST", Value: "host1"}, InstanceName: "instance1", ServiceName: "mysql"},
+			{EnvVar: bind.EnvVar{Name: "DATABASE_PASSWORD", Value: "old"}, InstanceName: "instance1", ServiceName: "mysql"},
+			{EnvVar: bind.EnvVar{Name: "REDIS_HOST", Value: "host2"}, InstanceName: "instance2", ServiceName: "redis"},
+		},
+		ShouldRestart: false,
+	})
+	c.Assert(err, check.IsNil)
+	var buf bytes.Buffer
+	err = a.ReplaceInstance(bind.ReplaceInstanceArgs{
+		ServiceName:  "mysql",
+		InstanceName: "instance1",
+		Envs: []bind.ServiceEnvVar{
+			{EnvVar: bind.EnvVar{Name: "DATABASE_HOST", Value: "host1"}, InstanceName: "instance1", ServiceName: "mysql"},
+			{EnvVar: bind.EnvVar{Name: "DATABASE_PASSWORD", Value: "new"}, InstanceName: "instance1", ServiceName: "mysql"},
+		},
+		Writer:        &buf,
+		ShouldRestart: false,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(buf.String(), check.Equals, "---- Replacing 2 environment variables ----\n")
+	dbApp, err := GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbApp.ServiceEnvs, check.DeepEquals, []bind.ServiceEnvVar{
+		{EnvVar: bind.EnvVar{Name: "REDIS_HOST", Value: "host2"}, InstanceName: "instance2", ServiceName: "redis"},
+		{EnvVar: bind.EnvVar{Name: "DATABASE_HOST", Value: "host1"}, InstanceName: "instance1", ServiceName: "mysql"},
+		{EnvVar: bind.EnvVar{Name: "DATABASE_PASSWORD", Value: "new"}, InstanceName: "instance1", ServiceName: "mysql"},
+	})
+}
+
 func (s *S) TestRemoveInstance(c *check.C) {
 	a := &App{Name: "dark", TeamOwner: s.team.Name}
 	err := CreateApp(a, s.user)
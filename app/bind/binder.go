@@ -45,6 +45,10 @@ type App interface {
 
 	// RemoveInstance removes an instance from the application.
 	RemoveInstance(args RemoveInstanceArgs) error
+
+	// ReplaceInstance atomically replaces the environment variables of an
+	// instance bound to the application.
+	ReplaceInstance(args ReplaceInstanceArgs) error
 }
 
 type SetEnvArgs struct {
@@ -71,3 +75,11 @@ type RemoveInstanceArgs struct {
 	Writer        io.Writer
 	ShouldRestart bool
 }
+
+type ReplaceInstanceArgs struct {
+	ServiceName   string
+	InstanceName  string
+	Envs          []ServiceEnvVar
+	Writer        io.Writer
+	ShouldRestart bool
+}
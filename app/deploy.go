@@ -23,7 +23,10 @@ import (
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/router/rebuild"
+	"github.com/tsuru/tsuru/servicemanager"
 	"github.com/tsuru/tsuru/set"
+	"github.com/tsuru/tsuru/tracing"
+	imageTypes "github.com/tsuru/tsuru/types/app/image"
 	permTypes "github.com/tsuru/tsuru/types/permission"
 )
 
@@ -170,6 +173,14 @@ type DeployOptions struct {
 	Event        *event.Event `bson:"-"`
 	Kind         DeployKind
 	Message      string
+	// AllowPlatformEOL allows builds using a platform version that has
+	// reached its end-of-life.
+	AllowPlatformEOL bool
+	// NoCache builds the app without restoring its build cache.
+	NoCache bool
+	// TraceID identifies the trace of the API request that triggered the
+	// deploy, spans of the deploy are added to it.
+	TraceID string `bson:"-"`
 }
 
 func (o *DeployOptions) GetOrigin() string {
@@ -219,6 +230,10 @@ func Build(opts DeployOptions) (string, error) {
 	if opts.App.GetPlatform() == "" {
 		return "", errors.Errorf("can't build app without platform")
 	}
+	err = checkPlatformVersion(&opts)
+	if err != nil {
+		return "", err
+	}
 	builder, ok := prov.(provision.BuilderDeploy)
 	if !ok {
 		return "", errors.Errorf("provisioner don't implement builder interface")
@@ -258,10 +273,13 @@ func (e *errorWithLog) Error() string {
 // Deploy runs a deployment of an application. It will first try to run an
 // archive based deploy (if opts.ArchiveURL is not empty), and then fallback to
 // the Git based deployment.
-func Deploy(opts DeployOptions) (string, error) {
+func Deploy(opts DeployOptions) (imageID string, err error) {
 	if opts.Event == nil {
 		return "", errors.Errorf("missing event in deploy opts")
 	}
+	span := tracing.StartSpan(opts.TraceID, "app.Deploy")
+	span.SetTag("app", opts.App.Name)
+	defer func() { span.Finish(err) }()
 	if opts.Rollback && !regexp.MustCompile(":v[0-9]+$").MatchString(opts.Image) {
 		imageName, err := image.GetAppImageBySuffix(opts.App.Name, opts.Image)
 		if err != nil {
@@ -273,8 +291,14 @@ func Deploy(opts DeployOptions) (string, error) {
 	logWriter.Async()
 	defer logWriter.Close()
 	opts.Event.SetLogWriter(io.MultiWriter(&tsuruIo.NoErrorWriter{Writer: opts.OutputStream}, &logWriter))
-	imageID, err := deployToProvisioner(&opts, opts.Event)
+	err = checkPlatformVersion(&opts)
+	if err != nil {
+		return "", err
+	}
+	imageID, err = deployToProvisioner(&opts, opts.Event)
+	routesSpan := tracing.StartSpan(opts.TraceID, "router.RebuildRoutes")
 	rebuild.RoutesRebuildOrEnqueue(opts.App.Name)
+	routesSpan.Finish(nil)
 	quotaErr := opts.App.fixQuota()
 	if quotaErr != nil {
 		log.Errorf("WARNING: unable to ensure quota is up-to-date after deploy: %v", quotaErr)
@@ -303,6 +327,61 @@ func Deploy(opts DeployOptions) (string, error) {
 	return imageID, nil
 }
 
+// PlatformVersionEOLError is returned when a build would use a platform
+// version that has reached its end-of-life.
+type PlatformVersionEOLError struct {
+	Platform string
+	Version  string
+}
+
+func (e *PlatformVersionEOLError) Error() string {
+	return fmt.Sprintf("platform %s:%s has reached its end-of-life, update the app platform or force the deploy", e.Platform, e.Version)
+}
+
+// checkPlatformVersion blocks builds using end-of-life platform versions,
+// unless explicitly allowed, and warns about deprecated ones. Deploys that
+// don't build on top of the platform image (image deploys, rollbacks and
+// rebuilds) are not affected.
+func checkPlatformVersion(opts *DeployOptions) error {
+	kind := opts.Kind
+	if kind == "" {
+		kind = opts.GetKind()
+	}
+	switch kind {
+	case DeployArchiveURL, DeployGit, DeployUpload, DeployUploadBuild:
+	default:
+		return nil
+	}
+	if opts.App.GetPlatform() == "" {
+		return nil
+	}
+	versionName := opts.App.GetPlatformVersion()
+	if versionName == "latest" {
+		var err error
+		versionName, err = currentPlatformVersion(opts.App.GetPlatform())
+		if err != nil {
+			return err
+		}
+	}
+	version, err := servicemanager.PlatformImage.FindVersion(opts.App.GetPlatform(), versionName)
+	if err == imageTypes.ErrPlatformVersionNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch version.Status {
+	case imageTypes.PlatformVersionEOL:
+		if !opts.AllowPlatformEOL {
+			return &PlatformVersionEOLError{Platform: version.Platform, Version: version.Version}
+		}
+		fmt.Fprintf(opts.Event, "WARNING: platform %s:%s has reached its end-of-life, deploying anyway.\n", version.Platform, version.Version)
+	case imageTypes.PlatformVersionDeprecated:
+		fmt.Fprintf(opts.Event, "WARNING: platform %s:%s is deprecated.\n", version.Platform, version.Version)
+	}
+	return nil
+}
+
 func RollbackUpdate(appName, imageID, reason string, disableRollback bool) error {
 	imgName, err := image.GetAppImageBySuffix(appName, imageID)
 	if err != nil {
@@ -329,16 +408,28 @@ func deployToProvisioner(opts *DeployOptions, evt *event.Event) (string, error)
 			if err != nil {
 				return "", err
 			}
-			return deployer.Deploy(opts.App, imageID, evt)
+			span := startProvisionerSpan(opts, prov, "provisioner.Deploy")
+			imageID, err = deployer.Deploy(opts.App, imageID, evt)
+			span.Finish(err)
+			return imageID, err
 		}
 	} else {
 		if deployer, ok := prov.(provision.RollbackableDeployer); ok {
-			return deployer.Rollback(opts.App, opts.Image, evt)
+			span := startProvisionerSpan(opts, prov, "provisioner.Rollback")
+			imageID, err := deployer.Rollback(opts.App, opts.Image, evt)
+			span.Finish(err)
+			return imageID, err
 		}
 	}
 	return "", provision.ProvisionerNotSupported{Prov: prov, Action: fmt.Sprintf("%s deploy", opts.Kind)}
 }
 
+func startProvisionerSpan(opts *DeployOptions, prov provision.Provisioner, operation string) *tracing.Span {
+	span := tracing.StartSpan(opts.TraceID, operation)
+	span.SetTag("provisioner", prov.GetName())
+	return span
+}
+
 func builderDeploy(prov provision.BuilderDeploy, opts *DeployOptions, evt *event.Event) (string, error) {
 	isRebuild := opts.Kind == DeployRebuild
 	buildOpts := builder.BuildOpts{
@@ -349,12 +440,16 @@ func builderDeploy(prov provision.BuilderDeploy, opts *DeployOptions, evt *event
 		Rebuild:       isRebuild,
 		ImageID:       opts.Image,
 		Tag:           opts.BuildTag,
+		NoCache:       opts.NoCache,
 	}
 	builder, err := opts.App.getBuilder()
 	if err != nil {
 		return "", err
 	}
+	span := tracing.StartSpan(opts.TraceID, "builder.Build")
+	span.SetTag("kind", string(opts.Kind))
 	img, err := builder.Build(prov, opts.App, evt, &buildOpts)
+	span.Finish(err)
 	if buildOpts.IsTsuruBuilderImage {
 		opts.Kind = DeployBuildedImage
 	}
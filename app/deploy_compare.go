@@ -0,0 +1,288 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// ErrNoCurrentDeploy is returned when comparing with the current deploy of an
+// app that was never deployed.
+var ErrNoCurrentDeploy = errors.New("app has no deployed image to compare")
+
+// DeployChange is a single value that differs between two deploys. An empty
+// From means the value was added, while an empty To means it was removed.
+type DeployChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// DeployComparison holds the differences between the images of two deploys
+// of an app.
+type DeployComparison struct {
+	App         string         `json:"app"`
+	From        string         `json:"from"`
+	To          string         `json:"to"`
+	Processes   []DeployChange `json:"processes"`
+	ExposedPort *DeployChange  `json:"exposedPort,omitempty"`
+	TsuruYaml   []DeployChange `json:"tsuruYaml"`
+	// RepositoryDiff joins the code diffs recorded by the git deploys made
+	// after the older of the two deploys, up to the newer one. When rolling
+	// back, it's the code being reverted.
+	RepositoryDiff string `json:"repositoryDiff,omitempty"`
+}
+
+// HasChanges returns whether the images differ in processes, exposed port
+// or tsuru.yaml.
+func (c *DeployComparison) HasChanges() bool {
+	return len(c.Processes) > 0 || c.ExposedPort != nil || len(c.TsuruYaml) > 0
+}
+
+func (c *DeployComparison) String() string {
+	var buf bytes.Buffer
+	if !c.HasChanges() {
+		fmt.Fprintf(&buf, "No changes in processes, exposed port or tsuru.yaml from %s to %s.\n", c.From, c.To)
+		return buf.String()
+	}
+	fmt.Fprintf(&buf, "Changes from %s to %s:\n", c.From, c.To)
+	for _, change := range c.Processes {
+		fmt.Fprintf(&buf, "  process %s: %q => %q\n", change.Field, change.From, change.To)
+	}
+	if c.ExposedPort != nil {
+		fmt.Fprintf(&buf, "  exposed port: %q => %q\n", c.ExposedPort.From, c.ExposedPort.To)
+	}
+	for _, change := range c.TsuruYaml {
+		fmt.Fprintf(&buf, "  tsuru.yaml %s: %q => %q\n", change.Field, change.From, change.To)
+	}
+	return buf.String()
+}
+
+// CompareDeploys returns the differences between two deploys of the app.
+// Each deploy may be referenced by its deploy ID, its image name or a suffix
+// of the image name, like its version. An empty from references the image
+// currently deployed.
+//
+// It returns ErrNoCurrentDeploy when from is empty and the app has no
+// deployed image.
+func CompareDeploys(app *App, from, to string) (*DeployComparison, error) {
+	fromImage, err := resolveDeployImage(app, from)
+	if from == "" && err == image.ErrNoImagesAvailable {
+		return nil, ErrNoCurrentDeploy
+	}
+	if err != nil {
+		return nil, err
+	}
+	toImage, err := resolveDeployImage(app, to)
+	if err != nil {
+		return nil, err
+	}
+	comparison := DeployComparison{
+		App:       app.Name,
+		From:      fromImage,
+		To:        toImage,
+		Processes: []DeployChange{},
+		TsuruYaml: []DeployChange{},
+	}
+	fromData, err := image.GetImageMetaData(fromImage)
+	if err != nil {
+		return nil, err
+	}
+	toData, err := image.GetImageMetaData(toImage)
+	if err != nil {
+		return nil, err
+	}
+	comparison.Processes = diffValues(processesMap(fromData.Processes), processesMap(toData.Processes))
+	if fromData.ExposedPort != toData.ExposedPort {
+		comparison.ExposedPort = &DeployChange{Field: "exposedPort", From: fromData.ExposedPort, To: toData.ExposedPort}
+	}
+	fromYaml, err := image.GetImageTsuruYamlData(fromImage)
+	if err != nil {
+		return nil, err
+	}
+	toYaml, err := image.GetImageTsuruYamlData(toImage)
+	if err != nil {
+		return nil, err
+	}
+	fromYamlMap, err := flattenTsuruYaml(fromYaml)
+	if err != nil {
+		return nil, err
+	}
+	toYamlMap, err := flattenTsuruYaml(toYaml)
+	if err != nil {
+		return nil, err
+	}
+	comparison.TsuruYaml = diffValues(fromYamlMap, toYamlMap)
+	comparison.RepositoryDiff, err = deployDiffBetween(app.Name, fromImage, toImage)
+	if err != nil {
+		return nil, err
+	}
+	return &comparison, nil
+}
+
+func resolveDeployImage(app *App, ref string) (string, error) {
+	if ref == "" {
+		return image.AppCurrentImageName(app.Name)
+	}
+	if bson.IsObjectIdHex(ref) {
+		evt, err := event.GetByID(bson.ObjectIdHex(ref))
+		if err != nil {
+			return "", err
+		}
+		if evt.Target != (event.Target{Type: event.TargetTypeApp, Value: app.Name}) {
+			return "", event.ErrEventNotFound
+		}
+		var endData map[string]string
+		err = evt.EndData(&endData)
+		if err != nil || endData["image"] == "" {
+			return "", errors.Errorf("deploy %s has no image", ref)
+		}
+		return endData["image"], nil
+	}
+	return image.GetAppImageBySuffix(app.Name, ref)
+}
+
+func deployEventForImage(appName, imageName string) (*event.Event, error) {
+	evts, err := event.List(&event.Filter{
+		Target:    event.Target{Type: event.TargetTypeApp, Value: appName},
+		KindType:  event.KindTypePermission,
+		KindNames: []string{permission.PermAppDeploy.FullName()},
+		Raw:       bson.M{"endcustomdata.image": imageName},
+		Limit:     1,
+	})
+	if err != nil || len(evts) == 0 {
+		return nil, err
+	}
+	return &evts[0], nil
+}
+
+func deployDiffBetween(appName, fromImage, toImage string) (string, error) {
+	if fromImage == toImage {
+		return "", nil
+	}
+	toEvt, err := deployEventForImage(appName, toImage)
+	if err != nil || toEvt == nil {
+		return "", err
+	}
+	fromEvt, err := deployEventForImage(appName, fromImage)
+	if err != nil {
+		return "", err
+	}
+	if fromEvt == nil {
+		return eventDiff(toEvt), nil
+	}
+	older, newer := fromEvt, toEvt
+	if newer.StartTime.Before(older.StartTime) {
+		older, newer = newer, older
+	}
+	evts, err := event.List(&event.Filter{
+		Target:    event.Target{Type: event.TargetTypeApp, Value: appName},
+		KindType:  event.KindTypePermission,
+		KindNames: []string{permission.PermAppDeploy.FullName()},
+		Since:     older.StartTime,
+		Until:     newer.StartTime,
+		Raw: bson.M{
+			"uniqueid":            bson.M{"$ne": older.UniqueID},
+			"endcustomdata.image": bson.M{"$nin": []interface{}{nil, ""}},
+		},
+		Sort: "starttime",
+	})
+	if err != nil {
+		return "", err
+	}
+	var diffs []string
+	for i := range evts {
+		if diff := eventDiff(&evts[i]); diff != "" {
+			diffs = append(diffs, diff)
+		}
+	}
+	return strings.Join(diffs, "\n"), nil
+}
+
+func eventDiff(evt *event.Event) string {
+	var otherData map[string]string
+	if evt.OtherData(&otherData) != nil {
+		return ""
+	}
+	return otherData["diff"]
+}
+
+func processesMap(processes map[string][]string) map[string]string {
+	result := make(map[string]string, len(processes))
+	for name, cmds := range processes {
+		result[name] = strings.Join(cmds, " ")
+	}
+	return result
+}
+
+// flattenTsuruYaml converts the tsuru.yaml data to a map with keys as they
+// appear in the tsuru.yaml file, e.g. healthcheck.path.
+func flattenTsuruYaml(data provision.TsuruYamlData) (map[string]string, error) {
+	raw, err := yaml.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var values map[interface{}]interface{}
+	err = yaml.Unmarshal(raw, &values)
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]string{}
+	flattenYamlValue("", values, result)
+	return result, nil
+}
+
+func flattenYamlValue(prefix string, value interface{}, result map[string]string) {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		for key, item := range v {
+			name := fmt.Sprint(key)
+			if prefix != "" {
+				name = prefix + "." + name
+			}
+			flattenYamlValue(name, item, result)
+		}
+	case []interface{}:
+		items := make([]string, len(v))
+		for i := range v {
+			items[i] = fmt.Sprint(v[i])
+		}
+		if len(items) > 0 {
+			result[prefix] = strings.Join(items, "; ")
+		}
+	case nil:
+	default:
+		if str := fmt.Sprint(v); str != "" && str != "0" && str != "false" {
+			result[prefix] = str
+		}
+	}
+}
+
+func diffValues(from, to map[string]string) []DeployChange {
+	changes := []DeployChange{}
+	for key, fromValue := range from {
+		if toValue := to[key]; toValue != fromValue {
+			changes = append(changes, DeployChange{Field: key, From: fromValue, To: toValue})
+		}
+	}
+	for key, toValue := range to {
+		if _, ok := from[key]; !ok {
+			changes = append(changes, DeployChange{Field: key, To: toValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"time"
+
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/provision"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) createCompareFixtures(c *check.C) *App {
+	a := App{Name: "cmpapp", Platform: "python", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	v1 := "registry.somewhere/tsuru/app-cmpapp:v1"
+	v2 := "registry.somewhere/tsuru/app-cmpapp:v2"
+	for _, img := range []string{v1, v2} {
+		err = image.AppendAppImageName(a.Name, img)
+		c.Assert(err, check.IsNil)
+	}
+	err = image.SaveImageCustomData(v1, map[string]interface{}{
+		"processes":   map[string]interface{}{"web": "python app.py", "worker": "celery"},
+		"exposedPort": "8888/tcp",
+		"healthcheck": map[string]interface{}{"path": "/"},
+	})
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData(v2, map[string]interface{}{
+		"processes":   map[string]interface{}{"web": "gunicorn app", "cron": "python cron.py"},
+		"exposedPort": "8888/tcp",
+		"healthcheck": map[string]interface{}{"path": "/health", "status": 200},
+		"hooks":       map[string]interface{}{"build": []string{"make", "make test"}},
+	})
+	c.Assert(err, check.IsNil)
+	insertDeploysAsEvents([]DeployData{
+		{App: a.Name, Timestamp: time.Now().Add(-time.Hour), Image: v1, Diff: "diff v1"},
+		{App: a.Name, Timestamp: time.Now(), Image: v2, Diff: "diff v2"},
+	}, c)
+	return &a
+}
+
+func (s *S) TestCompareDeploys(c *check.C) {
+	a := s.createCompareFixtures(c)
+	comparison, err := CompareDeploys(a, "v1", "v2")
+	c.Assert(err, check.IsNil)
+	c.Assert(comparison, check.DeepEquals, &DeployComparison{
+		App:  a.Name,
+		From: "registry.somewhere/tsuru/app-cmpapp:v1",
+		To:   "registry.somewhere/tsuru/app-cmpapp:v2",
+		Processes: []DeployChange{
+			{Field: "cron", To: "python cron.py"},
+			{Field: "web", From: "python app.py", To: "gunicorn app"},
+			{Field: "worker", From: "celery"},
+		},
+		TsuruYaml: []DeployChange{
+			{Field: "healthcheck.path", From: "/", To: "/health"},
+			{Field: "healthcheck.status", To: "200"},
+			{Field: "hooks.build", To: "make; make test"},
+		},
+		RepositoryDiff: "diff v2",
+	})
+	c.Assert(comparison.HasChanges(), check.Equals, true)
+	c.Assert(comparison.String(), check.Equals, `Changes from registry.somewhere/tsuru/app-cmpapp:v1 to registry.somewhere/tsuru/app-cmpapp:v2:
+  process cron: "" => "python cron.py"
+  process web: "python app.py" => "gunicorn app"
+  process worker: "celery" => ""
+  tsuru.yaml healthcheck.path: "/" => "/health"
+  tsuru.yaml healthcheck.status: "" => "200"
+  tsuru.yaml hooks.build: "" => "make; make test"
+`)
+}
+
+func (s *S) TestCompareDeploysFromCurrent(c *check.C) {
+	a := s.createCompareFixtures(c)
+	comparison, err := CompareDeploys(a, "", "v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(comparison.From, check.Equals, "registry.somewhere/tsuru/app-cmpapp:v2")
+	c.Assert(comparison.To, check.Equals, "registry.somewhere/tsuru/app-cmpapp:v1")
+	c.Assert(comparison.RepositoryDiff, check.Equals, "diff v2")
+	c.Assert(comparison.Processes, check.HasLen, 3)
+}
+
+func (s *S) TestCompareDeploysRepositoryDiffAcrossDeploys(c *check.C) {
+	a := s.createCompareFixtures(c)
+	v3 := "registry.somewhere/tsuru/app-cmpapp:v3"
+	err := image.AppendAppImageName(a.Name, v3)
+	c.Assert(err, check.IsNil)
+	insertDeploysAsEvents([]DeployData{
+		{App: a.Name, Timestamp: time.Now().Add(time.Minute), Image: v3, Diff: "diff v3"},
+	}, c)
+	comparison, err := CompareDeploys(a, "", "v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(comparison.From, check.Equals, v3)
+	c.Assert(comparison.RepositoryDiff, check.Equals, "diff v2\ndiff v3")
+	comparison, err = CompareDeploys(a, "v2", "v3")
+	c.Assert(err, check.IsNil)
+	c.Assert(comparison.RepositoryDiff, check.Equals, "diff v3")
+}
+
+func (s *S) TestCompareDeploysNoChanges(c *check.C) {
+	a := s.createCompareFixtures(c)
+	comparison, err := CompareDeploys(a, "v2", "v2")
+	c.Assert(err, check.IsNil)
+	c.Assert(comparison.HasChanges(), check.Equals, false)
+	c.Assert(comparison.String(), check.Equals, "No changes in processes, exposed port or tsuru.yaml from registry.somewhere/tsuru/app-cmpapp:v2 to registry.somewhere/tsuru/app-cmpapp:v2.\n")
+}
+
+func (s *S) TestCompareDeploysByDeployID(c *check.C) {
+	a := s.createCompareFixtures(c)
+	evts := insertDeploysAsEvents([]DeployData{
+		{App: a.Name, Timestamp: time.Now(), Image: "registry.somewhere/tsuru/app-cmpapp:v1"},
+	}, c)
+	comparison, err := CompareDeploys(a, evts[0].UniqueID.Hex(), "v2")
+	c.Assert(err, check.IsNil)
+	c.Assert(comparison.From, check.Equals, "registry.somewhere/tsuru/app-cmpapp:v1")
+}
+
+func (s *S) TestCompareDeploysInvalidVersion(c *check.C) {
+	a := s.createCompareFixtures(c)
+	_, err := CompareDeploys(a, "v1", "v9")
+	c.Assert(err, check.FitsTypeOf, &image.InvalidVersionErr{})
+}
+
+func (s *S) TestFlattenTsuruYaml(c *check.C) {
+	data := provision.TsuruYamlData{
+		Hooks: provision.TsuruYamlHooks{
+			Restart: provision.TsuruYamlRestartHooks{Before: []string{"a", "b"}},
+		},
+		Healthcheck: provision.TsuruYamlHealthcheck{Path: "/", UseInRouter: true, AllowedFailures: 3},
+	}
+	values, err := flattenTsuruYaml(data)
+	c.Assert(err, check.IsNil)
+	c.Assert(values, check.DeepEquals, map[string]string{
+		"hooks.restart.before":         "a; b",
+		"healthcheck.path":             "/",
+		"healthcheck.use_in_router":    "true",
+		"healthcheck.allowed_failures": "3",
+	})
+}
@@ -0,0 +1,464 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision/pool"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+)
+
+const (
+	defaultDeployRequestExpiration      = 24 * time.Hour
+	defaultDeployRequestExpirationCheck = time.Minute
+
+	// DeployRequestEventKind is the internal kind of the events recording
+	// the creation of deploy requests.
+	DeployRequestEventKind = "deploy-request"
+
+	deployRequestExpiredEventKind = "deploy-request-expired"
+)
+
+type DeployRequestStatus string
+
+const (
+	DeployRequestPending  DeployRequestStatus = "pending"
+	DeployRequestApproved DeployRequestStatus = "approved"
+	DeployRequestExpired  DeployRequestStatus = "expired"
+	DeployRequestFailed   DeployRequestStatus = "failed"
+)
+
+var (
+	ErrDeployRequestNotFound        = errors.New("deploy request not found")
+	ErrDeployRequestNotPending      = errors.New("deploy request is not pending approval")
+	ErrDeployRequestNotFailed       = errors.New("deploy request has not failed")
+	ErrDeployRequestExpired         = errors.New("deploy request has expired")
+	ErrDeployRequestSelfApproval    = errors.New("users cannot approve their own deploy requests")
+	ErrDeployRequestAlreadyApproved = errors.New("deploy request already approved by this user")
+	ErrDeployRequestUpload          = errors.New("deploys to protected pools cannot use uploaded files, use an image or an archive url instead")
+)
+
+// DeployRequest is a deploy to an app in a protected pool waiting for
+// approvals. The recorded options are used to run the deploy once enough
+// users approve it. Approved requests whose deploy could not run are marked
+// as failed and may be retried.
+type DeployRequest struct {
+	ID                bson.ObjectId `bson:"_id"`
+	App               string
+	Pool              string
+	User              string
+	Options           DeployOptions
+	RequiredApprovals int
+	Approvals         []DeployApproval
+	Status            DeployRequestStatus
+	EventID           string
+	DeployEventID     string
+	Error             string `bson:",omitempty"`
+	CreatedAt         time.Time
+	ExpiresAt         time.Time
+}
+
+type DeployApproval struct {
+	User      string
+	EventID   string
+	Timestamp time.Time
+}
+
+func deployRequestExpiration() time.Duration {
+	expiration, err := config.GetDuration("deploy-approval:expiration")
+	if err != nil || expiration <= 0 {
+		return defaultDeployRequestExpiration
+	}
+	return expiration
+}
+
+// DeployApprovalsRequired returns the number of approvals needed to deploy
+// the app, according to its pool. Zero means the deploy may run right away.
+func DeployApprovalsRequired(app *App) (int, error) {
+	p, err := pool.GetPoolByName(app.Pool)
+	if err == pool.ErrPoolNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return p.DeployApprovals, nil
+}
+
+// NewDeployRequest records a deploy request for the app in opts, which must
+// be in a protected pool. EventID points to the event that created the
+// request.
+func NewDeployRequest(opts DeployOptions, eventID string) (*DeployRequest, error) {
+	if opts.File != nil {
+		return nil, ErrDeployRequestUpload
+	}
+	required, err := DeployApprovalsRequired(opts.App)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	req := DeployRequest{
+		ID:                bson.NewObjectId(),
+		App:               opts.App.Name,
+		Pool:              opts.App.Pool,
+		User:              opts.User,
+		Options:           opts,
+		RequiredApprovals: required,
+		Approvals:         []DeployApproval{},
+		Status:            DeployRequestPending,
+		EventID:           eventID,
+		CreatedAt:         now,
+		ExpiresAt:         now.Add(deployRequestExpiration()),
+	}
+	req.Options.App = nil
+	req.Options.OutputStream = nil
+	req.Options.Event = nil
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	err = conn.DeployRequests().Insert(req)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// InitializeDeployRequestsExpiration starts the periodic expiration of
+// pending deploy requests, so expiry events are emitted even when nobody
+// reads the requests.
+func InitializeDeployRequestsExpiration() error {
+	interval, _ := config.GetDuration("deploy-approval:expiration-check-interval")
+	if interval <= 0 {
+		interval = defaultDeployRequestExpirationCheck
+	}
+	expirer := &deployRequestExpirer{interval: interval, once: &sync.Once{}}
+	expirer.start()
+	shutdown.Register(expirer)
+	return nil
+}
+
+type deployRequestExpirer struct {
+	interval time.Duration
+	once     *sync.Once
+	stopCh   chan struct{}
+}
+
+func (e *deployRequestExpirer) start() {
+	e.once.Do(func() {
+		e.stopCh = make(chan struct{})
+		go e.spin()
+	})
+}
+
+func (e *deployRequestExpirer) Shutdown(ctx context.Context) error {
+	if e.stopCh == nil {
+		return nil
+	}
+	e.stopCh <- struct{}{}
+	e.stopCh = nil
+	e.once = &sync.Once{}
+	return nil
+}
+
+func (e *deployRequestExpirer) spin() {
+	for {
+		err := ExpireDeployRequests()
+		if err != nil {
+			log.Errorf("[deploy requests] errors expiring deploy requests: %v", err)
+		}
+		select {
+		case <-e.stopCh:
+			return
+		case <-time.After(e.interval):
+		}
+	}
+}
+
+// ExpireDeployRequests marks pending requests past their expiration time as
+// expired, recording an event for each of them.
+func ExpireDeployRequests() error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return expireDeployRequests(conn)
+}
+
+func expireDeployRequests(conn *db.Storage) error {
+	var reqs []DeployRequest
+	err := conn.DeployRequests().Find(bson.M{
+		"status":    DeployRequestPending,
+		"expiresat": bson.M{"$lt": time.Now().UTC()},
+	}).All(&reqs)
+	if err != nil {
+		return err
+	}
+	multi := tsuruErrors.NewMultiError()
+	for i := range reqs {
+		req := &reqs[i]
+		// Only the process moving the request out of pending records the
+		// expiration event.
+		err = conn.DeployRequests().Update(
+			bson.M{"_id": req.ID, "status": DeployRequestPending},
+			bson.M{"$set": bson.M{"status": DeployRequestExpired}},
+		)
+		if err == mgo.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			multi.Add(err)
+			continue
+		}
+		req.Status = DeployRequestExpired
+		err = req.notifyExpired()
+		if err != nil {
+			multi.Add(errors.Wrapf(err, "deploy request %s", req.ID.Hex()))
+		}
+	}
+	return multi.ToError()
+}
+
+func (r *DeployRequest) notifyExpired() error {
+	allowed := []permTypes.PermissionContext{
+		permission.Context(permTypes.CtxApp, r.App),
+		permission.Context(permTypes.CtxPool, r.Pool),
+	}
+	if a, err := GetByName(r.App); err == nil {
+		allowed = append(allowed, permission.Contexts(permTypes.CtxTeam, a.Teams)...)
+	}
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeApp, Value: r.App},
+		InternalKind: deployRequestExpiredEventKind,
+		CustomData:   r,
+		DisableLock:  true,
+		Allowed:      event.Allowed(permission.PermAppReadEvents, allowed...),
+	})
+	if err != nil {
+		return err
+	}
+	return evt.Done(nil)
+}
+
+// GetDeployRequest returns the deploy request with the given id for the app.
+func GetDeployRequest(appName, id string) (*DeployRequest, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, ErrDeployRequestNotFound
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	err = expireDeployRequests(conn)
+	if err != nil {
+		return nil, err
+	}
+	var req DeployRequest
+	err = conn.DeployRequests().Find(bson.M{"_id": bson.ObjectIdHex(id), "app": appName}).One(&req)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, ErrDeployRequestNotFound
+		}
+		return nil, err
+	}
+	return &req, nil
+}
+
+// ListDeployRequests returns the deploy requests for the app, newest first.
+// An empty status returns requests in any status.
+func ListDeployRequests(appName string, status DeployRequestStatus) ([]DeployRequest, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	err = expireDeployRequests(conn)
+	if err != nil {
+		return nil, err
+	}
+	query := bson.M{"app": appName}
+	if status != "" {
+		query["status"] = status
+	}
+	var reqs []DeployRequest
+	err = conn.DeployRequests().Find(query).Sort("-createdat").All(&reqs)
+	if err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+func (r *DeployRequest) approvedBy(user string) bool {
+	for _, a := range r.Approvals {
+		if a.User == user {
+			return true
+		}
+	}
+	return false
+}
+
+// Approve records the approval of the request by user. It returns true when
+// this approval is the one completing the required number of approvals, in
+// which case the caller is responsible for running the deploy.
+func (r *DeployRequest) Approve(user, eventID string) (bool, error) {
+	if r.Status == DeployRequestExpired {
+		return false, ErrDeployRequestExpired
+	}
+	if r.Status != DeployRequestPending {
+		return false, ErrDeployRequestNotPending
+	}
+	if time.Now().UTC().After(r.ExpiresAt) {
+		return false, ErrDeployRequestExpired
+	}
+	if r.User == user {
+		return false, ErrDeployRequestSelfApproval
+	}
+	if r.approvedBy(user) {
+		return false, ErrDeployRequestAlreadyApproved
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	approval := DeployApproval{User: user, EventID: eventID, Timestamp: time.Now().UTC()}
+	_, err = conn.DeployRequests().Find(bson.M{
+		"_id":            r.ID,
+		"status":         DeployRequestPending,
+		"approvals.user": bson.M{"$ne": user},
+	}).Apply(mgo.Change{
+		Update:    bson.M{"$push": bson.M{"approvals": approval}},
+		ReturnNew: true,
+	}, r)
+	if err == mgo.ErrNotFound {
+		err = conn.DeployRequests().FindId(r.ID).One(r)
+		if err != nil {
+			return false, err
+		}
+		if r.approvedBy(user) {
+			return false, ErrDeployRequestAlreadyApproved
+		}
+		return false, ErrDeployRequestNotPending
+	}
+	if err != nil {
+		return false, err
+	}
+	if len(r.Approvals) < r.RequiredApprovals {
+		return false, nil
+	}
+	// Only one of concurrent approvers may move the request out of pending
+	// and trigger the deploy.
+	err = conn.DeployRequests().Update(
+		bson.M{"_id": r.ID, "status": DeployRequestPending},
+		bson.M{"$set": bson.M{"status": DeployRequestApproved}},
+	)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	r.Status = DeployRequestApproved
+	return true, nil
+}
+
+// Fail marks an approved request as failed, recording deployErr, so it may
+// be retried.
+func (r *DeployRequest) Fail(deployErr error) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.DeployRequests().Update(
+		bson.M{"_id": r.ID, "status": DeployRequestApproved},
+		bson.M{"$set": bson.M{"status": DeployRequestFailed, "error": deployErr.Error()}},
+	)
+	if err == mgo.ErrNotFound {
+		return ErrDeployRequestNotPending
+	}
+	if err != nil {
+		return err
+	}
+	r.Status = DeployRequestFailed
+	r.Error = deployErr.Error()
+	return nil
+}
+
+// Retry moves a failed request back to approved, in which case the caller is
+// responsible for running the deploy again.
+func (r *DeployRequest) Retry() error {
+	if r.Status != DeployRequestFailed {
+		return ErrDeployRequestNotFailed
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Only one of concurrent retries may move the request out of failed.
+	_, err = conn.DeployRequests().Find(bson.M{
+		"_id":    r.ID,
+		"status": DeployRequestFailed,
+	}).Apply(mgo.Change{
+		Update: bson.M{
+			"$set":   bson.M{"status": DeployRequestApproved},
+			"$unset": bson.M{"error": ""},
+		},
+		ReturnNew: true,
+	}, r)
+	if err == mgo.ErrNotFound {
+		return ErrDeployRequestNotFailed
+	}
+	if err != nil {
+		return err
+	}
+	r.Error = ""
+	return nil
+}
+
+// Deploy runs the deploy recorded in an approved request, using evt as the
+// deploy event.
+func (r *DeployRequest) Deploy(w io.Writer, evt *event.Event) (string, error) {
+	if r.Status != DeployRequestApproved {
+		return "", ErrDeployRequestNotPending
+	}
+	app, err := GetByName(r.App)
+	if err != nil {
+		return "", err
+	}
+	opts := r.Options
+	opts.App = app
+	opts.OutputStream = w
+	opts.Event = evt
+	conn, err := db.Conn()
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	r.DeployEventID = evt.UniqueID.Hex()
+	err = conn.DeployRequests().UpdateId(r.ID, bson.M{"$set": bson.M{"deployeventid": r.DeployEventID}})
+	if err != nil {
+		return "", err
+	}
+	return Deploy(opts)
+}
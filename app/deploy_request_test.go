@@ -0,0 +1,198 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision/pool"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) createProtectedApp(c *check.C, approvals int) *App {
+	err := pool.PoolUpdate(s.Pool, pool.UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	a := App{
+		Name:      "protected-app",
+		Platform:  "django",
+		TeamOwner: s.team.Name,
+		Router:    "fake",
+	}
+	err = CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	return &a
+}
+
+func (s *S) TestDeployApprovalsRequired(c *check.C) {
+	a := App{Name: "unprotected-app", Platform: "django", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	required, err := DeployApprovalsRequired(&a)
+	c.Assert(err, check.IsNil)
+	c.Assert(required, check.Equals, 0)
+	protected := s.createProtectedApp(c, 2)
+	required, err = DeployApprovalsRequired(protected)
+	c.Assert(err, check.IsNil)
+	c.Assert(required, check.Equals, 2)
+}
+
+func (s *S) TestNewDeployRequest(c *check.C) {
+	a := s.createProtectedApp(c, 2)
+	req, err := NewDeployRequest(DeployOptions{App: a, Image: "myimage", User: "dev@tsuru.io"}, "evt1")
+	c.Assert(err, check.IsNil)
+	c.Assert(req.App, check.Equals, a.Name)
+	c.Assert(req.Pool, check.Equals, s.Pool)
+	c.Assert(req.User, check.Equals, "dev@tsuru.io")
+	c.Assert(req.RequiredApprovals, check.Equals, 2)
+	c.Assert(req.Status, check.Equals, DeployRequestPending)
+	c.Assert(req.ExpiresAt.Sub(req.CreatedAt), check.Equals, defaultDeployRequestExpiration)
+	dbReq, err := GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Options.Image, check.Equals, "myimage")
+	c.Assert(dbReq.Options.App, check.IsNil)
+	c.Assert(dbReq.EventID, check.Equals, "evt1")
+}
+
+func (s *S) TestNewDeployRequestWithUpload(c *check.C) {
+	a := s.createProtectedApp(c, 1)
+	_, err := NewDeployRequest(DeployOptions{App: a, File: ioutil.NopCloser(strings.NewReader("data"))}, "evt1")
+	c.Assert(err, check.Equals, ErrDeployRequestUpload)
+}
+
+func (s *S) TestGetDeployRequestNotFound(c *check.C) {
+	_, err := GetDeployRequest("myapp", "invalid")
+	c.Assert(err, check.Equals, ErrDeployRequestNotFound)
+	_, err = GetDeployRequest("myapp", bson.NewObjectId().Hex())
+	c.Assert(err, check.Equals, ErrDeployRequestNotFound)
+}
+
+func (s *S) TestListDeployRequestsExpiresPending(c *check.C) {
+	config.Set("deploy-approval:expiration", "1ms")
+	defer config.Unset("deploy-approval:expiration")
+	a := s.createProtectedApp(c, 1)
+	req, err := NewDeployRequest(DeployOptions{App: a, Image: "myimage", User: "dev@tsuru.io"}, "evt1")
+	c.Assert(err, check.IsNil)
+	time.Sleep(10 * time.Millisecond)
+	reqs, err := ListDeployRequests(a.Name, DeployRequestPending)
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 0)
+	reqs, err = ListDeployRequests(a.Name, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(reqs, check.HasLen, 1)
+	c.Assert(reqs[0].ID, check.Equals, req.ID)
+	c.Assert(reqs[0].Status, check.Equals, DeployRequestExpired)
+	_, err = reqs[0].Approve("ops@tsuru.io", "evt2")
+	c.Assert(err, check.Equals, ErrDeployRequestExpired)
+}
+
+func (s *S) TestExpireDeployRequests(c *check.C) {
+	config.Set("deploy-approval:expiration", "1ms")
+	defer config.Unset("deploy-approval:expiration")
+	a := s.createProtectedApp(c, 1)
+	req, err := NewDeployRequest(DeployOptions{App: a, Image: "myimage", User: "dev@tsuru.io"}, "evt1")
+	c.Assert(err, check.IsNil)
+	time.Sleep(10 * time.Millisecond)
+	err = ExpireDeployRequests()
+	c.Assert(err, check.IsNil)
+	dbReq, err := GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Status, check.Equals, DeployRequestExpired)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeApp, Value: a.Name},
+		Kind:   deployRequestExpiredEventKind,
+	}, eventtest.HasEvent)
+	err = ExpireDeployRequests()
+	c.Assert(err, check.IsNil)
+	evts, err := event.List(&event.Filter{KindNames: []string{deployRequestExpiredEventKind}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+}
+
+func (s *S) TestDeployRequestApprove(c *check.C) {
+	a := s.createProtectedApp(c, 2)
+	req, err := NewDeployRequest(DeployOptions{App: a, Image: "myimage", User: "dev@tsuru.io"}, "evt1")
+	c.Assert(err, check.IsNil)
+	_, err = req.Approve("dev@tsuru.io", "evt2")
+	c.Assert(err, check.Equals, ErrDeployRequestSelfApproval)
+	ready, err := req.Approve("ops1@tsuru.io", "evt3")
+	c.Assert(err, check.IsNil)
+	c.Assert(ready, check.Equals, false)
+	_, err = req.Approve("ops1@tsuru.io", "evt4")
+	c.Assert(err, check.Equals, ErrDeployRequestAlreadyApproved)
+	ready, err = req.Approve("ops2@tsuru.io", "evt5")
+	c.Assert(err, check.IsNil)
+	c.Assert(ready, check.Equals, true)
+	c.Assert(req.Status, check.Equals, DeployRequestApproved)
+	dbReq, err := GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Status, check.Equals, DeployRequestApproved)
+	c.Assert(dbReq.Approvals, check.HasLen, 2)
+	c.Assert(dbReq.Approvals[0].User, check.Equals, "ops1@tsuru.io")
+	c.Assert(dbReq.Approvals[1].EventID, check.Equals, "evt5")
+	_, err = dbReq.Approve("ops3@tsuru.io", "evt6")
+	c.Assert(err, check.Equals, ErrDeployRequestNotPending)
+}
+
+func (s *S) TestDeployRequestDeploy(c *check.C) {
+	a := s.createProtectedApp(c, 1)
+	req, err := NewDeployRequest(DeployOptions{App: a, Image: "myimage", User: "dev@tsuru.io"}, "evt1")
+	c.Assert(err, check.IsNil)
+	ready, err := req.Approve("ops@tsuru.io", "evt2")
+	c.Assert(err, check.IsNil)
+	c.Assert(ready, check.Equals, true)
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: req.User},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	writer := &bytes.Buffer{}
+	_, err = req.Deploy(writer, evt)
+	c.Assert(err, check.IsNil)
+	c.Assert(writer.String(), check.Equals, "Builder deploy called")
+	dbReq, err := GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.DeployEventID, check.Equals, evt.UniqueID.Hex())
+}
+
+func (s *S) TestDeployRequestFailAndRetry(c *check.C) {
+	a := s.createProtectedApp(c, 1)
+	req, err := NewDeployRequest(DeployOptions{App: a, Image: "myimage", User: "dev@tsuru.io"}, "evt1")
+	c.Assert(err, check.IsNil)
+	err = req.Retry()
+	c.Assert(err, check.Equals, ErrDeployRequestNotFailed)
+	err = req.Fail(errors.New("deploy failed"))
+	c.Assert(err, check.Equals, ErrDeployRequestNotPending)
+	ready, err := req.Approve("ops@tsuru.io", "evt2")
+	c.Assert(err, check.IsNil)
+	c.Assert(ready, check.Equals, true)
+	err = req.Fail(errors.New("deploy failed"))
+	c.Assert(err, check.IsNil)
+	c.Assert(req.Status, check.Equals, DeployRequestFailed)
+	dbReq, err := GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Status, check.Equals, DeployRequestFailed)
+	c.Assert(dbReq.Error, check.Equals, "deploy failed")
+	err = dbReq.Retry()
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Status, check.Equals, DeployRequestApproved)
+	c.Assert(dbReq.Error, check.Equals, "")
+	err = req.Retry()
+	c.Assert(err, check.Equals, ErrDeployRequestNotFailed)
+	dbReq, err = GetDeployRequest(a.Name, req.ID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(dbReq.Status, check.Equals, DeployRequestApproved)
+	c.Assert(dbReq.Approvals, check.HasLen, 1)
+}
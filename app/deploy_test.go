@@ -21,6 +21,9 @@ import (
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/tracing"
+	"github.com/tsuru/tsuru/tracing/tracingtest"
+	imageTypes "github.com/tsuru/tsuru/types/app/image"
 	authTypes "github.com/tsuru/tsuru/types/auth"
 	"gopkg.in/check.v1"
 )
@@ -319,6 +322,85 @@ func (s *S) TestDeployAppUpload(c *check.C) {
 	c.Assert(updatedApp.UpdatePlatform, check.Equals, false)
 }
 
+func (s *S) TestDeployAppPlatformVersionEOL(c *check.C) {
+	a := App{
+		Name:      "some-app",
+		Platform:  "django",
+		Teams:     []string{s.team.Name},
+		TeamOwner: s.team.Name,
+		Router:    "fake",
+	}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	s.mockService.PlatformImage.OnCurrentImage = func(name string) (string, error) {
+		return "tsuru/django:v2", nil
+	}
+	s.mockService.PlatformImage.OnFindVersion = func(name, version string) (*imageTypes.PlatformVersion, error) {
+		c.Assert(name, check.Equals, "django")
+		c.Assert(version, check.Equals, "v2")
+		return &imageTypes.PlatformVersion{Platform: name, Version: version, Status: imageTypes.PlatformVersionEOL}, nil
+	}
+	defer s.mockService.ResetPlatformImage()
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	buf := strings.NewReader("my file")
+	_, err = Deploy(DeployOptions{
+		App:          &a,
+		File:         ioutil.NopCloser(buf),
+		FileSize:     int64(buf.Len()),
+		OutputStream: ioutil.Discard,
+		Event:        evt,
+	})
+	c.Assert(err, check.DeepEquals, &PlatformVersionEOLError{Platform: "django", Version: "v2"})
+	writer := &bytes.Buffer{}
+	buf = strings.NewReader("my file")
+	_, err = Deploy(DeployOptions{
+		App:              &a,
+		File:             ioutil.NopCloser(buf),
+		FileSize:         int64(buf.Len()),
+		OutputStream:     writer,
+		Event:            evt,
+		AllowPlatformEOL: true,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(writer.String(), check.Matches, "(?s)WARNING: platform django:v2 has reached its end-of-life.*Builder deploy called")
+}
+
+func (s *S) TestDeployAppImagePlatformVersionEOL(c *check.C) {
+	a := App{
+		Name:      "some-app",
+		Platform:  "django",
+		Teams:     []string{s.team.Name},
+		TeamOwner: s.team.Name,
+		Router:    "fake",
+	}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	s.mockService.PlatformImage.OnFindVersion = func(name, version string) (*imageTypes.PlatformVersion, error) {
+		return &imageTypes.PlatformVersion{Platform: name, Version: version, Status: imageTypes.PlatformVersionEOL}, nil
+	}
+	defer s.mockService.ResetPlatformImage()
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	_, err = Deploy(DeployOptions{
+		App:          &a,
+		Image:        "myimage",
+		OutputStream: ioutil.Discard,
+		Event:        evt,
+	})
+	c.Assert(err, check.IsNil)
+}
+
 func (s *S) TestDeployAppImage(c *check.C) {
 	a := App{
 		Name:      "some-app",
@@ -477,6 +559,58 @@ func (s *S) TestDeployAppIncrementDeployNumber(c *check.C) {
 	c.Assert(updatedApp.Deploys, check.Equals, uint(1))
 }
 
+func (s *S) TestDeployAppTracing(c *check.C) {
+	exporter := tracingtest.New()
+	defer tracing.SetExporter(nil)
+	a := App{
+		Name:      "otherapp",
+		Platform:  "zend",
+		Teams:     []string{s.team.Name},
+		TeamOwner: s.team.Name,
+		Router:    "fake",
+	}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	evt, err := event.New(&event.Opts{
+		Target:   event.Target{Type: "app", Value: a.Name},
+		Kind:     permission.PermAppDeploy,
+		RawOwner: event.Owner{Type: event.OwnerTypeUser, Name: s.user.Email},
+		Allowed:  event.Allowed(permission.PermApp),
+	})
+	c.Assert(err, check.IsNil)
+	root := tracing.StartTrace("POST /apps/{appname}/deploy", "")
+	_, err = Deploy(DeployOptions{
+		App:          &a,
+		Image:        "myimage",
+		OutputStream: &bytes.Buffer{},
+		Event:        evt,
+		TraceID:      root.TraceID,
+	})
+	c.Assert(err, check.IsNil)
+	root.Finish(nil)
+	spans := exporter.Spans()
+	c.Assert(spans, check.HasLen, 5)
+	operations := make([]string, len(spans))
+	for i, span := range spans {
+		c.Assert(span.TraceID, check.Equals, root.TraceID)
+		operations[i] = span.Operation
+	}
+	c.Assert(operations, check.DeepEquals, []string{
+		"builder.Build",
+		"provisioner.Deploy",
+		"router.RebuildRoutes",
+		"app.Deploy",
+		"POST /apps/{appname}/deploy",
+	})
+	deploySpan := spans[3]
+	c.Assert(deploySpan.ParentID, check.Equals, root.ID)
+	c.Assert(deploySpan.Tags["app"], check.Equals, a.Name)
+	for _, span := range spans[:3] {
+		c.Assert(span.ParentID, check.Equals, deploySpan.ID)
+	}
+	c.Assert(spans[1].Tags["provisioner"], check.Equals, "fake")
+}
+
 func (s *S) TestDeployAppSaveDeployData(c *check.C) {
 	a := App{
 		Name:      "otherapp",
@@ -0,0 +1,211 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"github.com/tsuru/tsuru/service"
+	appTypes "github.com/tsuru/tsuru/types/app"
+)
+
+const (
+	GraphNodeApp      = "app"
+	GraphNodeUnit     = "unit"
+	GraphNodeRouter   = "router"
+	GraphNodeTeam     = "team"
+	GraphNodeInstance = "service-instance"
+
+	GraphEdgeOwner  = "owner"
+	GraphEdgeTeam   = "team"
+	GraphEdgeUnit   = "unit"
+	GraphEdgeRouter = "router"
+	GraphEdgeBind   = "bind"
+)
+
+type GraphNode struct {
+	ID     string            `json:"id"`
+	Kind   string            `json:"kind"`
+	Name   string            `json:"name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+// Graph represents the resources depending on, or used by, an app or a
+// service instance. Edges always go from the dependent resource to the
+// resource it depends on, e.g. from an app to the service instances it binds.
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+
+	nodeIndex map[string]int
+	edgeIndex map[GraphEdge]struct{}
+}
+
+func newGraph() *Graph {
+	return &Graph{
+		Nodes:     []GraphNode{},
+		Edges:     []GraphEdge{},
+		nodeIndex: map[string]int{},
+		edgeIndex: map[GraphEdge]struct{}{},
+	}
+}
+
+func (g *Graph) addNode(kind, name string, labels map[string]string) string {
+	id := kind + ":" + name
+	if idx, ok := g.nodeIndex[id]; ok {
+		for k, v := range labels {
+			if g.Nodes[idx].Labels == nil {
+				g.Nodes[idx].Labels = map[string]string{}
+			}
+			g.Nodes[idx].Labels[k] = v
+		}
+		return id
+	}
+	g.nodeIndex[id] = len(g.Nodes)
+	g.Nodes = append(g.Nodes, GraphNode{ID: id, Kind: kind, Name: name, Labels: labels})
+	return id
+}
+
+func (g *Graph) addEdge(from, to, kind string) {
+	edge := GraphEdge{From: from, To: to, Kind: kind}
+	if _, ok := g.edgeIndex[edge]; ok {
+		return
+	}
+	g.edgeIndex[edge] = struct{}{}
+	g.Edges = append(g.Edges, edge)
+}
+
+// NodesByKind returns the names of the nodes of the given kind, sorted.
+func (g *Graph) NodesByKind(kind string) []string {
+	var names []string
+	for _, n := range g.Nodes {
+		if n.Kind == kind {
+			names = append(names, n.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+var graphNodeShapes = map[string]string{
+	GraphNodeApp:      "box",
+	GraphNodeUnit:     "ellipse",
+	GraphNodeRouter:   "diamond",
+	GraphNodeTeam:     "house",
+	GraphNodeInstance: "cylinder",
+}
+
+// DOT returns the graph in the Graphviz DOT language.
+func (g *Graph) DOT() string {
+	var buf bytes.Buffer
+	buf.WriteString("digraph tsuru {\n")
+	for _, n := range g.Nodes {
+		fmt.Fprintf(&buf, "  %q [label=%q, shape=%s];\n", n.ID, n.Name, graphNodeShapes[n.Kind])
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(&buf, "  %q -> %q [label=%q];\n", e.From, e.To, e.Kind)
+	}
+	buf.WriteString("}\n")
+	return buf.String()
+}
+
+func instanceNodeName(si *service.ServiceInstance) string {
+	return si.ServiceName + "/" + si.Name
+}
+
+func (g *Graph) addInstance(si *service.ServiceInstance) string {
+	id := g.addNode(GraphNodeInstance, instanceNodeName(si), map[string]string{"plan": si.PlanName})
+	if si.TeamOwner != "" {
+		g.addEdge(id, g.addNode(GraphNodeTeam, si.TeamOwner, nil), GraphEdgeOwner)
+	}
+	for _, team := range si.Teams {
+		g.addEdge(id, g.addNode(GraphNodeTeam, team, nil), GraphEdgeTeam)
+	}
+	return id
+}
+
+// addApp adds the app to the graph along with its units, routers, teams and
+// the service instances bound to it.
+func (g *Graph) addApp(app *App, instances []service.ServiceInstance) string {
+	id := g.addNode(GraphNodeApp, app.Name, map[string]string{"pool": app.Pool, "platform": app.Platform})
+	if app.TeamOwner != "" {
+		g.addEdge(id, g.addNode(GraphNodeTeam, app.TeamOwner, nil), GraphEdgeOwner)
+	}
+	for _, team := range app.Teams {
+		g.addEdge(id, g.addNode(GraphNodeTeam, team, nil), GraphEdgeTeam)
+	}
+	units, err := app.Units()
+	if err != nil {
+		g.Nodes[g.nodeIndex[id]].Labels["units-error"] = err.Error()
+	}
+	for _, u := range units {
+		unitID := g.addNode(GraphNodeUnit, u.ID, map[string]string{"process": u.ProcessName, "status": u.Status.String()})
+		g.addEdge(id, unitID, GraphEdgeUnit)
+	}
+	for _, r := range app.GetRouters() {
+		g.addEdge(id, g.addNode(GraphNodeRouter, r.Name, nil), GraphEdgeRouter)
+	}
+	for i := range instances {
+		g.addEdge(id, g.addInstance(&instances[i]), GraphEdgeBind)
+	}
+	return id
+}
+
+// AppGraph returns the dependency graph of the app: its units, routers,
+// teams and bound service instances, including the other apps sharing those
+// instances.
+func AppGraph(app *App) (*Graph, error) {
+	instances, err := service.GetServiceInstancesBoundToApp(app.Name)
+	if err != nil {
+		return nil, err
+	}
+	g := newGraph()
+	appID := g.addApp(app, instances)
+	for i := range instances {
+		instanceID := g.addInstance(&instances[i])
+		for _, appName := range instances[i].Apps {
+			otherID := g.addNode(GraphNodeApp, appName, nil)
+			if otherID != appID {
+				g.addEdge(otherID, instanceID, GraphEdgeBind)
+			}
+		}
+	}
+	return g, nil
+}
+
+// InstanceGraph returns the dependency graph of the service instance: its
+// teams and every bound app, along with their units, routers, teams and the
+// other service instances they bind. Apps for which canRead returns false are
+// left out of the graph, a nil canRead includes every app.
+func InstanceGraph(si *service.ServiceInstance, canRead func(*App) bool) (*Graph, error) {
+	g := newGraph()
+	g.addInstance(si)
+	for _, appName := range si.Apps {
+		app, err := GetByName(appName)
+		if err != nil {
+			if err == appTypes.ErrAppNotFound {
+				continue
+			}
+			return nil, err
+		}
+		if canRead != nil && !canRead(app) {
+			continue
+		}
+		instances, err := service.GetServiceInstancesBoundToApp(app.Name)
+		if err != nil {
+			return nil, err
+		}
+		g.addApp(app, instances)
+	}
+	return g, nil
+}
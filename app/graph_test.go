@@ -0,0 +1,87 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package app
+
+import (
+	"github.com/tsuru/tsuru/service"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) createGraphFixtures(c *check.C) (*App, *App) {
+	a := App{Name: "graph-app", TeamOwner: s.team.Name}
+	err := CreateApp(&a, s.user)
+	c.Assert(err, check.IsNil)
+	err = s.provisioner.AddUnits(&a, 2, "web", nil)
+	c.Assert(err, check.IsNil)
+	other := App{Name: "other-app", TeamOwner: s.team.Name}
+	err = CreateApp(&other, s.user)
+	c.Assert(err, check.IsNil)
+	err = s.conn.ServiceInstances().Insert(
+		service.ServiceInstance{Name: "db", ServiceName: "mysql", TeamOwner: s.team.Name, Apps: []string{"graph-app", "other-app"}},
+		service.ServiceInstance{Name: "cache", ServiceName: "redis", TeamOwner: s.team.Name, Apps: []string{"graph-app"}},
+	)
+	c.Assert(err, check.IsNil)
+	return &a, &other
+}
+
+func (s *S) TestAppGraph(c *check.C) {
+	a, _ := s.createGraphFixtures(c)
+	graph, err := AppGraph(a)
+	c.Assert(err, check.IsNil)
+	c.Assert(graph.NodesByKind(GraphNodeApp), check.DeepEquals, []string{"graph-app", "other-app"})
+	c.Assert(graph.NodesByKind(GraphNodeInstance), check.DeepEquals, []string{"mysql/db", "redis/cache"})
+	c.Assert(graph.NodesByKind(GraphNodeTeam), check.DeepEquals, []string{s.team.Name})
+	c.Assert(graph.NodesByKind(GraphNodeUnit), check.HasLen, 2)
+	c.Assert(graph.NodesByKind(GraphNodeRouter), check.HasLen, 1)
+	var binds []GraphEdge
+	for _, e := range graph.Edges {
+		if e.Kind == GraphEdgeBind {
+			binds = append(binds, e)
+		}
+	}
+	c.Assert(binds, check.DeepEquals, []GraphEdge{
+		{From: "app:graph-app", To: "service-instance:mysql/db", Kind: GraphEdgeBind},
+		{From: "app:graph-app", To: "service-instance:redis/cache", Kind: GraphEdgeBind},
+		{From: "app:other-app", To: "service-instance:mysql/db", Kind: GraphEdgeBind},
+	})
+}
+
+func (s *S) TestInstanceGraph(c *check.C) {
+	s.createGraphFixtures(c)
+	si, err := service.GetServiceInstance("mysql", "db")
+	c.Assert(err, check.IsNil)
+	graph, err := InstanceGraph(si, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(graph.Nodes[0].ID, check.Equals, "service-instance:mysql/db")
+	c.Assert(graph.NodesByKind(GraphNodeApp), check.DeepEquals, []string{"graph-app", "other-app"})
+	c.Assert(graph.NodesByKind(GraphNodeInstance), check.DeepEquals, []string{"mysql/db", "redis/cache"})
+	c.Assert(graph.NodesByKind(GraphNodeUnit), check.HasLen, 2)
+}
+
+func (s *S) TestInstanceGraphFiltersApps(c *check.C) {
+	s.createGraphFixtures(c)
+	si, err := service.GetServiceInstance("mysql", "db")
+	c.Assert(err, check.IsNil)
+	graph, err := InstanceGraph(si, func(a *App) bool {
+		return a.Name == "other-app"
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(graph.NodesByKind(GraphNodeApp), check.DeepEquals, []string{"other-app"})
+	c.Assert(graph.NodesByKind(GraphNodeInstance), check.DeepEquals, []string{"mysql/db"})
+	c.Assert(graph.NodesByKind(GraphNodeUnit), check.HasLen, 0)
+}
+
+func (s *S) TestGraphDOT(c *check.C) {
+	g := newGraph()
+	appID := g.addNode(GraphNodeApp, "myapp", nil)
+	g.addEdge(appID, g.addNode(GraphNodeRouter, "fake", nil), GraphEdgeRouter)
+	g.addEdge(appID, g.addNode(GraphNodeRouter, "fake", nil), GraphEdgeRouter)
+	c.Assert(g.DOT(), check.Equals, `digraph tsuru {
+  "app:myapp" [label="myapp", shape=box];
+  "router:fake" [label="fake", shape=diamond];
+  "app:myapp" -> "router:fake" [label="router"];
+}
+`)
+}
@@ -55,9 +55,10 @@ type ImageMetadata struct {
 }
 
 type appImages struct {
-	AppName string `bson:"_id"`
-	Images  []string
-	Count   int
+	AppName    string `bson:"_id"`
+	Images     []string
+	Count      int
+	BuildCache string `bson:",omitempty"`
 }
 
 func (i *ImageMetadata) Save() error {
@@ -436,6 +437,13 @@ func PullAppImageNames(appName string, images []string) error {
 	if err != nil && err != mgo.ErrNotFound {
 		return err
 	}
+	err = coll.Update(
+		bson.M{"_id": appName, "buildcache": bson.M{"$in": images}},
+		bson.M{"$unset": bson.M{"buildcache": ""}},
+	)
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
 	return nil
 }
 
@@ -508,6 +516,47 @@ func AppCurrentBuilderImageName(appName string) (string, error) {
 	return imgs.Images[len(imgs.Images)-1], nil
 }
 
+// AppBuildCacheImageName returns the image holding the build cache of the
+// app, or an empty string when the app has no build cache.
+func AppBuildCacheImageName(appName string) (string, error) {
+	coll, err := appBuilderImagesColl()
+	if err != nil {
+		return "", err
+	}
+	defer coll.Close()
+	var imgs appImages
+	err = coll.FindId(appName).One(&imgs)
+	if err == mgo.ErrNotFound {
+		return "", nil
+	}
+	return imgs.BuildCache, err
+}
+
+func SetAppBuildCacheImageName(appName, imageID string) error {
+	coll, err := appBuilderImagesColl()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	_, err = coll.UpsertId(appName, bson.M{"$set": bson.M{"buildcache": imageID}})
+	return err
+}
+
+// PurgeAppBuildCache discards the build cache of the app, the next build
+// starts from scratch.
+func PurgeAppBuildCache(appName string) error {
+	coll, err := appBuilderImagesColl()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	err = coll.UpdateId(appName, bson.M{"$unset": bson.M{"buildcache": ""}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
 func GetAppImageBySuffix(appName, imageIdSuffix string) (string, error) {
 	inputImage := imageIdSuffix
 	validImgs, err := ListValidAppImages(appName)
@@ -201,6 +201,41 @@ func (s *S) TestPullAppImageNames(c *check.C) {
 	c.Assert(images, check.DeepEquals, []string{})
 }
 
+func (s *S) TestAppBuildCacheImageName(c *check.C) {
+	img, err := AppBuildCacheImageName("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(img, check.Equals, "")
+	err = AppendAppBuilderImageName("myapp", "tsuru/app-myapp:v1-builder")
+	c.Assert(err, check.IsNil)
+	err = SetAppBuildCacheImageName("myapp", "tsuru/app-myapp:v1-builder")
+	c.Assert(err, check.IsNil)
+	img, err = AppBuildCacheImageName("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(img, check.Equals, "tsuru/app-myapp:v1-builder")
+	images, err := ListAppBuilderImages("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(images, check.DeepEquals, []string{"tsuru/app-myapp:v1-builder"})
+	err = PurgeAppBuildCache("myapp")
+	c.Assert(err, check.IsNil)
+	img, err = AppBuildCacheImageName("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(img, check.Equals, "")
+	err = PurgeAppBuildCache("otherapp")
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestPullAppImageNamesRemovesBuildCache(c *check.C) {
+	err := AppendAppBuilderImageName("myapp", "tsuru/app-myapp:v1-builder")
+	c.Assert(err, check.IsNil)
+	err = SetAppBuildCacheImageName("myapp", "tsuru/app-myapp:v1-builder")
+	c.Assert(err, check.IsNil)
+	err = PullAppImageNames("myapp", []string{"tsuru/app-myapp:v1-builder"})
+	c.Assert(err, check.IsNil)
+	img, err := AppBuildCacheImageName("myapp")
+	c.Assert(err, check.IsNil)
+	c.Assert(img, check.Equals, "")
+}
+
 func (s *S) TestPullAppImageNamesRemovesCustomData(c *check.C) {
 	img1Name := "tsuru/app-myapp:v1"
 	err := AppendAppImageName("myapp", img1Name)
@@ -99,6 +99,24 @@ func (s *platformImageService) FindImage(platformName, image string) (string, er
 	return "", imageTypes.ErrPlatformImageNotFound
 }
 
+func (s *platformImageService) SetVersion(v imageTypes.PlatformVersion) error {
+	if v.Status == "" {
+		v.Status = imageTypes.PlatformVersionActive
+	}
+	if !v.Status.Valid() {
+		return imageTypes.ErrInvalidPlatformVersion
+	}
+	return s.storage.UpsertVersion(v)
+}
+
+func (s *platformImageService) FindVersion(platformName, version string) (*imageTypes.PlatformVersion, error) {
+	return s.storage.FindVersion(platformName, version)
+}
+
+func (s *platformImageService) ListVersions(platformName string) ([]imageTypes.PlatformVersion, error) {
+	return s.storage.FindVersions(platformName)
+}
+
 func platformBasicImageName(platformName string) string {
 	return fmt.Sprintf("%s/%s:latest", basicImageName("tsuru"), platformName)
 }
@@ -235,3 +235,33 @@ func (s *S) TestPlatformFindImage(c *check.C) {
 	c.Assert(err, check.NotNil)
 	c.Assert(image, check.Equals, "")
 }
+
+func (s *S) TestPlatformSetVersion(c *check.C) {
+	var stored imageTypes.PlatformVersion
+	service := &platformImageService{
+		storage: &imageTypes.MockPlatformImageStorage{
+			OnUpsertVersion: func(v imageTypes.PlatformVersion) error {
+				stored = v
+				return nil
+			},
+		},
+	}
+	err := service.SetVersion(imageTypes.PlatformVersion{Platform: "python", Version: "v1"})
+	c.Assert(err, check.IsNil)
+	c.Assert(stored, check.DeepEquals, imageTypes.PlatformVersion{
+		Platform: "python",
+		Version:  "v1",
+		Status:   imageTypes.PlatformVersionActive,
+	})
+	err = service.SetVersion(imageTypes.PlatformVersion{Platform: "python", Version: "v1", Status: imageTypes.PlatformVersionEOL})
+	c.Assert(err, check.IsNil)
+	c.Assert(stored.Status, check.Equals, imageTypes.PlatformVersionEOL)
+}
+
+func (s *S) TestPlatformSetVersionInvalidStatus(c *check.C) {
+	service := &platformImageService{
+		storage: &imageTypes.MockPlatformImageStorage{},
+	}
+	err := service.SetVersion(imageTypes.PlatformVersion{Platform: "python", Version: "v1", Status: "retired"})
+	c.Assert(err, check.Equals, imageTypes.ErrInvalidPlatformVersion)
+}
@@ -7,10 +7,13 @@ package app
 import (
 	"fmt"
 	"io/ioutil"
+	"sort"
 	"strconv"
+	"time"
 
 	"github.com/globalsign/mgo/bson"
 	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/builder"
 	"github.com/tsuru/tsuru/db"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
@@ -19,6 +22,7 @@ import (
 	"github.com/tsuru/tsuru/servicemanager"
 	"github.com/tsuru/tsuru/storage"
 	appTypes "github.com/tsuru/tsuru/types/app"
+	imageTypes "github.com/tsuru/tsuru/types/app/image"
 	"github.com/tsuru/tsuru/validation"
 )
 
@@ -69,7 +73,11 @@ func (s *platformService) Create(opts appTypes.PlatformOptions) error {
 		}
 		return err
 	}
-	return servicemanager.PlatformImage.AppendImage(opts.Name, opts.ImageName)
+	err = servicemanager.PlatformImage.AppendImage(opts.Name, opts.ImageName)
+	if err != nil {
+		return err
+	}
+	return saveBuiltVersion(opts, nil)
 }
 
 // List implements List method of PlatformService interface
@@ -124,6 +132,10 @@ func (s *platformService) Update(opts appTypes.PlatformOptions) error {
 		if err != nil {
 			return err
 		}
+		err = saveBuiltVersion(opts, nil)
+		if err != nil {
+			return err
+		}
 		var apps []App
 		err = conn.Apps().Find(bson.M{"framework": opts.Name}).All(&apps)
 		if err != nil {
@@ -190,14 +202,22 @@ func (s *platformService) Rollback(opts appTypes.PlatformOptions) error {
 	if err != nil {
 		return err
 	}
-	image, err := servicemanager.PlatformImage.FindImage(opts.Name, opts.ImageName)
+	img, err := servicemanager.PlatformImage.FindImage(opts.Name, opts.ImageName)
 	if err != nil {
 		return err
 	}
-	if image == "" {
+	if img == "" {
 		return fmt.Errorf("Image %s not found in platform %q", opts.ImageName, opts.Name)
 	}
-	opts.Data = []byte("FROM " + image)
+	_, tag := image.SplitImageName(img)
+	previous, err := servicemanager.PlatformImage.FindVersion(opts.Name, tag)
+	if err != nil && err != imageTypes.ErrPlatformVersionNotFound {
+		return err
+	}
+	if previous != nil && previous.IsEOL() {
+		return errors.Wrapf(imageTypes.ErrPlatformVersionEOL, "unable to rollback platform %q to version %q", opts.Name, tag)
+	}
+	opts.Data = []byte("FROM " + img)
 	opts.ImageName, err = servicemanager.PlatformImage.NewImage(opts.Name)
 	if err != nil {
 		return err
@@ -210,6 +230,10 @@ func (s *platformService) Rollback(opts appTypes.PlatformOptions) error {
 	if err != nil {
 		return err
 	}
+	err = saveBuiltVersion(opts, previous)
+	if err != nil {
+		return err
+	}
 	conn, err := db.Conn()
 	if err != nil {
 		return err
@@ -235,3 +259,120 @@ func (s *platformService) validate(p appTypes.Platform) error {
 	}
 	return nil
 }
+
+// saveBuiltVersion stores the metadata for the platform image that has just
+// been built. When rolling back, the runtime and changelog of the original
+// version are kept unless new values are provided, and so is its status, so
+// restoring a deprecated image doesn't make it active again.
+func saveBuiltVersion(opts appTypes.PlatformOptions, previous *imageTypes.PlatformVersion) error {
+	_, tag := image.SplitImageName(opts.ImageName)
+	version := imageTypes.PlatformVersion{
+		Platform:  opts.Name,
+		Version:   tag,
+		Image:     opts.ImageName,
+		Runtime:   opts.Args["runtime"],
+		Changelog: opts.Args["changelog"],
+		BuildDate: time.Now().UTC(),
+		Status:    imageTypes.PlatformVersionActive,
+	}
+	if previous != nil {
+		if version.Runtime == "" {
+			version.Runtime = previous.Runtime
+		}
+		if version.Changelog == "" {
+			version.Changelog = previous.Changelog
+		}
+		if previous.Status != "" {
+			version.Status = previous.Status
+		}
+	}
+	return servicemanager.PlatformImage.SetVersion(version)
+}
+
+// PlatformVersionReport describes a platform version along with the apps
+// using it.
+type PlatformVersionReport struct {
+	imageTypes.PlatformVersion
+	Apps []string
+}
+
+// PlatformVersionsReport lists every known version of the given platform,
+// oldest first, and the apps that are pinned to each one. Apps not pinned to
+// any version are reported under the current platform version.
+func PlatformVersionsReport(platformName string) ([]PlatformVersionReport, error) {
+	_, err := servicemanager.Platform.FindByName(platformName)
+	if err != nil {
+		return nil, err
+	}
+	images, err := servicemanager.PlatformImage.ListImagesOrDefault(platformName)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := servicemanager.PlatformImage.ListVersions(platformName)
+	if err != nil {
+		return nil, err
+	}
+	versionMap := make(map[string]imageTypes.PlatformVersion, len(versions))
+	for _, v := range versions {
+		versionMap[v.Version] = v
+	}
+	report := make([]PlatformVersionReport, 0, len(images))
+	reportIdx := make(map[string]int, len(images))
+	for _, img := range images {
+		_, tag := image.SplitImageName(img)
+		v, ok := versionMap[tag]
+		if !ok {
+			v = imageTypes.PlatformVersion{
+				Platform: platformName,
+				Version:  tag,
+				Image:    img,
+				Status:   imageTypes.PlatformVersionActive,
+			}
+		}
+		reportIdx[tag] = len(report)
+		report = append(report, PlatformVersionReport{PlatformVersion: v, Apps: []string{}})
+	}
+	current, err := currentPlatformVersion(platformName)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var apps []App
+	err = conn.Apps().Find(bson.M{"framework": platformName}).Select(bson.M{"name": 1, "platformversion": 1}).All(&apps)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range apps {
+		version := a.GetPlatformVersion()
+		if version == "latest" {
+			version = current
+		}
+		idx, ok := reportIdx[version]
+		if !ok {
+			reportIdx[version] = len(report)
+			idx = len(report)
+			report = append(report, PlatformVersionReport{
+				PlatformVersion: imageTypes.PlatformVersion{Platform: platformName, Version: version},
+				Apps:            []string{},
+			})
+		}
+		report[idx].Apps = append(report[idx].Apps, a.Name)
+	}
+	for i := range report {
+		sort.Strings(report[i].Apps)
+	}
+	return report, nil
+}
+
+func currentPlatformVersion(platformName string) (string, error) {
+	img, err := servicemanager.PlatformImage.CurrentImage(platformName)
+	if err != nil {
+		return "", err
+	}
+	_, tag := image.SplitImageName(img)
+	return tag, nil
+}
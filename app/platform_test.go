@@ -16,6 +16,7 @@ import (
 	"github.com/tsuru/tsuru/repository/repositorytest"
 	servicemock "github.com/tsuru/tsuru/servicemanager/mock"
 	appTypes "github.com/tsuru/tsuru/types/app"
+	imageTypes "github.com/tsuru/tsuru/types/app/image"
 	"gopkg.in/check.v1"
 )
 
@@ -584,3 +585,59 @@ func (s *PlatformSuite) TestPlatformRollback(c *check.C) {
 	err := ps.Rollback(appTypes.PlatformOptions{Name: name, ImageName: image})
 	c.Assert(err, check.IsNil)
 }
+
+func (s *PlatformSuite) TestPlatformRollbackKeepsVersionStatus(c *check.C) {
+	name := "test-platform-rollback"
+	image := "tsuru/test-platform-rollback:v1"
+	s.mockService.PlatformImage.OnFindImage = func(name, image string) (string, error) {
+		return image, nil
+	}
+	s.mockService.PlatformImage.OnFindVersion = func(platformName, version string) (*imageTypes.PlatformVersion, error) {
+		c.Assert(version, check.Equals, "v1")
+		return &imageTypes.PlatformVersion{Platform: platformName, Version: version, Runtime: "go1.9", Status: imageTypes.PlatformVersionDeprecated}, nil
+	}
+	s.mockService.PlatformImage.OnNewImage = func(platformName string) (string, error) {
+		return "tsuru/test-platform-rollback:v3", nil
+	}
+	var saved imageTypes.PlatformVersion
+	s.mockService.PlatformImage.OnSetVersion = func(v imageTypes.PlatformVersion) error {
+		saved = v
+		return nil
+	}
+	ps := &platformService{
+		storage: &appTypes.MockPlatformStorage{
+			OnFindByName: func(n string) (*appTypes.Platform, error) {
+				return &appTypes.Platform{Name: name}, nil
+			},
+		},
+	}
+	err := ps.Rollback(appTypes.PlatformOptions{Name: name, ImageName: image})
+	c.Assert(err, check.IsNil)
+	c.Assert(saved.Version, check.Equals, "v3")
+	c.Assert(saved.Runtime, check.Equals, "go1.9")
+	c.Assert(saved.Status, check.Equals, imageTypes.PlatformVersionDeprecated)
+}
+
+func (s *PlatformSuite) TestPlatformRollbackEOLVersion(c *check.C) {
+	name := "test-platform-rollback"
+	image := "tsuru/test-platform-rollback:v1"
+	s.mockService.PlatformImage.OnFindImage = func(name, image string) (string, error) {
+		return image, nil
+	}
+	s.mockService.PlatformImage.OnFindVersion = func(platformName, version string) (*imageTypes.PlatformVersion, error) {
+		return &imageTypes.PlatformVersion{Platform: platformName, Version: version, Status: imageTypes.PlatformVersionEOL}, nil
+	}
+	s.mockService.PlatformImage.OnNewImage = func(platformName string) (string, error) {
+		c.Errorf("new image not expected to be created")
+		return "", nil
+	}
+	ps := &platformService{
+		storage: &appTypes.MockPlatformStorage{
+			OnFindByName: func(n string) (*appTypes.Platform, error) {
+				return &appTypes.Platform{Name: name}, nil
+			},
+		},
+	}
+	err := ps.Rollback(appTypes.PlatformOptions{Name: name, ImageName: image})
+	c.Assert(errors.Cause(err), check.Equals, imageTypes.ErrPlatformVersionEOL)
+}
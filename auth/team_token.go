@@ -15,14 +15,16 @@ import (
 	"github.com/tsuru/tsuru/storage"
 	authTypes "github.com/tsuru/tsuru/types/auth"
 	permTypes "github.com/tsuru/tsuru/types/permission"
+	"github.com/tsuru/tsuru/types/ratelimit"
 	"github.com/tsuru/tsuru/validation"
 )
 
 type teamToken authTypes.TeamToken
 
 var (
-	_ authTypes.Token      = &teamToken{}
-	_ authTypes.NamedToken = &teamToken{}
+	_ authTypes.Token            = &teamToken{}
+	_ authTypes.NamedToken       = &teamToken{}
+	_ authTypes.RateLimitedToken = &teamToken{}
 )
 
 func (t *teamToken) GetValue() string {
@@ -49,6 +51,10 @@ func (t *teamToken) GetAppName() string {
 	return ""
 }
 
+func (t *teamToken) GetRateLimit() ratelimit.Limit {
+	return t.RateLimit
+}
+
 func (t *teamToken) Permissions() ([]permission.Permission, error) {
 	return expandRolePermissions(t.Roles)
 }
@@ -119,6 +125,10 @@ func (s *teamTokenService) Create(args authTypes.TeamTokenCreateArgs, token auth
 	if args.ExpiresIn != 0 {
 		resultToken.ExpiresAt = now.Add(time.Duration(args.ExpiresIn) * time.Second)
 	}
+	if args.RequestsPerMinute < 0 || args.Burst < 0 {
+		return authTypes.TeamToken{}, authTypes.ErrTeamTokenInvalidLimit
+	}
+	resultToken.RateLimit = ratelimit.Limit{RequestsPerMinute: args.RequestsPerMinute, Burst: args.Burst}
 	if resultToken.TokenID == "" {
 		resultToken.TokenID = fmt.Sprintf("%s-%s", resultToken.Team, resultToken.Token[:5])
 	}
@@ -198,6 +208,14 @@ func (s *teamTokenService) Update(args authTypes.TeamTokenUpdateArgs, t authType
 	if args.Regenerate {
 		token.Token = generateToken(token.Team, crypto.SHA256)
 	}
+	if args.Burst < 0 {
+		return authTypes.TeamToken{}, authTypes.ErrTeamTokenInvalidLimit
+	}
+	if args.RequestsPerMinute > 0 {
+		token.RateLimit = ratelimit.Limit{RequestsPerMinute: args.RequestsPerMinute, Burst: args.Burst}
+	} else if args.RequestsPerMinute < 0 {
+		token.RateLimit = ratelimit.Limit{}
+	}
 	err = s.storage.Update(*token)
 	if err != nil {
 		return authTypes.TeamToken{}, err
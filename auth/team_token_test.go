@@ -14,6 +14,7 @@ import (
 	"github.com/tsuru/tsuru/servicemanager"
 	authTypes "github.com/tsuru/tsuru/types/auth"
 	permTypes "github.com/tsuru/tsuru/types/permission"
+	"github.com/tsuru/tsuru/types/ratelimit"
 	"gopkg.in/check.v1"
 )
 
@@ -381,6 +382,48 @@ func (s *S) Test_TeamTokenService_Update_Expires(c *check.C) {
 	c.Assert(updatedToken.ExpiresAt.IsZero(), check.Equals, true)
 }
 
+func (s *S) Test_TeamTokenService_RateLimit(c *check.C) {
+	token, err := servicemanager.TeamToken.Create(authTypes.TeamTokenCreateArgs{
+		Team:              s.team.Name,
+		TokenID:           "t1",
+		RequestsPerMinute: 60,
+		Burst:             10,
+	}, &userToken{user: s.user})
+	c.Assert(err, check.IsNil)
+	c.Assert(token.RateLimit, check.Equals, ratelimit.Limit{RequestsPerMinute: 60, Burst: 10})
+	updatedToken, err := servicemanager.TeamToken.Update(authTypes.TeamTokenUpdateArgs{
+		TokenID:     "t1",
+		Description: "ci",
+	}, &userToken{user: s.user})
+	c.Assert(err, check.IsNil)
+	c.Assert(updatedToken.RateLimit, check.Equals, ratelimit.Limit{RequestsPerMinute: 60, Burst: 10})
+	updatedToken, err = servicemanager.TeamToken.Update(authTypes.TeamTokenUpdateArgs{
+		TokenID:           "t1",
+		RequestsPerMinute: 30,
+	}, &userToken{user: s.user})
+	c.Assert(err, check.IsNil)
+	c.Assert(updatedToken.RateLimit, check.Equals, ratelimit.Limit{RequestsPerMinute: 30})
+	t, err := servicemanager.TeamToken.Authenticate("bearer " + token.Token)
+	c.Assert(err, check.IsNil)
+	c.Assert(t.(authTypes.RateLimitedToken).GetRateLimit(), check.Equals, ratelimit.Limit{RequestsPerMinute: 30})
+	updatedToken, err = servicemanager.TeamToken.Update(authTypes.TeamTokenUpdateArgs{
+		TokenID:           "t1",
+		RequestsPerMinute: -1,
+	}, &userToken{user: s.user})
+	c.Assert(err, check.IsNil)
+	c.Assert(updatedToken.RateLimit, check.Equals, ratelimit.Limit{})
+	_, err = servicemanager.TeamToken.Update(authTypes.TeamTokenUpdateArgs{
+		TokenID: "t1",
+		Burst:   -1,
+	}, &userToken{user: s.user})
+	c.Assert(err, check.Equals, authTypes.ErrTeamTokenInvalidLimit)
+	_, err = servicemanager.TeamToken.Create(authTypes.TeamTokenCreateArgs{
+		Team:              s.team.Name,
+		RequestsPerMinute: -1,
+	}, &userToken{user: s.user})
+	c.Assert(err, check.Equals, authTypes.ErrTeamTokenInvalidLimit)
+}
+
 func (s *S) Test_TeamToken_Permissions(c *check.C) {
 	r1, err := permission.NewRole("app-deployer", "app", "")
 	c.Assert(err, check.IsNil)
@@ -16,6 +16,7 @@ import (
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/api/shutdown"
 	"github.com/tsuru/tsuru/app"
+	internalConfig "github.com/tsuru/tsuru/config"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/iaas"
@@ -42,6 +43,7 @@ type Config struct {
 	RunInterval         time.Duration
 	TotalMemoryMetadata string
 	done                chan bool
+	reload              chan *Config
 	writer              io.Writer
 	running             bool
 	Enabled             bool
@@ -59,6 +61,25 @@ func Initialize() error {
 	shutdown.Register(globalConfig)
 	globalConfig.running = true
 	go globalConfig.run()
+	internalConfig.Subscribe("node autoscale", []string{
+		"docker:auto-scale",
+		"docker:scheduler:total-memory-metadata",
+	}, reloadConfig)
+	return nil
+}
+
+// reloadConfig hands the current settings to the running autoscale, which
+// applies them before its next run. Rules read from the configuration file
+// are already read again on each run.
+func reloadConfig() error {
+	if globalConfig == nil || !globalConfig.running {
+		return nil
+	}
+	select {
+	case <-globalConfig.reload:
+	default:
+	}
+	globalConfig.reload <- newConfig()
 	return nil
 }
 
@@ -78,6 +99,7 @@ func newConfig() *Config {
 		RunInterval:         time.Duration(runInterval) * time.Second,
 		Enabled:             true,
 		done:                make(chan bool),
+		reload:              make(chan *Config, 1),
 	}
 	if c.RunInterval == 0 {
 		c.RunInterval = time.Hour
@@ -121,10 +143,26 @@ func (a *Config) run() error {
 			a.logError(err.Error())
 			err = errors.Wrap(err, "[node autoscale]")
 		}
+		if !a.wait() {
+			return err
+		}
+	}
+}
+
+// wait sleeps until the next run, returning false when the autoscale is shut
+// down. Reloaded settings are applied right away, restarting the wait with
+// the new run interval.
+func (a *Config) wait() bool {
+	for {
 		select {
 		case <-a.done:
-			return err
+			return false
+		case conf := <-a.reload:
+			a.WaitTimeNewMachine = conf.WaitTimeNewMachine
+			a.RunInterval = conf.RunInterval
+			a.TotalMemoryMetadata = conf.TotalMemoryMetadata
 		case <-time.After(a.RunInterval):
+			return true
 		}
 	}
 }
@@ -1196,3 +1196,31 @@ func (s *S) TestChooseMetadataFromNodes(c *check.C) {
 	_, err = chooseMetadataFromNodes(nodes)
 	c.Assert(err, check.ErrorMatches, "unbalanced metadata for node group:.*")
 }
+
+func (s *S) TestReloadConfig(c *check.C) {
+	config.Set("docker:auto-scale:run-interval", 3600)
+	defer config.Unset("docker:auto-scale:run-interval")
+	conf := newConfig()
+	conf.running = true
+	globalConfig = conf
+	defer func() { globalConfig = nil }()
+	config.Set("docker:auto-scale:run-interval", 1)
+	config.Set("docker:auto-scale:wait-new-time", 10)
+	defer config.Unset("docker:auto-scale:wait-new-time")
+	err := reloadConfig()
+	c.Assert(err, check.IsNil)
+	err = reloadConfig()
+	c.Assert(err, check.IsNil)
+	done := make(chan bool)
+	go func() {
+		done <- conf.wait()
+	}()
+	select {
+	case next := <-done:
+		c.Assert(next, check.Equals, true)
+	case <-time.After(5 * time.Second):
+		c.Fatal("timeout waiting for the reloaded run interval")
+	}
+	c.Assert(conf.RunInterval, check.Equals, time.Second)
+	c.Assert(conf.WaitTimeNewMachine, check.Equals, 10*time.Second)
+}
@@ -13,7 +13,9 @@ import (
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/servicemanager"
 	appTypes "github.com/tsuru/tsuru/types/app"
+	imageTypes "github.com/tsuru/tsuru/types/app/image"
 )
 
 var DefaultBuilder = "docker"
@@ -29,6 +31,9 @@ type BuildOpts struct {
 	ArchiveSize         int64
 	ImageID             string
 	Tag                 string
+	// NoCache disables restoring the app build cache, the cache is still
+	// refreshed with the result of the build.
+	NoCache bool
 }
 
 // Builder is the basic interface of this package.
@@ -157,3 +162,21 @@ func CompressDockerFile(data []byte) io.Reader {
 	writer.Close()
 	return &buf
 }
+
+// PlatformCacheImages returns the latest successfully built image of the
+// platform, which is used as a layer cache source for the new build.
+func PlatformCacheImages(platformName, newImage string) ([]string, error) {
+	images, err := servicemanager.PlatformImage.ListImages(platformName)
+	if err == imageTypes.ErrPlatformImageNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := len(images) - 1; i >= 0; i-- {
+		if images[i] != newImage {
+			return []string{images[i]}, nil
+		}
+	}
+	return nil, nil
+}
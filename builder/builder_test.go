@@ -8,7 +8,9 @@ import (
 	"errors"
 	"testing"
 
+	servicemock "github.com/tsuru/tsuru/servicemanager/mock"
 	appTypes "github.com/tsuru/tsuru/types/app"
+	imageTypes "github.com/tsuru/tsuru/types/app/image"
 	check "gopkg.in/check.v1"
 )
 
@@ -148,3 +150,29 @@ func (s S) TestPlatformRemoveNoBuilder(c *check.C) {
 	err := PlatformRemove("platform-name")
 	c.Assert(err, check.ErrorMatches, "No builder available")
 }
+
+func (s S) TestPlatformCacheImages(c *check.C) {
+	var mockService servicemock.MockService
+	servicemock.SetMockService(&mockService)
+	mockService.PlatformImage.OnListImages = func(name string) ([]string, error) {
+		c.Assert(name, check.Equals, "python")
+		return []string{"tsuru/python:v1", "tsuru/python:v2"}, nil
+	}
+	images, err := PlatformCacheImages("python", "tsuru/python:v3")
+	c.Assert(err, check.IsNil)
+	c.Assert(images, check.DeepEquals, []string{"tsuru/python:v2"})
+	images, err = PlatformCacheImages("python", "tsuru/python:v2")
+	c.Assert(err, check.IsNil)
+	c.Assert(images, check.DeepEquals, []string{"tsuru/python:v1"})
+}
+
+func (s S) TestPlatformCacheImagesNoImages(c *check.C) {
+	var mockService servicemock.MockService
+	servicemock.SetMockService(&mockService)
+	mockService.PlatformImage.OnListImages = func(name string) ([]string, error) {
+		return nil, imageTypes.ErrPlatformImageNotFound
+	}
+	images, err := PlatformCacheImages("python", "tsuru/python:v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(images, check.IsNil)
+}
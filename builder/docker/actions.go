@@ -35,6 +35,8 @@ type runContainerActionsArgs struct {
 	exposedPort   string
 	event         *event.Event
 	tarFile       io.Reader
+	cacheImage    string
+	cacheDirs     []string
 }
 
 func checkCanceled(evt *event.Event) error {
@@ -93,7 +93,7 @@ func (b *dockerBuilder) Build(prov provision.BuilderDeploy, app provision.App, e
 		return "", errors.New("no valid files found")
 	}
 	defer tarFile.Close()
-	imageID, err := b.buildPipeline(p, client, app, tarFile, evt, opts.Tag)
+	imageID, err := b.buildPipeline(p, client, app, tarFile, evt, opts.Tag, opts.NoCache)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,117 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/fsouza/go-dockerclient"
+	"github.com/tsuru/tsuru/action"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/docker/container"
+	"github.com/tsuru/tsuru/provision/dockercommon"
+)
+
+const buildCacheBaseDir = defaultArchivePath + "/current"
+
+// buildCacheDirs returns the absolute paths of the cache directories declared
+// in the tsuru.yaml of the current app image.
+func buildCacheDirs(app provision.App) []string {
+	imageName, err := image.AppCurrentImageName(app.GetName())
+	if err != nil || imageName == "" {
+		return nil
+	}
+	yamlData, err := image.GetImageTsuruYamlData(imageName)
+	if err != nil {
+		log.Errorf("[build-cache] unable to get tsuru.yaml for app %q: %v", app.GetName(), err)
+		return nil
+	}
+	var dirs []string
+	for _, dir := range yamlData.Build.Cache {
+		if dir == "" {
+			continue
+		}
+		if !path.IsAbs(dir) {
+			dir = path.Join(buildCacheBaseDir, dir)
+		}
+		dirs = append(dirs, path.Clean(dir))
+	}
+	return dirs
+}
+
+// loadBuildCache fills the cache image and directories restored in the build
+// container, unless the cache is disabled or the app has no cache.
+func loadBuildCache(app provision.App, args *runContainerActionsArgs, noCache bool) error {
+	if noCache {
+		return nil
+	}
+	dirs := buildCacheDirs(app)
+	if len(dirs) == 0 {
+		return nil
+	}
+	cacheImage, err := image.AppBuildCacheImageName(app.GetName())
+	if err != nil {
+		return err
+	}
+	args.cacheImage = cacheImage
+	args.cacheDirs = dirs
+	return nil
+}
+
+// copyBuildCache copies the cache directories from a container created from
+// the cache image into the build container. Directories missing in the cache
+// image are skipped.
+func copyBuildCache(args runContainerActionsArgs, contID string) error {
+	cacheCont, _, err := args.client.PullAndCreateContainer(docker.CreateContainerOptions{
+		Config: &docker.Config{Image: args.cacheImage},
+	}, nil)
+	if err != nil {
+		return err
+	}
+	defer args.client.RemoveContainer(docker.RemoveContainerOptions{ID: cacheCont.ID, Force: true})
+	for _, dir := range args.cacheDirs {
+		tarFile, err := dockercommon.DownloadFromContainer(args.client, cacheCont.ID, dir)
+		if err != nil {
+			return err
+		}
+		err = args.client.UploadToContainer(contID, docker.UploadToContainerOptions{
+			InputStream: tarFile,
+			Path:        path.Dir(dir),
+		})
+		tarFile.Close()
+		if err != nil {
+			log.Errorf("[build-cache] unable to restore %q for app %q: %v", dir, args.app.GetName(), err)
+			continue
+		}
+		fmt.Fprintf(args.writer, " ---> Restored build cache %q\n", dir)
+	}
+	return nil
+}
+
+var restoreBuildCache = action.Action{
+	Name: "restore-build-cache",
+	Forward: func(ctx action.FWContext) (action.Result, error) {
+		args := ctx.Params[0].(runContainerActionsArgs)
+		if err := checkCanceled(args.event); err != nil {
+			return nil, err
+		}
+		c := ctx.Previous.(container.Container)
+		if args.cacheImage == "" || len(args.cacheDirs) == 0 {
+			return c, nil
+		}
+		fmt.Fprintf(args.writer, "---- Restoring build cache ----\n")
+		err := copyBuildCache(args, c.ID)
+		if err != nil {
+			log.Errorf("[build-cache] unable to restore cache for app %q, building without cache: %v", args.app.GetName(), err)
+			fmt.Fprintf(args.writer, " ---> Unable to restore build cache, building without it\n")
+		}
+		return c, nil
+	},
+	Backward: func(ctx action.BWContext) {
+	},
+}
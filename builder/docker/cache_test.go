@@ -0,0 +1,89 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package docker
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/builder"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestBuildCacheDirs(c *check.C) {
+	a := &app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	c.Assert(buildCacheDirs(a), check.IsNil)
+	err := image.AppendAppImageName(a.Name, "tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData("tsuru/app-myapp:v1", map[string]interface{}{
+		"build": map[string]interface{}{
+			"cache": []string{"node_modules", "/home/application/.npm", "", "vendor/../.cache"},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(buildCacheDirs(a), check.DeepEquals, []string{
+		"/home/application/current/node_modules",
+		"/home/application/.npm",
+		"/home/application/current/.cache",
+	})
+}
+
+func (s *S) TestLoadBuildCache(c *check.C) {
+	a := &app.App{Name: "myapp", Platform: "python", TeamOwner: s.team.Name}
+	err := image.AppendAppImageName(a.Name, "tsuru/app-myapp:v1")
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData("tsuru/app-myapp:v1", map[string]interface{}{
+		"build": map[string]interface{}{"cache": []string{"node_modules"}},
+	})
+	c.Assert(err, check.IsNil)
+	err = image.SetAppBuildCacheImageName(a.Name, "tsuru/app-myapp:v1-builder")
+	c.Assert(err, check.IsNil)
+	var args runContainerActionsArgs
+	err = loadBuildCache(a, &args, true)
+	c.Assert(err, check.IsNil)
+	c.Assert(args.cacheImage, check.Equals, "")
+	c.Assert(args.cacheDirs, check.IsNil)
+	err = loadBuildCache(a, &args, false)
+	c.Assert(err, check.IsNil)
+	c.Assert(args.cacheImage, check.Equals, "tsuru/app-myapp:v1-builder")
+	c.Assert(args.cacheDirs, check.DeepEquals, []string{"/home/application/current/node_modules"})
+}
+
+func (s *S) TestBuilderArchiveURLSavesBuildCache(c *check.C) {
+	stopCh := s.stopContainers(s.server.URL(), 1)
+	defer func() { <-stopCh }()
+	opts := provision.AddNodeOptions{Address: s.server.URL()}
+	err := s.provisioner.AddNode(opts)
+	c.Assert(err, check.IsNil)
+	a := &app.App{Name: "myapp", Platform: "whitespace", TeamOwner: s.team.Name}
+	err = app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("my archive data"))
+	}))
+	defer ts.Close()
+	evt, err := event.New(&event.Opts{
+		Target:  event.Target{Type: event.TargetTypeApp, Value: a.GetName()},
+		Kind:    permission.PermAppDeploy,
+		Owner:   s.token,
+		Allowed: event.Allowed(permission.PermAppDeploy),
+	})
+	c.Assert(err, check.IsNil)
+	bopts := builder.BuildOpts{
+		ArchiveURL: ts.URL + "/myfile.tgz",
+		NoCache:    true,
+	}
+	imgID, err := s.b.Build(s.provisioner, a, evt, &bopts)
+	c.Assert(err, check.IsNil)
+	cacheImage, err := image.AppBuildCacheImageName(a.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(cacheImage, check.Equals, imgID)
+}
@@ -27,9 +27,10 @@ const (
 	archiveFileName = "archive.tar.gz"
 )
 
-func (b *dockerBuilder) buildPipeline(p provision.BuilderDeployDockerClient, client provision.BuilderDockerClient, app provision.App, tarFile io.Reader, evt *event.Event, imageTag string) (string, error) {
+func (b *dockerBuilder) buildPipeline(p provision.BuilderDeployDockerClient, client provision.BuilderDockerClient, app provision.App, tarFile io.Reader, evt *event.Event, imageTag string, noCache bool) (string, error) {
 	actions := []*action.Action{
 		&createContainer,
+		&restoreBuildCache,
 		&uploadToContainer,
 		&startContainer,
 		&followLogsAndCommit,
@@ -62,11 +63,19 @@ func (b *dockerBuilder) buildPipeline(p provision.BuilderDeployDockerClient, cli
 		tarFile:       tarFile,
 		isDeploy:      true,
 	}
+	err = loadBuildCache(app, &args, noCache)
+	if err != nil {
+		log.Errorf("[build-cache] unable to load build cache for app %s, building without cache: %s", app.GetName(), err)
+	}
 	err = container.RunPipelineWithRetry(pipeline, args)
 	if err != nil {
 		log.Errorf("error on execute build pipeline for app %s - %s", app.GetName(), err)
 		return "", err
 	}
+	err = image.SetAppBuildCacheImageName(app.GetName(), buildingImage)
+	if err != nil {
+		log.Errorf("[build-cache] unable to save build cache image for app %s: %s", app.GetName(), err)
+	}
 	return buildingImage, nil
 }
 
@@ -60,10 +60,24 @@ func (b *dockerBuilder) buildPlatform(opts appTypes.PlatformOptions) error {
 	}
 	inputStream := builder.CompressDockerFile(opts.Data)
 	client.SetTimeout(0)
+	var cacheFrom []string
+	if !opts.NoCache {
+		cacheFrom, err = builder.PlatformCacheImages(opts.Name, opts.ImageName)
+		if err != nil {
+			return err
+		}
+	}
+	var buildArgs []docker.BuildArg
+	for name, value := range opts.BuildArgs {
+		buildArgs = append(buildArgs, docker.BuildArg{Name: name, Value: value})
+	}
 	buildOptions := docker.BuildImageOptions{
 		Name:              opts.ImageName,
+		BuildArgs:         buildArgs,
+		Target:            opts.Target,
 		Pull:              true,
-		NoCache:           true,
+		NoCache:           opts.NoCache,
+		CacheFrom:         cacheFrom,
 		RmTmpContainer:    true,
 		InputStream:       inputStream,
 		OutputStream:      &tsuruIo.DockerErrorCheckWriter{W: opts.Output},
@@ -45,9 +45,44 @@ func (s *S) TestPlatformAdd(c *check.C) {
 	queryString := requests[0].URL.Query()
 	c.Assert(queryString.Get("t"), check.Equals, "localhost:3030/tsuru/test:v1")
 	c.Assert(queryString.Get("remote"), check.Equals, "")
+	c.Assert(queryString.Get("nocache"), check.Equals, "")
 	c.Assert(requests[1].URL.Path, check.Equals, "/images/localhost:3030/tsuru/test/push")
 }
 
+func (s *S) TestPlatformAddNoCache(c *check.C) {
+	var requests []*http.Request
+	server, err := testing.NewServer("127.0.0.1:0", nil, func(r *http.Request) {
+		requests = append(requests, r)
+	})
+	c.Assert(err, check.IsNil)
+	defer server.Stop()
+	err = s.provisioner.AddNode(provision.AddNodeOptions{Address: server.URL()})
+	c.Assert(err, check.IsNil)
+	config.Set("docker:registry", "localhost:3030")
+	defer config.Unset("docker:registry")
+	s.mockService.PlatformImage.OnListImages = func(name string) ([]string, error) {
+		c.Errorf("cache images not expected to be listed")
+		return nil, nil
+	}
+	var b dockerBuilder
+	err = b.PlatformAdd(appTypes.PlatformOptions{
+		Name:      "test",
+		ImageName: "localhost:3030/tsuru/test:v2",
+		Output:    ioutil.Discard,
+		Data:      []byte("FROM tsuru/java"),
+		BuildArgs: map[string]string{"JAVA_VERSION": "8"},
+		Target:    "runtime",
+		NoCache:   true,
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(len(requests) >= 2, check.Equals, true)
+	requests = requests[len(requests)-2:]
+	c.Assert(requests[0].URL.Path, check.Equals, "/build")
+	queryString := requests[0].URL.Query()
+	c.Assert(queryString.Get("nocache"), check.Equals, "1")
+	c.Assert(queryString.Get("target"), check.Equals, "runtime")
+}
+
 func (s *S) TestPlatformAddProvisionerError(c *check.C) {
 	var requests []*http.Request
 	server, err := testing.NewServer("127.0.0.1:0", nil, func(r *http.Request) {
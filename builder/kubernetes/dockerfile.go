@@ -0,0 +1,60 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+)
+
+var argValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`)
+
+// dockerfileWithBuildOptions applies the build args and the target stage to
+// the Dockerfile itself, as the deploy agent building images in the cluster
+// only builds the last stage of a Dockerfile, without build args. Build args
+// become the default value of the matching ARG instructions, args not
+// declared in the Dockerfile are ignored, like docker does. Instructions
+// after the target stage are removed, making it the last stage.
+func dockerfileWithBuildOptions(data []byte, buildArgs map[string]string, target string) ([]byte, error) {
+	if len(buildArgs) == 0 && target == "" {
+		return data, nil
+	}
+	var result []string
+	var continued, inTarget, targetFound bool
+	for _, line := range strings.SplitAfter(string(data), "\n") {
+		isInstruction := !continued
+		continued = strings.HasSuffix(strings.TrimRight(line, " \t\r\n"), `\`)
+		fields := strings.Fields(line)
+		if !isInstruction || len(fields) == 0 {
+			result = append(result, line)
+			continue
+		}
+		switch strings.ToUpper(fields[0]) {
+		case "FROM":
+			if inTarget {
+				return []byte(strings.Join(result, "")), nil
+			}
+			if target != "" && len(fields) >= 4 && strings.EqualFold(fields[len(fields)-2], "as") && strings.EqualFold(fields[len(fields)-1], target) {
+				inTarget = true
+				targetFound = true
+			}
+		case "ARG":
+			if len(fields) != 2 || continued {
+				break
+			}
+			name := strings.SplitN(fields[1], "=", 2)[0]
+			if value, ok := buildArgs[name]; ok {
+				line = fmt.Sprintf("ARG %s=\"%s\"\n", name, argValueReplacer.Replace(value))
+			}
+		}
+		result = append(result, line)
+	}
+	if target != "" && !targetFound {
+		return nil, &tsuruErrors.ValidationError{Message: fmt.Sprintf("target stage %q not found in Dockerfile", target)}
+	}
+	return []byte(strings.Join(result, "")), nil
+}
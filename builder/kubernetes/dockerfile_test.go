@@ -0,0 +1,59 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	check "gopkg.in/check.v1"
+)
+
+const multiStageDockerfile = `ARG BASE=ubuntu:16.04
+FROM $BASE AS build
+ARG PYTHON_VERSION=2.7
+ARG DEBUG
+RUN apt-get install -y \
+    python$PYTHON_VERSION
+FROM build as runtime
+RUN make install
+FROM runtime AS test
+RUN make test
+`
+
+func (s *S) TestDockerfileWithBuildOptions(c *check.C) {
+	data, err := dockerfileWithBuildOptions([]byte(multiStageDockerfile), map[string]string{
+		"BASE":           "ubuntu:18.04",
+		"PYTHON_VERSION": "3.6",
+		"DEBUG":          `"$1"`,
+		"UNUSED":         "x",
+	}, "Runtime")
+	c.Assert(err, check.IsNil)
+	c.Assert(string(data), check.Equals, `ARG BASE="ubuntu:18.04"
+FROM $BASE AS build
+ARG PYTHON_VERSION="3.6"
+ARG DEBUG="\"\$1\""
+RUN apt-get install -y \
+    python$PYTHON_VERSION
+FROM build as runtime
+RUN make install
+`)
+}
+
+func (s *S) TestDockerfileWithBuildOptionsNoOptions(c *check.C) {
+	data, err := dockerfileWithBuildOptions([]byte(multiStageDockerfile), nil, "")
+	c.Assert(err, check.IsNil)
+	c.Assert(string(data), check.Equals, multiStageDockerfile)
+}
+
+func (s *S) TestDockerfileWithBuildOptionsTargetNotFound(c *check.C) {
+	_, err := dockerfileWithBuildOptions([]byte(multiStageDockerfile), nil, "deploy")
+	c.Assert(err, check.DeepEquals, &tsuruErrors.ValidationError{Message: `target stage "deploy" not found in Dockerfile`})
+}
+
+func (s *S) TestBuildPlatformNoCacheUnsupported(c *check.C) {
+	b := &kubernetesBuilder{}
+	err := b.PlatformUpdate(appTypes.PlatformOptions{Name: "python", NoCache: true})
+	c.Assert(err, check.DeepEquals, &tsuruErrors.ValidationError{Message: "building platforms without cache is not supported by the kubernetes builder"})
+}
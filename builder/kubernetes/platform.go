@@ -5,6 +5,13 @@
 package kubernetes
 
 import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+	"time"
+
 	"github.com/pkg/errors"
 	"github.com/tsuru/tsuru/builder"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
@@ -26,13 +33,105 @@ func (b *kubernetesBuilder) PlatformRemove(name string) error {
 	// Kubernetes already removes unused images on nodes.
 	return nil
 }
+// buildPlatform builds the platform image in a pod running the deploy agent.
+// The agent doesn't receive build options, so build args and the target
+// stage are applied to the Dockerfile before sending it. Layers are reused
+// from the Docker cache of the node running the build, which can't be
+// disabled.
 func (b *kubernetesBuilder) buildPlatform(opts appTypes.PlatformOptions) error {
+	if opts.NoCache {
+		return &tsuruErrors.ValidationError{Message: "building platforms without cache is not supported by the kubernetes builder"}
+	}
 	client, err := getKubeClient()
 	if err != nil {
 		return err
 	}
-	inputStream := builder.CompressDockerFile(opts.Data)
-	return client.BuildImage(opts.Name, opts.ImageName, inputStream, opts.Output, opts.Ctx)
+	data, err := dockerfileWithBuildOptions(opts.Data, opts.BuildArgs, opts.Target)
+	if err != nil {
+		return err
+	}
+	output := opts.Output
+	if output != nil {
+		timingWriter := newStepTimingWriter(output)
+		defer timingWriter.Close()
+		output = timingWriter
+	}
+	inputStream := builder.CompressDockerFile(data)
+	return client.BuildImage(provision.BuildImageOpts{
+		Name:   opts.Name,
+		Image:  opts.ImageName,
+		Input:  inputStream,
+		Output: output,
+		Ctx:    opts.Ctx,
+	})
+}
+
+var buildStepRegexp = regexp.MustCompile(`^Step (\d+/\d+) : `)
+
+// stepTimingWriter forwards a Dockerfile build output line by line, adding
+// the time spent on each build step after it finishes.
+type stepTimingWriter struct {
+	mu        sync.Mutex
+	w         io.Writer
+	buf       []byte
+	step      string
+	stepStart time.Time
+	start     time.Time
+	now       func() time.Time
+}
+
+func newStepTimingWriter(w io.Writer) *stepTimingWriter {
+	tw := &stepTimingWriter{w: w, now: time.Now}
+	tw.start = tw.now()
+	return tw
+}
+
+func (w *stepTimingWriter) Write(data []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.buf = append(w.buf, data...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		line := w.buf[:idx+1]
+		if matches := buildStepRegexp.FindSubmatch(line); matches != nil {
+			w.finishStep()
+			w.step = string(matches[1])
+			w.stepStart = w.now()
+		}
+		if _, err := w.w.Write(line); err != nil {
+			return 0, err
+		}
+		w.buf = w.buf[idx+1:]
+	}
+	return len(data), nil
+}
+
+func (w *stepTimingWriter) finishStep() {
+	if w.step == "" {
+		return
+	}
+	fmt.Fprintf(w.w, " ---> Step %s took %s\n", w.step, w.now().Sub(w.stepStart).Round(time.Millisecond))
+	w.step = ""
+}
+
+// Close flushes any pending output and reports the timing of the last step
+// and of the whole build.
+func (w *stepTimingWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.w.Write(append(w.buf, '\n'))
+		w.buf = nil
+	}
+	if w.step == "" {
+		return nil
+	}
+	w.finishStep()
+	fmt.Fprintf(w.w, " ---> Build took %s\n", w.now().Sub(w.start).Round(time.Millisecond))
+	return nil
 }
 
 func getKubeClient() (provision.BuilderKubeClient, error) {
@@ -0,0 +1,34 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"bytes"
+	"time"
+
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) TestStepTimingWriter(c *check.C) {
+	var buf bytes.Buffer
+	current := time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC)
+	w := &stepTimingWriter{w: &buf, now: func() time.Time { return current }, start: current}
+	w.Write([]byte("Step 1/2 : FROM ubuntu\n ---> 123\nStep 2/2 "))
+	current = current.Add(2 * time.Second)
+	w.Write([]byte(": RUN make\nbuilding...\n"))
+	current = current.Add(1500 * time.Millisecond)
+	w.Write([]byte("Successfully built 456"))
+	err := w.Close()
+	c.Assert(err, check.IsNil)
+	c.Assert(buf.String(), check.Equals, `Step 1/2 : FROM ubuntu
+ ---> 123
+ ---> Step 1/2 took 2s
+Step 2/2 : RUN make
+building...
+Successfully built 456
+ ---> Step 2/2 took 1.5s
+ ---> Build took 3.5s
+`)
+}
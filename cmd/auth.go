@@ -34,15 +34,15 @@ func nativeLogin(context *Context, client *Client) error {
 	if len(context.Args) > 0 {
 		email = context.Args[0]
 	} else {
-		fmt.Fprint(context.Stdout, "Email: ")
+		fmt.Fprint(context.PromptOutput(), "Email: ")
 		fmt.Fscanf(context.Stdin, "%s\n", &email)
 	}
-	fmt.Fprint(context.Stdout, "Password: ")
+	fmt.Fprint(context.PromptOutput(), "Password: ")
 	password, err := PasswordFromReader(context.Stdin)
 	if err != nil {
 		return err
 	}
-	fmt.Fprintln(context.Stdout)
+	fmt.Fprintln(context.PromptOutput())
 	u, err := GetURL("/users/" + email + "/tokens")
 	if err != nil {
 		return err
@@ -69,8 +69,11 @@ func nativeLogin(context *Context, client *Client) error {
 	if err != nil {
 		return err
 	}
-	fmt.Fprintln(context.Stdout, "Successfully logged in!")
-	return writeToken(out["token"].(string))
+	err = writeToken(out["token"].(string))
+	if err != nil {
+		return err
+	}
+	return context.Render(Message("Successfully logged in!"))
 }
 
 func (c *login) getScheme() *loginScheme {
@@ -137,8 +140,7 @@ func (c *logout) Run(context *Context, client *Client) error {
 	if err != nil && os.IsNotExist(err) && errTokend != nil {
 		return errors.New("You're not logged in!")
 	}
-	fmt.Fprintln(context.Stdout, "Successfully logged out!")
-	return nil
+	return context.Render(Message("Successfully logged out!"))
 }
 
 type APIRolePermissionData struct {
@@ -210,21 +212,33 @@ func (userInfo) Info() *Info {
 	}
 }
 
+type userInfoResult struct {
+	Email       string   `json:"email"`
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+func (u *userInfoResult) RenderTable(w io.Writer) error {
+	fmt.Fprintf(w, "Email: %s\n", u.Email)
+	if len(u.Roles) > 0 {
+		fmt.Fprintf(w, "Roles:\n\t%s\n", strings.Join(u.Roles, "\n\t"))
+	}
+	if len(u.Permissions) > 0 {
+		fmt.Fprintf(w, "Permissions:\n\t%s\n", strings.Join(u.Permissions, "\n\t"))
+	}
+	return nil
+}
+
 func (userInfo) Run(context *Context, client *Client) error {
 	u, err := GetUser(client)
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(context.Stdout, "Email: %s\n", u.Email)
-	roles := u.RoleInstances()
-	if len(roles) > 0 {
-		fmt.Fprintf(context.Stdout, "Roles:\n\t%s\n", strings.Join(roles, "\n\t"))
-	}
-	perms := u.PermissionInstances()
-	if len(perms) > 0 {
-		fmt.Fprintf(context.Stdout, "Permissions:\n\t%s\n", strings.Join(perms, "\n\t"))
-	}
-	return nil
+	return context.Render(&userInfoResult{
+		Email:       u.Email,
+		Roles:       u.RoleInstances(),
+		Permissions: u.PermissionInstances(),
+	})
 }
 
 func PasswordFromReader(reader io.Reader) (string, error) {
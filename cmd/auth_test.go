@@ -40,7 +40,7 @@ func (s *S) TestNativeLogin(c *check.C) {
 	}()
 	expected := "Password: \nSuccessfully logged in!\n"
 	reader := strings.NewReader("chico\n")
-	context := Context{[]string{"foo@foo.com"}, globalManager.stdout, globalManager.stderr, reader}
+	context := Context{Args: []string{"foo@foo.com"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: reader}
 	transport := cmdtest.ConditionalTransport{
 		Transport: cmdtest.Transport{
 			Message: `{"token": "sometoken", "is_admin": true}`,
@@ -73,7 +73,7 @@ func (s *S) TestNativeLoginWithoutEmailFromArg(c *check.C) {
 	}()
 	expected := "Email: Password: \nSuccessfully logged in!\n"
 	reader := strings.NewReader("chico@tsuru.io\nchico\n")
-	context := Context{[]string{}, globalManager.stdout, globalManager.stderr, reader}
+	context := Context{Args: []string{}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: reader}
 	transport := cmdtest.ConditionalTransport{
 		Transport: cmdtest.Transport{
 			Message: `{"token": "sometoken", "is_admin": true}`,
@@ -105,7 +105,7 @@ func (s *S) TestNativeLoginShouldNotDependOnTsuruTokenFile(c *check.C) {
 	}()
 	expected := "Password: \nSuccessfully logged in!\n"
 	reader := strings.NewReader("chico\n")
-	context := Context{[]string{"foo@foo.com"}, globalManager.stdout, globalManager.stderr, reader}
+	context := Context{Args: []string{"foo@foo.com"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: reader}
 	client := NewClient(&http.Client{Transport: &cmdtest.Transport{Message: `{"token":"anothertoken"}`, Status: http.StatusOK}}, nil, globalManager)
 	command := login{}
 	err := command.Run(&context, client)
@@ -115,7 +115,7 @@ func (s *S) TestNativeLoginShouldNotDependOnTsuruTokenFile(c *check.C) {
 
 func (s *S) TestNativeLoginShouldReturnErrorIfThePasswordIsNotGiven(c *check.C) {
 	nativeScheme()
-	context := Context{[]string{"foo@foo.com"}, globalManager.stdout, globalManager.stderr, strings.NewReader("\n")}
+	context := Context{Args: []string{"foo@foo.com"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: strings.NewReader("\n")}
 	command := login{}
 	err := command.Run(&context, nil)
 	c.Assert(err, check.NotNil)
@@ -132,7 +132,7 @@ func (s *S) TestLogout(c *check.C) {
 	writeToken("mytoken")
 	os.Setenv("TSURU_TARGET", "localhost:8080")
 	expected := "Successfully logged out!\n"
-	context := Context{[]string{}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := Context{Args: []string{}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	command := logout{}
 	transport := cmdtest.ConditionalTransport{
 		Transport: cmdtest.Transport{
@@ -160,7 +160,7 @@ func (s *S) TestLogoutWhenNotLoggedIn(c *check.C) {
 	defer func() {
 		fsystem = nil
 	}()
-	context := Context{[]string{}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := Context{Args: []string{}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	command := logout{}
 	err := command.Run(&context, nil)
 	c.Assert(err, check.NotNil)
@@ -175,7 +175,7 @@ func (s *S) TestLogoutNoTarget(c *check.C) {
 	}()
 	writeToken("mytoken")
 	expected := "Successfully logged out!\n"
-	context := Context{[]string{}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := Context{Args: []string{}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	command := logout{}
 	transport := cmdtest.Transport{Message: "", Status: http.StatusOK}
 	client := NewClient(&http.Client{Transport: &transport}, nil, globalManager)
@@ -310,7 +310,7 @@ Roles:
 Permissions:
 	a(y q)
 `
-	context := Context{[]string{}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := Context{Args: []string{}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	command := userInfo{}
 	transport := cmdtest.ConditionalTransport{
 		Transport: cmdtest.Transport{
@@ -61,6 +61,7 @@ type Manager struct {
 	wrong         bool
 	lookup        Lookup
 	contexts      []*Context
+	outputFormat  OutputFormat
 }
 
 func NewManager(name, ver, verHeader string, stdout, stderr io.Writer, stdin io.Reader, lookup Lookup) *Manager {
@@ -155,6 +156,7 @@ func (m *Manager) Run(args []string) {
 		displayHelp    bool
 		displayVersion bool
 		target         string
+		output         string
 	)
 	if len(args) == 0 {
 		args = append(args, "help")
@@ -168,7 +170,12 @@ func (m *Manager) Run(args []string) {
 	flagset.BoolVar(&displayVersion, "version", false, "Print version and exit")
 	flagset.StringVar(&target, "t", "", "Define target for running command")
 	flagset.StringVar(&target, "target", "", "Define target for running command")
+	flagset.StringVar(&output, "o", string(OutputTable), "Output format of the command result: table, json or yaml")
+	flagset.StringVar(&output, "output", string(OutputTable), "Output format of the command result: table, json or yaml")
 	parseErr := flagset.Parse(false, args)
+	if parseErr == nil {
+		m.outputFormat, parseErr = ParseOutputFormat(output)
+	}
 	if parseErr != nil {
 		fmt.Fprint(m.stderr, parseErr)
 		m.finisher().Exit(2)
@@ -292,9 +299,15 @@ func (m *Manager) Run(args []string) {
 }
 
 func (m *Manager) newContext(args []string, stdout io.Writer, stderr io.Writer, stdin io.Reader) *Context {
-	stdout = newPagerWriter(stdout)
-	stdin = newSyncReader(stdin, stdout)
-	ctx := &Context{Args: args, Stdout: stdout, Stderr: stderr, Stdin: stdin}
+	format := m.outputFormat
+	if format == "" {
+		format = OutputTable
+	}
+	if format.Paged() {
+		stdout = newPagerWriter(stdout)
+		stdin = newSyncReader(stdin, stdout)
+	}
+	ctx := &Context{Args: args, Stdout: stdout, Stderr: stderr, Stdin: stdin, OutputFormat: format}
 	m.contexts = append(m.contexts, ctx)
 	return ctx
 }
@@ -489,12 +502,16 @@ func (c *DeprecatedCommand) Flags() *gnuflag.FlagSet {
 }
 
 type Context struct {
-	Args   []string
-	Stdout io.Writer
-	Stderr io.Writer
-	Stdin  io.Reader
+	Args         []string
+	Stdout       io.Writer
+	Stderr       io.Writer
+	Stdin        io.Reader
+	OutputFormat OutputFormat
 }
 
+// RawOutput disables the pager in the standard output of the context, used
+// by commands that stream their output. Contexts using an output format
+// that isn't paged are always raw.
 func (c *Context) RawOutput() {
 	if pager, ok := c.Stdout.(*pagerWriter); ok {
 		c.Stdout = pager.baseWriter
@@ -601,17 +618,31 @@ func (c *version) Info() *Info {
 
 var GitHash = ""
 
-func versionString(manager *Manager) string {
+type versionInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	GitHash string `json:"gitHash,omitempty"`
+}
+
+func (v versionInfo) String() string {
 	suffix := "\n"
-	if GitHash != "" {
-		suffix = fmt.Sprintf(" hash %s\n", GitHash)
+	if v.GitHash != "" {
+		suffix = fmt.Sprintf(" hash %s\n", v.GitHash)
 	}
-	return fmt.Sprintf("%s version %s.%s", manager.name, manager.version, suffix)
+	return fmt.Sprintf("%s version %s.%s", v.Name, v.Version, suffix)
+}
+
+func (v versionInfo) RenderTable(w io.Writer) error {
+	_, err := io.WriteString(w, v.String())
+	return err
+}
+
+func versionString(manager *Manager) string {
+	return versionInfo{Name: manager.name, Version: manager.version, GitHash: GitHash}.String()
 }
 
 func (c *version) Run(context *Context, client *Client) error {
-	fmt.Fprintf(context.Stdout, versionString(c.manager))
-	return nil
+	return context.Render(versionInfo{Name: c.manager.name, Version: c.manager.version, GitHash: GitHash})
 }
 
 func ExtractProgramName(path string) string {
@@ -127,7 +127,7 @@ func (c *TopicCommand) Run(context *Context, client *Client) error {
 func (s *S) TestImplicitTopicsHelp(c *check.C) {
 	globalManager.Register(&TopicCommand{name: "foo-bar"})
 	globalManager.Register(&TopicCommand{name: "foo-baz"})
-	context := Context{[]string{"foo"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := Context{Args: []string{"foo"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	command := help{manager: globalManager}
 	err := command.Run(&context, nil)
 	c.Assert(err, check.IsNil)
@@ -361,7 +361,7 @@ Available commands:
 Use glb help <commandname> to get more information about a command.
 `
 	globalManager.RegisterDeprecated(&login{}, "login")
-	context := Context{[]string{}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := Context{Args: []string{}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	command := help{manager: globalManager}
 	err := command.Run(&context, nil)
 	c.Assert(err, check.IsNil)
@@ -387,7 +387,7 @@ Use glb help <topicname> to get more information about a topic.
 `
 	globalManager.Register(&login{})
 	globalManager.RegisterTopic("target", "something")
-	context := Context{[]string{}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := Context{Args: []string{}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	command := help{manager: globalManager}
 	err := command.Run(&context, nil)
 	c.Assert(err, check.IsNil)
@@ -402,7 +402,7 @@ Targets
 Tsuru likes to manage targets
 `
 	globalManager.RegisterTopic("target", "Targets\n\nTsuru likes to manage targets\n")
-	context := Context{[]string{"target"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := Context{Args: []string{"target"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	command := help{manager: globalManager}
 	err := command.Run(&context, nil)
 	c.Assert(err, check.IsNil)
@@ -420,7 +420,7 @@ func (s *S) TestHelpCommandShouldBeRegisteredByDefault(c *check.C) {
 
 func (s *S) TestHelpReturnErrorIfTheGivenCommandDoesNotExist(c *check.C) {
 	command := help{manager: globalManager}
-	context := Context{[]string{"user-create"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := Context{Args: []string{"user-create"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	err := command.Run(&context, nil)
 	c.Assert(err, check.NotNil)
 	c.Assert(err, check.ErrorMatches, `^command "user-create" does not exist.$`)
@@ -606,7 +606,7 @@ func (s *S) TestVersion(c *check.C) {
 	var exiter recordingExiter
 	mngr.e = &exiter
 	command := version{manager: mngr}
-	context := Context{[]string{}, mngr.stdout, mngr.stderr, mngr.stdin}
+	context := Context{Args: []string{}, Stdout: mngr.stdout, Stderr: mngr.stderr, Stdin: mngr.stdin}
 	err := command.Run(&context, nil)
 	c.Assert(err, check.IsNil)
 	c.Assert(mngr.stdout.(*bytes.Buffer).String(), check.Equals, "tsuru version 5.0.\n")
@@ -693,7 +693,7 @@ Foo do anything or nothing.
 	var exiter recordingExiter
 	mngr.e = &exiter
 	mngr.Register(&TestCommand{})
-	context := Context{[]string{"foo"}, mngr.stdout, mngr.stderr, mngr.stdin}
+	context := Context{Args: []string{"foo"}, Stdout: mngr.stdout, Stderr: mngr.stderr, Stdin: mngr.stdin}
 	command := help{manager: mngr}
 	err := command.Run(&context, nil)
 	c.Assert(err, check.IsNil)
@@ -28,13 +28,13 @@ func (cmd *ConfirmationCommand) Confirm(context *Context, question string) bool
 	if cmd.yes {
 		return true
 	}
-	fmt.Fprintf(context.Stdout, `%s (y/n) `, question)
+	fmt.Fprintf(context.PromptOutput(), `%s (y/n) `, question)
 	var answer string
 	if context.Stdin != nil {
 		fmt.Fscanf(context.Stdin, "%s", &answer)
 	}
 	if answer != "y" {
-		fmt.Fprintln(context.Stdout, "Abort.")
+		fmt.Fprintln(context.PromptOutput(), "Abort.")
 		return false
 	}
 	return true
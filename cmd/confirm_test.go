@@ -50,3 +50,13 @@ func (s *S) TestConfirmationConfirmWithFlagLong(c *check.C) {
 	c.Assert(result, check.Equals, true)
 	c.Assert(stdout.String(), check.Equals, "")
 }
+
+func (s *S) TestConfirmationConfirmStructuredOutput(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := Context{Stdout: &stdout, Stderr: &stderr, Stdin: strings.NewReader("n\n"), OutputFormat: OutputJSON}
+	cmd := ConfirmationCommand{}
+	result := cmd.Confirm(&context, "Are you sure you wanna do it?")
+	c.Assert(result, check.Equals, false)
+	c.Assert(stdout.String(), check.Equals, "")
+	c.Assert(stderr.String(), check.Equals, "Are you sure you wanna do it? (y/n) Abort.\n")
+}
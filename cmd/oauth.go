@@ -124,10 +124,9 @@ func (c *login) oauthLogin(context *Context, client *Client) error {
 	go server.Serve(l)
 	err = open(authURL)
 	if err != nil {
-		fmt.Fprintln(context.Stdout, "Failed to start your browser.")
-		fmt.Fprintf(context.Stdout, "Please open the following URL in your browser: %s\n", authURL)
+		fmt.Fprintln(context.PromptOutput(), "Failed to start your browser.")
+		fmt.Fprintf(context.PromptOutput(), "Please open the following URL in your browser: %s\n", authURL)
 	}
 	<-finish
-	fmt.Fprintln(context.Stdout, "Successfully logged in!")
-	return nil
+	return context.Render(Message("Successfully logged in!"))
 }
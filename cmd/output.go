@@ -0,0 +1,113 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+)
+
+// OutputFormat is the format used to render the result of commands, chosen
+// with the global --output flag.
+type OutputFormat string
+
+const (
+	OutputTable OutputFormat = "table"
+	OutputJSON  OutputFormat = "json"
+	OutputYAML  OutputFormat = "yaml"
+)
+
+// ParseOutputFormat returns the OutputFormat with the given name.
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch f := OutputFormat(name); f {
+	case OutputTable, OutputJSON, OutputYAML:
+		return f, nil
+	case "":
+		return OutputTable, nil
+	}
+	return "", errors.Errorf("invalid output format %q, must be one of: table, json, yaml", name)
+}
+
+// Structured returns whether the format is meant to be consumed by scripts
+// instead of people.
+func (f OutputFormat) Structured() bool {
+	return f == OutputJSON || f == OutputYAML
+}
+
+// Paged returns whether the standard output is sent through the pager in
+// the format. Only the human readable table format is paged, structured
+// formats are written as is so they can be piped to other programs.
+func (f OutputFormat) Paged() bool {
+	return !f.Structured()
+}
+
+// TableRenderer is implemented by command results that have a human
+// readable representation, used by the table output format.
+type TableRenderer interface {
+	RenderTable(w io.Writer) error
+}
+
+// Message is the result of commands that only report what they did.
+type Message string
+
+func (m Message) RenderTable(w io.Writer) error {
+	_, err := fmt.Fprintln(w, string(m))
+	return err
+}
+
+func (m Message) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{"message": string(m)})
+}
+
+// Render writes the result of a command to the standard output, in the
+// output format of the context. Values rendered as JSON and YAML use the same
+// field names, taken from their json tags. In the table format, values that
+// don't implement TableRenderer are printed with fmt.Fprintln.
+func (c *Context) Render(v interface{}) error {
+	switch c.OutputFormat {
+	case OutputJSON:
+		enc := json.NewEncoder(c.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case OutputYAML:
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		_, err = c.Stdout.Write(data)
+		return err
+	}
+	if r, ok := v.(TableRenderer); ok {
+		return r.RenderTable(c.Stdout)
+	}
+	_, err := fmt.Fprintln(c.Stdout, v)
+	return err
+}
+
+// PromptOutput returns the writer used for prompts and progress messages. In
+// structured output formats it's the standard error, so the standard output
+// only carries the rendered result.
+func (c *Context) PromptOutput() io.Writer {
+	if c.OutputFormat.Structured() && c.Stderr != nil {
+		return c.Stderr
+	}
+	return c.Stdout
+}
+
+// StreamOutput prepares the context for commands that stream their output as
+// it's produced, which can't be represented in a structured output format.
+// Streamed output must reach the terminal as it's produced, so the pager
+// chosen by the table format is disabled with RawOutput.
+func (c *Context) StreamOutput() error {
+	if c.OutputFormat.Structured() {
+		return errors.Errorf("output format %q is not supported by this command, its output is streamed as it's produced", c.OutputFormat)
+	}
+	c.RawOutput()
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"gopkg.in/check.v1"
+)
+
+type renderTestResult struct {
+	Name  string   `json:"name"`
+	Items []string `json:"items,omitempty"`
+}
+
+func (r renderTestResult) RenderTable(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "Name: %s\n", r.Name)
+	return err
+}
+
+func (s *S) TestParseOutputFormat(c *check.C) {
+	tests := []struct {
+		name     string
+		expected OutputFormat
+	}{
+		{"", OutputTable},
+		{"table", OutputTable},
+		{"json", OutputJSON},
+		{"yaml", OutputYAML},
+	}
+	for _, tt := range tests {
+		format, err := ParseOutputFormat(tt.name)
+		c.Assert(err, check.IsNil)
+		c.Assert(format, check.Equals, tt.expected)
+	}
+	_, err := ParseOutputFormat("xml")
+	c.Assert(err, check.ErrorMatches, `invalid output format "xml", must be one of: table, json, yaml`)
+}
+
+func (s *S) TestOutputFormatStructured(c *check.C) {
+	c.Assert(OutputTable.Structured(), check.Equals, false)
+	c.Assert(OutputJSON.Structured(), check.Equals, true)
+	c.Assert(OutputYAML.Structured(), check.Equals, true)
+}
+
+func (s *S) TestOutputFormatPaged(c *check.C) {
+	c.Assert(OutputTable.Paged(), check.Equals, true)
+	c.Assert(OutputJSON.Paged(), check.Equals, false)
+	c.Assert(OutputYAML.Paged(), check.Equals, false)
+}
+
+func (s *S) TestContextRender(c *check.C) {
+	result := renderTestResult{Name: "x", Items: []string{"a", "b"}}
+	tests := []struct {
+		format   OutputFormat
+		value    interface{}
+		expected string
+	}{
+		{OutputTable, result, "Name: x\n"},
+		{OutputJSON, result, "{\n  \"name\": \"x\",\n  \"items\": [\n    \"a\",\n    \"b\"\n  ]\n}\n"},
+		{OutputYAML, result, "items:\n- a\n- b\nname: x\n"},
+		{OutputTable, 42, "42\n"},
+		{OutputTable, Message("done"), "done\n"},
+		{OutputJSON, Message("done"), "{\n  \"message\": \"done\"\n}\n"},
+		{OutputYAML, Message("done"), "message: done\n"},
+	}
+	for _, tt := range tests {
+		var stdout bytes.Buffer
+		context := Context{Stdout: &stdout, OutputFormat: tt.format}
+		err := context.Render(tt.value)
+		c.Assert(err, check.IsNil)
+		c.Assert(stdout.String(), check.Equals, tt.expected, check.Commentf("format %s", tt.format))
+	}
+}
+
+func (s *S) TestManagerRunWithOutputFormat(c *check.C) {
+	globalManager.Run([]string{"-o", "json", "version"})
+	c.Assert(globalManager.stdout.(*bytes.Buffer).String(), check.Equals, "{\n  \"name\": \"glb\",\n  \"version\": \"1.0\"\n}\n")
+	c.Assert(globalManager.e.(*recordingExiter).value(), check.Equals, 0)
+}
+
+func (s *S) TestManagerRunWithInvalidOutputFormat(c *check.C) {
+	globalManager.Run([]string{"--output", "xml", "version"})
+	c.Assert(globalManager.stderr.(*bytes.Buffer).String(), check.Equals, `invalid output format "xml", must be one of: table, json, yaml`)
+	c.Assert(globalManager.e.(*recordingExiter).value(), check.Equals, 2)
+}
+
+func (s *S) TestContextPromptOutput(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := Context{Stdout: &stdout, Stderr: &stderr}
+	c.Assert(context.PromptOutput(), check.Equals, &stdout)
+	context.OutputFormat = OutputJSON
+	c.Assert(context.PromptOutput(), check.Equals, &stderr)
+	context.OutputFormat = OutputYAML
+	c.Assert(context.PromptOutput(), check.Equals, &stderr)
+}
+
+func (s *S) TestContextStreamOutput(c *check.C) {
+	var stdout bytes.Buffer
+	context := Context{Stdout: &pagerWriter{baseWriter: &stdout}}
+	err := context.StreamOutput()
+	c.Assert(err, check.IsNil)
+	c.Assert(context.Stdout, check.Equals, &stdout)
+	context.OutputFormat = OutputJSON
+	err = context.StreamOutput()
+	c.Assert(err, check.ErrorMatches, `output format "json" is not supported by this command, its output is streamed as it's produced`)
+}
@@ -138,19 +138,20 @@ func (c *login) samlLogin(context *Context, client *Client) error {
 	server := &http.Server{}
 	go server.Serve(l)
 	if err = open(preLoginURL); err != nil {
-		fmt.Fprintln(context.Stdout, "Failed to start your browser.")
-		fmt.Fprintf(context.Stdout, "Please open the following URL in your browser: %s\n", preLoginURL)
+		fmt.Fprintln(context.PromptOutput(), "Failed to start your browser.")
+		fmt.Fprintf(context.PromptOutput(), "Please open the following URL in your browser: %s\n", preLoginURL)
 	}
 	<-finish
 	token, err := requestToken(schemeData)
 	switch err {
 	case nil:
 		writeToken(token)
-		fmt.Fprintln(context.Stdout, "\nSuccessfully logged in!")
+		fmt.Fprintln(context.PromptOutput())
+		return context.Render(Message("Successfully logged in!"))
 	case samlErrors.ErrRequestWaitingForCredentials:
-		fmt.Fprintln(context.Stdout, "\nLogin failed! Timeout waiting for credentials from IDP, please try again.")
+		fmt.Fprintln(context.PromptOutput(), "\nLogin failed! Timeout waiting for credentials from IDP, please try again.")
 	default:
-		fmt.Fprintln(context.Stdout, "\nLogin failed for some reason, please try again: "+err.Error())
+		fmt.Fprintln(context.PromptOutput(), "\nLogin failed for some reason, please try again: "+err.Error())
 	}
 	return nil
 }
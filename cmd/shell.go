@@ -66,7 +66,10 @@ func (c *ShellToContainerCmd) Run(context *Context, client *Client) error {
 	if err != nil {
 		return err
 	}
-	context.RawOutput()
+	err = context.StreamOutput()
+	if err != nil {
+		return err
+	}
 	var width, height int
 	if desc, ok := context.Stdin.(descriptable); ok {
 		fd := int(desc.Fd())
@@ -5,6 +5,7 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -92,6 +93,22 @@ func (t *targetSlice) String() string {
 	return strings.Join(values, "\n")
 }
 
+func (t *targetSlice) MarshalJSON() ([]byte, error) {
+	if !t.sorted {
+		t.Sort()
+	}
+	type target struct {
+		Label   string `json:"label"`
+		URL     string `json:"url"`
+		Current bool   `json:"current"`
+	}
+	targets := make([]target, len(t.targets))
+	for i, tt := range t.targets {
+		targets[i] = target{Label: tt.label, URL: tt.url, Current: t.current == i}
+	}
+	return json.Marshal(targets)
+}
+
 // ReadTarget returns the current target, as defined in the TSURU_TARGET
 // environment variable or in the target file.
 func ReadTarget() (string, error) {
@@ -214,13 +231,12 @@ func (t *targetAdd) Run(ctx *Context, client *Client) error {
 	if err != nil {
 		return err
 	}
-	fmt.Fprintf(ctx.Stdout, "New target %s -> %s added to target list", label, target)
+	msg := fmt.Sprintf("New target %s -> %s added to target list", label, target)
 	if t.set {
 		WriteTarget(target)
-		fmt.Fprint(ctx.Stdout, " and defined as the current target")
+		msg += " and defined as the current target"
 	}
-	fmt.Fprintln(ctx.Stdout)
-	return nil
+	return ctx.Render(Message(msg))
 }
 
 func (t *targetAdd) Flags() *gnuflag.FlagSet {
@@ -356,8 +372,7 @@ func (t *targetList) Run(ctx *Context, client *Client) error {
 	if current, err := ReadTarget(); err == nil {
 		slice.setCurrent(current)
 	}
-	fmt.Fprintf(ctx.Stdout, "%v\n", slice)
-	return nil
+	return ctx.Render(slice)
 }
 
 type targetRemove struct{}
@@ -440,7 +455,7 @@ func (t *targetSet) Run(ctx *Context, client *Client) error {
 			if err != nil {
 				return err
 			}
-			fmt.Fprintf(ctx.Stdout, "New target is %s -> %s\n", label, target)
+			return ctx.Render(Message(fmt.Sprintf("New target is %s -> %s", label, target)))
 		}
 	}
 	return nil
@@ -289,7 +289,7 @@ func (s *S) TestTargetAddRun(c *check.C) {
 	defer func() {
 		fsystem = nil
 	}()
-	context := &Context{[]string{"default", "http://tsuru.google.com"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := &Context{Args: []string{"default", "http://tsuru.google.com"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	targetAdd := &targetAdd{}
 	err := targetAdd.Run(context, nil)
 	c.Assert(err, check.IsNil)
@@ -302,7 +302,7 @@ func (s *S) TestTargetAddRunOnlyOneArg(c *check.C) {
 	defer func() {
 		fsystem = nil
 	}()
-	context := &Context{[]string{"default http://tsuru.google.com"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := &Context{Args: []string{"default http://tsuru.google.com"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	targetAdd := &targetAdd{}
 	err := targetAdd.Run(context, nil)
 	c.Assert(err, check.NotNil)
@@ -316,7 +316,7 @@ func (s *S) TestTargetAddWithSet(c *check.C) {
 	defer func() {
 		fsystem = nil
 	}()
-	context := &Context{[]string{"default", "http://tsuru.google.com"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := &Context{Args: []string{"default", "http://tsuru.google.com"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	targetAdd := &targetAdd{}
 	targetAdd.Flags().Parse(true, []string{"-s"})
 	err := targetAdd.Run(context, nil)
@@ -448,7 +448,7 @@ other	http://other.tsuru.io`
 * first (http://tsuru.io)
   other (http://other.tsuru.io)` + "\n"
 	target := &targetList{}
-	context := &Context{[]string{""}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := &Context{Args: []string{""}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	err := target.Run(context, nil)
 	c.Assert(err, check.IsNil)
 	got := context.Stdout.(*bytes.Buffer).String()
@@ -508,7 +508,7 @@ func (s *S) TestTargetRemove(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(got, check.HasLen, len(expectedBefore))
 	targetRemove := &targetRemove{}
-	context := &Context{[]string{"first"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := &Context{Args: []string{"first"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	err = targetRemove.Run(context, nil)
 	c.Assert(err, check.IsNil)
 	got, err = getTargets()
@@ -534,7 +534,7 @@ func (s *S) TestTargetRemoveCurrentTarget(c *check.C) {
 		fsystem = nil
 	}()
 	targetRemove := &targetRemove{}
-	context := &Context{[]string{"default"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := &Context{Args: []string{"default"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	err := targetRemove.Run(context, nil)
 	c.Assert(err, check.IsNil)
 	_, err = ReadTarget()
@@ -561,7 +561,7 @@ func (s *S) TestTargetSetRun(c *check.C) {
 		fsystem = nil
 	}()
 	targetSet := &targetSet{}
-	context := &Context{[]string{"default"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := &Context{Args: []string{"default"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	err := targetSet.Run(context, nil)
 	c.Assert(err, check.IsNil)
 	got := context.Stdout.(*bytes.Buffer).String()
@@ -575,7 +575,7 @@ func (s *S) TestTargetSetRunUnknowTarget(c *check.C) {
 		fsystem = nil
 	}()
 	targetSet := &targetSet{}
-	context := &Context{[]string{"doesnotexist"}, globalManager.stdout, globalManager.stderr, globalManager.stdin}
+	context := &Context{Args: []string{"doesnotexist"}, Stdout: globalManager.stdout, Stderr: globalManager.stderr, Stdin: globalManager.stdin}
 	err := targetSet.Run(context, nil)
 	c.Assert(err, check.ErrorMatches, "Target not found")
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/tsuru/gnuflag"
 	"github.com/tsuru/tsuru/api"
 	"github.com/tsuru/tsuru/cmd"
+	internalConfig "github.com/tsuru/tsuru/config"
 )
 
 type configFile struct {
@@ -59,6 +60,7 @@ func (c *tsurudCommand) Run(context *cmd.Context, client *cmd.Client) error {
 		return err
 	}
 	fmt.Fprintf(context.Stderr, "Done reading config file: %s\n", configPath)
+	internalConfig.SetFilePath(configPath)
 	err = api.InitializeDBServices()
 	if err != nil {
 		return errors.Wrap(err, "error initializing services")
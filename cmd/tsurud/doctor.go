@@ -0,0 +1,67 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/tablecli"
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/diagnostic"
+)
+
+type doctorCmd struct{}
+
+func (*doctorCmd) Info() *cmd.Info {
+	return &cmd.Info{
+		Name:  "doctor",
+		Usage: "doctor [check-name]...",
+		Desc: `Runs self-diagnostics on the tsuru installation, checking components,
+clusters, routers, registry, IaaSes, pools and orphaned data. Only the checks
+named in the arguments are executed, if any.
+
+The command fails if any check finds an error. Use the global --output flag to
+get the report as JSON or YAML.`,
+	}
+}
+
+func (*doctorCmd) Run(context *cmd.Context, client *cmd.Client) error {
+	report := diagnostic.Run(context.Args...)
+	err := context.Render(doctorReport{report})
+	if err != nil {
+		return err
+	}
+	if errCount := report.Count(diagnostic.SeverityError); errCount > 0 {
+		return errors.Errorf("diagnostics found %d error(s)", errCount)
+	}
+	return nil
+}
+
+type doctorReport struct {
+	*diagnostic.Report
+}
+
+func (r doctorReport) RenderTable(w io.Writer) error {
+	if len(r.Findings) == 0 {
+		_, err := fmt.Fprintf(w, "No problems found by checks: %v\n", r.Checks)
+		return err
+	}
+	tbl := tablecli.NewTable()
+	tbl.Headers = tablecli.Row{"Severity", "Check", "Target", "Message"}
+	for _, f := range r.Findings {
+		var target string
+		if f.Target != nil {
+			target = f.Target.Type
+			if f.Target.Value != "" {
+				target += " " + f.Target.Value
+			}
+		}
+		tbl.AddRow(tablecli.Row{string(f.Severity), f.Check, target, f.Message})
+	}
+	_, err := fmt.Fprint(w, tbl.String())
+	return err
+}
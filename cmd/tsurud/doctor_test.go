@@ -0,0 +1,54 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/tsuru/tsuru/cmd"
+	"github.com/tsuru/tsuru/diagnostic"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestDoctorReportRenderTable(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, OutputFormat: cmd.OutputTable}
+	err := context.Render(doctorReport{&diagnostic.Report{
+		Checks: []string{"pools", "registry"},
+		Findings: []diagnostic.Finding{
+			{Check: "pools", Severity: diagnostic.SeverityWarning, Target: &diagnostic.Target{Type: "pool", Value: "p1"}, Message: "pool has no routers"},
+			{Check: "registry", Severity: diagnostic.SeverityError, Target: &diagnostic.Target{Type: "registry"}, Message: "invalid registry credentials"},
+		},
+	}})
+	c.Assert(err, check.IsNil)
+	expected := `+----------+----------+----------+------------------------------+
+| Severity | Check    | Target   | Message                      |
++----------+----------+----------+------------------------------+
+| warning  | pools    | pool p1  | pool has no routers          |
+| error    | registry | registry | invalid registry credentials |
++----------+----------+----------+------------------------------+
+`
+	c.Assert(stdout.String(), check.Equals, expected)
+}
+
+func (s *S) TestDoctorReportRenderTableNoFindings(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, OutputFormat: cmd.OutputTable}
+	err := context.Render(doctorReport{&diagnostic.Report{Checks: []string{"pools"}}})
+	c.Assert(err, check.IsNil)
+	c.Assert(stdout.String(), check.Equals, "No problems found by checks: [pools]\n")
+}
+
+func (s *S) TestDoctorReportRenderJSON(c *check.C) {
+	var stdout bytes.Buffer
+	context := cmd.Context{Stdout: &stdout, OutputFormat: cmd.OutputJSON}
+	err := context.Render(doctorReport{&diagnostic.Report{Checks: []string{"pools"}}})
+	c.Assert(err, check.IsNil)
+	var report diagnostic.Report
+	err = json.Unmarshal(stdout.Bytes(), &report)
+	c.Assert(err, check.IsNil)
+	c.Assert(report.Checks, check.DeepEquals, []string{"pools"})
+}
@@ -20,5 +20,9 @@ func (gandalfSyncCmd) Info() *cmd.Info {
 }
 
 func (gandalfSyncCmd) Run(context *cmd.Context, client *cmd.Client) error {
+	err := context.StreamOutput()
+	if err != nil {
+		return err
+	}
 	return gandalf.Sync(context.Stdout)
 }
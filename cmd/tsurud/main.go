@@ -15,6 +15,7 @@ import (
 	_ "github.com/tsuru/tsuru/builder/docker"
 	_ "github.com/tsuru/tsuru/builder/kubernetes"
 	"github.com/tsuru/tsuru/cmd"
+	internalConfig "github.com/tsuru/tsuru/config"
 	"github.com/tsuru/tsuru/iaas/dockermachine"
 	_ "github.com/tsuru/tsuru/provision/docker"
 	_ "github.com/tsuru/tsuru/provision/kubernetes"
@@ -35,6 +36,7 @@ func buildManager() *cmd.Manager {
 	m.Register(&tsurudCommand{Command: gandalfSyncCmd{}})
 	m.Register(&tsurudCommand{Command: createRootUserCmd{}})
 	m.Register(&tsurudCommand{Command: &migrationListCmd{}})
+	m.Register(&tsurudCommand{Command: &doctorCmd{}})
 	return m
 }
 
@@ -56,6 +58,7 @@ func main() {
 	} else {
 		localbinary.CurrentBinaryIsDockerMachine = true
 		config.ReadConfigFile(configPath)
+		internalConfig.SetFilePath(configPath)
 		listenSignals()
 		m := buildManager()
 		m.Run(os.Args[1:])
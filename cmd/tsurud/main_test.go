@@ -57,6 +57,15 @@ func (s *S) TestMigrateCmdIsRegistered(c *check.C) {
 	c.Assert(migrate.Command, check.FitsTypeOf, &migrateCmd{})
 }
 
+func (s *S) TestDoctorCmdIsRegistered(c *check.C) {
+	manager := buildManager()
+	cmd, ok := manager.Commands["doctor"]
+	c.Assert(ok, check.Equals, true)
+	doctor, ok := cmd.(*tsurudCommand)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(doctor.Command, check.FitsTypeOf, &doctorCmd{})
+}
+
 func (s *S) TestGandalfSyncCmdIsRegistered(c *check.C) {
 	manager := buildManager()
 	cmd, ok := manager.Commands["gandalf-sync"]
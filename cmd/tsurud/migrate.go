@@ -6,6 +6,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"log"
 	"strconv"
 
@@ -124,33 +125,65 @@ func (*migrationListCmd) Run(context *cmd.Context, client *cmd.Client) error {
 	if err != nil {
 		return err
 	}
+	result := make(migrationList, len(migrations))
+	for i, m := range migrations {
+		result[i] = migrationListItem{Name: m.Name, Mandatory: !m.Optional, Executed: m.Ran}
+	}
+	return context.Render(result)
+}
+
+type migrationListItem struct {
+	Name      string `json:"name"`
+	Mandatory bool   `json:"mandatory"`
+	Executed  bool   `json:"executed"`
+}
+
+type migrationList []migrationListItem
+
+func (l migrationList) RenderTable(w io.Writer) error {
 	tbl := tablecli.NewTable()
 	tbl.Headers = tablecli.Row{"Name", "Mandatory?", "Executed?"}
-	for _, m := range migrations {
-		tbl.AddRow(tablecli.Row{m.Name, strconv.FormatBool(!m.Optional), strconv.FormatBool(m.Ran)})
+	for _, m := range l {
+		tbl.AddRow(tablecli.Row{m.Name, strconv.FormatBool(m.Mandatory), strconv.FormatBool(m.Executed)})
 	}
-	fmt.Fprint(context.Stdout, tbl.String())
-	return nil
+	_, err := fmt.Fprint(w, tbl.String())
+	return err
 }
 
 type migrateCmd struct {
-	fs    *gnuflag.FlagSet
-	dry   bool
-	force bool
-	name  string
+	fs       *gnuflag.FlagSet
+	dry      bool
+	force    bool
+	name     string
+	rollback string
 }
 
 func (*migrateCmd) Info() *cmd.Info {
 	return &cmd.Info{
 		Name:  "migrate",
-		Usage: "migrate [-n/--dry] [-f/--force] [--name name]",
+		Usage: "migrate [-n/--dry] [-f/--force] [--name name] [--rollback name]",
 		Desc: `Runs migrations from previous versions of tsurud. Only mandatory migrations
 will be executed by default. To execute an optional migration the --name flag
-must be informed.`,
+must be informed.
+
+The --rollback flag undoes an executed migration, if it is reversible. A
+mandatory migration rolled back will run again in the next call to migrate.`,
 	}
 }
 
 func (c *migrateCmd) Run(context *cmd.Context, client *cmd.Client) error {
+	err := context.StreamOutput()
+	if err != nil {
+		return err
+	}
+	if c.rollback != "" {
+		return migration.Rollback(migration.RunArgs{
+			Writer: context.Stdout,
+			Dry:    c.dry,
+			Name:   c.rollback,
+			Force:  c.force,
+		})
+	}
 	return migration.Run(migration.RunArgs{
 		Writer: context.Stdout,
 		Dry:    c.dry,
@@ -165,10 +198,11 @@ func (c *migrateCmd) Flags() *gnuflag.FlagSet {
 		dryMsg := "Do not run migrations, just print what would run"
 		c.fs.BoolVar(&c.dry, "dry", false, dryMsg)
 		c.fs.BoolVar(&c.dry, "n", false, dryMsg)
-		forceMsg := "Force the execution of an already executed optional migration"
+		forceMsg := "Force the execution of an already executed optional migration, or the rollback of a migration not executed"
 		c.fs.BoolVar(&c.force, "force", false, forceMsg)
 		c.fs.BoolVar(&c.force, "f", false, forceMsg)
 		c.fs.StringVar(&c.name, "name", "", "The name of an optional migration to run")
+		c.fs.StringVar(&c.rollback, "rollback", "", "The name of a migration to roll back")
 	}
 	return c.fs
 }
@@ -12,7 +12,8 @@ import (
 	"runtime/pprof"
 	"syscall"
 
-	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/config"
+	"github.com/tsuru/tsuru/log"
 )
 
 func listenSignals() {
@@ -23,9 +24,24 @@ func listenSignals() {
 			case syscall.SIGUSR1:
 				pprof.Lookup("goroutine").WriteTo(os.Stdout, 2)
 			case syscall.SIGHUP:
-				config.ReadConfigFile(configPath)
+				reloadConfig()
 			}
 		}
 	}()
 	signal.Notify(ch, syscall.SIGHUP, syscall.SIGUSR1)
 }
+
+func reloadConfig() {
+	result, err := config.Reload()
+	if err != nil {
+		log.Errorf("[config] unable to reload configuration: %v", err)
+		return
+	}
+	log.Debugf("[config] reloaded configuration, applied: %v", result.Applied)
+	for name, errMsg := range result.Failed {
+		log.Errorf("[config] unable to apply configuration changes to %s: %s", name, errMsg)
+	}
+	if len(result.RestartRequired) > 0 {
+		log.Errorf("[config] restart required to apply changes to: %v", result.RestartRequired)
+	}
+}
@@ -6,6 +6,7 @@ package main
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/pkg/errors"
 	"github.com/tsuru/config"
@@ -37,21 +38,21 @@ func (createRootUserCmd) Run(context *cmd.Context, client *cmd.Client) error {
 		if err != nil {
 			return err
 		}
-		fmt.Fprintln(context.Stdout, "Root user successfully updated.")
+		fmt.Fprintln(context.PromptOutput(), "Root user successfully updated.")
 	}
 	var confirm, password string
 	if scheme == nativeSchemeName {
-		fmt.Fprint(context.Stdout, "Password: ")
+		fmt.Fprint(context.PromptOutput(), "Password: ")
 		password, err = cmd.PasswordFromReader(context.Stdin)
 		if err != nil {
 			return err
 		}
-		fmt.Fprint(context.Stdout, "\nConfirm: ")
+		fmt.Fprint(context.PromptOutput(), "\nConfirm: ")
 		confirm, err = cmd.PasswordFromReader(context.Stdin)
 		if err != nil {
 			return err
 		}
-		fmt.Fprintln(context.Stdout)
+		fmt.Fprintln(context.PromptOutput())
 		if password != confirm {
 			return errors.New("Passwords didn't match.")
 		}
@@ -67,8 +68,7 @@ func (createRootUserCmd) Run(context *cmd.Context, client *cmd.Client) error {
 	if err != nil {
 		return err
 	}
-	fmt.Fprintln(context.Stdout, "Root user successfully created.")
-	return nil
+	return context.Render(cmd.Message("Root user successfully created."))
 }
 
 func addSuperRole(u *auth.User) error {
@@ -113,8 +113,16 @@ func (tokenCmd) Run(context *cmd.Context, client *cmd.Client) error {
 	if err != nil {
 		return err
 	}
-	fmt.Fprintln(context.Stdout, t.GetValue())
-	return nil
+	return context.Render(tokenResult{Token: t.GetValue()})
+}
+
+type tokenResult struct {
+	Token string `json:"token"`
+}
+
+func (r tokenResult) RenderTable(w io.Writer) error {
+	_, err := fmt.Fprintln(w, r.Token)
+	return err
 }
 
 func (tokenCmd) Info() *cmd.Info {
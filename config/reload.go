@@ -0,0 +1,211 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"gopkg.in/yaml.v2"
+)
+
+// ReloadFunc applies the current configuration to a subsystem. It's called
+// after a reload changes one of the keys the subsystem subscribed to.
+type ReloadFunc func() error
+
+// ReloadResult describes the outcome of a configuration reload. Changed keys
+// not handled by any subscriber are listed in RestartRequired, they only take
+// effect after tsurud is restarted. The reload only affects the process
+// running it, identified by Host, other replicas must be reloaded too.
+type ReloadResult struct {
+	Host            string            `json:"host"`
+	Changed         []string          `json:"changed"`
+	Applied         []string          `json:"applied"`
+	Failed          map[string]string `json:"failed,omitempty"`
+	RestartRequired []string          `json:"restartRequired"`
+}
+
+type subscriber struct {
+	name string
+	keys []string
+	fn   ReloadFunc
+}
+
+var (
+	reloadMu    sync.Mutex
+	subsMu      sync.Mutex
+	subscribers []subscriber
+	filePath    string
+)
+
+// SetFilePath sets the path of the configuration file read by Reload.
+func SetFilePath(path string) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	filePath = path
+}
+
+// Subscribe registers fn to be called when a reload changes any of the given
+// keys, or any key below them. Subscribing again with the same name replaces
+// the previous subscription.
+func Subscribe(name string, keys []string, fn ReloadFunc) {
+	subsMu.Lock()
+	defer subsMu.Unlock()
+	sub := subscriber{name: name, keys: keys, fn: fn}
+	for i := range subscribers {
+		if subscribers[i].name == name {
+			subscribers[i] = sub
+			return
+		}
+	}
+	subscribers = append(subscribers, sub)
+}
+
+// Unsubscribe removes the subscription with the given name.
+func Unsubscribe(name string) {
+	subsMu.Lock()
+	defer subsMu.Unlock()
+	for i := range subscribers {
+		if subscribers[i].name == name {
+			subscribers = append(subscribers[:i], subscribers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Reload reads the configuration file again and notifies the subscribers of
+// the keys that changed.
+func Reload() (*ReloadResult, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	if filePath == "" {
+		return nil, errors.New("configuration file path not set")
+	}
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return reloadBytes(data)
+}
+
+// ReloadBytes replaces the configuration with the given YAML data and
+// notifies the subscribers of the keys that changed.
+func ReloadBytes(data []byte) (*ReloadResult, error) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+	return reloadBytes(data)
+}
+
+func reloadBytes(data []byte) (*ReloadResult, error) {
+	oldValues := currentValues()
+	err := config.ReadConfigBytes(data)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid configuration")
+	}
+	newValues := currentValues()
+	host, _ := os.Hostname()
+	result := &ReloadResult{
+		Host:            host,
+		Changed:         changedKeys(oldValues, newValues),
+		Applied:         []string{},
+		RestartRequired: []string{},
+	}
+	subsMu.Lock()
+	subs := make([]subscriber, len(subscribers))
+	copy(subs, subscribers)
+	subsMu.Unlock()
+	handled := make([]bool, len(result.Changed))
+	for _, sub := range subs {
+		notify := false
+		for i, key := range result.Changed {
+			if sub.covers(key) {
+				handled[i] = true
+				notify = true
+			}
+		}
+		if !notify {
+			continue
+		}
+		err = sub.fn()
+		if err != nil {
+			if result.Failed == nil {
+				result.Failed = map[string]string{}
+			}
+			result.Failed[sub.name] = err.Error()
+			continue
+		}
+		result.Applied = append(result.Applied, sub.name)
+	}
+	for i, key := range result.Changed {
+		if !handled[i] {
+			result.RestartRequired = append(result.RestartRequired, key)
+		}
+	}
+	return result, nil
+}
+
+func (s *subscriber) covers(key string) bool {
+	for _, k := range s.keys {
+		if key == k || strings.HasPrefix(key, k+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// currentValues returns the current configuration as a map from each leaf
+// key, in the "a:b:c" form, to its value.
+func currentValues() map[string]interface{} {
+	values := map[string]interface{}{}
+	data, err := config.Bytes()
+	if err != nil {
+		return values
+	}
+	var current map[interface{}]interface{}
+	if yaml.Unmarshal(data, &current) == nil {
+		flatten("", current, values)
+	}
+	return values
+}
+
+func flatten(prefix string, value interface{}, out map[string]interface{}) {
+	m, ok := value.(map[interface{}]interface{})
+	if !ok || len(m) == 0 {
+		if prefix != "" {
+			out[prefix] = value
+		}
+		return
+	}
+	for k, v := range m {
+		key := fmt.Sprintf("%v", k)
+		if prefix != "" {
+			key = prefix + ":" + key
+		}
+		flatten(key, v, out)
+	}
+}
+
+func changedKeys(oldValues, newValues map[string]interface{}) []string {
+	changed := []string{}
+	for k, v := range oldValues {
+		if newV, ok := newValues[k]; !ok || !reflect.DeepEqual(v, newV) {
+			changed = append(changed, k)
+		}
+	}
+	for k := range newValues {
+		if _, ok := oldValues[k]; !ok {
+			changed = append(changed, k)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
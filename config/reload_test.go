@@ -0,0 +1,116 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestReloadBytes(c *check.C) {
+	err := config.ReadConfigBytes([]byte("a: 1\nb:\n  c: x\n  d: y\ne: z\n"))
+	c.Assert(err, check.IsNil)
+	var calls []string
+	Subscribe("sub-b", []string{"b"}, func() error {
+		value, _ := config.GetString("b:c")
+		calls = append(calls, "b="+value)
+		return nil
+	})
+	defer Unsubscribe("sub-b")
+	Subscribe("sub-e", []string{"e"}, func() error {
+		calls = append(calls, "e")
+		return nil
+	})
+	defer Unsubscribe("sub-e")
+	result, err := ReloadBytes([]byte("a: 2\nb:\n  c: w\n  d: y\ne: z\nf: new\n"))
+	c.Assert(err, check.IsNil)
+	host, _ := os.Hostname()
+	c.Assert(result.Host, check.Equals, host)
+	c.Assert(result.Changed, check.DeepEquals, []string{"a", "b:c", "f"})
+	c.Assert(result.Applied, check.DeepEquals, []string{"sub-b"})
+	c.Assert(result.Failed, check.IsNil)
+	c.Assert(result.RestartRequired, check.DeepEquals, []string{"a", "f"})
+	c.Assert(calls, check.DeepEquals, []string{"b=w"})
+}
+
+func (s *S) TestReloadBytesRemovedKey(c *check.C) {
+	err := config.ReadConfigBytes([]byte("a: 1\nb: 2\n"))
+	c.Assert(err, check.IsNil)
+	result, err := ReloadBytes([]byte("a: 1\n"))
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Changed, check.DeepEquals, []string{"b"})
+	c.Assert(result.RestartRequired, check.DeepEquals, []string{"b"})
+}
+
+func (s *S) TestReloadBytesSubscriberFailure(c *check.C) {
+	err := config.ReadConfigBytes([]byte("a:\n  b: 1\n"))
+	c.Assert(err, check.IsNil)
+	Subscribe("sub-a", []string{"a:b"}, func() error {
+		return errors.New("my error")
+	})
+	defer Unsubscribe("sub-a")
+	result, err := ReloadBytes([]byte("a:\n  b: 2\n"))
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Changed, check.DeepEquals, []string{"a:b"})
+	c.Assert(result.Applied, check.DeepEquals, []string{})
+	c.Assert(result.Failed, check.DeepEquals, map[string]string{"sub-a": "my error"})
+	c.Assert(result.RestartRequired, check.DeepEquals, []string{})
+}
+
+func (s *S) TestReloadBytesInvalid(c *check.C) {
+	err := config.ReadConfigBytes([]byte("a: 1\n"))
+	c.Assert(err, check.IsNil)
+	_, err = ReloadBytes([]byte("a: [\n"))
+	c.Assert(err, check.NotNil)
+	value, err := config.GetInt("a")
+	c.Assert(err, check.IsNil)
+	c.Assert(value, check.Equals, 1)
+}
+
+func (s *S) TestSubscribeReplaces(c *check.C) {
+	err := config.ReadConfigBytes([]byte("a: 1\n"))
+	c.Assert(err, check.IsNil)
+	var calls []string
+	Subscribe("sub", []string{"a"}, func() error {
+		calls = append(calls, "first")
+		return nil
+	})
+	Subscribe("sub", []string{"a"}, func() error {
+		calls = append(calls, "second")
+		return nil
+	})
+	defer Unsubscribe("sub")
+	_, err = ReloadBytes([]byte("a: 2\n"))
+	c.Assert(err, check.IsNil)
+	c.Assert(calls, check.DeepEquals, []string{"second"})
+}
+
+func (s *S) TestReload(c *check.C) {
+	f, err := ioutil.TempFile("", "tsuru-config")
+	c.Assert(err, check.IsNil)
+	defer os.Remove(f.Name())
+	_, err = f.WriteString("a: 1\n")
+	c.Assert(err, check.IsNil)
+	f.Close()
+	err = config.ReadConfigBytes([]byte("a: 0\n"))
+	c.Assert(err, check.IsNil)
+	SetFilePath(f.Name())
+	defer SetFilePath("")
+	result, err := Reload()
+	c.Assert(err, check.IsNil)
+	c.Assert(result.Changed, check.DeepEquals, []string{"a"})
+	value, err := config.GetInt("a")
+	c.Assert(err, check.IsNil)
+	c.Assert(value, check.Equals, 1)
+}
+
+func (s *S) TestReloadWithoutFilePath(c *check.C) {
+	_, err := Reload()
+	c.Assert(err, check.NotNil)
+}
@@ -106,6 +106,28 @@ func (s *Storage) ServiceInstances() *storage.Collection {
 	return s.Collection("service_instances")
 }
 
+// ServiceInstanceQuotas returns the service_instance_quotas collection from
+// MongoDB.
+func (s *Storage) ServiceInstanceQuotas() *storage.Collection {
+	index := mgo.Index{Key: []string{"team", "service", "plan"}, Unique: true}
+	c := s.Collection("service_instance_quotas")
+	c.EnsureIndex(index)
+	return c
+}
+
+// DeployRequests returns the deploy_requests collection from MongoDB.
+func (s *Storage) DeployRequests() *storage.Collection {
+	index := mgo.Index{Key: []string{"app", "status"}}
+	c := s.Collection("deploy_requests")
+	c.EnsureIndex(index)
+	return c
+}
+
+// ClusterStatuses returns the cluster_statuses collection from MongoDB.
+func (s *Storage) ClusterStatuses() *storage.Collection {
+	return s.Collection("cluster_statuses")
+}
+
 // Pools returns the pool collection.
 func (s *Storage) Pools() *storage.Collection {
 	return s.Collection("pool")
@@ -202,6 +224,7 @@ func (s *Storage) Events() *storage.Collection {
 	startTimeIndex := mgo.Index{Key: []string{"-starttime"}}
 	uniqueIdIndex := mgo.Index{Key: []string{"uniqueid"}}
 	runningIndex := mgo.Index{Key: []string{"running"}}
+	updateTimeIndex := mgo.Index{Key: []string{"updatetime", "uniqueid"}}
 	c := s.Collection("events")
 	c.EnsureIndex(ownerIndex)
 	c.EnsureIndex(targetIndex)
@@ -210,6 +233,7 @@ func (s *Storage) Events() *storage.Collection {
 	c.EnsureIndex(startTimeIndex)
 	c.EnsureIndex(uniqueIdIndex)
 	c.EnsureIndex(runningIndex)
+	c.EnsureIndex(updateTimeIndex)
 	return c
 }
 
@@ -238,3 +262,8 @@ func (s *Storage) VolumeBinds() *storage.Collection {
 	c := s.Collection("volume_binds")
 	return c
 }
+
+func (s *Storage) VolumeSnapshots() *storage.Collection {
+	c := s.Collection("volume_snapshots")
+	return c
+}
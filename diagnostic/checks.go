@@ -0,0 +1,269 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diagnostic
+
+import (
+	"fmt"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/hc"
+	"github.com/tsuru/tsuru/iaas"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/pool"
+	"github.com/tsuru/tsuru/registry"
+	"github.com/tsuru/tsuru/router"
+	"github.com/tsuru/tsuru/servicemanager"
+	provTypes "github.com/tsuru/tsuru/types/provision"
+)
+
+func init() {
+	Register("healthcheck", checkHealthCheckers)
+	Register("clusters", checkClusters)
+	Register("routers", checkRouters)
+	Register("registry", checkRegistry)
+	Register("iaas", checkIaaS)
+	Register("pools", checkPools)
+	Register("orphaned-apps", checkOrphanedApps)
+	Register("orphaned-service-instances", checkOrphanedServiceInstances)
+}
+
+func checkHealthCheckers() ([]Finding, error) {
+	var findings []Finding
+	for _, result := range hc.Check("all") {
+		if result.Status == hc.HealthCheckOK {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Target:   &Target{Type: "component", Value: result.Name},
+			Message:  result.Status,
+		})
+	}
+	return findings, nil
+}
+
+func checkClusters() ([]Finding, error) {
+	clusters, err := servicemanager.Cluster.List()
+	if err != nil {
+		if errors.Cause(err) == provTypes.ErrNoCluster {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var findings []Finding
+	for i := range clusters {
+		c := &clusters[i]
+		target := &Target{Type: "cluster", Value: c.Name}
+		if !c.Default && len(c.Pools) == 0 {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Target:   target,
+				Message:  "cluster is not default and has no pools",
+			})
+		}
+		prov, err := provision.Get(c.Provisioner)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Target:   target,
+				Message:  fmt.Sprintf("invalid provisioner %q: %v", c.Provisioner, err),
+			})
+			continue
+		}
+		checker, ok := prov.(provision.ClusterCheckerProvisioner)
+		if !ok {
+			continue
+		}
+		err = checker.CheckCluster(c)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Target:   target,
+				Message:  fmt.Sprintf("unable to connect to cluster: %v", err),
+			})
+		}
+	}
+	return findings, nil
+}
+
+func checkRouters() ([]Finding, error) {
+	routers, err := router.List()
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, planRouter := range routers {
+		target := &Target{Type: "router", Value: planRouter.Name}
+		r, err := router.Get(planRouter.Name)
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Target:   target,
+				Message:  fmt.Sprintf("unable to get router: %v", err),
+			})
+			continue
+		}
+		checker, ok := r.(router.HealthChecker)
+		if !ok {
+			continue
+		}
+		err = checker.HealthCheck()
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Target:   target,
+				Message:  fmt.Sprintf("router is unreachable: %v", err),
+			})
+		}
+	}
+	return findings, nil
+}
+
+func checkRegistry() ([]Finding, error) {
+	err := registry.CheckCredentials()
+	if err != nil {
+		return []Finding{{
+			Severity: SeverityError,
+			Target:   &Target{Type: "registry"},
+			Message:  err.Error(),
+		}}, nil
+	}
+	return nil, nil
+}
+
+func checkIaaS() ([]Finding, error) {
+	var findings []Finding
+	for _, name := range iaas.ConfiguredNames() {
+		err := iaas.HealthCheck(name)
+		if err != nil && err != hc.ErrDisabledComponent {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Target:   &Target{Type: "iaas", Value: name},
+				Message:  err.Error(),
+			})
+		}
+	}
+	return findings, nil
+}
+
+func checkPools() ([]Finding, error) {
+	pools, err := pool.ListAllPools()
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for i := range pools {
+		p := &pools[i]
+		target := &Target{Type: "pool", Value: p.Name}
+		_, err = p.GetProvisioner()
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Target:   target,
+				Message:  fmt.Sprintf("invalid provisioner: %v", err),
+			})
+		}
+		_, err = p.GetRouters()
+		if err == pool.ErrPoolHasNoRouter {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Target:   target,
+				Message:  "pool has no routers",
+			})
+		} else if err != nil {
+			return nil, err
+		}
+		_, err = p.GetTeams()
+		if err == pool.ErrPoolHasNoTeam {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Target:   target,
+				Message:  "pool has no teams",
+			})
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	return findings, nil
+}
+
+func checkOrphanedApps() ([]Finding, error) {
+	pools, err := pool.ListAllPools()
+	if err != nil {
+		return nil, err
+	}
+	poolNames := make(map[string]struct{}, len(pools))
+	for _, p := range pools {
+		poolNames[p.Name] = struct{}{}
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var apps []struct {
+		Name string
+		Pool string
+	}
+	err = conn.Apps().Find(nil).Select(bson.M{"name": 1, "pool": 1}).All(&apps)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, a := range apps {
+		if _, ok := poolNames[a.Pool]; ok {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityError,
+			Target:   &Target{Type: "app", Value: a.Name},
+			Message:  fmt.Sprintf("app pool %q not found", a.Pool),
+		})
+	}
+	return findings, nil
+}
+
+func checkOrphanedServiceInstances() ([]Finding, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var appNames []string
+	err = conn.Apps().Find(nil).Distinct("name", &appNames)
+	if err != nil {
+		return nil, err
+	}
+	apps := make(map[string]struct{}, len(appNames))
+	for _, name := range appNames {
+		apps[name] = struct{}{}
+	}
+	var instances []struct {
+		Name        string
+		ServiceName string `bson:"service_name"`
+		Apps        []string
+	}
+	query := bson.M{"apps": bson.M{"$not": bson.M{"$size": 0}, "$exists": true}}
+	err = conn.ServiceInstances().Find(query).Select(bson.M{"name": 1, "service_name": 1, "apps": 1}).All(&instances)
+	if err != nil {
+		return nil, err
+	}
+	var findings []Finding
+	for _, si := range instances {
+		for _, appName := range si.Apps {
+			if _, ok := apps[appName]; ok {
+				continue
+			}
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Target:   &Target{Type: "service-instance", Value: si.ServiceName + "/" + si.Name},
+				Message:  fmt.Sprintf("service instance bound to app %q, which does not exist", appName),
+			})
+		}
+	}
+	return findings, nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diagnostic
+
+import (
+	"errors"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/provision/pool"
+	"github.com/tsuru/tsuru/router/routertest"
+	"github.com/tsuru/tsuru/service"
+	provTypes "github.com/tsuru/tsuru/types/provision"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestCheckClusters(c *check.C) {
+	s.mockService.Cluster.OnList = func() ([]provTypes.Cluster, error) {
+		return []provTypes.Cluster{
+			{Name: "c1", Provisioner: "fake", Default: true},
+			{Name: "c2", Provisioner: "unknown", Pools: []string{"p1"}},
+			{Name: "c3", Provisioner: "fake"},
+		}, nil
+	}
+	findings, err := checkClusters()
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.HasLen, 2)
+	c.Assert(findings[0].Target, check.DeepEquals, &Target{Type: "cluster", Value: "c2"})
+	c.Assert(findings[0].Severity, check.Equals, SeverityError)
+	c.Assert(findings[1], check.DeepEquals, Finding{
+		Severity: SeverityWarning,
+		Target:   &Target{Type: "cluster", Value: "c3"},
+		Message:  "cluster is not default and has no pools",
+	})
+}
+
+func (s *S) TestCheckClustersNoCluster(c *check.C) {
+	s.mockService.Cluster.OnList = func() ([]provTypes.Cluster, error) {
+		return nil, provTypes.ErrNoCluster
+	}
+	findings, err := checkClusters()
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.HasLen, 0)
+}
+
+func (s *S) TestCheckRouters(c *check.C) {
+	config.Set("routers:r1:type", "fake")
+	config.Set("routers:r2:type", "fake-hc")
+	config.Set("routers:r3:type", "unknown")
+	routertest.HCRouter.SetErr(errors.New("router is down"))
+	findings, err := checkRouters()
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.HasLen, 2)
+	c.Assert(findings[0], check.DeepEquals, Finding{
+		Severity: SeverityError,
+		Target:   &Target{Type: "router", Value: "r2"},
+		Message:  "router is unreachable: router is down",
+	})
+	c.Assert(findings[1].Target, check.DeepEquals, &Target{Type: "router", Value: "r3"})
+	c.Assert(findings[1].Severity, check.Equals, SeverityError)
+}
+
+func (s *S) TestCheckPools(c *check.C) {
+	config.Set("routers:r1:type", "fake")
+	err := pool.AddPool(pool.AddPoolOptions{Name: "p1", Provisioner: "fake"})
+	c.Assert(err, check.IsNil)
+	err = pool.AddPool(pool.AddPoolOptions{Name: "p2", Provisioner: "fake"})
+	c.Assert(err, check.IsNil)
+	err = pool.SetPoolConstraint(&pool.PoolConstraint{PoolExpr: "p2", Field: pool.ConstraintTypeRouter, Values: []string{"other"}})
+	c.Assert(err, check.IsNil)
+	err = pool.SetPoolConstraint(&pool.PoolConstraint{PoolExpr: "p2", Field: pool.ConstraintTypeTeam, Values: []string{"other"}})
+	c.Assert(err, check.IsNil)
+	err = s.conn.Pools().Insert(pool.Pool{Name: "p3", Provisioner: "unknown"})
+	c.Assert(err, check.IsNil)
+	findings, err := checkPools()
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.HasLen, 3)
+	c.Assert(findings[0], check.DeepEquals, Finding{
+		Severity: SeverityWarning,
+		Target:   &Target{Type: "pool", Value: "p2"},
+		Message:  "pool has no routers",
+	})
+	c.Assert(findings[1], check.DeepEquals, Finding{
+		Severity: SeverityWarning,
+		Target:   &Target{Type: "pool", Value: "p2"},
+		Message:  "pool has no teams",
+	})
+	c.Assert(findings[2].Target, check.DeepEquals, &Target{Type: "pool", Value: "p3"})
+	c.Assert(findings[2].Severity, check.Equals, SeverityError)
+}
+
+func (s *S) TestCheckOrphanedApps(c *check.C) {
+	err := pool.AddPool(pool.AddPoolOptions{Name: "p1", Provisioner: "fake"})
+	c.Assert(err, check.IsNil)
+	err = s.conn.Apps().Insert(bson.M{"name": "a1", "pool": "p1"}, bson.M{"name": "a2", "pool": "removed"})
+	c.Assert(err, check.IsNil)
+	findings, err := checkOrphanedApps()
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.DeepEquals, []Finding{{
+		Severity: SeverityError,
+		Target:   &Target{Type: "app", Value: "a2"},
+		Message:  `app pool "removed" not found`,
+	}})
+}
+
+func (s *S) TestCheckOrphanedServiceInstances(c *check.C) {
+	err := s.conn.Apps().Insert(bson.M{"name": "a1", "pool": "p1"})
+	c.Assert(err, check.IsNil)
+	err = s.conn.ServiceInstances().Insert(
+		service.ServiceInstance{Name: "si1", ServiceName: "mysql", Apps: []string{"a1", "removed"}},
+		service.ServiceInstance{Name: "si2", ServiceName: "mysql", Apps: []string{}},
+		service.ServiceInstance{Name: "si3", ServiceName: "redis", Apps: []string{"a1"}},
+	)
+	c.Assert(err, check.IsNil)
+	findings, err := checkOrphanedServiceInstances()
+	c.Assert(err, check.IsNil)
+	c.Assert(findings, check.DeepEquals, []Finding{{
+		Severity: SeverityWarning,
+		Target:   &Target{Type: "service-instance", Value: "mysql/si1"},
+		Message:  `service instance bound to app "removed", which does not exist`,
+	}})
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package diagnostic provides self-diagnostics for a tsuru installation. It
+// runs a list of registered checks and reports what they found as a list of
+// findings with severity.
+package diagnostic
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tsuru/tsuru/set"
+)
+
+type Severity string
+
+const (
+	SeverityInfo    = Severity("info")
+	SeverityWarning = Severity("warning")
+	SeverityError   = Severity("error")
+)
+
+// Target identifies the object a finding refers to, e.g. a pool or an app.
+type Target struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Finding is a single problem found by a check.
+type Finding struct {
+	Check    string   `json:"check"`
+	Severity Severity `json:"severity"`
+	Target   *Target  `json:"target,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Report is the result of running a set of checks.
+type Report struct {
+	StartTime time.Time     `json:"startTime"`
+	Duration  time.Duration `json:"duration"`
+	Checks    []string      `json:"checks"`
+	Findings  []Finding     `json:"findings"`
+}
+
+// Count returns the number of findings with the given severity.
+func (r *Report) Count(severity Severity) int {
+	var count int
+	for _, f := range r.Findings {
+		if f.Severity == severity {
+			count++
+		}
+	}
+	return count
+}
+
+// CheckFunc runs a check, returning the findings about the installation. An
+// error means the check itself couldn't run.
+type CheckFunc func() ([]Finding, error)
+
+type checker struct {
+	name  string
+	check CheckFunc
+}
+
+var (
+	checkersMu sync.RWMutex
+	checkers   []checker
+)
+
+// Register adds a new check to the list of checks executed by Run. The name
+// of the check is set in every finding it returns.
+func Register(name string, check CheckFunc) {
+	checkersMu.Lock()
+	defer checkersMu.Unlock()
+	checkers = append(checkers, checker{name: name, check: check})
+}
+
+// Names returns the names of all registered checks, in execution order.
+func Names() []string {
+	checkersMu.RLock()
+	defer checkersMu.RUnlock()
+	names := make([]string, len(checkers))
+	for i := range checkers {
+		names[i] = checkers[i].name
+	}
+	return names
+}
+
+// Run runs the registered checks matching names, or all of them if names is
+// empty, and returns a report with their findings.
+func Run(names ...string) *Report {
+	checkersMu.RLock()
+	toRun := make([]checker, len(checkers))
+	copy(toRun, checkers)
+	checkersMu.RUnlock()
+	nameSet := set.FromSlice(names)
+	report := &Report{
+		StartTime: time.Now().UTC(),
+		Checks:    []string{},
+		Findings:  []Finding{},
+	}
+	for _, c := range toRun {
+		if len(names) > 0 && !nameSet.Includes(c.name) {
+			continue
+		}
+		report.Checks = append(report.Checks, c.name)
+		findings, err := c.check()
+		if err != nil {
+			findings = append(findings, Finding{
+				Severity: SeverityError,
+				Message:  fmt.Sprintf("unable to run check: %v", err),
+			})
+		}
+		for _, f := range findings {
+			f.Check = c.name
+			report.Findings = append(report.Findings, f)
+		}
+	}
+	report.Duration = time.Since(report.StartTime)
+	return report
+}
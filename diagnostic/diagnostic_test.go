@@ -0,0 +1,53 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package diagnostic
+
+import (
+	"errors"
+
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestRun(c *check.C) {
+	checkers = nil
+	Register("ok", func() ([]Finding, error) {
+		return nil, nil
+	})
+	Register("warn", func() ([]Finding, error) {
+		return []Finding{
+			{Severity: SeverityWarning, Target: &Target{Type: "pool", Value: "p1"}, Message: "something"},
+		}, nil
+	})
+	Register("fail", func() ([]Finding, error) {
+		return nil, errors.New("my error")
+	})
+	c.Assert(Names(), check.DeepEquals, []string{"ok", "warn", "fail"})
+	report := Run()
+	c.Assert(report.StartTime.IsZero(), check.Equals, false)
+	c.Assert(report.Checks, check.DeepEquals, []string{"ok", "warn", "fail"})
+	c.Assert(report.Findings, check.DeepEquals, []Finding{
+		{Check: "warn", Severity: SeverityWarning, Target: &Target{Type: "pool", Value: "p1"}, Message: "something"},
+		{Check: "fail", Severity: SeverityError, Message: "unable to run check: my error"},
+	})
+	c.Assert(report.Count(SeverityWarning), check.Equals, 1)
+	c.Assert(report.Count(SeverityError), check.Equals, 1)
+	c.Assert(report.Count(SeverityInfo), check.Equals, 0)
+}
+
+func (s *S) TestRunFiltered(c *check.C) {
+	checkers = nil
+	var calls []string
+	for _, name := range []string{"c1", "c2", "c3"} {
+		name := name
+		Register(name, func() ([]Finding, error) {
+			calls = append(calls, name)
+			return nil, nil
+		})
+	}
+	report := Run("c1", "c3")
+	c.Assert(report.Checks, check.DeepEquals, []string{"c1", "c3"})
+	c.Assert(report.Findings, check.DeepEquals, []Finding{})
+	c.Assert(calls, check.DeepEquals, []string{"c1", "c3"})
+}
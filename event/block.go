@@ -12,9 +12,42 @@ import (
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	permTypes "github.com/tsuru/tsuru/types/permission"
 )
 
-const blockListLimit = 25
+const (
+	blockListLimit       = 25
+	blockUpcomingWindows = 3
+)
+
+type BlockRecurrence string
+
+const (
+	BlockRecurrenceNone   = BlockRecurrence("")
+	BlockRecurrenceDaily  = BlockRecurrence("daily")
+	BlockRecurrenceWeekly = BlockRecurrence("weekly")
+)
+
+func (r BlockRecurrence) period() time.Duration {
+	switch r {
+	case BlockRecurrenceDaily:
+		return 24 * time.Hour
+	case BlockRecurrenceWeekly:
+		return 7 * 24 * time.Hour
+	}
+	return 0
+}
+
+func (r BlockRecurrence) days() int {
+	switch r {
+	case BlockRecurrenceDaily:
+		return 1
+	case BlockRecurrenceWeekly:
+		return 7
+	}
+	return 0
+}
 
 type ErrActiveEventBlockNotFound struct {
 	id string
@@ -38,15 +71,34 @@ func (e ErrEventBlocked) Error() string {
 	)
 }
 
+// Block prevents events from running while active. Scheduled blocks, those
+// with a WindowStart, are only enforced from WindowStart to WindowEnd,
+// optionally repeating daily or weekly until RecurrenceEnd. Recurring windows
+// keep the wall clock time of the first one in Timezone, UTC by default.
+// Events owned by ExceptUsers or on resources of ExceptTeams are never
+// blocked.
 type Block struct {
-	ID        bson.ObjectId `bson:"_id,omitempty"`
-	StartTime time.Time
-	EndTime   time.Time `bson:"endtime,omitempty"`
-	KindName  string
-	OwnerName string
-	Target    Target `bson:"target,omitempty"`
-	Reason    string
-	Active    bool
+	ID            bson.ObjectId `bson:"_id,omitempty"`
+	StartTime     time.Time
+	EndTime       time.Time `bson:"endtime,omitempty"`
+	KindName      string
+	OwnerName     string
+	Target        Target `bson:"target,omitempty"`
+	Reason        string
+	Active        bool
+	WindowStart   time.Time       `bson:",omitempty"`
+	WindowEnd     time.Time       `bson:",omitempty"`
+	Recurrence    BlockRecurrence `bson:",omitempty"`
+	RecurrenceEnd time.Time       `bson:",omitempty"`
+	Timezone      string          `bson:",omitempty"`
+	ExceptTeams   []string        `bson:",omitempty"`
+	ExceptUsers   []string        `bson:",omitempty"`
+	Windows       []BlockWindow   `bson:"-"`
+}
+
+type BlockWindow struct {
+	Start time.Time
+	End   time.Time
 }
 
 func (b *Block) Blocks(e *Event) bool {
@@ -59,9 +111,129 @@ func (b *Block) Blocks(e *Event) bool {
 	if !(e.Target == b.Target || b.Target == Target{} || (b.Target.Type == e.Target.Type && b.Target.Value == "")) {
 		return false
 	}
+	for _, u := range b.ExceptUsers {
+		if e.Owner.Name == u {
+			return false
+		}
+	}
+	for _, ctx := range e.Allowed.Contexts {
+		if ctx.CtxType != permTypes.CtxTeam {
+			continue
+		}
+		for _, team := range b.ExceptTeams {
+			if ctx.Value == team {
+				return false
+			}
+		}
+	}
 	return true
 }
 
+func (b *Block) IsScheduled() bool {
+	return !b.WindowStart.IsZero()
+}
+
+func (b *Block) location() *time.Location {
+	loc, err := time.LoadLocation(b.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// windowAt returns the n-th window of a recurring block, moving the first
+// window by whole days in the block timezone so it doesn't drift across
+// daylight saving time changes.
+func (b *Block) windowAt(n int) BlockWindow {
+	loc := b.location()
+	days := n * b.Recurrence.days()
+	shift := func(t time.Time) time.Time {
+		t = t.In(loc)
+		return time.Date(t.Year(), t.Month(), t.Day()+days, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+	}
+	return BlockWindow{Start: shift(b.WindowStart), End: shift(b.WindowEnd)}
+}
+
+// nextWindow returns the first window of a scheduled block ending after t.
+func (b *Block) nextWindow(t time.Time) (BlockWindow, bool) {
+	w := BlockWindow{Start: b.WindowStart, End: b.WindowEnd}
+	if period := b.Recurrence.period(); period > 0 && t.After(w.Start) {
+		// Windows are not exactly one period apart across daylight saving
+		// time changes, so start from the one before the estimate.
+		n := int(t.Sub(w.Start)/period) - 1
+		if n < 0 {
+			n = 0
+		}
+		w = b.windowAt(n)
+		for !t.Before(w.End) {
+			n++
+			w = b.windowAt(n)
+		}
+		if !b.RecurrenceEnd.IsZero() && !w.Start.Before(b.RecurrenceEnd) {
+			return BlockWindow{}, false
+		}
+	}
+	if !t.Before(w.End) {
+		return BlockWindow{}, false
+	}
+	return w, true
+}
+
+// ActiveAt returns whether the block is enforced at t.
+func (b *Block) ActiveAt(t time.Time) bool {
+	if !b.Active {
+		return false
+	}
+	if !b.IsScheduled() {
+		return true
+	}
+	w, ok := b.nextWindow(t)
+	return ok && !t.Before(w.Start)
+}
+
+// UpcomingWindows returns up to n windows of a scheduled block, starting
+// with the one in progress at t, if any.
+func (b *Block) UpcomingWindows(t time.Time, n int) []BlockWindow {
+	if !b.Active || !b.IsScheduled() {
+		return nil
+	}
+	var windows []BlockWindow
+	for len(windows) < n {
+		w, ok := b.nextWindow(t)
+		if !ok {
+			break
+		}
+		windows = append(windows, w)
+		t = w.End
+	}
+	return windows
+}
+
+func (b *Block) validate() error {
+	if _, err := time.LoadLocation(b.Timezone); err != nil {
+		return &tsuruErrors.ValidationError{Message: fmt.Sprintf("invalid timezone %q", b.Timezone)}
+	}
+	period := b.Recurrence.period()
+	if b.Recurrence != BlockRecurrenceNone && period == 0 {
+		return &tsuruErrors.ValidationError{
+			Message: fmt.Sprintf("invalid recurrence %q, valid values are: %s, %s", b.Recurrence, BlockRecurrenceDaily, BlockRecurrenceWeekly),
+		}
+	}
+	if !b.IsScheduled() {
+		if !b.WindowEnd.IsZero() || b.Recurrence != BlockRecurrenceNone || !b.RecurrenceEnd.IsZero() || b.Timezone != "" {
+			return &tsuruErrors.ValidationError{Message: "window start is required for scheduled blocks"}
+		}
+		return nil
+	}
+	if !b.WindowEnd.After(b.WindowStart) {
+		return &tsuruErrors.ValidationError{Message: "window end must be after window start"}
+	}
+	if period > 0 && b.WindowEnd.Sub(b.WindowStart) >= period {
+		return &tsuruErrors.ValidationError{Message: fmt.Sprintf("window must be shorter than the %s recurrence", b.Recurrence)}
+	}
+	return nil
+}
+
 func (b *Block) String() string {
 	kind := b.KindName
 	if kind == "" {
@@ -79,6 +251,10 @@ func (b *Block) String() string {
 }
 
 func AddBlock(b *Block) error {
+	err := b.validate()
+	if err != nil {
+		return err
+	}
 	conn, err := db.Conn()
 	if err != nil {
 		return err
@@ -104,12 +280,22 @@ func RemoveBlock(id bson.ObjectId) error {
 	return err
 }
 
+// ListBlocks returns the blocks, filling the upcoming windows of scheduled
+// blocks.
 func ListBlocks(active *bool) ([]Block, error) {
 	query := bson.M{}
 	if active != nil {
 		query["active"] = *active
 	}
-	return listBlocks(query)
+	blocks, err := listBlocks(query)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	for i := range blocks {
+		blocks[i].Windows = blocks[i].UpcomingWindows(now, blockUpcomingWindows)
+	}
+	return blocks, nil
 }
 
 func listBlocks(query bson.M) ([]Block, error) {
@@ -134,8 +320,9 @@ func checkIsBlocked(evt *Event) error {
 	if err != nil {
 		return err
 	}
+	now := time.Now().UTC()
 	for _, b := range blocks {
-		if b.Blocks(evt) {
+		if b.ActiveAt(now) && b.Blocks(evt) {
 			return ErrEventBlocked{event: evt, block: &b}
 		}
 	}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/globalsign/mgo/bson"
+	permTypes "github.com/tsuru/tsuru/types/permission"
 	check "gopkg.in/check.v1"
 )
 
@@ -117,3 +118,148 @@ func (s *S) TestCheckIsBlocked(c *check.C) {
 		}
 	}
 }
+
+func (s *S) TestAddBlockInvalidSchedule(c *check.C) {
+	start := time.Date(2018, time.June, 1, 18, 0, 0, 0, time.UTC)
+	tt := []struct {
+		block *Block
+		err   string
+	}{
+		{&Block{Recurrence: "monthly"}, `invalid recurrence "monthly", valid values are: daily, weekly`},
+		{&Block{WindowEnd: start}, "window start is required for scheduled blocks"},
+		{&Block{Recurrence: BlockRecurrenceDaily}, "window start is required for scheduled blocks"},
+		{&Block{WindowStart: start, WindowEnd: start}, "window end must be after window start"},
+		{&Block{WindowStart: start, WindowEnd: start.Add(24 * time.Hour), Recurrence: BlockRecurrenceDaily}, "window must be shorter than the daily recurrence"},
+		{&Block{WindowStart: start, WindowEnd: start.Add(time.Hour), Timezone: "America/Nowhere"}, `invalid timezone "America/Nowhere"`},
+		{&Block{Timezone: "America/New_York"}, "window start is required for scheduled blocks"},
+	}
+	for i, t := range tt {
+		err := AddBlock(t.block)
+		c.Assert(err, check.ErrorMatches, t.err, check.Commentf("(%d)", i))
+	}
+	blocks, err := listBlocks(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(blocks, check.HasLen, 0)
+}
+
+func (s *S) TestBlockActiveAt(c *check.C) {
+	friday := time.Date(2018, time.June, 1, 18, 0, 0, 0, time.UTC)
+	monday := time.Date(2018, time.June, 4, 8, 0, 0, 0, time.UTC)
+	weekly := &Block{Active: true, WindowStart: friday, WindowEnd: monday, Recurrence: BlockRecurrenceWeekly}
+	once := &Block{Active: true, WindowStart: friday, WindowEnd: monday}
+	until := &Block{Active: true, WindowStart: friday, WindowEnd: monday, Recurrence: BlockRecurrenceWeekly, RecurrenceEnd: friday.Add(24 * time.Hour)}
+	tt := []struct {
+		block    *Block
+		t        time.Time
+		expected bool
+	}{
+		{&Block{Active: true}, friday, true},
+		{&Block{Active: false}, friday, false},
+		{weekly, friday.Add(-time.Minute), false},
+		{weekly, friday, true},
+		{weekly, monday.Add(-time.Minute), true},
+		{weekly, monday, false},
+		{weekly, friday.AddDate(0, 0, 7).Add(time.Hour), true},
+		{weekly, monday.AddDate(0, 0, 3), false},
+		{once, friday.Add(time.Hour), true},
+		{once, friday.AddDate(0, 0, 7).Add(time.Hour), false},
+		{until, friday.Add(time.Hour), true},
+		{until, friday.AddDate(0, 0, 7).Add(time.Hour), false},
+	}
+	for i, t := range tt {
+		c.Check(t.block.ActiveAt(t.t), check.Equals, t.expected, check.Commentf("(%d)", i))
+	}
+}
+
+func (s *S) TestBlockUpcomingWindows(c *check.C) {
+	start := time.Date(2018, time.June, 1, 22, 0, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	block := &Block{Active: true, WindowStart: start, WindowEnd: end, Recurrence: BlockRecurrenceDaily}
+	windows := block.UpcomingWindows(start.Add(time.Hour), 3)
+	c.Assert(windows, check.DeepEquals, []BlockWindow{
+		{Start: start, End: end},
+		{Start: start.AddDate(0, 0, 1), End: end.AddDate(0, 0, 1)},
+		{Start: start.AddDate(0, 0, 2), End: end.AddDate(0, 0, 2)},
+	})
+	block.RecurrenceEnd = start.AddDate(0, 0, 1)
+	windows = block.UpcomingWindows(start.Add(time.Hour), 3)
+	c.Assert(windows, check.DeepEquals, []BlockWindow{{Start: start, End: end}})
+	c.Assert((&Block{Active: true}).UpcomingWindows(start, 3), check.IsNil)
+}
+
+func (s *S) TestBlockWindowsAcrossDaylightSavingTime(c *check.C) {
+	loc, err := time.LoadLocation("America/New_York")
+	c.Assert(err, check.IsNil)
+	friday := time.Date(2018, time.March, 2, 18, 0, 0, 0, loc)
+	block := &Block{
+		Active:      true,
+		WindowStart: friday.UTC(),
+		WindowEnd:   friday.Add(2 * time.Hour).UTC(),
+		Recurrence:  BlockRecurrenceWeekly,
+		Timezone:    "America/New_York",
+	}
+	windows := block.UpcomingWindows(friday.Add(time.Hour), 3)
+	c.Assert(windows, check.HasLen, 3)
+	for i, w := range windows {
+		c.Check(w.Start.Equal(time.Date(2018, time.March, 2+7*i, 18, 0, 0, 0, loc)), check.Equals, true, check.Commentf("(%d) %v", i, w.Start))
+		c.Check(w.End.Equal(time.Date(2018, time.March, 2+7*i, 20, 0, 0, 0, loc)), check.Equals, true, check.Commentf("(%d) %v", i, w.End))
+	}
+	afterDST := time.Date(2018, time.March, 16, 18, 0, 0, 0, loc)
+	c.Assert(block.ActiveAt(afterDST.Add(-time.Minute)), check.Equals, false)
+	c.Assert(block.ActiveAt(afterDST), check.Equals, true)
+	c.Assert(block.ActiveAt(afterDST.Add(2*time.Hour-time.Minute)), check.Equals, true)
+	c.Assert(block.ActiveAt(afterDST.Add(2*time.Hour)), check.Equals, false)
+	block.Timezone = ""
+	c.Assert(block.ActiveAt(afterDST), check.Equals, false)
+	c.Assert(block.ActiveAt(afterDST.Add(2*time.Hour)), check.Equals, true)
+}
+
+func (s *S) TestListBlocksUpcomingWindows(c *check.C) {
+	start := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	block := &Block{Reason: "freeze", WindowStart: start, WindowEnd: start.Add(time.Hour), Recurrence: BlockRecurrenceWeekly}
+	err := AddBlock(block)
+	c.Assert(err, check.IsNil)
+	blocks, err := ListBlocks(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(blocks, check.HasLen, 1)
+	c.Assert(blocks[0].Windows, check.HasLen, blockUpcomingWindows)
+	c.Assert(blocks[0].Windows[0].Start.Equal(start), check.Equals, true)
+	c.Assert(blocks[0].Windows[1].Start.Equal(start.AddDate(0, 0, 7)), check.Equals, true)
+}
+
+func (s *S) TestCheckIsBlockedScheduled(c *check.C) {
+	now := time.Now().UTC()
+	future := &Block{KindName: "app.deploy", WindowStart: now.Add(time.Hour), WindowEnd: now.Add(2 * time.Hour)}
+	err := AddBlock(future)
+	c.Assert(err, check.IsNil)
+	evt := &Event{eventData: eventData{Kind: Kind{Name: "app.deploy"}}}
+	c.Assert(checkIsBlocked(evt), check.IsNil)
+	current := &Block{KindName: "app.deploy", WindowStart: now.Add(-time.Hour), WindowEnd: now.Add(time.Hour)}
+	err = AddBlock(current)
+	c.Assert(err, check.IsNil)
+	err = checkIsBlocked(evt)
+	c.Assert(err, check.FitsTypeOf, ErrEventBlocked{})
+	c.Assert(err.(ErrEventBlocked).block.ID, check.Equals, current.ID)
+}
+
+func (s *S) TestCheckIsBlockedExceptions(c *check.C) {
+	block := &Block{KindName: "app.deploy", ExceptUsers: []string{"sre@tsuru.io"}, ExceptTeams: []string{"sre"}}
+	err := AddBlock(block)
+	c.Assert(err, check.IsNil)
+	teamCtx := func(team string) AllowedPermission {
+		return AllowedPermission{Contexts: []permTypes.PermissionContext{{CtxType: permTypes.CtxTeam, Value: team}}}
+	}
+	tt := []struct {
+		event   *Event
+		blocked bool
+	}{
+		{&Event{eventData: eventData{Kind: Kind{Name: "app.deploy"}, Owner: Owner{Type: OwnerTypeUser, Name: "dev@tsuru.io"}}}, true},
+		{&Event{eventData: eventData{Kind: Kind{Name: "app.deploy"}, Owner: Owner{Type: OwnerTypeUser, Name: "sre@tsuru.io"}}}, false},
+		{&Event{eventData: eventData{Kind: Kind{Name: "app.deploy"}, Allowed: teamCtx("dev")}}, true},
+		{&Event{eventData: eventData{Kind: Kind{Name: "app.deploy"}, Allowed: teamCtx("sre")}}, false},
+	}
+	for i, t := range tt {
+		err := checkIsBlocked(t.event)
+		c.Check(err != nil, check.Equals, t.blocked, check.Commentf("(%d)", i))
+	}
+}
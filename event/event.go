@@ -62,8 +62,10 @@ const (
 )
 
 var (
-	throttlingInfo  = map[string]ThrottlingSpec{}
-	errInvalidQuery = errors.New("invalid query")
+	throttlingMu       sync.RWMutex
+	throttlingInfo     = map[string]ThrottlingSpec{}
+	throttlingDefaults = map[string]ThrottlingSpec{}
+	errInvalidQuery    = errors.New("invalid query")
 
 	ErrNotCancelable          = errors.New("event is not cancelable")
 	ErrCancelAlreadyRequested = errors.New("event cancel already requested")
@@ -202,12 +204,14 @@ type eventData struct {
 	Kind            Kind
 	Owner           Owner
 	LockUpdateTime  time.Time
+	UpdateTime      time.Time `bson:",omitempty"`
 	Error           string
 	Log             string    `bson:",omitempty"`
 	RemoveDate      time.Time `bson:",omitempty"`
 	CancelInfo      cancelInfo
 	Cancelable      bool
 	Running         bool
+	Pending         bool `bson:",omitempty"`
 	Allowed         AllowedPermission
 	AllowedCancel   AllowedPermission
 }
@@ -326,27 +330,39 @@ func Initialize() error {
 	if err != nil {
 		return errors.Wrap(err, "unable to load event throttling")
 	}
+	internalConfig.Subscribe("event throttling", []string{"event:throttling"}, loadThrottling)
 	cleaner.start()
 	return nil
 }
 
+// loadThrottling replaces the throttling specs loaded from the config,
+// keeping the ones set with SetThrottling unless overridden in the config.
 func loadThrottling() error {
 	var specs []ThrottlingSpec
 	err := internalConfig.UnmarshalConfig("event:throttling", &specs)
 	if err != nil {
-		if _, isNotFound := errors.Cause(err).(config.ErrKeyNotFound); isNotFound {
-			return nil
+		if _, isNotFound := errors.Cause(err).(config.ErrKeyNotFound); !isNotFound {
+			return err
 		}
-		return err
+	}
+	throttlingMu.Lock()
+	defer throttlingMu.Unlock()
+	newInfo := make(map[string]ThrottlingSpec, len(throttlingDefaults)+len(specs))
+	for key, spec := range throttlingDefaults {
+		newInfo[key] = spec
 	}
 	for _, spec := range specs {
-		SetThrottling(spec)
+		newInfo[throttlingKey(spec.TargetType, spec.KindName, spec.AllTargets)] = spec
 	}
+	throttlingInfo = newInfo
 	return nil
 }
 
 func SetThrottling(spec ThrottlingSpec) {
 	key := throttlingKey(spec.TargetType, spec.KindName, spec.AllTargets)
+	throttlingMu.Lock()
+	defer throttlingMu.Unlock()
+	throttlingDefaults[key] = spec
 	throttlingInfo[key] = spec
 }
 
@@ -355,6 +371,8 @@ func getThrottling(t *Target, k *Kind, allTargets bool) *ThrottlingSpec {
 		throttlingKey(t.Type, k.Name, allTargets),
 		throttlingKey(t.Type, "", allTargets),
 	}
+	throttlingMu.RLock()
+	defer throttlingMu.RUnlock()
 	for _, key := range keys {
 		if s, ok := throttlingInfo[key]; ok {
 			return &s
@@ -908,6 +926,7 @@ func newEvt(opts *Opts) (evt *Event, err error) {
 		Owner:           o,
 		StartCustomData: raw,
 		LockUpdateTime:  now,
+		UpdateTime:      now,
 		Running:         true,
 		Cancelable:      opts.Cancelable,
 		Allowed:         opts.Allowed,
@@ -992,6 +1011,10 @@ func checkLocked(evt *Event, disableLock bool) error {
 
 func (e *Event) RawInsert(start, other, end interface{}) error {
 	e.ID = eventID{ObjId: e.UniqueID}
+	e.UpdateTime = e.EndTime
+	if e.UpdateTime.IsZero() {
+		e.UpdateTime = e.StartTime
+	}
 	var err error
 	e.StartCustomData, err = makeBSONRaw(start)
 	if err != nil {
@@ -1026,6 +1049,38 @@ func (e *Event) DoneCustomData(evtErr error, customData interface{}) error {
 	return e.done(evtErr, customData, false)
 }
 
+// SetPending hands the event off to an asynchronous operation responsible for
+// finishing it, possibly in another tsuru process. The log written so far is
+// stored, and the event lock is no longer refreshed by this process nor
+// considered expired. It may be called again to store log written afterwards.
+func (e *Event) SetPending() error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	var newLog string
+	if e.logBuffer != nil {
+		newLog = e.logBuffer.String()
+	}
+	err = conn.Events().Update(bson.M{"_id": e.ID, "running": true}, bson.M{
+		"$set": bson.M{"pending": true, "log": e.Log + newLog, "updatetime": time.Now().UTC()},
+	})
+	if err != nil && err != mgo.ErrNotFound {
+		return err
+	}
+	e.Log += newLog
+	if e.logBuffer != nil {
+		e.logBuffer.Reset()
+	}
+	if !e.Pending {
+		e.Pending = true
+		updater.remove(e.ID)
+		eventCurrent.WithLabelValues(e.Kind.Name).Dec()
+	}
+	return nil
+}
+
 func (e *Event) SetLogWriter(w io.Writer) {
 	e.logWriter = w
 }
@@ -1042,7 +1097,7 @@ func (e *Event) SetOtherCustomData(data interface{}) error {
 	defer conn.Close()
 	coll := conn.Events()
 	return coll.UpdateId(e.ID, bson.M{
-		"$set": bson.M{"othercustomdata": data},
+		"$set": bson.M{"othercustomdata": data, "updatetime": time.Now().UTC()},
 	})
 }
 
@@ -1110,14 +1165,16 @@ func (e *Event) TryCancel(reason, owner string) error {
 	}
 	defer conn.Close()
 	coll := conn.Events()
+	now := time.Now().UTC()
 	change := mgo.Change{
 		Update: bson.M{"$set": bson.M{
 			"cancelinfo": cancelInfo{
 				Owner:     owner,
 				Reason:    reason,
-				StartTime: time.Now().UTC(),
+				StartTime: now,
 				Asked:     true,
 			},
+			"updatetime": now,
 		}},
 		ReturnNew: true,
 	}
@@ -1141,10 +1198,12 @@ func (e *Event) AckCancel() (bool, error) {
 	}
 	defer conn.Close()
 	coll := conn.Events()
+	now := time.Now().UTC()
 	change := mgo.Change{
 		Update: bson.M{"$set": bson.M{
-			"cancelinfo.acktime":  time.Now().UTC(),
+			"cancelinfo.acktime":  now,
 			"cancelinfo.canceled": true,
+			"updatetime":          now,
 		}},
 		ReturnNew: true,
 	}
@@ -1177,11 +1236,16 @@ func (e *Event) OtherData(value interface{}) error {
 }
 
 func (e *Event) done(evtErr error, customData interface{}, abort bool) (err error) {
+	// Pending events were already removed from the current events when they
+	// were handed off.
+	pending := e.Pending
 	// Done will be usually called in a defer block ignoring errors. This is
 	// why we log error messages here.
 	defer func() {
 		eventDuration.WithLabelValues(e.Kind.Name).Observe(time.Since(e.StartTime).Seconds())
-		eventCurrent.WithLabelValues(e.Kind.Name).Dec()
+		if !pending {
+			eventCurrent.WithLabelValues(e.Kind.Name).Dec()
+		}
 		if err != nil {
 			log.Errorf("[events] error marking event as done - %#v: %s", e, err)
 		} else {
@@ -1206,13 +1270,15 @@ func (e *Event) done(evtErr error, customData interface{}, abort bool) (err erro
 		e.Error = "canceled by user request"
 	}
 	e.EndTime = time.Now().UTC()
+	e.UpdateTime = e.EndTime
 	e.EndCustomData, err = makeBSONRaw(customData)
 	if err != nil {
 		return err
 	}
 	e.Running = false
+	e.Pending = false
 	if e.logBuffer != nil {
-		e.Log = e.logBuffer.String()
+		e.Log += e.logBuffer.String()
 	}
 	var dbEvt Event
 	err = coll.FindId(e.ID).One(&dbEvt.eventData)
@@ -1236,7 +1302,7 @@ func (e *Event) Init() {
 func checkIsExpired(coll *storage.Collection, id interface{}) bool {
 	var existingEvt Event
 	err := coll.FindId(id).One(&existingEvt.eventData)
-	if err == nil {
+	if err == nil && !existingEvt.Pending {
 		now := time.Now().UTC()
 		lastUpdate := existingEvt.LockUpdateTime.UTC()
 		if now.After(lastUpdate.Add(lockExpireTimeout)) {
@@ -48,6 +48,7 @@ func setBaseConfig() {
 func (s *S) SetUpTest(c *check.C) {
 	setBaseConfig()
 	throttlingInfo = map[string]ThrottlingSpec{}
+	throttlingDefaults = map[string]ThrottlingSpec{}
 	conn, err := db.Conn()
 	c.Assert(err, check.IsNil)
 	defer conn.Close()
@@ -80,6 +81,7 @@ func (s *S) TestNewDone(c *check.C) {
 		Running:        true,
 		StartTime:      evt.StartTime,
 		LockUpdateTime: evt.LockUpdateTime,
+		UpdateTime:     evt.UpdateTime,
 		Allowed:        Allowed(permission.PermAppReadEvents),
 	}}
 	expected.Init()
@@ -91,6 +93,7 @@ func (s *S) TestNewDone(c *check.C) {
 	c.Assert(evts[0].LockUpdateTime.IsZero(), check.Equals, false)
 	evts[0].StartTime = expected.StartTime
 	evts[0].LockUpdateTime = expected.LockUpdateTime
+	evts[0].UpdateTime = expected.UpdateTime
 	c.Assert(&evts[0], check.DeepEquals, expected)
 	err = evt.Done(nil)
 	c.Assert(err, check.IsNil)
@@ -103,6 +106,7 @@ func (s *S) TestNewDone(c *check.C) {
 	evts[0].EndTime = time.Time{}
 	evts[0].StartTime = expected.StartTime
 	evts[0].LockUpdateTime = expected.LockUpdateTime
+	evts[0].UpdateTime = expected.UpdateTime
 	expected.Running = false
 	expected.ID = eventID{ObjId: evts[0].ID.ObjId}
 	c.Assert(&evts[0], check.DeepEquals, expected)
@@ -133,6 +137,7 @@ func (s *S) TestNewCustomDataDone(c *check.C) {
 		Running:         true,
 		StartTime:       evt.StartTime,
 		LockUpdateTime:  evt.LockUpdateTime,
+		UpdateTime:      evt.UpdateTime,
 		StartCustomData: evt.StartCustomData,
 		Allowed:         Allowed(permission.PermAppReadEvents),
 	}}
@@ -156,6 +161,7 @@ func (s *S) TestNewCustomDataDone(c *check.C) {
 	evts[0].EndTime = time.Time{}
 	evts[0].StartTime = expected.StartTime
 	evts[0].LockUpdateTime = expected.LockUpdateTime
+	evts[0].UpdateTime = expected.UpdateTime
 	expected.Running = false
 	expected.ID = eventID{ObjId: evts[0].ID.ObjId}
 	expected.EndCustomData = evts[0].EndCustomData
@@ -309,6 +315,7 @@ func (s *S) TestNewDoneDisableLock(c *check.C) {
 		Running:        true,
 		StartTime:      evt.StartTime,
 		LockUpdateTime: evt.LockUpdateTime,
+		UpdateTime:     evt.UpdateTime,
 		Allowed:        Allowed(permission.PermAppReadEvents),
 	}}
 	expected.Init()
@@ -320,6 +327,7 @@ func (s *S) TestNewDoneDisableLock(c *check.C) {
 	c.Assert(evts[0].LockUpdateTime.IsZero(), check.Equals, false)
 	evts[0].StartTime = expected.StartTime
 	evts[0].LockUpdateTime = expected.LockUpdateTime
+	evts[0].UpdateTime = expected.UpdateTime
 	c.Assert(&evts[0], check.DeepEquals, expected)
 	err = evt.Done(nil)
 	c.Assert(err, check.IsNil)
@@ -332,6 +340,7 @@ func (s *S) TestNewDoneDisableLock(c *check.C) {
 	evts[0].EndTime = time.Time{}
 	evts[0].StartTime = expected.StartTime
 	evts[0].LockUpdateTime = expected.LockUpdateTime
+	evts[0].UpdateTime = expected.UpdateTime
 	expected.Running = false
 	expected.ID = eventID{ObjId: evts[0].ID.ObjId}
 	c.Assert(&evts[0], check.DeepEquals, expected)
@@ -429,6 +438,7 @@ func (s *S) TestEventDoneError(c *check.C) {
 		Owner:          Owner{Type: OwnerTypeUser, Name: s.token.GetUserName()},
 		StartTime:      evts[0].StartTime,
 		LockUpdateTime: evts[0].LockUpdateTime,
+		UpdateTime:     evts[0].UpdateTime,
 		EndTime:        evts[0].EndTime,
 		Error:          "myerr",
 		Allowed:        Allowed(permission.PermAppReadEvents),
@@ -474,6 +484,71 @@ func (s *S) TestEventLogfWithWriter(c *check.C) {
 	c.Assert(evts[0].Log, check.Equals, "hey 42\n")
 }
 
+func (s *S) TestEventSetPending(c *check.C) {
+	evt, err := New(&Opts{
+		Target:  Target{Type: "app", Value: "myapp"},
+		Kind:    permission.PermAppUpdateEnvSet,
+		Owner:   s.token,
+		Allowed: Allowed(permission.PermAppReadEvents),
+	})
+	c.Assert(err, check.IsNil)
+	evt.Logf("started")
+	err = evt.SetPending()
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Pending, check.Equals, true)
+	evt.Logf("handed off")
+	err = evt.SetPending()
+	c.Assert(err, check.IsNil)
+	updater.setMu.Lock()
+	_, updating := updater.set[evt.ID]
+	updater.setMu.Unlock()
+	c.Assert(updating, check.Equals, false)
+	dbEvt, err := GetByID(evt.UniqueID)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbEvt.Running, check.Equals, true)
+	c.Assert(dbEvt.Pending, check.Equals, true)
+	c.Assert(dbEvt.Log, check.Equals, "started\nhanded off\n")
+	dbEvt.Logf("finished")
+	err = dbEvt.Done(nil)
+	c.Assert(err, check.IsNil)
+	dbEvt, err = GetByID(evt.UniqueID)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbEvt.Running, check.Equals, false)
+	c.Assert(dbEvt.Pending, check.Equals, false)
+	c.Assert(dbEvt.Log, check.Equals, "started\nhanded off\nfinished\n")
+}
+
+func (s *S) TestNewLockExpiredPendingEvent(c *check.C) {
+	oldLockExpire := lockExpireTimeout
+	lockExpireTimeout = time.Millisecond
+	defer func() {
+		lockExpireTimeout = oldLockExpire
+	}()
+	evt, err := New(&Opts{
+		Target:  Target{Type: "app", Value: "myapp"},
+		Kind:    permission.PermAppUpdateEnvSet,
+		Owner:   s.token,
+		Allowed: Allowed(permission.PermAppReadEvents),
+	})
+	c.Assert(err, check.IsNil)
+	err = evt.SetPending()
+	c.Assert(err, check.IsNil)
+	time.Sleep(100 * time.Millisecond)
+	_, err = New(&Opts{
+		Target:  Target{Type: "app", Value: "myapp"},
+		Kind:    permission.PermAppUpdateEnvUnset,
+		Owner:   s.token,
+		Allowed: Allowed(permission.PermAppReadEvents),
+	})
+	c.Assert(err, check.FitsTypeOf, ErrEventLocked{})
+	err = cleaner.tryCleaning()
+	c.Assert(err, check.IsNil)
+	dbEvt, err := GetByID(evt.UniqueID)
+	c.Assert(err, check.IsNil)
+	c.Assert(dbEvt.Running, check.Equals, true)
+	c.Assert(dbEvt.Error, check.Equals, "")
+}
+
 func (s *S) TestEventCancel(c *check.C) {
 	evt, err := New(&Opts{
 		Target:        Target{Type: "app", Value: "myapp"},
@@ -1177,6 +1252,7 @@ func (s *S) TestNewWithPermission(c *check.C) {
 		Running:        true,
 		StartTime:      evt.StartTime,
 		LockUpdateTime: evt.LockUpdateTime,
+		UpdateTime:     evt.UpdateTime,
 		Allowed: AllowedPermission{
 			Scheme:   permission.PermAppReadEvents.FullName(),
 			Contexts: []permTypes.PermissionContext{permission.Context(permTypes.CtxApp, "myapp"), permission.Context(permTypes.CtxTeam, "myteam")},
@@ -1189,6 +1265,7 @@ func (s *S) TestNewWithPermission(c *check.C) {
 	c.Assert(evts, check.HasLen, 1)
 	evts[0].StartTime = expected.StartTime
 	evts[0].LockUpdateTime = expected.LockUpdateTime
+	evts[0].UpdateTime = expected.UpdateTime
 	c.Assert(&evts[0], check.DeepEquals, expected)
 }
 
@@ -1248,6 +1325,7 @@ func (s *S) TestNewCustomDataPtr(c *check.C) {
 		Running:         true,
 		StartTime:       evt.StartTime,
 		LockUpdateTime:  evt.LockUpdateTime,
+		UpdateTime:      evt.UpdateTime,
 		StartCustomData: evt.StartCustomData,
 		Allowed:         Allowed(permission.PermAppReadEvents),
 	}}
@@ -1341,6 +1419,32 @@ event:
 	c.Assert(throttlingInfo, check.DeepEquals, map[string]ThrottlingSpec{})
 }
 
+func (s *S) TestLoadThrottlingKeepsDefaults(c *check.C) {
+	defer config.Unset("event:throttling")
+	defaultSpec := ThrottlingSpec{TargetType: TargetTypeNode, KindName: "healer", Time: time.Minute, Max: 3, AllTargets: true}
+	SetThrottling(defaultSpec)
+	err := config.ReadConfigBytes([]byte(`
+event:
+  throttling:
+  - target-type: node
+    kind-name: healer
+    limit: 1
+    window: 60
+    all-targets: true
+`))
+	c.Assert(err, check.IsNil)
+	setBaseConfig()
+	err = loadThrottling()
+	c.Assert(err, check.IsNil)
+	c.Assert(throttlingInfo["node_healer_global"].Max, check.Equals, 1)
+	config.Unset("event:throttling")
+	err = loadThrottling()
+	c.Assert(err, check.IsNil)
+	c.Assert(throttlingInfo, check.DeepEquals, map[string]ThrottlingSpec{
+		"node_healer_global": defaultSpec,
+	})
+}
+
 func (s *S) TestEventCancelableContext(c *check.C) {
 	evt, err := New(&Opts{
 		Target:        Target{Type: "app", Value: "myapp"},
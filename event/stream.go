@@ -0,0 +1,190 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/db"
+)
+
+var ErrInvalidStreamCursor = errors.New("invalid stream cursor")
+
+// streamLookback is how far behind the cursor a Stream reads changes again,
+// so changes committed after others with a later update time are not missed.
+var streamLookback = 10 * time.Second
+
+// StreamCursor identifies the position of an event in the stream of event
+// changes, ordered by update time and unique id.
+type StreamCursor struct {
+	UpdateTime time.Time
+	UniqueID   bson.ObjectId
+}
+
+// CursorFor returns the stream cursor pointing to the current state of evt.
+func CursorFor(evt *Event) StreamCursor {
+	return StreamCursor{UpdateTime: evt.UpdateTime, UniqueID: evt.UniqueID}
+}
+
+// After returns whether c points to a later position in the stream than other.
+func (c StreamCursor) After(other StreamCursor) bool {
+	if !c.UpdateTime.Equal(other.UpdateTime) {
+		return c.UpdateTime.After(other.UpdateTime)
+	}
+	return c.UniqueID > other.UniqueID
+}
+
+func (c StreamCursor) IsZero() bool {
+	return c.UpdateTime.IsZero() && c.UniqueID == ""
+}
+
+func (c StreamCursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf("%d-%s", c.UpdateTime.UnixNano(), c.UniqueID.Hex())
+}
+
+// ParseStreamCursor parses a cursor previously returned by
+// StreamCursor.String. A plain event id is also accepted, in which case the
+// cursor points to the current state of that event.
+func ParseStreamCursor(value string) (StreamCursor, error) {
+	if value == "" {
+		return StreamCursor{}, nil
+	}
+	parts := strings.SplitN(value, "-", 2)
+	if len(parts) == 1 {
+		evt, err := GetByHexID(value)
+		if err != nil {
+			if err == ErrEventNotFound {
+				return StreamCursor{}, err
+			}
+			return StreamCursor{}, ErrInvalidStreamCursor
+		}
+		return CursorFor(evt), nil
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || !bson.IsObjectIdHex(parts[1]) {
+		return StreamCursor{}, ErrInvalidStreamCursor
+	}
+	return StreamCursor{
+		UpdateTime: time.Unix(0, nanos).UTC(),
+		UniqueID:   bson.ObjectIdHex(parts[1]),
+	}, nil
+}
+
+// ListUpdates returns up to limit events matching filter that were created or
+// updated after the position in cursor, in the order they changed.
+func ListUpdates(filter *Filter, cursor StreamCursor, limit int) ([]Event, error) {
+	if filter == nil {
+		filter = &Filter{}
+	}
+	query, err := filter.toQuery()
+	if err != nil {
+		if err == errInvalidQuery {
+			return nil, nil
+		}
+		return nil, err
+	}
+	cursorQuery := bson.M{"updatetime": bson.M{"$gt": cursor.UpdateTime}}
+	if cursor.UniqueID != "" {
+		cursorQuery = bson.M{"$or": []bson.M{
+			cursorQuery,
+			{"updatetime": cursor.UpdateTime, "uniqueid": bson.M{"$gt": cursor.UniqueID}},
+		}}
+	}
+	if andBlock, ok := query["$and"].([]bson.M); ok {
+		query["$and"] = append(andBlock, cursorQuery)
+	} else {
+		query["$and"] = []bson.M{cursorQuery}
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	find := conn.Events().Find(query).Sort("updatetime", "uniqueid")
+	if limit > 0 {
+		find = find.Limit(limit)
+	}
+	var allData []eventData
+	err = find.All(&allData)
+	if err != nil {
+		return nil, err
+	}
+	evts := make([]Event, len(allData))
+	for i := range evts {
+		evts[i].Init()
+		evts[i].eventData = allData[i]
+	}
+	return evts, nil
+}
+
+// Stream follows the changes of events matching a filter after a cursor.
+// Each call to Next reads again the changes in a window behind the cursor,
+// skipping the ones already returned, so changes committed after others with
+// a later update time are still returned once.
+type Stream struct {
+	Filter *Filter
+	Cursor StreamCursor
+	start  StreamCursor
+	seen   map[StreamCursor]struct{}
+}
+
+// NewStream returns a stream of the changes after cursor.
+func NewStream(filter *Filter, cursor StreamCursor) *Stream {
+	return &Stream{
+		Filter: filter,
+		Cursor: cursor,
+		start:  cursor,
+		seen:   map[StreamCursor]struct{}{},
+	}
+}
+
+// Next returns up to limit changes not yet returned by the stream, moving its
+// cursor to the latest of them. Late changes may come before changes with a
+// later update time returned by previous calls.
+func (s *Stream) Next(limit int) ([]Event, error) {
+	windowStart := s.Cursor.UpdateTime.Add(-streamLookback)
+	from := StreamCursor{UpdateTime: windowStart}
+	if !from.After(s.start) {
+		from = s.start
+	}
+	var evts []Event
+	for {
+		updates, err := ListUpdates(s.Filter, from, limit)
+		if err != nil {
+			return nil, err
+		}
+		for i := range updates {
+			cursor := CursorFor(&updates[i])
+			if _, ok := s.seen[cursor]; ok {
+				continue
+			}
+			s.seen[cursor] = struct{}{}
+			if cursor.After(s.Cursor) {
+				s.Cursor = cursor
+			}
+			evts = append(evts, updates[i])
+		}
+		if len(evts) > 0 || limit <= 0 || len(updates) < limit {
+			break
+		}
+		// the whole batch was already returned, keep reading after it
+		from = CursorFor(&updates[len(updates)-1])
+	}
+	windowStart = s.Cursor.UpdateTime.Add(-streamLookback)
+	for cursor := range s.seen {
+		if !cursor.UpdateTime.After(windowStart) {
+			delete(s.seen, cursor)
+		}
+	}
+	return evts, nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package event
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/permission"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) newStreamEvent(c *check.C, name string) *Event {
+	evt, err := New(&Opts{
+		Target:  Target{Type: "app", Value: name},
+		Kind:    permission.PermAppUpdateEnvSet,
+		Owner:   s.token,
+		Allowed: Allowed(permission.PermAppReadEvents),
+	})
+	c.Assert(err, check.IsNil)
+	return evt
+}
+
+func (s *S) TestStreamCursorString(c *check.C) {
+	c.Assert(StreamCursor{}.String(), check.Equals, "")
+	id := bson.NewObjectId()
+	cursor := StreamCursor{UpdateTime: time.Unix(10, 5).UTC(), UniqueID: id}
+	c.Assert(cursor.String(), check.Equals, "10000000005-"+id.Hex())
+	parsed, err := ParseStreamCursor(cursor.String())
+	c.Assert(err, check.IsNil)
+	c.Assert(parsed, check.DeepEquals, cursor)
+}
+
+func (s *S) TestParseStreamCursorEmpty(c *check.C) {
+	cursor, err := ParseStreamCursor("")
+	c.Assert(err, check.IsNil)
+	c.Assert(cursor.IsZero(), check.Equals, true)
+}
+
+func (s *S) TestParseStreamCursorInvalid(c *check.C) {
+	for _, value := range []string{"abc-" + bson.NewObjectId().Hex(), "10-xyz", "xyz"} {
+		_, err := ParseStreamCursor(value)
+		c.Assert(err, check.Equals, ErrInvalidStreamCursor)
+	}
+	_, err := ParseStreamCursor(bson.NewObjectId().Hex())
+	c.Assert(err, check.Equals, ErrEventNotFound)
+}
+
+func (s *S) TestParseStreamCursorEventID(c *check.C) {
+	evt := s.newStreamEvent(c, "myapp")
+	dbEvt, err := GetByID(evt.UniqueID)
+	c.Assert(err, check.IsNil)
+	cursor, err := ParseStreamCursor(evt.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(cursor, check.DeepEquals, CursorFor(dbEvt))
+}
+
+func (s *S) TestListUpdates(c *check.C) {
+	evt1 := s.newStreamEvent(c, "app1")
+	evt2 := s.newStreamEvent(c, "app2")
+	evts, err := ListUpdates(nil, StreamCursor{}, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 2)
+	c.Assert(evts[0].UniqueID, check.Equals, evt1.UniqueID)
+	c.Assert(evts[1].UniqueID, check.Equals, evt2.UniqueID)
+	cursor := CursorFor(&evts[1])
+	evts, err = ListUpdates(nil, cursor, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+	time.Sleep(5 * time.Millisecond)
+	err = evt1.Done(nil)
+	c.Assert(err, check.IsNil)
+	evts, err = ListUpdates(nil, cursor, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].UniqueID, check.Equals, evt1.UniqueID)
+	c.Assert(evts[0].Running, check.Equals, false)
+}
+
+func (s *S) TestListUpdatesFilterAndLimit(c *check.C) {
+	s.newStreamEvent(c, "app1")
+	evt2 := s.newStreamEvent(c, "app2")
+	s.newStreamEvent(c, "app3")
+	evts, err := ListUpdates(&Filter{Target: Target{Value: "app2"}}, StreamCursor{}, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].UniqueID, check.Equals, evt2.UniqueID)
+	evts, err = ListUpdates(nil, StreamCursor{}, 2)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 2)
+}
+
+func (s *S) TestListUpdatesOtherCustomData(c *check.C) {
+	evt := s.newStreamEvent(c, "myapp")
+	evts, err := ListUpdates(nil, StreamCursor{}, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	cursor := CursorFor(&evts[0])
+	time.Sleep(5 * time.Millisecond)
+	err = evt.SetOtherCustomData(map[string]string{"a": "b"})
+	c.Assert(err, check.IsNil)
+	evts, err = ListUpdates(nil, cursor, 0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].UniqueID, check.Equals, evt.UniqueID)
+}
+
+func (s *S) TestStreamCursorAfter(c *check.C) {
+	t0 := time.Unix(10, 0).UTC()
+	id1 := bson.ObjectIdHex("5b0000000000000000000001")
+	id2 := bson.ObjectIdHex("5b0000000000000000000002")
+	c.Assert(StreamCursor{UpdateTime: t0.Add(time.Second)}.After(StreamCursor{UpdateTime: t0, UniqueID: id2}), check.Equals, true)
+	c.Assert(StreamCursor{UpdateTime: t0, UniqueID: id2}.After(StreamCursor{UpdateTime: t0, UniqueID: id1}), check.Equals, true)
+	c.Assert(StreamCursor{UpdateTime: t0, UniqueID: id1}.After(StreamCursor{UpdateTime: t0, UniqueID: id1}), check.Equals, false)
+	c.Assert(StreamCursor{UpdateTime: t0}.After(StreamCursor{UpdateTime: t0.Add(time.Second)}), check.Equals, false)
+}
+
+func (s *S) TestStreamNextLateChange(c *check.C) {
+	stream := NewStream(nil, StreamCursor{UpdateTime: time.Now().UTC().Add(-time.Minute)})
+	evt1 := s.newStreamEvent(c, "app1")
+	evts, err := stream.Next(0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].UniqueID, check.Equals, evt1.UniqueID)
+	c.Assert(stream.Cursor, check.DeepEquals, CursorFor(&evts[0]))
+	evts, err = stream.Next(0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+	late := s.newStreamEvent(c, "late")
+	lateTime := stream.Cursor.UpdateTime.Add(-time.Second)
+	conn, err := db.Conn()
+	c.Assert(err, check.IsNil)
+	defer conn.Close()
+	err = conn.Events().Update(bson.M{"uniqueid": late.UniqueID}, bson.M{"$set": bson.M{"updatetime": lateTime}})
+	c.Assert(err, check.IsNil)
+	cursor := stream.Cursor
+	evts, err = stream.Next(0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].UniqueID, check.Equals, late.UniqueID)
+	c.Assert(stream.Cursor, check.DeepEquals, cursor)
+	evts, err = stream.Next(0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+	time.Sleep(5 * time.Millisecond)
+	err = evt1.Done(nil)
+	c.Assert(err, check.IsNil)
+	evts, err = stream.Next(0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].UniqueID, check.Equals, evt1.UniqueID)
+	c.Assert(evts[0].Running, check.Equals, false)
+}
+
+func (s *S) TestStreamNextIgnoresChangesBeforeStart(c *check.C) {
+	s.newStreamEvent(c, "before")
+	evts, err := ListUpdates(nil, StreamCursor{}, 0)
+	c.Assert(err, check.IsNil)
+	stream := NewStream(nil, CursorFor(&evts[0]))
+	evts, err = stream.Next(0)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+}
+
+func (s *S) TestStreamNextSkipsReturnedBatch(c *check.C) {
+	stream := NewStream(nil, StreamCursor{UpdateTime: time.Now().UTC().Add(-time.Minute)})
+	for i := 0; i < 3; i++ {
+		s.newStreamEvent(c, fmt.Sprintf("app%d", i))
+	}
+	evts, err := stream.Next(2)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 2)
+	evts, err = stream.Next(2)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].Target.Value, check.Equals, "app2")
+	evts, err = stream.Next(2)
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+}
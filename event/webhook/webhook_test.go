@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"sort"
 	"testing"
 
 	"github.com/tsuru/config"
@@ -149,6 +150,42 @@ func (s *S) TestWebhookServiceNotifyDefaultBody(c *check.C) {
 	c.Assert(receivedReq.Header.Get("Content-Type"), check.Equals, "application/json")
 }
 
+func (s *S) TestWebhookServiceNotifyDeployRequestEvents(c *check.C) {
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: "app", Value: "myapp"},
+		RawOwner:     event.Owner{Type: "user", Name: "me@me.com"},
+		InternalKind: "deploy-request",
+		DisableLock:  true,
+		Allowed:      event.Allowed(permission.PermAppReadEvents, permission.Context(permTypes.CtxApp, "myapp")),
+	})
+	c.Assert(err, check.IsNil)
+	err = evt.Done(nil)
+	c.Assert(err, check.IsNil)
+	var called []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = append(called, r.URL.Path)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	hooks := map[string][]string{
+		"requests":  {"deploy-request"},
+		"approvals": {"app.deploy-approve"},
+		"deploys":   {"app.deploy"},
+	}
+	for name, kinds := range hooks {
+		err = s.service.storage.Insert(eventTypes.Webhook{
+			Name:        name,
+			URL:         srv.URL + "/" + name,
+			EventFilter: eventTypes.WebhookEventFilter{KindNames: kinds},
+		})
+		c.Assert(err, check.IsNil)
+	}
+	err = s.service.handleEvent(evt.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	sort.Strings(called)
+	c.Assert(called, check.DeepEquals, []string{"/requests"})
+}
+
 func (s *S) TestWebhookServiceNotifyTemplate(c *check.C) {
 	evt, err := event.New(&event.Opts{
 		Target: event.Target{Type: "app", Value: "myapp"},
@@ -58,6 +58,7 @@ func (l *eventCleaner) tryCleaning() error {
 	var allData []eventData
 	err = coll.Find(bson.M{
 		"running":        true,
+		"pending":        bson.M{"$ne": true},
 		"lockupdatetime": bson.M{"$lt": now.Add(-lockExpireTimeout)},
 	}).All(&allData)
 	conn.Close()
@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/api/shutdown"
+	internalConfig "github.com/tsuru/tsuru/config"
 	"github.com/tsuru/tsuru/event"
 )
 
@@ -42,6 +43,17 @@ func Initialize() (*NodeHealer, error) {
 	if !autoHealingNodes {
 		return nil, nil
 	}
+	HealerInstance = newNodeHealer(nodeHealerArgsFromConfig())
+	shutdown.Register(HealerInstance)
+	internalConfig.Subscribe("node healer", []string{
+		"docker:healing:disabled-time",
+		"docker:healing:max-failures",
+		"docker:healing:wait-new-time",
+	}, reloadNodeHealer)
+	return HealerInstance, nil
+}
+
+func nodeHealerArgsFromConfig() nodeHealerArgs {
 	disabledSeconds, _ := config.GetInt("docker:healing:disabled-time")
 	if disabledSeconds <= 0 {
 		disabledSeconds = 30
@@ -54,11 +66,16 @@ func Initialize() (*NodeHealer, error) {
 	if waitSecondsNewMachine <= 0 {
 		waitSecondsNewMachine = 5 * 60
 	}
-	HealerInstance = newNodeHealer(nodeHealerArgs{
+	return nodeHealerArgs{
 		DisabledTime:          time.Duration(disabledSeconds) * time.Second,
 		WaitTimeNewMachine:    time.Duration(waitSecondsNewMachine) * time.Second,
 		FailuresBeforeHealing: maxFailures,
-	})
-	shutdown.Register(HealerInstance)
-	return HealerInstance, nil
+	}
+}
+
+func reloadNodeHealer() error {
+	if HealerInstance != nil {
+		HealerInstance.updateArgs(nodeHealerArgsFromConfig())
+	}
+	return nil
 }
@@ -34,6 +34,7 @@ const (
 
 type NodeHealer struct {
 	wg                    sync.WaitGroup
+	mu                    sync.RWMutex
 	disabledTime          time.Duration
 	waitTimeNewMachine    time.Duration
 	failuresBeforeHealing int
@@ -97,6 +98,26 @@ func newNodeHealer(args nodeHealerArgs) *NodeHealer {
 	return healer
 }
 
+// updateArgs replaces the healer settings, applied to the next failures
+// handled by the healer.
+func (h *NodeHealer) updateArgs(args nodeHealerArgs) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.disabledTime = args.DisabledTime
+	h.waitTimeNewMachine = args.WaitTimeNewMachine
+	h.failuresBeforeHealing = args.FailuresBeforeHealing
+}
+
+func (h *NodeHealer) args() nodeHealerArgs {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return nodeHealerArgs{
+		DisabledTime:          h.disabledTime,
+		WaitTimeNewMachine:    h.waitTimeNewMachine,
+		FailuresBeforeHealing: h.failuresBeforeHealing,
+	}
+}
+
 func removeNodeTryRebalance(node provision.Node, newAddr string) error {
 	var buf bytes.Buffer
 	addr := node.Address()
@@ -158,7 +179,7 @@ func (h *NodeHealer) healNode(node provision.Node) (*provision.NodeSpec, error)
 		Address:    newAddr,
 		Metadata:   newNodeMetadata,
 		Pool:       node.Pool(),
-		WaitTO:     h.waitTimeNewMachine,
+		WaitTO:     h.args().WaitTimeNewMachine,
 		CaCert:     machine.CaCert,
 		ClientCert: machine.ClientCert,
 		ClientKey:  machine.ClientKey,
@@ -268,20 +289,21 @@ func (h *NodeHealer) tryHealingNode(node provision.Node, reason string, lastChec
 func (h *NodeHealer) HandleError(node provision.NodeHealthChecker) time.Duration {
 	h.wg.Add(1)
 	defer h.wg.Done()
+	args := h.args()
 	failures := node.FailureCount()
-	if failures < h.failuresBeforeHealing {
+	if failures < args.FailuresBeforeHealing {
 		log.Debugf("%d failures detected in node %q, waiting for more failures before healing.", failures, node.Address())
-		return h.disabledTime
+		return args.DisabledTime
 	}
 	if !node.HasSuccess() {
 		log.Debugf("Node %q has never been successfully reached, healing won't run on it.", node.Address())
-		return h.disabledTime
+		return args.DisabledTime
 	}
 	err := h.tryHealingNode(node, fmt.Sprintf("%d consecutive failures", failures), nil)
 	if err != nil {
 		log.Errorf("[node healer handle error] %s", err)
 	}
-	return h.disabledTime
+	return args.DisabledTime
 }
 
 func (h *NodeHealer) Shutdown(ctx context.Context) error {
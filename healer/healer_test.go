@@ -47,3 +47,21 @@ func (s *S) TestInitializeConf(c *check.C) {
 	c.Assert(healer.disabledTime, check.Equals, 10*time.Second)
 	c.Assert(healer.failuresBeforeHealing, check.Equals, 3)
 }
+
+func (s *S) TestReloadNodeHealer(c *check.C) {
+	healer, err := Initialize()
+	c.Assert(err, check.IsNil)
+	c.Assert(healer, check.NotNil)
+	defer healer.Shutdown(context.Background())
+	config.Set("docker:healing:disabled-time", 10)
+	config.Set("docker:healing:max-failures", 3)
+	defer config.Unset("docker:healing:disabled-time")
+	defer config.Unset("docker:healing:max-failures")
+	err = reloadNodeHealer()
+	c.Assert(err, check.IsNil)
+	c.Assert(healer.args(), check.DeepEquals, nodeHealerArgs{
+		DisabledTime:          10 * time.Second,
+		WaitTimeNewMachine:    5 * 60 * time.Second,
+		FailuresBeforeHealing: 3,
+	})
+}
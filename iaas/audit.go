@@ -0,0 +1,172 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iaas
+
+import (
+	"sort"
+
+	tsuruNet "github.com/tsuru/tsuru/net"
+	"github.com/tsuru/tsuru/provision"
+)
+
+// MachineTagName is the tag added to machines created by tsuru in providers
+// supporting tags. Its value is the name of the IaaS that created the machine.
+const MachineTagName = "tsuru-iaas"
+
+// CloudMachine is a machine as reported by the cloud provider.
+type CloudMachine struct {
+	Id      string `json:"id"`
+	Iaas    string `json:"iaas"`
+	Address string `json:"address"`
+	Status  string `json:"status"`
+}
+
+// MachineAudit holds the inconsistencies found between the machines stored
+// in tsuru, the machines in the cloud providers and the registered nodes.
+type MachineAudit struct {
+	// Missing are machines stored in tsuru which no longer exist in the IaaS.
+	Missing []Machine `json:"missing"`
+	// Unknown are machines tagged by tsuru in the IaaS but not stored in tsuru.
+	Unknown []CloudMachine `json:"unknown"`
+	// Unregistered are machines stored in tsuru not registered as nodes.
+	Unregistered []Machine `json:"unregistered"`
+	// Errors holds errors listing or checking machines, indexed by the IaaS
+	// name. Errors checking different machines of an IaaS are joined.
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Filter removes from the audit every finding related to IaaSs not accepted
+// by the given function.
+func (a *MachineAudit) Filter(accept func(iaasName string) bool) {
+	filterMachines := func(machines []Machine) []Machine {
+		result := []Machine{}
+		for _, m := range machines {
+			if accept(m.Iaas) {
+				result = append(result, m)
+			}
+		}
+		return result
+	}
+	a.Missing = filterMachines(a.Missing)
+	a.Unregistered = filterMachines(a.Unregistered)
+	unknown := []CloudMachine{}
+	for _, m := range a.Unknown {
+		if accept(m.Iaas) {
+			unknown = append(unknown, m)
+		}
+	}
+	a.Unknown = unknown
+	for name := range a.Errors {
+		if !accept(name) {
+			delete(a.Errors, name)
+		}
+	}
+}
+
+// AuditMachines compares the machines stored in tsuru with the ones reported
+// by each ListableIaaS and with the given nodes. IaaSs not implementing
+// ListableIaaS are only checked against the nodes.
+func AuditMachines(nodes []provision.Node) (*MachineAudit, error) {
+	machines, err := ListMachines()
+	if err != nil {
+		return nil, err
+	}
+	audit := MachineAudit{
+		Missing:      []Machine{},
+		Unknown:      []CloudMachine{},
+		Unregistered: []Machine{},
+		Errors:       map[string]string{},
+	}
+	iaasNames := map[string]struct{}{}
+	for _, name := range configuredIaaSNames() {
+		iaasNames[name] = struct{}{}
+	}
+	stored := map[string]map[string]struct{}{}
+	for _, m := range machines {
+		iaasNames[m.Iaas] = struct{}{}
+		if stored[m.Iaas] == nil {
+			stored[m.Iaas] = map[string]struct{}{}
+		}
+		stored[m.Iaas][m.Id] = struct{}{}
+		if !isMachineRegistered(&m, nodes) {
+			audit.Unregistered = append(audit.Unregistered, m)
+		}
+	}
+	names := make([]string, 0, len(iaasNames))
+	for name := range iaasNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		provider, err := getIaasProvider(name)
+		if err != nil {
+			audit.Errors[name] = err.Error()
+			continue
+		}
+		listable, ok := provider.(ListableIaaS)
+		if !ok {
+			continue
+		}
+		cloudMachines, err := listable.ListMachines()
+		if err != nil {
+			audit.Errors[name] = err.Error()
+			continue
+		}
+		inCloud := map[string]struct{}{}
+		for _, cm := range cloudMachines {
+			inCloud[cm.Id] = struct{}{}
+			if _, ok := stored[name][cm.Id]; !ok {
+				cm.Iaas = name
+				audit.Unknown = append(audit.Unknown, cm)
+			}
+		}
+		for idx := range machines {
+			m := &machines[idx]
+			if m.Iaas != name {
+				continue
+			}
+			if _, ok := inCloud[m.Id]; ok {
+				continue
+			}
+			exists, err := listable.MachineExists(m)
+			if err != nil {
+				if prev, ok := audit.Errors[name]; ok {
+					audit.Errors[name] = prev + "; " + err.Error()
+				} else {
+					audit.Errors[name] = err.Error()
+				}
+				continue
+			}
+			if !exists {
+				audit.Missing = append(audit.Missing, *m)
+			}
+		}
+	}
+	return &audit, nil
+}
+
+// RemoveMissingMachines removes from tsuru the machines reported by the audit
+// as missing in the IaaS, without calling the IaaS.
+func RemoveMissingMachines(audit *MachineAudit) error {
+	for i := range audit.Missing {
+		err := audit.Missing[i].removeFromDB()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isMachineRegistered(m *Machine, nodes []provision.Node) bool {
+	for _, n := range nodes {
+		if n.IaaSID() == m.Id {
+			return true
+		}
+		if m.Address != "" && tsuruNet.URLToHost(n.Address()) == m.Address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iaas
+
+import (
+	"errors"
+
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"gopkg.in/check.v1"
+)
+
+type TestListableIaaS struct {
+	TestIaaS
+	machines  []CloudMachine
+	untagged  []string
+	existsErr map[string]error
+}
+
+func (i *TestListableIaaS) ListMachines() ([]CloudMachine, error) {
+	return i.machines, nil
+}
+
+func (i *TestListableIaaS) MachineExists(m *Machine) (bool, error) {
+	if err, ok := i.existsErr[m.Id]; ok {
+		return false, err
+	}
+	for _, cm := range i.machines {
+		if cm.Id == m.Id {
+			return true, nil
+		}
+	}
+	for _, id := range i.untagged {
+		if id == m.Id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *S) TestMachineTags(c *check.C) {
+	i := NamedIaaS{BaseIaaSName: "ec2", IaaSName: "ec2-east"}
+	c.Assert(i.MachineTags(""), check.Equals, "tsuru-iaas:ec2-east")
+	c.Assert(i.MachineTags("a:b"), check.Equals, "a:b,tsuru-iaas:ec2-east")
+}
+
+func (s *S) TestAuditMachines(c *check.C) {
+	listable := &TestListableIaaS{machines: []CloudMachine{
+		{Id: "m1", Address: "m1.somewhere.com", Status: "running"},
+		{Id: "m3", Address: "m3.somewhere.com", Status: "running"},
+	}}
+	RegisterIaasProvider("listable-iaas", func(string) IaaS { return listable })
+	for _, id := range []string{"m1", "m2"} {
+		_, err := CreateMachineForIaaS("listable-iaas", map[string]string{"id": id})
+		c.Assert(err, check.IsNil)
+	}
+	_, err := CreateMachineForIaaS("test-iaas", map[string]string{"id": "t1"})
+	c.Assert(err, check.IsNil)
+	nodes := []provision.Node{
+		&provisiontest.FakeNode{ID: "m1", Addr: "http://m1.somewhere.com:2375"},
+		&provisiontest.FakeNode{Addr: "http://t1.somewhere.com:2375"},
+	}
+	audit, err := AuditMachines(nodes)
+	c.Assert(err, check.IsNil)
+	c.Assert(audit.Unknown, check.DeepEquals, []CloudMachine{
+		{Id: "m3", Iaas: "listable-iaas", Address: "m3.somewhere.com", Status: "running"},
+	})
+	c.Assert(audit.Missing, check.HasLen, 1)
+	c.Assert(audit.Missing[0].Id, check.Equals, "m2")
+	c.Assert(audit.Unregistered, check.HasLen, 1)
+	c.Assert(audit.Unregistered[0].Id, check.Equals, "m2")
+	c.Assert(audit.Errors, check.DeepEquals, map[string]string{})
+	err = RemoveMissingMachines(audit)
+	c.Assert(err, check.IsNil)
+	_, err = FindMachineById("m2")
+	c.Assert(err, check.Equals, ErrMachineNotFound)
+	_, err = FindMachineById("m1")
+	c.Assert(err, check.IsNil)
+	c.Assert(listable.cmds, check.DeepEquals, []string{"create", "create"})
+}
+
+func (s *S) TestAuditMachinesCheckErrors(c *check.C) {
+	listable := &TestListableIaaS{
+		existsErr: map[string]error{
+			"m1": errors.New("failed to check machine \"m1\": timeout"),
+			"m2": errors.New("failed to check machine \"m2\": timeout"),
+		},
+	}
+	RegisterIaasProvider("listable-iaas", func(string) IaaS { return listable })
+	for _, id := range []string{"m1", "m2", "gone"} {
+		_, err := CreateMachineForIaaS("listable-iaas", map[string]string{"id": id})
+		c.Assert(err, check.IsNil)
+	}
+	audit, err := AuditMachines(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(audit.Missing, check.HasLen, 1)
+	c.Assert(audit.Missing[0].Id, check.Equals, "gone")
+	c.Assert(audit.Errors, check.DeepEquals, map[string]string{
+		"listable-iaas": `failed to check machine "m1": timeout; failed to check machine "m2": timeout`,
+	})
+}
+
+func (s *S) TestAuditMachinesUntaggedMachineStillExists(c *check.C) {
+	listable := &TestListableIaaS{
+		machines: []CloudMachine{{Id: "m1", Address: "m1.somewhere.com", Status: "running"}},
+		untagged: []string{"old"},
+	}
+	RegisterIaasProvider("listable-iaas", func(string) IaaS { return listable })
+	for _, id := range []string{"m1", "old", "gone"} {
+		_, err := CreateMachineForIaaS("listable-iaas", map[string]string{"id": id})
+		c.Assert(err, check.IsNil)
+	}
+	audit, err := AuditMachines(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(audit.Missing, check.HasLen, 1)
+	c.Assert(audit.Missing[0].Id, check.Equals, "gone")
+	c.Assert(audit.Unknown, check.HasLen, 0)
+	err = RemoveMissingMachines(audit)
+	c.Assert(err, check.IsNil)
+	_, err = FindMachineById("old")
+	c.Assert(err, check.IsNil)
+	_, err = FindMachineById("gone")
+	c.Assert(err, check.Equals, ErrMachineNotFound)
+}
+
+func (s *S) TestMachineAuditFilter(c *check.C) {
+	audit := MachineAudit{
+		Missing:      []Machine{{Id: "m1", Iaas: "a"}, {Id: "m2", Iaas: "b"}},
+		Unknown:      []CloudMachine{{Id: "m3", Iaas: "b"}},
+		Unregistered: []Machine{{Id: "m1", Iaas: "a"}},
+		Errors:       map[string]string{"b": "failed"},
+	}
+	audit.Filter(func(name string) bool { return name == "a" })
+	c.Assert(audit, check.DeepEquals, MachineAudit{
+		Missing:      []Machine{{Id: "m1", Iaas: "a"}},
+		Unknown:      []CloudMachine{},
+		Unregistered: []Machine{{Id: "m1", Iaas: "a"}},
+		Errors:       map[string]string{},
+	})
+}
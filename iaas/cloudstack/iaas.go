@@ -50,6 +50,19 @@ Further params will also be sent to cloudstack's deployVirtualMachine command.
 `
 }
 
+func (i *CloudstackIaaS) ParamsSchema() []iaas.TemplateParam {
+	return []iaas.TemplateParam{
+		{Name: "networkids", Description: "Your network uuid", Required: true},
+		{Name: "templateid", Description: "Your template uuid", Required: true},
+		{Name: "serviceofferingid", Description: "Your service offering uuid", Required: true},
+		{Name: "zoneid", Description: "Your zone uuid", Required: true},
+		{Name: "projectid", Description: "Your project uuid"},
+		{Name: "displayname", Description: "Machine display name"},
+		{Name: "keypair", Description: "SSH key pair name"},
+		{Name: "tags", Description: "Comma separated list of key:value tags"},
+	}
+}
+
 func (i *CloudstackIaaS) HealthCheck() error {
 	var resp ListZonesResponse
 	err := i.do("listZones", map[string]string{}, &resp)
@@ -181,9 +194,7 @@ func (i *CloudstackIaaS) CreateMachine(params map[string]string) (*iaas.Machine,
 		"jobId": vmStatus.DeployVirtualMachineResponse.JobID,
 		"vmId":  vmStatus.DeployVirtualMachineResponse.ID,
 	}
-	if tags, ok := params["tags"]; ok {
-		jobParams["tags"] = tags
-	}
+	jobParams["tags"] = i.base.MachineTags(params["tags"])
 	if projectId, ok := params["projectid"]; ok {
 		jobParams["projectId"] = projectId
 	}
@@ -207,6 +218,66 @@ func (i *CloudstackIaaS) CreateMachine(params map[string]string) (*iaas.Machine,
 	return m, nil
 }
 
+// ListMachines returns the virtual machines tagged as created by the IaaS,
+// both outside projects and in every project where machines were created.
+func (i *CloudstackIaaS) ListMachines() ([]iaas.CloudMachine, error) {
+	machines, err := iaas.ListMachines()
+	if err != nil {
+		return nil, err
+	}
+	projects := []string{""}
+	seen := map[string]struct{}{"": {}}
+	for _, m := range machines {
+		projectId := m.CreationParams["projectid"]
+		if _, ok := seen[projectId]; m.Iaas == i.base.IaaSName && !ok {
+			seen[projectId] = struct{}{}
+			projects = append(projects, projectId)
+		}
+	}
+	var result []iaas.CloudMachine
+	for _, projectId := range projects {
+		apiParams := ApiParams{
+			"listall":       "true",
+			"tags[0].key":   iaas.MachineTagName,
+			"tags[0].value": i.base.IaaSName,
+		}
+		if projectId != "" {
+			apiParams["projectid"] = projectId
+		}
+		var vms ListVirtualMachinesResponse
+		err = i.do("listVirtualMachines", apiParams, &vms)
+		if err != nil {
+			return nil, err
+		}
+		for _, vm := range vms.ListVirtualMachinesResponse.VirtualMachine {
+			cm := iaas.CloudMachine{Id: vm.Id, Status: strings.ToLower(vm.State)}
+			if len(vm.Nic) > 0 {
+				cm.Address = vm.Nic[0].IpAddress
+			}
+			result = append(result, cm)
+		}
+	}
+	return result, nil
+}
+
+// MachineExists looks up the virtual machine by its id, regardless of its
+// tags.
+func (i *CloudstackIaaS) MachineExists(m *iaas.Machine) (bool, error) {
+	apiParams := ApiParams{
+		"listall": "true",
+		"id":      m.Id,
+	}
+	if projectId := m.CreationParams["projectid"]; projectId != "" {
+		apiParams["projectid"] = projectId
+	}
+	var vms ListVirtualMachinesResponse
+	err := i.do("listVirtualMachines", apiParams, &vms)
+	if err != nil {
+		return false, err
+	}
+	return len(vms.ListVirtualMachinesResponse.VirtualMachine) > 0, nil
+}
+
 func (i *CloudstackIaaS) buildUrl(command string, params map[string]string) (string, error) {
 	apiKey, err := i.base.GetConfigString("api-key")
 	if err != nil {
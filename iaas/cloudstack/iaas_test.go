@@ -53,6 +53,11 @@ func (s *cloudstackSuite) TestCreateMachineWithoutProjectID(c *check.C) {
 			json := `{ "listvirtualmachinesresponse" : { "count":1 ,"virtualmachine" : [  {"id":"0366ae09-0a77-4e2b-8595-3b749764a107","name":"vm-0366ae09-0a77-4e2b-8595-3b749764a107","domainid":"eec2dacf-9982-11e3-a2b8-eee0bc1594e0","domain":"ROOT","created":"2014-07-18T18:29:30-0300","state":"Stopped","haenable":false,"zoneid":"95046c6c-65b8-415f-99cb-0cff40dc5f9c","zonename":"RJOEBT0200BE","templateid":"99f66d4c-f923-46e5-aa7b-09a0b22ee747","templatename":"ubuntu-14.04-server-amd64","templatedisplaytext":"ubuntu 14.04 ( 3.13.0-24-generic )","passwordenabled":false,"serviceofferingid":"3ff651c8-a27f-4008-87d5-71636aaabbc6","serviceofferingname":"Medium","cpunumber":2,"cpuspeed":1800,"memory":8192,"guestosid":"eede1fdf-9982-11e3-a2b8-eee0bc1594e0","rootdeviceid":0,"rootdevicetype":"ROOT","securitygroup":[],"nic":[{"id":"40cd6225-9475-44a3-8288-d7a9a485d8ac","networkid":"18c20437-df18-4757-8435-1230248f955b","networkname":"PLAYGROUND_200BE","netmask":"255.255.255.0","gateway":"10.24.16.1","ipaddress":"10.24.16.241","isolationuri":"vlan://19","broadcasturi":"vlan://19","traffictype":"Guest","type":"Shared","isdefault":true,"macaddress":"06:54:7e:00:46:c6"}],"hypervisor":"XenServer","tags":[],"affinitygroup":[],"displayvm":true,"isdynamicallyscalable":true,"jobid":"82a574cc-43f2-440d-8774-e638065c37af","jobstatus":0} ] } }`
 			fmt.Fprintln(w, json)
 		}
+		if cmd == "createTags" {
+			c.Assert(r.URL.Query().Get("tags[1].key"), check.Equals, "tsuru-iaas")
+			c.Assert(r.URL.Query().Get("tags[1].value"), check.Equals, "cloudstack")
+			fmt.Fprintln(w, `{"createtagsresponse": {"Displaytext": "display", "Success": "success"}}`)
+		}
 	}))
 	defer fakeServer.Close()
 	config.Set("iaas:cloudstack:url", fakeServer.URL)
@@ -70,7 +75,7 @@ func (s *cloudstackSuite) TestCreateMachineWithoutProjectID(c *check.C) {
 	c.Assert(vm, check.NotNil)
 	c.Assert(vm.Address, check.Equals, "10.24.16.241")
 	c.Assert(vm.Id, check.Equals, "0366ae09-0a77-4e2b-8595-3b749764a107")
-	c.Assert(calls, check.DeepEquals, []string{"deployVirtualMachine", "queryAsyncJobResult", "listVirtualMachines"})
+	c.Assert(calls, check.DeepEquals, []string{"deployVirtualMachine", "queryAsyncJobResult", "listVirtualMachines", "createTags"})
 }
 
 func (s *cloudstackSuite) TestCreateMachine(c *check.C) {
@@ -89,6 +94,11 @@ func (s *cloudstackSuite) TestCreateMachine(c *check.C) {
 			json := `{ "listvirtualmachinesresponse" : { "count":1 ,"virtualmachine" : [  {"id":"0366ae09-0a77-4e2b-8595-3b749764a107","name":"vm-0366ae09-0a77-4e2b-8595-3b749764a107","projectid":"a98738c9-5acd-43e3-b1a1-972a3db5b196","project":"tsuru playground","domainid":"eec2dacf-9982-11e3-a2b8-eee0bc1594e0","domain":"ROOT","created":"2014-07-18T18:29:30-0300","state":"Stopped","haenable":false,"zoneid":"95046c6c-65b8-415f-99cb-0cff40dc5f9c","zonename":"RJOEBT0200BE","templateid":"99f66d4c-f923-46e5-aa7b-09a0b22ee747","templatename":"ubuntu-14.04-server-amd64","templatedisplaytext":"ubuntu 14.04 ( 3.13.0-24-generic )","passwordenabled":false,"serviceofferingid":"3ff651c8-a27f-4008-87d5-71636aaabbc6","serviceofferingname":"Medium","cpunumber":2,"cpuspeed":1800,"memory":8192,"guestosid":"eede1fdf-9982-11e3-a2b8-eee0bc1594e0","rootdeviceid":0,"rootdevicetype":"ROOT","securitygroup":[],"nic":[{"id":"40cd6225-9475-44a3-8288-d7a9a485d8ac","networkid":"18c20437-df18-4757-8435-1230248f955b","networkname":"PLAYGROUND_200BE","netmask":"255.255.255.0","gateway":"10.24.16.1","ipaddress":"10.24.16.241","isolationuri":"vlan://19","broadcasturi":"vlan://19","traffictype":"Guest","type":"Shared","isdefault":true,"macaddress":"06:54:7e:00:46:c6"}],"hypervisor":"XenServer","tags":[],"affinitygroup":[],"displayvm":true,"isdynamicallyscalable":true,"jobid":"82a574cc-43f2-440d-8774-e638065c37af","jobstatus":0} ] } }`
 			fmt.Fprintln(w, json)
 		}
+		if cmd == "createTags" {
+			c.Assert(r.URL.Query().Get("tags[1].key"), check.Equals, "tsuru-iaas")
+			c.Assert(r.URL.Query().Get("tags[1].value"), check.Equals, "cloudstack")
+			fmt.Fprintln(w, `{"createtagsresponse": {"Displaytext": "display", "Success": "success"}}`)
+		}
 	}))
 	defer fakeServer.Close()
 	config.Set("iaas:cloudstack:url", fakeServer.URL)
@@ -108,7 +118,7 @@ func (s *cloudstackSuite) TestCreateMachine(c *check.C) {
 	c.Assert(vm, check.NotNil)
 	c.Assert(vm.Address, check.Equals, "10.24.16.241")
 	c.Assert(vm.Id, check.Equals, "0366ae09-0a77-4e2b-8595-3b749764a107")
-	c.Assert(calls, check.DeepEquals, []string{"deployVirtualMachine", "queryAsyncJobResult", "listVirtualMachines"})
+	c.Assert(calls, check.DeepEquals, []string{"deployVirtualMachine", "queryAsyncJobResult", "listVirtualMachines", "createTags"})
 }
 
 func (s *cloudstackSuite) TestCreateMachineAsyncFailure(c *check.C) {
@@ -20,7 +20,9 @@ type ListVirtualMachinesResponse struct {
 }
 
 type VirtualMachine struct {
-	Nic []NicStruct `json:"nic"`
+	Id    string      `json:"id"`
+	State string      `json:"state"`
+	Nic   []NicStruct `json:"nic"`
 }
 
 type NicStruct struct {
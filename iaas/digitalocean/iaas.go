@@ -7,6 +7,7 @@ package digitalocean
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
@@ -81,6 +82,7 @@ func (i *digitalOceanIaas) CreateMachine(params map[string]string) (*iaas.Machin
 		Image:             image,
 		SSHKeys:           sshKeys,
 		UserData:          userData,
+		Tags:              []string{i.machineTag()},
 	}
 	droplet, _, err := i.client.Droplets.Create(context.Background(), createRequest)
 	if err != nil {
@@ -107,6 +109,38 @@ func (i *digitalOceanIaas) CreateMachine(params map[string]string) (*iaas.Machin
 	return m, nil
 }
 
+func (i *digitalOceanIaas) machineTag() string {
+	return i.base.MachineTags("")
+}
+
+// ListMachines returns the droplets tagged as created by the IaaS.
+func (i *digitalOceanIaas) ListMachines() ([]iaas.CloudMachine, error) {
+	err := i.Auth()
+	if err != nil {
+		return nil, err
+	}
+	var result []iaas.CloudMachine
+	opts := &godo.ListOptions{Page: 1, PerPage: 200}
+	for {
+		droplets, resp, err := i.client.Droplets.ListByTag(context.Background(), i.machineTag(), opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range droplets {
+			cm := iaas.CloudMachine{Id: strconv.Itoa(d.ID), Status: d.Status}
+			if d.Networks != nil && len(d.Networks.V4) > 0 {
+				cm.Address = d.Networks.V4[0].IPAddress
+			}
+			result = append(result, cm)
+		}
+		if resp == nil || resp.Links == nil || resp.Links.IsLastPage() {
+			break
+		}
+		opts.Page++
+	}
+	return result, nil
+}
+
 func (i *digitalOceanIaas) waitNetworkCreated(droplet *godo.Droplet) (*godo.Droplet, error) {
 	rawTimeout, _ := i.base.GetConfigString("wait-timeout")
 	timeout, _ := strconv.Atoi(rawTimeout)
@@ -144,6 +178,34 @@ func (i *digitalOceanIaas) waitNetworkCreated(droplet *godo.Droplet) (*godo.Drop
 	}
 }
 
+func (i *digitalOceanIaas) ParamsSchema() []iaas.TemplateParam {
+	return []iaas.TemplateParam{
+		{Name: "name", Description: "Droplet name"},
+		{Name: "region", Description: "Droplet region slug", Required: true},
+		{Name: "size", Description: "Droplet size slug", Required: true},
+		{Name: "image", Description: "Droplet image slug", Required: true},
+		{Name: "ssh-keys", Description: "Comma separated list of SSH key IDs or fingerprints"},
+		{Name: "private-networking", Type: iaas.ParamTypeBool},
+	}
+}
+
+// MachineExists looks up the droplet by its id, regardless of its tags.
+func (i *digitalOceanIaas) MachineExists(m *iaas.Machine) (bool, error) {
+	err := i.Auth()
+	if err != nil {
+		return false, err
+	}
+	machineId, err := strconv.Atoi(m.Id)
+	if err != nil {
+		return false, errors.Wrapf(err, "invalid droplet id %q", m.Id)
+	}
+	_, resp, err := i.client.Droplets.Get(context.Background(), machineId)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	return err == nil, errors.WithStack(err)
+}
+
 func (i *digitalOceanIaas) DeleteMachine(m *iaas.Machine) error {
 	i.Auth()
 	machineId, _ := strconv.Atoi(m.Id)
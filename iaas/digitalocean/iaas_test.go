@@ -58,6 +58,7 @@ func (s *digitaloceanSuite) TestCreateMachine(c *check.C) {
 	expectedKeys := []interface{}{float64(5050), float64(2032), "07:b9:a1:65:1b", float64(13)}
 	c.Assert(createRequest["ssh_keys"], check.DeepEquals, expectedKeys)
 	c.Assert(createRequest["private_networking"], check.Equals, false)
+	c.Assert(createRequest["tags"], check.DeepEquals, []interface{}{"tsuru-iaas:digitalocean"})
 }
 
 func (s *digitaloceanSuite) TestCreateMachinePrivateNetworking(c *check.C) {
@@ -156,3 +157,40 @@ func (s *digitaloceanSuite) TestDeleteMachineFailure(c *check.C) {
 	c.Assert(err, check.NotNil)
 	c.Assert(err.Error(), check.Equals, "failed to delete machine")
 }
+
+func (s *digitaloceanSuite) TestMachineExists(c *check.C) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/droplets/1" {
+			fmt.Fprintln(w, `{"droplet": {"id": 1, "status": "active"}}`)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, `{"id": "not_found", "message": "The resource you were accessing could not be found."}`)
+	}))
+	defer fakeServer.Close()
+	config.Set("iaas:digitalocean:url", fakeServer.URL)
+	do := newDigitalOceanIaas("digitalocean")
+	exists, err := do.(iaas.ListableIaaS).MachineExists(&iaas.Machine{Id: "1"})
+	c.Assert(err, check.IsNil)
+	c.Assert(exists, check.Equals, true)
+	exists, err = do.(iaas.ListableIaaS).MachineExists(&iaas.Machine{Id: "2"})
+	c.Assert(err, check.IsNil)
+	c.Assert(exists, check.Equals, false)
+}
+
+func (s *digitaloceanSuite) TestListMachines(c *check.C) {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.Assert(r.URL.Path, check.Equals, "/v2/droplets")
+		c.Assert(r.URL.Query().Get("tag_name"), check.Equals, "tsuru-iaas:digitalocean")
+		fmt.Fprintln(w, `{"droplets": [{"id": 1, "status": "active", "networks": {"v4": [{"ip_address": "104.131.186.241", "type": "public"}]}}, {"id": 2, "status": "off"}]}`)
+	}))
+	defer fakeServer.Close()
+	config.Set("iaas:digitalocean:url", fakeServer.URL)
+	do := newDigitalOceanIaas("digitalocean")
+	machines, err := do.(iaas.ListableIaaS).ListMachines()
+	c.Assert(err, check.IsNil)
+	c.Assert(machines, check.DeepEquals, []iaas.CloudMachine{
+		{Id: "1", Address: "104.131.186.241", Status: "active"},
+		{Id: "2", Status: "off"},
+	})
+}
@@ -19,12 +19,18 @@ import (
 	"github.com/docker/machine/libmachine/engine"
 	"github.com/docker/machine/libmachine/host"
 	"github.com/docker/machine/libmachine/log"
+	"github.com/docker/machine/libmachine/mcnerror"
 	"github.com/docker/machine/libmachine/mcnflag"
 	"github.com/docker/machine/libmachine/mcnutils"
+	"github.com/docker/machine/libmachine/state"
 	"github.com/pkg/errors"
 	"github.com/tsuru/tsuru/iaas"
 )
 
+// ErrMachineNotFound is returned by MachineState when the driver no longer
+// finds the host.
+var ErrMachineNotFound = errors.New("machine not found")
+
 type DockerMachine struct {
 	io.Closer
 	client    libmachine.API
@@ -45,6 +51,7 @@ type DockerMachineAPI interface {
 	io.Closer
 	CreateMachine(CreateMachineOpts) (*Machine, error)
 	DeleteMachine(*iaas.Machine) error
+	MachineState(*iaas.Machine) (state.State, error)
 	RegisterMachine(RegisterMachineOpts) (*Machine, error)
 	List() ([]*Machine, error)
 	DeleteAll() error
@@ -191,6 +198,44 @@ func (d *DockerMachine) DeleteMachine(m *iaas.Machine) error {
 	return d.client.Remove(m.Id)
 }
 
+// MachineState returns the state of the machine as reported by its driver.
+// It returns ErrMachineNotFound when the driver reports the host doesn't
+// exist.
+func (d *DockerMachine) MachineState(m *iaas.Machine) (state.State, error) {
+	rawDriver, err := json.Marshal(m.CustomData)
+	if err != nil {
+		return state.None, errors.Wrap(err, "failed to marshal machine data")
+	}
+	host, err := d.client.NewHost(m.CreationParams["driver"], rawDriver)
+	if err != nil {
+		return state.None, errors.Wrap(err, "failed to initialize host")
+	}
+	st, err := host.Driver.GetState()
+	if err != nil {
+		if isHostNotFound(err) {
+			return state.None, ErrMachineNotFound
+		}
+		return state.None, errors.Wrap(err, "failed to get host state")
+	}
+	return st, nil
+}
+
+// isHostNotFound reports whether err means the host doesn't exist. Drivers
+// don't share an error for it, so the messages used by the most common
+// cloud providers are also checked.
+func isHostNotFound(err error) bool {
+	if _, ok := errors.Cause(err).(mcnerror.ErrHostDoesNotExist); ok {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	for _, notFound := range []string{"not found", "notfound", "does not exist", "could not be found"} {
+		if strings.Contains(msg, notFound) {
+			return true
+		}
+	}
+	return false
+}
+
 func (d *DockerMachine) DeleteAll() error {
 	hosts, err := d.client.List()
 	if err != nil {
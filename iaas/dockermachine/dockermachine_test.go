@@ -5,11 +5,13 @@
 package dockermachine
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 
 	"github.com/docker/machine/drivers/amazonec2"
+	"github.com/docker/machine/libmachine/mcnerror"
 	"github.com/tsuru/tsuru/iaas"
 	check "gopkg.in/check.v1"
 )
@@ -256,3 +258,10 @@ func (s *S) TestList(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(machines, check.DeepEquals, []*Machine{m, m2})
 }
+
+func (s *S) TestIsHostNotFound(c *check.C) {
+	c.Assert(isHostNotFound(mcnerror.ErrHostDoesNotExist{Name: "m1"}), check.Equals, true)
+	c.Assert(isHostNotFound(errors.New("InvalidInstanceID.NotFound: The instance ID 'i-1' does not exist")), check.Equals, true)
+	c.Assert(isHostNotFound(errors.New("GET https://api.digitalocean.com/v2/droplets/1: 404 The resource you were accessing could not be found.")), check.Equals, true)
+	c.Assert(isHostNotFound(errors.New("connection refused")), check.Equals, false)
+}
@@ -7,6 +7,7 @@ package dockermachine
 import (
 	"errors"
 
+	"github.com/docker/machine/libmachine/state"
 	"github.com/tsuru/tsuru/iaas"
 )
 
@@ -16,6 +17,8 @@ type FakeDockerMachine struct {
 	config         *DockerMachineConfig
 	hostOpts       *CreateMachineOpts
 	closed         bool
+	states         map[string]state.State
+	stateErrors    map[string]error
 }
 
 var FakeDM = &FakeDockerMachine{}
@@ -52,6 +55,17 @@ func (f *FakeDockerMachine) DeleteMachine(m *iaas.Machine) error {
 	return nil
 }
 
+func (f *FakeDockerMachine) MachineState(m *iaas.Machine) (state.State, error) {
+	if err, ok := f.stateErrors[m.Id]; ok {
+		return state.None, err
+	}
+	st, ok := f.states[m.Id]
+	if !ok {
+		return state.None, ErrMachineNotFound
+	}
+	return st, nil
+}
+
 func (f *FakeDockerMachine) DeleteAll() error {
 	return nil
 }
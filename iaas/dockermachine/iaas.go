@@ -16,6 +16,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/docker/machine/libmachine/state"
 	"github.com/pkg/errors"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/iaas"
@@ -187,6 +188,61 @@ func (i *dockerMachineIaaS) DeleteMachine(m *iaas.Machine) error {
 	return dockerMachine.DeleteMachine(m)
 }
 
+// ListMachines returns the machines created by the IaaS which still exist
+// according to their drivers. Docker machine is unable to find hosts which
+// were not created by tsuru, so only machines known to tsuru are checked.
+// Machines whose state can't be read are not listed, so their errors are
+// reported by MachineExists.
+func (i *dockerMachineIaaS) ListMachines() ([]iaas.CloudMachine, error) {
+	machines, err := iaas.ListMachines()
+	if err != nil {
+		return nil, err
+	}
+	dockerMachine, err := i.apiFactory(DockerMachineConfig{})
+	if err != nil {
+		return nil, err
+	}
+	defer dockerMachine.Close()
+	var result []iaas.CloudMachine
+	for idx := range machines {
+		m := &machines[idx]
+		if m.Iaas != i.base.IaaSName {
+			continue
+		}
+		st, err := dockerMachine.MachineState(m)
+		if err != nil {
+			continue
+		}
+		if st == state.None || st == state.Error {
+			continue
+		}
+		result = append(result, iaas.CloudMachine{
+			Id:      m.Id,
+			Address: m.Address,
+			Status:  strings.ToLower(st.String()),
+		})
+	}
+	return result, nil
+}
+
+// MachineExists checks the machine state according to its driver. Hosts
+// the driver no longer finds don't exist.
+func (i *dockerMachineIaaS) MachineExists(m *iaas.Machine) (bool, error) {
+	dockerMachine, err := i.apiFactory(DockerMachineConfig{})
+	if err != nil {
+		return false, err
+	}
+	defer dockerMachine.Close()
+	st, err := dockerMachine.MachineState(m)
+	if err == ErrMachineNotFound {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.WithMessage(err, fmt.Sprintf("failed to check machine %q", m.Id))
+	}
+	return st != state.None && st != state.Error, nil
+}
+
 func generateMachineName(prefix string) (string, error) {
 	r := strings.NewReplacer("_", "-", " ", "-")
 	prefix = r.Replace(prefix)
@@ -5,8 +5,10 @@
 package dockermachine
 
 import (
+	"errors"
 	"strings"
 
+	"github.com/docker/machine/libmachine/state"
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/iaas"
 	check "gopkg.in/check.v1"
@@ -193,3 +195,26 @@ func (s *S) TestGenerateMachineName(c *check.C) {
 		c.Assert(len(name), check.Equals, t.expectedLength)
 	}
 }
+
+func (s *S) TestMachineExistsIaaS(c *check.C) {
+	i := newDockerMachineIaaS("dockermachine")
+	dmIaas := i.(*dockerMachineIaaS)
+	dmIaas.apiFactory = NewFakeDockerMachine
+	FakeDM.states = map[string]state.State{"running": state.Running, "stopped": state.Stopped}
+	FakeDM.stateErrors = map[string]error{"broken": errors.New("timeout")}
+	defer func() {
+		FakeDM.states = nil
+		FakeDM.stateErrors = nil
+	}()
+	exists, err := dmIaas.MachineExists(&iaas.Machine{Id: "running"})
+	c.Assert(err, check.IsNil)
+	c.Assert(exists, check.Equals, true)
+	exists, err = dmIaas.MachineExists(&iaas.Machine{Id: "stopped"})
+	c.Assert(err, check.IsNil)
+	c.Assert(exists, check.Equals, true)
+	exists, err = dmIaas.MachineExists(&iaas.Machine{Id: "deleted"})
+	c.Assert(err, check.IsNil)
+	c.Assert(exists, check.Equals, false)
+	_, err = dmIaas.MachineExists(&iaas.Machine{Id: "broken"})
+	c.Assert(err, check.ErrorMatches, `failed to check machine "broken": timeout`)
+}
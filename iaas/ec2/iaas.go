@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/credentials"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
@@ -125,6 +126,26 @@ Optional params:
 `
 }
 
+func (i *EC2IaaS) ParamsSchema() []iaas.TemplateParam {
+	return []iaas.TemplateParam{
+		{Name: "imageid", Aliases: []string{"image"}, Description: "Image AMI ID", Required: true},
+		{Name: "instancetype", Aliases: []string{"type"}, Description: "Instance type", Required: true},
+		{Name: "region", Description: "Chosen region, defaults to " + defaultRegion},
+		{Name: "endpoint", Description: "EC2 endpoint, overrides the region"},
+		{Name: "keyname", Description: "Key name for machine"},
+		{Name: "securitygroups", Aliases: []string{"securitygroup"}, Description: "Comma separated list of security groups"},
+		{Name: "subnetid", Description: "Subnet ID"},
+		{Name: "tags", Description: "Comma separated list of key:value tags"},
+		{Name: "ebsoptimized", Aliases: []string{"ebs-optimized"}, Type: iaas.ParamTypeBool},
+		{Name: "monitoring-enabled", Type: iaas.ParamTypeBool},
+		{Name: "blockdevicemappings", Description: "JSON list of block device mappings"},
+		{Name: "networkinterfaces", Description: "JSON list of network interfaces"},
+		{Name: "iaminstanceprofile", Description: "JSON IAM instance profile"},
+		{Name: "placement", Description: "JSON placement"},
+		{Name: "network-index", Type: iaas.ParamTypeInt},
+	}
+}
+
 func (i *EC2IaaS) DeleteMachine(m *iaas.Machine) error {
 	regionOrEndpoint := getRegionOrEndpoint(m.CreationParams, false)
 	if regionOrEndpoint == "" {
@@ -314,7 +335,7 @@ func (i *EC2IaaS) CreateMachine(params map[string]string) (*iaas.Machine, error)
 		return nil, errors.Errorf("no instance created")
 	}
 	runInst := resp.Instances[0]
-	if tags, ok := params["tags"]; ok {
+	if tags := i.base.MachineTags(params["tags"]); tags != "" {
 		var ec2Tags []*ec2.Tag
 		tagList := strings.Split(tags, ",")
 		ec2Tags = make([]*ec2.Tag, 0, len(tagList))
@@ -350,6 +371,90 @@ func (i *EC2IaaS) CreateMachine(params map[string]string) (*iaas.Machine, error)
 	return &machine, nil
 }
 
+// ListMachines returns the instances tagged as created by the IaaS in the
+// default region and in every region where machines were created.
+func (i *EC2IaaS) ListMachines() ([]iaas.CloudMachine, error) {
+	machines, err := iaas.ListMachines()
+	if err != nil {
+		return nil, err
+	}
+	regions := []string{defaultRegion}
+	seen := map[string]struct{}{defaultRegion: {}}
+	for _, m := range machines {
+		if m.Iaas != i.base.IaaSName {
+			continue
+		}
+		regionOrEndpoint := getRegionOrEndpoint(m.CreationParams, true)
+		if _, ok := seen[regionOrEndpoint]; !ok {
+			seen[regionOrEndpoint] = struct{}{}
+			regions = append(regions, regionOrEndpoint)
+		}
+	}
+	var result []iaas.CloudMachine
+	for _, regionOrEndpoint := range regions {
+		ec2Inst, err := i.createEC2Handler(regionOrEndpoint)
+		if err != nil {
+			return nil, err
+		}
+		input := ec2.DescribeInstancesInput{
+			Filters: []*ec2.Filter{
+				{Name: aws.String("tag:" + iaas.MachineTagName), Values: []*string{aws.String(i.base.IaaSName)}},
+				{Name: aws.String("instance-state-name"), Values: aws.StringSlice([]string{"pending", "running", "stopping", "stopped"})},
+			},
+		}
+		err = ec2Inst.DescribeInstancesPages(&input, func(page *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range page.Reservations {
+				for _, instance := range reservation.Instances {
+					address := aws.StringValue(instance.PublicDnsName)
+					if address == "" {
+						address = aws.StringValue(instance.PrivateDnsName)
+					}
+					cm := iaas.CloudMachine{
+						Id:      aws.StringValue(instance.InstanceId),
+						Address: address,
+					}
+					if instance.State != nil {
+						cm.Status = aws.StringValue(instance.State.Name)
+					}
+					result = append(result, cm)
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "unable to list instances in %q", regionOrEndpoint)
+		}
+	}
+	return result, nil
+}
+
+// MachineExists looks up the instance by its id, in the region it was
+// created, regardless of its tags.
+func (i *EC2IaaS) MachineExists(m *iaas.Machine) (bool, error) {
+	ec2Inst, err := i.createEC2Handler(getRegionOrEndpoint(m.CreationParams, true))
+	if err != nil {
+		return false, err
+	}
+	resp, err := ec2Inst.DescribeInstances(&ec2.DescribeInstancesInput{
+		InstanceIds: []*string{aws.String(m.Id)},
+	})
+	if err != nil {
+		if awsErr, ok := err.(awserr.Error); ok && awsErr.Code() == "InvalidInstanceID.NotFound" {
+			return false, nil
+		}
+		return false, errors.Wrapf(err, "unable to describe instance %q", m.Id)
+	}
+	for _, reservation := range resp.Reservations {
+		for _, instance := range reservation.Instances {
+			if instance.State != nil && aws.StringValue(instance.State.Name) == ec2.InstanceStateNameTerminated {
+				continue
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func getRegionOrEndpoint(params map[string]string, useDefault bool) string {
 	regionOrEndpoint := params["endpoint"]
 	if regionOrEndpoint == "" {
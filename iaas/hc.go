@@ -44,6 +44,12 @@ func BuildHealthCheck(providerName string) func() error {
 	}
 }
 
+// HealthCheck calls the HealthCheck() method of the IaaS with the given name,
+// returning hc.ErrDisabledComponent if it's not a HealthChecker.
+func HealthCheck(name string) error {
+	return healthCheck(name)
+}
+
 func healthCheck(name string) error {
 	provider, err := getIaasProvider(name)
 	if err != nil {
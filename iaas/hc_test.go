@@ -49,3 +49,13 @@ func (s *S) TestBuildHealthCheckNotChecker(c *check.C) {
 	err := fn()
 	c.Assert(err, check.Equals, hc.ErrDisabledComponent)
 }
+
+func (s *S) TestHealthCheck(c *check.C) {
+	err := errors.New("fatal failure")
+	RegisterIaasProvider("hc", newTestHealthcheckIaaS)
+	iaas, getErr := getIaasProvider("hc")
+	c.Assert(getErr, check.IsNil)
+	iaas.(*TestHealthCheckerIaaS).err = err
+	c.Assert(HealthCheck("hc"), check.Equals, err)
+	c.Assert(HealthCheck("test-iaas"), check.Equals, hc.ErrDisabledComponent)
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"sort"
 	"sync"
 
 	"github.com/pkg/errors"
@@ -44,6 +45,15 @@ type InitializableIaaS interface {
 	Initialize() error
 }
 
+// ListableIaaS is implemented by IaaS providers able to list, directly from
+// the cloud provider, the machines created by tsuru. MachineExists looks up a
+// single machine by its id, it is used for stored machines missing from the
+// list, as they may have been created before tsuru tagged its machines.
+type ListableIaaS interface {
+	ListMachines() ([]CloudMachine, error)
+	MachineExists(m *Machine) (bool, error)
+}
+
 type NamedIaaS struct {
 	BaseIaaSName string
 	IaaSName     string
@@ -83,6 +93,16 @@ func (i *UserDataIaaS) ReadUserData(params map[string]string) (string, error) {
 	return string(body), nil
 }
 
+// MachineTags appends the tag identifying machines created by tsuru to a
+// comma separated list of key:value tags.
+func (i *NamedIaaS) MachineTags(tags string) string {
+	tag := MachineTagName + ":" + i.IaaSName
+	if tags == "" {
+		return tag
+	}
+	return tags + "," + tag
+}
+
 func (i *NamedIaaS) GetConfigString(name string) (string, error) {
 	val, err := i.GetConfig(name)
 	if err != nil || val == nil {
@@ -160,13 +180,34 @@ func getDefaultIaasName() (string, error) {
 	}
 	ec2ProviderName := "ec2"
 	ec2Configured := false
+	configuredIaases := configuredIaaSNames()
+	for _, name := range configuredIaases {
+		if name == ec2ProviderName {
+			ec2Configured = true
+		}
+	}
+	if len(configuredIaases) == 1 {
+		return configuredIaases[0], nil
+	}
+	if ec2Configured {
+		return ec2ProviderName, nil
+	}
+	return "", ErrNoDefaultIaaS
+}
+
+// ConfiguredNames returns the sorted names of all IaaSes present in the
+// config file, including custom ones.
+func ConfiguredNames() []string {
+	names := configuredIaaSNames()
+	sort.Strings(names)
+	return names
+}
+
+func configuredIaaSNames() []string {
 	var configuredIaases []string
 	for provider := range iaasProviders {
-		if _, err = config.Get(fmt.Sprintf("iaas:%s", provider)); err == nil {
+		if _, err := config.Get(fmt.Sprintf("iaas:%s", provider)); err == nil {
 			configuredIaases = append(configuredIaases, provider)
-			if provider == ec2ProviderName {
-				ec2Configured = true
-			}
 		}
 	}
 	c, err := config.Get("iaas:custom")
@@ -177,13 +218,7 @@ func getDefaultIaasName() (string, error) {
 			}
 		}
 	}
-	if len(configuredIaases) == 1 {
-		return configuredIaases[0], nil
-	}
-	if ec2Configured {
-		return ec2ProviderName, nil
-	}
-	return "", ErrNoDefaultIaaS
+	return configuredIaases
 }
 
 func ResetAll() {
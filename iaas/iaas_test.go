@@ -52,6 +52,14 @@ func (s *S) TestDescribe(c *check.C) {
 	c.Assert(desc, check.Equals, "ahoy desc!")
 }
 
+func (s *S) TestConfiguredNames(c *check.C) {
+	RegisterIaasProvider("other-iaas", newTestIaaS)
+	config.Set("iaas:test-iaas:url", "http://localhost")
+	config.Set("iaas:custom:abc:provider", "test-iaas")
+	defer config.Unset("iaas")
+	c.Assert(ConfiguredNames(), check.DeepEquals, []string{"abc", "test-iaas"})
+}
+
 func (s *S) TestCustomizableIaaSProvider(c *check.C) {
 	RegisterIaasProvider("customable-iaas", newTestCustomizableIaaS)
 	config.Set("iaas:custom:abc:provider", "customable-iaas")
@@ -0,0 +1,350 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package openstack
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rackspace/gophercloud"
+	"github.com/rackspace/gophercloud/openstack"
+	"github.com/rackspace/gophercloud/openstack/compute/v2/extensions/keypairs"
+	"github.com/rackspace/gophercloud/openstack/compute/v2/flavors"
+	"github.com/rackspace/gophercloud/openstack/compute/v2/images"
+	"github.com/rackspace/gophercloud/openstack/compute/v2/servers"
+	"github.com/rackspace/gophercloud/openstack/networking/v2/networks"
+	"github.com/rackspace/gophercloud/pagination"
+	"github.com/tsuru/tsuru/hc"
+	"github.com/tsuru/tsuru/iaas"
+	"github.com/tsuru/tsuru/net"
+)
+
+const (
+	defaultWaitTimeout = 300
+	statusActive       = "ACTIVE"
+	statusError        = "ERROR"
+)
+
+var pollInterval = 5 * time.Second
+
+func init() {
+	iaas.RegisterIaasProvider("openstack", newOpenStackIaaS)
+	hc.AddChecker("OpenStack", iaas.BuildHealthCheck("openstack"))
+}
+
+type openStackIaaS struct {
+	base iaas.UserDataIaaS
+}
+
+func newOpenStackIaaS(name string) iaas.IaaS {
+	return &openStackIaaS{base: iaas.UserDataIaaS{NamedIaaS: iaas.NamedIaaS{BaseIaaSName: "openstack", IaaSName: name}}}
+}
+
+func (i *openStackIaaS) Describe() string {
+	return `OpenStack IaaS required params:
+  flavor=<flavor>                Flavor name or ID
+  image=<image>                  Image name or ID
+
+Optional params:
+  name=<name>                    Server name, defaults to a generated name
+  network=<networks>             Comma separated list of network names or IDs
+  keypair=<keypair>              Name of the key pair injected in the server
+  securitygroups=<groups>        Comma separated list of security group names
+  availability-zone=<zone>       Availability zone of the server
+  user-data=<data>               User data, defaults to the configured user data
+`
+}
+
+func (i *openStackIaaS) ParamsSchema() []iaas.TemplateParam {
+	return []iaas.TemplateParam{
+		{Name: "flavor", Description: "Flavor name or ID", Required: true},
+		{Name: "image", Description: "Image name or ID", Required: true},
+		{Name: "name", Description: "Server name"},
+		{Name: "network", Aliases: []string{"networks"}, Description: "Comma separated list of network names or IDs"},
+		{Name: "keypair", Description: "Name of the key pair injected in the server"},
+		{Name: "securitygroups", Aliases: []string{"securitygroup"}, Description: "Comma separated list of security group names"},
+		{Name: "availability-zone", Description: "Availability zone of the server"},
+	}
+}
+
+// Initialize checks the configuration of the IaaS, so missing settings are
+// reported when tsuru starts instead of when the first machine is created.
+func (i *openStackIaaS) Initialize() error {
+	for _, key := range []string{"auth-url", "username", "password"} {
+		_, err := i.base.GetConfigString(key)
+		if err != nil {
+			return errors.Wrapf(err, "openstack: missing required config %q", key)
+		}
+	}
+	return nil
+}
+
+func (i *openStackIaaS) HealthCheck() error {
+	client, err := i.computeClient()
+	if err != nil {
+		return err
+	}
+	_, err = flavors.ListDetail(client, flavors.ListOpts{Limit: 1}).AllPages()
+	return err
+}
+
+func (i *openStackIaaS) providerClient() (*gophercloud.ProviderClient, error) {
+	opts := gophercloud.AuthOptions{AllowReauth: true}
+	var err error
+	opts.IdentityEndpoint, err = i.base.GetConfigString("auth-url")
+	if err != nil {
+		return nil, err
+	}
+	opts.Username, err = i.base.GetConfigString("username")
+	if err != nil {
+		return nil, err
+	}
+	opts.Password, err = i.base.GetConfigString("password")
+	if err != nil {
+		return nil, err
+	}
+	opts.TenantName, _ = i.base.GetConfigString("tenant-name")
+	opts.TenantID, _ = i.base.GetConfigString("tenant-id")
+	opts.DomainName, _ = i.base.GetConfigString("domain-name")
+	opts.DomainID, _ = i.base.GetConfigString("domain-id")
+	client, err := openstack.NewClient(opts.IdentityEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	client.HTTPClient = *net.Dial15Full300Client
+	err = openstack.Authenticate(client, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "openstack: unable to authenticate")
+	}
+	return client, nil
+}
+
+func (i *openStackIaaS) endpointOpts() gophercloud.EndpointOpts {
+	region, _ := i.base.GetConfigString("region")
+	availability, _ := i.base.GetConfigString("endpoint-type")
+	return gophercloud.EndpointOpts{
+		Region:       region,
+		Availability: gophercloud.Availability(availability),
+	}
+}
+
+func (i *openStackIaaS) computeClient() (*gophercloud.ServiceClient, error) {
+	provider, err := i.providerClient()
+	if err != nil {
+		return nil, err
+	}
+	return openstack.NewComputeV2(provider, i.endpointOpts())
+}
+
+func (i *openStackIaaS) CreateMachine(params map[string]string) (*iaas.Machine, error) {
+	if params["flavor"] == "" || params["image"] == "" {
+		return nil, errors.New("openstack: params flavor and image are required")
+	}
+	userData, err := i.base.ReadUserData(params)
+	if err != nil {
+		return nil, err
+	}
+	provider, err := i.providerClient()
+	if err != nil {
+		return nil, err
+	}
+	client, err := openstack.NewComputeV2(provider, i.endpointOpts())
+	if err != nil {
+		return nil, err
+	}
+	name := params["name"]
+	if name == "" {
+		name = fmt.Sprintf("tsuru-%d", time.Now().UnixNano())
+	}
+	createOpts := servers.CreateOpts{
+		Name:             name,
+		FlavorRef:        resolveID(client, params["flavor"], flavors.IDFromName),
+		ImageRef:         resolveID(client, params["image"], images.IDFromName),
+		AvailabilityZone: params["availability-zone"],
+		Metadata:         map[string]string{iaas.MachineTagName: i.machineTagValue()},
+	}
+	if userData != "" {
+		createOpts.UserData = []byte(userData)
+	}
+	if groups := splitParam(params["securitygroups"], params["securitygroup"]); len(groups) > 0 {
+		createOpts.SecurityGroups = groups
+	}
+	networkNames := splitParam(params["network"], params["networks"])
+	if len(networkNames) > 0 {
+		networkClient, netErr := openstack.NewNetworkV2(provider, i.endpointOpts())
+		if netErr != nil {
+			return nil, netErr
+		}
+		for _, n := range networkNames {
+			createOpts.Networks = append(createOpts.Networks, servers.Network{
+				UUID: resolveID(networkClient, n, networks.IDFromName),
+			})
+		}
+	}
+	server, err := servers.Create(client, keypairs.CreateOptsExt{
+		CreateOptsBuilder: createOpts,
+		KeyName:           params["keypair"],
+	}).Extract()
+	if err != nil {
+		return nil, errors.Wrap(err, "openstack: unable to create server")
+	}
+	server, err = i.waitServerActive(client, server.ID)
+	if err != nil {
+		servers.Delete(client, server.ID)
+		return nil, err
+	}
+	return &iaas.Machine{
+		Id:      server.ID,
+		Status:  server.Status,
+		Address: serverAddress(server, networkNames),
+	}, nil
+}
+
+func (i *openStackIaaS) DeleteMachine(m *iaas.Machine) error {
+	client, err := i.computeClient()
+	if err != nil {
+		return err
+	}
+	err = servers.Delete(client, m.Id).ExtractErr()
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// ListMachines returns the servers created by the IaaS, identified by the
+// tsuru-iaas metadata set when creating them.
+func (i *openStackIaaS) ListMachines() ([]iaas.CloudMachine, error) {
+	client, err := i.computeClient()
+	if err != nil {
+		return nil, err
+	}
+	var result []iaas.CloudMachine
+	err = servers.List(client, servers.ListOpts{}).EachPage(func(page pagination.Page) (bool, error) {
+		serverList, extractErr := servers.ExtractServers(page)
+		if extractErr != nil {
+			return false, extractErr
+		}
+		for idx := range serverList {
+			s := &serverList[idx]
+			if s.Metadata[iaas.MachineTagName] != i.machineTagValue() {
+				continue
+			}
+			result = append(result, iaas.CloudMachine{
+				Id:      s.ID,
+				Status:  s.Status,
+				Address: serverAddress(s, nil),
+			})
+		}
+		return true, nil
+	})
+	return result, err
+}
+
+// MachineExists looks up the server by its id, regardless of its metadata.
+func (i *openStackIaaS) MachineExists(m *iaas.Machine) (bool, error) {
+	client, err := i.computeClient()
+	if err != nil {
+		return false, err
+	}
+	_, err = servers.Get(client, m.Id).Extract()
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (i *openStackIaaS) machineTagValue() string {
+	if i.base.IaaSName != "" {
+		return i.base.IaaSName
+	}
+	return i.base.BaseIaaSName
+}
+
+func (i *openStackIaaS) waitServerActive(client *gophercloud.ServiceClient, id string) (*servers.Server, error) {
+	rawTimeout, _ := i.base.GetConfigString("wait-timeout")
+	timeout, _ := strconv.Atoi(rawTimeout)
+	if timeout == 0 {
+		timeout = defaultWaitTimeout
+	}
+	deadline := time.Now().Add(time.Duration(timeout) * time.Second)
+	for {
+		server, err := servers.Get(client, id).Extract()
+		if err != nil {
+			return &servers.Server{ID: id}, err
+		}
+		switch server.Status {
+		case statusActive:
+			return server, nil
+		case statusError:
+			return server, errors.Errorf("openstack: server %s failed to start", id)
+		}
+		if time.Now().After(deadline) {
+			return server, errors.Errorf("openstack: time out after %ds waiting for server %s to be %s, last status: %s", timeout, id, statusActive, server.Status)
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// resolveID returns the ID of the resource with the given name, falling back
+// to the value itself so params may hold either names or IDs.
+func resolveID(client *gophercloud.ServiceClient, value string, idFromName func(*gophercloud.ServiceClient, string) (string, error)) string {
+	id, err := idFromName(client, value)
+	if err != nil {
+		return value
+	}
+	return id
+}
+
+// serverAddress returns the first IPv4 address of the server, preferring the
+// networks in the given order and falling back to the access address.
+func serverAddress(server *servers.Server, networkNames []string) string {
+	if server.AccessIPv4 != "" {
+		return server.AccessIPv4
+	}
+	var pools []string
+	for pool := range server.Addresses {
+		pools = append(pools, pool)
+	}
+	sort.Strings(pools)
+	pools = append(append([]string{}, networkNames...), pools...)
+	for _, pool := range pools {
+		addrs, _ := server.Addresses[pool].([]interface{})
+		for _, rawAddr := range addrs {
+			addr, _ := rawAddr.(map[string]interface{})
+			if version, _ := addr["version"].(float64); version != 4 {
+				continue
+			}
+			if ip, _ := addr["addr"].(string); ip != "" {
+				return ip
+			}
+		}
+	}
+	return ""
+}
+
+func splitParam(values ...string) []string {
+	var result []string
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				result = append(result, part)
+			}
+		}
+	}
+	return result
+}
+
+func isNotFound(err error) bool {
+	if respErr, ok := err.(*gophercloud.UnexpectedResponseCodeError); ok {
+		return respErr.Actual == http.StatusNotFound
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package openstack
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/iaas"
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type openstackSuite struct {
+	server *fakeOpenStack
+}
+
+var _ = check.Suite(&openstackSuite{})
+
+func (s *openstackSuite) SetUpSuite(c *check.C) {
+	config.Set("log:disable-syslog", true)
+	pollInterval = time.Millisecond
+}
+
+func (s *openstackSuite) SetUpTest(c *check.C) {
+	s.server = newFakeOpenStack()
+	config.Set("iaas:openstack:auth-url", s.server.URL+"/v2.0/")
+	config.Set("iaas:openstack:username", "tsuru")
+	config.Set("iaas:openstack:password", "secret")
+	config.Set("iaas:openstack:tenant-name", "tsuru-tenant")
+	config.Set("iaas:openstack:user-data", "")
+	config.Unset("iaas:openstack:wait-timeout")
+}
+
+func (s *openstackSuite) TearDownTest(c *check.C) {
+	s.server.Close()
+}
+
+type fakeOpenStack struct {
+	*httptest.Server
+	mu            sync.Mutex
+	authRequest   map[string]interface{}
+	createRequest map[string]interface{}
+	statuses      []string
+	deleted       []string
+}
+
+func newFakeOpenStack() *fakeOpenStack {
+	f := &fakeOpenStack{statuses: []string{"BUILD", "BUILD", "ACTIVE"}}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	return f
+}
+
+func (f *fakeOpenStack) handle(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/v2.0/tokens":
+		json.NewDecoder(r.Body).Decode(&f.authRequest)
+		fmt.Fprintf(w, `{"access": {
+			"token": {"id": "tok1", "expires": "2100-01-01T00:00:00Z"},
+			"serviceCatalog": [
+				{"type": "compute", "name": "nova", "endpoints": [{"region": "RegionOne", "publicURL": "%[1]s/compute/"}]},
+				{"type": "network", "name": "neutron", "endpoints": [{"region": "RegionOne", "publicURL": "%[1]s/network/"}]}
+			]
+		}}`, f.URL)
+	case r.Header.Get("X-Auth-Token") != "tok1":
+		w.WriteHeader(http.StatusUnauthorized)
+	case r.Method == "GET" && r.URL.Path == "/compute/flavors/detail":
+		fmt.Fprint(w, `{"flavors": [{"id": "f1", "name": "m1.small"}, {"id": "f2", "name": "m1.large"}]}`)
+	case r.Method == "GET" && r.URL.Path == "/compute/images/detail":
+		fmt.Fprint(w, `{"images": [{"id": "img1", "name": "ubuntu-16.04"}]}`)
+	case r.Method == "GET" && r.URL.Path == "/network/v2.0/networks":
+		fmt.Fprint(w, `{"networks": [{"id": "net1", "name": "public"}, {"id": "net2", "name": "private"}]}`)
+	case r.Method == "POST" && r.URL.Path == "/compute/servers":
+		json.NewDecoder(r.Body).Decode(&f.createRequest)
+		w.WriteHeader(http.StatusAccepted)
+		fmt.Fprint(w, `{"server": {"id": "srv1"}}`)
+	case r.Method == "GET" && r.URL.Path == "/compute/servers/srv1":
+		status := f.statuses[0]
+		if len(f.statuses) > 1 {
+			f.statuses = f.statuses[1:]
+		}
+		fmt.Fprintf(w, `{"server": {"id": "srv1", "status": %q, "addresses": {
+			"public": [{"addr": "200.0.0.10", "version": 4}],
+			"private": [{"addr": "fe80::1", "version": 6}, {"addr": "10.0.0.5", "version": 4}]
+		}}}`, status)
+	case r.Method == "GET" && r.URL.Path == "/compute/servers/detail":
+		fmt.Fprint(w, `{"servers": [
+			{"id": "srv1", "status": "ACTIVE", "metadata": {"tsuru-iaas": "openstack"}, "addresses": {"private": [{"addr": "10.0.0.5", "version": 4}]}},
+			{"id": "srv2", "status": "ACTIVE", "metadata": {}, "addresses": {}}
+		]}`)
+	case r.Method == "DELETE" && r.URL.Path == "/compute/servers/srv1":
+		f.deleted = append(f.deleted, "srv1")
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, `{"itemNotFound": {"message": "not found", "code": 404}}`)
+	}
+}
+
+func (s *openstackSuite) TestCreateMachine(c *check.C) {
+	i := newOpenStackIaaS("openstack")
+	m, err := i.CreateMachine(map[string]string{
+		"name":           "node1",
+		"flavor":         "m1.large",
+		"image":          "ubuntu-16.04",
+		"network":        "private,public",
+		"keypair":        "mykey",
+		"securitygroups": "default,web",
+		"user-data":      "#!/bin/sh\necho hi",
+	})
+	c.Assert(err, check.IsNil)
+	c.Assert(m, check.DeepEquals, &iaas.Machine{Id: "srv1", Status: "ACTIVE", Address: "10.0.0.5"})
+	auth := s.server.authRequest["auth"].(map[string]interface{})
+	c.Assert(auth["tenantName"], check.Equals, "tsuru-tenant")
+	c.Assert(auth["passwordCredentials"], check.DeepEquals, map[string]interface{}{"username": "tsuru", "password": "secret"})
+	server := s.server.createRequest["server"].(map[string]interface{})
+	c.Assert(server["name"], check.Equals, "node1")
+	c.Assert(server["flavorRef"], check.Equals, "f2")
+	c.Assert(server["imageRef"], check.Equals, "img1")
+	c.Assert(server["key_name"], check.Equals, "mykey")
+	c.Assert(server["networks"], check.DeepEquals, []interface{}{
+		map[string]interface{}{"uuid": "net2"},
+		map[string]interface{}{"uuid": "net1"},
+	})
+	c.Assert(server["security_groups"], check.DeepEquals, []interface{}{
+		map[string]interface{}{"name": "default"},
+		map[string]interface{}{"name": "web"},
+	})
+	c.Assert(server["metadata"], check.DeepEquals, map[string]interface{}{"tsuru-iaas": "openstack"})
+	c.Assert(server["user_data"], check.Equals, base64.StdEncoding.EncodeToString([]byte("#!/bin/sh\necho hi")))
+}
+
+func (s *openstackSuite) TestCreateMachineIDParams(c *check.C) {
+	i := newOpenStackIaaS("openstack")
+	m, err := i.CreateMachine(map[string]string{"flavor": "f1", "image": "some-image-id"})
+	c.Assert(err, check.IsNil)
+	c.Assert(m.Address, check.Equals, "10.0.0.5")
+	server := s.server.createRequest["server"].(map[string]interface{})
+	c.Assert(server["flavorRef"], check.Equals, "f1")
+	c.Assert(server["imageRef"], check.Equals, "some-image-id")
+	c.Assert(server["name"], check.Matches, "tsuru-.+")
+	_, hasUserData := server["user_data"]
+	c.Assert(hasUserData, check.Equals, false)
+}
+
+func (s *openstackSuite) TestCreateMachineMissingParams(c *check.C) {
+	i := newOpenStackIaaS("openstack")
+	_, err := i.CreateMachine(map[string]string{"image": "ubuntu-16.04"})
+	c.Assert(err, check.ErrorMatches, "openstack: params flavor and image are required")
+	c.Assert(s.server.createRequest, check.IsNil)
+}
+
+func (s *openstackSuite) TestCreateMachineError(c *check.C) {
+	s.server.statuses = []string{"BUILD", "ERROR"}
+	i := newOpenStackIaaS("openstack")
+	_, err := i.CreateMachine(map[string]string{"flavor": "f1", "image": "img1"})
+	c.Assert(err, check.ErrorMatches, "openstack: server srv1 failed to start")
+	c.Assert(s.server.deleted, check.DeepEquals, []string{"srv1"})
+}
+
+func (s *openstackSuite) TestCreateMachineTimeout(c *check.C) {
+	config.Set("iaas:openstack:wait-timeout", "-1")
+	s.server.statuses = []string{"BUILD"}
+	i := newOpenStackIaaS("openstack")
+	_, err := i.CreateMachine(map[string]string{"flavor": "f1", "image": "img1"})
+	c.Assert(err, check.ErrorMatches, `openstack: time out after -1s waiting for server srv1 to be ACTIVE, last status: BUILD`)
+	c.Assert(s.server.deleted, check.DeepEquals, []string{"srv1"})
+}
+
+func (s *openstackSuite) TestDeleteMachine(c *check.C) {
+	i := newOpenStackIaaS("openstack")
+	err := i.DeleteMachine(&iaas.Machine{Id: "srv1"})
+	c.Assert(err, check.IsNil)
+	c.Assert(s.server.deleted, check.DeepEquals, []string{"srv1"})
+	err = i.DeleteMachine(&iaas.Machine{Id: "srv-gone"})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *openstackSuite) TestListMachines(c *check.C) {
+	i := newOpenStackIaaS("openstack").(*openStackIaaS)
+	machines, err := i.ListMachines()
+	c.Assert(err, check.IsNil)
+	c.Assert(machines, check.DeepEquals, []iaas.CloudMachine{
+		{Id: "srv1", Status: "ACTIVE", Address: "10.0.0.5"},
+	})
+}
+
+func (s *openstackSuite) TestHealthCheck(c *check.C) {
+	i := newOpenStackIaaS("openstack").(*openStackIaaS)
+	c.Assert(i.HealthCheck(), check.IsNil)
+	config.Set("iaas:openstack:password", "")
+	config.Set("iaas:openstack:auth-url", "http://localhost:1/v2.0/")
+	c.Assert(i.HealthCheck(), check.NotNil)
+}
+
+func (s *openstackSuite) TestInitialize(c *check.C) {
+	i := newOpenStackIaaS("openstack").(*openStackIaaS)
+	c.Assert(i.Initialize(), check.IsNil)
+	config.Unset("iaas:openstack:username")
+	c.Assert(i.Initialize(), check.ErrorMatches, `openstack: missing required config "username".*`)
+}
+
+func (s *openstackSuite) TestDescribe(c *check.C) {
+	i := newOpenStackIaaS("openstack").(iaas.Describer)
+	c.Assert(i.Describe(), check.Matches, "(?s)OpenStack IaaS required params:.*flavor=.*image=.*")
+}
+
+func (s *openstackSuite) TestInterfaces(c *check.C) {
+	i := newOpenStackIaaS("openstack")
+	var ok bool
+	_, ok = i.(iaas.HealthChecker)
+	c.Assert(ok, check.Equals, true)
+	_, ok = i.(iaas.InitializableIaaS)
+	c.Assert(ok, check.Equals, true)
+	_, ok = i.(iaas.ListableIaaS)
+	c.Assert(ok, check.Equals, true)
+	_, ok = i.(iaas.SchemaIaaS)
+	c.Assert(ok, check.Equals, true)
+}
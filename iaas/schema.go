@@ -0,0 +1,192 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iaas
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	ParamTypeString = "string"
+	ParamTypeInt    = "int"
+	ParamTypeBool   = "bool"
+)
+
+// TemplateParam describes a param understood by an IaaS when creating
+// machines.
+type TemplateParam struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Type        string   `json:"type,omitempty"`
+	Required    bool     `json:"required,omitempty"`
+	Values      []string `json:"values,omitempty"`
+}
+
+// SchemaIaaS is implemented by IaaS providers describing the params they
+// accept, allowing templates to be validated before being used.
+type SchemaIaaS interface {
+	ParamsSchema() []TemplateParam
+}
+
+// InvalidParamsError is returned when params don't match the IaaS schema.
+type InvalidParamsError struct {
+	Errors []string
+}
+
+func (e *InvalidParamsError) Error() string {
+	return fmt.Sprintf("invalid params: %s", strings.Join(e.Errors, "; "))
+}
+
+// commonParams are handled by tsuru itself and accepted by every IaaS.
+var commonParams = []TemplateParam{
+	{Name: "iaas"},
+	{Name: "template"},
+	{Name: "pool"},
+	{Name: "user-data"},
+	{Name: "user-data-url"},
+}
+
+// GetParamsSchema returns the params schema of the named IaaS. A nil schema
+// is returned for IaaS providers not implementing SchemaIaaS.
+func GetParamsSchema(iaasName string) ([]TemplateParam, error) {
+	provider, err := getIaasProvider(iaasName)
+	if err != nil {
+		return nil, err
+	}
+	schemaIaaS, ok := provider.(SchemaIaaS)
+	if !ok {
+		return nil, nil
+	}
+	return schemaIaaS.ParamsSchema(), nil
+}
+
+// validateParams checks the params against the schema. Params unknown to the
+// schema are accepted, as they're also used as node metadata, but a warning is
+// returned when their names are too similar to a known param, which usually
+// indicates a typo. Required params are only checked when checkRequired is
+// true.
+func validateParams(schema []TemplateParam, params map[string]string, checkRequired bool) ([]string, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	known := map[string]TemplateParam{}
+	for _, p := range append(schema, commonParams...) {
+		known[strings.ToLower(p.Name)] = p
+		for _, alias := range p.Aliases {
+			known[strings.ToLower(alias)] = p
+		}
+	}
+	var errs, warnings []string
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value := params[name]
+		p, ok := known[strings.ToLower(name)]
+		if !ok {
+			if suggestion := similarParam(name, known); suggestion != "" {
+				warnings = append(warnings, fmt.Sprintf("unknown param %q, did you mean %q?", name, suggestion))
+			}
+			continue
+		}
+		switch p.Type {
+		case ParamTypeInt:
+			if _, err := strconv.Atoi(value); err != nil {
+				errs = append(errs, fmt.Sprintf("param %q must be an integer", name))
+			}
+		case ParamTypeBool:
+			if _, err := strconv.ParseBool(value); err != nil {
+				errs = append(errs, fmt.Sprintf("param %q must be a boolean", name))
+			}
+		}
+		if len(p.Values) > 0 && !containsString(p.Values, value) {
+			errs = append(errs, fmt.Sprintf("param %q must be one of: %s", name, strings.Join(p.Values, ", ")))
+		}
+	}
+	if checkRequired {
+		for _, p := range schema {
+			if p.Required && !hasAnyParam(params, append([]string{p.Name}, p.Aliases...)) {
+				errs = append(errs, fmt.Sprintf("param %q is required", p.Name))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return warnings, &InvalidParamsError{Errors: errs}
+	}
+	return warnings, nil
+}
+
+func hasAnyParam(params map[string]string, names []string) bool {
+	for param := range params {
+		for _, name := range names {
+			if strings.EqualFold(param, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// similarParam returns the known param at edit distance 1 or 2 from name,
+// ignoring case.
+func similarParam(name string, known map[string]TemplateParam) string {
+	best := ""
+	bestDistance := 3
+	for candidate := range known {
+		d := editDistance(strings.ToLower(name), candidate)
+		if d < bestDistance || (d == bestDistance && candidate < best) {
+			best, bestDistance = candidate, d
+		}
+	}
+	if len(name) <= bestDistance*2 {
+		return ""
+	}
+	return best
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func minInt(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
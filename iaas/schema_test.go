@@ -0,0 +1,53 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package iaas
+
+import "gopkg.in/check.v1"
+
+func (s *S) TestValidateParamsNilSchema(c *check.C) {
+	warnings, err := validateParams(nil, map[string]string{"imagee": "x"}, true)
+	c.Assert(err, check.IsNil)
+	c.Assert(warnings, check.IsNil)
+}
+
+func (s *S) TestValidateParamsCaseInsensitive(c *check.C) {
+	schema := []TemplateParam{{Name: "imageid", Required: true}, {Name: "ebsoptimized", Type: ParamTypeBool}}
+	_, err := validateParams(schema, map[string]string{"ImageId": "ami-1", "EbsOptimized": "true"}, true)
+	c.Assert(err, check.IsNil)
+	_, err = validateParams(schema, map[string]string{"ImageId": "ami-1", "EbsOptimized": "yes"}, true)
+	c.Assert(err, check.ErrorMatches, `invalid params: param "EbsOptimized" must be a boolean`)
+}
+
+func (s *S) TestValidateParamsShortNamesNotSuggested(c *check.C) {
+	schema := []TemplateParam{{Name: "zone"}}
+	warnings, err := validateParams(schema, map[string]string{"zn": "a", "az": "b"}, false)
+	c.Assert(err, check.IsNil)
+	c.Assert(warnings, check.IsNil)
+}
+
+func (s *S) TestValidateParamsSimilarNamesWarning(c *check.C) {
+	schema := []TemplateParam{{Name: "image"}}
+	warnings, err := validateParams(schema, map[string]string{"imagee": "a"}, false)
+	c.Assert(err, check.IsNil)
+	c.Assert(warnings, check.DeepEquals, []string{`unknown param "imagee", did you mean "image"?`})
+}
+
+func (s *S) TestEditDistance(c *check.C) {
+	c.Assert(editDistance("", ""), check.Equals, 0)
+	c.Assert(editDistance("image", "image"), check.Equals, 0)
+	c.Assert(editDistance("imagee", "image"), check.Equals, 1)
+	c.Assert(editDistance("imgae", "image"), check.Equals, 2)
+	c.Assert(editDistance("abc", ""), check.Equals, 3)
+}
+
+func (s *S) TestGetParamsSchema(c *check.C) {
+	RegisterIaasProvider("schema-iaas", func(string) IaaS { return &TestSchemaIaaS{} })
+	schema, err := GetParamsSchema("schema-iaas")
+	c.Assert(err, check.IsNil)
+	c.Assert(schema, check.HasLen, 4)
+	schema, err = GetParamsSchema("test-iaas")
+	c.Assert(err, check.IsNil)
+	c.Assert(schema, check.IsNil)
+}
@@ -5,10 +5,16 @@
 package iaas
 
 import (
+	"fmt"
+	"sort"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
 	"github.com/pkg/errors"
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/db"
 	"github.com/tsuru/tsuru/db/storage"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/log"
 )
 
@@ -23,9 +29,22 @@ func (l TemplateDataList) Len() int           { return len(l) }
 func (l TemplateDataList) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
 func (l TemplateDataList) Less(i, j int) bool { return l[i].Name < l[j].Name }
 
+var (
+	ErrTemplateParentNotFound = errors.New("parent template not found")
+	ErrTemplateCycle          = errors.New("template inheritance cycle detected")
+	ErrTemplateHasChildren    = errors.New("template is extended by other templates")
+	ErrTemplateIaaSChange     = errors.New("cannot change the IaaS of a template extended by other templates")
+)
+
+// Template holds params used when creating machines. A template may extend a
+// parent template, in which case its params override the ones inherited from
+// the parent. Params listed in Unset are inherited from the parent but not
+// used by the template.
 type Template struct {
 	Name     string `bson:"_id"`
 	IaaSName string
+	Parent   string   `bson:",omitempty" form:",omitempty"`
+	Unset    []string `bson:",omitempty" form:",omitempty"`
 	Data     TemplateDataList
 }
 
@@ -42,7 +61,10 @@ func ExpandTemplate(name string, params map[string]string) (map[string]string, e
 	if err != nil {
 		return nil, err
 	}
-	templateParams := template.paramsMap()
+	templateParams, err := template.resolvedParams()
+	if err != nil {
+		return nil, err
+	}
 	delete(params, "template")
 	// User params will override template params
 	for k, v := range templateParams {
@@ -54,6 +76,23 @@ func ExpandTemplate(name string, params map[string]string) (map[string]string, e
 	return params, nil
 }
 
+// ValidateTemplate expands the template with the given params, exactly as
+// done when creating machines, and validates the result against the params
+// schema of the IaaS, including required params. Params which look like typos
+// of known params are returned as warnings.
+func ValidateTemplate(name string, params map[string]string) (map[string]string, []string, error) {
+	expanded, err := ExpandTemplate(name, params)
+	if err != nil {
+		return nil, nil, err
+	}
+	schema, err := GetParamsSchema(expanded["iaas"])
+	if err != nil {
+		return nil, nil, err
+	}
+	warnings, err := validateParams(schema, expanded, true)
+	return expanded, warnings, err
+}
+
 func ListTemplates() ([]Template, error) {
 	coll := template_collection()
 	defer coll.Close()
@@ -65,25 +104,50 @@ func ListTemplates() ([]Template, error) {
 func DestroyTemplate(name string) error {
 	coll := template_collection()
 	defer coll.Close()
+	children, err := coll.Find(bson.M{"parent": name}).Count()
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return ErrTemplateHasChildren
+	}
 	return coll.RemoveId(name)
 }
 
+// Update merges the params of toMerge into the template. Params with empty
+// values are removed from the template, going back to the value inherited
+// from the parent, if any, while params listed in toMerge.Unset stop being
+// inherited.
 func (t *Template) Update(toMerge *Template) error {
 	currentMap := t.paramsMap()
 	toMergeMap := toMerge.paramsMap()
 	delete(toMergeMap, "iaas")
 	delete(currentMap, "iaas")
+	unset := map[string]struct{}{}
+	for _, k := range t.Unset {
+		unset[k] = struct{}{}
+	}
 	for k, v := range toMergeMap {
+		delete(unset, k)
 		if v == "" {
 			delete(currentMap, k)
 		} else {
 			currentMap[k] = v
 		}
 	}
+	for _, k := range toMerge.Unset {
+		delete(currentMap, k)
+		unset[k] = struct{}{}
+	}
 	t.Data = make(TemplateDataList, 0, len(currentMap))
 	for k, v := range currentMap {
 		t.Data = append(t.Data, TemplateData{Name: k, Value: v})
 	}
+	t.Unset = nil
+	for k := range unset {
+		t.Unset = append(t.Unset, k)
+	}
+	sort.Strings(t.Unset)
 	return t.Save()
 }
 
@@ -91,13 +155,144 @@ func (t *Template) Save() error {
 	if t.Name == "" {
 		return errors.New("template name cannot be empty")
 	}
+	if t.Parent != "" {
+		parent, err := t.parent()
+		if err != nil {
+			return err
+		}
+		if t.IaaSName == "" {
+			t.IaaSName = parent.IaaSName
+		}
+		if t.IaaSName != parent.IaaSName {
+			return &tsuruErrors.ValidationError{
+				Message: fmt.Sprintf("template IaaS %q differs from parent template IaaS %q", t.IaaSName, parent.IaaSName),
+			}
+		}
+	}
 	_, err := getIaasProvider(t.IaaSName)
 	if err != nil {
 		return err
 	}
+	params, err := t.resolvedParams()
+	if err != nil {
+		return err
+	}
+	schema, err := GetParamsSchema(t.IaaSName)
+	if err != nil {
+		return err
+	}
+	_, err = validateParams(schema, params, false)
+	if err != nil {
+		return err
+	}
+	err = t.validateDescendants(schema)
+	if err != nil {
+		return err
+	}
 	return t.saveToDB()
 }
 
+// validateDescendants checks that every template extending t is still valid
+// once t is saved.
+func (t *Template) validateDescendants(schema []TemplateParam) error {
+	descendants, err := t.descendants()
+	if err != nil {
+		return err
+	}
+	for i := range descendants {
+		if descendants[i].IaaSName != t.IaaSName {
+			return ErrTemplateIaaSChange
+		}
+		params, err := descendants[i].resolvedParamsWith(map[string]*Template{t.Name: t})
+		if err != nil {
+			return err
+		}
+		_, err = validateParams(schema, params, false)
+		if invalidErr, ok := err.(*InvalidParamsError); ok {
+			errs := make([]string, len(invalidErr.Errors))
+			for j, msg := range invalidErr.Errors {
+				errs[j] = fmt.Sprintf("template %q: %s", descendants[i].Name, msg)
+			}
+			return &InvalidParamsError{Errors: errs}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// descendants returns the templates extending t, directly or not.
+func (t *Template) descendants() ([]Template, error) {
+	coll := template_collection()
+	defer coll.Close()
+	var result []Template
+	visited := map[string]struct{}{t.Name: {}}
+	for pending := []string{t.Name}; len(pending) > 0; pending = pending[1:] {
+		var children []Template
+		err := coll.Find(bson.M{"parent": pending[0]}).Sort("_id").All(&children)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			if _, ok := visited[child.Name]; ok {
+				continue
+			}
+			visited[child.Name] = struct{}{}
+			result = append(result, child)
+			pending = append(pending, child.Name)
+		}
+	}
+	return result, nil
+}
+
+func (t *Template) parent() (*Template, error) {
+	parent, err := FindTemplate(t.Parent)
+	if err == mgo.ErrNotFound {
+		return nil, ErrTemplateParentNotFound
+	}
+	return parent, err
+}
+
+// resolvedParams returns the template params merged with the params of
+// every ancestor, closer templates taking precedence.
+func (t *Template) resolvedParams() (map[string]string, error) {
+	return t.resolvedParamsWith(nil)
+}
+
+// resolvedParamsWith works like resolvedParams, using the templates in
+// overrides instead of the stored ones with the same names.
+func (t *Template) resolvedParamsWith(overrides map[string]*Template) (map[string]string, error) {
+	chain := []*Template{t}
+	visited := map[string]struct{}{t.Name: {}}
+	for current := t; current.Parent != ""; {
+		if _, ok := visited[current.Parent]; ok {
+			return nil, ErrTemplateCycle
+		}
+		visited[current.Parent] = struct{}{}
+		parent, ok := overrides[current.Parent]
+		if !ok {
+			var err error
+			parent, err = current.parent()
+			if err != nil {
+				return nil, err
+			}
+		}
+		chain = append(chain, parent)
+		current = parent
+	}
+	params := map[string]string{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, k := range chain[i].Unset {
+			delete(params, k)
+		}
+		for k, v := range chain[i].paramsMap() {
+			params[k] = v
+		}
+	}
+	return params, nil
+}
+
 func (t *Template) saveToDB() error {
 	coll := template_collection()
 	defer coll.Close()
@@ -196,3 +196,223 @@ func (s *S) TestExpandTemplate(c *check.C) {
 		"iaas": "test-iaas",
 	})
 }
+
+type TestSchemaIaaS struct {
+	TestIaaS
+}
+
+func (i *TestSchemaIaaS) ParamsSchema() []TemplateParam {
+	return []TemplateParam{
+		{Name: "image", Required: true},
+		{Name: "instancetype", Aliases: []string{"type"}, Required: true},
+		{Name: "disksize", Type: ParamTypeInt},
+		{Name: "zone", Values: []string{"a", "b"}},
+	}
+}
+
+func (s *S) TestTemplateSaveWithParent(c *check.C) {
+	parent := Template{
+		Name:     "base",
+		IaaSName: "test-iaas",
+		Data:     TemplateDataList{{Name: "key1", Value: "val1"}},
+	}
+	err := parent.Save()
+	c.Assert(err, check.IsNil)
+	child := Template{
+		Name:   "child",
+		Parent: "base",
+		Data:   TemplateDataList{{Name: "key2", Value: "val2"}},
+	}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	c.Assert(child.IaaSName, check.Equals, "test-iaas")
+	dbTpl, err := FindTemplate("child")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbTpl.Parent, check.Equals, "base")
+	c.Assert(dbTpl.IaaSName, check.Equals, "test-iaas")
+}
+
+func (s *S) TestTemplateSaveParentNotFound(c *check.C) {
+	t := Template{Name: "child", Parent: "base", IaaSName: "test-iaas"}
+	err := t.Save()
+	c.Assert(err, check.Equals, ErrTemplateParentNotFound)
+}
+
+func (s *S) TestTemplateSaveParentDifferentIaaS(c *check.C) {
+	RegisterIaasProvider("other-iaas", newTestIaaS)
+	parent := Template{Name: "base", IaaSName: "test-iaas"}
+	err := parent.Save()
+	c.Assert(err, check.IsNil)
+	child := Template{Name: "child", Parent: "base", IaaSName: "other-iaas"}
+	err = child.Save()
+	c.Assert(err, check.ErrorMatches, `template IaaS "other-iaas" differs from parent template IaaS "test-iaas"`)
+}
+
+func (s *S) TestTemplateSaveCycle(c *check.C) {
+	base := Template{Name: "base", IaaSName: "test-iaas"}
+	err := base.Save()
+	c.Assert(err, check.IsNil)
+	child := Template{Name: "child", Parent: "base"}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	base.Parent = "child"
+	err = base.Save()
+	c.Assert(err, check.Equals, ErrTemplateCycle)
+}
+
+func (s *S) TestTemplateSaveInvalidParams(c *check.C) {
+	RegisterIaasProvider("schema-iaas", func(string) IaaS { return &TestSchemaIaaS{} })
+	t := Template{
+		Name:     "tpl1",
+		IaaSName: "schema-iaas",
+		Data: TemplateDataList{
+			{Name: "imagee", Value: "img"},
+			{Name: "disksize", Value: "big"},
+			{Name: "zone", Value: "c"},
+			{Name: "custom-metadata", Value: "x"},
+		},
+	}
+	err := t.Save()
+	c.Assert(err, check.FitsTypeOf, &InvalidParamsError{})
+	c.Assert(err.(*InvalidParamsError).Errors, check.DeepEquals, []string{
+		`param "disksize" must be an integer`,
+		`param "zone" must be one of: a, b`,
+	})
+}
+
+func (s *S) TestTemplateSaveRevalidatesDescendants(c *check.C) {
+	RegisterIaasProvider("schema-iaas", func(string) IaaS { return &TestSchemaIaaS{} })
+	base := Template{Name: "base", IaaSName: "schema-iaas"}
+	err := base.Save()
+	c.Assert(err, check.IsNil)
+	child := Template{Name: "child", Parent: "base", IaaSName: "schema-iaas"}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	// stored before the IaaS described its params
+	grandchild := Template{
+		Name:     "grandchild",
+		Parent:   "child",
+		IaaSName: "schema-iaas",
+		Data:     TemplateDataList{{Name: "disksize", Value: "big"}},
+	}
+	err = grandchild.saveToDB()
+	c.Assert(err, check.IsNil)
+	err = base.Update(&Template{Data: TemplateDataList{{Name: "zone", Value: "a"}}})
+	c.Assert(err, check.FitsTypeOf, &InvalidParamsError{})
+	c.Assert(err.(*InvalidParamsError).Errors, check.DeepEquals, []string{
+		`template "grandchild": param "disksize" must be an integer`,
+	})
+	dbBase, err := FindTemplate("base")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbBase.Data, check.HasLen, 0)
+}
+
+func (s *S) TestTemplateSaveRejectsIaaSChangeWithChildren(c *check.C) {
+	base := Template{Name: "base", IaaSName: "test-iaas"}
+	err := base.Save()
+	c.Assert(err, check.IsNil)
+	child := Template{Name: "child", Parent: "base"}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	RegisterIaasProvider("other-iaas", newTestIaaS)
+	base.IaaSName = "other-iaas"
+	err = base.Save()
+	c.Assert(err, check.Equals, ErrTemplateIaaSChange)
+	dbBase, err := FindTemplate("base")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbBase.IaaSName, check.Equals, "test-iaas")
+}
+
+func (s *S) TestTemplateUpdateUnsetInheritedParam(c *check.C) {
+	base := Template{
+		Name:     "base",
+		IaaSName: "test-iaas",
+		Data:     TemplateDataList{{Name: "key1", Value: "val1"}, {Name: "key2", Value: "val2"}},
+	}
+	err := base.Save()
+	c.Assert(err, check.IsNil)
+	child := Template{Name: "child", Parent: "base"}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	err = child.Update(&Template{Unset: []string{"key1"}})
+	c.Assert(err, check.IsNil)
+	data, err := ExpandTemplate("child", map[string]string{})
+	c.Assert(err, check.IsNil)
+	c.Assert(data, check.DeepEquals, map[string]string{"key2": "val2", "iaas": "test-iaas"})
+	dbChild, err := FindTemplate("child")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbChild.Unset, check.DeepEquals, []string{"key1"})
+	err = dbChild.Update(&Template{Data: TemplateDataList{{Name: "key1", Value: ""}}})
+	c.Assert(err, check.IsNil)
+	data, err = ExpandTemplate("child", map[string]string{})
+	c.Assert(err, check.IsNil)
+	c.Assert(data, check.DeepEquals, map[string]string{"key1": "val1", "key2": "val2", "iaas": "test-iaas"})
+}
+
+func (s *S) TestDestroyTemplateWithChildren(c *check.C) {
+	base := Template{Name: "base", IaaSName: "test-iaas"}
+	err := base.Save()
+	c.Assert(err, check.IsNil)
+	child := Template{Name: "child", Parent: "base"}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	err = DestroyTemplate("base")
+	c.Assert(err, check.Equals, ErrTemplateHasChildren)
+	err = DestroyTemplate("child")
+	c.Assert(err, check.IsNil)
+	err = DestroyTemplate("base")
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestExpandTemplateWithParent(c *check.C) {
+	base := Template{
+		Name:     "base",
+		IaaSName: "test-iaas",
+		Data: TemplateDataList{
+			{Name: "key1", Value: "val1"},
+			{Name: "key2", Value: "val2"},
+		},
+	}
+	err := base.Save()
+	c.Assert(err, check.IsNil)
+	child := Template{
+		Name:   "child",
+		Parent: "base",
+		Data: TemplateDataList{
+			{Name: "key2", Value: "child2"},
+			{Name: "key3", Value: "child3"},
+		},
+	}
+	err = child.Save()
+	c.Assert(err, check.IsNil)
+	data, err := ExpandTemplate("child", map[string]string{"key3": "user3"})
+	c.Assert(err, check.IsNil)
+	c.Assert(data, check.DeepEquals, map[string]string{
+		"key1": "val1",
+		"key2": "child2",
+		"key3": "user3",
+		"iaas": "test-iaas",
+	})
+}
+
+func (s *S) TestValidateTemplate(c *check.C) {
+	RegisterIaasProvider("schema-iaas", func(string) IaaS { return &TestSchemaIaaS{} })
+	t := Template{
+		Name:     "tpl1",
+		IaaSName: "schema-iaas",
+		Data:     TemplateDataList{{Name: "image", Value: "img"}},
+	}
+	err := t.Save()
+	c.Assert(err, check.IsNil)
+	_, _, err = ValidateTemplate("tpl1", map[string]string{})
+	c.Assert(err, check.ErrorMatches, `invalid params: param "instancetype" is required`)
+	data, warnings, err := ValidateTemplate("tpl1", map[string]string{"type": "small", "imagee": "other"})
+	c.Assert(err, check.IsNil)
+	c.Assert(warnings, check.DeepEquals, []string{`unknown param "imagee", did you mean "image"?`})
+	delete(data, "imagee")
+	c.Assert(data, check.DeepEquals, map[string]string{
+		"image": "img",
+		"type":  "small",
+		"iaas":  "schema-iaas",
+	})
+}
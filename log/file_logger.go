@@ -62,6 +62,10 @@ func (l *fileLogger) Debugf(format string, o ...interface{}) {
 	l.Debug(fmt.Sprintf(format, o...))
 }
 
+func (l *fileLogger) setDebug(debug bool) {
+	l.debug = debug
+}
+
 func (l *fileLogger) GetStdLogger() *log.Logger {
 	return l.logger
 }
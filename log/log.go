@@ -18,6 +18,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/tsuru/config"
+	internalConfig "github.com/tsuru/tsuru/config"
 )
 
 type Logger interface {
@@ -53,9 +54,20 @@ func Init() error {
 		loggers = append(loggers, NewWriterLogger(os.Stderr, debug))
 	}
 	SetLogger(NewMultiLogger(loggers...))
+	internalConfig.Subscribe("log level", []string{"debug"}, reloadDebug)
 	return nil
 }
 
+func reloadDebug() error {
+	debug, _ := config.GetBool("debug")
+	SetDebug(debug)
+	return nil
+}
+
+type debugSetter interface {
+	setDebug(bool)
+}
+
 // Target is the current target for the log package.
 type Target struct {
 	logger Logger
@@ -140,6 +152,15 @@ func (t *Target) Debugf(format string, v ...interface{}) {
 	}
 }
 
+// SetDebug enables or disables debug messages in the current logger.
+func (t *Target) SetDebug(debug bool) {
+	t.mut.Lock()
+	defer t.mut.Unlock()
+	if setter, ok := t.logger.(debugSetter); ok {
+		setter.setDebug(debug)
+	}
+}
+
 // GetStdLogger returns a standard Logger instance
 // useful for configuring log in external packages.
 func (t *Target) GetStdLogger() *log.Logger {
@@ -188,6 +209,11 @@ func GetStdLogger() *log.Logger {
 	return DefaultTarget.GetStdLogger()
 }
 
+// SetDebug is a wrapper for DefaultTarget.SetDebug.
+func SetDebug(debug bool) {
+	DefaultTarget.SetDebug(debug)
+}
+
 // SetLogger is a wrapper for DefaultTarget.SetLogger.
 func SetLogger(logger Logger) {
 	DefaultTarget.SetLogger(logger)
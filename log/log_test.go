@@ -86,6 +86,34 @@ func (s *S) TestLogDebug(c *check.C) {
 	c.Assert(buf.String(), check.Equals, "DEBUG: log anything\n")
 }
 
+func (s *S) TestSetDebug(c *check.C) {
+	buf := newFakeLogger()
+	defer buf.Reset()
+	SetLogger(NewMultiLogger(DefaultTarget.logger))
+	SetDebug(false)
+	Debug("hidden")
+	c.Assert(buf.String(), check.Equals, "")
+	SetDebug(true)
+	Debug("visible")
+	c.Assert(buf.String(), check.Equals, "DEBUG: visible\n")
+}
+
+func (s *S) TestReloadDebug(c *check.C) {
+	buf := newFakeLogger()
+	defer buf.Reset()
+	defer config.Unset("debug")
+	config.Set("debug", false)
+	err := reloadDebug()
+	c.Assert(err, check.IsNil)
+	Debug("hidden")
+	c.Assert(buf.String(), check.Equals, "")
+	config.Set("debug", true)
+	err = reloadDebug()
+	c.Assert(err, check.IsNil)
+	Debug("visible")
+	c.Assert(buf.String(), check.Equals, "DEBUG: visible\n")
+}
+
 func (s *S) TestLogDebugf(c *check.C) {
 	buf := newFakeLogger()
 	defer buf.Reset()
@@ -55,6 +55,14 @@ func (m *multiLogger) Fatalf(format string, v ...interface{}) {
 	os.Exit(1)
 }
 
+func (m *multiLogger) setDebug(debug bool) {
+	for _, logger := range m.loggers {
+		if setter, ok := logger.(debugSetter); ok {
+			setter.setDebug(debug)
+		}
+	}
+}
+
 func (m *multiLogger) GetStdLogger() *log.Logger {
 	if len(m.loggers) == 0 {
 		return nil
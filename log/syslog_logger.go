@@ -55,6 +55,10 @@ func (l *syslogLogger) Debugf(format string, o ...interface{}) {
 	l.Debug(fmt.Sprintf(format, o...))
 }
 
+func (l *syslogLogger) setDebug(debug bool) {
+	l.debug = debug
+}
+
 func (l *syslogLogger) GetStdLogger() *log.Logger {
 	return log.New(l.w, "", 0)
 }
@@ -10,7 +10,10 @@ package migration
 import (
 	"fmt"
 	"io"
+	"os"
+	"time"
 
+	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	"github.com/pkg/errors"
 	"github.com/tsuru/tsuru/db"
@@ -37,13 +40,40 @@ var ErrMigrationAlreadyExecuted = errors.New("migration already executed")
 // parameter is supplied without the name of a migration to run.
 var ErrCannotForceMandatory = errors.New("mandatory migrations can only run once")
 
+// ErrMigrationNotReversible is the error returned by Rollback when the given
+// migration has no Down function.
+var ErrMigrationNotReversible = errors.New("migration cannot be rolled back")
+
+// ErrMigrationNotExecuted is the error returned by Rollback when the given
+// migration has not been executed and the force parameter was not supplied.
+var ErrMigrationNotExecuted = errors.New("migration not executed")
+
+// ErrMigrationLocked is the error returned by Run and Rollback when another
+// process is already running migrations.
+var ErrMigrationLocked = errors.New("migrations are locked by another process")
+
 // MigrateFunc represents a migration function, that can be registered with the
 // Register function. Migrations are later ran in the registration order, and
 // this package keeps track of which migrate have ran already.
 type MigrateFunc func() error
 
+// CheckFunc represents the precondition of a migration. The migration is only
+// executed when its check returns nil.
+type CheckFunc func() error
+
+// Migration describes a migration registered with RegisterMigration. Down
+// and Check are optional, a migration without Down cannot be rolled back.
+type Migration struct {
+	Name     string
+	Optional bool
+	Up       MigrateFunc
+	Down     MigrateFunc
+	Check    CheckFunc
+}
+
 // RunArgs is used by Run and RunOptional functions to modify how migrations
-// are executed.
+// are executed. In dry mode preconditions are checked, but migrations are not
+// executed.
 type RunArgs struct {
 	Name   string
 	Writer io.Writer
@@ -56,6 +86,41 @@ type migration struct {
 	Ran      bool
 	Optional bool
 	fn       MigrateFunc
+	down     MigrateFunc
+	check    CheckFunc
+}
+
+// State is the execution state of a migration.
+type State string
+
+const (
+	StatePending    = State("pending")
+	StateRan        = State("ran")
+	StateFailed     = State("failed")
+	StateRolledBack = State("rolled-back")
+)
+
+// MigrationStatus is the state of a registered migration, along with the
+// timings of its last execution.
+type MigrationStatus struct {
+	Name       string    `json:"name"`
+	Optional   bool      `json:"optional"`
+	Reversible bool      `json:"reversible"`
+	State      State     `json:"state"`
+	Error      string    `json:"error,omitempty"`
+	StartTime  time.Time `json:"startTime,omitempty"`
+	EndTime    time.Time `json:"endTime,omitempty"`
+}
+
+type migrationRecord struct {
+	Name       string
+	Ran        bool
+	Optional   bool
+	Failed     bool
+	RolledBack bool
+	Error      string
+	StartTime  time.Time
+	EndTime    time.Time
 }
 
 var migrations []migration
@@ -63,22 +128,34 @@ var migrations []migration
 // Register register a new migration for later execution with the Run
 // functions.
 func Register(name string, fn MigrateFunc) error {
-	return register(name, false, fn)
+	return register(migration{Name: name, fn: fn})
 }
 
 // RegisterOptional register a new migration that will not run automatically
 // when calling the Run funcition.
 func RegisterOptional(name string, fn MigrateFunc) error {
-	return register(name, true, fn)
+	return register(migration{Name: name, Optional: true, fn: fn})
+}
+
+// RegisterMigration register a new migration, possibly reversible and with a
+// precondition, for later execution with the Run functions.
+func RegisterMigration(m Migration) error {
+	return register(migration{
+		Name:     m.Name,
+		Optional: m.Optional,
+		fn:       m.Up,
+		down:     m.Down,
+		check:    m.Check,
+	})
 }
 
-func register(name string, optional bool, fn MigrateFunc) error {
+func register(mig migration) error {
 	for _, m := range migrations {
-		if m.Name == name {
+		if m.Name == mig.Name {
 			return ErrDuplicateMigration
 		}
 	}
-	migrations = append(migrations, migration{Name: name, Optional: optional, fn: fn})
+	migrations = append(migrations, mig)
 	return nil
 }
 
@@ -96,30 +173,25 @@ func Run(args RunArgs) error {
 }
 
 func run(args RunArgs) error {
-	migrationsToRun, err := getMigrations(true)
-	if err != nil {
-		return err
+	if !args.Dry {
+		unlock, err := acquireLock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
 	}
-	coll, err := collection()
+	migrationsToRun, err := getMigrations(true)
 	if err != nil {
 		return err
 	}
-	defer coll.Close()
 	for _, m := range migrationsToRun {
 		if m.Optional {
 			continue
 		}
 		fmt.Fprintf(args.Writer, "Running %q... ", m.Name)
-		if !args.Dry {
-			err := m.fn()
-			if err != nil {
-				return err
-			}
-			m.Ran = true
-			err = coll.Insert(m)
-			if err != nil {
-				return err
-			}
+		err = execute(m, args.Dry)
+		if err != nil {
+			return err
 		}
 		fmt.Fprintln(args.Writer, "OK")
 	}
@@ -127,19 +199,16 @@ func run(args RunArgs) error {
 }
 
 func runOptional(args RunArgs) error {
-	migrationsToRun, err := getMigrations(false)
-	if err != nil {
-		return err
-	}
-	var toRun *migration
-	for i, m := range migrationsToRun {
-		if m.Name == args.Name {
-			toRun = &migrationsToRun[i]
-			break
+	if !args.Dry {
+		unlock, err := acquireLock()
+		if err != nil {
+			return err
 		}
+		defer unlock()
 	}
-	if toRun == nil {
-		return ErrMigrationNotFound
+	toRun, err := findMigration(args.Name)
+	if err != nil {
+		return err
 	}
 	if !toRun.Optional {
 		return ErrMigrationMandatory
@@ -148,18 +217,54 @@ func runOptional(args RunArgs) error {
 		return ErrMigrationAlreadyExecuted
 	}
 	fmt.Fprintf(args.Writer, "Running %q... ", toRun.Name)
+	err = execute(*toRun, args.Dry)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(args.Writer, "OK")
+	return nil
+}
+
+// Rollback undoes the migration with the given ".Name" calling its Down
+// function. Unless ".Force" is informed, only executed migrations may be
+// rolled back. Mandatory migrations rolled back run again in the next call to
+// Run.
+func Rollback(args RunArgs) error {
 	if !args.Dry {
-		coll, err := collection()
+		unlock, err := acquireLock()
 		if err != nil {
 			return err
 		}
-		defer coll.Close()
-		err = toRun.fn()
+		defer unlock()
+	}
+	toRollback, err := findMigration(args.Name)
+	if err != nil {
+		return err
+	}
+	if toRollback.down == nil {
+		return ErrMigrationNotReversible
+	}
+	if !toRollback.Ran && !args.Force {
+		return ErrMigrationNotExecuted
+	}
+	fmt.Fprintf(args.Writer, "Rolling back %q... ", toRollback.Name)
+	if !args.Dry {
+		record := migrationRecord{
+			Name:      toRollback.Name,
+			Optional:  toRollback.Optional,
+			StartTime: time.Now().UTC(),
+		}
+		err = toRollback.down()
+		record.EndTime = time.Now().UTC()
 		if err != nil {
+			record.Ran = toRollback.Ran
+			record.Failed = true
+			record.Error = err.Error()
+			saveRecord(record)
 			return err
 		}
-		toRun.Ran = true
-		_, err = coll.Upsert(bson.M{"name": toRun.Name}, toRun)
+		record.RolledBack = true
+		err = saveRecord(record)
 		if err != nil {
 			return err
 		}
@@ -168,6 +273,105 @@ func runOptional(args RunArgs) error {
 	return nil
 }
 
+func findMigration(name string) (*migration, error) {
+	allMigrations, err := getMigrations(false)
+	if err != nil {
+		return nil, err
+	}
+	for i, m := range allMigrations {
+		if m.Name == name {
+			return &allMigrations[i], nil
+		}
+	}
+	return nil, ErrMigrationNotFound
+}
+
+// execute checks the precondition of the migration and runs it, recording
+// the result. In dry mode only the precondition is checked.
+func execute(m migration, dry bool) error {
+	if m.check != nil {
+		err := m.check()
+		if err != nil {
+			return errors.Wrapf(err, "precondition for migration %q failed", m.Name)
+		}
+	}
+	if dry {
+		return nil
+	}
+	record := migrationRecord{
+		Name:      m.Name,
+		Optional:  m.Optional,
+		StartTime: time.Now().UTC(),
+	}
+	err := m.fn()
+	record.EndTime = time.Now().UTC()
+	if err != nil {
+		record.Failed = true
+		record.Error = err.Error()
+		saveRecord(record)
+		return err
+	}
+	record.Ran = true
+	return saveRecord(record)
+}
+
+func saveRecord(record migrationRecord) error {
+	coll, err := collection()
+	if err != nil {
+		return err
+	}
+	defer coll.Close()
+	_, err = coll.Upsert(bson.M{"name": record.Name}, record)
+	return err
+}
+
+// Status returns the state of all registered migrations, in the order they
+// were registered.
+func Status() ([]MigrationStatus, error) {
+	coll, err := collection()
+	if err != nil {
+		return nil, err
+	}
+	defer coll.Close()
+	names := make([]string, len(migrations))
+	for i, m := range migrations {
+		names[i] = m.Name
+	}
+	var records []migrationRecord
+	err = coll.Find(bson.M{"name": bson.M{"$in": names}}).All(&records)
+	if err != nil {
+		return nil, err
+	}
+	recordMap := make(map[string]migrationRecord, len(records))
+	for _, r := range records {
+		recordMap[r.Name] = r
+	}
+	result := make([]MigrationStatus, len(migrations))
+	for i, m := range migrations {
+		status := MigrationStatus{
+			Name:       m.Name,
+			Optional:   m.Optional,
+			Reversible: m.down != nil,
+			State:      StatePending,
+		}
+		if r, ok := recordMap[m.Name]; ok {
+			status.Error = r.Error
+			status.StartTime = r.StartTime
+			status.EndTime = r.EndTime
+			switch {
+			case r.Failed:
+				status.State = StateFailed
+			case r.Ran:
+				status.State = StateRan
+			case r.RolledBack:
+				status.State = StateRolledBack
+			}
+		}
+		result[i] = status
+	}
+	return result, nil
+}
+
 func List() ([]migration, error) {
 	return getMigrations(false)
 }
@@ -211,3 +415,79 @@ func collection() (*storage.Collection, error) {
 	}
 	return conn.Collection("migrations"), nil
 }
+
+var (
+	lockUpdateInterval = 30 * time.Second
+	lockExpireTimeout  = 2 * time.Minute
+)
+
+const migrationLockID = "migrate"
+
+type migrationLock struct {
+	ID        string `bson:"_id"`
+	Owner     string
+	UpdatedAt time.Time
+}
+
+func lockCollection() (*storage.Collection, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	return conn.Collection("migrations_lock"), nil
+}
+
+// acquireLock ensures only one process runs migrations at a time. The lock
+// is kept alive while migrations are running and expires if its owner dies.
+// The returned function releases the lock.
+func acquireLock() (func(), error) {
+	coll, err := lockCollection()
+	if err != nil {
+		return nil, err
+	}
+	defer coll.Close()
+	hostname, _ := os.Hostname()
+	owner := fmt.Sprintf("%s:%d", hostname, os.Getpid())
+	_, err = coll.RemoveAll(bson.M{
+		"_id":       migrationLockID,
+		"updatedat": bson.M{"$lt": time.Now().UTC().Add(-lockExpireTimeout)},
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = coll.Insert(migrationLock{ID: migrationLockID, Owner: owner, UpdatedAt: time.Now().UTC()})
+	if err != nil {
+		if mgo.IsDup(err) {
+			return nil, ErrMigrationLocked
+		}
+		return nil, err
+	}
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-quit:
+				return
+			case <-time.After(lockUpdateInterval):
+			}
+			coll, err := lockCollection()
+			if err != nil {
+				continue
+			}
+			coll.Update(bson.M{"_id": migrationLockID, "owner": owner}, bson.M{"$set": bson.M{"updatedat": time.Now().UTC()}})
+			coll.Close()
+		}
+	}()
+	return func() {
+		close(quit)
+		<-done
+		coll, err := lockCollection()
+		if err != nil {
+			return
+		}
+		defer coll.Close()
+		coll.Remove(bson.M{"_id": migrationLockID, "owner": owner})
+	}, nil
+}
@@ -8,6 +8,7 @@ import (
 	"bytes"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/db"
@@ -241,3 +242,188 @@ func (s *Suite) TestList(c *check.C) {
 		{Name: "migration3", Optional: true, Ran: true},
 	})
 }
+
+func (s *Suite) TestRunWithPrecondition(c *check.C) {
+	var buf bytes.Buffer
+	var runs []string
+	checkErr := errors.New("not ready")
+	err := Register("migration1", func() error {
+		runs = append(runs, "migration1")
+		return nil
+	})
+	c.Assert(err, check.IsNil)
+	err = RegisterMigration(Migration{
+		Name: "migration2",
+		Up: func() error {
+			runs = append(runs, "migration2")
+			return nil
+		},
+		Check: func() error {
+			return checkErr
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = Run(RunArgs{Writer: &buf, Dry: true})
+	c.Assert(err, check.ErrorMatches, `precondition for migration "migration2" failed: not ready`)
+	c.Assert(runs, check.HasLen, 0)
+	err = Run(RunArgs{Writer: &buf})
+	c.Assert(err, check.ErrorMatches, `precondition for migration "migration2" failed: not ready`)
+	c.Assert(runs, check.DeepEquals, []string{"migration1"})
+	checkErr = nil
+	err = Run(RunArgs{Writer: &buf})
+	c.Assert(err, check.IsNil)
+	c.Assert(runs, check.DeepEquals, []string{"migration1", "migration2"})
+}
+
+func (s *Suite) TestRollback(c *check.C) {
+	expected := `Running "migration1"... OK
+Rolling back "migration1"... OK
+Running "migration1"... OK
+`
+	var buf bytes.Buffer
+	var runs []string
+	err := RegisterMigration(Migration{
+		Name: "migration1",
+		Up: func() error {
+			runs = append(runs, "up")
+			return nil
+		},
+		Down: func() error {
+			runs = append(runs, "down")
+			return nil
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = Rollback(RunArgs{Name: "migration1", Writer: &buf})
+	c.Assert(err, check.Equals, ErrMigrationNotExecuted)
+	err = Run(RunArgs{Writer: &buf})
+	c.Assert(err, check.IsNil)
+	err = Rollback(RunArgs{Name: "migration1", Writer: &buf})
+	c.Assert(err, check.IsNil)
+	migrationsList, err := List()
+	c.Assert(err, check.IsNil)
+	c.Assert(migrationsList, check.HasLen, 1)
+	c.Assert(migrationsList[0].Ran, check.Equals, false)
+	status, err := Status()
+	c.Assert(err, check.IsNil)
+	c.Assert(status[0].State, check.Equals, StateRolledBack)
+	err = Run(RunArgs{Writer: &buf})
+	c.Assert(err, check.IsNil)
+	c.Assert(runs, check.DeepEquals, []string{"up", "down", "up"})
+	c.Assert(buf.String(), check.Equals, expected)
+}
+
+func (s *Suite) TestRollbackDryMode(c *check.C) {
+	var buf bytes.Buffer
+	var runs []string
+	err := RegisterMigration(Migration{
+		Name: "migration1",
+		Up:   func() error { return nil },
+		Down: func() error {
+			runs = append(runs, "down")
+			return nil
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = Run(RunArgs{Writer: &buf})
+	c.Assert(err, check.IsNil)
+	err = Rollback(RunArgs{Name: "migration1", Writer: &buf, Dry: true})
+	c.Assert(err, check.IsNil)
+	c.Assert(runs, check.HasLen, 0)
+	migrationsList, err := List()
+	c.Assert(err, check.IsNil)
+	c.Assert(migrationsList[0].Ran, check.Equals, true)
+}
+
+func (s *Suite) TestRollbackErrors(c *check.C) {
+	var buf bytes.Buffer
+	err := Register("migration1", func() error { return nil })
+	c.Assert(err, check.IsNil)
+	err = Rollback(RunArgs{Name: "migration1", Writer: &buf})
+	c.Assert(err, check.Equals, ErrMigrationNotReversible)
+	err = Rollback(RunArgs{Name: "migration2", Writer: &buf})
+	c.Assert(err, check.Equals, ErrMigrationNotFound)
+}
+
+func (s *Suite) TestRollbackFailure(c *check.C) {
+	var buf bytes.Buffer
+	err := RegisterMigration(Migration{
+		Name: "migration1",
+		Up:   func() error { return nil },
+		Down: func() error { return errors.New("cannot undo") },
+	})
+	c.Assert(err, check.IsNil)
+	err = Run(RunArgs{Writer: &buf})
+	c.Assert(err, check.IsNil)
+	err = Rollback(RunArgs{Name: "migration1", Writer: &buf})
+	c.Assert(err, check.ErrorMatches, "cannot undo")
+	status, err := Status()
+	c.Assert(err, check.IsNil)
+	c.Assert(status[0].State, check.Equals, StateFailed)
+	c.Assert(status[0].Error, check.Equals, "cannot undo")
+	migrationsList, err := List()
+	c.Assert(err, check.IsNil)
+	c.Assert(migrationsList[0].Ran, check.Equals, true)
+}
+
+func (s *Suite) TestRunLocked(c *check.C) {
+	var buf bytes.Buffer
+	var runs []string
+	err := Register("migration1", func() error {
+		runs = append(runs, "migration1")
+		return nil
+	})
+	c.Assert(err, check.IsNil)
+	unlock, err := acquireLock()
+	c.Assert(err, check.IsNil)
+	err = Run(RunArgs{Writer: &buf})
+	c.Assert(err, check.Equals, ErrMigrationLocked)
+	c.Assert(runs, check.HasLen, 0)
+	unlock()
+	err = Run(RunArgs{Writer: &buf})
+	c.Assert(err, check.IsNil)
+	c.Assert(runs, check.DeepEquals, []string{"migration1"})
+}
+
+func (s *Suite) TestLockExpired(c *check.C) {
+	oldTimeout := lockExpireTimeout
+	lockExpireTimeout = 10 * time.Millisecond
+	defer func() { lockExpireTimeout = oldTimeout }()
+	_, err := acquireLock()
+	c.Assert(err, check.IsNil)
+	_, err = acquireLock()
+	c.Assert(err, check.Equals, ErrMigrationLocked)
+	time.Sleep(20 * time.Millisecond)
+	unlock, err := acquireLock()
+	c.Assert(err, check.IsNil)
+	unlock()
+}
+
+func (s *Suite) TestStatus(c *check.C) {
+	var buf bytes.Buffer
+	nilFn := func() error { return nil }
+	err := Register("migration1", nilFn)
+	c.Assert(err, check.IsNil)
+	err = RegisterMigration(Migration{Name: "migration2", Up: func() error {
+		return errors.New("failed")
+	}, Down: nilFn})
+	c.Assert(err, check.IsNil)
+	err = RegisterOptional("migration3", nilFn)
+	c.Assert(err, check.IsNil)
+	err = Run(RunArgs{Writer: &buf})
+	c.Assert(err, check.NotNil)
+	status, err := Status()
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.HasLen, 3)
+	c.Assert(status[0].Name, check.Equals, "migration1")
+	c.Assert(status[0].State, check.Equals, StateRan)
+	c.Assert(status[0].StartTime.IsZero(), check.Equals, false)
+	c.Assert(status[0].EndTime.Before(status[0].StartTime), check.Equals, false)
+	c.Assert(status[0].Reversible, check.Equals, false)
+	c.Assert(status[1].State, check.Equals, StateFailed)
+	c.Assert(status[1].Error, check.Equals, "failed")
+	c.Assert(status[1].Reversible, check.Equals, true)
+	c.Assert(status[2].State, check.Equals, StatePending)
+	c.Assert(status[2].Optional, check.Equals, true)
+	c.Assert(status[2].StartTime.IsZero(), check.Equals, true)
+}
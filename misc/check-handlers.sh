@@ -28,6 +28,8 @@ github.com/tsuru/tsuru/api.authScheme
 github.com/tsuru/tsuru/api.healthcheck
 github.com/tsuru/tsuru/api.index
 github.com/tsuru/tsuru/api.info
+github.com/tsuru/tsuru/api.addLogs
+github.com/tsuru/tsuru/api.openAPISpec
 github.com/tsuru/tsuru/api.resetPassword
 github.com/tsuru/tsuru/api.samlCallbackLogin
 github.com/tsuru/tsuru/api.samlMetadata
@@ -18,6 +18,7 @@ var (
 	PermAppCreate                        = PermissionRegistry.get("app.create")                          // [global team]
 	PermAppDelete                        = PermissionRegistry.get("app.delete")                          // [global app team pool]
 	PermAppDeploy                        = PermissionRegistry.get("app.deploy")                          // [global app team pool]
+	PermAppDeployApprove                 = PermissionRegistry.get("app.deploy-approve")                  // [global app team pool]
 	PermAppDeployArchiveUrl              = PermissionRegistry.get("app.deploy.archive-url")              // [global app team pool]
 	PermAppDeployBuild                   = PermissionRegistry.get("app.deploy.build")                    // [global app team pool]
 	PermAppDeployGit                     = PermissionRegistry.get("app.deploy.git")                      // [global app team pool]
@@ -37,6 +38,7 @@ var (
 	PermAppUpdate                        = PermissionRegistry.get("app.update")                          // [global app team pool]
 	PermAppUpdateBind                    = PermissionRegistry.get("app.update.bind")                     // [global app team pool]
 	PermAppUpdateBindVolume              = PermissionRegistry.get("app.update.bind-volume")              // [global app team pool]
+	PermAppUpdateBuildCache              = PermissionRegistry.get("app.update.build-cache")              // [global app team pool]
 	PermAppUpdateCertificate             = PermissionRegistry.get("app.update.certificate")              // [global app team pool]
 	PermAppUpdateCertificateSet          = PermissionRegistry.get("app.update.certificate.set")          // [global app team pool]
 	PermAppUpdateCertificateUnset        = PermissionRegistry.get("app.update.certificate.unset")        // [global app team pool]
@@ -81,7 +83,14 @@ var (
 	PermClusterRead                      = PermissionRegistry.get("cluster.read")                        // [global]
 	PermClusterReadEvents                = PermissionRegistry.get("cluster.read.events")                 // [global]
 	PermClusterUpdate                    = PermissionRegistry.get("cluster.update")                      // [global]
+	PermClusterUpdateCredentials         = PermissionRegistry.get("cluster.update.credentials")          // [global]
+	PermConfig                           = PermissionRegistry.get("config")                              // [global]
+	PermConfigRead                       = PermissionRegistry.get("config.read")                         // [global]
+	PermConfigReadEvents                 = PermissionRegistry.get("config.read.events")                  // [global]
+	PermConfigReload                     = PermissionRegistry.get("config.reload")                       // [global]
 	PermDebug                            = PermissionRegistry.get("debug")                               // [global]
+	PermDiagnostics                      = PermissionRegistry.get("diagnostics")                         // [global]
+	PermDiagnosticsRead                  = PermissionRegistry.get("diagnostics.read")                    // [global]
 	PermEventBlock                       = PermissionRegistry.get("event-block")                         // [global]
 	PermEventBlockAdd                    = PermissionRegistry.get("event-block.add")                     // [global]
 	PermEventBlockRead                   = PermissionRegistry.get("event-block.read")                    // [global]
@@ -102,6 +111,8 @@ var (
 	PermMachineTemplateDelete            = PermissionRegistry.get("machine.template.delete")             // [global iaas]
 	PermMachineTemplateRead              = PermissionRegistry.get("machine.template.read")               // [global iaas]
 	PermMachineTemplateUpdate            = PermissionRegistry.get("machine.template.update")             // [global iaas]
+	PermMigration                        = PermissionRegistry.get("migration")                           // [global]
+	PermMigrationRead                    = PermissionRegistry.get("migration.read")                      // [global]
 	PermNode                             = PermissionRegistry.get("node")                                // [global pool]
 	PermNodeAutoscale                    = PermissionRegistry.get("node.autoscale")                      // [global]
 	PermNodeAutoscaleDelete              = PermissionRegistry.get("node.autoscale.delete")               // [global]
@@ -184,6 +195,7 @@ var (
 	PermServiceInstanceUpdatePlan        = PermissionRegistry.get("service-instance.update.plan")        // [global service-instance team]
 	PermServiceInstanceUpdateProxy       = PermissionRegistry.get("service-instance.update.proxy")       // [global service-instance team]
 	PermServiceInstanceUpdateRevoke      = PermissionRegistry.get("service-instance.update.revoke")      // [global service-instance team]
+	PermServiceInstanceUpdateRotate      = PermissionRegistry.get("service-instance.update.rotate")      // [global service-instance team]
 	PermServiceInstanceUpdateTags        = PermissionRegistry.get("service-instance.update.tags")        // [global service-instance team]
 	PermServiceInstanceUpdateTeamowner   = PermissionRegistry.get("service-instance.update.teamowner")   // [global service-instance team]
 	PermServiceInstanceUpdateUnbind      = PermissionRegistry.get("service-instance.update.unbind")      // [global service-instance team]
@@ -199,6 +211,8 @@ var (
 	PermServiceUpdateProxy               = PermissionRegistry.get("service.update.proxy")                // [global service team]
 	PermServiceUpdateRevokeAccess        = PermissionRegistry.get("service.update.revoke-access")        // [global service team]
 	PermTeam                             = PermissionRegistry.get("team")                                // [global team]
+	PermTeamAdmin                        = PermissionRegistry.get("team.admin")                          // [global team]
+	PermTeamAdminQuota                   = PermissionRegistry.get("team.admin.quota")                    // [global team]
 	PermTeamCreate                       = PermissionRegistry.get("team.create")                         // [global]
 	PermTeamDelete                       = PermissionRegistry.get("team.delete")                         // [global team]
 	PermTeamRead                         = PermissionRegistry.get("team.read")                           // [global team]
@@ -229,6 +243,9 @@ var (
 	PermVolumeReadEvents                 = PermissionRegistry.get("volume.read.events")                  // [global volume team pool]
 	PermVolumeUpdate                     = PermissionRegistry.get("volume.update")                       // [global volume team pool]
 	PermVolumeUpdateBind                 = PermissionRegistry.get("volume.update.bind")                  // [global volume team pool]
+	PermVolumeUpdateResize               = PermissionRegistry.get("volume.update.resize")                // [global volume team pool]
+	PermVolumeUpdateRestore              = PermissionRegistry.get("volume.update.restore")               // [global volume team pool]
+	PermVolumeUpdateSnapshot             = PermissionRegistry.get("volume.update.snapshot")              // [global volume team pool]
 	PermVolumeUpdateUnbind               = PermissionRegistry.get("volume.update.unbind")                // [global volume team pool]
 	PermWebhook                          = PermissionRegistry.get("webhook")                             // [global team]
 	PermWebhookCreate                    = PermissionRegistry.get("webhook.create")                      // [global team]
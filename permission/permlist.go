@@ -40,6 +40,7 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"app.update.bind",
 	"app.update.bind-volume",
 	"app.update.image-reset",
+	"app.update.build-cache",
 	"app.update.events",
 	"app.update.unbind",
 	"app.update.unbind-volume",
@@ -56,6 +57,7 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"app.deploy.image",
 	"app.deploy.rollback",
 	"app.deploy.upload",
+	"app.deploy-approve",
 	"app.read",
 	"app.read.deploy",
 	"app.read.router",
@@ -109,6 +111,7 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"team.token.create",
 	"team.token.delete",
 	"team.token.update",
+	"team.admin.quota",
 ).addWithCtx(
 	"user", []permTypes.ContextType{permTypes.CtxUser},
 ).addWithCtx(
@@ -157,6 +160,7 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"service-instance.update.tags",
 	"service-instance.update.teamowner",
 	"service-instance.update.plan",
+	"service-instance.update.rotate",
 ).add(
 	"role.create",
 	"role.delete",
@@ -211,6 +215,13 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"nodecontainer.delete",
 ).add(
 	"install.manage",
+).add(
+	"migration.read",
+).add(
+	"config.read.events",
+	"config.reload",
+).add(
+	"diagnostics.read",
 ).add(
 	"event-block.read",
 	"event-block.read.events",
@@ -220,6 +231,7 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"cluster.read.events",
 	"cluster.create",
 	"cluster.update",
+	"cluster.update.credentials",
 	"cluster.delete",
 ).addWithCtx(
 	"volume", []permTypes.ContextType{permTypes.CtxVolume, permTypes.CtxTeam, permTypes.CtxPool},
@@ -229,6 +241,9 @@ var PermissionRegistry = (&registry{}).addWithCtx(
 	"volume.read.events",
 	"volume.update.bind",
 	"volume.update.unbind",
+	"volume.update.snapshot",
+	"volume.update.restore",
+	"volume.update.resize",
 	"volume.delete",
 ).addWithCtx(
 	"webhook", []permTypes.ContextType{permTypes.CtxTeam},
@@ -5,6 +5,8 @@
 package cluster
 
 import (
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -82,6 +84,7 @@ func (s *clusterService) FindByPools(prov string, pools []string) (map[string]pr
 	if err != nil {
 		return nil, err
 	}
+	sortByPriority(provClusters)
 	result := make(map[string]provTypes.Cluster)
 poolLoop:
 	for _, pool := range pools {
@@ -107,8 +110,55 @@ func (s *clusterService) FindByPool(prov, pool string) (*provTypes.Cluster, erro
 	return s.storage.FindByPool(prov, pool)
 }
 
+// FindAllByPool returns all clusters serving the pool, ordered by priority.
+// Only clusters with a placement policy share pools, other pools are served
+// by a single cluster or by the default cluster.
+func (s *clusterService) FindAllByPool(prov, pool string) ([]provTypes.Cluster, error) {
+	provClusters, err := s.FindByProvisioner(prov)
+	if err != nil {
+		return nil, err
+	}
+	sortByPriority(provClusters)
+	var result, defaults []provTypes.Cluster
+	for _, cluster := range provClusters {
+		if cluster.Default {
+			defaults = append(defaults, cluster)
+		}
+		if hasPool(cluster, pool) {
+			result = append(result, cluster)
+		}
+	}
+	if len(result) == 0 {
+		result = defaults
+	}
+	if len(result) == 0 {
+		return nil, provTypes.ErrNoCluster
+	}
+	return result, nil
+}
+
+func sortByPriority(clusters []provTypes.Cluster) {
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].Priority > clusters[j].Priority
+	})
+}
+
+func hasPool(c provTypes.Cluster, pool string) bool {
+	for _, p := range c.Pools {
+		if p == pool {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *clusterService) Delete(c provTypes.Cluster) error {
-	return s.storage.Delete(c)
+	err := s.storage.Delete(c)
+	if err != nil {
+		return err
+	}
+	removeStatus(c.Name)
+	return nil
 }
 
 func (s *clusterService) validate(c provTypes.Cluster, isNewCluster bool) error {
@@ -134,6 +184,35 @@ func (s *clusterService) validate(c provTypes.Cluster, isNewCluster bool) error
 			return errors.WithStack(&tsuruErrors.ValidationError{Message: "either default or a list of pools must be set"})
 		}
 	}
+	return s.validatePlacement(c)
+}
+
+func (s *clusterService) validatePlacement(c provTypes.Cluster) error {
+	if !c.Placement.Valid() {
+		msg := fmt.Sprintf("invalid placement %q, must be one of: %s, %s", c.Placement, provTypes.PlacementReplicate, provTypes.PlacementActivePassive)
+		return errors.WithStack(&tsuruErrors.ValidationError{Message: msg})
+	}
+	if c.Placement == "" {
+		return nil
+	}
+	if c.Default {
+		return errors.WithStack(&tsuruErrors.ValidationError{Message: "placement requires a list of pools"})
+	}
+	provClusters, err := s.storage.FindByProvisioner(c.Provisioner)
+	if err != nil && err != provTypes.ErrNoCluster {
+		return err
+	}
+	for _, other := range provClusters {
+		if other.Name == c.Name || other.Placement == "" || other.Placement == c.Placement {
+			continue
+		}
+		for _, pool := range c.Pools {
+			if hasPool(other, pool) {
+				msg := fmt.Sprintf("pool %q is placed in cluster %q with placement %q", pool, other.Name, other.Placement)
+				return errors.WithStack(&tsuruErrors.ValidationError{Message: msg})
+			}
+		}
+	}
 	return nil
 }
 
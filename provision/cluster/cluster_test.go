@@ -229,6 +229,36 @@ func (s *S) TestClusterServiceUpdateValidationError(c *check.C) {
 			},
 			err: "unknown provisioner: \"invalid\"",
 		},
+		{
+			c: provTypes.Cluster{
+				Name:        "c1",
+				Addresses:   []string{"addr1"},
+				Pools:       []string{"p1"},
+				Provisioner: "fake",
+				Placement:   "spread",
+			},
+			err: "invalid placement \"spread\", must be one of: replicate, active-passive",
+		},
+		{
+			c: provTypes.Cluster{
+				Name:        "c1",
+				Addresses:   []string{"addr1"},
+				Default:     true,
+				Provisioner: "fake",
+				Placement:   provTypes.PlacementReplicate,
+			},
+			err: "placement requires a list of pools",
+		},
+		{
+			c: provTypes.Cluster{
+				Name:        "c1",
+				Addresses:   []string{"addr1"},
+				Pools:       []string{"p1"},
+				Provisioner: "fake",
+				Placement:   provTypes.PlacementActivePassive,
+			},
+			err: "",
+		},
 	}
 	for _, tt := range tests {
 		err := cs.Update(tt.c)
@@ -240,6 +270,27 @@ func (s *S) TestClusterServiceUpdateValidationError(c *check.C) {
 	}
 }
 
+func (s *S) TestClusterServiceUpdatePlacementConflict(c *check.C) {
+	cs := &clusterService{
+		storage: &provTypes.MockClusterStorage{
+			OnFindByProvisioner: func(prov string) ([]provTypes.Cluster, error) {
+				c.Assert(prov, check.Equals, "fake")
+				return []provTypes.Cluster{
+					{Name: "c1", Provisioner: "fake", Pools: []string{"p1"}, Placement: provTypes.PlacementActivePassive},
+					{Name: "c2", Provisioner: "fake", Pools: []string{"p1", "p2"}, Placement: provTypes.PlacementReplicate},
+					{Name: "c3", Provisioner: "fake", Pools: []string{"p3"}},
+				}, nil
+			},
+		},
+	}
+	err := cs.Update(provTypes.Cluster{Name: "c1", Provisioner: "fake", Pools: []string{"p1"}, Placement: provTypes.PlacementReplicate})
+	c.Assert(err, check.IsNil)
+	err = cs.Update(provTypes.Cluster{Name: "c4", Provisioner: "fake", Pools: []string{"p2", "p3"}, Placement: provTypes.PlacementReplicate})
+	c.Assert(err, check.IsNil)
+	err = cs.Update(provTypes.Cluster{Name: "c4", Provisioner: "fake", Pools: []string{"p3", "p2"}, Placement: provTypes.PlacementActivePassive})
+	c.Assert(err, check.ErrorMatches, `pool "p2" is placed in cluster "c2" with placement "replicate"`)
+}
+
 func (s *S) TestClusterServiceList(c *check.C) {
 	clusters := []provTypes.Cluster{{Name: "cluster1"}, {Name: "cluster2"}}
 	cs := &clusterService{
@@ -434,3 +485,42 @@ func (s *S) TestFindByPoolsNotFound(c *check.C) {
 	_, err := cs.FindByPools(prov, []string{"poolA", "poolB", "poolC", "poolD"})
 	c.Assert(err, check.ErrorMatches, `unable to find cluster for pool "poolD"`)
 }
+
+func (s *S) TestFindAllByPool(c *check.C) {
+	clusters := []provTypes.Cluster{
+		{Name: "cluster1", Provisioner: "kubernetes", Pools: []string{"poolA", "poolB"}, Placement: provTypes.PlacementReplicate},
+		{Name: "cluster2", Provisioner: "kubernetes", Pools: []string{"poolA"}, Placement: provTypes.PlacementReplicate, Priority: 2},
+		{Name: "cluster3", Provisioner: "kubernetes", Default: true},
+	}
+	cs := &clusterService{
+		storage: &provTypes.MockClusterStorage{
+			OnFindByProvisioner: func(prov string) ([]provTypes.Cluster, error) {
+				c.Assert(prov, check.Equals, "kubernetes")
+				result := make([]provTypes.Cluster, len(clusters))
+				copy(result, clusters)
+				return result, nil
+			},
+		},
+	}
+	result, err := cs.FindAllByPool("kubernetes", "poolA")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, []provTypes.Cluster{clusters[1], clusters[0]})
+	result, err = cs.FindAllByPool("kubernetes", "poolB")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, []provTypes.Cluster{clusters[0]})
+	result, err = cs.FindAllByPool("kubernetes", "poolC")
+	c.Assert(err, check.IsNil)
+	c.Assert(result, check.DeepEquals, []provTypes.Cluster{clusters[2]})
+}
+
+func (s *S) TestFindAllByPoolNotFound(c *check.C) {
+	cs := &clusterService{
+		storage: &provTypes.MockClusterStorage{
+			OnFindByProvisioner: func(prov string) ([]provTypes.Cluster, error) {
+				return []provTypes.Cluster{{Name: "cluster1", Provisioner: "kubernetes", Pools: []string{"poolA"}}}, nil
+			},
+		},
+	}
+	_, err := cs.FindAllByPool("kubernetes", "poolB")
+	c.Assert(err, check.Equals, provTypes.ErrNoCluster)
+}
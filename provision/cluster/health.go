@@ -0,0 +1,436 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/servicemanager"
+	provTypes "github.com/tsuru/tsuru/types/provision"
+)
+
+const (
+	defaultHealthCheckInterval    = time.Minute
+	defaultHealthFailureThreshold = 3
+
+	healthChangeEventKind = "cluster-health-change"
+)
+
+var (
+	clusterHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_cluster_healthy",
+		Help: "Whether the last health check of the cluster succeeded.",
+	}, []string{"cluster"})
+
+	clusterNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_cluster_nodes",
+		Help: "The number of nodes in the cluster.",
+	}, []string{"cluster"})
+
+	clusterReadyNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_cluster_ready_nodes",
+		Help: "The number of ready nodes in the cluster.",
+	}, []string{"cluster"})
+
+	clusterClientCertExpiration = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_cluster_client_cert_expiration_timestamp_seconds",
+		Help: "The expiration time of the client certificate of the cluster, in seconds since the epoch.",
+	}, []string{"cluster"})
+)
+
+func init() {
+	prometheus.MustRegister(clusterHealthy, clusterNodes, clusterReadyNodes, clusterClientCertExpiration)
+}
+
+// statusData holds the last known status of a cluster, shared by every tsuru
+// API instance.
+type statusData struct {
+	Name   string `bson:"_id"`
+	Status provTypes.ClusterStatus
+	// HandledHealthy is the health last handled by the provisioner of the
+	// cluster, it differs from Status.Healthy while a change is pending.
+	HandledHealthy bool
+}
+
+func getStatusData(name string) (*statusData, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var data statusData
+	err = conn.ClusterStatuses().FindId(name).One(&data)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			return nil, nil
+		}
+		return nil, errors.WithStack(err)
+	}
+	data.Status.LastCheck = data.Status.LastCheck.UTC()
+	if data.Status.ClientCertExpiration != nil {
+		expiration := data.Status.ClientCertExpiration.UTC()
+		data.Status.ClientCertExpiration = &expiration
+	}
+	return &data, nil
+}
+
+// saveStatus stores the result of a check unless another check stored its
+// result after previous was read, returning whether it was stored.
+func saveStatus(status provTypes.ClusterStatus, previous *statusData) (bool, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	if previous == nil {
+		err = conn.ClusterStatuses().Insert(statusData{
+			Name:           status.Name,
+			Status:         status,
+			HandledHealthy: status.Healthy,
+		})
+		if mgo.IsDup(err) {
+			return false, nil
+		}
+		return err == nil, errors.WithStack(err)
+	}
+	err = conn.ClusterStatuses().Update(bson.M{
+		"_id":              status.Name,
+		"status.lastcheck": previous.Status.LastCheck,
+	}, bson.M{"$set": bson.M{"status": status}})
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, errors.WithStack(err)
+}
+
+func setHandledHealthy(name string, healthy bool) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.ClusterStatuses().UpdateId(name, bson.M{"$set": bson.M{"handledhealthy": healthy}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return errors.WithStack(err)
+}
+
+func healthCheckInterval() time.Duration {
+	interval, _ := config.GetDuration("cluster-health:interval")
+	if interval <= 0 {
+		return defaultHealthCheckInterval
+	}
+	return interval
+}
+
+func healthFailureThreshold() int {
+	threshold, _ := config.GetInt("cluster-health:failure-threshold")
+	if threshold <= 0 {
+		return defaultHealthFailureThreshold
+	}
+	return threshold
+}
+
+// InitializeHealthMonitor starts the periodic health check of every
+// registered cluster.
+func InitializeHealthMonitor() error {
+	monitor := &healthMonitor{interval: healthCheckInterval(), once: &sync.Once{}}
+	monitor.start()
+	shutdown.Register(monitor)
+	return nil
+}
+
+type healthMonitor struct {
+	interval time.Duration
+	once     *sync.Once
+	stopCh   chan struct{}
+}
+
+func (m *healthMonitor) start() {
+	m.once.Do(func() {
+		m.stopCh = make(chan struct{})
+		go m.spin()
+	})
+}
+
+func (m *healthMonitor) Shutdown(ctx context.Context) error {
+	if m.stopCh == nil {
+		return nil
+	}
+	m.stopCh <- struct{}{}
+	m.stopCh = nil
+	m.once = &sync.Once{}
+	return nil
+}
+
+func (m *healthMonitor) spin() {
+	for {
+		err := CheckHealth()
+		if err != nil {
+			log.Errorf("[cluster health] errors checking clusters health: %v", err)
+		}
+		select {
+		case <-m.stopCh:
+			return
+		case <-time.After(m.interval):
+		}
+	}
+}
+
+// CheckHealth updates the status of every registered cluster not checked by
+// another tsuru API instance in the current interval, and lets provisioners
+// handle the clusters whose health changed.
+func CheckHealth() error {
+	clusters, err := servicemanager.Cluster.List()
+	if err != nil {
+		if err == provTypes.ErrNoCluster {
+			return nil
+		}
+		return err
+	}
+	interval := healthCheckInterval()
+	multiErrors := tsuruErrors.NewMultiError()
+	for i := range clusters {
+		clust := &clusters[i]
+		data, err := getStatusData(clust.Name)
+		if err != nil {
+			multiErrors.Add(errors.Wrapf(err, "cluster %q", clust.Name))
+			continue
+		}
+		if data == nil || time.Since(data.Status.LastCheck) >= interval-interval/10 {
+			CheckStatus(clust)
+		}
+		err = handleHealthChange(clust)
+		if err != nil {
+			multiErrors.Add(errors.Wrapf(err, "cluster %q", clust.Name))
+		}
+	}
+	return multiErrors.ToError()
+}
+
+// Status returns the last known status of the cluster, checking it right
+// away when it was never checked.
+func Status(c *provTypes.Cluster) *provTypes.ClusterStatus {
+	data, err := getStatusData(c.Name)
+	if err != nil {
+		log.Errorf("[cluster health] unable to get status of cluster %q: %v", c.Name, err)
+	}
+	if data == nil {
+		return CheckStatus(c)
+	}
+	return &data.Status
+}
+
+// CheckStatus checks the cluster, storing the result as its last known
+// status. A cluster is only considered unhealthy after the configured number
+// of consecutive failed checks.
+func CheckStatus(c *provTypes.Cluster) *provTypes.ClusterStatus {
+	previous, dataErr := getStatusData(c.Name)
+	if dataErr != nil {
+		log.Errorf("[cluster health] unable to get status of cluster %q: %v", c.Name, dataErr)
+	}
+	status, err := checkCluster(c)
+	if status == nil {
+		status = &provTypes.ClusterStatus{}
+	}
+	status.Name = c.Name
+	status.Healthy = true
+	if err != nil {
+		status.Error = err.Error()
+		status.Failures = 1
+		if previous != nil {
+			status.Failures = previous.Status.Failures + 1
+			status.Healthy = previous.Status.Healthy
+		}
+		if status.Failures >= healthFailureThreshold() {
+			status.Healthy = false
+		}
+	}
+	status.LastCheck = time.Now().UTC().Truncate(time.Millisecond)
+	status.ClientCertExpiration, err = ClientCertExpiration(c.ClientCert)
+	if err != nil {
+		log.Errorf("[cluster health] unable to parse client certificate of cluster %q: %v", c.Name, err)
+	}
+	updateMetrics(c, status)
+	if dataErr != nil {
+		return status
+	}
+	saved, err := saveStatus(*status, previous)
+	if err != nil {
+		log.Errorf("[cluster health] unable to store status of cluster %q: %v", c.Name, err)
+	}
+	if saved && previous != nil && previous.Status.Healthy != status.Healthy {
+		if status.Healthy {
+			log.Debugf("[cluster health] cluster %q is healthy again", c.Name)
+		} else {
+			log.Errorf("[cluster health] cluster %q is unhealthy after %d failed checks: %s", c.Name, status.Failures, status.Error)
+		}
+	}
+	return status
+}
+
+// removeStatus discards the last known status of the cluster.
+func removeStatus(name string) {
+	conn, err := db.Conn()
+	if err == nil {
+		err = conn.ClusterStatuses().RemoveId(name)
+		conn.Close()
+	}
+	if err != nil && err != mgo.ErrNotFound {
+		log.Errorf("[cluster health] unable to remove status of cluster %q: %v", name, err)
+	}
+	for _, gauge := range []*prometheus.GaugeVec{clusterHealthy, clusterNodes, clusterReadyNodes, clusterClientCertExpiration} {
+		gauge.DeleteLabelValues(name)
+	}
+}
+
+// handleHealthChange lets the provisioner of the cluster react to a health
+// change not handled yet, e.g. moving units to other clusters. It runs under
+// the lock of an event targeting the cluster, so each change is handled by a
+// single tsuru API instance, and it's retried in the next check when
+// interrupted.
+func handleHealthChange(c *provTypes.Cluster) (err error) {
+	data, err := getStatusData(c.Name)
+	if err != nil || data == nil || data.HandledHealthy == data.Status.Healthy {
+		return err
+	}
+	customData := map[string]interface{}{
+		"healthy": data.Status.Healthy,
+		"pools":   c.Pools,
+	}
+	if data.Status.Error != "" {
+		customData["error"] = data.Status.Error
+	}
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeCluster, Value: c.Name},
+		InternalKind: healthChangeEventKind,
+		CustomData:   customData,
+		Allowed:      event.Allowed(permission.PermClusterReadEvents),
+	})
+	if err != nil {
+		if _, ok := err.(event.ErrEventLocked); ok {
+			return nil
+		}
+		return errors.WithStack(err)
+	}
+	data, err = getStatusData(c.Name)
+	if err != nil || data == nil || data.HandledHealthy == data.Status.Healthy {
+		evt.Abort()
+		return err
+	}
+	defer func() { evt.Done(err) }()
+	prov, err := provision.Get(c.Provisioner)
+	if err != nil {
+		return err
+	}
+	if handler, ok := prov.(provision.ClusterHealthChangeProvisioner); ok {
+		err = handler.ClusterHealthChanged(c, data.Status, evt)
+	}
+	// The change is marked as handled even when the provisioner fails, the
+	// error is recorded in the event and retrying would repeat the work done
+	// for every app.
+	handledErr := setHandledHealthy(c.Name, data.Status.Healthy)
+	if err == nil {
+		err = handledErr
+	}
+	return err
+}
+
+// checkCluster uses the provisioner of the cluster to check it, returning
+// the version and nodes summary when the provisioner is able to report them.
+func checkCluster(c *provTypes.Cluster) (*provTypes.ClusterStatus, error) {
+	prov, err := provision.Get(c.Provisioner)
+	if err != nil {
+		return nil, err
+	}
+	switch p := prov.(type) {
+	case provision.ClusterStatusProvisioner:
+		return p.ClusterStatus(c)
+	case provision.ClusterCheckerProvisioner:
+		return nil, p.CheckCluster(c)
+	}
+	return nil, nil
+}
+
+func updateMetrics(c *provTypes.Cluster, status *provTypes.ClusterStatus) {
+	var healthy float64
+	if status.Healthy {
+		healthy = 1
+	}
+	clusterHealthy.WithLabelValues(c.Name).Set(healthy)
+	clusterNodes.WithLabelValues(c.Name).Set(float64(status.Nodes.Total))
+	clusterReadyNodes.WithLabelValues(c.Name).Set(float64(status.Nodes.Ready))
+	if status.ClientCertExpiration != nil {
+		clusterClientCertExpiration.WithLabelValues(c.Name).Set(float64(status.ClientCertExpiration.Unix()))
+	} else {
+		clusterClientCertExpiration.DeleteLabelValues(c.Name)
+	}
+}
+
+// ClientCertExpiration returns the expiration time of the PEM encoded client
+// certificate, or nil when there is no certificate.
+func ClientCertExpiration(clientCert []byte) (*time.Time, error) {
+	if len(clientCert) == 0 {
+		return nil, nil
+	}
+	block, _ := pem.Decode(clientCert)
+	if block == nil {
+		return nil, errors.New("no PEM data found in certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	expiration := cert.NotAfter.UTC()
+	return &expiration, nil
+}
+
+// ValidateCredentials checks that the certificates of the cluster are valid
+// and that the cluster is reachable using them.
+func ValidateCredentials(c *provTypes.Cluster) error {
+	if len(c.CaCert) > 0 && !x509.NewCertPool().AppendCertsFromPEM(c.CaCert) {
+		return errors.WithStack(&tsuruErrors.ValidationError{Message: "invalid CA certificate"})
+	}
+	if len(c.ClientCert) > 0 || len(c.ClientKey) > 0 {
+		_, err := tls.X509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			msg := fmt.Sprintf("invalid client certificate and key: %v", err)
+			return errors.WithStack(&tsuruErrors.ValidationError{Message: msg})
+		}
+		expiration, err := ClientCertExpiration(c.ClientCert)
+		if err != nil {
+			return errors.WithStack(&tsuruErrors.ValidationError{Message: err.Error()})
+		}
+		if expiration.Before(time.Now()) {
+			msg := fmt.Sprintf("client certificate expired at %s", expiration.Format(time.RFC3339))
+			return errors.WithStack(&tsuruErrors.ValidationError{Message: msg})
+		}
+	}
+	_, err := checkCluster(c)
+	if err != nil {
+		msg := fmt.Sprintf("unable to connect to cluster: %v", err)
+		return errors.WithStack(&tsuruErrors.ValidationError{Message: msg})
+	}
+	return nil
+}
@@ -0,0 +1,274 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cluster
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/event/eventtest"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	servicemock "github.com/tsuru/tsuru/servicemanager/mock"
+	provTypes "github.com/tsuru/tsuru/types/provision"
+	"gopkg.in/check.v1"
+)
+
+func generateClientCert(c *check.C, notAfter time.Time) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, check.IsNil)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "tsuru"},
+		NotBefore:    notAfter.Add(-24 * time.Hour),
+		NotAfter:     notAfter,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	c.Assert(err, check.IsNil)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	c.Assert(err, check.IsNil)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return certPEM, keyPEM
+}
+
+func (s *S) TestCheckStatus(c *check.C) {
+	notAfter := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	cert, _ := generateClientCert(c, notAfter)
+	provisiontest.ProvisionerInstance.SetClusterStatus("c1", provTypes.ClusterStatus{
+		Version: "v1.10.1",
+		Nodes:   provTypes.ClusterNodesStatus{Total: 3, Ready: 2},
+	})
+	clust := &provTypes.Cluster{Name: "c1", Provisioner: "fake", ClientCert: cert}
+	status := CheckStatus(clust)
+	c.Assert(status.Name, check.Equals, "c1")
+	c.Assert(status.Healthy, check.Equals, true)
+	c.Assert(status.Error, check.Equals, "")
+	c.Assert(status.Version, check.Equals, "v1.10.1")
+	c.Assert(status.Nodes, check.Equals, provTypes.ClusterNodesStatus{Total: 3, Ready: 2})
+	c.Assert(status.ClientCertExpiration, check.NotNil)
+	c.Assert(status.ClientCertExpiration.Equal(notAfter), check.Equals, true)
+	c.Assert(status.LastCheck.IsZero(), check.Equals, false)
+	data, err := getStatusData("c1")
+	c.Assert(err, check.IsNil)
+	c.Assert(data.Status, check.DeepEquals, *status)
+	c.Assert(data.HandledHealthy, check.Equals, true)
+}
+
+func (s *S) TestCheckStatusUnhealthy(c *check.C) {
+	provisiontest.ProvisionerInstance.PrepareFailure("ClusterStatus", errors.New("connection refused"))
+	status := CheckStatus(&provTypes.Cluster{Name: "c1", Provisioner: "fake"})
+	c.Assert(status.Healthy, check.Equals, false)
+	c.Assert(status.Error, check.Equals, "connection refused")
+	c.Assert(status.ClientCertExpiration, check.IsNil)
+}
+
+func (s *S) TestCheckStatusUnhealthyAfterFailureThreshold(c *check.C) {
+	clust := &provTypes.Cluster{Name: "c1", Provisioner: "fake"}
+	for i := 1; i <= 3; i++ {
+		provisiontest.ProvisionerInstance.PrepareFailure("ClusterStatus", errors.New("connection refused"))
+		status := CheckStatus(clust)
+		c.Assert(status.Failures, check.Equals, i)
+		c.Assert(status.Error, check.Equals, "connection refused")
+		c.Assert(status.Healthy, check.Equals, i < 3)
+	}
+	data, err := getStatusData("c1")
+	c.Assert(err, check.IsNil)
+	c.Assert(data.Status.Healthy, check.Equals, false)
+	c.Assert(data.HandledHealthy, check.Equals, true)
+	status := CheckStatus(clust)
+	c.Assert(status.Healthy, check.Equals, true)
+	c.Assert(status.Failures, check.Equals, 0)
+	c.Assert(status.Error, check.Equals, "")
+}
+
+func (s *S) TestCheckStatusFailureThresholdFromConfig(c *check.C) {
+	config.Set("cluster-health:failure-threshold", 1)
+	defer config.Unset("cluster-health:failure-threshold")
+	provisiontest.ProvisionerInstance.PrepareFailure("ClusterStatus", errors.New("connection refused"))
+	status := CheckStatus(&provTypes.Cluster{Name: "c1", Provisioner: "fake"})
+	c.Assert(status.Healthy, check.Equals, false)
+}
+
+func (s *S) TestSaveStatusKeepsNewerStatus(c *check.C) {
+	CheckStatus(&provTypes.Cluster{Name: "c1", Provisioner: "fake"})
+	previous, err := getStatusData("c1")
+	c.Assert(err, check.IsNil)
+	newer := previous.Status
+	newer.LastCheck = newer.LastCheck.Add(time.Second)
+	saved, err := saveStatus(newer, previous)
+	c.Assert(err, check.IsNil)
+	c.Assert(saved, check.Equals, true)
+	stale := previous.Status
+	stale.Version = "stale"
+	stale.LastCheck = stale.LastCheck.Add(2 * time.Second)
+	saved, err = saveStatus(stale, previous)
+	c.Assert(err, check.IsNil)
+	c.Assert(saved, check.Equals, false)
+	saved, err = saveStatus(stale, nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(saved, check.Equals, false)
+	data, err := getStatusData("c1")
+	c.Assert(err, check.IsNil)
+	c.Assert(data.Status, check.DeepEquals, newer)
+}
+
+func (s *S) TestCheckHealthHandlesHealthChanges(c *check.C) {
+	config.Set("cluster-health:failure-threshold", 2)
+	defer config.Unset("cluster-health:failure-threshold")
+	clust := provTypes.Cluster{Name: "c1", Provisioner: "fake", Pools: []string{"p1"}}
+	var mockService servicemock.MockService
+	servicemock.SetMockService(&mockService)
+	mockService.Cluster.OnList = func() ([]provTypes.Cluster, error) {
+		return []provTypes.Cluster{clust}, nil
+	}
+	err := CheckHealth()
+	c.Assert(err, check.IsNil)
+	provisiontest.ProvisionerInstance.PrepareFailure("ClusterStatus", errors.New("connection refused"))
+	CheckStatus(&clust)
+	err = CheckHealth()
+	c.Assert(err, check.IsNil)
+	c.Assert(provisiontest.ProvisionerInstance.ClusterHealthChanges(), check.HasLen, 0)
+	provisiontest.ProvisionerInstance.PrepareFailure("ClusterStatus", errors.New("connection refused"))
+	CheckStatus(&clust)
+	err = CheckHealth()
+	c.Assert(err, check.IsNil)
+	err = CheckHealth()
+	c.Assert(err, check.IsNil)
+	changes := provisiontest.ProvisionerInstance.ClusterHealthChanges()
+	c.Assert(changes, check.HasLen, 1)
+	c.Assert(changes[0].Healthy, check.Equals, false)
+	c.Assert(changes[0].Failures, check.Equals, 2)
+	c.Assert(eventtest.EventDesc{
+		Target: event.Target{Type: event.TargetTypeCluster, Value: "c1"},
+		Kind:   "cluster-health-change",
+		StartCustomData: map[string]interface{}{
+			"healthy": false,
+			"error":   "connection refused",
+			"pools":   []interface{}{"p1"},
+		},
+	}, eventtest.HasEvent)
+	CheckStatus(&clust)
+	err = CheckHealth()
+	c.Assert(err, check.IsNil)
+	changes = provisiontest.ProvisionerInstance.ClusterHealthChanges()
+	c.Assert(changes, check.HasLen, 2)
+	c.Assert(changes[1].Healthy, check.Equals, true)
+}
+
+func (s *S) TestHandleHealthChangeWaitsForClusterLock(c *check.C) {
+	config.Set("cluster-health:failure-threshold", 1)
+	defer config.Unset("cluster-health:failure-threshold")
+	clust := &provTypes.Cluster{Name: "c1", Provisioner: "fake"}
+	CheckStatus(clust)
+	provisiontest.ProvisionerInstance.PrepareFailure("ClusterStatus", errors.New("connection refused"))
+	CheckStatus(clust)
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeCluster, Value: "c1"},
+		InternalKind: "other",
+		Allowed:      event.Allowed(permission.PermClusterReadEvents),
+	})
+	c.Assert(err, check.IsNil)
+	err = handleHealthChange(clust)
+	c.Assert(err, check.IsNil)
+	c.Assert(provisiontest.ProvisionerInstance.ClusterHealthChanges(), check.HasLen, 0)
+	err = evt.Done(nil)
+	c.Assert(err, check.IsNil)
+	err = handleHealthChange(clust)
+	c.Assert(err, check.IsNil)
+	c.Assert(provisiontest.ProvisionerInstance.ClusterHealthChanges(), check.HasLen, 1)
+	data, err := getStatusData("c1")
+	c.Assert(err, check.IsNil)
+	c.Assert(data.HandledHealthy, check.Equals, false)
+}
+
+func (s *S) TestStatusUsesLastCheck(c *check.C) {
+	clust := &provTypes.Cluster{Name: "c1", Provisioner: "fake"}
+	provisiontest.ProvisionerInstance.SetClusterStatus("c1", provTypes.ClusterStatus{Version: "v1"})
+	status := Status(clust)
+	c.Assert(status.Version, check.Equals, "v1")
+	provisiontest.ProvisionerInstance.SetClusterStatus("c1", provTypes.ClusterStatus{Version: "v2"})
+	status = Status(clust)
+	c.Assert(status.Version, check.Equals, "v1")
+	status = CheckStatus(clust)
+	c.Assert(status.Version, check.Equals, "v2")
+}
+
+func (s *S) TestClusterServiceDeleteRemovesStatus(c *check.C) {
+	clust := provTypes.Cluster{Name: "c1", Provisioner: "fake"}
+	CheckStatus(&clust)
+	cs := &clusterService{storage: &provTypes.MockClusterStorage{}}
+	err := cs.Delete(clust)
+	c.Assert(err, check.IsNil)
+	data, err := getStatusData("c1")
+	c.Assert(err, check.IsNil)
+	c.Assert(data, check.IsNil)
+}
+
+func (s *S) TestClientCertExpiration(c *check.C) {
+	expiration, err := ClientCertExpiration(nil)
+	c.Assert(err, check.IsNil)
+	c.Assert(expiration, check.IsNil)
+	_, err = ClientCertExpiration([]byte("invalid"))
+	c.Assert(err, check.ErrorMatches, "no PEM data found in certificate")
+	notAfter := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	cert, _ := generateClientCert(c, notAfter)
+	expiration, err = ClientCertExpiration(cert)
+	c.Assert(err, check.IsNil)
+	c.Assert(*expiration, check.Equals, notAfter)
+}
+
+func (s *S) TestValidateCredentials(c *check.C) {
+	cert, key := generateClientCert(c, time.Now().Add(time.Hour))
+	otherCert, _ := generateClientCert(c, time.Now().Add(time.Hour))
+	expiredCert, expiredKey := generateClientCert(c, time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC))
+	tests := []struct {
+		cluster provTypes.Cluster
+		err     string
+	}{
+		{
+			cluster: provTypes.Cluster{Name: "c1", Provisioner: "fake", CaCert: cert, ClientCert: cert, ClientKey: key},
+		},
+		{
+			cluster: provTypes.Cluster{Name: "c1", Provisioner: "fake", CaCert: []byte("invalid")},
+			err:     "invalid CA certificate",
+		},
+		{
+			cluster: provTypes.Cluster{Name: "c1", Provisioner: "fake", ClientCert: otherCert, ClientKey: key},
+			err:     "invalid client certificate and key: .*",
+		},
+		{
+			cluster: provTypes.Cluster{Name: "c1", Provisioner: "fake", ClientCert: expiredCert, ClientKey: expiredKey},
+			err:     "client certificate expired at 2018-01-02T03:04:05Z",
+		},
+	}
+	for _, tt := range tests {
+		err := ValidateCredentials(&tt.cluster)
+		if tt.err == "" {
+			c.Check(err, check.IsNil)
+		} else {
+			c.Check(err, check.ErrorMatches, tt.err)
+		}
+	}
+}
+
+func (s *S) TestValidateCredentialsUnreachableCluster(c *check.C) {
+	provisiontest.ProvisionerInstance.PrepareFailure("ClusterStatus", errors.New("x509: certificate signed by unknown authority"))
+	err := ValidateCredentials(&provTypes.Cluster{Name: "c1", Provisioner: "fake"})
+	c.Assert(err, check.ErrorMatches, "unable to connect to cluster: x509: certificate signed by unknown authority")
+	data, err := getStatusData("c1")
+	c.Assert(err, check.IsNil)
+	c.Assert(data, check.IsNil)
+}
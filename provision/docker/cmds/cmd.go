@@ -7,6 +7,7 @@ package cmds
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"sort"
 	"strconv"
@@ -76,7 +77,10 @@ on the chosen pool.`,
 }
 
 func (c *dockerLogUpdate) Run(context *cmd.Context, client *cmd.Client) error {
-	context.RawOutput()
+	err := context.StreamOutput()
+	if err != nil {
+		return err
+	}
 	if c.restart {
 		extra := ""
 		if c.pool != "" {
@@ -140,37 +144,46 @@ func (c *dockerLogInfo) Run(context *cmd.Context, client *cmd.Client) error {
 		return err
 	}
 	defer response.Body.Close()
-	var conf map[string]types.DockerLogConfig
+	var conf dockerLogConfigs
 	err = json.NewDecoder(response.Body).Decode(&conf)
 	if err != nil {
 		return err
 	}
+	return context.Render(conf)
+}
+
+// dockerLogConfigs is the log config of each pool, keyed by pool name. The
+// default config uses the empty key.
+type dockerLogConfigs map[string]types.DockerLogConfig
+
+func (conf dockerLogConfigs) RenderTable(w io.Writer) error {
 	baseConf := conf[""]
-	delete(conf, "")
 	t := tablecli.Table{Headers: tablecli.Row([]string{"Name", "Value"})}
-	fmt.Fprintf(context.Stdout, "Log driver [default]: %s\n", baseConf.Driver)
+	fmt.Fprintf(w, "Log driver [default]: %s\n", baseConf.Driver)
 	for optName, optValue := range baseConf.LogOpts {
 		t.AddRow(tablecli.Row([]string{optName, optValue}))
 	}
 	if t.Rows() > 0 {
 		t.Sort()
-		context.Stdout.Write(t.Bytes())
+		w.Write(t.Bytes())
 	}
-	poolNames := make([]string, 0, len(baseConf.LogOpts))
+	poolNames := make([]string, 0, len(conf))
 	for poolName := range conf {
-		poolNames = append(poolNames, poolName)
+		if poolName != "" {
+			poolNames = append(poolNames, poolName)
+		}
 	}
 	sort.Strings(poolNames)
 	for _, poolName := range poolNames {
 		poolConf := conf[poolName]
 		t := tablecli.Table{Headers: tablecli.Row([]string{"Name", "Value"})}
-		fmt.Fprintf(context.Stdout, "\nLog driver [pool %s]: %s\n", poolName, poolConf.Driver)
+		fmt.Fprintf(w, "\nLog driver [pool %s]: %s\n", poolName, poolConf.Driver)
 		for optName, optValue := range poolConf.LogOpts {
 			t.AddRow(tablecli.Row([]string{optName, optValue}))
 		}
 		if t.Rows() > 0 {
 			t.Sort()
-			context.Stdout.Write(t.Bytes())
+			w.Write(t.Bytes())
 		}
 	}
 	return nil
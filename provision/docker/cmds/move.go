@@ -34,7 +34,10 @@ This command will go through the following steps:
 }
 
 func (c *moveContainersCmd) Run(context *cmd.Context, client *cmd.Client) error {
-	context.RawOutput()
+	err := context.StreamOutput()
+	if err != nil {
+		return err
+	}
 	u, err := cmd.GetURL("/docker/containers/move")
 	if err != nil {
 		return err
@@ -69,7 +72,10 @@ from its previous host.`,
 }
 
 func (c *moveContainerCmd) Run(context *cmd.Context, client *cmd.Client) error {
-	context.RawOutput()
+	err := context.StreamOutput()
+	if err != nil {
+		return err
+	}
 	u, err := cmd.GetURL(fmt.Sprintf("/docker/container/%s/move", context.Args[0]))
 	if err != nil {
 		return err
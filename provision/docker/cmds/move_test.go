@@ -43,6 +43,22 @@ func (s *S) TestMoveContainersRun(c *check.C) {
 	c.Assert(stdout.String(), check.Equals, expected)
 }
 
+func (s *S) TestMoveContainersRunStructuredOutput(c *check.C) {
+	var stdout, stderr bytes.Buffer
+	context := cmd.Context{
+		Stdout:       &stdout,
+		Stderr:       &stderr,
+		Args:         []string{"from", "to"},
+		OutputFormat: cmd.OutputJSON,
+	}
+	manager := cmd.NewManager("admin", "0.1", "admin-ver", &stdout, &stderr, nil, nil)
+	client := cmd.NewClient(&http.Client{}, nil, manager)
+	moveCmd := moveContainersCmd{}
+	err := moveCmd.Run(&context, client)
+	c.Assert(err, check.ErrorMatches, `output format "json" is not supported by this command, .*`)
+	c.Assert(stdout.String(), check.Equals, "")
+}
+
 func (s *S) TestMoveContainerRun(c *check.C) {
 	var stdout, stderr bytes.Buffer
 	context := cmd.Context{
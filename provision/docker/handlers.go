@@ -26,6 +26,7 @@ import (
 	_ "github.com/tsuru/tsuru/iaas/digitalocean"
 	_ "github.com/tsuru/tsuru/iaas/dockermachine"
 	_ "github.com/tsuru/tsuru/iaas/ec2"
+	_ "github.com/tsuru/tsuru/iaas/openstack"
 	tsuruIo "github.com/tsuru/tsuru/io"
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/provision"
@@ -182,14 +183,29 @@ func moveContainersPermissionContexts(from, to string) ([]permTypes.PermissionCo
 	return permContexts, nil
 }
 
+// title: bs env set (deprecated)
+// path: /docker/bs/env
+// method: POST
+// responses:
+//   500: Route deprecated
 func bsEnvSetHandler(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 	return errors.New("this route is deprecated, please use POST /docker/nodecontainer/{name} (node-container-update command)")
 }
 
+// title: bs config get (deprecated)
+// path: /docker/bs
+// method: GET
+// responses:
+//   500: Route deprecated
 func bsConfigGetHandler(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 	return errors.New("this route is deprecated, please use GET /docker/nodecontainer/{name} (node-container-info command)")
 }
 
+// title: bs upgrade (deprecated)
+// path: /docker/bs/upgrade
+// method: POST
+// responses:
+//   500: Route deprecated
 func bsUpgradeHandler(w http.ResponseWriter, r *http.Request, t auth.Token) error {
 	return errors.New("this route is deprecated, please use POST /docker/nodecontainer/{name}/upgrade (node-container-upgrade command)")
 }
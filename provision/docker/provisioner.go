@@ -25,6 +25,7 @@ import (
 	"github.com/tsuru/tsuru/app"
 	"github.com/tsuru/tsuru/app/image"
 	"github.com/tsuru/tsuru/app/image/gc"
+	internalConfig "github.com/tsuru/tsuru/config"
 	"github.com/tsuru/tsuru/db"
 	"github.com/tsuru/tsuru/db/storage"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
@@ -126,13 +127,12 @@ func (p *dockerProvisioner) initDockerCluster() error {
 		p.collectionName = name
 	}
 	var nodes []cluster.Node
-	TotalMemoryMetadata, _ := config.GetString("docker:scheduler:total-memory-metadata")
-	maxUsedMemory, _ := config.GetFloat("docker:scheduler:max-used-memory")
-	p.scheduler = &segregatedScheduler{
-		maxMemoryRatio:      float32(maxUsedMemory),
-		TotalMemoryMetadata: TotalMemoryMetadata,
-		provisioner:         p,
-	}
+	p.scheduler = &segregatedScheduler{provisioner: p}
+	p.reloadSchedulerConfig()
+	internalConfig.Subscribe("docker scheduler", []string{
+		"docker:scheduler:total-memory-metadata",
+		"docker:scheduler:max-used-memory",
+	}, p.reloadSchedulerConfig)
 	caPath, _ := config.GetString("docker:tls:root-path")
 	p.cluster, err = cluster.New(p.scheduler, p.storage, caPath, nodes...)
 	if err != nil {
@@ -171,6 +171,13 @@ func (p *dockerProvisioner) initDockerCluster() error {
 	return nil
 }
 
+func (p *dockerProvisioner) reloadSchedulerConfig() error {
+	totalMemoryMetadata, _ := config.GetString("docker:scheduler:total-memory-metadata")
+	maxUsedMemory, _ := config.GetFloat("docker:scheduler:max-used-memory")
+	p.scheduler.setMemorySettings(float32(maxUsedMemory), totalMemoryMetadata)
+	return nil
+}
+
 func (p *dockerProvisioner) ActionLimiter() provision.ActionLimiter {
 	return p.actionLimiter
 }
@@ -182,9 +189,10 @@ func (p *dockerProvisioner) cloneProvisioner(ignoredContainers []container.Conta
 	for i := range ignoredContainers {
 		containerIds[i] = ignoredContainers[i].ID
 	}
+	maxMemoryRatio, totalMemoryMetadata := p.scheduler.memorySettings()
 	overridenProvisioner.scheduler = &segregatedScheduler{
-		maxMemoryRatio:      p.scheduler.maxMemoryRatio,
-		TotalMemoryMetadata: p.scheduler.TotalMemoryMetadata,
+		maxMemoryRatio:      maxMemoryRatio,
+		TotalMemoryMetadata: totalMemoryMetadata,
 		provisioner:         &overridenProvisioner,
 		ignoredContainers:   containerIds,
 	}
@@ -217,9 +225,10 @@ func (p *dockerProvisioner) dryMode(ignoredContainers []container.Container) (*d
 	for i := range ignoredContainers {
 		containerIds[i] = ignoredContainers[i].ID
 	}
+	maxMemoryRatio, totalMemoryMetadata := p.scheduler.memorySettings()
 	overridenProvisioner.scheduler = &segregatedScheduler{
-		maxMemoryRatio:      p.scheduler.maxMemoryRatio,
-		TotalMemoryMetadata: p.scheduler.TotalMemoryMetadata,
+		maxMemoryRatio:      maxMemoryRatio,
+		TotalMemoryMetadata: totalMemoryMetadata,
 		provisioner:         overridenProvisioner,
 		ignoredContainers:   containerIds,
 	}
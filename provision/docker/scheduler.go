@@ -25,6 +25,7 @@ import (
 
 type segregatedScheduler struct {
 	hostMutex           sync.Mutex
+	settingsMutex       sync.RWMutex
 	maxMemoryRatio      float32
 	TotalMemoryMetadata string
 	provisioner         *dockerProvisioner
@@ -34,6 +35,19 @@ type segregatedScheduler struct {
 	ignoredContainers []string
 }
 
+func (s *segregatedScheduler) memorySettings() (float32, string) {
+	s.settingsMutex.RLock()
+	defer s.settingsMutex.RUnlock()
+	return s.maxMemoryRatio, s.TotalMemoryMetadata
+}
+
+func (s *segregatedScheduler) setMemorySettings(maxMemoryRatio float32, totalMemoryMetadata string) {
+	s.settingsMutex.Lock()
+	defer s.settingsMutex.Unlock()
+	s.maxMemoryRatio = maxMemoryRatio
+	s.TotalMemoryMetadata = totalMemoryMetadata
+}
+
 func (s *segregatedScheduler) Schedule(c *cluster.Cluster, opts *docker.CreateContainerOptions, schedulerOpts cluster.SchedulerOptions) (cluster.Node, error) {
 	schedOpts, ok := schedulerOpts.(*container.SchedulerOpts)
 	if !ok {
@@ -58,7 +72,8 @@ func (s *segregatedScheduler) Schedule(c *cluster.Cluster, opts *docker.CreateCo
 		return cluster.Node{}, &container.SchedulerError{Base: err}
 	}
 	nodes = filterNodes(nodes, filterNodesMap)
-	nodes, err = s.filterByMemoryUsage(a, nodes, s.maxMemoryRatio, s.TotalMemoryMetadata)
+	maxMemoryRatio, totalMemoryMetadata := s.memorySettings()
+	nodes, err = s.filterByMemoryUsage(a, nodes, maxMemoryRatio, totalMemoryMetadata)
 	if err != nil {
 		return cluster.Node{}, &container.SchedulerError{Base: err}
 	}
@@ -86,11 +86,17 @@ var updateAppCR = action.Action{
 	Name: "update-app-custom-resource",
 	Forward: func(ctx action.FWContext) (action.Result, error) {
 		params := ctx.Params[0].(updatePipelineParams)
-		client, err := clusterForPool(params.old.GetPool())
+		placement, err := placementForPool(params.old.GetPool())
 		if err != nil {
 			return nil, err
 		}
-		return nil, updateAppNamespace(client, params.old.GetName(), client.PoolNamespace(params.new.GetPool()))
+		for _, client := range placement.clients {
+			err = updateAppNamespace(client, params.old.GetName(), client.PoolNamespace(params.new.GetPool()))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
 	},
 	Backward: func(ctx action.BWContext) {
 		params := ctx.Params[0].(updatePipelineParams)
@@ -101,31 +107,39 @@ var updateAppCR = action.Action{
 }
 
 func backwardCR(params updatePipelineParams) error {
-	client, err := clusterForPool(params.old.GetPool())
+	placement, err := placementForPool(params.old.GetPool())
 	if err != nil {
 		return err
 	}
-	return updateAppNamespace(client, params.old.GetName(), client.PoolNamespace(params.old.GetPool()))
+	for _, client := range placement.clients {
+		err = updateAppNamespace(client, params.old.GetName(), client.PoolNamespace(params.old.GetPool()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 var removeOldAppResources = action.Action{
 	Name: "remove-old-app-resources",
 	Forward: func(ctx action.FWContext) (action.Result, error) {
 		params := ctx.Params[0].(updatePipelineParams)
-		client, err := clusterForPool(params.old.GetPool())
+		placement, err := placementForPool(params.old.GetPool())
 		if err != nil {
 			log.Errorf("failed to remove old resources: %v", err)
 			return nil, nil
 		}
-		oldAppCR, err := getAppCR(client, params.old.GetName())
-		if err != nil {
-			log.Errorf("failed to remove old resources: %v", err)
-			return nil, nil
-		}
-		oldAppCR.Spec.NamespaceName = client.PoolNamespace(params.old.GetPool())
-		err = params.p.removeResources(client, oldAppCR)
-		if err != nil {
-			log.Errorf("failed to remove old resources: %v", err)
+		for _, client := range placement.clients {
+			oldAppCR, err := getAppCR(client, params.old.GetName())
+			if err != nil {
+				log.Errorf("failed to remove old resources: %v", err)
+				continue
+			}
+			oldAppCR.Spec.NamespaceName = client.PoolNamespace(params.old.GetPool())
+			err = params.p.removeResources(client, oldAppCR)
+			if err != nil {
+				log.Errorf("failed to remove old resources: %v", err)
+			}
 		}
 		return nil, nil
 	},
@@ -104,8 +104,8 @@ func (c *KubeClient) DownloadFromContainer(app provision.App, imageName string)
 	return reader, nil
 }
 
-func (c *KubeClient) BuildImage(name string, image string, inputStream io.Reader, output io.Writer, ctx context.Context) error {
-	buildPodName := fmt.Sprintf("%s-image-build", name)
+func (c *KubeClient) BuildImage(opts provision.BuildImageOpts) error {
+	buildPodName := fmt.Sprintf("%s-image-build", opts.Name)
 	client, err := clusterForPoolOrAny("")
 	if err != nil {
 		return err
@@ -114,10 +114,10 @@ func (c *KubeClient) BuildImage(name string, image string, inputStream io.Reader
 	params := createPodParams{
 		client:            client,
 		podName:           buildPodName,
-		destinationImages: []string{image},
+		destinationImages: []string{opts.Image},
 		inputFile:         "/data/context.tar.gz",
-		attachInput:       inputStream,
-		attachOutput:      output,
+		attachInput:       opts.Input,
+		attachOutput:      opts.Output,
 	}
-	return createImageBuildPod(ctx, params)
+	return createImageBuildPod(opts.Ctx, params)
 }
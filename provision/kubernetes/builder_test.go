@@ -16,6 +16,7 @@ import (
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/safe"
 	provTypes "github.com/tsuru/tsuru/types/provision"
 	"gopkg.in/check.v1"
@@ -314,7 +315,13 @@ func (s *S) TestBuildImage(c *check.C) {
 	inputStream := strings.NewReader("FROM tsuru/myplatform")
 	client := KubeClient{}
 	out := &safe.Buffer{}
-	err := client.BuildImage("myplatform", "tsuru/myplatform:latest", ioutil.NopCloser(inputStream), out, context.Background())
+	err := client.BuildImage(provision.BuildImageOpts{
+		Name:   "myplatform",
+		Image:  "tsuru/myplatform:latest",
+		Input:  ioutil.NopCloser(inputStream),
+		Output: out,
+		Ctx:    context.Background(),
+	})
 	c.Assert(err, check.IsNil)
 }
 
@@ -348,7 +355,13 @@ func (s *S) TestBuildImageNoDefaultPool(c *check.C) {
 	inputStream := strings.NewReader("FROM tsuru/myplatform")
 	client := KubeClient{}
 	out := &safe.Buffer{}
-	err := client.BuildImage("myplatform", "tsuru/myplatform:latest", ioutil.NopCloser(inputStream), out, context.Background())
+	err := client.BuildImage(provision.BuildImageOpts{
+		Name:   "myplatform",
+		Image:  "tsuru/myplatform:latest",
+		Input:  ioutil.NopCloser(inputStream),
+		Output: out,
+		Ctx:    context.Background(),
+	})
 	c.Assert(err, check.IsNil)
 }
 
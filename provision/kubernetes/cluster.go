@@ -258,9 +258,29 @@ func clustersForApps(apps []provision.App) ([]clusterApp, error) {
 	if err != nil {
 		return nil, err
 	}
+	placements := map[string]*poolPlacement{}
 	for _, a := range apps {
 		poolName := a.GetPool()
 		cluster := clusterPoolMap[poolName]
+		if cluster.Placement != "" {
+			placement, inMap := placements[poolName]
+			if !inMap {
+				placement, err = placementForPool(poolName)
+				if err != nil {
+					return nil, err
+				}
+				placements[poolName] = placement
+			}
+			for _, client := range placement.clients {
+				mapItem, inMap := clusterClientMap[client.Name]
+				if !inMap {
+					mapItem = clusterApp{client: client}
+				}
+				mapItem.apps = append(mapItem.apps, a)
+				clusterClientMap[client.Name] = mapItem
+			}
+			continue
+		}
 		mapItem, inMap := clusterClientMap[cluster.Name]
 		if !inMap {
 			cli, err := NewClusterClient(&cluster)
@@ -281,12 +301,34 @@ func clustersForApps(apps []provision.App) ([]clusterApp, error) {
 	return result, nil
 }
 
+// clusterForPool returns the cluster with the highest priority among the
+// healthy clusters serving the pool.
 func clusterForPool(pool string) (*ClusterClient, error) {
-	clust, err := servicemanager.Cluster.FindByPool(provisionerName, pool)
+	placement, err := placementForPool(pool)
+	if err != nil {
+		return nil, err
+	}
+	return placement.primary(), nil
+}
+
+// clustersForPool returns every cluster serving the pool, ordered by
+// priority, regardless of their health.
+func clustersForPool(pool string) ([]*ClusterClient, error) {
+	clusters, err := servicemanager.Cluster.FindAllByPool(provisionerName, pool)
 	if err != nil {
 		return nil, err
 	}
-	return NewClusterClient(clust)
+	if len(clusters) == 0 {
+		return nil, provTypes.ErrNoCluster
+	}
+	clients := make([]*ClusterClient, len(clusters))
+	for i := range clusters {
+		clients[i], err = NewClusterClient(&clusters[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return clients, nil
 }
 
 func clusterForPoolOrAny(pool string) (*ClusterClient, error) {
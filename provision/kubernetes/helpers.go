@@ -131,6 +131,10 @@ func volumeClaimName(name string) string {
 	return fmt.Sprintf("%s-tsuru-claim", name)
 }
 
+func volumeSnapshotName(volume, snapshot string) string {
+	return fmt.Sprintf("%s-snapshot-%s", volume, snapshot)
+}
+
 func registrySecretName(registry string) string {
 	registry = validKubeName(registry)
 	return fmt.Sprintf("registry-%s", registry)
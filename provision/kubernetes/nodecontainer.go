@@ -27,10 +27,14 @@ import (
 const alphaAffinityAnnotation = "scheduler.alpha.kubernetes.io/affinity"
 
 type nodeContainerManager struct {
-	app provision.App
+	app    provision.App
+	client *ClusterClient
 }
 
 func (m *nodeContainerManager) DeployNodeContainer(config *nodecontainer.NodeContainerConfig, pool string, filter servicecommon.PoolFilter, placementOnly bool) error {
+	if m.client != nil {
+		return m.deployNodeContainerForCluster(m.client, *config, pool, filter, placementOnly)
+	}
 	if m.app != nil {
 		client, err := clusterForPool(m.app.GetPool())
 		if err != nil {
@@ -238,9 +242,9 @@ func (m *nodeContainerManager) deployNodeContainerForCluster(client *ClusterClie
 	return errors.WithStack(err)
 }
 
-func ensureNodeContainers(a provision.App) error {
+func ensureNodeContainers(client *ClusterClient) error {
 	m := nodeContainerManager{
-		app: a,
+		client: client,
 	}
 	buf := &bytes.Buffer{}
 	err := servicecommon.EnsureNodeContainersCreated(&m, buf)
@@ -0,0 +1,331 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/cluster"
+	"github.com/tsuru/tsuru/provision/servicecommon"
+	"github.com/tsuru/tsuru/servicemanager"
+	"github.com/tsuru/tsuru/set"
+	appTypes "github.com/tsuru/tsuru/types/app"
+	provTypes "github.com/tsuru/tsuru/types/provision"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const placementLockWaitTimeout = time.Minute
+
+var errNoHealthyCluster = errors.New("no healthy cluster")
+
+// clusterStatus returns the last known status of a cluster, as reported by
+// the cluster health monitor.
+var clusterStatus = cluster.Status
+
+// poolPlacement holds the healthy clusters serving a pool, ordered by
+// priority, and the policy used to place units among them. Unhealthy
+// clusters of the pool are still targeted when removing apps and processes.
+type poolPlacement struct {
+	policy    provTypes.PlacementPolicy
+	clients   []*ClusterClient
+	unhealthy []*ClusterClient
+}
+
+func placementForPool(pool string) (*poolPlacement, error) {
+	clust, err := servicemanager.Cluster.FindByPool(provisionerName, pool)
+	if err != nil {
+		return nil, err
+	}
+	if clust.Placement == "" {
+		client, err := NewClusterClient(clust)
+		if err != nil {
+			return nil, err
+		}
+		return &poolPlacement{clients: []*ClusterClient{client}}, nil
+	}
+	clusters, err := servicemanager.Cluster.FindAllByPool(provisionerName, pool)
+	if err != nil {
+		return nil, err
+	}
+	placement := &poolPlacement{policy: clust.Placement}
+	for i := range clusters {
+		client, err := NewClusterClient(&clusters[i])
+		if err != nil {
+			return nil, err
+		}
+		if clusterStatus(&clusters[i]).Healthy {
+			placement.clients = append(placement.clients, client)
+		} else {
+			placement.unhealthy = append(placement.unhealthy, client)
+		}
+	}
+	if len(placement.clients) == 0 {
+		return nil, errors.Wrapf(errNoHealthyCluster, "unable to place units in pool %q", pool)
+	}
+	return placement, nil
+}
+
+// primary returns the cluster used by operations running in a single
+// cluster, like image builds and isolated commands.
+func (p *poolPlacement) primary() *ClusterClient {
+	return p.clients[0]
+}
+
+// active returns the clusters receiving units. In active-passive placement,
+// the other clusters keep the app deployed with no units.
+func (p *poolPlacement) active() []*ClusterClient {
+	if p.policy == provTypes.PlacementActivePassive {
+		return p.clients[:1]
+	}
+	return p.clients
+}
+
+// split returns the number of units of each cluster for the given total,
+// giving the remainder to the clusters with higher priority.
+func (p *poolPlacement) split(replicas int) []int {
+	result := make([]int, len(p.clients))
+	active := len(p.active())
+	for i := 0; i < active; i++ {
+		result[i] = replicas / active
+		if i < replicas%active {
+			result[i]++
+		}
+	}
+	return result
+}
+
+func (p *poolPlacement) clusterNames() []string {
+	names := make([]string, len(p.clients))
+	for i, c := range p.clients {
+		names[i] = c.Name
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (p *poolPlacement) serviceManager(w io.Writer) servicecommon.ServiceManager {
+	if len(p.clients) == 1 && len(p.unhealthy) == 0 {
+		return &serviceManager{client: p.clients[0], writer: w}
+	}
+	return &placementManager{placement: p, writer: w}
+}
+
+// clusterForUnit returns the cluster running the unit with the given id.
+func (p *poolPlacement) clusterForUnit(a provision.App, unitID string) (*ClusterClient, error) {
+	if len(p.clients) == 1 {
+		return p.clients[0], nil
+	}
+	for _, client := range p.clients {
+		ns, err := client.AppNamespace(a)
+		if err != nil {
+			return nil, err
+		}
+		_, err = client.CoreV1().Pods(ns).Get(unitID, metav1.GetOptions{})
+		if err == nil {
+			return client, nil
+		}
+		if !k8sErrors.IsNotFound(err) {
+			return nil, errors.WithStack(err)
+		}
+	}
+	return nil, &provision.UnitNotFoundError{ID: unitID}
+}
+
+// placementManager deploys the services of an app in every cluster of a
+// pool, splitting the units according to the placement policy.
+type placementManager struct {
+	placement *poolPlacement
+	writer    io.Writer
+}
+
+var _ servicecommon.ServiceManager = &placementManager{}
+
+// RemoveService removes the process from every cluster of the pool. Errors
+// in unhealthy clusters are only logged, the process is removed from them
+// once they're healthy again.
+func (m *placementManager) RemoveService(a provision.App, process string) error {
+	multiErrors := tsuruErrors.NewMultiError()
+	for _, client := range m.placement.clients {
+		err := (&serviceManager{client: client, writer: m.writer}).RemoveService(a, process)
+		if err != nil {
+			multiErrors.Add(errors.Wrapf(err, "cluster %q", client.Name))
+		}
+	}
+	for _, client := range m.placement.unhealthy {
+		err := (&serviceManager{client: client, writer: m.writer}).RemoveService(a, process)
+		if err != nil {
+			log.Errorf("[kubernetes placement] unable to remove process %q of app %q from unhealthy cluster %q, it will be removed once the cluster is healthy: %v", process, a.GetName(), client.Name, err)
+		}
+	}
+	return multiErrors.ToError()
+}
+
+// CurrentLabels returns the labels of the process in the first cluster
+// where it is deployed. All clusters share the same labels, including the
+// total number of units.
+func (m *placementManager) CurrentLabels(a provision.App, process string) (*provision.LabelSet, error) {
+	for _, client := range m.placement.clients {
+		labels, err := (&serviceManager{client: client, writer: m.writer}).CurrentLabels(a, process)
+		if err != nil || labels != nil {
+			return labels, err
+		}
+	}
+	return nil, nil
+}
+
+func (m *placementManager) DeployService(ctx context.Context, a provision.App, process string, labels *provision.LabelSet, replicas int, img string) error {
+	split := m.placement.split(replicas)
+	for i, client := range m.placement.clients {
+		if m.writer != nil {
+			fmt.Fprintf(m.writer, "\n---- Placing %d units of process %q in cluster %q ----\n", split[i], process, client.Name)
+		}
+		manager := &serviceManager{client: client, writer: m.writer}
+		err := manager.DeployService(ctx, a, process, copyLabelSet(labels), split[i], img)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyLabelSet(labels *provision.LabelSet) *provision.LabelSet {
+	if labels == nil {
+		return nil
+	}
+	result := &provision.LabelSet{Prefix: labels.Prefix, Labels: make(map[string]string, len(labels.Labels))}
+	for k, v := range labels.Labels {
+		result.Labels[k] = v
+	}
+	return result
+}
+
+// ClusterHealthChanged moves the units of apps in the pools of a cluster with
+// a placement policy to the other clusters when it becomes unhealthy, placing
+// them back once it's healthy again.
+func (p *kubernetesProvisioner) ClusterHealthChanged(clust *provTypes.Cluster, status provTypes.ClusterStatus, w io.Writer) error {
+	if clust.Placement == "" {
+		return nil
+	}
+	multiErrors := tsuruErrors.NewMultiError()
+	if status.Healthy {
+		fmt.Fprintf(w, " ---> Cluster %q is healthy again, placing units in it\n", clust.Name)
+		err := p.removeOrphanedResources(clust)
+		if err != nil {
+			multiErrors.Add(err)
+		}
+	} else {
+		fmt.Fprintf(w, " ---> Cluster %q is unhealthy, moving units to other clusters: %s\n", clust.Name, status.Error)
+	}
+	for _, pool := range clust.Pools {
+		err := p.placePoolApps(pool, w)
+		if err != nil {
+			multiErrors.Add(errors.Wrapf(err, "pool %q", pool))
+		}
+	}
+	return multiErrors.ToError()
+}
+
+// removeOrphanedResources removes from a cluster the apps destroyed, and the
+// processes removed, while it was unhealthy.
+func (p *kubernetesProvisioner) removeOrphanedResources(clust *provTypes.Cluster) error {
+	client, err := NewClusterClient(clust)
+	if err != nil {
+		return err
+	}
+	tclient, err := TsuruClientForConfig(client.restConfig)
+	if err != nil {
+		return err
+	}
+	appList, err := tclient.TsuruV1().Apps(client.Namespace()).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	multiErrors := tsuruErrors.NewMultiError()
+	for _, appCR := range appList.Items {
+		a, err := app.GetByName(appCR.Name)
+		if err == appTypes.ErrAppNotFound {
+			err = p.destroyInCluster(client, appCR.Name)
+			if err != nil {
+				multiErrors.Add(errors.Wrapf(err, "app %q", appCR.Name))
+			}
+			continue
+		}
+		if err != nil {
+			multiErrors.Add(errors.Wrapf(err, "app %q", appCR.Name))
+			continue
+		}
+		processes, err := image.AllAppProcesses(a.Name)
+		if err != nil {
+			continue
+		}
+		current := set.FromSlice(processes)
+		for process := range appCR.Spec.Deployments {
+			if current.Includes(process) {
+				continue
+			}
+			err = (&serviceManager{client: client}).RemoveService(a, process)
+			if err != nil {
+				multiErrors.Add(errors.Wrapf(err, "app %q process %q", a.Name, process))
+			}
+		}
+	}
+	return multiErrors.ToError()
+}
+
+// placePoolApps places the units of every app in the pool among the
+// clusters currently healthy and rebuilds their routes.
+func (p *kubernetesProvisioner) placePoolApps(pool string, w io.Writer) error {
+	placement, err := placementForPool(pool)
+	if err != nil {
+		return err
+	}
+	apps, err := app.List(&app.Filter{Pool: pool})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, " ---> Placing units of pool %q in clusters %v\n", pool, placement.clusterNames())
+	multiErrors := tsuruErrors.NewMultiError()
+	for i := range apps {
+		a := &apps[i]
+		if a.GetDeploys() == 0 {
+			continue
+		}
+		err = placeAppUnits(a, placement, w)
+		if err != nil {
+			multiErrors.Add(errors.Wrapf(err, "app %q", a.Name))
+		}
+	}
+	servicecommon.RebuildRoutesPoolApps(pool)
+	return multiErrors.ToError()
+}
+
+func placeAppUnits(a *app.App, placement *poolPlacement, w io.Writer) error {
+	locked, err := app.AcquireApplicationLockWait(a.Name, app.InternalAppName, "cluster placement", placementLockWaitTimeout)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return errors.Errorf("unable to lock app %q", a.Name)
+	}
+	defer app.ReleaseApplicationLock(a.Name)
+	for _, client := range placement.clients {
+		err = ensureAppCustomResourceSynced(client, a)
+		if err != nil {
+			return err
+		}
+	}
+	return servicecommon.ChangeAppState(placement.serviceManager(w), a, "", servicecommon.ProcessState{})
+}
@@ -0,0 +1,441 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"bytes"
+	"errors"
+	"net/url"
+
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/app"
+	"github.com/tsuru/tsuru/app/image"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/cluster"
+	tsuruv1clientset "github.com/tsuru/tsuru/provision/kubernetes/pkg/client/clientset/versioned"
+	faketsuru "github.com/tsuru/tsuru/provision/kubernetes/pkg/client/clientset/versioned/fake"
+	kTesting "github.com/tsuru/tsuru/provision/kubernetes/testing"
+	"github.com/tsuru/tsuru/provision/servicecommon"
+	provTypes "github.com/tsuru/tsuru/types/provision"
+	"github.com/tsuru/tsuru/volume"
+	"gopkg.in/check.v1"
+	apiv1 "k8s.io/api/core/v1"
+	apiextensionsclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
+	fakeapiextensions "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/fake"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	ktesting "k8s.io/client-go/testing"
+)
+
+// placementCluster is a fake cluster serving the test pool.
+type placementCluster struct {
+	cluster provTypes.Cluster
+	client  *kTesting.ClientWrapper
+	mock    *kTesting.KubeMock
+}
+
+// setUpPlacement makes the test pool served by the suite cluster, c1, and by
+// a second cluster, c2, with its own fake clients. The clusters in unhealthy
+// are reported as unhealthy by the health monitor.
+func (s *S) setUpPlacement(c *check.C, policy provTypes.PlacementPolicy, unhealthy map[string]bool) []placementCluster {
+	c1 := *s.clusterClient.Cluster
+	c1.Pools = []string{"test-default"}
+	c1.Placement = policy
+	c1.Priority = 10
+	c2 := provTypes.Cluster{
+		Name:        "c2",
+		Addresses:   []string{"https://clusteraddr2"},
+		Provisioner: provisionerName,
+		Pools:       []string{"test-default"},
+		Placement:   policy,
+		CustomData:  map[string]string{},
+	}
+	c2Client, err := NewClusterClient(&c2)
+	c.Assert(err, check.IsNil)
+	client := &kTesting.ClientWrapper{
+		Clientset:              fake.NewSimpleClientset(),
+		ApiExtensionsClientset: fakeapiextensions.NewSimpleClientset(),
+		TsuruClientset:         faketsuru.NewSimpleClientset(),
+		ClusterInterface:       c2Client,
+	}
+	c2Client.Interface = client
+	factory := informers.NewSharedInformerFactory(client, 1)
+	s.p.informerFactory[c2.Name] = factory
+	mock := kTesting.NewKubeMock(client, s.p, factory)
+	client.ApiExtensionsClientset.PrependReactor("create", "customresourcedefinitions", mock.CRDReaction(c))
+	clients := map[string]*kTesting.ClientWrapper{
+		c1.Addresses[0]: s.client,
+		c2.Addresses[0]: client,
+	}
+	ClientForConfig = func(conf *rest.Config) (kubernetes.Interface, error) {
+		return clients[conf.Host], nil
+	}
+	TsuruClientForConfig = func(conf *rest.Config) (tsuruv1clientset.Interface, error) {
+		return clients[conf.Host].TsuruClientset, nil
+	}
+	ExtensionsClientForConfig = func(conf *rest.Config) (apiextensionsclientset.Interface, error) {
+		return clients[conf.Host].ApiExtensionsClientset, nil
+	}
+	clusters := []provTypes.Cluster{c1, c2}
+	s.mockService.Cluster.OnFindByPool = func(_, _ string) (*provTypes.Cluster, error) {
+		return &clusters[0], nil
+	}
+	s.mockService.Cluster.OnFindAllByPool = func(_, _ string) ([]provTypes.Cluster, error) {
+		return clusters, nil
+	}
+	s.mockService.Cluster.OnFindByProvisioner = func(_ string) ([]provTypes.Cluster, error) {
+		return clusters, nil
+	}
+	clusterStatus = func(c *provTypes.Cluster) *provTypes.ClusterStatus {
+		return &provTypes.ClusterStatus{Name: c.Name, Healthy: !unhealthy[c.Name]}
+	}
+	return []placementCluster{
+		{cluster: c1, client: s.client, mock: s.mock},
+		{cluster: c2, client: client, mock: mock},
+	}
+}
+
+// createPlacementApp creates a deployed app in the test pool, with the p1
+// process running the given number of units.
+func (s *S) createPlacementApp(c *check.C, units int) *app.App {
+	a := &app.App{Name: "myapp", TeamOwner: s.team.Name}
+	err := app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	err = s.conn.Apps().Update(bson.M{"name": a.Name}, bson.M{"$set": bson.M{"deploys": 1}})
+	c.Assert(err, check.IsNil)
+	a, err = app.GetByName(a.Name)
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData("myimg", map[string]interface{}{
+		"processes": map[string]interface{}{
+			"p1": "cmd1",
+		},
+	})
+	c.Assert(err, check.IsNil)
+	placement, err := placementForPool(a.GetPool())
+	c.Assert(err, check.IsNil)
+	err = servicecommon.RunServicePipeline(placement.serviceManager(nil), a, "myimg", servicecommon.ProcessSpec{
+		"p1": servicecommon.ProcessState{Increment: units},
+	}, nil)
+	c.Assert(err, check.IsNil)
+	return a
+}
+
+func deploymentReplicas(c *check.C, client *kTesting.ClientWrapper, a provision.App, process string) int32 {
+	ns, err := client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	dep, err := client.Clientset.AppsV1beta2().Deployments(ns).Get(deploymentNameForApp(a, process), metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	return *dep.Spec.Replicas
+}
+
+func (s *S) TestPoolPlacementSplit(c *check.C) {
+	clients := []*ClusterClient{{}, {}, {}}
+	tests := []struct {
+		policy   provTypes.PlacementPolicy
+		replicas int
+		expected []int
+	}{
+		{provTypes.PlacementReplicate, 7, []int{3, 2, 2}},
+		{provTypes.PlacementReplicate, 2, []int{1, 1, 0}},
+		{provTypes.PlacementReplicate, 0, []int{0, 0, 0}},
+		{provTypes.PlacementActivePassive, 7, []int{7, 0, 0}},
+	}
+	for _, tt := range tests {
+		placement := poolPlacement{policy: tt.policy, clients: clients}
+		c.Check(placement.split(tt.replicas), check.DeepEquals, tt.expected, check.Commentf("%s with %d replicas", tt.policy, tt.replicas))
+	}
+	placement := poolPlacement{clients: clients[:1]}
+	c.Assert(placement.split(5), check.DeepEquals, []int{5})
+}
+
+func (s *S) TestPlacementForPoolSingleCluster(c *check.C) {
+	c1 := provTypes.Cluster{Name: "c1", Addresses: []string{"https://addr1"}, Pools: []string{"p1"}}
+	s.mockService.Cluster.OnFindByPool = func(_, pool string) (*provTypes.Cluster, error) {
+		c.Assert(pool, check.Equals, "p1")
+		return &c1, nil
+	}
+	s.mockService.Cluster.OnFindAllByPool = func(_, _ string) ([]provTypes.Cluster, error) {
+		c.Fatal("FindAllByPool should not be called for pools without placement")
+		return nil, nil
+	}
+	placement, err := placementForPool("p1")
+	c.Assert(err, check.IsNil)
+	c.Assert(placement.clusterNames(), check.DeepEquals, []string{"c1"})
+	c.Assert(placement.primary().Name, check.Equals, "c1")
+	_, isSingle := placement.serviceManager(nil).(*serviceManager)
+	c.Assert(isSingle, check.Equals, true)
+}
+
+func (s *S) TestPlacementForPoolSkipsUnhealthyClusters(c *check.C) {
+	unhealthy := map[string]bool{}
+	clusterStatus = func(c *provTypes.Cluster) *provTypes.ClusterStatus {
+		return &provTypes.ClusterStatus{Name: c.Name, Healthy: !unhealthy[c.Name]}
+	}
+	defer func() { clusterStatus = cluster.Status }()
+	clusters := []provTypes.Cluster{
+		{Name: "c2", Addresses: []string{"https://addr2"}, Pools: []string{"p1"}, Placement: provTypes.PlacementActivePassive, Priority: 10},
+		{Name: "c1", Addresses: []string{"https://addr1"}, Pools: []string{"p1"}, Placement: provTypes.PlacementActivePassive},
+		{Name: "c3", Addresses: []string{"https://addr3"}, Pools: []string{"p1"}, Placement: provTypes.PlacementActivePassive},
+	}
+	s.mockService.Cluster.OnFindByPool = func(_, _ string) (*provTypes.Cluster, error) {
+		return &clusters[0], nil
+	}
+	s.mockService.Cluster.OnFindAllByPool = func(_, pool string) ([]provTypes.Cluster, error) {
+		c.Assert(pool, check.Equals, "p1")
+		return clusters, nil
+	}
+	placement, err := placementForPool("p1")
+	c.Assert(err, check.IsNil)
+	c.Assert(placement.policy, check.Equals, provTypes.PlacementActivePassive)
+	c.Assert(placement.primary().Name, check.Equals, "c2")
+	c.Assert(placement.active(), check.HasLen, 1)
+	_, isPlacement := placement.serviceManager(nil).(*placementManager)
+	c.Assert(isPlacement, check.Equals, true)
+	unhealthy["c2"] = true
+	placement, err = placementForPool("p1")
+	c.Assert(err, check.IsNil)
+	c.Assert(placement.primary().Name, check.Equals, "c1")
+	c.Assert(placement.clusterNames(), check.DeepEquals, []string{"c1", "c3"})
+	c.Assert(placement.unhealthy, check.HasLen, 1)
+	c.Assert(placement.unhealthy[0].Name, check.Equals, "c2")
+	unhealthy["c1"] = true
+	unhealthy["c3"] = true
+	_, err = placementForPool("p1")
+	c.Assert(err, check.ErrorMatches, `unable to place units in pool "p1": no healthy cluster`)
+}
+
+func (s *S) TestClusterHealthChangedIgnoresClustersWithoutPlacement(c *check.C) {
+	s.mockService.Cluster.OnFindByPool = func(_, _ string) (*provTypes.Cluster, error) {
+		c.Fatal("placement should not be computed for clusters without placement")
+		return nil, nil
+	}
+	clust := provTypes.Cluster{Name: "c1", Pools: []string{"p1"}}
+	err := s.p.ClusterHealthChanged(&clust, provTypes.ClusterStatus{Name: "c1", Error: "unreachable"}, &bytes.Buffer{})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestPlacementManagerDeployService(c *check.C) {
+	defer func() { clusterStatus = cluster.Status }()
+	clusters := s.setUpPlacement(c, provTypes.PlacementReplicate, nil)
+	waitDep := clusters[0].mock.DeploymentReactions(c)
+	defer waitDep()
+	waitDep2 := clusters[1].mock.DeploymentReactions(c)
+	defer waitDep2()
+	a := s.createPlacementApp(c, 3)
+	waitDep()
+	waitDep2()
+	c.Assert(deploymentReplicas(c, clusters[0].client, a, "p1"), check.Equals, int32(2))
+	c.Assert(deploymentReplicas(c, clusters[1].client, a, "p1"), check.Equals, int32(1))
+}
+
+func (s *S) TestPlacementManagerDeployServiceActivePassive(c *check.C) {
+	defer func() { clusterStatus = cluster.Status }()
+	clusters := s.setUpPlacement(c, provTypes.PlacementActivePassive, nil)
+	waitDep := clusters[0].mock.DeploymentReactions(c)
+	defer waitDep()
+	waitDep2 := clusters[1].mock.DeploymentReactions(c)
+	defer waitDep2()
+	a := s.createPlacementApp(c, 3)
+	waitDep()
+	waitDep2()
+	c.Assert(deploymentReplicas(c, clusters[0].client, a, "p1"), check.Equals, int32(3))
+	c.Assert(deploymentReplicas(c, clusters[1].client, a, "p1"), check.Equals, int32(0))
+}
+
+func (s *S) TestClusterHealthChangedFailover(c *check.C) {
+	defer func() { clusterStatus = cluster.Status }()
+	unhealthy := map[string]bool{}
+	clusters := s.setUpPlacement(c, provTypes.PlacementReplicate, unhealthy)
+	waitDep := clusters[0].mock.DeploymentReactions(c)
+	defer waitDep()
+	waitDep2 := clusters[1].mock.DeploymentReactions(c)
+	defer waitDep2()
+	a := s.createPlacementApp(c, 3)
+	waitDep()
+	waitDep2()
+	unhealthy["c2"] = true
+	var buf bytes.Buffer
+	err := s.p.ClusterHealthChanged(&clusters[1].cluster, provTypes.ClusterStatus{Name: "c2", Error: "unreachable"}, &buf)
+	c.Assert(err, check.IsNil)
+	waitDep()
+	c.Assert(buf.String(), check.Matches, `(?s).*Cluster "c2" is unhealthy, moving units to other clusters: unreachable.*Placing units of pool "test-default" in clusters \[c1\].*`)
+	c.Assert(deploymentReplicas(c, clusters[0].client, a, "p1"), check.Equals, int32(3))
+	delete(unhealthy, "c2")
+	buf.Reset()
+	err = s.p.ClusterHealthChanged(&clusters[1].cluster, provTypes.ClusterStatus{Name: "c2", Healthy: true}, &buf)
+	c.Assert(err, check.IsNil)
+	waitDep()
+	waitDep2()
+	c.Assert(buf.String(), check.Matches, `(?s).*Cluster "c2" is healthy again, placing units in it.*Placing units of pool "test-default" in clusters \[c1 c2\].*`)
+	c.Assert(deploymentReplicas(c, clusters[0].client, a, "p1"), check.Equals, int32(2))
+	c.Assert(deploymentReplicas(c, clusters[1].client, a, "p1"), check.Equals, int32(1))
+}
+
+func (s *S) TestRoutableAddressesMergesActiveClusters(c *check.C) {
+	defer func() { clusterStatus = cluster.Status }()
+	unhealthy := map[string]bool{}
+	clusters := s.setUpPlacement(c, provTypes.PlacementReplicate, unhealthy)
+	a := &app.App{Name: "myapp", TeamOwner: s.team.Name}
+	err := app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	err = image.SaveImageCustomData("myimg", map[string]interface{}{
+		"processes": map[string]interface{}{
+			"web": "run mycmd",
+		},
+	})
+	c.Assert(err, check.IsNil)
+	err = image.AppendAppImageName(a.Name, "myimg")
+	c.Assert(err, check.IsNil)
+	for i, clust := range clusters {
+		ns, nsErr := clust.client.AppNamespace(a)
+		c.Assert(nsErr, check.IsNil)
+		_, err = clust.client.CoreV1().Services(ns).Create(&apiv1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "myapp-web", Namespace: ns},
+			Spec: apiv1.ServiceSpec{
+				Ports: []apiv1.ServicePort{{NodePort: int32(30000 + i)}},
+			},
+		})
+		c.Assert(err, check.IsNil)
+		_, err = clust.client.CoreV1().Nodes().Create(&apiv1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "n1",
+				Labels: map[string]string{"tsuru.io/pool": "test-default"},
+			},
+			Status: apiv1.NodeStatus{
+				Addresses: []apiv1.NodeAddress{
+					{Type: apiv1.NodeInternalIP, Address: "192.168.99.1"},
+				},
+			},
+		})
+		c.Assert(err, check.IsNil)
+	}
+	addrs, err := s.p.RoutableAddresses(a)
+	c.Assert(err, check.IsNil)
+	c.Assert(addrs, check.DeepEquals, []url.URL{
+		{Scheme: "http", Host: "192.168.99.1:30000"},
+		{Scheme: "http", Host: "192.168.99.1:30001"},
+	})
+	unhealthy["c2"] = true
+	addrs, err = s.p.RoutableAddresses(a)
+	c.Assert(err, check.IsNil)
+	c.Assert(addrs, check.DeepEquals, []url.URL{
+		{Scheme: "http", Host: "192.168.99.1:30000"},
+	})
+}
+
+func (s *S) TestDestroyRemovesAppFromUnhealthyClusterOnceHealthy(c *check.C) {
+	defer func() { clusterStatus = cluster.Status }()
+	unhealthy := map[string]bool{}
+	clusters := s.setUpPlacement(c, provTypes.PlacementReplicate, unhealthy)
+	a := &app.App{Name: "myapp", TeamOwner: s.team.Name}
+	err := app.CreateApp(a, s.user)
+	c.Assert(err, check.IsNil)
+	unhealthyClient := clusters[1].client.TsuruClientset
+	unhealthyClient.PrependReactor("get", "apps", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	unhealthy["c2"] = true
+	err = s.p.Destroy(a)
+	c.Assert(err, check.IsNil)
+	_, err = clusters[0].client.TsuruV1().Apps("tsuru").Get(a.Name, metav1.GetOptions{})
+	c.Assert(k8sErrors.IsNotFound(err), check.Equals, true)
+	unhealthyClient.ReactionChain = unhealthyClient.ReactionChain[1:]
+	_, err = unhealthyClient.TsuruV1().Apps("tsuru").Get(a.Name, metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	err = s.conn.Apps().Remove(bson.M{"name": a.Name})
+	c.Assert(err, check.IsNil)
+	delete(unhealthy, "c2")
+	err = s.p.ClusterHealthChanged(&clusters[1].cluster, provTypes.ClusterStatus{Name: "c2", Healthy: true}, &bytes.Buffer{})
+	c.Assert(err, check.IsNil)
+	_, err = unhealthyClient.TsuruV1().Apps("tsuru").Get(a.Name, metav1.GetOptions{})
+	c.Assert(k8sErrors.IsNotFound(err), check.Equals, true)
+}
+
+func (s *S) TestPlacementManagerRemoveServiceIgnoresUnhealthyClusters(c *check.C) {
+	defer func() { clusterStatus = cluster.Status }()
+	unhealthy := map[string]bool{}
+	clusters := s.setUpPlacement(c, provTypes.PlacementReplicate, unhealthy)
+	waitDep := clusters[0].mock.DeploymentReactions(c)
+	defer waitDep()
+	waitDep2 := clusters[1].mock.DeploymentReactions(c)
+	defer waitDep2()
+	a := s.createPlacementApp(c, 2)
+	waitDep()
+	waitDep2()
+	clusters[1].client.PrependReactor("delete", "deployments", func(action ktesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	unhealthy["c2"] = true
+	placement, err := placementForPool(a.GetPool())
+	c.Assert(err, check.IsNil)
+	err = placement.serviceManager(nil).RemoveService(a, "p1")
+	c.Assert(err, check.IsNil)
+	ns, err := clusters[0].client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	_, err = clusters[0].client.Clientset.AppsV1beta2().Deployments(ns).Get("myapp-p1", metav1.GetOptions{})
+	c.Assert(k8sErrors.IsNotFound(err), check.Equals, true)
+}
+
+func (s *S) TestVolumeOperationsUseEveryClusterOfThePool(c *check.C) {
+	defer func() { clusterStatus = cluster.Status }()
+	clusters := s.setUpPlacement(c, provTypes.PlacementReplicate, nil)
+	config.Set("volume-plans:p1:kubernetes:plugin", "nfs")
+	defer config.Unset("volume-plans")
+	v := volume.Volume{
+		Name: "v1",
+		Opts: map[string]string{
+			"path":         "/exports",
+			"server":       "192.168.1.1",
+			"capacity":     "20Gi",
+			"access-modes": string(apiv1.ReadWriteMany),
+		},
+		Plan:      volume.VolumePlan{Name: "p1"},
+		Pool:      "test-default",
+		TeamOwner: "admin",
+	}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	provisioned, err := s.p.IsVolumeProvisioned("v1", "test-default")
+	c.Assert(err, check.IsNil)
+	c.Assert(provisioned, check.Equals, false)
+	c2Client, err := NewClusterClient(&clusters[1].cluster)
+	c.Assert(err, check.IsNil)
+	ns := c2Client.PoolNamespace("test-default")
+	_, err = clusters[1].client.CoreV1().PersistentVolumes().Create(&apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: volumeName("v1")},
+	})
+	c.Assert(err, check.IsNil)
+	_, err = clusters[1].client.CoreV1().PersistentVolumeClaims(ns).Create(&apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: volumeClaimName("v1"), Namespace: ns},
+	})
+	c.Assert(err, check.IsNil)
+	provisioned, err = s.p.IsVolumeProvisioned("v1", "test-default")
+	c.Assert(err, check.IsNil)
+	c.Assert(provisioned, check.Equals, true)
+	err = s.p.DeleteVolume("v1", "test-default")
+	c.Assert(err, check.IsNil)
+	_, err = clusters[1].client.CoreV1().PersistentVolumes().Get(volumeName("v1"), metav1.GetOptions{})
+	c.Assert(k8sErrors.IsNotFound(err), check.Equals, true)
+	_, err = clusters[1].client.CoreV1().PersistentVolumeClaims(ns).Get(volumeClaimName("v1"), metav1.GetOptions{})
+	c.Assert(k8sErrors.IsNotFound(err), check.Equals, true)
+	provisioned, err = s.p.IsVolumeProvisioned("v1", "test-default")
+	c.Assert(err, check.IsNil)
+	c.Assert(provisioned, check.Equals, false)
+}
+
+func (s *S) TestCopyLabelSet(c *check.C) {
+	c.Assert(copyLabelSet(nil), check.IsNil)
+	labels := &provision.LabelSet{Prefix: "tsuru.io/", Labels: map[string]string{"app-name": "myapp"}}
+	copied := copyLabelSet(labels)
+	c.Assert(copied, check.DeepEquals, labels)
+	copied.SetIsHeadlessService()
+	c.Assert(labels.Labels, check.DeepEquals, map[string]string{"app-name": "myapp"})
+}
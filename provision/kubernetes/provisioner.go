@@ -6,6 +6,8 @@ package kubernetes
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"flag"
 	"fmt"
 	"io"
@@ -23,6 +25,7 @@ import (
 	"github.com/tsuru/tsuru/app/image"
 	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
 	tsuruNet "github.com/tsuru/tsuru/net"
 	"github.com/tsuru/tsuru/provision"
 	tsuruv1 "github.com/tsuru/tsuru/provision/kubernetes/pkg/apis/tsuru/v1"
@@ -61,20 +64,27 @@ type kubernetesProvisioner struct {
 	podInformers     map[string]v1informers.PodInformer
 	serviceInformers map[string]v1informers.ServiceInformer
 	nodeInformers    map[string]v1informers.NodeInformer
+	informerClusters map[string]*informerCluster
 	stopCh           chan struct{}
 }
 
 var (
-	_ provision.Provisioner              = &kubernetesProvisioner{}
-	_ provision.NodeProvisioner          = &kubernetesProvisioner{}
-	_ provision.NodeContainerProvisioner = &kubernetesProvisioner{}
-	_ provision.MessageProvisioner       = &kubernetesProvisioner{}
-	_ provision.SleepableProvisioner     = &kubernetesProvisioner{}
-	_ provision.VolumeProvisioner        = &kubernetesProvisioner{}
-	_ provision.BuilderDeploy            = &kubernetesProvisioner{}
-	_ provision.BuilderDeployKubeClient  = &kubernetesProvisioner{}
-	_ provision.InitializableProvisioner = &kubernetesProvisioner{}
-	_ provision.RollbackableDeployer     = &kubernetesProvisioner{}
+	_ provision.Provisioner                    = &kubernetesProvisioner{}
+	_ provision.NodeProvisioner                = &kubernetesProvisioner{}
+	_ provision.NodeContainerProvisioner       = &kubernetesProvisioner{}
+	_ provision.MessageProvisioner             = &kubernetesProvisioner{}
+	_ provision.SleepableProvisioner           = &kubernetesProvisioner{}
+	_ provision.VolumeProvisioner              = &kubernetesProvisioner{}
+	_ provision.SnapshotVolumeProvisioner      = &kubernetesProvisioner{}
+	_ provision.ResizableVolumeProvisioner     = &kubernetesProvisioner{}
+	_ provision.VolumeUsageProvisioner         = &kubernetesProvisioner{}
+	_ provision.BuilderDeploy                  = &kubernetesProvisioner{}
+	_ provision.BuilderDeployKubeClient        = &kubernetesProvisioner{}
+	_ provision.InitializableProvisioner       = &kubernetesProvisioner{}
+	_ provision.RollbackableDeployer           = &kubernetesProvisioner{}
+	_ provision.ClusterCheckerProvisioner      = &kubernetesProvisioner{}
+	_ provision.ClusterHealthChangeProvisioner = &kubernetesProvisioner{}
+	_ provision.ClusterCredentialsProvisioner  = &kubernetesProvisioner{}
 	// _ provision.OptionalLogsProvisioner  = &kubernetesProvisioner{}
 	// _ provision.UnitStatusProvisioner    = &kubernetesProvisioner{}
 	// _ provision.NodeRebalanceProvisioner = &kubernetesProvisioner{}
@@ -90,6 +100,7 @@ func init() {
 		podInformers:     make(map[string]v1informers.PodInformer),
 		serviceInformers: make(map[string]v1informers.ServiceInformer),
 		nodeInformers:    make(map[string]v1informers.NodeInformer),
+		informerClusters: make(map[string]*informerCluster),
 		stopCh:           make(chan struct{}),
 	}
 	provision.Register(provisionerName, func() (provision.Provisioner, error) {
@@ -183,35 +194,106 @@ func (p *kubernetesProvisioner) Initialize() error {
 	return nil
 }
 
+func (p *kubernetesProvisioner) CheckCluster(c *provTypes.Cluster) error {
+	client, err := NewClusterClient(c)
+	if err != nil {
+		return err
+	}
+	err = client.SetTimeout(getKubeConfig().APIShortTimeout)
+	if err != nil {
+		return err
+	}
+	_, err = client.Discovery().ServerVersion()
+	return errors.WithStack(err)
+}
+
+func (p *kubernetesProvisioner) ClusterStatus(c *provTypes.Cluster) (*provTypes.ClusterStatus, error) {
+	client, err := NewClusterClient(c)
+	if err != nil {
+		return nil, err
+	}
+	err = client.SetTimeout(getKubeConfig().APIShortTimeout)
+	if err != nil {
+		return nil, err
+	}
+	version, err := client.Discovery().ServerVersion()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	nodes, err := client.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	status := &provTypes.ClusterStatus{
+		Version: version.GitVersion,
+		Nodes:   provTypes.ClusterNodesStatus{Total: len(nodes.Items)},
+	}
+	for _, node := range nodes.Items {
+		for _, cond := range node.Status.Conditions {
+			if cond.Type == apiv1.NodeReady && cond.Status == apiv1.ConditionTrue {
+				status.Nodes.Ready++
+			}
+		}
+	}
+	return status, nil
+}
+
 func (p *kubernetesProvisioner) GetName() string {
 	return provisionerName
 }
 
 func (p *kubernetesProvisioner) Provision(a provision.App) error {
-	client, err := clusterForPool(a.GetPool())
+	placement, err := placementForPool(a.GetPool())
 	if err != nil {
 		return err
 	}
-	return ensureAppCustomResourceSynced(client, a)
+	return ensureAppCustomResourceSyncedAll(placement, a)
+}
+
+func ensureAppCustomResourceSyncedAll(placement *poolPlacement, a provision.App) error {
+	for _, client := range placement.clients {
+		err := ensureAppCustomResourceSynced(client, a)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 func (p *kubernetesProvisioner) Destroy(a provision.App) error {
-	client, err := clusterForPool(a.GetPool())
+	placement, err := placementForPool(a.GetPool())
 	if err != nil {
 		return err
 	}
+	multiErrors := tsuruErrors.NewMultiError()
+	for _, client := range placement.clients {
+		err = p.destroyInCluster(client, a.GetName())
+		if err != nil {
+			multiErrors.Add(err)
+		}
+	}
+	for _, client := range placement.unhealthy {
+		err = p.destroyInCluster(client, a.GetName())
+		if err != nil {
+			log.Errorf("[kubernetes placement] unable to remove app %q from unhealthy cluster %q, it will be removed once the cluster is healthy: %v", a.GetName(), client.Name, err)
+		}
+	}
+	return multiErrors.ToError()
+}
+
+func (p *kubernetesProvisioner) destroyInCluster(client *ClusterClient, appName string) error {
 	tclient, err := TsuruClientForConfig(client.restConfig)
 	if err != nil {
 		return err
 	}
-	app, err := tclient.TsuruV1().Apps(client.Namespace()).Get(a.GetName(), metav1.GetOptions{})
+	app, err := tclient.TsuruV1().Apps(client.Namespace()).Get(appName, metav1.GetOptions{})
 	if err != nil {
 		return err
 	}
 	if err := p.removeResources(client, app); err != nil {
 		return err
 	}
-	return tclient.TsuruV1().Apps(client.Namespace()).Delete(a.GetName(), &metav1.DeleteOptions{})
+	return tclient.TsuruV1().Apps(client.Namespace()).Delete(appName, &metav1.DeleteOptions{})
 }
 
 func (p *kubernetesProvisioner) removeResources(client *ClusterClient, app *tsuruv1.App) error {
@@ -269,31 +351,25 @@ func (p *kubernetesProvisioner) removeResources(client *ClusterClient, app *tsur
 }
 
 func changeState(a provision.App, process string, state servicecommon.ProcessState, w io.Writer) error {
-	client, err := clusterForPool(a.GetPool())
+	placement, err := placementForPool(a.GetPool())
 	if err != nil {
 		return err
 	}
-	if err := ensureAppCustomResourceSynced(client, a); err != nil {
+	if err := ensureAppCustomResourceSyncedAll(placement, a); err != nil {
 		return err
 	}
-	return servicecommon.ChangeAppState(&serviceManager{
-		client: client,
-		writer: w,
-	}, a, process, state)
+	return servicecommon.ChangeAppState(placement.serviceManager(w), a, process, state)
 }
 
 func changeUnits(a provision.App, units int, processName string, w io.Writer) error {
-	client, err := clusterForPool(a.GetPool())
+	placement, err := placementForPool(a.GetPool())
 	if err != nil {
 		return err
 	}
-	if err := ensureAppCustomResourceSynced(client, a); err != nil {
+	if err := ensureAppCustomResourceSyncedAll(placement, a); err != nil {
 		return err
 	}
-	return servicecommon.ChangeUnits(&serviceManager{
-		client: client,
-		writer: w,
-	}, a, units, processName)
+	return servicecommon.ChangeUnits(placement.serviceManager(w), a, units, processName)
 }
 
 func (p *kubernetesProvisioner) AddUnits(a provision.App, units uint, processName string, w io.Writer) error {
@@ -515,8 +591,10 @@ func (p *kubernetesProvisioner) podsForApps(client *ClusterClient, apps []provis
 	return podCopies, nil
 }
 
+// RoutableAddresses returns the addresses of the nodes of every cluster
+// receiving units of the app.
 func (p *kubernetesProvisioner) RoutableAddresses(a provision.App) ([]url.URL, error) {
-	client, err := clusterForPool(a.GetPool())
+	placement, err := placementForPool(a.GetPool())
 	if err != nil {
 		return nil, err
 	}
@@ -534,6 +612,18 @@ func (p *kubernetesProvisioner) RoutableAddresses(a provision.App) ([]url.URL, e
 	if webProcessName == "" {
 		return nil, nil
 	}
+	var addrs []url.URL
+	for _, client := range placement.active() {
+		clusterAddrs, err := p.routableAddressesForCluster(client, a, webProcessName)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, clusterAddrs...)
+	}
+	return addrs, nil
+}
+
+func (p *kubernetesProvisioner) routableAddressesForCluster(client *ClusterClient, a provision.App, webProcessName string) ([]url.URL, error) {
 	srvName := deploymentNameForApp(a, webProcessName)
 	ns, err := client.AppNamespace(a)
 	if err != nil {
@@ -569,7 +659,11 @@ func (p *kubernetesProvisioner) RoutableAddresses(a provision.App) ([]url.URL, e
 }
 
 func (p *kubernetesProvisioner) RegisterUnit(a provision.App, unitID string, customData map[string]interface{}) error {
-	client, err := clusterForPool(a.GetPool())
+	placement, err := placementForPool(a.GetPool())
+	if err != nil {
+		return err
+	}
+	client, err := placement.clusterForUnit(a, unitID)
 	if err != nil {
 		return err
 	}
@@ -852,13 +946,14 @@ func (p *kubernetesProvisioner) internalNodeUpdate(opts provision.UpdateNodeOpti
 }
 
 func (p *kubernetesProvisioner) Deploy(a provision.App, buildImageID string, evt *event.Event) (string, error) {
-	client, err := clusterForPool(a.GetPool())
+	placement, err := placementForPool(a.GetPool())
 	if err != nil {
 		return "", err
 	}
-	if err = ensureAppCustomResourceSynced(client, a); err != nil {
+	if err = ensureAppCustomResourceSyncedAll(placement, a); err != nil {
 		return "", err
 	}
+	client := placement.primary()
 	newImage := buildImageID
 	if strings.HasSuffix(buildImageID, "-builder") {
 		newImage, err = image.AppNewImageName(a.GetName())
@@ -892,19 +987,15 @@ func (p *kubernetesProvisioner) Deploy(a provision.App, buildImageID string, evt
 			return "", err
 		}
 	}
-	manager := &serviceManager{
-		client: client,
-		writer: evt,
-	}
-	err = servicecommon.RunServicePipeline(manager, a, newImage, nil, evt)
+	err = servicecommon.RunServicePipeline(placement.serviceManager(evt), a, newImage, nil, evt)
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
-	return newImage, ensureAppCustomResourceSynced(client, a)
+	return newImage, ensureAppCustomResourceSyncedAll(placement, a)
 }
 
 func (p *kubernetesProvisioner) Rollback(a provision.App, imageID string, evt *event.Event) (string, error) {
-	client, err := clusterForPool(a.GetPool())
+	placement, err := placementForPool(a.GetPool())
 	if err != nil {
 		return "", err
 	}
@@ -919,11 +1010,7 @@ func (p *kubernetesProvisioner) Rollback(a provision.App, imageID string, evt *e
 	if imgMetaData.DisableRollback {
 		return "", fmt.Errorf("Can't Rollback image %s, reason: %s", foundImageID, imgMetaData.Reason)
 	}
-	manager := &serviceManager{
-		client: client,
-		writer: evt,
-	}
-	err = servicecommon.RunServicePipeline(manager, a, foundImageID, nil, evt)
+	err = servicecommon.RunServicePipeline(placement.serviceManager(evt), a, foundImageID, nil, evt)
 	if err != nil {
 		return "", errors.WithStack(err)
 	}
@@ -946,10 +1033,11 @@ func (p *kubernetesProvisioner) RemoveNodeContainer(name string, pool string, wr
 }
 
 func (p *kubernetesProvisioner) ExecuteCommand(opts provision.ExecOptions) error {
-	client, err := clusterForPool(opts.App.GetPool())
+	placement, err := placementForPool(opts.App.GetPool())
 	if err != nil {
 		return err
 	}
+	client := placement.primary()
 	var size *remotecommand.TerminalSize
 	if opts.Width != 0 && opts.Height != 0 {
 		size = &remotecommand.TerminalSize{
@@ -975,7 +1063,11 @@ func (p *kubernetesProvisioner) ExecuteCommand(opts provision.ExecOptions) error
 	}
 	for _, u := range opts.Units {
 		eOpts.unit = u
-		err := execCommand(eOpts)
+		eOpts.client, err = placement.clusterForUnit(opts.App, u)
+		if err != nil {
+			return err
+		}
+		err = execCommand(eOpts)
 		if err != nil {
 			return err
 		}
@@ -1052,34 +1144,163 @@ func (p *kubernetesProvisioner) Sleep(a provision.App, process string) error {
 }
 
 func (p *kubernetesProvisioner) DeleteVolume(volumeName, pool string) error {
-	client, err := clusterForPool(pool)
+	clients, err := clustersForPool(pool)
 	if err != nil {
 		return err
 	}
-	return deleteVolume(client, volumeName)
+	multiErrors := tsuruErrors.NewMultiError()
+	for _, client := range clients {
+		err = deleteVolume(client, volumeName)
+		if err != nil {
+			multiErrors.Add(err)
+		}
+	}
+	return multiErrors.ToError()
 }
 
 func (p *kubernetesProvisioner) IsVolumeProvisioned(volumeName, pool string) (bool, error) {
-	client, err := clusterForPool(pool)
+	clients, err := clustersForPool(pool)
 	if err != nil {
 		return false, err
 	}
-	return volumeExists(client, volumeName)
+	for _, client := range clients {
+		exists, err := volumeExists(client, volumeName)
+		if err != nil || exists {
+			return exists, err
+		}
+	}
+	return false, nil
+}
+
+// volumeClusters returns the clusters of the pool where the volume is
+// provisioned. When it isn't provisioned anywhere, the cluster with the
+// highest priority is returned, so operations report the missing volume.
+func volumeClusters(volumeName, pool string) ([]*ClusterClient, error) {
+	clients, err := clustersForPool(pool)
+	if err != nil {
+		return nil, err
+	}
+	var result []*ClusterClient
+	for _, client := range clients {
+		exists, err := volumeExists(client, volumeName)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			result = append(result, client)
+		}
+	}
+	if len(result) == 0 {
+		return clients[:1], nil
+	}
+	return result, nil
+}
+
+func (p *kubernetesProvisioner) ResizeVolume(volumeName, pool, capacity string) error {
+	clients, err := volumeClusters(volumeName, pool)
+	if err != nil {
+		return err
+	}
+	for _, client := range clients {
+		err = resizeVolume(client, volumeName, capacity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// VolumeUsage returns the usage of the volume in the cluster where it is
+// fullest, as each cluster holds its own copy of the volume.
+func (p *kubernetesProvisioner) VolumeUsage(volumeName, pool string) (*provision.VolumeUsage, error) {
+	clients, err := volumeClusters(volumeName, pool)
+	if err != nil {
+		return nil, err
+	}
+	var result *provision.VolumeUsage
+	for _, client := range clients {
+		usage, err := volumeUsage(client, volumeName)
+		if err != nil {
+			return nil, err
+		}
+		if usage == nil {
+			continue
+		}
+		if result == nil || usage.Used*(result.Used+result.Available) > result.Used*(usage.Used+usage.Available) {
+			result = usage
+		}
+	}
+	return result, nil
+}
+
+// SnapshotVolume takes a snapshot of the volume in every cluster where it is
+// provisioned, returning the size of the largest one.
+func (p *kubernetesProvisioner) SnapshotVolume(volumeName, snapshotName, pool string) (int64, error) {
+	clients, err := volumeClusters(volumeName, pool)
+	if err != nil {
+		return 0, err
+	}
+	var size int64
+	for _, client := range clients {
+		clusterSize, err := snapshotVolume(client, volumeName, snapshotName)
+		if err != nil {
+			return 0, err
+		}
+		if clusterSize > size {
+			size = clusterSize
+		}
+	}
+	return size, nil
+}
+
+func (p *kubernetesProvisioner) RestoreVolume(volumeName, snapshotName, pool string) error {
+	clients, err := volumeClusters(volumeName, pool)
+	if err != nil {
+		return err
+	}
+	for _, client := range clients {
+		err = restoreVolume(client, volumeName, snapshotName)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *kubernetesProvisioner) DeleteVolumeSnapshot(volumeName, snapshotName, pool string) error {
+	clients, err := clustersForPool(pool)
+	if err != nil {
+		return err
+	}
+	multiErrors := tsuruErrors.NewMultiError()
+	for _, client := range clients {
+		err = deleteVolumeSnapshot(client, volumeName, snapshotName)
+		if err != nil {
+			multiErrors.Add(err)
+		}
+	}
+	return multiErrors.ToError()
 }
 
 func (p *kubernetesProvisioner) UpdateApp(old, new provision.App, w io.Writer) error {
 	if old.GetPool() == new.GetPool() {
 		return nil
 	}
-	client, err := clusterForPool(old.GetPool())
+	oldPlacement, err := placementForPool(old.GetPool())
 	if err != nil {
 		return err
 	}
-	newclient, err := clusterForPool(new.GetPool())
+	newPlacement, err := placementForPool(new.GetPool())
 	if err != nil {
 		return err
 	}
-	sameCluster := client.GetCluster().Name == newclient.GetCluster().Name
+	oldClusters := set.FromSlice(oldPlacement.clusterNames())
+	newClusters := set.FromSlice(newPlacement.clusterNames())
+	sameCluster := len(oldClusters.Difference(newClusters)) == 0 && len(newClusters.Difference(oldClusters)) == 0
+	if !sameCluster && len(oldClusters.Intersection(newClusters)) > 0 {
+		return errors.Errorf("can't change the pool of an app between pools sharing only some of their clusters: %v and %v", oldPlacement.clusterNames(), newPlacement.clusterNames())
+	}
+	client := oldPlacement.primary()
 	sameNamespace := client.PoolNamespace(old.GetPool()) == client.PoolNamespace(new.GetPool())
 	if sameCluster && !sameNamespace {
 		volumes, err := volume.ListByApp(old.GetName())
@@ -1120,12 +1341,62 @@ func (p *kubernetesProvisioner) UpdateApp(old, new provision.App, w io.Writer) e
 
 func (p *kubernetesProvisioner) Shutdown(ctx context.Context) error {
 	close(p.stopCh)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for name := range p.informerClusters {
+		p.dropInformers(name)
+	}
+	return nil
+}
+
+// informerCluster holds the channel stopping the informers of a cluster and
+// a digest of the credentials they were created with.
+type informerCluster struct {
+	stopCh      chan struct{}
+	credentials string
+}
+
+// ClusterCredentialsUpdated stops and removes the informers of the cluster,
+// they're created again with its new credentials when needed.
+func (p *kubernetesProvisioner) ClusterCredentialsUpdated(c *provTypes.Cluster) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dropInformers(c.Name)
 	return nil
 }
 
+// dropStaleInformers removes the informers of the cluster created with other
+// credentials, which happens when they're rotated by another API instance.
+func (p *kubernetesProvisioner) dropStaleInformers(client *ClusterClient) {
+	if info, ok := p.informerClusters[client.Name]; ok && info.credentials != clusterCredentials(client.Cluster) {
+		p.dropInformers(client.Name)
+	}
+}
+
+func (p *kubernetesProvisioner) dropInformers(clusterName string) {
+	if info, ok := p.informerClusters[clusterName]; ok {
+		close(info.stopCh)
+		delete(p.informerClusters, clusterName)
+	}
+	delete(p.informerFactory, clusterName)
+	delete(p.podInformers, clusterName)
+	delete(p.serviceInformers, clusterName)
+	delete(p.nodeInformers, clusterName)
+}
+
+func clusterCredentials(c *provTypes.Cluster) string {
+	h := sha256.New()
+	for _, data := range [][]byte{c.CaCert, c.ClientCert, c.ClientKey} {
+		h.Write(data)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 func (p *kubernetesProvisioner) podInformerForCluster(client *ClusterClient) (v1informers.PodInformer, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.dropStaleInformers(client)
 	if informer, ok := p.podInformers[client.Name]; ok {
 		return informer, nil
 	}
@@ -1139,6 +1410,7 @@ func (p *kubernetesProvisioner) podInformerForCluster(client *ClusterClient) (v1
 func (p *kubernetesProvisioner) serviceInformerForCluster(client *ClusterClient) (v1informers.ServiceInformer, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.dropStaleInformers(client)
 	if informer, ok := p.serviceInformers[client.Name]; ok {
 		return informer, nil
 	}
@@ -1152,6 +1424,7 @@ func (p *kubernetesProvisioner) serviceInformerForCluster(client *ClusterClient)
 func (p *kubernetesProvisioner) nodeInformerForCluster(client *ClusterClient) (v1informers.NodeInformer, error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.dropStaleInformers(client)
 	if informer, ok := p.nodeInformers[client.Name]; ok {
 		return informer, nil
 	}
@@ -1163,23 +1436,37 @@ func (p *kubernetesProvisioner) nodeInformerForCluster(client *ClusterClient) (v
 }
 
 func (p *kubernetesProvisioner) withInformerFactory(client *ClusterClient, fn func(factory informers.SharedInformerFactory)) error {
-	factory, err := p.factoryForCluster(client)
+	factory, stopCh, err := p.factoryForCluster(client)
 	if err != nil {
 		return err
 	}
 	fn(factory)
-	factory.Start(p.stopCh)
-	factory.WaitForCacheSync(p.stopCh)
+	factory.Start(stopCh)
+	factory.WaitForCacheSync(stopCh)
 	return nil
 }
 
-func (p *kubernetesProvisioner) factoryForCluster(client *ClusterClient) (informers.SharedInformerFactory, error) {
+func (p *kubernetesProvisioner) factoryForCluster(client *ClusterClient) (informers.SharedInformerFactory, <-chan struct{}, error) {
 	if factory, ok := p.informerFactory[client.Name]; ok {
-		return factory, nil
+		if info, ok := p.informerClusters[client.Name]; ok {
+			return factory, info.stopCh, nil
+		}
+		return factory, p.stopCh, nil
 	}
-	var err error
-	p.informerFactory[client.Name], err = InformerFactory(client, p.stopCh)
-	return p.informerFactory[client.Name], err
+	stopCh := make(chan struct{})
+	factory, err := InformerFactory(client, stopCh)
+	if err != nil {
+		return nil, nil, err
+	}
+	if p.informerClusters == nil {
+		p.informerClusters = make(map[string]*informerCluster)
+	}
+	p.informerClusters[client.Name] = &informerCluster{
+		stopCh:      stopCh,
+		credentials: clusterCredentials(client.Cluster),
+	}
+	p.informerFactory[client.Name] = factory
+	return factory, stopCh, nil
 }
 
 var InformerFactory = func(client *ClusterClient, stopCh <-chan struct{}) (informers.SharedInformerFactory, error) {
@@ -2113,3 +2113,38 @@ func (s *S) TestProvisionerUpdateAppWithVolumeWithTwoBindsOtherCluster(c *check.
 	c.Assert(err, check.IsNil)
 	c.Assert(pvcs.Items, check.HasLen, 1)
 }
+
+func (s *S) TestClusterCredentialsUpdatedDropsInformers(c *check.C) {
+	_, err := s.p.podInformerForCluster(s.clusterClient)
+	c.Assert(err, check.IsNil)
+	_, err = s.p.serviceInformerForCluster(s.clusterClient)
+	c.Assert(err, check.IsNil)
+	err = s.p.ClusterCredentialsUpdated(s.clusterClient.Cluster)
+	c.Assert(err, check.IsNil)
+	c.Assert(s.p.informerFactory, check.HasLen, 0)
+	c.Assert(s.p.podInformers, check.HasLen, 0)
+	c.Assert(s.p.serviceInformers, check.HasLen, 0)
+	_, err = s.p.podInformerForCluster(s.clusterClient)
+	c.Assert(err, check.IsNil)
+	c.Assert(s.p.informerClusters[s.clusterClient.Name], check.NotNil)
+}
+
+func (s *S) TestInformersRecreatedWithNewCredentials(c *check.C) {
+	err := s.p.ClusterCredentialsUpdated(s.clusterClient.Cluster)
+	c.Assert(err, check.IsNil)
+	informer, err := s.p.podInformerForCluster(s.clusterClient)
+	c.Assert(err, check.IsNil)
+	stopCh := s.p.informerClusters[s.clusterClient.Name].stopCh
+	sameInformer, err := s.p.podInformerForCluster(s.clusterClient)
+	c.Assert(err, check.IsNil)
+	c.Assert(sameInformer, check.Equals, informer)
+	s.clusterClient.Cluster.ClientKey = []byte("rotated-key")
+	newInformer, err := s.p.podInformerForCluster(s.clusterClient)
+	c.Assert(err, check.IsNil)
+	c.Assert(newInformer, check.Not(check.Equals), informer)
+	select {
+	case <-stopCh:
+	default:
+		c.Fatal("informers created with previous credentials were not stopped")
+	}
+}
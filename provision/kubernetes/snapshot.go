@@ -0,0 +1,407 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/volume"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	volumeSnapshotGroup      = "volumesnapshot.external-storage.k8s.io"
+	volumeSnapshotVersion    = "v1"
+	volumeSnapshotKind       = "VolumeSnapshot"
+	volumeSnapshotResource   = "volumesnapshots"
+	volumeSnapshotAnnotation = "snapshot.alpha.kubernetes.io/snapshot"
+
+	defaultSnapshotStorageClass  = "snapshot-promoter"
+	defaultVolumeSnapshotTimeout = 10 * time.Minute
+)
+
+// VolumeSnapshot is the representation of the VolumeSnapshot custom resource
+// handled by the kubernetes external-storage snapshot controller.
+type VolumeSnapshot struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              VolumeSnapshotSpec   `json:"spec"`
+	Status            VolumeSnapshotStatus `json:"status,omitempty"`
+}
+
+type VolumeSnapshotSpec struct {
+	PersistentVolumeClaimName string `json:"persistentVolumeClaimName"`
+	SnapshotDataName          string `json:"snapshotDataName,omitempty"`
+}
+
+type VolumeSnapshotStatus struct {
+	Conditions []VolumeSnapshotCondition `json:"conditions,omitempty"`
+}
+
+type VolumeSnapshotCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+func (s *VolumeSnapshot) ready() (bool, error) {
+	for _, cond := range s.Status.Conditions {
+		if cond.Status != string(apiv1.ConditionTrue) {
+			continue
+		}
+		switch cond.Type {
+		case "Ready":
+			return true, nil
+		case "Error":
+			return false, errors.Errorf("error creating volume snapshot %q: %s", s.Name, cond.Message)
+		}
+	}
+	return false, nil
+}
+
+// VolumeSnapshotInterface manages VolumeSnapshot resources in a cluster.
+type VolumeSnapshotInterface interface {
+	Create(snap *VolumeSnapshot) (*VolumeSnapshot, error)
+	Get(namespace, name string) (*VolumeSnapshot, error)
+	Delete(namespace, name string) error
+}
+
+var VolumeSnapshotClientForConfig = func(conf *rest.Config) (VolumeSnapshotInterface, error) {
+	cfg := *conf
+	cfg.GroupVersion = &schema.GroupVersion{Group: volumeSnapshotGroup, Version: volumeSnapshotVersion}
+	cfg.APIPath = "/apis"
+	cfg.ContentType = runtime.ContentTypeJSON
+	cfg.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: scheme.Codecs}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	cli, err := rest.RESTClientFor(&cfg)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &volumeSnapshotClient{rest: cli}, nil
+}
+
+type volumeSnapshotClient struct {
+	rest rest.Interface
+}
+
+func (c *volumeSnapshotClient) Create(snap *VolumeSnapshot) (*VolumeSnapshot, error) {
+	snap.APIVersion = volumeSnapshotGroup + "/" + volumeSnapshotVersion
+	snap.Kind = volumeSnapshotKind
+	data, err := json.Marshal(snap)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	body, err := c.rest.Post().
+		Namespace(snap.Namespace).
+		Resource(volumeSnapshotResource).
+		Body(data).
+		Do().
+		Raw()
+	if err != nil {
+		return nil, err
+	}
+	var result VolumeSnapshot
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &result, nil
+}
+
+func (c *volumeSnapshotClient) Get(namespace, name string) (*VolumeSnapshot, error) {
+	body, err := c.rest.Get().
+		Namespace(namespace).
+		Resource(volumeSnapshotResource).
+		Name(name).
+		Do().
+		Raw()
+	if err != nil {
+		return nil, err
+	}
+	var result VolumeSnapshot
+	err = json.Unmarshal(body, &result)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &result, nil
+}
+
+func (c *volumeSnapshotClient) Delete(namespace, name string) error {
+	return c.rest.Delete().
+		Namespace(namespace).
+		Resource(volumeSnapshotResource).
+		Name(name).
+		Do().
+		Error()
+}
+
+func volumeSnapshotTimeout() time.Duration {
+	timeout, _ := config.GetFloat("kubernetes:volume-snapshot-timeout")
+	if timeout != 0 {
+		return time.Duration(timeout * float64(time.Second))
+	}
+	return defaultVolumeSnapshotTimeout
+}
+
+func snapshotVolume(client *ClusterClient, volumeName, snapshotName string) (int64, error) {
+	v, err := volume.Load(volumeName)
+	if err != nil {
+		return 0, err
+	}
+	namespace, err := getNamespaceForVolume(client, v)
+	if err != nil {
+		return 0, err
+	}
+	pvc, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(volumeClaimName(v.Name), metav1.GetOptions{})
+	if err != nil {
+		if k8sErrors.IsNotFound(err) {
+			return 0, errors.Errorf("volume %q is not provisioned", v.Name)
+		}
+		return 0, errors.WithStack(err)
+	}
+	snapCli, err := VolumeSnapshotClientForConfig(client.restConfig)
+	if err != nil {
+		return 0, err
+	}
+	labelSet := provision.VolumeLabels(provision.VolumeLabelsOpts{
+		Name:        v.Name,
+		Provisioner: provisionerName,
+		Prefix:      tsuruLabelPrefix,
+		Pool:        v.Pool,
+		Plan:        v.Plan.Name,
+	})
+	name := volumeSnapshotName(v.Name, snapshotName)
+	_, err = snapCli.Create(&VolumeSnapshot{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labelSet.ToLabels(),
+		},
+		Spec: VolumeSnapshotSpec{
+			PersistentVolumeClaimName: pvc.Name,
+		},
+	})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), volumeSnapshotTimeout())
+	defer cancel()
+	err = waitFor(ctx, func() (bool, error) {
+		snap, err := snapCli.Get(namespace, name)
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		return snap.ready()
+	}, nil)
+	if err != nil {
+		return 0, err
+	}
+	size, ok := pvc.Status.Capacity[apiv1.ResourceStorage]
+	if !ok {
+		size = pvc.Spec.Resources.Requests[apiv1.ResourceStorage]
+	}
+	return size.Value(), nil
+}
+
+// restoreVolume replaces the persistent volume claim of a volume with a
+// claim created from the snapshot. The restored claim is created first, under
+// a temporary name, and the claim of the volume is only replaced after it is
+// bound. Its persistent volume is retained while the claims are swapped, so a
+// failure never leaves the volume without data.
+func restoreVolume(client *ClusterClient, volumeName, snapshotName string) error {
+	v, err := volume.Load(volumeName)
+	if err != nil {
+		return err
+	}
+	namespace, err := getNamespaceForVolume(client, v)
+	if err != nil {
+		return err
+	}
+	snapCli, err := VolumeSnapshotClientForConfig(client.restConfig)
+	if err != nil {
+		return err
+	}
+	snapName := volumeSnapshotName(v.Name, snapshotName)
+	snap, err := snapCli.Get(namespace, snapName)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	ready, err := snap.ready()
+	if err != nil {
+		return err
+	}
+	if !ready {
+		return errors.Errorf("volume snapshot %q is not ready", snapName)
+	}
+	claims := client.CoreV1().PersistentVolumeClaims(namespace)
+	oldPVC, err := claims.Get(volumeClaimName(v.Name), metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	storageClass, _ := config.GetString("kubernetes:volume-snapshot-storage-class")
+	if storageClass == "" {
+		storageClass = defaultSnapshotStorageClass
+	}
+	restoredPVC, err := claims.Create(&apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   restoreClaimName(oldPVC.Name),
+			Labels: oldPVC.Labels,
+			Annotations: map[string]string{
+				volumeSnapshotAnnotation: snapName,
+			},
+		},
+		Spec: apiv1.PersistentVolumeClaimSpec{
+			Resources:        oldPVC.Spec.Resources,
+			AccessModes:      oldPVC.Spec.AccessModes,
+			StorageClassName: &storageClass,
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	restoredPVC, err = waitForClaimBound(client, namespace, restoredPVC.Name, volumeSnapshotTimeout())
+	if err != nil {
+		if delErr := claims.Delete(restoreClaimName(oldPVC.Name), &metav1.DeleteOptions{}); delErr != nil && !k8sErrors.IsNotFound(delErr) {
+			log.Errorf("[volume restore] unable to remove claim %q: %v", restoreClaimName(oldPVC.Name), delErr)
+		}
+		return err
+	}
+	volumes := client.CoreV1().PersistentVolumes()
+	pv, err := volumes.Get(restoredPVC.Spec.VolumeName, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	reclaimPolicy := pv.Spec.PersistentVolumeReclaimPolicy
+	pv.Spec.PersistentVolumeReclaimPolicy = apiv1.PersistentVolumeReclaimRetain
+	pv, err = volumes.Update(pv)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = deleteClaim(client, namespace, restoredPVC.Name)
+	if err != nil {
+		return err
+	}
+	pv.Spec.ClaimRef = &apiv1.ObjectReference{
+		Kind:      "PersistentVolumeClaim",
+		Namespace: namespace,
+		Name:      oldPVC.Name,
+	}
+	pv, err = volumes.Update(pv)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	err = deleteVolume(client, v.Name)
+	if err != nil {
+		return err
+	}
+	err = waitForClaimDeleted(client, namespace, oldPVC.Name)
+	if err != nil {
+		return err
+	}
+	_, err = claims.Create(&apiv1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        oldPVC.Name,
+			Labels:      oldPVC.Labels,
+			Annotations: restoredPVC.Annotations,
+		},
+		Spec: apiv1.PersistentVolumeClaimSpec{
+			Resources:        oldPVC.Spec.Resources,
+			AccessModes:      oldPVC.Spec.AccessModes,
+			StorageClassName: &storageClass,
+			VolumeName:       pv.Name,
+		},
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = waitForClaimBound(client, namespace, oldPVC.Name, getKubeConfig().APITimeout)
+	if err != nil {
+		return err
+	}
+	pv, err = volumes.Get(pv.Name, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	pv.Spec.PersistentVolumeReclaimPolicy = reclaimPolicy
+	_, err = volumes.Update(pv)
+	return errors.WithStack(err)
+}
+
+func restoreClaimName(claimName string) string {
+	return claimName + "-restore"
+}
+
+func waitForClaimBound(client *ClusterClient, namespace, name string, timeout time.Duration) (*apiv1.PersistentVolumeClaim, error) {
+	var pvc *apiv1.PersistentVolumeClaim
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	err := waitFor(ctx, func() (bool, error) {
+		var err error
+		pvc, err = client.CoreV1().PersistentVolumeClaims(namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return false, errors.WithStack(err)
+		}
+		return pvc.Status.Phase == apiv1.ClaimBound, nil
+	}, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "waiting for claim %q to be bound", name)
+	}
+	return pvc, nil
+}
+
+func deleteClaim(client *ClusterClient, namespace, name string) error {
+	err := client.CoreV1().PersistentVolumeClaims(namespace).Delete(name, &metav1.DeleteOptions{})
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+	return waitForClaimDeleted(client, namespace, name)
+}
+
+func waitForClaimDeleted(client *ClusterClient, namespace, name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), getKubeConfig().APITimeout)
+	defer cancel()
+	return waitFor(ctx, func() (bool, error) {
+		_, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(name, metav1.GetOptions{})
+		if k8sErrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, errors.WithStack(err)
+	}, nil)
+}
+
+func deleteVolumeSnapshot(client *ClusterClient, volumeName, snapshotName string) error {
+	v, err := volume.Load(volumeName)
+	if err != nil {
+		return err
+	}
+	namespace, err := getNamespaceForVolume(client, v)
+	if err != nil {
+		return err
+	}
+	snapCli, err := VolumeSnapshotClientForConfig(client.restConfig)
+	if err != nil {
+		return err
+	}
+	err = snapCli.Delete(namespace, volumeSnapshotName(v.Name, snapshotName))
+	if err != nil && !k8sErrors.IsNotFound(err) {
+		return errors.WithStack(err)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package kubernetes
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"github.com/tsuru/tsuru/volume"
+	"gopkg.in/check.v1"
+	apiv1 "k8s.io/api/core/v1"
+	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kfake "k8s.io/client-go/kubernetes/typed/core/v1/fake"
+	"k8s.io/client-go/rest"
+	ktesting "k8s.io/client-go/testing"
+)
+
+type fakeVolumeSnapshotClient struct {
+	snapshots map[string]*VolumeSnapshot
+}
+
+func (f *fakeVolumeSnapshotClient) Create(snap *VolumeSnapshot) (*VolumeSnapshot, error) {
+	snap.Status.Conditions = []VolumeSnapshotCondition{{Type: "Ready", Status: string(apiv1.ConditionTrue)}}
+	f.snapshots[snap.Namespace+"/"+snap.Name] = snap
+	return snap, nil
+}
+
+func (f *fakeVolumeSnapshotClient) Get(namespace, name string) (*VolumeSnapshot, error) {
+	snap, ok := f.snapshots[namespace+"/"+name]
+	if !ok {
+		return nil, k8sErrors.NewNotFound(schema.GroupResource{Group: volumeSnapshotGroup, Resource: volumeSnapshotResource}, name)
+	}
+	return snap, nil
+}
+
+func (f *fakeVolumeSnapshotClient) Delete(namespace, name string) error {
+	if _, ok := f.snapshots[namespace+"/"+name]; !ok {
+		return k8sErrors.NewNotFound(schema.GroupResource{Group: volumeSnapshotGroup, Resource: volumeSnapshotResource}, name)
+	}
+	delete(f.snapshots, namespace+"/"+name)
+	return nil
+}
+
+func (s *S) fakeSnapshotClient() (*fakeVolumeSnapshotClient, func()) {
+	fake := &fakeVolumeSnapshotClient{snapshots: map[string]*VolumeSnapshot{}}
+	old := VolumeSnapshotClientForConfig
+	VolumeSnapshotClientForConfig = func(conf *rest.Config) (VolumeSnapshotInterface, error) {
+		return fake, nil
+	}
+	return fake, func() { VolumeSnapshotClientForConfig = old }
+}
+
+func (s *S) createSnapshotTestVolume(c *check.C) (*volume.Volume, string) {
+	config.Set("volume-plans:p1:kubernetes:storage-class", "my-class")
+	a := provisiontest.NewFakeApp("myapp", "python", 0)
+	err := s.p.Provision(a)
+	c.Assert(err, check.IsNil)
+	v := volume.Volume{
+		Name: "v1",
+		Opts: map[string]string{
+			"capacity":     "20Gi",
+			"access-modes": string(apiv1.ReadWriteOnce),
+		},
+		Plan:      volume.VolumePlan{Name: "p1"},
+		Pool:      "test-default",
+		TeamOwner: "admin",
+	}
+	err = v.Create()
+	c.Assert(err, check.IsNil)
+	err = v.BindApp(a.GetName(), "/mnt", false)
+	c.Assert(err, check.IsNil)
+	_, _, err = createVolumesForApp(s.clusterClient, a)
+	c.Assert(err, check.IsNil)
+	err = v.UnbindApp(a.GetName(), "/mnt")
+	c.Assert(err, check.IsNil)
+	return &v, s.clusterClient.PoolNamespace(v.Pool)
+}
+
+func (s *S) TestSnapshotVolume(c *check.C) {
+	defer config.Unset("volume-plans")
+	fake, rollback := s.fakeSnapshotClient()
+	defer rollback()
+	v, ns := s.createSnapshotTestVolume(c)
+	size, err := s.p.SnapshotVolume(v.Name, "snap1", v.Pool)
+	c.Assert(err, check.IsNil)
+	c.Assert(size, check.Equals, int64(20*1024*1024*1024))
+	snap, err := fake.Get(ns, "v1-snapshot-snap1")
+	c.Assert(err, check.IsNil)
+	c.Assert(snap.Spec.PersistentVolumeClaimName, check.Equals, volumeClaimName(v.Name))
+	c.Assert(snap.Labels["tsuru.io/volume-name"], check.Equals, "v1")
+}
+
+// bindRestoredClaims makes restored claims bound to the given persistent
+// volume, as done by the snapshot provisioner, and every other claim bound as
+// soon as it is created.
+func (s *S) bindRestoredClaims(c *check.C, ns, pvName string, bind bool) {
+	fakeClaims, ok := s.client.CoreV1().PersistentVolumeClaims(ns).(*kfake.FakePersistentVolumeClaims)
+	c.Assert(ok, check.Equals, true)
+	fakeClaims.Fake.PrependReactor("create", "persistentvolumeclaims", func(action ktesting.Action) (bool, runtime.Object, error) {
+		pvc := action.(ktesting.CreateAction).GetObject().(*apiv1.PersistentVolumeClaim)
+		if pvc.Name == restoreClaimName(volumeClaimName("v1")) {
+			if !bind {
+				return false, nil, nil
+			}
+			pvc.Spec.VolumeName = pvName
+		}
+		pvc.Status.Phase = apiv1.ClaimBound
+		return false, nil, nil
+	})
+}
+
+func (s *S) TestRestoreVolume(c *check.C) {
+	defer config.Unset("volume-plans")
+	_, rollback := s.fakeSnapshotClient()
+	defer rollback()
+	v, ns := s.createSnapshotTestVolume(c)
+	_, err := s.p.SnapshotVolume(v.Name, "snap1", v.Pool)
+	c.Assert(err, check.IsNil)
+	_, err = s.client.CoreV1().PersistentVolumes().Create(&apiv1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "restored-pv"},
+		Spec: apiv1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: apiv1.PersistentVolumeReclaimDelete,
+			ClaimRef:                      &apiv1.ObjectReference{Namespace: ns, Name: restoreClaimName(volumeClaimName(v.Name))},
+		},
+	})
+	c.Assert(err, check.IsNil)
+	s.bindRestoredClaims(c, ns, "restored-pv", true)
+	err = s.p.RestoreVolume(v.Name, "snap1", v.Pool)
+	c.Assert(err, check.IsNil)
+	pvc, err := s.client.CoreV1().PersistentVolumeClaims(ns).Get(volumeClaimName(v.Name), metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(pvc.Annotations, check.DeepEquals, map[string]string{
+		volumeSnapshotAnnotation: "v1-snapshot-snap1",
+	})
+	c.Assert(pvc.Spec.VolumeName, check.Equals, "restored-pv")
+	c.Assert(*pvc.Spec.StorageClassName, check.Equals, defaultSnapshotStorageClass)
+	c.Assert(pvc.Spec.AccessModes, check.DeepEquals, []apiv1.PersistentVolumeAccessMode{apiv1.ReadWriteOnce})
+	_, err = s.client.CoreV1().PersistentVolumeClaims(ns).Get(restoreClaimName(volumeClaimName(v.Name)), metav1.GetOptions{})
+	c.Assert(k8sErrors.IsNotFound(err), check.Equals, true)
+	pv, err := s.client.CoreV1().PersistentVolumes().Get("restored-pv", metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(pv.Spec.ClaimRef.Name, check.Equals, volumeClaimName(v.Name))
+	c.Assert(pv.Spec.PersistentVolumeReclaimPolicy, check.Equals, apiv1.PersistentVolumeReclaimDelete)
+}
+
+func (s *S) TestRestoreVolumeNotBoundKeepsVolume(c *check.C) {
+	config.Set("kubernetes:volume-snapshot-timeout", 0.1)
+	defer config.Unset("kubernetes:volume-snapshot-timeout")
+	defer config.Unset("volume-plans")
+	_, rollback := s.fakeSnapshotClient()
+	defer rollback()
+	v, ns := s.createSnapshotTestVolume(c)
+	_, err := s.p.SnapshotVolume(v.Name, "snap1", v.Pool)
+	c.Assert(err, check.IsNil)
+	oldPVC, err := s.client.CoreV1().PersistentVolumeClaims(ns).Get(volumeClaimName(v.Name), metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	s.bindRestoredClaims(c, ns, "", false)
+	err = s.p.RestoreVolume(v.Name, "snap1", v.Pool)
+	c.Assert(err, check.ErrorMatches, `.*waiting for claim "v1-tsuru-claim-restore" to be bound.*`)
+	pvc, err := s.client.CoreV1().PersistentVolumeClaims(ns).Get(volumeClaimName(v.Name), metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(pvc, check.DeepEquals, oldPVC)
+	_, err = s.client.CoreV1().PersistentVolumeClaims(ns).Get(restoreClaimName(volumeClaimName(v.Name)), metav1.GetOptions{})
+	c.Assert(k8sErrors.IsNotFound(err), check.Equals, true)
+}
+
+func (s *S) TestRestoreVolumeSnapshotNotFound(c *check.C) {
+	defer config.Unset("volume-plans")
+	_, rollback := s.fakeSnapshotClient()
+	defer rollback()
+	v, ns := s.createSnapshotTestVolume(c)
+	err := s.p.RestoreVolume(v.Name, "snap1", v.Pool)
+	c.Assert(k8sErrors.IsNotFound(errors.Cause(err)), check.Equals, true)
+	_, err = s.client.CoreV1().PersistentVolumeClaims(ns).Get(volumeClaimName(v.Name), metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestDeleteVolumeSnapshot(c *check.C) {
+	defer config.Unset("volume-plans")
+	fake, rollback := s.fakeSnapshotClient()
+	defer rollback()
+	v, _ := s.createSnapshotTestVolume(c)
+	_, err := s.p.SnapshotVolume(v.Name, "snap1", v.Pool)
+	c.Assert(err, check.IsNil)
+	err = s.p.DeleteVolumeSnapshot(v.Name, "snap1", v.Pool)
+	c.Assert(err, check.IsNil)
+	c.Assert(fake.snapshots, check.HasLen, 0)
+	err = s.p.DeleteVolumeSnapshot(v.Name, "snap1", v.Pool)
+	c.Assert(err, check.IsNil)
+}
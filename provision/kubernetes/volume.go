@@ -6,9 +6,11 @@ package kubernetes
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 
 	"github.com/pkg/errors"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/set"
 	"github.com/tsuru/tsuru/volume"
@@ -297,3 +299,128 @@ func getNamespaceForVolume(client *ClusterClient, v *volume.Volume) (string, err
 	}
 	return namespace, nil
 }
+
+func resizeVolume(client *ClusterClient, name, capacity string) error {
+	v, err := volume.Load(name)
+	if err != nil {
+		return err
+	}
+	opts, err := validateVolume(v)
+	if err != nil {
+		return err
+	}
+	if !opts.isPersistent() || opts.Plugin != "" {
+		return &tsuruErrors.ValidationError{Message: "volume resize is only supported for volumes using a storage-class"}
+	}
+	newCapacity, err := resource.ParseQuantity(capacity)
+	if err != nil {
+		return &tsuruErrors.ValidationError{Message: fmt.Sprintf("unable to parse capacity: %v", err)}
+	}
+	if newCapacity.Cmp(opts.Capacity) <= 0 {
+		return &tsuruErrors.ValidationError{
+			Message: fmt.Sprintf("new capacity %s must be greater than current capacity %s", newCapacity.String(), opts.Capacity.String()),
+		}
+	}
+	storageClass, err := client.StorageV1().StorageClasses().Get(opts.StorageClass, metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if storageClass.AllowVolumeExpansion == nil || !*storageClass.AllowVolumeExpansion {
+		return &tsuruErrors.ValidationError{Message: fmt.Sprintf("storage class %q does not allow volume expansion", opts.StorageClass)}
+	}
+	namespace, err := getNamespaceForVolume(client, v)
+	if err != nil {
+		return err
+	}
+	pvc, err := client.CoreV1().PersistentVolumeClaims(namespace).Get(volumeClaimName(v.Name), metav1.GetOptions{})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if pvc.Spec.Resources.Requests == nil {
+		pvc.Spec.Resources.Requests = apiv1.ResourceList{}
+	}
+	pvc.Spec.Resources.Requests[apiv1.ResourceStorage] = newCapacity
+	_, err = client.CoreV1().PersistentVolumeClaims(namespace).Update(pvc)
+	return errors.WithStack(err)
+}
+
+type kubeletStatsSummary struct {
+	Pods []struct {
+		Volumes []struct {
+			UsedBytes      *uint64 `json:"usedBytes"`
+			AvailableBytes *uint64 `json:"availableBytes"`
+			PVCRef         *struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"pvcRef"`
+		} `json:"volume"`
+	} `json:"pods"`
+}
+
+// nodeStatsSummary returns the raw stats summary reported by the kubelet
+// running in the node.
+var nodeStatsSummary = func(client *ClusterClient, nodeName string) ([]byte, error) {
+	return client.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("stats/summary").
+		DoRaw()
+}
+
+func volumeUsage(client *ClusterClient, name string) (*provision.VolumeUsage, error) {
+	v, err := volume.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := getNamespaceForVolume(client, v)
+	if err != nil {
+		return nil, err
+	}
+	claimName := volumeClaimName(v.Name)
+	pods, err := client.CoreV1().Pods(namespace).List(metav1.ListOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var nodes []string
+	seen := set.Set{}
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase != apiv1.PodRunning {
+			continue
+		}
+		for _, podVol := range pod.Spec.Volumes {
+			if podVol.PersistentVolumeClaim != nil && podVol.PersistentVolumeClaim.ClaimName == claimName {
+				if !seen.Includes(pod.Spec.NodeName) {
+					seen.Add(pod.Spec.NodeName)
+					nodes = append(nodes, pod.Spec.NodeName)
+				}
+			}
+		}
+	}
+	for _, nodeName := range nodes {
+		data, err := nodeStatsSummary(client, nodeName)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		var summary kubeletStatsSummary
+		err = json.Unmarshal(data, &summary)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		for _, pod := range summary.Pods {
+			for _, podVol := range pod.Volumes {
+				if podVol.PVCRef == nil || podVol.PVCRef.Name != claimName || podVol.PVCRef.Namespace != namespace {
+					continue
+				}
+				if podVol.UsedBytes == nil || podVol.AvailableBytes == nil {
+					continue
+				}
+				return &provision.VolumeUsage{
+					Used:      int64(*podVol.UsedBytes),
+					Available: int64(*podVol.AvailableBytes),
+				}, nil
+			}
+		}
+	}
+	return nil, nil
+}
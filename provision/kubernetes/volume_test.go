@@ -6,11 +6,14 @@ package kubernetes
 
 import (
 	"github.com/tsuru/config"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/provision"
 	tsuruv1 "github.com/tsuru/tsuru/provision/kubernetes/pkg/apis/tsuru/v1"
 	"github.com/tsuru/tsuru/provision/provisiontest"
 	"github.com/tsuru/tsuru/volume"
 	"gopkg.in/check.v1"
 	apiv1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -412,3 +415,116 @@ func (s *S) TestVolumeExists(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(exists, check.Equals, true)
 }
+
+func (s *S) TestResizeVolume(c *check.C) {
+	config.Set("volume-plans:p1:kubernetes:storage-class", "my-class")
+	defer config.Unset("volume-plans")
+	allowExpansion := true
+	_, err := s.client.StorageV1().StorageClasses().Create(&storagev1.StorageClass{
+		ObjectMeta:           metav1.ObjectMeta{Name: "my-class"},
+		AllowVolumeExpansion: &allowExpansion,
+	})
+	c.Assert(err, check.IsNil)
+	a := provisiontest.NewFakeApp("myapp", "python", 0)
+	err = s.p.Provision(a)
+	c.Assert(err, check.IsNil)
+	v := volume.Volume{
+		Name: "v1",
+		Opts: map[string]string{
+			"capacity":     "20Gi",
+			"access-modes": string(apiv1.ReadWriteOnce),
+		},
+		Plan:      volume.VolumePlan{Name: "p1"},
+		Pool:      "test-default",
+		TeamOwner: "admin",
+	}
+	err = v.Create()
+	c.Assert(err, check.IsNil)
+	err = v.BindApp(a.GetName(), "/mnt", false)
+	c.Assert(err, check.IsNil)
+	_, _, err = createVolumesForApp(s.clusterClient, a)
+	c.Assert(err, check.IsNil)
+	err = s.p.ResizeVolume("v1", "test-default", "10Gi")
+	c.Assert(err, check.ErrorMatches, `new capacity 10Gi must be greater than current capacity 20Gi`)
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	err = s.p.ResizeVolume("v1", "test-default", "30Gi")
+	c.Assert(err, check.IsNil)
+	ns, err := s.client.AppNamespace(a)
+	c.Assert(err, check.IsNil)
+	pvc, err := s.client.CoreV1().PersistentVolumeClaims(ns).Get(volumeClaimName("v1"), metav1.GetOptions{})
+	c.Assert(err, check.IsNil)
+	requested := pvc.Spec.Resources.Requests[apiv1.ResourceStorage]
+	c.Assert(requested.String(), check.Equals, "30Gi")
+}
+
+func (s *S) TestResizeVolumeStorageClassNotExpandable(c *check.C) {
+	config.Set("volume-plans:p1:kubernetes:storage-class", "my-class")
+	defer config.Unset("volume-plans")
+	_, err := s.client.StorageV1().StorageClasses().Create(&storagev1.StorageClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "my-class"},
+	})
+	c.Assert(err, check.IsNil)
+	v := volume.Volume{
+		Name: "v1",
+		Opts: map[string]string{
+			"capacity":     "20Gi",
+			"access-modes": string(apiv1.ReadWriteOnce),
+		},
+		Plan:      volume.VolumePlan{Name: "p1"},
+		Pool:      "test-default",
+		TeamOwner: "admin",
+	}
+	err = v.Create()
+	c.Assert(err, check.IsNil)
+	err = s.p.ResizeVolume("v1", "test-default", "30Gi")
+	c.Assert(err, check.ErrorMatches, `storage class "my-class" does not allow volume expansion`)
+}
+
+func (s *S) TestVolumeUsage(c *check.C) {
+	config.Set("volume-plans:p1:kubernetes:storage-class", "my-class")
+	defer config.Unset("volume-plans")
+	v := volume.Volume{
+		Name: "v1",
+		Opts: map[string]string{
+			"capacity":     "20Gi",
+			"access-modes": string(apiv1.ReadWriteOnce),
+		},
+		Plan:      volume.VolumePlan{Name: "p1"},
+		Pool:      "test-default",
+		TeamOwner: "admin",
+	}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	ns := s.clusterClient.PoolNamespace("test-default")
+	usage, err := s.p.VolumeUsage("v1", "test-default")
+	c.Assert(err, check.IsNil)
+	c.Assert(usage, check.IsNil)
+	_, err = s.client.CoreV1().Pods(ns).Create(&apiv1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "pod1", Namespace: ns},
+		Spec: apiv1.PodSpec{
+			NodeName: "node1",
+			Volumes: []apiv1.Volume{{
+				Name: volumeName("v1"),
+				VolumeSource: apiv1.VolumeSource{
+					PersistentVolumeClaim: &apiv1.PersistentVolumeClaimVolumeSource{ClaimName: volumeClaimName("v1")},
+				},
+			}},
+		},
+		Status: apiv1.PodStatus{Phase: apiv1.PodRunning},
+	})
+	c.Assert(err, check.IsNil)
+	var calledNode string
+	oldStats := nodeStatsSummary
+	defer func() { nodeStatsSummary = oldStats }()
+	nodeStatsSummary = func(client *ClusterClient, nodeName string) ([]byte, error) {
+		calledNode = nodeName
+		return []byte(`{"pods": [{"volume": [
+			{"usedBytes": 1, "availableBytes": 2},
+			{"usedBytes": 100, "availableBytes": 300, "pvcRef": {"name": "v1-tsuru-claim", "namespace": "` + ns + `"}}
+		]}]}`), nil
+	}
+	usage, err = s.p.VolumeUsage("v1", "test-default")
+	c.Assert(err, check.IsNil)
+	c.Assert(calledNode, check.Equals, "node1")
+	c.Assert(usage, check.DeepEquals, &provision.VolumeUsage{Used: 100, Available: 300})
+}
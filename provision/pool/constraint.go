@@ -18,7 +18,7 @@ import (
 
 var (
 	ErrInvalidConstraintType = errors.Errorf("invalid constraint type. Valid types are: %s", validConstraintTypes)
-	validConstraintTypes     = []poolConstraintType{ConstraintTypeTeam, ConstraintTypeService, ConstraintTypeRouter, ConstraintTypePlan}
+	validConstraintTypes     = []poolConstraintType{ConstraintTypeTeam, ConstraintTypeService, ConstraintTypeRouter, ConstraintTypePlan, ConstraintTypeServicePlan}
 )
 
 type poolConstraintType string
@@ -28,6 +28,10 @@ const (
 	ConstraintTypeRouter  = poolConstraintType("router")
 	ConstraintTypeService = poolConstraintType("service")
 	ConstraintTypePlan    = poolConstraintType("plan")
+
+	// ConstraintTypeServicePlan restricts service plans available to apps in
+	// a pool. Values are in the <service>:<plan> format, e.g. mysql:large.
+	ConstraintTypeServicePlan = poolConstraintType("service-plan")
 )
 
 type regexpCache struct {
@@ -33,26 +33,32 @@ var (
 	ErrPoolHasNoRouter                = errors.New("no router found for pool")
 	ErrPoolHasNoService               = errors.New("no service found for pool")
 	ErrPoolHasNoPlan                  = errors.New("no plan found for pool")
+	ErrInvalidDeployApprovals         = &tsuruErrors.ValidationError{Message: "number of deploy approvals must not be negative"}
 )
 
 type Pool struct {
 	Name        string `bson:"_id"`
 	Default     bool
 	Provisioner string
+	// DeployApprovals is the number of approvals a deploy to an app in the
+	// pool needs before running. Zero means deploys are not gated.
+	DeployApprovals int `bson:",omitempty"`
 }
 
 type AddPoolOptions struct {
-	Name        string
-	Public      bool
-	Default     bool
-	Force       bool
-	Provisioner string
+	Name            string
+	Public          bool
+	Default         bool
+	Force           bool
+	Provisioner     string
+	DeployApprovals int
 }
 
 type UpdatePoolOptions struct {
-	Default *bool
-	Public  *bool
-	Force   bool
+	Default         *bool
+	Public          *bool
+	Force           bool
+	DeployApprovals *int
 }
 
 func (p *Pool) GetProvisioner() (provision.Provisioner, error) {
@@ -106,6 +112,25 @@ func (p *Pool) GetPlans() ([]string, error) {
 	return nil, ErrPoolHasNoPlan
 }
 
+// ValidateServicePlan checks whether instances using the plan of the service
+// may be bound to apps in the pool.
+func (p *Pool) ValidateServicePlan(service, plan string) error {
+	constraints, err := getConstraintsForPool(p.Name, ConstraintTypeServicePlan)
+	if err != nil {
+		return err
+	}
+	c := constraints[ConstraintTypeServicePlan]
+	if c == nil || c.check(servicePlanValue(service, plan)) {
+		return nil
+	}
+	msg := fmt.Sprintf("plan %q of service %q is not available for pool %q", plan, service, p.Name)
+	return &tsuruErrors.ValidationError{Message: msg}
+}
+
+func servicePlanValue(service, plan string) string {
+	return service + ":" + plan
+}
+
 func (p *Pool) GetDefaultRouter() (string, error) {
 	constraints, err := getConstraintsForPool(p.Name, ConstraintTypeRouter)
 	if err != nil {
@@ -259,6 +284,7 @@ func (p *Pool) MarshalJSON() ([]byte, error) {
 	result["public"] = teams.AllowsAll()
 	result["default"] = p.Default
 	result["provisioner"] = p.Provisioner
+	result["deploy_approvals"] = p.DeployApprovals
 	result["teams"] = resolvedConstraints[ConstraintTypeTeam]
 	result["allowed"] = resolvedConstraints
 	return json.Marshal(&result)
@@ -274,11 +300,25 @@ func (p *Pool) validate() error {
 			"starting with a letter."
 		return &tsuruErrors.ValidationError{Message: msg}
 	}
+	if p.DeployApprovals < 0 {
+		return ErrInvalidDeployApprovals
+	}
 	return nil
 }
 
+// IsProtected returns whether deploys to apps in the pool must be approved
+// before running.
+func (p *Pool) IsProtected() bool {
+	return p.DeployApprovals > 0
+}
+
 func AddPool(opts AddPoolOptions) error {
-	pool := Pool{Name: opts.Name, Default: opts.Default, Provisioner: opts.Provisioner}
+	pool := Pool{
+		Name:            opts.Name,
+		Default:         opts.Default,
+		Provisioner:     opts.Provisioner,
+		DeployApprovals: opts.DeployApprovals,
+	}
 	if err := pool.validate(); err != nil {
 		return err
 	}
@@ -507,6 +547,9 @@ func PoolUpdate(name string, opts UpdatePoolOptions) error {
 	if err != nil {
 		return err
 	}
+	if opts.DeployApprovals != nil && *opts.DeployApprovals < 0 {
+		return ErrInvalidDeployApprovals
+	}
 	if opts.Default != nil && *opts.Default {
 		err = changeDefaultPool(opts.Force)
 		if err != nil {
@@ -517,6 +560,9 @@ func PoolUpdate(name string, opts UpdatePoolOptions) error {
 	if opts.Default != nil {
 		query["default"] = *opts.Default
 	}
+	if opts.DeployApprovals != nil {
+		query["deployapprovals"] = *opts.DeployApprovals
+	}
 	if (opts.Public != nil && *opts.Public) || (opts.Default != nil && *opts.Default) {
 		errConstraint := SetPoolConstraint(&PoolConstraint{PoolExpr: name, Field: ConstraintTypeTeam, Values: []string{"*"}})
 		if errConstraint != nil {
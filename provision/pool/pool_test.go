@@ -381,6 +381,27 @@ func (s *S) TestPoolUpdate(c *check.C) {
 	c.Assert(constraint.AllowsAll(), check.Equals, true)
 }
 
+func (s *S) TestPoolUpdateDeployApprovals(c *check.C) {
+	err := AddPool(AddPoolOptions{Name: "pool1"})
+	c.Assert(err, check.IsNil)
+	approvals := 2
+	err = PoolUpdate("pool1", UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	p, err := GetPoolByName("pool1")
+	c.Assert(err, check.IsNil)
+	c.Assert(p.DeployApprovals, check.Equals, 2)
+	c.Assert(p.IsProtected(), check.Equals, true)
+	approvals = -1
+	err = PoolUpdate("pool1", UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.Equals, ErrInvalidDeployApprovals)
+	approvals = 0
+	err = PoolUpdate("pool1", UpdatePoolOptions{DeployApprovals: &approvals})
+	c.Assert(err, check.IsNil)
+	p, err = GetPoolByName("pool1")
+	c.Assert(err, check.IsNil)
+	c.Assert(p.IsProtected(), check.Equals, false)
+}
+
 func (s *S) TestPoolUpdateToDefault(c *check.C) {
 	opts := AddPoolOptions{
 		Name:    "pool1",
@@ -559,6 +580,27 @@ func (s *S) TestGetServices(c *check.C) {
 	c.Assert(services, check.DeepEquals, []string{"demacia"})
 }
 
+func (s *S) TestValidateServicePlan(c *check.C) {
+	err := AddPool(AddPoolOptions{Name: "prod"})
+	c.Assert(err, check.IsNil)
+	err = AddPool(AddPoolOptions{Name: "dev"})
+	c.Assert(err, check.IsNil)
+	err = SetPoolConstraint(&PoolConstraint{PoolExpr: "*", Field: ConstraintTypeServicePlan, Values: []string{"mysql:large"}, Blacklist: true})
+	c.Assert(err, check.IsNil)
+	err = SetPoolConstraint(&PoolConstraint{PoolExpr: "prod", Field: ConstraintTypeServicePlan, Values: []string{"*"}})
+	c.Assert(err, check.IsNil)
+	prod, err := GetPoolByName("prod")
+	c.Assert(err, check.IsNil)
+	dev, err := GetPoolByName("dev")
+	c.Assert(err, check.IsNil)
+	c.Assert(prod.ValidateServicePlan("mysql", "large"), check.IsNil)
+	c.Assert(dev.ValidateServicePlan("mysql", "small"), check.IsNil)
+	c.Assert(dev.ValidateServicePlan("redis", "large"), check.IsNil)
+	err = dev.ValidateServicePlan("mysql", "large")
+	c.Assert(err, check.FitsTypeOf, &tsuruErrors.ValidationError{})
+	c.Assert(err, check.ErrorMatches, `plan "large" of service "mysql" is not available for pool "dev"`)
+}
+
 func (s *S) TestGetDefaultRouterFromConstraint(c *check.C) {
 	config.Set("routers:router1:type", "hipache")
 	config.Set("routers:router2:type", "hipache")
@@ -21,6 +21,7 @@ import (
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/router"
 	appTypes "github.com/tsuru/tsuru/types/app"
+	provTypes "github.com/tsuru/tsuru/types/provision"
 )
 
 const (
@@ -286,9 +287,18 @@ type ExecDockerClient interface {
 	InspectExec(execId string) (*docker.ExecInspect, error)
 }
 
+// BuildImageOpts holds the options used to build an image from a Dockerfile.
+type BuildImageOpts struct {
+	Name   string
+	Image  string
+	Input  io.Reader
+	Output io.Writer
+	Ctx    context.Context
+}
+
 type BuilderKubeClient interface {
 	BuildPod(App, *event.Event, io.Reader, string) (string, error)
-	BuildImage(name, image string, inputStream io.Reader, output io.Writer, ctx context.Context) error
+	BuildImage(BuildImageOpts) error
 	ImageTagPushAndInspect(App, string, string) (*docker.Image, string, *TsuruYamlData, error)
 	DownloadFromContainer(App, string) (io.ReadCloser, error)
 }
@@ -400,6 +410,31 @@ type InitializableProvisioner interface {
 	Initialize() error
 }
 
+// ClusterCheckerProvisioner is a provisioner able to check whether one of its
+// clusters is reachable.
+type ClusterCheckerProvisioner interface {
+	CheckCluster(*provTypes.Cluster) error
+}
+
+// ClusterHealthChangeProvisioner is a provisioner reacting to one of its
+// clusters becoming unhealthy, or healthy again, as reported by the cluster
+// health monitor. The progress is written to the given writer.
+type ClusterHealthChangeProvisioner interface {
+	ClusterHealthChanged(*provTypes.Cluster, provTypes.ClusterStatus, io.Writer) error
+}
+
+// ClusterCredentialsProvisioner is a provisioner keeping connections to its
+// clusters, which must be dropped when the credentials of a cluster change.
+type ClusterCredentialsProvisioner interface {
+	ClusterCredentialsUpdated(*provTypes.Cluster) error
+}
+
+// ClusterStatusProvisioner is a provisioner able to report the version and
+// the readiness of the nodes of one of its clusters.
+type ClusterStatusProvisioner interface {
+	ClusterStatus(*provTypes.Cluster) (*provTypes.ClusterStatus, error)
+}
+
 // OptionalLogsProvisioner is a provisioner that allows optionally disabling
 // logs for a given app.
 type OptionalLogsProvisioner interface {
@@ -500,6 +535,35 @@ type VolumeProvisioner interface {
 	DeleteVolume(volumeName, pool string) error
 }
 
+// SnapshotVolumeProvisioner is a provisioner able to take point-in-time
+// copies of volumes and restore volumes from them.
+type SnapshotVolumeProvisioner interface {
+	// SnapshotVolume creates a snapshot of the volume, returning its size
+	// in bytes.
+	SnapshotVolume(volumeName, snapshotName, pool string) (int64, error)
+	RestoreVolume(volumeName, snapshotName, pool string) error
+	DeleteVolumeSnapshot(volumeName, snapshotName, pool string) error
+}
+
+// ResizableVolumeProvisioner is a provisioner able to expand the capacity of
+// already provisioned volumes.
+type ResizableVolumeProvisioner interface {
+	ResizeVolume(volumeName, pool, capacity string) error
+}
+
+// VolumeUsage holds the disk usage, in bytes, of a provisioned volume.
+type VolumeUsage struct {
+	Used      int64
+	Available int64
+}
+
+// VolumeUsageProvisioner is a provisioner able to report how much space is
+// used in its volumes. A nil usage is returned when it's not possible to
+// measure it at the moment, e.g. when the volume isn't mounted anywhere.
+type VolumeUsageProvisioner interface {
+	VolumeUsage(volumeName, pool string) (*VolumeUsage, error)
+}
+
 type CleanImageProvisioner interface {
 	CleanImage(appName string, image string) error
 }
@@ -676,6 +740,13 @@ func (e *Error) Error() string {
 type TsuruYamlData struct {
 	Hooks       TsuruYamlHooks       `bson:",omitempty"`
 	Healthcheck TsuruYamlHealthcheck `bson:",omitempty"`
+	Build       TsuruYamlBuild       `bson:",omitempty"`
+}
+
+// TsuruYamlBuild holds build settings. Cache lists directories kept between
+// builds, relative paths are relative to the application directory.
+type TsuruYamlBuild struct {
+	Cache []string `bson:",omitempty"`
 }
 
 type TsuruYamlHooks struct {
@@ -26,6 +26,7 @@ import (
 	"github.com/tsuru/tsuru/provision/dockercommon"
 	"github.com/tsuru/tsuru/router/routertest"
 	appTypes "github.com/tsuru/tsuru/types/app"
+	provTypes "github.com/tsuru/tsuru/types/provision"
 	"github.com/tsuru/tsuru/types/quota"
 )
 
@@ -34,11 +35,12 @@ var (
 	errNotProvisioned         = &provision.Error{Reason: "App is not provisioned."}
 	uniqueIpCounter     int32 = 0
 
-	_ provision.NodeProvisioner      = &FakeProvisioner{}
-	_ provision.UpdatableProvisioner = &FakeProvisioner{}
-	_ provision.Provisioner          = &FakeProvisioner{}
-	_ provision.App                  = &FakeApp{}
-	_ bind.App                       = &FakeApp{}
+	_ provision.NodeProvisioner          = &FakeProvisioner{}
+	_ provision.UpdatableProvisioner     = &FakeProvisioner{}
+	_ provision.ClusterStatusProvisioner = &FakeProvisioner{}
+	_ provision.Provisioner              = &FakeProvisioner{}
+	_ provision.App                      = &FakeApp{}
+	_ bind.App                           = &FakeApp{}
 )
 
 const fakeAppImage = "app-image"
@@ -218,6 +220,22 @@ func (a *FakeApp) RemoveInstance(instanceArgs bind.RemoveInstanceArgs) error {
 	return nil
 }
 
+func (a *FakeApp) ReplaceInstance(instanceArgs bind.ReplaceInstanceArgs) error {
+	a.serviceLock.Lock()
+	defer a.serviceLock.Unlock()
+	envs := make([]bind.ServiceEnvVar, 0, len(a.serviceEnvs)+len(instanceArgs.Envs))
+	for _, se := range a.serviceEnvs {
+		if se.ServiceName != instanceArgs.ServiceName || se.InstanceName != instanceArgs.InstanceName {
+			envs = append(envs, se)
+		}
+	}
+	a.serviceEnvs = append(envs, instanceArgs.Envs...)
+	if instanceArgs.Writer != nil {
+		instanceArgs.Writer.Write([]byte("replace instance"))
+	}
+	return nil
+}
+
 func (a *FakeApp) Logs() []string {
 	a.logMut.Lock()
 	defer a.logMut.Unlock()
@@ -377,6 +395,13 @@ type FakeProvisioner struct {
 	execsMut       sync.Mutex
 	nodes          map[string]FakeNode
 	nodeContainers map[string]int
+	snapshots      map[string][]string
+	restored       map[string]string
+	resized        map[string]string
+	volumeUsage    map[string]provision.VolumeUsage
+	clusterStatus  map[string]provTypes.ClusterStatus
+	credsUpdated   []string
+	healthChanges  []provTypes.ClusterStatus
 }
 
 func NewFakeProvisioner() *FakeProvisioner {
@@ -387,6 +412,11 @@ func NewFakeProvisioner() *FakeProvisioner {
 	p.execs = make(map[string][]provision.ExecOptions)
 	p.nodes = make(map[string]FakeNode)
 	p.nodeContainers = make(map[string]int)
+	p.snapshots = make(map[string][]string)
+	p.restored = make(map[string]string)
+	p.resized = make(map[string]string)
+	p.volumeUsage = make(map[string]provision.VolumeUsage)
+	p.clusterStatus = make(map[string]provTypes.ClusterStatus)
 	return &p
 }
 
@@ -817,6 +847,16 @@ func (p *FakeProvisioner) Reset() {
 
 	p.nodeContainers = make(map[string]int)
 
+	p.mut.Lock()
+	p.snapshots = make(map[string][]string)
+	p.restored = make(map[string]string)
+	p.resized = make(map[string]string)
+	p.volumeUsage = make(map[string]provision.VolumeUsage)
+	p.clusterStatus = make(map[string]provTypes.ClusterStatus)
+	p.credsUpdated = nil
+	p.healthChanges = nil
+	p.mut.Unlock()
+
 	for {
 		select {
 		case <-p.outputs:
@@ -1368,6 +1408,151 @@ func (p *FakeProvisioner) IsVolumeProvisioned(name, pool string) (bool, error) {
 	return false, nil
 }
 
+// FakeSnapshotSize is the size reported for every snapshot taken by the fake
+// provisioner.
+const FakeSnapshotSize = 1024
+
+func (p *FakeProvisioner) SnapshotVolume(volName, snapshotName, pool string) (int64, error) {
+	if err := p.getError("SnapshotVolume"); err != nil {
+		return 0, err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.snapshots[volName] = append(p.snapshots[volName], snapshotName)
+	return FakeSnapshotSize, nil
+}
+
+func (p *FakeProvisioner) RestoreVolume(volName, snapshotName, pool string) error {
+	if err := p.getError("RestoreVolume"); err != nil {
+		return err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if !stringInArray(snapshotName, p.snapshots[volName]) {
+		return errors.Errorf("snapshot %q not found for volume %q", snapshotName, volName)
+	}
+	p.restored[volName] = snapshotName
+	return nil
+}
+
+func (p *FakeProvisioner) DeleteVolumeSnapshot(volName, snapshotName, pool string) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	snaps := p.snapshots[volName]
+	for i := range snaps {
+		if snaps[i] == snapshotName {
+			p.snapshots[volName] = append(snaps[:i], snaps[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// VolumeSnapshots returns the names of the snapshots taken for the volume.
+func (p *FakeProvisioner) VolumeSnapshots(volName string) []string {
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	return append([]string(nil), p.snapshots[volName]...)
+}
+
+// RestoredSnapshot returns the name of the last snapshot restored in the
+// volume.
+func (p *FakeProvisioner) RestoredSnapshot(volName string) string {
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	return p.restored[volName]
+}
+
+func (p *FakeProvisioner) ResizeVolume(volName, pool, capacity string) error {
+	if err := p.getError("ResizeVolume"); err != nil {
+		return err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.resized[volName] = capacity
+	return nil
+}
+
+// ResizedVolume returns the last capacity requested for the volume.
+func (p *FakeProvisioner) ResizedVolume(volName string) string {
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	return p.resized[volName]
+}
+
+func (p *FakeProvisioner) VolumeUsage(volName, pool string) (*provision.VolumeUsage, error) {
+	if err := p.getError("VolumeUsage"); err != nil {
+		return nil, err
+	}
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	usage, ok := p.volumeUsage[volName]
+	if !ok {
+		return nil, nil
+	}
+	return &usage, nil
+}
+
+// SetVolumeUsage sets the usage reported for the volume.
+func (p *FakeProvisioner) SetVolumeUsage(volName string, usage provision.VolumeUsage) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.volumeUsage[volName] = usage
+}
+
+func (p *FakeProvisioner) ClusterStatus(c *provTypes.Cluster) (*provTypes.ClusterStatus, error) {
+	if err := p.getError("ClusterStatus"); err != nil {
+		return nil, err
+	}
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	status := p.clusterStatus[c.Name]
+	return &status, nil
+}
+
+// SetClusterStatus sets the status reported for the cluster.
+func (p *FakeProvisioner) SetClusterStatus(clusterName string, status provTypes.ClusterStatus) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.clusterStatus[clusterName] = status
+}
+
+func (p *FakeProvisioner) ClusterCredentialsUpdated(c *provTypes.Cluster) error {
+	if err := p.getError("ClusterCredentialsUpdated"); err != nil {
+		return err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.credsUpdated = append(p.credsUpdated, c.Name)
+	return nil
+}
+
+// CredentialsUpdatedClusters returns the names of the clusters whose
+// credentials were reported as updated.
+func (p *FakeProvisioner) CredentialsUpdatedClusters() []string {
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	return p.credsUpdated
+}
+
+func (p *FakeProvisioner) ClusterHealthChanged(c *provTypes.Cluster, status provTypes.ClusterStatus, w io.Writer) error {
+	if err := p.getError("ClusterHealthChanged"); err != nil {
+		return err
+	}
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.healthChanges = append(p.healthChanges, status)
+	return nil
+}
+
+// ClusterHealthChanges returns the cluster statuses reported as health
+// changes, in order.
+func (p *FakeProvisioner) ClusterHealthChanges() []provTypes.ClusterStatus {
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	return p.healthChanges
+}
+
 func (p *FakeProvisioner) UpdateApp(old, new provision.App, w io.Writer) error {
 	provApp := p.apps[old.GetName()]
 	provApp.app = new
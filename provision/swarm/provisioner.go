@@ -29,6 +29,7 @@ import (
 	"github.com/tsuru/tsuru/provision/nodecontainer"
 	"github.com/tsuru/tsuru/provision/servicecommon"
 	provTypes "github.com/tsuru/tsuru/types/provision"
+	"github.com/tsuru/tsuru/volume"
 )
 
 const (
@@ -52,6 +53,8 @@ var (
 	_ provision.BuilderDeploy             = &swarmProvisioner{}
 	_ provision.BuilderDeployDockerClient = &swarmProvisioner{}
 	_ provision.VolumeProvisioner         = &swarmProvisioner{}
+	_ provision.SnapshotVolumeProvisioner = &swarmProvisioner{}
+	_ provision.ClusterCheckerProvisioner = &swarmProvisioner{}
 	_ cluster.InitClusterProvisioner      = &swarmProvisioner{}
 	// _ provision.RollbackableDeployer     = &swarmProvisioner{}
 	// _ provision.OptionalLogsProvisioner  = &swarmProvisioner{}
@@ -81,6 +84,45 @@ func (p *swarmProvisioner) Initialize() error {
 	return nil
 }
 
+func (p *swarmProvisioner) CheckCluster(c *provTypes.Cluster) error {
+	if len(c.Addresses) == 0 {
+		return errors.New("cluster has no addresses")
+	}
+	client, err := newClusterClient(c)
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(client.Ping())
+}
+
+func (p *swarmProvisioner) ClusterStatus(c *provTypes.Cluster) (*provTypes.ClusterStatus, error) {
+	if len(c.Addresses) == 0 {
+		return nil, errors.New("cluster has no addresses")
+	}
+	client, err := newClusterClient(c)
+	if err != nil {
+		return nil, err
+	}
+	version, err := client.Version()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	nodes, err := client.ListNodes(docker.ListNodesOptions{})
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	status := &provTypes.ClusterStatus{
+		Version: version.Get("Version"),
+		Nodes:   provTypes.ClusterNodesStatus{Total: len(nodes)},
+	}
+	for _, n := range nodes {
+		if n.Status.State == swarm.NodeStateReady {
+			status.Nodes.Ready++
+		}
+	}
+	return status, nil
+}
+
 func (p *swarmProvisioner) GetName() string {
 	return provisionerName
 }
@@ -808,23 +850,40 @@ func (p *swarmProvisioner) DeleteVolume(volumeName, pool string) error {
 	if err != nil {
 		return err
 	}
-	nodes, err := client.ListNodes(docker.ListNodesOptions{})
+	return removeVolumeFromNodes(client, volumeName)
+}
+
+func (p *swarmProvisioner) SnapshotVolume(volumeName, snapshotName, pool string) (int64, error) {
+	client, err := clusterForPool(pool)
+	if err != nil {
+		return 0, err
+	}
+	v, err := volume.Load(volumeName)
+	if err != nil {
+		return 0, err
+	}
+	return copyVolume(client, *v, snapshotName, false)
+}
+
+func (p *swarmProvisioner) RestoreVolume(volumeName, snapshotName, pool string) error {
+	client, err := clusterForPool(pool)
 	if err != nil {
 		return err
 	}
-	for _, n := range nodes {
-		nodeClient, err := clientForNode(client, n.ID)
-		if err != nil {
-			return err
-		}
-		err = nodeClient.RemoveVolumeWithOptions(docker.RemoveVolumeOptions{
-			Name: volumeName,
-		})
-		if err != docker.ErrNoSuchVolume && err != nil {
-			return err
-		}
+	v, err := volume.Load(volumeName)
+	if err != nil {
+		return err
 	}
-	return nil
+	_, err = copyVolume(client, *v, snapshotName, true)
+	return err
+}
+
+func (p *swarmProvisioner) DeleteVolumeSnapshot(volumeName, snapshotName, pool string) error {
+	client, err := clusterForPool(pool)
+	if err != nil {
+		return err
+	}
+	return removeVolumeFromNodes(client, volumeSnapshotName(volumeName, snapshotName))
 }
 
 func (p *swarmProvisioner) IsVolumeProvisioned(volumeName, pool string) (bool, error) {
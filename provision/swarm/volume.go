@@ -5,14 +5,23 @@
 package swarm
 
 import (
+	"bytes"
+	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/fsouza/go-dockerclient"
 	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/log"
 	"github.com/tsuru/tsuru/provision"
 	"github.com/tsuru/tsuru/volume"
 )
 
+const defaultVolumeCopyImage = "busybox"
+
 type volumeOptions struct {
 	Driver string
 	Opts   []string
@@ -35,6 +44,29 @@ func mountsForApp(app provision.App) ([]mount.Mount, error) {
 }
 
 func mountsForVolume(v volume.Volume, app provision.App) ([]mount.Mount, error) {
+	volumeOpts, err := mountOptionsForVolume(v)
+	if err != nil {
+		return nil, err
+	}
+	binds, err := v.LoadBindsForApp(app.GetName())
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	var mounts []mount.Mount
+	for _, b := range binds {
+		m := mount.Mount{
+			Source:        v.Name,
+			Target:        b.ID.MountPoint,
+			ReadOnly:      b.ReadOnly,
+			Type:          mount.TypeVolume,
+			VolumeOptions: volumeOpts,
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts, nil
+}
+
+func mountOptionsForVolume(v volume.Volume) (*mount.VolumeOptions, error) {
 	var volumeOpts volumeOptions
 	err := v.UnmarshalPlan(&volumeOpts)
 	if err != nil {
@@ -51,10 +83,6 @@ func mountsForVolume(v volume.Volume, app provision.App) ([]mount.Mount, error)
 		}
 		optsMap[parts[0]] = parts[1]
 	}
-	binds, err := v.LoadBindsForApp(app.GetName())
-	if err != nil {
-		return nil, errors.WithStack(err)
-	}
 	labelSet := provision.VolumeLabels(provision.VolumeLabelsOpts{
 		Name:        v.Name,
 		Provisioner: provisionerName,
@@ -62,22 +90,192 @@ func mountsForVolume(v volume.Volume, app provision.App) ([]mount.Mount, error)
 		Pool:        v.Pool,
 		Plan:        v.Plan.Name,
 	})
-	var mounts []mount.Mount
-	for _, b := range binds {
-		m := mount.Mount{
-			Source:   v.Name,
-			Target:   b.ID.MountPoint,
-			ReadOnly: b.ReadOnly,
-			Type:     mount.TypeVolume,
-			VolumeOptions: &mount.VolumeOptions{
-				Labels: labelSet.ToLabels(),
-				DriverConfig: &mount.Driver{
-					Name:    volumeOpts.Driver,
-					Options: optsMap,
+	return &mount.VolumeOptions{
+		Labels: labelSet.ToLabels(),
+		DriverConfig: &mount.Driver{
+			Name:    volumeOpts.Driver,
+			Options: optsMap,
+		},
+	}, nil
+}
+
+func volumeSnapshotName(volumeName, snapshotName string) string {
+	return fmt.Sprintf("%s-snapshot-%s", volumeName, snapshotName)
+}
+
+// snapshotVolumeOptions returns the options of the volume holding a snapshot
+// of v. Snapshots are plain local volumes, so they never share the storage
+// of v. Only local volumes without driver options are supported, as options
+// like device name the backing storage, which would be shared by the
+// snapshot.
+func snapshotVolumeOptions(v volume.Volume) (*mount.VolumeOptions, error) {
+	volumeOpts, err := mountOptionsForVolume(v)
+	if err != nil {
+		return nil, err
+	}
+	driver := volumeOpts.DriverConfig
+	if (driver.Name != "" && driver.Name != "local") || len(driver.Options) > 0 {
+		return nil, volume.ErrVolumeSnapshotDriverNotSupported
+	}
+	return &mount.VolumeOptions{
+		Labels:       volumeOpts.Labels,
+		DriverConfig: &mount.Driver{Name: "local"},
+	}, nil
+}
+
+// volumeNode returns the ID of the node holding a volume. Local volumes are
+// only reachable in the node where they were created, so the volume must
+// exist in a single node.
+func volumeNode(client *clusterClient, volumeName string) (string, error) {
+	nodes, err := client.ListNodes(docker.ListNodesOptions{})
+	if err != nil {
+		return "", errors.WithStack(err)
+	}
+	var found []string
+	for _, n := range nodes {
+		nodeClient, err := clientForNode(client, n.ID)
+		if err != nil {
+			return "", err
+		}
+		_, err = nodeClient.InspectVolume(volumeName)
+		if err == docker.ErrNoSuchVolume {
+			continue
+		}
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		found = append(found, n.ID)
+	}
+	switch len(found) {
+	case 0:
+		return "", errors.Errorf("volume %q not found in any node", volumeName)
+	case 1:
+		return found[0], nil
+	}
+	return "", errors.Errorf("volume %q found in multiple nodes: %s", volumeName, strings.Join(found, ", "))
+}
+
+// copyVolume runs a one-off service in the node holding the volume, copying
+// its content to the snapshot volume, or the other way around when
+// restoring. The content of the destination is replaced and the size in bytes
+// of the copied data is returned.
+func copyVolume(client *clusterClient, v volume.Volume, snapshotName string, restore bool) (int64, error) {
+	volumeOpts, err := mountOptionsForVolume(v)
+	if err != nil {
+		return 0, err
+	}
+	snapshotOpts, err := snapshotVolumeOptions(v)
+	if err != nil {
+		return 0, err
+	}
+	nodeID, err := volumeNode(client, v.Name)
+	if err != nil {
+		return 0, err
+	}
+	src := mount.Mount{Source: v.Name, VolumeOptions: volumeOpts}
+	dst := mount.Mount{Source: volumeSnapshotName(v.Name, snapshotName), VolumeOptions: snapshotOpts}
+	if restore {
+		var snapshotNodeID string
+		snapshotNodeID, err = volumeNode(client, dst.Source)
+		if err != nil {
+			return 0, err
+		}
+		if snapshotNodeID != nodeID {
+			return 0, errors.Errorf("snapshot %q is in node %q, but volume %q is in node %q", snapshotName, snapshotNodeID, v.Name, nodeID)
+		}
+		src, dst = dst, src
+	}
+	src.Target, src.ReadOnly, src.Type = "/src", true, mount.TypeVolume
+	dst.Target, dst.Type = "/dst", mount.TypeVolume
+	img, _ := config.GetString("swarm:volume-copy-image")
+	if img == "" {
+		img = defaultVolumeCopyImage
+	}
+	spec := swarm.ServiceSpec{
+		Annotations: swarm.Annotations{
+			Name:   fmt.Sprintf("%s-copy", dst.Source),
+			Labels: volumeOpts.Labels,
+		},
+		TaskTemplate: swarm.TaskSpec{
+			ContainerSpec: &swarm.ContainerSpec{
+				Image:  img,
+				Labels: volumeOpts.Labels,
+				Command: []string{
+					"/bin/sh", "-c",
+					"find /dst -mindepth 1 -delete && cp -a /src/. /dst/ && du -sb /dst | cut -f1",
+				},
+				Mounts: []mount.Mount{src, dst},
+			},
+			RestartPolicy: &swarm.RestartPolicy{
+				Condition: swarm.RestartPolicyConditionNone,
+			},
+			Placement: &swarm.Placement{
+				Constraints: []string{
+					toNodePoolConstraint(v.Pool, true),
+					fmt.Sprintf("node.id == %s", nodeID),
 				},
 			},
+		},
+	}
+	srv, err := client.CreateService(docker.CreateServiceOptions{
+		ServiceSpec: spec,
+	})
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	defer func() {
+		if rmErr := client.RemoveService(docker.RemoveServiceOptions{ID: srv.ID}); rmErr != nil {
+			log.Errorf("[volume copy] unable to remove service %q: %v", srv.ID, rmErr)
 		}
-		mounts = append(mounts, m)
+	}()
+	tasks, err := waitForTasks(client, srv.ID, swarm.TaskStateShutdown, swarm.TaskStateComplete)
+	if err != nil {
+		return 0, err
 	}
-	return mounts, nil
+	nodeClient, err := clientForNode(client, tasks[0].NodeID)
+	if err != nil {
+		return 0, err
+	}
+	var stdout, stderr bytes.Buffer
+	exitCode, err := safeAttachWaitContainer(nodeClient, docker.AttachToContainerOptions{
+		Container:    taskContainerID(&tasks[0]),
+		OutputStream: &stdout,
+		ErrorStream:  &stderr,
+		Logs:         true,
+		Stdout:       true,
+		Stderr:       true,
+		Stream:       true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if exitCode != 0 {
+		return 0, errors.Errorf("unexpected result code for volume copy container: %d: %s", exitCode, stderr.String())
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(stdout.String()), 10, 64)
+	if err != nil {
+		log.Errorf("[volume copy] unable to parse copied size for volume %q: %v", dst.Source, err)
+		return 0, nil
+	}
+	return size, nil
+}
+
+func removeVolumeFromNodes(client *clusterClient, volumeName string) error {
+	nodes, err := client.ListNodes(docker.ListNodesOptions{})
+	if err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		nodeClient, err := clientForNode(client, n.ID)
+		if err != nil {
+			return err
+		}
+		err = nodeClient.RemoveVolumeWithOptions(docker.RemoveVolumeOptions{
+			Name: volumeName,
+		})
+		if err != docker.ErrNoSuchVolume && err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -5,7 +5,11 @@
 package swarm
 
 import (
+	"net/http"
+
 	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/fsouza/go-dockerclient"
 	"github.com/tsuru/config"
 	"github.com/tsuru/tsuru/provision/provisiontest"
 	"github.com/tsuru/tsuru/volume"
@@ -87,3 +91,141 @@ func (s *S) TestMountsForApp(c *check.C) {
 		},
 	})
 }
+
+func (s *S) TestSnapshotVolume(c *check.C) {
+	s.addCluster(c)
+	config.Set("volume-plans:p1:swarm:driver", "local")
+	defer config.Unset("volume-plans")
+	v := volume.Volume{
+		Name:      "v1",
+		Plan:      volume.VolumePlan{Name: "p1"},
+		Pool:      "bonehunters",
+		TeamOwner: "admin",
+	}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	attached := s.attachRegister(c, s.clusterSrv, false, nil)
+	var service *swarm.Service
+	client, err := docker.NewClient(s.clusterSrv.URL())
+	c.Assert(err, check.IsNil)
+	_, err = client.CreateVolume(docker.CreateVolumeOptions{Name: "v1"})
+	c.Assert(err, check.IsNil)
+	nodes, err := client.ListNodes(docker.ListNodesOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(nodes, check.HasLen, 1)
+	s.clusterSrv.CustomHandler("/services/create", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.clusterSrv.DefaultHandler().ServeHTTP(w, r)
+		service, err = client.InspectService("v1-snapshot-snap1-copy")
+		c.Assert(err, check.IsNil)
+	}))
+	_, err = s.p.SnapshotVolume("v1", "snap1", "bonehunters")
+	c.Assert(err, check.IsNil)
+	c.Assert(<-attached, check.Equals, true)
+	mounts := service.Spec.TaskTemplate.ContainerSpec.Mounts
+	c.Assert(mounts, check.HasLen, 2)
+	c.Assert(mounts[0].Source, check.Equals, "v1")
+	c.Assert(mounts[0].Target, check.Equals, "/src")
+	c.Assert(mounts[0].ReadOnly, check.Equals, true)
+	c.Assert(mounts[1].Source, check.Equals, "v1-snapshot-snap1")
+	c.Assert(mounts[1].Target, check.Equals, "/dst")
+	c.Assert(mounts[1].VolumeOptions.DriverConfig, check.DeepEquals, &mount.Driver{Name: "local"})
+	c.Assert(service.Spec.TaskTemplate.ContainerSpec.Image, check.Equals, defaultVolumeCopyImage)
+	c.Assert(service.Spec.TaskTemplate.Placement.Constraints, check.DeepEquals, []string{
+		toNodePoolConstraint("bonehunters", true),
+		"node.id == " + nodes[0].ID,
+	})
+	_, err = client.InspectService("v1-snapshot-snap1-copy")
+	c.Assert(err, check.DeepEquals, &docker.NoSuchService{ID: "v1-snapshot-snap1-copy"})
+}
+
+func (s *S) TestRestoreVolume(c *check.C) {
+	s.addCluster(c)
+	config.Set("volume-plans:p1:swarm:driver", "local")
+	defer config.Unset("volume-plans")
+	v := volume.Volume{
+		Name:      "v1",
+		Plan:      volume.VolumePlan{Name: "p1"},
+		Pool:      "bonehunters",
+		TeamOwner: "admin",
+	}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	attached := s.attachRegister(c, s.clusterSrv, false, nil)
+	var service *swarm.Service
+	client, err := docker.NewClient(s.clusterSrv.URL())
+	c.Assert(err, check.IsNil)
+	for _, name := range []string{"v1", "v1-snapshot-snap1"} {
+		_, err = client.CreateVolume(docker.CreateVolumeOptions{Name: name})
+		c.Assert(err, check.IsNil)
+	}
+	s.clusterSrv.CustomHandler("/services/create", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.clusterSrv.DefaultHandler().ServeHTTP(w, r)
+		service, err = client.InspectService("v1-copy")
+		c.Assert(err, check.IsNil)
+	}))
+	err = s.p.RestoreVolume("v1", "snap1", "bonehunters")
+	c.Assert(err, check.IsNil)
+	c.Assert(<-attached, check.Equals, true)
+	mounts := service.Spec.TaskTemplate.ContainerSpec.Mounts
+	c.Assert(mounts, check.HasLen, 2)
+	c.Assert(mounts[0].Source, check.Equals, "v1-snapshot-snap1")
+	c.Assert(mounts[0].Target, check.Equals, "/src")
+	c.Assert(mounts[1].Source, check.Equals, "v1")
+	c.Assert(mounts[1].Target, check.Equals, "/dst")
+}
+
+func (s *S) TestRestoreVolumeSnapshotNotFound(c *check.C) {
+	s.addCluster(c)
+	config.Set("volume-plans:p1:swarm:driver", "local")
+	defer config.Unset("volume-plans")
+	v := volume.Volume{
+		Name:      "v1",
+		Plan:      volume.VolumePlan{Name: "p1"},
+		Pool:      "bonehunters",
+		TeamOwner: "admin",
+	}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	client, err := docker.NewClient(s.clusterSrv.URL())
+	c.Assert(err, check.IsNil)
+	_, err = client.CreateVolume(docker.CreateVolumeOptions{Name: "v1"})
+	c.Assert(err, check.IsNil)
+	err = s.p.RestoreVolume("v1", "snap1", "bonehunters")
+	c.Assert(err, check.ErrorMatches, `volume "v1-snapshot-snap1" not found in any node`)
+	services, err := client.ListServices(docker.ListServicesOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(services, check.HasLen, 0)
+}
+
+func (s *S) TestSnapshotVolumeDriverOptions(c *check.C) {
+	s.addCluster(c)
+	config.Set("volume-plans:p1:swarm:driver", "local")
+	config.Set("volume-plans:p1:swarm:opts", []string{"type=nfs"})
+	defer config.Unset("volume-plans")
+	v := volume.Volume{
+		Name:      "v1",
+		Opts:      map[string]string{"device": "/exports"},
+		Plan:      volume.VolumePlan{Name: "p1"},
+		Pool:      "bonehunters",
+		TeamOwner: "admin",
+	}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	_, err = s.p.SnapshotVolume("v1", "snap1", "bonehunters")
+	c.Assert(err, check.Equals, volume.ErrVolumeSnapshotDriverNotSupported)
+	err = s.p.RestoreVolume("v1", "snap1", "bonehunters")
+	c.Assert(err, check.Equals, volume.ErrVolumeSnapshotDriverNotSupported)
+}
+
+func (s *S) TestDeleteVolumeSnapshot(c *check.C) {
+	s.addCluster(c)
+	client, err := docker.NewClient(s.clusterSrv.URL())
+	c.Assert(err, check.IsNil)
+	_, err = client.CreateVolume(docker.CreateVolumeOptions{Name: "myvol-snapshot-snap1"})
+	c.Assert(err, check.IsNil)
+	err = s.p.DeleteVolumeSnapshot("myvol", "snap1", "pool")
+	c.Assert(err, check.IsNil)
+	vols, err := client.ListVolumes(docker.ListVolumesOptions{})
+	c.Assert(err, check.IsNil)
+	c.Assert(vols, check.HasLen, 0)
+}
@@ -0,0 +1,66 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tsuru/tsuru/types/ratelimit"
+)
+
+const memorySweepInterval = time.Minute
+
+type memoryBucket struct {
+	ratelimit.Bucket
+	expireAt time.Time
+}
+
+type memoryStorage struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+var _ ratelimit.RateLimitStorage = &memoryStorage{}
+
+// NewMemoryStorage returns a storage keeping buckets in memory. Limits are
+// enforced per API instance when it is used.
+func NewMemoryStorage() ratelimit.RateLimitStorage {
+	return &memoryStorage{buckets: map[string]*memoryBucket{}}
+}
+
+func (s *memoryStorage) Check(key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[key]
+	if !ok {
+		return true, 0, nil
+	}
+	allowed, retryAfter := b.Check(limit, time.Now().UTC())
+	return allowed, retryAfter, nil
+}
+
+func (s *memoryStorage) Take(key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now().UTC()
+	if now.Sub(s.lastSweep) > memorySweepInterval {
+		for k, b := range s.buckets {
+			if now.After(b.expireAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{Bucket: ratelimit.Bucket{Key: key}}
+		s.buckets[key] = b
+	}
+	allowed, retryAfter := b.Take(limit, now)
+	b.expireAt = now.Add(limit.FillTime())
+	return allowed, retryAfter, nil
+}
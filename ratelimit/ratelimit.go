@@ -0,0 +1,219 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ratelimit limits the rate of API requests per token, per user and
+// per group of routes, using token buckets kept in a shared storage so limits
+// hold across API instances.
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/storage"
+	authTypes "github.com/tsuru/tsuru/types/auth"
+	"github.com/tsuru/tsuru/types/ratelimit"
+)
+
+// Group is a set of route templates sharing a rate limit, applied
+// separately to each user or token.
+type Group struct {
+	Name   string
+	Routes []string
+	Limit  ratelimit.Limit
+}
+
+type Limiter struct {
+	Storage ratelimit.RateLimitStorage
+	User    ratelimit.Limit
+	Token   ratelimit.Limit
+	Groups  []Group
+}
+
+var (
+	limiterMu sync.RWMutex
+	limiter   *Limiter
+)
+
+// Initialize loads the rate limits from the ratelimit config. Rate limiting
+// is disabled if no limits are configured.
+func Initialize() error {
+	l, err := limiterFromConfig()
+	if err != nil {
+		return err
+	}
+	SetLimiter(l)
+	return nil
+}
+
+// SetLimiter sets the limiter used by Allow. A nil limiter disables rate
+// limiting.
+func SetLimiter(l *Limiter) {
+	limiterMu.Lock()
+	defer limiterMu.Unlock()
+	limiter = l
+}
+
+// TokenLimit returns the configured per token limit. Team tokens may only set
+// their own limit within it.
+func TokenLimit() ratelimit.Limit {
+	limiterMu.RLock()
+	defer limiterMu.RUnlock()
+	if limiter == nil {
+		return ratelimit.Limit{}
+	}
+	return limiter.Token
+}
+
+func limitFromConfig(prefix string) (ratelimit.Limit, error) {
+	rpm, _ := config.GetInt(prefix + ":requests-per-minute")
+	burst, _ := config.GetInt(prefix + ":burst")
+	if rpm < 0 || burst < 0 {
+		return ratelimit.Limit{}, errors.Errorf("invalid rate limit in %q, values must not be negative", prefix)
+	}
+	return ratelimit.Limit{RequestsPerMinute: rpm, Burst: burst}, nil
+}
+
+func limiterFromConfig() (*Limiter, error) {
+	var l Limiter
+	var err error
+	l.User, err = limitFromConfig("ratelimit:user")
+	if err != nil {
+		return nil, err
+	}
+	l.Token, err = limitFromConfig("ratelimit:token")
+	if err != nil {
+		return nil, err
+	}
+	groupsConfig, _ := config.Get("ratelimit:groups")
+	groups, _ := groupsConfig.(map[interface{}]interface{})
+	var names []string
+	for name := range groups {
+		names = append(names, name.(string))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prefix := "ratelimit:groups:" + name
+		g := Group{Name: name}
+		g.Limit, err = limitFromConfig(prefix)
+		if err != nil {
+			return nil, err
+		}
+		g.Routes, err = config.GetList(prefix + ":routes")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid routes for rate limit group %q", name)
+		}
+		l.Groups = append(l.Groups, g)
+	}
+	if !l.User.Enabled() && !l.Token.Enabled() && len(l.Groups) == 0 {
+		return nil, nil
+	}
+	backend, _ := config.GetString("ratelimit:backend")
+	switch backend {
+	case "memory":
+		l.Storage = NewMemoryStorage()
+	case "", "database":
+		dbDriver, err := storage.GetCurrentDbDriver()
+		if err != nil {
+			dbDriver, err = storage.GetDefaultDbDriver()
+			if err != nil {
+				return nil, err
+			}
+		}
+		l.Storage = dbDriver.RateLimitStorage
+	default:
+		return nil, errors.Errorf("unknown rate limit backend: %q", backend)
+	}
+	return &l, nil
+}
+
+type bucketLimit struct {
+	key   string
+	limit ratelimit.Limit
+}
+
+func tokenKey(t authTypes.Token) string {
+	if named, ok := t.(authTypes.NamedToken); ok {
+		return "token:" + named.GetTokenName()
+	}
+	sum := sha256.Sum256([]byte(t.GetValue()))
+	return "token:" + hex.EncodeToString(sum[:16])
+}
+
+func (l *Limiter) limitsFor(t authTypes.Token, route string) []bucketLimit {
+	tokenLimit := l.Token
+	if limited, ok := t.(authTypes.RateLimitedToken); ok {
+		tokenLimit = tokenLimit.Min(limited.GetRateLimit())
+	}
+	userKey := "user:" + t.GetUserName()
+	limits := []bucketLimit{
+		{key: tokenKey(t), limit: tokenLimit},
+		{key: userKey, limit: l.User},
+	}
+	for _, g := range l.Groups {
+		for _, r := range g.Routes {
+			if r == route {
+				limits = append(limits, bucketLimit{key: "group:" + g.Name + ":" + userKey, limit: g.Limit})
+				break
+			}
+		}
+	}
+	return limits
+}
+
+// Allow takes a token from each bucket limiting requests to route using t.
+// All buckets are checked before any token is taken, so a request denied by
+// one bucket doesn't consume the others. When any of them is empty it
+// returns false and how long the client should wait before retrying.
+// Requests without a token and requests using app tokens, used by units, are
+// not limited.
+func Allow(t authTypes.Token, route string) (bool, time.Duration, error) {
+	limiterMu.RLock()
+	l := limiter
+	limiterMu.RUnlock()
+	if l == nil || t == nil || t.IsAppToken() {
+		return true, 0, nil
+	}
+	var limits []bucketLimit
+	for _, bl := range l.limitsFor(t, route) {
+		if bl.limit.Enabled() {
+			limits = append(limits, bl)
+		}
+	}
+	allowed := true
+	var retryAfter time.Duration
+	for _, bl := range limits {
+		ok, wait, err := l.Storage.Check(bl.key, bl.limit)
+		if err != nil {
+			return true, 0, err
+		}
+		if !ok {
+			allowed = false
+			if wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+	if !allowed {
+		return false, retryAfter, nil
+	}
+	for _, bl := range limits {
+		ok, wait, err := l.Storage.Take(bl.key, bl.limit)
+		if err != nil {
+			return true, 0, err
+		}
+		if !ok {
+			allowed = false
+			if wait > retryAfter {
+				retryAfter = wait
+			}
+		}
+	}
+	return allowed, retryAfter, nil
+}
@@ -0,0 +1,200 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/types/ratelimit"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TearDownTest(c *check.C) {
+	SetLimiter(nil)
+	config.Unset("ratelimit")
+}
+
+func (s *S) TestInitializeWithoutLimits(c *check.C) {
+	err := Initialize()
+	c.Assert(err, check.IsNil)
+	c.Assert(limiter, check.IsNil)
+	allowed, _, err := Allow(&fakeToken{value: "abc", userName: "me@tsuru.io"}, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+}
+
+func (s *S) TestInitialize(c *check.C) {
+	config.Set("ratelimit", map[interface{}]interface{}{
+		"backend": "memory",
+		"user": map[interface{}]interface{}{
+			"requests-per-minute": 600,
+		},
+		"token": map[interface{}]interface{}{
+			"requests-per-minute": 300,
+			"burst":               50,
+		},
+		"groups": map[interface{}]interface{}{
+			"logs": map[interface{}]interface{}{
+				"routes":              []interface{}{"/apps/{app}/log"},
+				"requests-per-minute": 60,
+			},
+			"deploys": map[interface{}]interface{}{
+				"routes":              []interface{}{"/apps/{appname}/deploy", "/deploys"},
+				"requests-per-minute": 10,
+				"burst":               2,
+			},
+		},
+	})
+	err := Initialize()
+	c.Assert(err, check.IsNil)
+	c.Assert(limiter.Storage, check.FitsTypeOf, &memoryStorage{})
+	c.Assert(limiter.User, check.Equals, ratelimit.Limit{RequestsPerMinute: 600})
+	c.Assert(limiter.Token, check.Equals, ratelimit.Limit{RequestsPerMinute: 300, Burst: 50})
+	c.Assert(limiter.Groups, check.DeepEquals, []Group{
+		{Name: "deploys", Routes: []string{"/apps/{appname}/deploy", "/deploys"}, Limit: ratelimit.Limit{RequestsPerMinute: 10, Burst: 2}},
+		{Name: "logs", Routes: []string{"/apps/{app}/log"}, Limit: ratelimit.Limit{RequestsPerMinute: 60}},
+	})
+}
+
+func (s *S) TestInitializeInvalid(c *check.C) {
+	config.Set("ratelimit:user:requests-per-minute", -1)
+	err := Initialize()
+	c.Assert(err, check.ErrorMatches, `invalid rate limit in "ratelimit:user".*`)
+	config.Set("ratelimit:user:requests-per-minute", 10)
+	config.Set("ratelimit:backend", "etcd")
+	err = Initialize()
+	c.Assert(err, check.ErrorMatches, `unknown rate limit backend: "etcd"`)
+}
+
+func (s *S) TestAllowPerUser(c *check.C) {
+	SetLimiter(&Limiter{
+		Storage: NewMemoryStorage(),
+		User:    ratelimit.Limit{RequestsPerMinute: 1, Burst: 2},
+	})
+	token1 := &fakeToken{value: "token1", userName: "me@tsuru.io"}
+	token2 := &fakeToken{value: "token2", userName: "me@tsuru.io"}
+	allowed, _, err := Allow(token1, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, _, err = Allow(token2, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, retryAfter, err := Allow(token1, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+	c.Assert(retryAfter > 50*time.Second, check.Equals, true)
+	allowed, _, err = Allow(&fakeToken{value: "token3", userName: "other@tsuru.io"}, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+}
+
+func (s *S) TestAllowPerTokenWithTeamTokenLimit(c *check.C) {
+	SetLimiter(&Limiter{
+		Storage: NewMemoryStorage(),
+		Token:   ratelimit.Limit{RequestsPerMinute: 1, Burst: 2},
+	})
+	token := &fakeTeamToken{fakeToken{value: "abc", userName: "ci", tokenName: "ci"}}
+	token.limit = ratelimit.Limit{RequestsPerMinute: 1, Burst: 1}
+	allowed, _, err := Allow(token, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, _, err = Allow(token, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+	other := &fakeTeamToken{fakeToken{value: "def", userName: "other", tokenName: "other"}}
+	for i := 0; i < 2; i++ {
+		allowed, _, err = Allow(other, "/apps")
+		c.Assert(err, check.IsNil)
+		c.Assert(allowed, check.Equals, true)
+	}
+	allowed, _, err = Allow(other, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+}
+
+func (s *S) TestAllowTeamTokenLimitCannotRaiseTokenLimit(c *check.C) {
+	SetLimiter(&Limiter{
+		Storage: NewMemoryStorage(),
+		Token:   ratelimit.Limit{RequestsPerMinute: 1, Burst: 1},
+	})
+	token := &fakeTeamToken{fakeToken{value: "abc", userName: "ci", tokenName: "ci"}}
+	token.limit = ratelimit.Limit{RequestsPerMinute: 600, Burst: 100}
+	allowed, _, err := Allow(token, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, _, err = Allow(token, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+}
+
+func (s *S) TestAllowPerUserWithTeamToken(c *check.C) {
+	SetLimiter(&Limiter{
+		Storage: NewMemoryStorage(),
+		User:    ratelimit.Limit{RequestsPerMinute: 1, Burst: 1},
+	})
+	token := &fakeTeamToken{fakeToken{value: "abc", userName: "ci", tokenName: "ci"}}
+	allowed, _, err := Allow(token, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, _, err = Allow(token, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+}
+
+func (s *S) TestAllowDeniedDoesNotConsumeOtherBuckets(c *check.C) {
+	storage := NewMemoryStorage()
+	SetLimiter(&Limiter{
+		Storage: storage,
+		User:    ratelimit.Limit{RequestsPerMinute: 1, Burst: 1},
+		Token:   ratelimit.Limit{RequestsPerMinute: 1, Burst: 1},
+	})
+	token1 := &fakeToken{value: "token1", userName: "me@tsuru.io"}
+	token2 := &fakeToken{value: "token2", userName: "me@tsuru.io"}
+	allowed, _, err := Allow(token1, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, _, err = Allow(token2, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+	allowed, _, err = storage.Check(tokenKey(token2), ratelimit.Limit{RequestsPerMinute: 1, Burst: 1})
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+}
+
+func (s *S) TestAllowPerGroup(c *check.C) {
+	SetLimiter(&Limiter{
+		Storage: NewMemoryStorage(),
+		Groups: []Group{
+			{Name: "logs", Routes: []string{"/apps/{app}/log"}, Limit: ratelimit.Limit{RequestsPerMinute: 1, Burst: 1}},
+		},
+	})
+	token := &fakeToken{value: "token1", userName: "me@tsuru.io"}
+	allowed, _, err := Allow(token, "/apps/{app}/log")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, _, err = Allow(token, "/apps/{app}/log")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+	allowed, _, err = Allow(token, "/apps/{app}")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+}
+
+func (s *S) TestAllowAppTokenNotLimited(c *check.C) {
+	SetLimiter(&Limiter{
+		Storage: NewMemoryStorage(),
+		Token:   ratelimit.Limit{RequestsPerMinute: 1, Burst: 1},
+	})
+	token := &fakeToken{value: "apptoken", appName: "myapp"}
+	for i := 0; i < 3; i++ {
+		allowed, _, err := Allow(token, "/apps/{app}/log")
+		c.Assert(err, check.IsNil)
+		c.Assert(allowed, check.Equals, true)
+	}
+	allowed, _, err := Allow(nil, "/apps")
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+}
@@ -0,0 +1,55 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"testing"
+
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/storage/storagetest"
+	authTypes "github.com/tsuru/tsuru/types/auth"
+	"github.com/tsuru/tsuru/types/ratelimit"
+	"gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type S struct{}
+
+var _ = check.Suite(&S{})
+
+type noopHooks struct{}
+
+func (noopHooks) SetUpSuite(c *check.C)    {}
+func (noopHooks) SetUpTest(c *check.C)     {}
+func (noopHooks) TearDownTest(c *check.C)  {}
+func (noopHooks) TearDownSuite(c *check.C) {}
+
+var _ = check.Suite(&storagetest.RateLimitSuite{
+	RateLimitStorage: NewMemoryStorage(),
+	SuiteHooks:       noopHooks{},
+})
+
+type fakeToken struct {
+	value     string
+	userName  string
+	appName   string
+	tokenName string
+	limit     ratelimit.Limit
+}
+
+func (t *fakeToken) GetValue() string                              { return t.value }
+func (t *fakeToken) GetAppName() string                            { return t.appName }
+func (t *fakeToken) GetUserName() string                           { return t.userName }
+func (t *fakeToken) IsAppToken() bool                              { return t.appName != "" }
+func (t *fakeToken) User() (*authTypes.User, error)                { return &authTypes.User{Email: t.userName}, nil }
+func (t *fakeToken) Permissions() ([]permission.Permission, error) { return nil, nil }
+
+type fakeTeamToken struct {
+	fakeToken
+}
+
+func (t *fakeTeamToken) GetTokenName() string          { return t.tokenName }
+func (t *fakeTeamToken) GetRateLimit() ratelimit.Limit { return t.limit }
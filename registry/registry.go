@@ -29,6 +29,8 @@ var (
 	ErrImageNotFound  = errors.New("image not found")
 	ErrDigestNotFound = errors.New("digest not found")
 	ErrDeleteDisabled = errors.New("delete disabled")
+
+	ErrInvalidCredentials = errors.New("invalid registry credentials")
 )
 
 func RemoveImageIgnoreNotFound(imageName string) error {
@@ -101,6 +103,29 @@ func RemoveAppImages(appName string) error {
 	return multi.ToError()
 }
 
+// CheckCredentials checks whether the configured registry is reachable and
+// accepts the configured credentials. It does nothing if no registry is set.
+func CheckCredentials() error {
+	registry, _ := config.GetString("docker:registry")
+	if registry == "" {
+		return nil
+	}
+	r := &dockerRegistry{server: registry}
+	resp, err := r.doRequest("GET", "/v2/", nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		return ErrInvalidCredentials
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := ioutil.ReadAll(resp.Body)
+		return errors.Errorf("invalid status code checking registry (%d): %s", resp.StatusCode, string(data))
+	}
+	return nil
+}
+
 func (r dockerRegistry) getDigest(image, tag string) (string, error) {
 	path := fmt.Sprintf("/v2/%s/manifests/%s", image, tag)
 	resp, err := r.doRequest("HEAD", path, map[string]string{"Accept": "application/vnd.docker.distribution.manifest.v2+json"})
@@ -221,3 +221,34 @@ func (s *S) TestDockerRegistryDoRequestTLS(c *check.C) {
 	c.Assert(err, check.IsNil)
 	c.Assert(rsp.StatusCode, check.Equals, http.StatusOK)
 }
+
+func (s *S) TestCheckCredentials(c *check.C) {
+	err := CheckCredentials()
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestCheckCredentialsWithAuth(c *check.C) {
+	s.server.SetCredentials("user", "pwd")
+	config.Set("docker:registry-auth:username", "user")
+	defer config.Unset("docker:registry-auth:username")
+	config.Set("docker:registry-auth:password", "pwd")
+	defer config.Unset("docker:registry-auth:password")
+	err := CheckCredentials()
+	c.Assert(err, check.IsNil)
+}
+
+func (s *S) TestCheckCredentialsBadCredentials(c *check.C) {
+	s.server.SetCredentials("user", "pwd")
+	config.Set("docker:registry-auth:username", "user")
+	defer config.Unset("docker:registry-auth:username")
+	config.Set("docker:registry-auth:password", "wrong-pwd")
+	defer config.Unset("docker:registry-auth:password")
+	err := CheckCredentials()
+	c.Assert(err, check.Equals, ErrInvalidCredentials)
+}
+
+func (s *S) TestCheckCredentialsNoRegistry(c *check.C) {
+	config.Unset("docker:registry")
+	err := CheckCredentials()
+	c.Assert(err, check.IsNil)
+}
@@ -35,6 +35,8 @@ type RegistryServer struct {
 	Repos         []Repository
 	reposLock     sync.RWMutex
 	storageDelete bool
+	username      string
+	password      string
 }
 
 // NewServer returns a new instance of the fake server.
@@ -66,6 +68,8 @@ func (s *RegistryServer) Reset() {
 	s.reposLock.Lock()
 	s.Repos = nil
 	s.storageDelete = true
+	s.username = ""
+	s.password = ""
 	s.reposLock.Unlock()
 }
 
@@ -84,6 +88,14 @@ func (s *RegistryServer) SetStorageDelete(sd bool) {
 	s.reposLock.Unlock()
 }
 
+// SetCredentials sets the credentials required by the base /v2/ endpoint.
+func (s *RegistryServer) SetCredentials(username, password string) {
+	s.reposLock.Lock()
+	s.username = username
+	s.password = password
+	s.reposLock.Unlock()
+}
+
 // ServeHTTP handler HTTP requests, dealing with prepared failures before
 // dispatching the request to the proper internal handler.
 func (s *RegistryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -92,6 +104,7 @@ func (s *RegistryServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 func (s *RegistryServer) buildMuxer() {
 	s.muxer = mux.NewRouter()
+	s.muxer.Path("/v2/").Methods("GET").HandlerFunc(s.ping)
 	s.muxer.Path("/v2/{name:.*}/manifests/{tag:.*}").Methods("HEAD").HandlerFunc(s.getDigest)
 	s.muxer.Path("/v2/{name:.*}/manifests/{digest:.*}").Methods("DELETE").HandlerFunc(s.removeTag)
 	s.muxer.Path("/v2/{name:.*}/tags/list").Methods("GET").HandlerFunc(s.listTags)
@@ -113,6 +126,21 @@ func (s *RegistryServer) auth(w http.ResponseWriter, r *http.Request) error {
 	return nil
 }
 
+func (s *RegistryServer) ping(w http.ResponseWriter, r *http.Request) {
+	s.reposLock.RLock()
+	username, password := s.username, s.password
+	s.reposLock.RUnlock()
+	if username != "" || password != "" {
+		user, pass, _ := r.BasicAuth()
+		if user != username || pass != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte("{}"))
+}
+
 func (s *RegistryServer) removeTag(w http.ResponseWriter, r *http.Request) {
 	err := s.auth(w, r)
 	if err != nil {
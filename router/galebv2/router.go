@@ -73,6 +73,12 @@ func getClient(configPrefix string) (*galebClient.GalebClient, error) {
 	return client, nil
 }
 
+func resetClientCache() {
+	clientCache.Lock()
+	defer clientCache.Unlock()
+	clientCache.cache = nil
+}
+
 type galebRouter struct {
 	client     *galebClient.GalebClient
 	domain     string
@@ -82,6 +88,7 @@ type galebRouter struct {
 
 func init() {
 	router.Register(routerType, createRouter)
+	router.RegisterConfigReset(resetClientCache)
 	hc.AddChecker("Router galeb", router.BuildHealthCheck(routerType))
 }
 
@@ -39,6 +39,7 @@ var (
 func init() {
 	router.Register(routerType, createHipacheRouter)
 	router.Register("planb", createPlanbRouter)
+	router.RegisterConfigReset(resetRedisClients)
 	hc.AddChecker("Router Hipache", router.BuildHealthCheck("hipache"))
 	hc.AddChecker("Router Planb", router.BuildHealthCheck("planb"))
 }
@@ -51,6 +52,12 @@ func createPlanbRouter(routerName, configPrefix string) (router.Router, error) {
 	return &planbRouter{hipacheRouter{prefix: configPrefix, routerName: routerName}}, nil
 }
 
+func resetRedisClients() {
+	redisClientsMut.Lock()
+	defer redisClientsMut.Unlock()
+	redisClients = map[string]tsuruRedis.Client{}
+}
+
 func (r *hipacheRouter) connect() (tsuruRedis.Client, error) {
 	redisClientsMut.RLock()
 	client := redisClients[r.prefix]
@@ -16,6 +16,7 @@ import (
 	"github.com/globalsign/mgo/bson"
 	"github.com/pkg/errors"
 	"github.com/tsuru/config"
+	internalConfig "github.com/tsuru/tsuru/config"
 	"github.com/tsuru/tsuru/db"
 	"github.com/tsuru/tsuru/db/storage"
 	"github.com/tsuru/tsuru/log"
@@ -45,13 +46,34 @@ func (e *ErrRouterNotFound) Error() string {
 
 const HttpScheme = "http"
 
-var routers = make(map[string]routerFactory)
+var (
+	routers         = make(map[string]routerFactory)
+	configResetters []func()
+)
+
+func init() {
+	internalConfig.Subscribe("routers", []string{"routers"}, resetRoutersConfig)
+}
 
 // Register registers a new router.
 func Register(name string, r routerFactory) {
 	routers[name] = r
 }
 
+// RegisterConfigReset registers a function that drops any state a router
+// type built from its config, e.g. cached clients. It's called whenever the
+// routers config is reloaded.
+func RegisterConfigReset(fn func()) {
+	configResetters = append(configResetters, fn)
+}
+
+func resetRoutersConfig() error {
+	for _, fn := range configResetters {
+		fn()
+	}
+	return nil
+}
+
 func Unregister(name string) {
 	delete(routers, name)
 }
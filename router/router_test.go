@@ -37,6 +37,16 @@ func (s *S) TestRegisterAndGet(c *check.C) {
 	c.Assert(`unknown router: "unknown".`, check.Equals, err.Error())
 }
 
+func (s *S) TestRegisterConfigReset(c *check.C) {
+	original := configResetters
+	defer func() { configResetters = original }()
+	var calls int
+	RegisterConfigReset(func() { calls++ })
+	err := resetRoutersConfig()
+	c.Assert(err, check.IsNil)
+	c.Assert(calls, check.Equals, 1)
+}
+
 func (s *S) TestRegisterAndType(c *check.C) {
 	config.Set("routers:mine:type", "myrouter")
 	defer config.Unset("routers:mine:type")
@@ -81,6 +81,26 @@ var notifyCreateServiceInstance = action.Action{
 	MinParams: 3,
 }
 
+// reserveServiceInstanceQuotas is an action that takes the new instance from
+// the instance quotas of its team.
+//
+// The second argument in the context must be a Service Instance.
+var reserveServiceInstanceQuotas = action.Action{
+	Name: "reserve-service-instance-quotas",
+	Forward: func(ctx action.FWContext) (action.Result, error) {
+		instance, ok := ctx.Params[1].(*ServiceInstance)
+		if !ok {
+			return nil, errors.New("Second parameter must be a *ServiceInstance.")
+		}
+		return reserveInstanceQuotas(*instance, nil)
+	},
+	Backward: func(ctx action.BWContext) {
+		quotas, _ := ctx.FWResult.([]InstanceQuota)
+		releaseInstanceQuotas(quotas)
+	},
+	MinParams: 2,
+}
+
 // createServiceInstance is an action that inserts an instance in the database.
 //
 // The second argument in the context must be a Service Instance.
@@ -113,6 +133,31 @@ var createServiceInstance = action.Action{
 	MinParams: 2,
 }
 
+// reserveUpdatedServiceInstanceQuotas is an action that takes the instance
+// from the quotas it starts to match after changing its team owner or plan.
+//
+// The second argument in the context must be a Service Instance with the current attributes.
+// The third argument in the context must be a Service Instance with the updated attributes.
+var reserveUpdatedServiceInstanceQuotas = action.Action{
+	Name: "reserve-updated-service-instance-quotas",
+	Forward: func(ctx action.FWContext) (action.Result, error) {
+		instance, ok := ctx.Params[1].(ServiceInstance)
+		if !ok {
+			return nil, errors.New("Second parameter must be a ServiceInstance.")
+		}
+		updateData, ok := ctx.Params[2].(ServiceInstance)
+		if !ok {
+			return nil, errors.New("Third parameter must be a ServiceInstance.")
+		}
+		return reserveInstanceQuotas(updateData, &instance)
+	},
+	Backward: func(ctx action.BWContext) {
+		quotas, _ := ctx.FWResult.([]InstanceQuota)
+		releaseInstanceQuotas(quotas)
+	},
+	MinParams: 3,
+}
+
 // updateServiceInstance is an action that updates an instance in the database.
 //
 // The second argument in the context must be a Service Instance with the current attributes.
@@ -301,23 +346,8 @@ var setBoundEnvsAction = &action.Action{
 			return nil, errors.New("invalid arguments for pipeline, expected *bindPipelineArgs.")
 		}
 		envMap := ctx.Previous.(map[string]string)
-		envs := make([]bind.ServiceEnvVar, 0, len(envMap))
-		for k, v := range envMap {
-			envs = append(envs, bind.ServiceEnvVar{
-				ServiceName:  args.serviceInstance.ServiceName,
-				InstanceName: args.serviceInstance.Name,
-				EnvVar: bind.EnvVar{
-					Public: false,
-					Name:   k,
-					Value:  v,
-				},
-			})
-		}
-		sort.Slice(envs, func(i, j int) bool {
-			return envs[i].Name < envs[j].Name
-		})
 		addArgs := bind.AddInstanceArgs{
-			Envs:          envs,
+			Envs:          serviceEnvsFromMap(args.serviceInstance, envMap),
 			ShouldRestart: args.shouldRestart,
 			Writer:        args.writer,
 		}
@@ -523,3 +553,22 @@ var removeBoundEnvs = action.Action{
 	},
 	MinParams: 1,
 }
+
+func serviceEnvsFromMap(instance *ServiceInstance, envMap map[string]string) []bind.ServiceEnvVar {
+	envs := make([]bind.ServiceEnvVar, 0, len(envMap))
+	for k, v := range envMap {
+		envs = append(envs, bind.ServiceEnvVar{
+			ServiceName:  instance.ServiceName,
+			InstanceName: instance.Name,
+			EnvVar: bind.EnvVar{
+				Public: false,
+				Name:   k,
+				Value:  v,
+			},
+		})
+	}
+	sort.Slice(envs, func(i, j int) bool {
+		return envs[i].Name < envs[j].Name
+	})
+	return envs
+}
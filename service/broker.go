@@ -40,7 +40,10 @@ type brokerClient struct {
 	client  osb.Client
 }
 
-var _ ServiceClient = &brokerClient{}
+var (
+	_ ServiceClient      = &brokerClient{}
+	_ CredentialsRotator = &brokerClient{}
+)
 
 func newClient(b serviceTypes.Broker, service string) (*brokerClient, error) {
 	broker := brokerClient{
@@ -167,6 +170,9 @@ func (b *brokerClient) Create(instance *ServiceInstance, evt *event.Event, reque
 	if err != nil {
 		return err
 	}
+	if resp != nil && resp.Async {
+		return startBrokerOperation(instance, BrokerOperationProvision, resp.OperationKey, evt)
+	}
 	if resp != nil && resp.OperationKey != nil {
 		instance.BrokerData.LastOperationKey = string(*resp.OperationKey)
 	}
@@ -216,7 +222,13 @@ func (b *brokerClient) Update(instance *ServiceInstance, evt *event.Event, reque
 	if err != nil {
 		return err
 	}
-	if resp != nil && resp.OperationKey != nil {
+	instance.BrokerData.LastOperation = nil
+	if resp != nil && resp.Async {
+		err = startBrokerOperation(instance, BrokerOperationUpdate, resp.OperationKey, evt)
+		if err != nil {
+			return err
+		}
+	} else if resp != nil && resp.OperationKey != nil {
 		instance.BrokerData.LastOperationKey = string(*resp.OperationKey)
 	}
 	return updateBrokerData(instance)
@@ -241,6 +253,13 @@ func (b *brokerClient) Destroy(instance *ServiceInstance, evt *event.Event, requ
 	if err != nil {
 		return err
 	}
+	if resp != nil && resp.Async {
+		err = startBrokerOperation(instance, BrokerOperationDeprovision, resp.OperationKey, evt)
+		if err != nil {
+			return err
+		}
+		return updateBrokerData(instance)
+	}
 	if resp != nil && resp.OperationKey != nil {
 		instance.BrokerData.LastOperationKey = string(*resp.OperationKey)
 		err = updateBrokerData(instance)
@@ -252,29 +271,61 @@ func (b *brokerClient) BindApp(instance *ServiceInstance, app bind.App, params B
 	if instance.BrokerData == nil {
 		return nil, ErrInvalidBrokerData
 	}
-	id, err := idForEvent(evt)
+	bindID, err := uuid.NewV4()
 	if err != nil {
 		return nil, err
 	}
-	appGUID, err := app.GetUUID()
+	bind := BrokerInstanceBind{
+		UUID:       bindID.String(),
+		Parameters: params,
+	}
+	req, err := b.bindRequest(instance, app, bind, evt, requestID)
 	if err != nil {
 		return nil, err
 	}
-	bindID, err := uuid.NewV4()
+	req.AcceptsIncomplete = true
+	resp, err := b.client.Bind(req)
+	if osb.IsAsyncBindingOperationsNotAllowedError(err) {
+		req.AcceptsIncomplete = false
+		resp, err = b.client.Bind(req)
+	}
 	if err != nil {
 		return nil, err
 	}
-	bind := BrokerInstanceBind{
-		UUID:       bindID.String(),
-		Parameters: params,
+	if resp.OperationKey != nil {
+		bind.OperationKey = string(*resp.OperationKey)
+		instance.BrokerData.LastOperationKey = string(*resp.OperationKey)
+	}
+	envs := credentialsToEnvs(resp.Credentials)
+	if resp.Async {
+		err = startBindOperation(instance, BrokerOperationBind, resp.OperationKey, evt, app.GetName(), bind.UUID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if instance.BrokerData.Binds == nil {
+		instance.BrokerData.Binds = make(map[string]BrokerInstanceBind)
+	}
+	instance.BrokerData.Binds[app.GetName()] = bind
+	return envs, updateBrokerData(instance)
+}
+
+func (b *brokerClient) bindRequest(instance *ServiceInstance, app bind.App, binding BrokerInstanceBind, evt *event.Event, requestID string) (*osb.BindRequest, error) {
+	id, err := idForEvent(evt)
+	if err != nil {
+		return nil, err
+	}
+	appGUID, err := app.GetUUID()
+	if err != nil {
+		return nil, err
 	}
 	req := osb.BindRequest{
 		ServiceID:           instance.BrokerData.ServiceID,
 		InstanceID:          instance.BrokerData.UUID,
 		PlanID:              instance.BrokerData.PlanID,
-		BindingID:           bind.UUID,
+		BindingID:           binding.UUID,
 		AppGUID:             &appGUID,
-		Parameters:          params,
+		Parameters:          binding.Parameters,
 		OriginatingIdentity: id,
 		BindResource: &osb.BindResource{
 			AppGUID: &appGUID,
@@ -283,37 +334,84 @@ func (b *brokerClient) BindApp(instance *ServiceInstance, app bind.App, params B
 			"request_id": requestID,
 			"event_id":   evt.UniqueID.Hex(),
 		},
-		AcceptsIncomplete: true,
 	}
 	for k, v := range b.broker.Config.Context {
 		req.Context[k] = v
 	}
-	resp, err := b.client.Bind(&req)
-	if osb.IsAsyncBindingOperationsNotAllowedError(err) {
-		req.AcceptsIncomplete = false
-		resp, err = b.client.Bind(&req)
+	return &req, nil
+}
+
+// RotateCredentials creates a new binding for the app, keeping the current
+// one until RevokePreviousCredentials is called.
+func (b *brokerClient) RotateCredentials(instance *ServiceInstance, app bind.App, evt *event.Event, requestID string) (map[string]string, error) {
+	if instance.BrokerData == nil {
+		return nil, ErrInvalidBrokerData
+	}
+	current, ok := instance.BrokerData.Binds[app.GetName()]
+	if !ok {
+		return nil, ErrAppNotBound
+	}
+	bindID, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+	// a retried rotation keeps every binding it replaced, including the ones
+	// created by previous attempts, so none of them is left behind
+	previous := append([]string{}, current.PreviousUUIDs...)
+	binding := BrokerInstanceBind{
+		UUID:          bindID.String(),
+		Parameters:    current.Parameters,
+		PreviousUUIDs: append(previous, current.UUID),
 	}
+	req, err := b.bindRequest(instance, app, binding, evt, requestID)
 	if err != nil {
 		return nil, err
 	}
-	if resp.OperationKey != nil {
-		bind.OperationKey = string(*resp.OperationKey)
-		instance.BrokerData.LastOperationKey = string(*resp.OperationKey)
+	resp, err := b.client.Bind(req)
+	if err != nil {
+		return nil, err
 	}
-	envs := make(map[string]string)
-	for k, v := range resp.Credentials {
-		switch s := v.(type) {
-		case string:
-			envs[k] = s
-		case int:
-			envs[k] = strconv.Itoa(s)
+	instance.BrokerData.Binds[app.GetName()] = binding
+	return credentialsToEnvs(resp.Credentials), updateBrokerData(instance)
+}
+
+// RevokePreviousCredentials removes the bindings replaced by the credentials
+// rotations of the app.
+func (b *brokerClient) RevokePreviousCredentials(instance *ServiceInstance, app bind.App, evt *event.Event, requestID string) error {
+	if instance.BrokerData == nil {
+		return ErrInvalidBrokerData
+	}
+	current, ok := instance.BrokerData.Binds[app.GetName()]
+	if !ok || len(current.PreviousUUIDs) == 0 {
+		return nil
+	}
+	id, err := idForEvent(evt)
+	if err != nil {
+		return err
+	}
+	for len(current.PreviousUUIDs) > 0 {
+		_, err = b.client.Unbind(&osb.UnbindRequest{
+			InstanceID:          instance.BrokerData.UUID,
+			BindingID:           current.PreviousUUIDs[0],
+			ServiceID:           instance.BrokerData.ServiceID,
+			PlanID:              instance.BrokerData.PlanID,
+			OriginatingIdentity: id,
+		})
+		if err != nil && !osb.IsGoneError(err) {
+			break
 		}
+		err = nil
+		current.PreviousUUIDs = current.PreviousUUIDs[1:]
 	}
-	if instance.BrokerData.Binds == nil {
-		instance.BrokerData.Binds = make(map[string]BrokerInstanceBind)
+	if len(current.PreviousUUIDs) == 0 {
+		current.PreviousUUIDs = nil
 	}
-	instance.BrokerData.Binds[app.GetName()] = bind
-	return envs, updateBrokerData(instance)
+	instance.BrokerData.Binds[app.GetName()] = current
+	updateErr := updateBrokerData(instance)
+	if err != nil {
+		return err
+	}
+	return updateErr
 }
 
 func (b *brokerClient) UnbindApp(instance *ServiceInstance, app bind.App, evt *event.Event, requestID string) error {
@@ -341,6 +439,13 @@ func (b *brokerClient) UnbindApp(instance *ServiceInstance, app bind.App, evt *e
 		return err
 	}
 	delete(instance.BrokerData.Binds, app.GetName())
+	if resp != nil && resp.Async {
+		err = startBindOperation(instance, BrokerOperationUnbind, resp.OperationKey, evt, app.GetName(), req.BindingID)
+		if err != nil {
+			return err
+		}
+		return updateBrokerData(instance)
+	}
 	if resp != nil && resp.OperationKey != nil {
 		instance.BrokerData.LastOperationKey = string(*resp.OperationKey)
 		err = updateBrokerData(instance)
@@ -352,6 +457,16 @@ func (b *brokerClient) Status(instance *ServiceInstance, requestID string) (stri
 	if instance.BrokerData == nil {
 		return "", ErrInvalidBrokerData
 	}
+	if op := instance.BrokerData.LastOperation; op != nil {
+		if !op.inProgress() {
+			return op.status(), nil
+		}
+		resp, err := b.pollOperation(instance, op)
+		if err != nil {
+			return "", err
+		}
+		return lastOperationStatus(resp), nil
+	}
 	origID, err := json.Marshal(map[string]interface{}{
 		"team": instance.TeamOwner,
 	})
@@ -372,11 +487,15 @@ func (b *brokerClient) Status(instance *ServiceInstance, requestID string) (stri
 	if err != nil {
 		return "", err
 	}
+	return lastOperationStatus(op), nil
+}
+
+func lastOperationStatus(op *osb.LastOperationResponse) string {
 	output := string(op.State)
 	if op.Description != nil {
 		output += " - " + *op.Description
 	}
-	return output, nil
+	return output
 }
 
 func (b *brokerClient) Info(instance *ServiceInstance, requestID string) ([]map[string]string, error) {
@@ -482,6 +601,19 @@ func idForEvent(evt *event.Event) (*osb.OriginatingIdentity, error) {
 	}, nil
 }
 
+func credentialsToEnvs(credentials map[string]interface{}) map[string]string {
+	envs := make(map[string]string)
+	for k, v := range credentials {
+		switch s := v.(type) {
+		case string:
+			envs[k] = s
+		case int:
+			envs[k] = strconv.Itoa(s)
+		}
+	}
+	return envs
+}
+
 func updateBrokerData(instance *ServiceInstance) error {
 	conn, err := db.Conn()
 	if err != nil {
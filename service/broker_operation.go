@@ -0,0 +1,634 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	osb "github.com/pmorie/go-open-service-broker-client/v2"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+)
+
+const (
+	BrokerOperationProvision   = "provision"
+	BrokerOperationUpdate      = "update"
+	BrokerOperationDeprovision = "deprovision"
+	BrokerOperationBind        = "bind"
+	BrokerOperationUnbind      = "unbind"
+
+	defaultBrokerOperationTimeout = time.Hour
+
+	// brokerPollLockExpiration is how long an instance stays claimed by a
+	// poller, allowing other pollers to take over if the owner dies.
+	brokerPollLockExpiration = 5 * time.Minute
+)
+
+// BrokerOperation represents an asynchronous operation started in a broker.
+// The event that originated the operation is only finished once the broker
+// reports the operation as succeeded or failed.
+type BrokerOperation struct {
+	Type        string
+	Key         string
+	State       string
+	Description string
+	EventID     string
+	App         string
+	BindingID   string
+	StartedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (op *BrokerOperation) inProgress() bool {
+	return op.State == string(osb.StateInProgress)
+}
+
+func (op *BrokerOperation) status() string {
+	if op.Description == "" {
+		return op.State
+	}
+	return op.State + " - " + op.Description
+}
+
+func (op *BrokerOperation) operationKey() *osb.OperationKey {
+	if op.Key == "" {
+		return nil
+	}
+	key := osb.OperationKey(op.Key)
+	return &key
+}
+
+func (si *ServiceInstance) hasPendingOperation() bool {
+	return si.BrokerData != nil && si.BrokerData.LastOperation != nil && si.BrokerData.LastOperation.inProgress()
+}
+
+// startedAt returns the start time of the oldest operation in progress in the
+// instance.
+func (si *ServiceInstance) startedAt() time.Time {
+	var started time.Time
+	if si.hasPendingOperation() {
+		started = si.BrokerData.LastOperation.StartedAt
+	}
+	for _, op := range si.BindOperations {
+		if op.inProgress() && (started.IsZero() || op.StartedAt.Before(started)) {
+			started = op.StartedAt
+		}
+	}
+	return started
+}
+
+func newBrokerOperation(opType string, key *osb.OperationKey, evt *event.Event) *BrokerOperation {
+	now := time.Now().UTC()
+	op := &BrokerOperation{
+		Type:      opType,
+		State:     string(osb.StateInProgress),
+		EventID:   evt.UniqueID.Hex(),
+		StartedAt: now,
+		UpdatedAt: now,
+	}
+	if key != nil {
+		op.Key = string(*key)
+	}
+	return op
+}
+
+// startBrokerOperation records an asynchronous operation on the instance,
+// handing evt off to the broker operation poller. The operation is stored
+// along with the broker data of the instance.
+func startBrokerOperation(instance *ServiceInstance, opType string, key *osb.OperationKey, evt *event.Event) error {
+	op := newBrokerOperation(opType, key, evt)
+	err := evt.SetPending()
+	if err != nil {
+		return err
+	}
+	if op.Key != "" {
+		instance.BrokerData.LastOperationKey = op.Key
+	}
+	instance.BrokerData.LastOperation = op
+	return nil
+}
+
+// startBindOperation records an asynchronous operation on the binding of the
+// app, handing evt off to the broker operation poller. Operations are tracked
+// by binding, so operations on different bindings of the same instance don't
+// replace each other.
+func startBindOperation(instance *ServiceInstance, opType string, key *osb.OperationKey, evt *event.Event, appName, bindingID string) error {
+	op := newBrokerOperation(opType, key, evt)
+	op.App = appName
+	op.BindingID = bindingID
+	err := evt.SetPending()
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	query := bson.M{"name": instance.Name, "service_name": instance.ServiceName}
+	err = conn.ServiceInstances().Update(query, bson.M{
+		"$pull": bson.M{"bind_operations": bson.M{"bindingid": bindingID}},
+	})
+	if err != nil {
+		return err
+	}
+	err = conn.ServiceInstances().Update(query, bson.M{
+		"$push": bson.M{"bind_operations": op},
+	})
+	if err != nil {
+		return err
+	}
+	ops := instance.BindOperations[:0]
+	for _, existing := range instance.BindOperations {
+		if existing.BindingID != bindingID {
+			ops = append(ops, existing)
+		}
+	}
+	instance.BindOperations = append(ops, *op)
+	return nil
+}
+
+func updateBindOperation(instance *ServiceInstance, op *BrokerOperation) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return conn.ServiceInstances().Update(bson.M{
+		"name":            instance.Name,
+		"service_name":    instance.ServiceName,
+		"bind_operations": bson.M{"$elemMatch": bson.M{"bindingid": op.BindingID, "eventid": op.EventID}},
+	}, bson.M{"$set": bson.M{"bind_operations.$": op}})
+}
+
+// removeBindOperation removes the operation from the instance, returning
+// false if it was already removed.
+func removeBindOperation(instance *ServiceInstance, op *BrokerOperation) (bool, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	err = conn.ServiceInstances().Update(
+		bson.M{
+			"name":            instance.Name,
+			"service_name":    instance.ServiceName,
+			"bind_operations": bson.M{"$elemMatch": bson.M{"bindingid": op.BindingID, "eventid": op.EventID}},
+		},
+		bson.M{"$pull": bson.M{"bind_operations": bson.M{"bindingid": op.BindingID, "eventid": op.EventID}}},
+	)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// eventHasPendingOperations returns whether any operation started by the
+// event is still in progress in a broker.
+func eventHasPendingOperations(eventID string) (bool, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	n, err := conn.ServiceInstances().Find(bson.M{"$or": []bson.M{
+		{
+			"broker_data.lastoperation.eventid": eventID,
+			"broker_data.lastoperation.state":   string(osb.StateInProgress),
+		},
+		{
+			"bind_operations": bson.M{"$elemMatch": bson.M{
+				"eventid": eventID,
+				"state":   string(osb.StateInProgress),
+			}},
+		},
+	}}).Count()
+	return n > 0, err
+}
+
+func (b *brokerClient) pollOperation(instance *ServiceInstance, op *BrokerOperation) (*osb.LastOperationResponse, error) {
+	origID, err := json.Marshal(map[string]interface{}{
+		"team": instance.TeamOwner,
+	})
+	if err != nil {
+		return nil, err
+	}
+	identity := &osb.OriginatingIdentity{
+		Platform: "tsuru",
+		Value:    string(origID),
+	}
+	if op.Type == BrokerOperationBind || op.Type == BrokerOperationUnbind {
+		return b.client.PollBindingLastOperation(&osb.BindingLastOperationRequest{
+			InstanceID:          instance.BrokerData.UUID,
+			BindingID:           op.BindingID,
+			ServiceID:           &instance.BrokerData.ServiceID,
+			PlanID:              &instance.BrokerData.PlanID,
+			OriginatingIdentity: identity,
+			OperationKey:        op.operationKey(),
+		})
+	}
+	return b.client.PollLastOperation(&osb.LastOperationRequest{
+		InstanceID:          instance.BrokerData.UUID,
+		ServiceID:           &instance.BrokerData.ServiceID,
+		PlanID:              &instance.BrokerData.PlanID,
+		OriginatingIdentity: identity,
+		OperationKey:        op.operationKey(),
+	})
+}
+
+func (b *brokerClient) bindingEnvs(instance *ServiceInstance, bindingID string) (map[string]string, error) {
+	resp, err := b.client.GetBinding(&osb.GetBindingRequest{
+		InstanceID: instance.BrokerData.UUID,
+		BindingID:  bindingID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return credentialsToEnvs(resp.Credentials), nil
+}
+
+func InitializeBrokerOperations(appGetter func(string) (bind.App, error)) error {
+	interval, _ := config.GetDuration("service:broker:poll-interval")
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	timeout, _ := config.GetDuration("service:broker:operation-timeout")
+	if timeout <= 0 {
+		timeout = defaultBrokerOperationTimeout
+	}
+	poller := &brokerOperationPoller{
+		id:        bson.NewObjectId().Hex(),
+		interval:  interval,
+		timeout:   timeout,
+		appGetter: appGetter,
+	}
+	err := poller.start()
+	if err != nil {
+		return err
+	}
+	shutdown.Register(poller)
+	return nil
+}
+
+// brokerOperationPoller periodically polls the last operation of service
+// instances with asynchronous broker operations in progress, finishing the
+// events that started them once the broker is done. Operations running for
+// longer than timeout are considered failed. Each instance is claimed by a
+// single poller before being polled, so that only one tsurud replica acts
+// upon the result of an operation.
+type brokerOperationPoller struct {
+	id        string
+	interval  time.Duration
+	timeout   time.Duration
+	appGetter func(string) (bind.App, error)
+
+	started  bool
+	shutdown chan struct{}
+	done     chan struct{}
+}
+
+func (p *brokerOperationPoller) start() error {
+	if p.started {
+		return errors.New("broker operation poller already started")
+	}
+	if p.appGetter == nil {
+		return errors.New("must set app getter function")
+	}
+	p.shutdown = make(chan struct{}, 1)
+	p.done = make(chan struct{})
+	p.started = true
+	log.Debugf("[broker-operations] starting. Running every %s.\n", p.interval)
+	go func() {
+		for {
+			select {
+			case <-time.After(p.interval):
+				err := p.run()
+				if err != nil {
+					log.Errorf("[broker-operations] error polling operations: %v", err)
+				}
+			case <-p.shutdown:
+				p.done <- struct{}{}
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Shutdown shutdowns brokerOperationPoller waiting for the current run to
+// complete
+func (p *brokerOperationPoller) Shutdown(ctx context.Context) error {
+	if !p.started {
+		return nil
+	}
+	p.shutdown <- struct{}{}
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+	}
+	p.started = false
+	return ctx.Err()
+}
+
+func (p *brokerOperationPoller) run() error {
+	instances, err := pendingBrokerInstances()
+	if err != nil {
+		return err
+	}
+	for i := range instances {
+		err = p.claimAndPoll(&instances[i])
+		if err != nil {
+			log.Errorf("[broker-operations] error polling instance %q of service %q: %v", instances[i].Name, instances[i].ServiceName, err)
+		}
+		if len(p.shutdown) > 0 {
+			break
+		}
+	}
+	return nil
+}
+
+func pendingBrokerInstances() ([]ServiceInstance, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var instances []ServiceInstance
+	err = conn.ServiceInstances().Find(bson.M{"$or": []bson.M{
+		{"broker_data.lastoperation.state": string(osb.StateInProgress)},
+		{"bind_operations.state": string(osb.StateInProgress)},
+	}}).All(&instances)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(instances, func(i, j int) bool {
+		return instances[i].startedAt().Before(instances[j].startedAt())
+	})
+	return instances, nil
+}
+
+func (p *brokerOperationPoller) claimAndPoll(instance *ServiceInstance) error {
+	claimed, err := p.claim(instance)
+	if err != nil || !claimed {
+		return err
+	}
+	defer func() {
+		if releaseErr := p.release(instance); releaseErr != nil {
+			log.Errorf("[broker-operations] unable to release instance %q of service %q: %v", instance.Name, instance.ServiceName, releaseErr)
+		}
+	}()
+	return p.poll(instance)
+}
+
+// claim atomically marks the instance as being polled by p, reloading it so
+// that operations finished by another poller are not handled again.
+func (p *brokerOperationPoller) claim(instance *ServiceInstance) (bool, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+	now := time.Now().UTC()
+	_, err = conn.ServiceInstances().Find(bson.M{
+		"name":         instance.Name,
+		"service_name": instance.ServiceName,
+		"$or": []bson.M{
+			{"broker_poll.owner": bson.M{"$exists": false}},
+			{"broker_poll.owner": p.id},
+			{"broker_poll.expires": bson.M{"$lt": now}},
+		},
+	}).Apply(mgo.Change{
+		Update: bson.M{"$set": bson.M{"broker_poll": bson.M{
+			"owner":   p.id,
+			"expires": now.Add(brokerPollLockExpiration),
+		}}},
+		ReturnNew: true,
+	}, instance)
+	if err == mgo.ErrNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (p *brokerOperationPoller) release(instance *ServiceInstance) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.ServiceInstances().Update(bson.M{
+		"name":              instance.Name,
+		"service_name":      instance.ServiceName,
+		"broker_poll.owner": p.id,
+	}, bson.M{"$unset": bson.M{"broker_poll": ""}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	return err
+}
+
+// expired returns whether the operation is running for longer than the
+// poller timeout.
+func (p *brokerOperationPoller) expired(op *BrokerOperation) bool {
+	return p.timeout > 0 && !op.StartedAt.IsZero() && time.Since(op.StartedAt) > p.timeout
+}
+
+func (p *brokerOperationPoller) timedOut() *osb.LastOperationResponse {
+	description := fmt.Sprintf("operation timed out after %s", p.timeout)
+	return &osb.LastOperationResponse{State: osb.StateFailed, Description: &description}
+}
+
+func (p *brokerOperationPoller) pollOperation(client *brokerClient, instance *ServiceInstance, op *BrokerOperation) (*osb.LastOperationResponse, error) {
+	if p.expired(op) {
+		return p.timedOut(), nil
+	}
+	return client.pollOperation(instance, op)
+}
+
+func (p *brokerOperationPoller) poll(instance *ServiceInstance) error {
+	s, err := Get(instance.ServiceName)
+	if err != nil {
+		return err
+	}
+	endpoint, err := s.getClient("production")
+	if err != nil {
+		return err
+	}
+	client, ok := endpoint.(*brokerClient)
+	if !ok {
+		return errors.Errorf("service %q is not provided by a broker", instance.ServiceName)
+	}
+	multi := tsuruErrors.NewMultiError()
+	for i := range instance.BindOperations {
+		op := &instance.BindOperations[i]
+		if !op.inProgress() {
+			continue
+		}
+		err = p.pollBindOperation(client, instance, op)
+		if err != nil {
+			multi.Add(errors.Wrapf(err, "%s operation of app %q", op.Type, op.App))
+		}
+	}
+	if instance.hasPendingOperation() {
+		err = p.pollInstanceOperation(client, instance)
+		if err != nil {
+			multi.Add(err)
+		}
+	}
+	return multi.ToError()
+}
+
+func (p *brokerOperationPoller) pollInstanceOperation(client *brokerClient, instance *ServiceInstance) error {
+	op := instance.BrokerData.LastOperation
+	resp, err := p.pollOperation(client, instance, op)
+	if err != nil {
+		if !osb.IsGoneError(err) || op.Type != BrokerOperationDeprovision {
+			return err
+		}
+		// brokers may answer 410 Gone once the instance is deprovisioned
+		resp = &osb.LastOperationResponse{State: osb.StateSucceeded}
+	}
+	opErr := op.update(resp)
+	if op.inProgress() {
+		return updateBrokerData(instance)
+	}
+	if op.Type == BrokerOperationDeprovision && op.State == string(osb.StateSucceeded) {
+		err = removeInstance(instance)
+	} else {
+		err = updateBrokerData(instance)
+	}
+	doneEvent(op, opErr)
+	return err
+}
+
+func (p *brokerOperationPoller) pollBindOperation(client *brokerClient, instance *ServiceInstance, op *BrokerOperation) error {
+	resp, err := p.pollOperation(client, instance, op)
+	if err != nil {
+		if !osb.IsGoneError(err) || op.Type != BrokerOperationUnbind {
+			return err
+		}
+		// brokers may answer 410 Gone once the binding is removed
+		resp = &osb.LastOperationResponse{State: osb.StateSucceeded}
+	}
+	opErr := op.update(resp)
+	if op.inProgress() {
+		return updateBindOperation(instance, op)
+	}
+	removed, err := removeBindOperation(instance, op)
+	if err != nil || !removed {
+		return err
+	}
+	if op.Type == BrokerOperationBind {
+		finishErr := p.finishBind(client, instance, op, opErr)
+		if finishErr != nil {
+			opErr = finishErr
+		}
+	}
+	doneEvent(op, opErr)
+	return nil
+}
+
+// update stores the state reported by the broker, returning an error when
+// the operation failed.
+func (op *BrokerOperation) update(resp *osb.LastOperationResponse) error {
+	op.State = string(resp.State)
+	op.Description = ""
+	if resp.Description != nil {
+		op.Description = *resp.Description
+	}
+	op.UpdatedAt = time.Now().UTC()
+	if resp.State == osb.StateFailed {
+		return errors.Errorf("broker %s operation failed: %s", op.Type, op.status())
+	}
+	return nil
+}
+
+func (p *brokerOperationPoller) finishBind(client *brokerClient, instance *ServiceInstance, op *BrokerOperation, opErr error) error {
+	app, err := p.appGetter(op.App)
+	if err != nil {
+		return err
+	}
+	if opErr != nil {
+		return rollbackBind(instance, app)
+	}
+	envs, err := client.bindingEnvs(instance, op.BindingID)
+	if err != nil {
+		return err
+	}
+	return app.AddInstance(bind.AddInstanceArgs{
+		Envs:          serviceEnvsFromMap(instance, envs),
+		ShouldRestart: true,
+	})
+}
+
+func rollbackBind(instance *ServiceInstance, app bind.App) error {
+	delete(instance.BrokerData.Binds, app.GetName())
+	err := instance.updateData(bson.M{
+		"$pull": bson.M{
+			"apps":        app.GetName(),
+			"bound_units": bson.M{"appname": app.GetName()},
+		},
+		"$unset": bson.M{"broker_data.binds." + app.GetName(): ""},
+	})
+	if err != nil {
+		return err
+	}
+	return app.RemoveInstance(bind.RemoveInstanceArgs{
+		ServiceName:  instance.ServiceName,
+		InstanceName: instance.Name,
+	})
+}
+
+func removeInstance(instance *ServiceInstance) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	err = conn.ServiceInstances().Remove(bson.M{"name": instance.Name, "service_name": instance.ServiceName})
+	if err != nil {
+		return err
+	}
+	releaseRemovedInstanceQuotas(*instance, nil)
+	return nil
+}
+
+// doneEvent finishes the event that started the operation, once every
+// operation it started is done. A failed operation finishes it right away.
+func doneEvent(op *BrokerOperation, opErr error) {
+	if opErr == nil {
+		pending, err := eventHasPendingOperations(op.EventID)
+		if err != nil {
+			log.Errorf("[broker-operations] unable to check operations of event %q: %v", op.EventID, err)
+		}
+		if pending {
+			return
+		}
+	}
+	evt, err := event.GetByHexID(op.EventID)
+	if err != nil {
+		log.Errorf("[broker-operations] unable to find event %q: %v", op.EventID, err)
+		return
+	}
+	if !evt.Running {
+		return
+	}
+	err = evt.Done(opErr)
+	if err != nil {
+		log.Errorf("[broker-operations] unable to finish event %q: %v", op.EventID, err)
+	}
+}
@@ -0,0 +1,413 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package service
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/globalsign/mgo/bson"
+	osb "github.com/pmorie/go-open-service-broker-client/v2"
+	osbfake "github.com/pmorie/go-open-service-broker-client/v2/fake"
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	serviceTypes "github.com/tsuru/tsuru/types/service"
+	check "gopkg.in/check.v1"
+)
+
+func (s *S) setupBrokerOperationService(c *check.C, config osbfake.FakeClientConfiguration) {
+	s.mockService.ServiceBroker.OnFind = func(name string) (serviceTypes.Broker, error) {
+		c.Assert(name, check.Equals, "broker")
+		return serviceTypes.Broker{Name: name}, nil
+	}
+	config.CatalogReaction = &osbfake.CatalogReaction{Response: &osb.CatalogResponse{
+		Services: []osb.Service{
+			{ID: "s1", Name: "service", Plans: []osb.Plan{{ID: "p1", Name: "plan1"}}},
+		},
+	}}
+	ClientFactory = osbfake.NewFakeClientFunc(config)
+}
+
+func (s *S) TestBrokerClientUpdateAsync(c *check.C) {
+	ev := createEvt(c)
+	opKey := osb.OperationKey("op-1")
+	ClientFactory = osbfake.NewFakeClientFunc(osbfake.FakeClientConfiguration{
+		UpdateInstanceReaction: &osbfake.UpdateInstanceReaction{
+			Response: &osb.UpdateInstanceResponse{Async: true, OperationKey: &opKey},
+		},
+		CatalogReaction: &osbfake.CatalogReaction{Response: &osb.CatalogResponse{
+			Services: []osb.Service{
+				{ID: "s1", Name: "service", Plans: []osb.Plan{{ID: "p1", Name: "plan1"}}},
+			},
+		}},
+	})
+	client, err := newClient(serviceTypes.Broker{Name: "broker"}, "service")
+	c.Assert(err, check.IsNil)
+	instance := createTestInstance()
+	err = s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	err = client.Update(&instance, ev, "request-id")
+	c.Assert(err, check.IsNil)
+	storedInstance, err := GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	op := storedInstance.BrokerData.LastOperation
+	c.Assert(op, check.NotNil)
+	c.Assert(op.Type, check.Equals, BrokerOperationUpdate)
+	c.Assert(op.Key, check.Equals, "op-1")
+	c.Assert(op.State, check.Equals, "in progress")
+	c.Assert(op.EventID, check.Equals, ev.UniqueID.Hex())
+	c.Assert(storedInstance.BrokerData.LastOperationKey, check.Equals, "op-1")
+	c.Assert(ev.Pending, check.Equals, true)
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, true)
+	c.Assert(evt.Pending, check.Equals, true)
+}
+
+func (s *S) TestBrokerClientStatusPendingOperation(c *check.C) {
+	description := "creating database"
+	reaction := func(req *osb.LastOperationRequest) (*osb.LastOperationResponse, error) {
+		c.Assert(req.OperationKey, check.NotNil)
+		c.Assert(*req.OperationKey, check.Equals, osb.OperationKey("op-1"))
+		return &osb.LastOperationResponse{State: osb.StateInProgress, Description: &description}, nil
+	}
+	ClientFactory = osbfake.NewFakeClientFunc(osbfake.FakeClientConfiguration{
+		PollLastOperationReaction: osbfake.DynamicPollLastOperationReaction(reaction),
+	})
+	client, err := newClient(serviceTypes.Broker{Name: "broker"}, "service")
+	c.Assert(err, check.IsNil)
+	instance := createTestInstance()
+	instance.BrokerData.LastOperation = &BrokerOperation{Type: BrokerOperationProvision, Key: "op-1", State: "in progress"}
+	status, err := client.Status(&instance, "request-id")
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, "in progress - creating database")
+	instance.BrokerData.LastOperation = &BrokerOperation{Type: BrokerOperationProvision, State: "failed", Description: "quota exceeded"}
+	status, err = client.Status(&instance, "request-id")
+	c.Assert(err, check.IsNil)
+	c.Assert(status, check.Equals, "failed - quota exceeded")
+}
+
+func (s *S) TestBrokerOperationPollerUpdate(c *check.C) {
+	ev := createEvt(c)
+	description := "plan changed"
+	s.setupBrokerOperationService(c, osbfake.FakeClientConfiguration{
+		PollLastOperationReaction: &osbfake.PollLastOperationReaction{
+			Response: &osb.LastOperationResponse{State: osb.StateSucceeded, Description: &description},
+		},
+	})
+	instance := createTestInstance()
+	instance.ServiceName = "broker::service"
+	instance.BrokerData.LastOperation = &BrokerOperation{
+		Type:    BrokerOperationUpdate,
+		State:   "in progress",
+		EventID: ev.UniqueID.Hex(),
+	}
+	err := s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	poller := &brokerOperationPoller{appGetter: func(string) (bind.App, error) { return nil, nil }}
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	storedInstance, err := GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(storedInstance.BrokerData.LastOperation.State, check.Equals, "succeeded")
+	c.Assert(storedInstance.BrokerData.LastOperation.Description, check.Equals, "plan changed")
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, false)
+	c.Assert(evt.Error, check.Equals, "")
+}
+
+func (s *S) TestBrokerOperationPollerInProgress(c *check.C) {
+	ev := createEvt(c)
+	s.setupBrokerOperationService(c, osbfake.FakeClientConfiguration{
+		PollLastOperationReaction: &osbfake.PollLastOperationReaction{
+			Response: &osb.LastOperationResponse{State: osb.StateInProgress},
+		},
+	})
+	instance := createTestInstance()
+	instance.ServiceName = "broker::service"
+	instance.BrokerData.LastOperation = &BrokerOperation{
+		Type:    BrokerOperationProvision,
+		State:   "in progress",
+		EventID: ev.UniqueID.Hex(),
+	}
+	err := s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	poller := &brokerOperationPoller{appGetter: func(string) (bind.App, error) { return nil, nil }}
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, true)
+	pending, err := eventHasPendingOperations(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(pending, check.Equals, true)
+}
+
+func (s *S) TestBrokerOperationPollerTimeout(c *check.C) {
+	ev := createEvt(c)
+	s.setupBrokerOperationService(c, osbfake.FakeClientConfiguration{
+		PollLastOperationReaction: osbfake.DynamicPollLastOperationReaction(func(*osb.LastOperationRequest) (*osb.LastOperationResponse, error) {
+			c.Fatal("expired operations must not be polled")
+			return nil, nil
+		}),
+	})
+	instance := createTestInstance()
+	instance.ServiceName = "broker::service"
+	instance.BrokerData.LastOperation = &BrokerOperation{
+		Type:      BrokerOperationProvision,
+		State:     "in progress",
+		EventID:   ev.UniqueID.Hex(),
+		StartedAt: time.Now().UTC().Add(-2 * time.Hour),
+	}
+	err := s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	poller := &brokerOperationPoller{timeout: time.Hour, appGetter: func(string) (bind.App, error) { return nil, nil }}
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	storedInstance, err := GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(storedInstance.BrokerData.LastOperation.State, check.Equals, "failed")
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, false)
+	c.Assert(evt.Error, check.Equals, "broker provision operation failed: failed - operation timed out after 1h0m0s")
+}
+
+func (s *S) TestBrokerOperationPollerSkipsInstanceClaimedByOtherPoller(c *check.C) {
+	ev := createEvt(c)
+	s.setupBrokerOperationService(c, osbfake.FakeClientConfiguration{
+		PollLastOperationReaction: &osbfake.PollLastOperationReaction{
+			Response: &osb.LastOperationResponse{State: osb.StateSucceeded},
+		},
+	})
+	instance := createTestInstance()
+	instance.ServiceName = "broker::service"
+	instance.BrokerData.LastOperation = &BrokerOperation{
+		Type:    BrokerOperationUpdate,
+		State:   "in progress",
+		EventID: ev.UniqueID.Hex(),
+	}
+	err := s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	query := bson.M{"name": instance.Name, "service_name": instance.ServiceName}
+	err = s.conn.ServiceInstances().Update(query, bson.M{"$set": bson.M{"broker_poll": bson.M{
+		"owner":   "other-poller",
+		"expires": time.Now().UTC().Add(time.Minute),
+	}}})
+	c.Assert(err, check.IsNil)
+	poller := &brokerOperationPoller{id: "poller", appGetter: func(string) (bind.App, error) { return nil, nil }}
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, true)
+	err = s.conn.ServiceInstances().Update(query, bson.M{"$set": bson.M{"broker_poll.expires": time.Now().UTC().Add(-time.Minute)}})
+	c.Assert(err, check.IsNil)
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	evt, err = event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, false)
+	n, err := s.conn.ServiceInstances().Find(bson.M{"name": instance.Name, "broker_poll": bson.M{"$exists": true}}).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 0)
+}
+
+func (s *S) TestBrokerOperationPollerDeprovision(c *check.C) {
+	ev := createEvt(c)
+	s.setupBrokerOperationService(c, osbfake.FakeClientConfiguration{
+		PollLastOperationReaction: &osbfake.PollLastOperationReaction{
+			Response: &osb.LastOperationResponse{State: osb.StateSucceeded},
+		},
+	})
+	instance := createTestInstance()
+	instance.ServiceName = "broker::service"
+	instance.BrokerData.LastOperation = &BrokerOperation{
+		Type:    BrokerOperationDeprovision,
+		State:   "in progress",
+		EventID: ev.UniqueID.Hex(),
+	}
+	err := s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	poller := &brokerOperationPoller{appGetter: func(string) (bind.App, error) { return nil, nil }}
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	n, err := s.conn.ServiceInstances().Find(bson.M{"name": instance.Name}).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 0)
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, false)
+}
+
+func (s *S) TestBrokerOperationPollerBind(c *check.C) {
+	ev := createEvt(c)
+	s.setupBrokerOperationService(c, osbfake.FakeClientConfiguration{
+		PollBindingLastOperationReaction: &osbfake.PollBindingLastOperationReaction{
+			Response: &osb.LastOperationResponse{State: osb.StateSucceeded},
+		},
+		GetBindingReaction: &osbfake.GetBindingReaction{
+			Response: &osb.GetBindingResponse{Credentials: map[string]interface{}{"DB_HOST": "10.0.0.1"}},
+		},
+	})
+	a := provisiontest.NewFakeApp("theapp", "python", 1)
+	instance := createTestInstance()
+	instance.ServiceName = "broker::service"
+	instance.Apps = []string{"theapp"}
+	instance.BrokerData.Binds = map[string]BrokerInstanceBind{"theapp": {UUID: "bind-1"}}
+	instance.BindOperations = []BrokerOperation{{
+		Type:      BrokerOperationBind,
+		State:     "in progress",
+		EventID:   ev.UniqueID.Hex(),
+		App:       "theapp",
+		BindingID: "bind-1",
+	}}
+	err := s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	poller := &brokerOperationPoller{appGetter: func(name string) (bind.App, error) {
+		c.Assert(name, check.Equals, "theapp")
+		return a, nil
+	}}
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	c.Assert(a.GetServiceEnvs(), check.DeepEquals, []bind.ServiceEnvVar{
+		{
+			ServiceName:  "broker::service",
+			InstanceName: "instance",
+			EnvVar:       bind.EnvVar{Name: "DB_HOST", Value: "10.0.0.1"},
+		},
+	})
+	storedInstance, err := GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(storedInstance.BindOperations, check.HasLen, 0)
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, false)
+}
+
+func (s *S) TestBrokerOperationPollerBindFailed(c *check.C) {
+	ev := createEvt(c)
+	description := "no capacity"
+	s.setupBrokerOperationService(c, osbfake.FakeClientConfiguration{
+		PollBindingLastOperationReaction: &osbfake.PollBindingLastOperationReaction{
+			Response: &osb.LastOperationResponse{State: osb.StateFailed, Description: &description},
+		},
+	})
+	a := provisiontest.NewFakeApp("theapp", "python", 1)
+	instance := createTestInstance()
+	instance.ServiceName = "broker::service"
+	instance.Apps = []string{"theapp"}
+	instance.BrokerData.Binds = map[string]BrokerInstanceBind{"theapp": {UUID: "bind-1"}}
+	instance.BindOperations = []BrokerOperation{{
+		Type:      BrokerOperationBind,
+		State:     "in progress",
+		EventID:   ev.UniqueID.Hex(),
+		App:       "theapp",
+		BindingID: "bind-1",
+	}}
+	err := s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	poller := &brokerOperationPoller{appGetter: func(name string) (bind.App, error) {
+		return a, nil
+	}}
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	storedInstance, err := GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(storedInstance.Apps, check.HasLen, 0)
+	c.Assert(storedInstance.BrokerData.Binds, check.HasLen, 0)
+	c.Assert(storedInstance.BindOperations, check.HasLen, 0)
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, false)
+	c.Assert(evt.Error, check.Equals, "broker bind operation failed: failed - no capacity")
+}
+
+func (s *S) TestBrokerClientUnbindAsyncTracksEachBinding(c *check.C) {
+	ev := createEvt(c)
+	ev.Logf("unbinding apps")
+	ClientFactory = osbfake.NewFakeClientFunc(osbfake.FakeClientConfiguration{
+		UnbindReaction: &osbfake.UnbindReaction{
+			Response: &osb.UnbindResponse{Async: true},
+		},
+	})
+	client, err := newClient(serviceTypes.Broker{Name: "broker"}, "service")
+	c.Assert(err, check.IsNil)
+	instance := createTestInstance()
+	instance.BrokerData.Binds = map[string]BrokerInstanceBind{
+		"app1": {UUID: "bind-1"},
+		"app2": {UUID: "bind-2"},
+	}
+	err = s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	err = client.UnbindApp(&instance, provisiontest.NewFakeApp("app1", "python", 1), ev, "request-id")
+	c.Assert(err, check.IsNil)
+	err = client.UnbindApp(&instance, provisiontest.NewFakeApp("app2", "python", 1), ev, "request-id")
+	c.Assert(err, check.IsNil)
+	storedInstance, err := GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(storedInstance.BrokerData.LastOperation, check.IsNil)
+	c.Assert(storedInstance.BindOperations, check.HasLen, 2)
+	c.Assert(storedInstance.BindOperations[0].BindingID, check.Equals, "bind-1")
+	c.Assert(storedInstance.BindOperations[0].App, check.Equals, "app1")
+	c.Assert(storedInstance.BindOperations[1].BindingID, check.Equals, "bind-2")
+	c.Assert(storedInstance.BindOperations[1].App, check.Equals, "app2")
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Pending, check.Equals, true)
+	c.Assert(evt.Log, check.Equals, "unbinding apps\n")
+}
+
+func (s *S) TestBrokerOperationPollerFinishesEventAfterAllOperations(c *check.C) {
+	ev := createEvt(c)
+	ev.Logf("unbinding apps")
+	err := ev.SetPending()
+	c.Assert(err, check.IsNil)
+	reaction := func(req *osb.BindingLastOperationRequest) (*osb.LastOperationResponse, error) {
+		if req.BindingID == "bind-1" {
+			return &osb.LastOperationResponse{State: osb.StateSucceeded}, nil
+		}
+		return &osb.LastOperationResponse{State: osb.StateInProgress}, nil
+	}
+	s.setupBrokerOperationService(c, osbfake.FakeClientConfiguration{
+		PollBindingLastOperationReaction: osbfake.DynamicPollBindingLastOperationReaction(reaction),
+	})
+	instance := createTestInstance()
+	instance.ServiceName = "broker::service"
+	instance.BindOperations = []BrokerOperation{
+		{Type: BrokerOperationUnbind, State: "in progress", EventID: ev.UniqueID.Hex(), App: "app1", BindingID: "bind-1"},
+		{Type: BrokerOperationUnbind, State: "in progress", EventID: ev.UniqueID.Hex(), App: "app2", BindingID: "bind-2"},
+	}
+	err = s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	poller := &brokerOperationPoller{appGetter: func(string) (bind.App, error) { return nil, nil }}
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	storedInstance, err := GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(storedInstance.BindOperations, check.HasLen, 1)
+	c.Assert(storedInstance.BindOperations[0].BindingID, check.Equals, "bind-2")
+	evt, err := event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, true)
+	s.setupBrokerOperationService(c, osbfake.FakeClientConfiguration{
+		PollBindingLastOperationReaction: &osbfake.PollBindingLastOperationReaction{
+			Error: osb.HTTPStatusCodeError{StatusCode: http.StatusGone},
+		},
+	})
+	err = poller.run()
+	c.Assert(err, check.IsNil)
+	storedInstance, err = GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(storedInstance.BindOperations, check.HasLen, 0)
+	evt, err = event.GetByHexID(ev.UniqueID.Hex())
+	c.Assert(err, check.IsNil)
+	c.Assert(evt.Running, check.Equals, false)
+	c.Assert(evt.Pending, check.Equals, false)
+	c.Assert(evt.Error, check.Equals, "")
+	c.Assert(evt.Log, check.Equals, "unbinding apps\n")
+}
@@ -357,6 +357,52 @@ func (s *S) TestBrokerClientUnbindApp(c *check.C) {
 	})
 }
 
+func (s *S) TestBrokerClientRotateCredentialsRetryRevokesEveryPreviousBinding(c *check.C) {
+	ev := createEvt(c)
+	var unbound []string
+	config := osbfake.FakeClientConfiguration{
+		CatalogReaction: &osbfake.CatalogReaction{Response: &osb.CatalogResponse{
+			Services: []osb.Service{
+				{ID: "s1", Name: "service", Plans: []osb.Plan{{ID: "p1", Name: "plan1"}}},
+			},
+		}},
+		BindReaction: &osbfake.BindReaction{
+			Response: &osb.BindResponse{Credentials: map[string]interface{}{"env1": "val1"}},
+		},
+		UnbindReaction: osbfake.DynamicUnbindReaction(func(req *osb.UnbindRequest) (*osb.UnbindResponse, error) {
+			unbound = append(unbound, req.BindingID)
+			return &osb.UnbindResponse{}, nil
+		}),
+	}
+	ClientFactory = osbfake.NewFakeClientFunc(config)
+	client, err := newClient(serviceTypes.Broker{Name: "broker"}, "service")
+	c.Assert(err, check.IsNil)
+	instance := createTestInstance()
+	instance.BrokerData.Binds = map[string]BrokerInstanceBind{
+		"theapp": {UUID: "bind-0"},
+	}
+	err = s.conn.ServiceInstances().Insert(&instance)
+	c.Assert(err, check.IsNil)
+	a := provisiontest.NewFakeApp("theapp", "python", 1)
+	_, err = client.RotateCredentials(&instance, a, ev, "request-id")
+	c.Assert(err, check.IsNil)
+	firstRotation := instance.BrokerData.Binds["theapp"].UUID
+	// the app failed to restart, the rotation is retried before revoking
+	_, err = client.RotateCredentials(&instance, a, ev, "request-id")
+	c.Assert(err, check.IsNil)
+	storedInstance, err := GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	binding := storedInstance.BrokerData.Binds["theapp"]
+	c.Assert(binding.PreviousUUIDs, check.DeepEquals, []string{"bind-0", firstRotation})
+	err = client.RevokePreviousCredentials(storedInstance, a, ev, "request-id")
+	c.Assert(err, check.IsNil)
+	c.Assert(unbound, check.DeepEquals, []string{"bind-0", firstRotation})
+	storedInstance, err = GetServiceInstance(instance.ServiceName, instance.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(storedInstance.BrokerData.Binds["theapp"].UUID, check.Equals, binding.UUID)
+	c.Assert(storedInstance.BrokerData.Binds["theapp"].PreviousUUIDs, check.IsNil)
+}
+
 func (s *S) TestBrokerClientUpdate(c *check.C) {
 	ev := createEvt(c)
 	planID := "planid"
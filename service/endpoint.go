@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -21,12 +22,14 @@ import (
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/log"
 	"github.com/tsuru/tsuru/net"
+	"github.com/tsuru/tsuru/tracing"
 )
 
 var (
 	ErrInstanceAlreadyExistsInAPI = errors.New("instance already exists in the service API")
 	ErrInstanceNotFoundInAPI      = errors.New("instance does not exist in the service API")
 	ErrInstanceNotReady           = errors.New("instance is not ready yet")
+	ErrRotationNotSupported       = errors.New("credentials rotation is not supported by the service")
 
 	requestLatencies = prometheus.NewHistogramVec(prometheus.HistogramOpts{
 		Name: "tsuru_service_request_duration_seconds",
@@ -239,6 +242,78 @@ func (c *endpointClient) UnbindApp(instance *ServiceInstance, app bind.App, evt
 	return err
 }
 
+var _ CredentialsRotator = &endpointClient{}
+
+// RotateCredentials asks the service API for new credentials for the app. The
+// service must keep the previous credentials valid until
+// RevokePreviousCredentials is called.
+func (c *endpointClient) RotateCredentials(instance *ServiceInstance, app bind.App, evt *event.Event, requestID string) (map[string]string, error) {
+	log.Debugf("Calling credentials rotation of instance %q and %q app at %q API",
+		instance.Name, app.GetName(), instance.ServiceName)
+	params, err := c.rotateParams(app, evt)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.issueRequest("/resources/"+instance.GetIdentifier()+"/bind-app/rotate", "POST", params, requestID)
+	if err != nil {
+		return nil, log.WrapError(errors.Wrapf(err, `Failed to rotate credentials of app %q in service instance "%s/%s"`, app.GetName(), instance.ServiceName, instance.Name))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 300 {
+		var result map[string]string
+		err = c.jsonFromResponse(resp, &result)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+	switch resp.StatusCode {
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return nil, ErrRotationNotSupported
+	case http.StatusPreconditionFailed:
+		return nil, ErrInstanceNotReady
+	}
+	err = errors.Wrapf(c.buildErrorMessage(err, resp), `Failed to rotate credentials of app %q in service instance "%s/%s"`, app.GetName(), instance.ServiceName, instance.Name)
+	return nil, log.WrapError(err)
+}
+
+// RevokePreviousCredentials tells the service API that the app is no longer
+// using the credentials replaced by the last rotation.
+func (c *endpointClient) RevokePreviousCredentials(instance *ServiceInstance, app bind.App, evt *event.Event, requestID string) error {
+	params, err := c.rotateParams(app, evt)
+	if err != nil {
+		return err
+	}
+	url := "/resources/" + instance.GetIdentifier() + "/bind-app/rotate"
+	resp, err := c.issueRequest(url, "DELETE", params, requestID)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		err = errors.Wrapf(c.buildErrorMessage(err, resp), "Failed to revoke previous credentials (%q)", url)
+		return log.WrapError(err)
+	}
+	return nil
+}
+
+func (c *endpointClient) rotateParams(app bind.App, evt *event.Event) (map[string][]string, error) {
+	appAddrs, err := app.GetAddresses()
+	if err != nil {
+		return nil, err
+	}
+	params := map[string][]string{
+		"app-name":  {app.GetName()},
+		"app-hosts": appAddrs,
+		"user":      {evt.Owner.Name},
+		"eventid":   {evt.UniqueID.Hex()},
+	}
+	if len(appAddrs) > 0 {
+		params["app-host"] = []string{appAddrs[0]}
+	}
+	return params, nil
+}
+
 func (c *endpointClient) UnbindUnit(instance *ServiceInstance, app bind.App, unit bind.Unit) error {
 	log.Debugf("Calling unbind of service instance %q and unit %q at %q", instance.Name, unit.GetIp(), instance.ServiceName)
 	appAddrs, err := app.GetAddresses()
@@ -402,12 +477,19 @@ func (c *endpointClient) issueRequest(path, method string, params map[string][]s
 	}
 	req.SetBasicAuth(c.username, c.password)
 	req.Close = true
+	span := tracing.StartSpan(tracing.TraceForRequest(requestID), "service.Request")
+	span.SetTag("service", c.serviceName)
+	span.SetTag("http.method", method)
+	span.SetTag("http.path", "/"+strings.Trim(path, "/"))
 	t0 := time.Now()
 	resp, err := net.Dial15Full300ClientWithPool.Do(req)
 	requestLatencies.WithLabelValues(c.serviceName).Observe(time.Since(t0).Seconds())
 	if err != nil {
 		requestErrors.WithLabelValues(c.serviceName).Inc()
+	} else {
+		span.SetTag("http.status_code", strconv.Itoa(resp.StatusCode))
 	}
+	span.Finish(err)
 	return resp, err
 }
 
@@ -19,6 +19,8 @@ import (
 	"github.com/tsuru/tsuru/event"
 	"github.com/tsuru/tsuru/permission"
 	"github.com/tsuru/tsuru/provision/provisiontest"
+	"github.com/tsuru/tsuru/tracing"
+	"github.com/tsuru/tsuru/tracing/tracingtest"
 	"gopkg.in/check.v1"
 )
 
@@ -118,6 +120,31 @@ func (s *S) TestEndpointCreate(c *check.C) {
 	c.Assert("close", check.Equals, h.request.Header.Get("Connection"))
 }
 
+func (s *S) TestEndpointCreateTracing(c *check.C) {
+	exporter := tracingtest.New()
+	defer tracing.SetExporter(nil)
+	h := TestHandler{}
+	ts := httptest.NewServer(&h)
+	defer ts.Close()
+	instance := ServiceInstance{Name: "my-redis", ServiceName: "redis", TeamOwner: "theteam"}
+	client := &endpointClient{serviceName: "redis", endpoint: ts.URL, username: "user", password: "abcde"}
+	evt := createEvt(c)
+	root := tracing.StartTrace("POST /services/{service}/instances", "my-rid")
+	err := client.Create(&instance, evt, "my-rid")
+	c.Assert(err, check.IsNil)
+	root.Finish(nil)
+	spans := exporter.SpansByOperation("service.Request")
+	c.Assert(spans, check.HasLen, 1)
+	c.Assert(spans[0].TraceID, check.Equals, root.TraceID)
+	c.Assert(spans[0].ParentID, check.Equals, root.ID)
+	c.Assert(spans[0].Tags, check.DeepEquals, map[string]string{
+		"service":          "redis",
+		"http.method":      "POST",
+		"http.path":        "/resources",
+		"http.status_code": "200",
+	})
+}
+
 func (s *S) TestEndpointCreateEndpointDown(c *check.C) {
 	instance := ServiceInstance{Name: "my-redis", ServiceName: "redis", TeamOwner: "theteam", Description: "xyz"}
 	client := &endpointClient{endpoint: "http://127.0.0.1:19999", username: "user", password: "abcde"}
@@ -0,0 +1,244 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package service
+
+import (
+	"fmt"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/types/quota"
+)
+
+var ErrInstanceQuotaPlanWithoutService = errors.New("plan quotas must also define the service")
+
+// InstanceQuota limits the number of service instances owned by a team. Empty
+// Service and Plan fields match any service and plan, so the same quota type
+// is used to limit instances per team, per service and per plan.
+type InstanceQuota struct {
+	ID      bson.ObjectId `json:"-" bson:"_id,omitempty"`
+	Team    string        `json:"team"`
+	Service string        `json:"service,omitempty"`
+	Plan    string        `json:"plan,omitempty"`
+	Limit   int           `json:"limit"`
+	InUse   int           `json:"inuse"`
+}
+
+type InstanceQuotaExceededError struct {
+	Quota InstanceQuota
+}
+
+func (err *InstanceQuotaExceededError) Error() string {
+	return fmt.Sprintf("Instance quota exceeded for %s. Limit: %d, In use: %d.", err.Quota.scope(), err.Quota.Limit, err.Quota.InUse)
+}
+
+func (q *InstanceQuota) scope() string {
+	scope := fmt.Sprintf("team %q", q.Team)
+	if q.Service != "" {
+		scope += fmt.Sprintf(", service %q", q.Service)
+	}
+	if q.Plan != "" {
+		scope += fmt.Sprintf(", plan %q", q.Plan)
+	}
+	return scope
+}
+
+func (q *InstanceQuota) instancesQuery() bson.M {
+	query := bson.M{"teamowner": q.Team}
+	if q.Service != "" {
+		query["service_name"] = q.Service
+	}
+	if q.Plan != "" {
+		query["plan_name"] = q.Plan
+	}
+	return query
+}
+
+// SetInstanceQuota defines the limit of instances for the quota scope. A
+// negative limit removes the quota, allowing unlimited instances.
+func SetInstanceQuota(q InstanceQuota) error {
+	if q.Team == "" {
+		return ErrTeamMandatory
+	}
+	if q.Plan != "" && q.Service == "" {
+		return ErrInstanceQuotaPlanWithoutService
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	query := bson.M{"team": q.Team, "service": q.Service, "plan": q.Plan}
+	if q.Limit < 0 {
+		err = conn.ServiceInstanceQuotas().Remove(query)
+		if err == mgo.ErrNotFound {
+			return nil
+		}
+		return err
+	}
+	inUse, err := conn.ServiceInstances().Find(q.instancesQuery()).Count()
+	if err != nil {
+		return err
+	}
+	if q.Limit < inUse {
+		return quota.ErrLimitLowerThanAllocated
+	}
+	_, err = conn.ServiceInstanceQuotas().Upsert(query, bson.M{"$set": bson.M{"limit": q.Limit, "inuse": inUse}})
+	return err
+}
+
+// ListInstanceQuotas returns the instance quotas defined for the team along
+// with the number of instances currently using each one of them.
+func ListInstanceQuotas(team string) ([]InstanceQuota, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var quotas []InstanceQuota
+	err = conn.ServiceInstanceQuotas().Find(bson.M{"team": team}).Sort("service", "plan").All(&quotas)
+	if err != nil {
+		return nil, err
+	}
+	for i := range quotas {
+		quotas[i].InUse, err = conn.ServiceInstances().Find(quotas[i].instancesQuery()).Count()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return quotas, nil
+}
+
+func matchingInstanceQuotas(conn *db.Storage, si ServiceInstance) ([]InstanceQuota, error) {
+	var quotas []InstanceQuota
+	err := conn.ServiceInstanceQuotas().Find(bson.M{
+		"team":    si.TeamOwner,
+		"service": bson.M{"$in": []string{"", si.ServiceName}},
+		"plan":    bson.M{"$in": []string{"", si.PlanName}},
+	}).All(&quotas)
+	return quotas, err
+}
+
+// instanceQuotasDiff returns the quotas matching the instance that do not
+// match the previous version of it. A nil previous instance returns every
+// quota matching the instance.
+func instanceQuotasDiff(conn *db.Storage, si ServiceInstance, previous *ServiceInstance) ([]InstanceQuota, error) {
+	quotas, err := matchingInstanceQuotas(conn, si)
+	if err != nil || previous == nil {
+		return quotas, err
+	}
+	previousQuotas, err := matchingInstanceQuotas(conn, *previous)
+	if err != nil {
+		return nil, err
+	}
+	var result []InstanceQuota
+	for _, q := range quotas {
+		found := false
+		for _, pq := range previousQuotas {
+			if pq.ID == q.ID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, q)
+		}
+	}
+	return result, nil
+}
+
+// reserveInstanceQuotas takes one instance from every quota matching the
+// instance and not matching its previous version, which is nil for new
+// instances. Each quota is incremented only while it is below its limit, so
+// concurrent requests can't exceed it. When any quota is exceeded, the quotas
+// already taken are released. The reserved quotas are returned so they can be
+// released if the operation fails.
+func reserveInstanceQuotas(si ServiceInstance, previous *ServiceInstance) ([]InstanceQuota, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	quotas, err := instanceQuotasDiff(conn, si, previous)
+	if err != nil {
+		return nil, err
+	}
+	var reserved []InstanceQuota
+	for _, q := range quotas {
+		err = reserveInstanceQuota(conn, q)
+		if err == mgo.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			releaseInstanceQuotas(reserved)
+			return nil, err
+		}
+		reserved = append(reserved, q)
+	}
+	return reserved, nil
+}
+
+func reserveInstanceQuota(conn *db.Storage, q InstanceQuota) error {
+	for {
+		err := conn.ServiceInstanceQuotas().Update(
+			bson.M{"_id": q.ID, "limit": q.Limit, "inuse": bson.M{"$lt": q.Limit}},
+			bson.M{"$inc": bson.M{"inuse": 1}},
+		)
+		if err != mgo.ErrNotFound {
+			return err
+		}
+		// The quota may have been changed since it was read, check it
+		// again before giving up.
+		err = conn.ServiceInstanceQuotas().FindId(q.ID).One(&q)
+		if err != nil {
+			return err
+		}
+		if q.InUse >= q.Limit {
+			return &InstanceQuotaExceededError{Quota: q}
+		}
+	}
+}
+
+// releaseInstanceQuotas gives back one instance to each one of the quotas.
+func releaseInstanceQuotas(quotas []InstanceQuota) {
+	if len(quotas) == 0 {
+		return
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		log.Errorf("unable to release service instance quotas: %v", err)
+		return
+	}
+	defer conn.Close()
+	for _, q := range quotas {
+		err = conn.ServiceInstanceQuotas().Update(
+			bson.M{"_id": q.ID, "inuse": bson.M{"$gt": 0}},
+			bson.M{"$inc": bson.M{"inuse": -1}},
+		)
+		if err != nil && err != mgo.ErrNotFound {
+			log.Errorf("unable to release service instance quota for %s: %v", q.scope(), err)
+		}
+	}
+}
+
+// releaseRemovedInstanceQuotas releases the quotas matching the instance and
+// not matching its current version, which is nil for removed instances.
+func releaseRemovedInstanceQuotas(si ServiceInstance, current *ServiceInstance) {
+	conn, err := db.Conn()
+	if err != nil {
+		log.Errorf("unable to release service instance quotas: %v", err)
+		return
+	}
+	defer conn.Close()
+	quotas, err := instanceQuotasDiff(conn, si, current)
+	if err != nil {
+		log.Errorf("unable to release service instance quotas: %v", err)
+		return
+	}
+	releaseInstanceQuotas(quotas)
+}
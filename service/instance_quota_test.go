@@ -0,0 +1,163 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/tsuru/tsuru/types/quota"
+	"gopkg.in/check.v1"
+)
+
+func (s *InstanceSuite) TestSetInstanceQuota(c *check.C) {
+	err := SetInstanceQuota(InstanceQuota{Team: s.team.Name, Limit: 10})
+	c.Assert(err, check.IsNil)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Plan: "large", Limit: 1})
+	c.Assert(err, check.IsNil)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Limit: 3})
+	c.Assert(err, check.IsNil)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Limit: 2})
+	c.Assert(err, check.IsNil)
+	err = s.conn.ServiceInstances().Insert(ServiceInstance{Name: "db1", ServiceName: "mysql", PlanName: "large", TeamOwner: s.team.Name})
+	c.Assert(err, check.IsNil)
+	quotas, err := ListInstanceQuotas(s.team.Name)
+	c.Assert(err, check.IsNil)
+	for i := range quotas {
+		quotas[i].ID = ""
+	}
+	c.Assert(quotas, check.DeepEquals, []InstanceQuota{
+		{Team: s.team.Name, Limit: 10, InUse: 1},
+		{Team: s.team.Name, Service: "mysql", Limit: 2, InUse: 1},
+		{Team: s.team.Name, Service: "mysql", Plan: "large", Limit: 1, InUse: 1},
+	})
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Limit: -1})
+	c.Assert(err, check.IsNil)
+	quotas, err = ListInstanceQuotas(s.team.Name)
+	c.Assert(err, check.IsNil)
+	c.Assert(quotas, check.HasLen, 2)
+}
+
+func (s *InstanceSuite) TestSetInstanceQuotaLowerThanInUse(c *check.C) {
+	err := s.conn.ServiceInstances().Insert(ServiceInstance{Name: "db1", ServiceName: "mysql", TeamOwner: s.team.Name})
+	c.Assert(err, check.IsNil)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Limit: 0})
+	c.Assert(err, check.Equals, quota.ErrLimitLowerThanAllocated)
+}
+
+func (s *InstanceSuite) TestSetInstanceQuotaInvalid(c *check.C) {
+	err := SetInstanceQuota(InstanceQuota{Service: "mysql", Limit: 1})
+	c.Assert(err, check.Equals, ErrTeamMandatory)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Plan: "large", Limit: 1})
+	c.Assert(err, check.Equals, ErrInstanceQuotaPlanWithoutService)
+}
+
+func (s *InstanceSuite) TestCreateServiceInstanceQuotaExceeded(c *check.C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	srv := Service{Name: "mysql", Endpoint: map[string]string{"production": ts.URL}, Password: "s3cr3t"}
+	err := s.conn.Services().Insert(&srv)
+	c.Assert(err, check.IsNil)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Plan: "large", Limit: 1})
+	c.Assert(err, check.IsNil)
+	evt := createEvt(c)
+	err = CreateServiceInstance(ServiceInstance{Name: "db1", PlanName: "large", TeamOwner: s.team.Name}, &srv, evt, "")
+	c.Assert(err, check.IsNil)
+	err = CreateServiceInstance(ServiceInstance{Name: "db2", PlanName: "small", TeamOwner: s.team.Name}, &srv, evt, "")
+	c.Assert(err, check.IsNil)
+	err = CreateServiceInstance(ServiceInstance{Name: "db3", PlanName: "large", TeamOwner: s.team.Name}, &srv, evt, "")
+	c.Assert(err, check.FitsTypeOf, &InstanceQuotaExceededError{})
+	c.Assert(err, check.ErrorMatches, `Instance quota exceeded for team "raul", service "mysql", plan "large". Limit: 1, In use: 1.`)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Limit: 2})
+	c.Assert(err, check.IsNil)
+	err = CreateServiceInstance(ServiceInstance{Name: "db4", PlanName: "small", TeamOwner: s.team.Name}, &srv, evt, "")
+	c.Assert(err, check.ErrorMatches, `Instance quota exceeded for team "raul". Limit: 2, In use: 2.`)
+	n, err := s.conn.ServiceInstances().Find(nil).Count()
+	c.Assert(err, check.IsNil)
+	c.Assert(n, check.Equals, 2)
+}
+
+func (s *InstanceSuite) TestUpdateServiceInstanceQuotaExceeded(c *check.C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	srv := Service{Name: "mysql", Endpoint: map[string]string{"production": ts.URL}, Password: "s3cr3t"}
+	err := s.conn.Services().Insert(&srv)
+	c.Assert(err, check.IsNil)
+	err = s.conn.ServiceInstances().Insert(
+		ServiceInstance{Name: "db1", ServiceName: "mysql", PlanName: "large", TeamOwner: s.team.Name},
+		ServiceInstance{Name: "db2", ServiceName: "mysql", PlanName: "small", TeamOwner: s.team.Name},
+	)
+	c.Assert(err, check.IsNil)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Plan: "large", Limit: 1})
+	c.Assert(err, check.IsNil)
+	instance, err := GetServiceInstance("mysql", "db1")
+	c.Assert(err, check.IsNil)
+	instance.Description = "still large"
+	evt := createEvt(c)
+	err = instance.Update(srv, *instance, evt, "")
+	c.Assert(err, check.IsNil)
+	instance, err = GetServiceInstance("mysql", "db2")
+	c.Assert(err, check.IsNil)
+	updateData := *instance
+	updateData.PlanName = "large"
+	err = instance.Update(srv, updateData, evt, "")
+	c.Assert(err, check.FitsTypeOf, &InstanceQuotaExceededError{})
+	instance, err = GetServiceInstance("mysql", "db2")
+	c.Assert(err, check.IsNil)
+	c.Assert(instance.PlanName, check.Equals, "small")
+}
+
+func (s *InstanceSuite) TestCreateServiceInstanceQuotaReleasedOnFailure(c *check.C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+	srv := Service{Name: "mysql", Endpoint: map[string]string{"production": ts.URL}, Password: "s3cr3t"}
+	err := s.conn.Services().Insert(&srv)
+	c.Assert(err, check.IsNil)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Limit: 1})
+	c.Assert(err, check.IsNil)
+	evt := createEvt(c)
+	err = CreateServiceInstance(ServiceInstance{Name: "db1", PlanName: "large", TeamOwner: s.team.Name}, &srv, evt, "")
+	c.Assert(err, check.NotNil)
+	var q InstanceQuota
+	err = s.conn.ServiceInstanceQuotas().Find(nil).One(&q)
+	c.Assert(err, check.IsNil)
+	c.Assert(q.InUse, check.Equals, 0)
+}
+
+func (s *InstanceSuite) TestUpdateServiceInstanceQuotaMovesReservation(c *check.C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	srv := Service{Name: "mysql", Endpoint: map[string]string{"production": ts.URL}, Password: "s3cr3t"}
+	err := s.conn.Services().Insert(&srv)
+	c.Assert(err, check.IsNil)
+	err = s.conn.ServiceInstances().Insert(ServiceInstance{Name: "db1", ServiceName: "mysql", PlanName: "small", TeamOwner: s.team.Name})
+	c.Assert(err, check.IsNil)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Plan: "small", Limit: 1})
+	c.Assert(err, check.IsNil)
+	err = SetInstanceQuota(InstanceQuota{Team: s.team.Name, Service: "mysql", Plan: "large", Limit: 1})
+	c.Assert(err, check.IsNil)
+	instance, err := GetServiceInstance("mysql", "db1")
+	c.Assert(err, check.IsNil)
+	updateData := *instance
+	updateData.PlanName = "large"
+	err = instance.Update(srv, updateData, createEvt(c), "")
+	c.Assert(err, check.IsNil)
+	var quotas []InstanceQuota
+	err = s.conn.ServiceInstanceQuotas().Find(nil).Sort("plan").All(&quotas)
+	c.Assert(err, check.IsNil)
+	c.Assert(quotas, check.HasLen, 2)
+	c.Assert(quotas[0].Plan, check.Equals, "large")
+	c.Assert(quotas[0].InUse, check.Equals, 1)
+	c.Assert(quotas[1].Plan, check.Equals, "small")
+	c.Assert(quotas[1].InUse, check.Equals, 0)
+}
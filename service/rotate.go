@@ -0,0 +1,105 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package service
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/event"
+)
+
+type RotateCredentialsArgs struct {
+	Apps      []bind.App
+	Writer    io.Writer
+	Event     *event.Event
+	RequestID string
+}
+
+// RotationError is returned when a rotation stops partway. Rotated lists the
+// apps already switched to their new credentials, the previous credentials of
+// every app are kept valid so the rotation can be safely retried.
+type RotationError struct {
+	Rotated []string
+	Err     error
+}
+
+func (e *RotationError) Error() string {
+	if len(e.Rotated) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%v (apps already using new credentials: %s)", e.Err, strings.Join(e.Rotated, ", "))
+}
+
+func (e *RotationError) Cause() error {
+	return errors.Cause(e.Err)
+}
+
+// RotateCredentials issues new credentials for the apps bound to the service
+// instance. Apps are switched to their new credentials and restarted one at a
+// time, and the previous credentials are only revoked after every app has been
+// restarted, so both remain valid while the rotation is in progress. Callers
+// must hold the lock of every app, so that their service envs are not changed
+// concurrently.
+func (si *ServiceInstance) RotateCredentials(args RotateCredentialsArgs) error {
+	s, err := Get(si.ServiceName)
+	if err != nil {
+		return err
+	}
+	endpoint, err := s.getClient("production")
+	if err != nil {
+		return err
+	}
+	rotator, ok := endpoint.(CredentialsRotator)
+	if !ok {
+		return ErrRotationNotSupported
+	}
+	for _, a := range args.Apps {
+		if si.FindApp(a.GetName()) == -1 {
+			return ErrAppNotBound
+		}
+	}
+	w := args.Writer
+	if w == nil {
+		w = ioutil.Discard
+	}
+	var rotated []string
+	for _, a := range args.Apps {
+		envs, err := rotator.RotateCredentials(si, a, args.Event, args.RequestID)
+		if err != nil {
+			return &RotationError{
+				Rotated: rotated,
+				Err:     errors.Wrapf(err, "unable to rotate credentials of app %q", a.GetName()),
+			}
+		}
+		fmt.Fprintf(w, "---- Rotated credentials of app %q, restarting it ----\n", a.GetName())
+		err = a.ReplaceInstance(bind.ReplaceInstanceArgs{
+			ServiceName:   si.ServiceName,
+			InstanceName:  si.Name,
+			Envs:          serviceEnvsFromMap(si, envs),
+			Writer:        w,
+			ShouldRestart: true,
+		})
+		if err != nil {
+			return &RotationError{
+				Rotated: rotated,
+				Err:     errors.Wrapf(err, "unable to update credentials of app %q, previous credentials were kept", a.GetName()),
+			}
+		}
+		rotated = append(rotated, a.GetName())
+	}
+	for _, a := range args.Apps {
+		err = rotator.RevokePreviousCredentials(si, a, args.Event, args.RequestID)
+		if err != nil {
+			return errors.Wrapf(err, "unable to revoke previous credentials of app %q", a.GetName())
+		}
+	}
+	fmt.Fprintf(w, "---- Credentials of %d apps rotated ----\n", len(args.Apps))
+	return nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/app/bind"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"gopkg.in/check.v1"
+)
+
+func (s *InstanceSuite) TestRotateCredentials(c *check.C) {
+	var mut sync.Mutex
+	var calls []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mut.Lock()
+		defer mut.Unlock()
+		r.ParseForm()
+		calls = append(calls, r.Method+" "+r.URL.Path+" "+r.FormValue("app-name"))
+		if r.Method == http.MethodPost {
+			w.Write([]byte(`{"DATABASE_PASSWORD": "new-` + r.FormValue("app-name") + `"}`))
+		}
+	}))
+	defer ts.Close()
+	srv := Service{Name: "mysql", Endpoint: map[string]string{"production": ts.URL}, Password: "s3cr3t"}
+	err := s.conn.Services().Insert(&srv)
+	c.Assert(err, check.IsNil)
+	si := ServiceInstance{Name: "my-mysql", ServiceName: "mysql", Apps: []string{"app1", "app2"}, TeamOwner: s.team.Name}
+	err = s.conn.ServiceInstances().Insert(&si)
+	c.Assert(err, check.IsNil)
+	var apps []bind.App
+	for _, name := range si.Apps {
+		a := provisiontest.NewFakeApp(name, "python", 1)
+		err = a.AddInstance(bind.AddInstanceArgs{
+			Envs: []bind.ServiceEnvVar{
+				{ServiceName: "mysql", InstanceName: "my-mysql", EnvVar: bind.EnvVar{Name: "DATABASE_PASSWORD", Value: "old"}},
+				{ServiceName: "redis", InstanceName: "my-redis", EnvVar: bind.EnvVar{Name: "REDIS_HOST", Value: "localhost"}},
+			},
+		})
+		c.Assert(err, check.IsNil)
+		apps = append(apps, a)
+	}
+	evt := createEvt(c)
+	err = si.RotateCredentials(RotateCredentialsArgs{Apps: apps, Event: evt})
+	c.Assert(err, check.IsNil)
+	c.Assert(calls, check.DeepEquals, []string{
+		"POST /resources/my-mysql/bind-app/rotate app1",
+		"POST /resources/my-mysql/bind-app/rotate app2",
+		"DELETE /resources/my-mysql/bind-app/rotate app1",
+		"DELETE /resources/my-mysql/bind-app/rotate app2",
+	})
+	c.Assert(apps[0].(*provisiontest.FakeApp).GetServiceEnvs(), check.DeepEquals, []bind.ServiceEnvVar{
+		{ServiceName: "redis", InstanceName: "my-redis", EnvVar: bind.EnvVar{Name: "REDIS_HOST", Value: "localhost"}},
+		{ServiceName: "mysql", InstanceName: "my-mysql", EnvVar: bind.EnvVar{Name: "DATABASE_PASSWORD", Value: "new-app1"}},
+	})
+	c.Assert(apps[1].(*provisiontest.FakeApp).GetServiceEnvs(), check.DeepEquals, []bind.ServiceEnvVar{
+		{ServiceName: "redis", InstanceName: "my-redis", EnvVar: bind.EnvVar{Name: "REDIS_HOST", Value: "localhost"}},
+		{ServiceName: "mysql", InstanceName: "my-mysql", EnvVar: bind.EnvVar{Name: "DATABASE_PASSWORD", Value: "new-app2"}},
+	})
+}
+
+func (s *InstanceSuite) TestRotateCredentialsFailureKeepsPreviousCredentials(c *check.C) {
+	var deleteCalls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Method == http.MethodDelete {
+			deleteCalls++
+			return
+		}
+		if r.FormValue("app-name") == "app2" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("out of credentials"))
+			return
+		}
+		w.Write([]byte(`{"DATABASE_PASSWORD": "new"}`))
+	}))
+	defer ts.Close()
+	srv := Service{Name: "mysql", Endpoint: map[string]string{"production": ts.URL}, Password: "s3cr3t"}
+	err := s.conn.Services().Insert(&srv)
+	c.Assert(err, check.IsNil)
+	si := ServiceInstance{Name: "my-mysql", ServiceName: "mysql", Apps: []string{"app1", "app2"}, TeamOwner: s.team.Name}
+	app1 := provisiontest.NewFakeApp("app1", "python", 1)
+	app2 := provisiontest.NewFakeApp("app2", "python", 1)
+	evt := createEvt(c)
+	err = si.RotateCredentials(RotateCredentialsArgs{Apps: []bind.App{app1, app2}, Event: evt})
+	c.Assert(err, check.ErrorMatches, `(?s)unable to rotate credentials of app "app2".*out of credentials.*\(apps already using new credentials: app1\)`)
+	rotationErr, ok := err.(*RotationError)
+	c.Assert(ok, check.Equals, true)
+	c.Assert(rotationErr.Rotated, check.DeepEquals, []string{"app1"})
+	c.Assert(deleteCalls, check.Equals, 0)
+	c.Assert(app1.GetServiceEnvs(), check.HasLen, 1)
+	c.Assert(app2.GetServiceEnvs(), check.HasLen, 0)
+}
+
+func (s *InstanceSuite) TestRotateCredentialsNotSupported(c *check.C) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer ts.Close()
+	srv := Service{Name: "mysql", Endpoint: map[string]string{"production": ts.URL}, Password: "s3cr3t"}
+	err := s.conn.Services().Insert(&srv)
+	c.Assert(err, check.IsNil)
+	si := ServiceInstance{Name: "my-mysql", ServiceName: "mysql", Apps: []string{"app1"}, TeamOwner: s.team.Name}
+	evt := createEvt(c)
+	err = si.RotateCredentials(RotateCredentialsArgs{Apps: []bind.App{provisiontest.NewFakeApp("app1", "python", 1)}, Event: evt})
+	c.Assert(err, check.ErrorMatches, `unable to rotate credentials of app "app1": credentials rotation is not supported by the service`)
+	c.Assert(errors.Cause(err), check.Equals, ErrRotationNotSupported)
+}
+
+func (s *InstanceSuite) TestRotateCredentialsAppNotBound(c *check.C) {
+	srv := Service{Name: "mysql", Endpoint: map[string]string{"production": "http://localhost:1"}, Password: "s3cr3t"}
+	err := s.conn.Services().Insert(&srv)
+	c.Assert(err, check.IsNil)
+	si := ServiceInstance{Name: "my-mysql", ServiceName: "mysql", TeamOwner: s.team.Name}
+	evt := createEvt(c)
+	err = si.RotateCredentials(RotateCredentialsArgs{Apps: []bind.App{provisiontest.NewFakeApp("app1", "python", 1)}, Event: evt})
+	c.Assert(err, check.Equals, ErrAppNotBound)
+}
@@ -48,6 +48,13 @@ type ServiceClient interface {
 	Proxy(path string, evt *event.Event, requestID string, w http.ResponseWriter, r *http.Request) error
 }
 
+// CredentialsRotator is implemented by service clients able to issue new
+// credentials for a bound app while keeping the current ones valid.
+type CredentialsRotator interface {
+	RotateCredentials(instance *ServiceInstance, app bind.App, evt *event.Event, requestID string) (map[string]string, error)
+	RevokePreviousCredentials(instance *ServiceInstance, app bind.App, evt *event.Event, requestID string) error
+}
+
 var (
 	ErrServiceAlreadyExists = errors.New("Service already exists.")
 	ErrServiceNotFound      = errors.New("Service not found.")
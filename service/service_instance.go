@@ -53,6 +53,10 @@ type ServiceInstance struct {
 
 	// BrokerData stores data used by Instances provisioned by Brokers
 	BrokerData *BrokerInstanceData `json:"broker_data,omitempty" bson:"broker_data"`
+
+	// BindOperations tracks the asynchronous bind and unbind operations
+	// started in the broker, one for each binding.
+	BindOperations []BrokerOperation `json:"-" bson:"bind_operations,omitempty"`
 }
 
 type BrokerInstanceData struct {
@@ -64,6 +68,10 @@ type BrokerInstanceData struct {
 	SpaceID          string
 	LastOperationKey string
 
+	// LastOperation tracks the last asynchronous provision, update or
+	// deprovision operation started in the broker for this instance.
+	LastOperation *BrokerOperation `bson:",omitempty"`
+
 	Binds map[string]BrokerInstanceBind
 }
 
@@ -72,6 +80,11 @@ type BrokerInstanceBind struct {
 	UUID         string
 	OperationKey string
 	Parameters   map[string]interface{}
+
+	// PreviousUUIDs are the bindings replaced by credentials rotations, kept
+	// until the app is restarted with the new credentials. A retried rotation
+	// may leave more than one binding to be revoked.
+	PreviousUUIDs []string `bson:",omitempty"`
 }
 
 type Unit struct {
@@ -101,12 +114,42 @@ func DeleteInstance(si *ServiceInstance, evt *event.Event, requestID string) err
 	if err == nil {
 		endpoint.Destroy(si, evt, requestID)
 	}
-	conn, err := db.Conn()
-	if err != nil {
-		return err
+	if si.hasPendingOperation() {
+		// the instance will be removed once the broker finishes deprovisioning it
+		return nil
 	}
-	defer conn.Close()
-	return conn.ServiceInstances().Remove(bson.M{"name": si.Name, "service_name": si.ServiceName})
+	return removeInstance(si)
+}
+
+// DeleteInstanceReport describes the effects of removing a service instance.
+type DeleteInstanceReport struct {
+	Service    string   `json:"service"`
+	Instance   string   `json:"instance"`
+	UnbindApps []string `json:"unbindApps"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// DeleteInstanceDryRun reports what DeleteInstance would do with the instance
+// without changing it. When unbindAll is true, the apps bound to the instance
+// are reported as the ones to be unbound before the removal, otherwise bound
+// apps cause the report to include the error the removal would fail with.
+func DeleteInstanceDryRun(si *ServiceInstance, unbindAll bool) (*DeleteInstanceReport, error) {
+	if _, err := Get(si.ServiceName); err != nil {
+		return nil, err
+	}
+	report := DeleteInstanceReport{
+		Service:    si.ServiceName,
+		Instance:   si.Name,
+		UnbindApps: []string{},
+	}
+	if len(si.Apps) > 0 {
+		if unbindAll {
+			report.UnbindApps = append(report.UnbindApps, si.Apps...)
+		} else {
+			report.Error = ErrServiceInstanceBound.Error()
+		}
+	}
+	return &report, nil
 }
 
 func (si *ServiceInstance) GetIdentifier() string {
@@ -183,6 +226,8 @@ func (si *ServiceInstance) Update(service Service, updateData ServiceInstance, e
 	if err != nil {
 		return err
 	}
+	updateData.Name = si.Name
+	updateData.ServiceName = si.ServiceName
 	conn, err := db.Conn()
 	if err != nil {
 		return err
@@ -194,9 +239,14 @@ func (si *ServiceInstance) Update(service Service, updateData ServiceInstance, e
 	} else {
 		updateData.Tags = tags
 	}
-	actions := []*action.Action{&updateServiceInstance, &notifyUpdateServiceInstance}
+	actions := []*action.Action{&reserveUpdatedServiceInstanceQuotas, &updateServiceInstance, &notifyUpdateServiceInstance}
 	pipeline := action.NewPipeline(actions...)
-	return pipeline.Execute(service, *si, updateData, evt, requestID)
+	err = pipeline.Execute(service, *si, updateData, evt, requestID)
+	if err != nil {
+		return err
+	}
+	releaseRemovedInstanceQuotas(*si, &updateData)
+	return nil
 }
 
 func (si *ServiceInstance) updateData(update bson.M) error {
@@ -454,7 +504,7 @@ func CreateServiceInstance(instance ServiceInstance, service *Service, evt *even
 	instance.ServiceName = service.Name
 	instance.Teams = []string{instance.TeamOwner}
 	instance.Tags = processTags(instance.Tags)
-	actions := []*action.Action{&notifyCreateServiceInstance, &createServiceInstance}
+	actions := []*action.Action{&reserveServiceInstanceQuotas, &notifyCreateServiceInstance, &createServiceInstance}
 	pipeline := action.NewPipeline(actions...)
 	return pipeline.Execute(*service, &instance, evt, requestID)
 }
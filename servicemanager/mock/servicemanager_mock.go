@@ -82,6 +82,9 @@ func (m *MockService) ResetPlatformImage() {
 	m.PlatformImage.OnDeleteImages = nil
 	m.PlatformImage.OnListImages = nil
 	m.PlatformImage.OnListImagesOrDefault = nil
+	m.PlatformImage.OnSetVersion = nil
+	m.PlatformImage.OnFindVersion = nil
+	m.PlatformImage.OnListVersions = nil
 }
 
 func (m *MockService) ResetTeam() {
@@ -113,6 +116,7 @@ func (m *MockService) ResetCluster() {
 	m.Cluster.OnFindByName = nil
 	m.Cluster.OnFindByProvisioner = nil
 	m.Cluster.OnFindByPool = nil
+	m.Cluster.OnFindAllByPool = nil
 	m.Cluster.OnDelete = nil
 }
 
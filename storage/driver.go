@@ -16,6 +16,7 @@ import (
 	"github.com/tsuru/tsuru/types/event"
 	"github.com/tsuru/tsuru/types/provision"
 	"github.com/tsuru/tsuru/types/quota"
+	"github.com/tsuru/tsuru/types/ratelimit"
 	"github.com/tsuru/tsuru/types/service"
 )
 
@@ -32,6 +33,7 @@ type DbDriver struct {
 	ServiceBrokerStorage             service.ServiceBrokerStorage
 	ServiceBrokerCatalogCacheStorage cache.CacheStorage
 	PlatformImageStorage             image.PlatformImageStorage
+	RateLimitStorage                 ratelimit.RateLimitStorage
 }
 
 var (
@@ -28,6 +28,8 @@ type cluster struct {
 	CustomData  map[string]string `bson:",omitempty"`
 	CreateData  map[string]string `bson:",omitempty"`
 	Default     bool
+	Placement   provision.PlacementPolicy `bson:",omitempty"`
+	Priority    int                       `bson:",omitempty"`
 }
 
 func clustersCollection(conn *db.Storage) *dbStorage.Collection {
@@ -41,15 +43,20 @@ func (s *clusterStorage) Upsert(c provision.Cluster) error {
 	}
 	defer conn.Close()
 	coll := clustersCollection(conn)
-	updates := bson.M{}
 	if len(c.Pools) > 0 {
-		updates["$pullAll"] = bson.M{"pools": c.Pools}
+		// Pools are only shared among clusters with a placement policy,
+		// other clusters lose the pools assigned to this one.
+		query := bson.M{"provisioner": c.Provisioner}
+		if c.Placement != "" {
+			query["placement"] = bson.M{"$exists": false}
+		}
+		_, err = coll.UpdateAll(query, bson.M{"$pullAll": bson.M{"pools": c.Pools}})
+		if err != nil {
+			return errors.WithStack(err)
+		}
 	}
 	if c.Default {
-		updates["$set"] = bson.M{"default": false}
-	}
-	if len(updates) > 0 {
-		_, err = coll.UpdateAll(bson.M{"provisioner": c.Provisioner}, updates)
+		_, err = coll.UpdateAll(bson.M{"provisioner": c.Provisioner}, bson.M{"$set": bson.M{"default": false}})
 		if err != nil {
 			return errors.WithStack(err)
 		}
@@ -93,7 +100,7 @@ func (s *clusterStorage) FindByPool(provisioner, pool string) (*provision.Cluste
 	coll := clustersCollection(conn)
 	var c cluster
 	if pool != "" {
-		err = coll.Find(bson.M{"provisioner": provisioner, "pools": pool}).One(&c)
+		err = coll.Find(bson.M{"provisioner": provisioner, "pools": pool}).Sort("-priority", "_id").One(&c)
 	}
 	if pool == "" || err == mgo.ErrNotFound {
 		err = coll.Find(bson.M{"provisioner": provisioner, "default": true}).One(&c)
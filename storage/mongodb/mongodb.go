@@ -22,6 +22,7 @@ func init() {
 		ClusterStorage:                   &clusterStorage{},
 		ServiceBrokerStorage:             &serviceBrokerStorage{},
 		ServiceBrokerCatalogCacheStorage: serviceBrokerCatalogCacheStorage(),
+		RateLimitStorage:                 &rateLimitStorage{},
 	}
 	storage.RegisterDbDriver("mongodb", mongodbDriver)
 }
@@ -5,6 +5,8 @@
 package mongodb
 
 import (
+	"time"
+
 	"github.com/globalsign/mgo"
 	"github.com/globalsign/mgo/bson"
 	"github.com/tsuru/tsuru/db"
@@ -23,6 +25,23 @@ func platformImageCollection(conn *db.Storage) *dbStorage.Collection {
 	return c
 }
 
+type platformVersion struct {
+	Platform  string
+	Version   string
+	Image     string
+	Runtime   string
+	Changelog string
+	BuildDate time.Time
+	Status    image.PlatformVersionStatus
+}
+
+func platformVersionCollection(conn *db.Storage) *dbStorage.Collection {
+	versionIndex := mgo.Index{Key: []string{"platform", "version"}, Unique: true}
+	c := conn.Collection("platform_versions")
+	c.EnsureIndex(versionIndex)
+	return c
+}
+
 func (s *PlatformImageStorage) Upsert(name string) (*image.PlatformImage, error) {
 	conn, err := db.Conn()
 	if err != nil {
@@ -75,9 +94,62 @@ func (s *PlatformImageStorage) Delete(name string) error {
 		return err
 	}
 	defer conn.Close()
+	_, err = platformVersionCollection(conn).RemoveAll(bson.M{"platform": name})
+	if err != nil {
+		return err
+	}
 	err = platformImageCollection(conn).Remove(bson.M{"name": name})
 	if err == mgo.ErrNotFound {
 		return image.ErrPlatformImageNotFound
 	}
 	return err
 }
+
+func (s *PlatformImageStorage) UpsertVersion(v image.PlatformVersion) error {
+	conn, err := db.Conn()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = platformVersionCollection(conn).Upsert(
+		bson.M{"platform": v.Platform, "version": v.Version},
+		platformVersion(v),
+	)
+	return err
+}
+
+func (s *PlatformImageStorage) FindVersion(name, version string) (*image.PlatformVersion, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var v platformVersion
+	err = platformVersionCollection(conn).Find(bson.M{"platform": name, "version": version}).One(&v)
+	if err != nil {
+		if err == mgo.ErrNotFound {
+			err = image.ErrPlatformVersionNotFound
+		}
+		return nil, err
+	}
+	result := image.PlatformVersion(v)
+	return &result, nil
+}
+
+func (s *PlatformImageStorage) FindVersions(name string) ([]image.PlatformVersion, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	var versions []platformVersion
+	err = platformVersionCollection(conn).Find(bson.M{"platform": name}).Sort("builddate").All(&versions)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]image.PlatformVersion, len(versions))
+	for i, v := range versions {
+		result[i] = image.PlatformVersion(v)
+	}
+	return result, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package mongodb
+
+import (
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/tsuru/db"
+	dbStorage "github.com/tsuru/tsuru/db/storage"
+	"github.com/tsuru/tsuru/types/ratelimit"
+)
+
+const rateLimitMaxRetries = 5
+
+type rateLimitStorage struct{}
+
+var _ ratelimit.RateLimitStorage = &rateLimitStorage{}
+
+type rateLimitBucket struct {
+	Key       string `bson:"_id"`
+	Tokens    float64
+	UpdatedAt time.Time
+	ExpireAt  time.Time
+	Version   int64
+}
+
+func rateLimitCollection(conn *db.Storage) *dbStorage.Collection {
+	c := conn.Collection("rate_limit_buckets")
+	c.EnsureIndex(mgo.Index{Key: []string{"expireat"}, ExpireAfter: time.Second})
+	return c
+}
+
+func (s *rateLimitStorage) Check(key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, 0, err
+	}
+	defer conn.Close()
+	var dbBucket rateLimitBucket
+	err = rateLimitCollection(conn).FindId(key).One(&dbBucket)
+	if err == mgo.ErrNotFound {
+		return true, 0, nil
+	}
+	if err != nil {
+		return false, 0, err
+	}
+	bucket := ratelimit.Bucket{Key: key, Tokens: dbBucket.Tokens, UpdatedAt: dbBucket.UpdatedAt}
+	allowed, retryAfter := bucket.Check(limit, time.Now().UTC())
+	return allowed, retryAfter, nil
+}
+
+// Take updates the bucket only if its version wasn't changed since it was
+// read, retrying otherwise, so concurrent API instances share the same
+// bucket.
+func (s *rateLimitStorage) Take(key string, limit ratelimit.Limit) (bool, time.Duration, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return false, 0, err
+	}
+	defer conn.Close()
+	coll := rateLimitCollection(conn)
+	for i := 0; i < rateLimitMaxRetries; i++ {
+		var dbBucket rateLimitBucket
+		err = coll.FindId(key).One(&dbBucket)
+		if err != nil && err != mgo.ErrNotFound {
+			return false, 0, err
+		}
+		exists := err == nil
+		bucket := ratelimit.Bucket{Key: key, Tokens: dbBucket.Tokens, UpdatedAt: dbBucket.UpdatedAt}
+		now := time.Now().UTC()
+		allowed, retryAfter := bucket.Take(limit, now)
+		newBucket := rateLimitBucket{
+			Key:       key,
+			Tokens:    bucket.Tokens,
+			UpdatedAt: bucket.UpdatedAt,
+			ExpireAt:  now.Add(limit.FillTime()),
+			Version:   dbBucket.Version + 1,
+		}
+		if exists {
+			err = coll.Update(bson.M{"_id": key, "version": dbBucket.Version}, newBucket)
+			if err == mgo.ErrNotFound {
+				continue
+			}
+		} else {
+			err = coll.Insert(newBucket)
+			if mgo.IsDup(err) {
+				continue
+			}
+		}
+		if err != nil {
+			return false, 0, err
+		}
+		return allowed, retryAfter, nil
+	}
+	return false, 0, ratelimit.ErrBucketConflict
+}
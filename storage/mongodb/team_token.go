@@ -12,6 +12,7 @@ import (
 	"github.com/tsuru/tsuru/db"
 	dbStorage "github.com/tsuru/tsuru/db/storage"
 	"github.com/tsuru/tsuru/types/auth"
+	"github.com/tsuru/tsuru/types/ratelimit"
 )
 
 type teamTokenStorage struct{}
@@ -26,6 +27,7 @@ type teamToken struct {
 	CreatorEmail string    `bson:"creator_email"`
 	Team         string
 	Roles        []auth.RoleInstance `bson:",omitempty"`
+	RateLimit    ratelimit.Limit     `bson:"rate_limit,omitempty"`
 }
 
 var _ auth.TeamTokenStorage = &teamTokenStorage{}
@@ -56,6 +56,30 @@ func (s *ClusterSuite) TestUpsertExistingCluster(c *check.C) {
 	c.Assert(cl.Default, check.Equals, true)
 }
 
+func (s *ClusterSuite) TestUpsertClusterSharedPools(c *check.C) {
+	err := s.ClusterStorage.Upsert(provision.Cluster{Name: "c1", Provisioner: "kubernetes", Pools: []string{"p1", "p2"}, Placement: provision.PlacementReplicate})
+	c.Assert(err, check.IsNil)
+	err = s.ClusterStorage.Upsert(provision.Cluster{Name: "c2", Provisioner: "kubernetes", Pools: []string{"p1"}, Placement: provision.PlacementReplicate, Priority: 10})
+	c.Assert(err, check.IsNil)
+	err = s.ClusterStorage.Upsert(provision.Cluster{Name: "c3", Provisioner: "kubernetes", Pools: []string{"p2"}})
+	c.Assert(err, check.IsNil)
+
+	cluster, err := s.ClusterStorage.FindByName("c1")
+	c.Assert(err, check.IsNil)
+	c.Assert(cluster.Pools, check.DeepEquals, []string{"p1"})
+	c.Assert(cluster.Placement, check.Equals, provision.PlacementReplicate)
+	cluster, err = s.ClusterStorage.FindByName("c2")
+	c.Assert(err, check.IsNil)
+	c.Assert(cluster.Pools, check.DeepEquals, []string{"p1"})
+	c.Assert(cluster.Priority, check.Equals, 10)
+	cluster, err = s.ClusterStorage.FindByPool("kubernetes", "p1")
+	c.Assert(err, check.IsNil)
+	c.Assert(cluster.Name, check.Equals, "c2")
+	cluster, err = s.ClusterStorage.FindByPool("kubernetes", "p2")
+	c.Assert(err, check.IsNil)
+	c.Assert(cluster.Name, check.Equals, "c3")
+}
+
 func (s *ClusterSuite) TestFindAllClusters(c *check.C) {
 	err := s.ClusterStorage.Upsert(provision.Cluster{Name: "cluster-a"})
 	c.Assert(err, check.IsNil)
@@ -5,6 +5,8 @@
 package storagetest
 
 import (
+	"time"
+
 	"github.com/tsuru/tsuru/types/app/image"
 	"gopkg.in/check.v1"
 )
@@ -60,3 +62,54 @@ func (s *PlatformImageSuite) TestDeletePlatformNotFound(c *check.C) {
 	err := s.PlatformImageStorage.Delete("myplatform")
 	c.Assert(err, check.Equals, image.ErrPlatformImageNotFound)
 }
+
+func (s *PlatformImageSuite) TestPlatformImageUpsertVersion(c *check.C) {
+	v := image.PlatformVersion{
+		Platform:  "python",
+		Version:   "v1",
+		Image:     "tsuru/python:v1",
+		Runtime:   "3.6",
+		BuildDate: time.Date(2018, 5, 1, 10, 0, 0, 0, time.UTC),
+		Status:    image.PlatformVersionActive,
+	}
+	err := s.PlatformImageStorage.UpsertVersion(v)
+	c.Assert(err, check.IsNil)
+	v.Status = image.PlatformVersionEOL
+	err = s.PlatformImageStorage.UpsertVersion(v)
+	c.Assert(err, check.IsNil)
+	stored, err := s.PlatformImageStorage.FindVersion("python", "v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(*stored, check.DeepEquals, v)
+}
+
+func (s *PlatformImageSuite) TestPlatformImageFindVersionNotFound(c *check.C) {
+	v, err := s.PlatformImageStorage.FindVersion("python", "v1")
+	c.Assert(err, check.Equals, image.ErrPlatformVersionNotFound)
+	c.Assert(v, check.IsNil)
+}
+
+func (s *PlatformImageSuite) TestPlatformImageFindVersions(c *check.C) {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	v1 := image.PlatformVersion{Platform: "python", Version: "v1", BuildDate: now.Add(-time.Hour)}
+	v2 := image.PlatformVersion{Platform: "python", Version: "v2", BuildDate: now}
+	other := image.PlatformVersion{Platform: "go", Version: "v1", BuildDate: now}
+	for _, v := range []image.PlatformVersion{v2, v1, other} {
+		err := s.PlatformImageStorage.UpsertVersion(v)
+		c.Assert(err, check.IsNil)
+	}
+	versions, err := s.PlatformImageStorage.FindVersions("python")
+	c.Assert(err, check.IsNil)
+	c.Assert(versions, check.DeepEquals, []image.PlatformVersion{v1, v2})
+}
+
+func (s *PlatformImageSuite) TestDeletePlatformRemovesVersions(c *check.C) {
+	_, err := s.PlatformImageStorage.Upsert("python")
+	c.Assert(err, check.IsNil)
+	err = s.PlatformImageStorage.UpsertVersion(image.PlatformVersion{Platform: "python", Version: "v1"})
+	c.Assert(err, check.IsNil)
+	err = s.PlatformImageStorage.Delete("python")
+	c.Assert(err, check.IsNil)
+	versions, err := s.PlatformImageStorage.FindVersions("python")
+	c.Assert(err, check.IsNil)
+	c.Assert(versions, check.HasLen, 0)
+}
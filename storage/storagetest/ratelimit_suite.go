@@ -0,0 +1,92 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package storagetest
+
+import (
+	"sync"
+	"time"
+
+	"github.com/tsuru/tsuru/types/ratelimit"
+	"gopkg.in/check.v1"
+)
+
+type RateLimitSuite struct {
+	SuiteHooks
+	RateLimitStorage ratelimit.RateLimitStorage
+}
+
+func (s *RateLimitSuite) TestTake(c *check.C) {
+	limit := ratelimit.Limit{RequestsPerMinute: 1, Burst: 2}
+	for i := 0; i < 2; i++ {
+		allowed, retryAfter, err := s.RateLimitStorage.Take("user:me@tsuru.io", limit)
+		c.Assert(err, check.IsNil)
+		c.Assert(allowed, check.Equals, true)
+		c.Assert(retryAfter, check.Equals, time.Duration(0))
+	}
+	allowed, retryAfter, err := s.RateLimitStorage.Take("user:me@tsuru.io", limit)
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+	c.Assert(retryAfter > 50*time.Second, check.Equals, true)
+	c.Assert(retryAfter <= time.Minute, check.Equals, true)
+	allowed, _, err = s.RateLimitStorage.Take("user:other@tsuru.io", limit)
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+}
+
+func (s *RateLimitSuite) TestCheck(c *check.C) {
+	limit := ratelimit.Limit{RequestsPerMinute: 1, Burst: 1}
+	allowed, _, err := s.RateLimitStorage.Check("token:check", limit)
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, _, err = s.RateLimitStorage.Check("token:check", limit)
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, _, err = s.RateLimitStorage.Take("token:check", limit)
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, retryAfter, err := s.RateLimitStorage.Check("token:check", limit)
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+	c.Assert(retryAfter > 50*time.Second, check.Equals, true)
+}
+
+func (s *RateLimitSuite) TestTakeRefill(c *check.C) {
+	limit := ratelimit.Limit{RequestsPerMinute: 600, Burst: 1}
+	allowed, _, err := s.RateLimitStorage.Take("token:mytoken", limit)
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+	allowed, retryAfter, err := s.RateLimitStorage.Take("token:mytoken", limit)
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, false)
+	time.Sleep(retryAfter + 10*time.Millisecond)
+	allowed, _, err = s.RateLimitStorage.Take("token:mytoken", limit)
+	c.Assert(err, check.IsNil)
+	c.Assert(allowed, check.Equals, true)
+}
+
+func (s *RateLimitSuite) TestTakeConcurrent(c *check.C) {
+	limit := ratelimit.Limit{RequestsPerMinute: 1, Burst: 5}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var allowedCount int
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			allowed, _, err := s.RateLimitStorage.Take("token:concurrent", limit)
+			if err == ratelimit.ErrBucketConflict {
+				return
+			}
+			c.Check(err, check.IsNil)
+			if allowed {
+				mu.Lock()
+				allowedCount++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	c.Assert(allowedCount <= 5, check.Equals, true)
+}
@@ -0,0 +1,227 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tracing records spans for the operations triggered by API requests.
+//
+// Spans are grouped in traces identified by an ID generated when the trace
+// starts. Components that only receive the ID of the request, like service
+// endpoints, may find the trace of the request with TraceForRequest. A span
+// started in a trace is a child of the innermost span of the trace that is
+// still running.
+package tracing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/log"
+)
+
+// Exporter receives spans once they are finished.
+type Exporter interface {
+	Export(span *Span)
+}
+
+// Span is a timed operation in a trace.
+type Span struct {
+	TraceID   string
+	ID        string
+	ParentID  string
+	Operation string
+	Tags      map[string]string
+	Start     time.Time
+	End       time.Time
+	Error     string
+
+	mu        sync.Mutex
+	exporter  Exporter
+	finished  bool
+	requestID string
+}
+
+// requestTrace is the trace started by requests with a given ID. As request
+// IDs are sent by clients, more than one running trace may share the same
+// request ID, in which case traceID is empty.
+type requestTrace struct {
+	traceID string
+	count   int
+}
+
+type tracer struct {
+	sync.Mutex
+	exporter Exporter
+	active   map[string][]*Span
+	requests map[string]*requestTrace
+}
+
+var globalTracer = tracer{active: map[string][]*Span{}, requests: map[string]*requestTrace{}}
+
+// Initialize sets the exporter according to the tracing:exporter config. An
+// empty value disables tracing.
+func Initialize() error {
+	name, _ := config.GetString("tracing:exporter")
+	switch name {
+	case "":
+		SetExporter(nil)
+	case "log":
+		SetExporter(LogExporter{})
+	default:
+		return errors.Errorf("unknown tracing exporter: %q", name)
+	}
+	return nil
+}
+
+// SetExporter sets the exporter receiving finished spans. A nil exporter
+// disables tracing.
+func SetExporter(exporter Exporter) {
+	globalTracer.Lock()
+	defer globalTracer.Unlock()
+	globalTracer.exporter = exporter
+	globalTracer.active = map[string][]*Span{}
+	globalTracer.requests = map[string]*requestTrace{}
+}
+
+// StartTrace starts the root span of a new trace for operation. The trace is
+// identified by a generated ID, available in the TraceID field of the span.
+// While the span is running, the trace may be found by the ID of the request
+// that started it, if not empty. It returns nil if tracing is disabled.
+func StartTrace(operation, requestID string) *Span {
+	globalTracer.Lock()
+	defer globalTracer.Unlock()
+	if globalTracer.exporter == nil {
+		return nil
+	}
+	span := globalTracer.startSpan(newID(16), operation)
+	if requestID != "" {
+		span.requestID = requestID
+		if req, ok := globalTracer.requests[requestID]; ok {
+			req.traceID = ""
+			req.count++
+		} else {
+			globalTracer.requests[requestID] = &requestTrace{traceID: span.TraceID, count: 1}
+		}
+	}
+	return span
+}
+
+// TraceForRequest returns the ID of the running trace started by the request
+// with the given ID. It returns an empty string if there's no such trace or if
+// more than one running trace was started by requests with this ID.
+func TraceForRequest(requestID string) string {
+	globalTracer.Lock()
+	defer globalTracer.Unlock()
+	if req, ok := globalTracer.requests[requestID]; ok {
+		return req.traceID
+	}
+	return ""
+}
+
+// StartSpan starts a span for operation in the trace identified by traceID.
+// It returns nil if tracing is disabled or traceID is empty; all Span methods
+// may be called on a nil span.
+func StartSpan(traceID, operation string) *Span {
+	if traceID == "" {
+		return nil
+	}
+	globalTracer.Lock()
+	defer globalTracer.Unlock()
+	if globalTracer.exporter == nil {
+		return nil
+	}
+	return globalTracer.startSpan(traceID, operation)
+}
+
+func (t *tracer) startSpan(traceID, operation string) *Span {
+	span := &Span{
+		TraceID:   traceID,
+		ID:        newID(8),
+		Operation: operation,
+		Tags:      map[string]string{},
+		Start:     time.Now().UTC(),
+		exporter:  t.exporter,
+	}
+	if active := t.active[traceID]; len(active) > 0 {
+		span.ParentID = active[len(active)-1].ID
+	}
+	t.active[traceID] = append(t.active[traceID], span)
+	return span
+}
+
+func newID(size int) string {
+	buf := make([]byte, size)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// SetTag sets a tag in the span.
+func (s *Span) SetTag(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Tags[key] = value
+}
+
+// Finish ends the span, recording err if not nil, and sends it to the
+// exporter. Calling Finish more than once has no effect.
+func (s *Span) Finish(err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.finished {
+		s.mu.Unlock()
+		return
+	}
+	s.finished = true
+	s.End = time.Now().UTC()
+	if err != nil {
+		s.Error = err.Error()
+	}
+	s.mu.Unlock()
+	globalTracer.Lock()
+	active := globalTracer.active[s.TraceID]
+	for i := range active {
+		if active[i] == s {
+			active = append(active[:i], active[i+1:]...)
+			break
+		}
+	}
+	if len(active) == 0 {
+		delete(globalTracer.active, s.TraceID)
+	} else {
+		globalTracer.active[s.TraceID] = active
+	}
+	if req, ok := globalTracer.requests[s.requestID]; ok && s.requestID != "" {
+		req.count--
+		if req.count == 0 {
+			delete(globalTracer.requests, s.requestID)
+		}
+	}
+	globalTracer.Unlock()
+	s.exporter.Export(s)
+}
+
+// Duration returns the time taken by a finished span.
+func (s *Span) Duration() time.Duration {
+	return s.End.Sub(s.Start)
+}
+
+func (s *Span) String() string {
+	return fmt.Sprintf("trace=%s span=%s parent=%s operation=%q duration=%s tags=%v error=%q",
+		s.TraceID, s.ID, s.ParentID, s.Operation, s.Duration(), s.Tags, s.Error)
+}
+
+// LogExporter writes finished spans to the tsuru debug log.
+type LogExporter struct{}
+
+func (LogExporter) Export(span *Span) {
+	log.Debugf("[tracing] %s", span)
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tracing
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/tsuru/config"
+	"gopkg.in/check.v1"
+)
+
+type S struct {
+	exporter *fakeExporter
+}
+
+var _ = check.Suite(&S{})
+
+func Test(t *testing.T) { check.TestingT(t) }
+
+type fakeExporter struct {
+	spans []*Span
+}
+
+func (e *fakeExporter) Export(span *Span) {
+	e.spans = append(e.spans, span)
+}
+
+func (s *S) SetUpTest(c *check.C) {
+	s.exporter = &fakeExporter{}
+	SetExporter(s.exporter)
+}
+
+func (s *S) TearDownTest(c *check.C) {
+	SetExporter(nil)
+}
+
+func (s *S) TestStartSpanDisabled(c *check.C) {
+	SetExporter(nil)
+	span := StartSpan("req1", "op")
+	c.Assert(span, check.IsNil)
+	span.SetTag("key", "value")
+	span.Finish(errors.New("ignored"))
+}
+
+func (s *S) TestStartSpanWithoutTraceID(c *check.C) {
+	c.Assert(StartSpan("", "op"), check.IsNil)
+}
+
+func (s *S) TestSpanParents(c *check.C) {
+	root := StartSpan("req1", "root")
+	child := StartSpan("req1", "child")
+	grandChild := StartSpan("req1", "grandchild")
+	grandChild.Finish(nil)
+	sibling := StartSpan("req1", "sibling")
+	other := StartSpan("req2", "other")
+	sibling.SetTag("app", "myapp")
+	sibling.Finish(errors.New("my error"))
+	child.Finish(nil)
+	child.Finish(nil)
+	root.Finish(nil)
+	other.Finish(nil)
+	c.Assert(root.ParentID, check.Equals, "")
+	c.Assert(child.ParentID, check.Equals, root.ID)
+	c.Assert(grandChild.ParentID, check.Equals, child.ID)
+	c.Assert(sibling.ParentID, check.Equals, child.ID)
+	c.Assert(sibling.Tags, check.DeepEquals, map[string]string{"app": "myapp"})
+	c.Assert(sibling.Error, check.Equals, "my error")
+	c.Assert(other.ParentID, check.Equals, "")
+	c.Assert(other.TraceID, check.Equals, "req2")
+	c.Assert(s.exporter.spans, check.DeepEquals, []*Span{grandChild, sibling, child, root, other})
+	c.Assert(globalTracer.active, check.HasLen, 0)
+}
+
+func (s *S) TestStartTrace(c *check.C) {
+	root := StartTrace("root", "req1")
+	c.Assert(root.TraceID, check.Not(check.Equals), "")
+	c.Assert(root.TraceID, check.Not(check.Equals), "req1")
+	c.Assert(TraceForRequest("req1"), check.Equals, root.TraceID)
+	child := StartSpan(TraceForRequest("req1"), "child")
+	child.Finish(nil)
+	root.Finish(nil)
+	c.Assert(child.TraceID, check.Equals, root.TraceID)
+	c.Assert(child.ParentID, check.Equals, root.ID)
+	c.Assert(TraceForRequest("req1"), check.Equals, "")
+	c.Assert(globalTracer.requests, check.HasLen, 0)
+}
+
+func (s *S) TestStartTraceWithoutRequestID(c *check.C) {
+	root1 := StartTrace("root", "")
+	root2 := StartTrace("root", "")
+	c.Assert(root1.TraceID, check.Not(check.Equals), root2.TraceID)
+	c.Assert(TraceForRequest(""), check.Equals, "")
+	root1.Finish(nil)
+	root2.Finish(nil)
+	c.Assert(globalTracer.requests, check.HasLen, 0)
+}
+
+func (s *S) TestStartTraceSameRequestID(c *check.C) {
+	root1 := StartTrace("root", "req1")
+	root2 := StartTrace("root", "req1")
+	c.Assert(root1.TraceID, check.Not(check.Equals), root2.TraceID)
+	c.Assert(TraceForRequest("req1"), check.Equals, "")
+	root1.Finish(nil)
+	c.Assert(TraceForRequest("req1"), check.Equals, "")
+	root2.Finish(nil)
+	c.Assert(globalTracer.requests, check.HasLen, 0)
+}
+
+func (s *S) TestStartTraceDisabled(c *check.C) {
+	SetExporter(nil)
+	c.Assert(StartTrace("root", "req1"), check.IsNil)
+	c.Assert(TraceForRequest("req1"), check.Equals, "")
+}
+
+func (s *S) TestInitialize(c *check.C) {
+	defer config.Unset("tracing:exporter")
+	config.Set("tracing:exporter", "log")
+	err := Initialize()
+	c.Assert(err, check.IsNil)
+	c.Assert(globalTracer.exporter, check.Equals, LogExporter{})
+	config.Set("tracing:exporter", "invalid")
+	err = Initialize()
+	c.Assert(err, check.ErrorMatches, `unknown tracing exporter: "invalid"`)
+	config.Unset("tracing:exporter")
+	err = Initialize()
+	c.Assert(err, check.IsNil)
+	c.Assert(globalTracer.exporter, check.IsNil)
+}
@@ -0,0 +1,59 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package tracingtest provides an in-memory exporter for testing code that
+// records spans.
+package tracingtest
+
+import (
+	"sync"
+
+	"github.com/tsuru/tsuru/tracing"
+)
+
+// Exporter keeps finished spans in memory.
+type Exporter struct {
+	mu    sync.Mutex
+	spans []*tracing.Span
+}
+
+// New creates an in-memory exporter and sets it as the tracing exporter.
+func New() *Exporter {
+	e := &Exporter{}
+	tracing.SetExporter(e)
+	return e
+}
+
+func (e *Exporter) Export(span *tracing.Span) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, span)
+}
+
+// Spans returns the finished spans, in the order they finished.
+func (e *Exporter) Spans() []*tracing.Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]*tracing.Span(nil), e.spans...)
+}
+
+// SpansByOperation returns the finished spans for the given operation.
+func (e *Exporter) SpansByOperation(operation string) []*tracing.Span {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var spans []*tracing.Span
+	for _, span := range e.spans {
+		if span.Operation == operation {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+// Reset removes all recorded spans.
+func (e *Exporter) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = nil
+}
@@ -6,6 +6,7 @@ package image
 
 import (
 	"errors"
+	"time"
 )
 
 type PlatformImage struct {
@@ -14,6 +15,40 @@ type PlatformImage struct {
 	Count  int
 }
 
+type PlatformVersionStatus string
+
+const (
+	PlatformVersionActive     PlatformVersionStatus = "active"
+	PlatformVersionDeprecated PlatformVersionStatus = "deprecated"
+	PlatformVersionEOL        PlatformVersionStatus = "eol"
+)
+
+// PlatformVersion holds the metadata of a single platform image, identified
+// by its tag (e.g. "v3").
+type PlatformVersion struct {
+	Platform  string
+	Version   string
+	Image     string
+	Runtime   string
+	Changelog string
+	BuildDate time.Time
+	Status    PlatformVersionStatus
+}
+
+// IsEOL returns whether the version has reached its end-of-life, blocking
+// builds on top of it and rollbacks to it.
+func (v *PlatformVersion) IsEOL() bool {
+	return v.Status == PlatformVersionEOL
+}
+
+func (s PlatformVersionStatus) Valid() bool {
+	switch s {
+	case PlatformVersionActive, PlatformVersionDeprecated, PlatformVersionEOL:
+		return true
+	}
+	return false
+}
+
 type PlatformImageService interface {
 	NewImage(string) (string, error)
 	CurrentImage(string) (string, error)
@@ -22,6 +57,9 @@ type PlatformImageService interface {
 	ListImages(string) ([]string, error)
 	ListImagesOrDefault(string) ([]string, error)
 	FindImage(string, string) (string, error)
+	SetVersion(PlatformVersion) error
+	FindVersion(string, string) (*PlatformVersion, error)
+	ListVersions(string) ([]PlatformVersion, error)
 }
 
 type PlatformImageStorage interface {
@@ -29,8 +67,14 @@ type PlatformImageStorage interface {
 	FindByName(string) (*PlatformImage, error)
 	Append(string, string) error
 	Delete(string) error
+	UpsertVersion(PlatformVersion) error
+	FindVersion(string, string) (*PlatformVersion, error)
+	FindVersions(string) ([]PlatformVersion, error)
 }
 
 var (
-	ErrPlatformImageNotFound = errors.New("Platform image not found")
+	ErrPlatformImageNotFound   = errors.New("Platform image not found")
+	ErrPlatformVersionNotFound = errors.New("Platform version not found")
+	ErrInvalidPlatformVersion  = errors.New("Invalid platform version status, must be one of: active, deprecated, eol")
+	ErrPlatformVersionEOL      = errors.New("Platform version has reached its end-of-life")
 )
@@ -11,10 +11,13 @@ var (
 
 // MockPlatformStorage implements PlatformStorage interface
 type MockPlatformImageStorage struct {
-	OnUpsert     func(string) (*PlatformImage, error)
-	OnFindByName func(string) (*PlatformImage, error)
-	OnAppend     func(string, string) error
-	OnDelete     func(string) error
+	OnUpsert        func(string) (*PlatformImage, error)
+	OnFindByName    func(string) (*PlatformImage, error)
+	OnAppend        func(string, string) error
+	OnDelete        func(string) error
+	OnUpsertVersion func(PlatformVersion) error
+	OnFindVersion   func(string, string) (*PlatformVersion, error)
+	OnFindVersions  func(string) ([]PlatformVersion, error)
 }
 
 func (m *MockPlatformImageStorage) Upsert(name string) (*PlatformImage, error) {
@@ -33,6 +36,18 @@ func (m *MockPlatformImageStorage) Delete(name string) error {
 	return m.OnDelete(name)
 }
 
+func (m *MockPlatformImageStorage) UpsertVersion(v PlatformVersion) error {
+	return m.OnUpsertVersion(v)
+}
+
+func (m *MockPlatformImageStorage) FindVersion(name, version string) (*PlatformVersion, error) {
+	return m.OnFindVersion(name, version)
+}
+
+func (m *MockPlatformImageStorage) FindVersions(name string) ([]PlatformVersion, error) {
+	return m.OnFindVersions(name)
+}
+
 // MockPlatformImageService implements PlatformImageService interface
 type MockPlatformImageService struct {
 	OnNewImage            func(string) (string, error)
@@ -42,6 +57,9 @@ type MockPlatformImageService struct {
 	OnListImages          func(string) ([]string, error)
 	OnListImagesOrDefault func(string) ([]string, error)
 	OnFindImage           func(string, string) (string, error)
+	OnSetVersion          func(PlatformVersion) error
+	OnFindVersion         func(string, string) (*PlatformVersion, error)
+	OnListVersions        func(string) ([]PlatformVersion, error)
 }
 
 func (m *MockPlatformImageService) NewImage(platformName string) (string, error) {
@@ -92,3 +110,24 @@ func (m *MockPlatformImageService) FindImage(platformName, image string) (string
 	}
 	return m.OnFindImage(platformName, image)
 }
+
+func (m *MockPlatformImageService) SetVersion(v PlatformVersion) error {
+	if m.OnSetVersion == nil {
+		return nil
+	}
+	return m.OnSetVersion(v)
+}
+
+func (m *MockPlatformImageService) FindVersion(platformName, version string) (*PlatformVersion, error) {
+	if m.OnFindVersion == nil {
+		return nil, ErrPlatformVersionNotFound
+	}
+	return m.OnFindVersion(platformName, version)
+}
+
+func (m *MockPlatformImageService) ListVersions(platformName string) ([]PlatformVersion, error) {
+	if m.OnListVersions == nil {
+		return nil, nil
+	}
+	return m.OnListVersions(platformName)
+}
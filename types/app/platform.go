@@ -22,6 +22,13 @@ type PlatformOptions struct {
 	Output    io.Writer
 	Data      []byte
 	Ctx       context.Context
+	// BuildArgs and Target are passed to the Dockerfile build. NoCache
+	// disables reusing layers from previous platform images. The kubernetes
+	// builder applies BuildArgs and Target to the Dockerfile and doesn't
+	// support NoCache.
+	BuildArgs map[string]string
+	Target    string
+	NoCache   bool
 }
 
 type PlatformService interface {
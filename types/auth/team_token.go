@@ -7,13 +7,17 @@ package auth
 import (
 	"errors"
 	"time"
+
+	"github.com/tsuru/tsuru/types/ratelimit"
 )
 
 type TeamTokenCreateArgs struct {
-	TokenID     string `json:"token_id" form:"token_id"`
-	Description string `json:"description" form:"description"`
-	ExpiresIn   int    `json:"expires_in" form:"expires_in"`
-	Team        string `json:"team" form:"team"`
+	TokenID           string `json:"token_id" form:"token_id"`
+	Description       string `json:"description" form:"description"`
+	ExpiresIn         int    `json:"expires_in" form:"expires_in"`
+	Team              string `json:"team" form:"team"`
+	RequestsPerMinute int    `json:"requests_per_minute" form:"requests_per_minute"`
+	Burst             int    `json:"burst" form:"burst"`
 }
 
 type TeamTokenUpdateArgs struct {
@@ -21,6 +25,11 @@ type TeamTokenUpdateArgs struct {
 	Regenerate  bool   `json:"regenerate" form:"regenerate"`
 	Description string `json:"description" form:"description"`
 	ExpiresIn   int    `json:"expires_in" form:"expires_in"`
+	// RequestsPerMinute replaces the token rate limit when positive and
+	// removes it when negative. The limit can't exceed the configured per
+	// token limit.
+	RequestsPerMinute int `json:"requests_per_minute" form:"requests_per_minute"`
+	Burst             int `json:"burst" form:"burst"`
 }
 
 type TeamToken struct {
@@ -33,6 +42,9 @@ type TeamToken struct {
 	CreatorEmail string         `json:"creator_email"`
 	Team         string         `json:"team"`
 	Roles        []RoleInstance `json:"roles,omitempty"`
+	// RateLimit lowers the configured per token rate limit for requests
+	// using this token.
+	RateLimit ratelimit.Limit `json:"rate_limit"`
 }
 
 type TeamTokenStorage interface {
@@ -60,4 +72,6 @@ var (
 	ErrTeamTokenAlreadyExists = errors.New("team token already exists")
 	ErrTeamTokenNotFound      = errors.New("team token not found")
 	ErrTeamTokenExpired       = errors.New("team token expired")
+	ErrTeamTokenInvalidLimit  = errors.New("invalid rate limit, requests per minute and burst must not be negative")
+	ErrTeamTokenLimitTooHigh  = errors.New("invalid rate limit, requests per minute and burst must not exceed the configured token limit")
 )
@@ -4,7 +4,10 @@
 
 package auth
 
-import "github.com/tsuru/tsuru/permission"
+import (
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/types/ratelimit"
+)
 
 type Token interface {
 	GetValue() string
@@ -18,3 +21,9 @@ type Token interface {
 type NamedToken interface {
 	GetTokenName() string
 }
+
+// RateLimitedToken is implemented by tokens having their own request rate
+// limit.
+type RateLimitedToken interface {
+	GetRateLimit() ratelimit.Limit
+}
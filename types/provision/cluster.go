@@ -4,7 +4,33 @@
 
 package provision
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
+
+// PlacementPolicy defines how the units of apps are placed among the
+// clusters sharing a pool.
+type PlacementPolicy string
+
+const (
+	// PlacementReplicate splits the units of each process among all healthy
+	// clusters of the pool.
+	PlacementReplicate PlacementPolicy = "replicate"
+	// PlacementActivePassive keeps all units in the healthy cluster with the
+	// highest priority, failing over to the next one when it is unhealthy.
+	PlacementActivePassive PlacementPolicy = "active-passive"
+)
+
+// Valid returns whether p is a known placement policy. The empty policy is
+// valid and means the cluster owns its pools exclusively.
+func (p PlacementPolicy) Valid() bool {
+	switch p {
+	case "", PlacementReplicate, PlacementActivePassive:
+		return true
+	}
+	return false
+}
 
 // Cluster represents a cluster of nodes.
 type Cluster struct {
@@ -18,6 +44,33 @@ type Cluster struct {
 	CustomData  map[string]string `json:"custom_data"`
 	CreateData  map[string]string `json:"create_data"`
 	Default     bool              `json:"default"`
+	// Placement allows the pools of the cluster to be shared with other
+	// clusters using the same policy.
+	Placement PlacementPolicy `json:"placement,omitempty"`
+	// Priority orders the clusters sharing a pool, higher values first.
+	Priority int `json:"priority,omitempty"`
+}
+
+// ClusterStatus is the result of the last health check of a cluster.
+type ClusterStatus struct {
+	Name    string             `json:"name"`
+	Healthy bool               `json:"healthy"`
+	Error   string             `json:"error,omitempty"`
+	Version string             `json:"version,omitempty"`
+	Nodes   ClusterNodesStatus `json:"nodes"`
+	// Failures is the number of consecutive failed checks. The cluster is
+	// only considered unhealthy once it reaches the configured threshold.
+	Failures int `json:"failures,omitempty"`
+	// ClientCertExpiration is the expiration time of the client certificate
+	// used to connect to the cluster, if any.
+	ClientCertExpiration *time.Time `json:"clientcert_expiration,omitempty"`
+	LastCheck            time.Time  `json:"last_check"`
+}
+
+// ClusterNodesStatus summarizes the readiness of the nodes of a cluster.
+type ClusterNodesStatus struct {
+	Total int `json:"total"`
+	Ready int `json:"ready"`
 }
 
 type ClusterService interface {
@@ -28,6 +81,7 @@ type ClusterService interface {
 	FindByProvisioner(string) ([]Cluster, error)
 	FindByPool(string, string) (*Cluster, error)
 	FindByPools(string, []string) (map[string]Cluster, error)
+	FindAllByPool(string, string) ([]Cluster, error)
 	Delete(Cluster) error
 }
 
@@ -67,6 +67,7 @@ type MockClusterService struct {
 	OnFindByProvisioner func(string) ([]Cluster, error)
 	OnFindByPool        func(string, string) (*Cluster, error)
 	OnFindByPools       func(string, []string) (map[string]Cluster, error)
+	OnFindAllByPool     func(string, string) ([]Cluster, error)
 	OnDelete            func(Cluster) error
 }
 
@@ -119,6 +120,13 @@ func (m *MockClusterService) FindByPools(provisioner string, pool []string) (map
 	return m.OnFindByPools(provisioner, pool)
 }
 
+func (m *MockClusterService) FindAllByPool(provisioner, pool string) ([]Cluster, error) {
+	if m.OnFindAllByPool == nil {
+		return nil, nil
+	}
+	return m.OnFindAllByPool(provisioner, pool)
+}
+
 func (m *MockClusterService) Delete(c Cluster) error {
 	if m.OnDelete == nil {
 		return nil
@@ -0,0 +1,132 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var ErrBucketConflict = errors.New("unable to update rate limit bucket due to concurrent updates")
+
+// Limit describes a token bucket that is refilled at RequestsPerMinute and
+// holds up to Burst tokens. When Burst is not set the bucket holds one minute
+// worth of requests.
+type Limit struct {
+	RequestsPerMinute int `json:"requests_per_minute"`
+	Burst             int `json:"burst"`
+}
+
+func (l Limit) Enabled() bool {
+	return l.RequestsPerMinute > 0
+}
+
+// Min returns the stricter of l and other. A limit that is not enabled does
+// not restrict anything, so the other one is returned.
+func (l Limit) Min(other Limit) Limit {
+	if !l.Enabled() {
+		return other
+	}
+	if !other.Enabled() {
+		return l
+	}
+	result := Limit{
+		RequestsPerMinute: l.RequestsPerMinute,
+		Burst:             int(l.capacity()),
+	}
+	if other.RequestsPerMinute < result.RequestsPerMinute {
+		result.RequestsPerMinute = other.RequestsPerMinute
+	}
+	if capacity := int(other.capacity()); capacity < result.Burst {
+		result.Burst = capacity
+	}
+	return result
+}
+
+// Exceeds reports whether l allows more requests per minute or a larger burst
+// than other. Every limit is within a limit that is not enabled.
+func (l Limit) Exceeds(other Limit) bool {
+	if !other.Enabled() {
+		return false
+	}
+	if !l.Enabled() {
+		return true
+	}
+	return l.RequestsPerMinute > other.RequestsPerMinute || l.capacity() > other.capacity()
+}
+
+func (l Limit) capacity() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return float64(l.RequestsPerMinute)
+}
+
+func (l Limit) rate() float64 {
+	return float64(l.RequestsPerMinute) / 60
+}
+
+// FillTime returns how long an empty bucket takes to be full again.
+func (l Limit) FillTime() time.Duration {
+	return time.Duration(l.capacity() / l.rate() * float64(time.Second))
+}
+
+type Bucket struct {
+	Key       string
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+func (b *Bucket) refill(limit Limit, now time.Time) {
+	capacity := limit.capacity()
+	if b.UpdatedAt.IsZero() {
+		b.Tokens = capacity
+		b.UpdatedAt = now
+	} else if now.After(b.UpdatedAt) {
+		b.Tokens += now.Sub(b.UpdatedAt).Seconds() * limit.rate()
+		if b.Tokens > capacity {
+			b.Tokens = capacity
+		}
+		b.UpdatedAt = now
+	}
+}
+
+func (b *Bucket) retryAfter(limit Limit) time.Duration {
+	wait := (1 - b.Tokens) / limit.rate()
+	return time.Duration(wait * float64(time.Second))
+}
+
+// Check reports whether a token could be taken from the bucket at now,
+// without changing it. When the bucket is empty it also returns the time
+// until the next token is available.
+func (b Bucket) Check(limit Limit, now time.Time) (bool, time.Duration) {
+	b.refill(limit, now)
+	if b.Tokens >= 1 {
+		return true, 0
+	}
+	return false, b.retryAfter(limit)
+}
+
+// Take refills the bucket for the time elapsed since its last update and
+// removes one token from it. When the bucket is empty it returns false and the
+// time until the next token is available.
+func (b *Bucket) Take(limit Limit, now time.Time) (bool, time.Duration) {
+	b.refill(limit, now)
+	if b.Tokens >= 1 {
+		b.Tokens--
+		return true, 0
+	}
+	return false, b.retryAfter(limit)
+}
+
+type RateLimitStorage interface {
+	// Check reports whether a token could be taken from the bucket
+	// identified by key, without taking it. Missing buckets are full.
+	Check(key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+	// Take atomically takes a token from the bucket identified by key,
+	// creating a full bucket if it doesn't exist.
+	Take(key string, limit Limit) (allowed bool, retryAfter time.Duration, err error)
+}
@@ -0,0 +1,253 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package volume
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/pool"
+	"github.com/tsuru/tsuru/validation"
+)
+
+const snapshotRetentionKey = "snapshot-retention"
+
+var (
+	ErrVolumeSnapshotNotFound           = errors.New("volume snapshot not found")
+	ErrVolumeSnapshotAlreadyExists      = errors.New("volume snapshot already exists")
+	ErrVolumeSnapshotNotSupported       = errors.New("provisioner does not support volume snapshots")
+	ErrVolumeSnapshotDriverNotSupported = errors.New("volume driver does not support snapshots")
+	ErrVolumeRestoreWithBinds           = errors.New("cannot restore volume with existing binds, unbind it first")
+)
+
+type VolumeSnapshotID struct {
+	Volume string
+	Name   string
+}
+
+// VolumeSnapshot is a point-in-time copy of a volume. EventID points to the
+// event that created the snapshot.
+type VolumeSnapshot struct {
+	ID        VolumeSnapshotID `bson:"_id"`
+	Size      int64
+	EventID   string
+	CreatedAt time.Time
+}
+
+// SnapshotRetention returns the maximum number of snapshots kept for each
+// volume using the plan, as set by the snapshot-retention plan option. Zero
+// means snapshots are never automatically removed.
+func (p *VolumePlan) SnapshotRetention() int {
+	switch v := p.Opts[snapshotRetentionKey].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		retention, _ := strconv.Atoi(v)
+		return retention
+	}
+	return 0
+}
+
+func (v *Volume) snapshotProvisioner() (provision.SnapshotVolumeProvisioner, error) {
+	p, err := pool.GetPoolByName(v.Pool)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	prov, err := p.GetProvisioner()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	snapProv, ok := prov.(provision.SnapshotVolumeProvisioner)
+	if !ok {
+		return nil, ErrVolumeSnapshotNotSupported
+	}
+	return snapProv, nil
+}
+
+// CreateSnapshot takes a snapshot of the volume. A name is generated from the
+// current time when none is provided. Older snapshots exceeding the plan
+// retention are removed afterwards.
+func (v *Volume) CreateSnapshot(name, eventID string) (*VolumeSnapshot, error) {
+	now := time.Now().UTC()
+	if name == "" {
+		name = fmt.Sprintf("snap-%s", now.Format("20060102150405"))
+	}
+	if !validation.ValidateName(name) {
+		msg := "Invalid snapshot name, snapshot name should have at most 40 " +
+			"characters, containing only lower case letters, numbers or dashes, " +
+			"starting with a letter."
+		return nil, errors.WithStack(&tsuruErrors.ValidationError{Message: msg})
+	}
+	prov, err := v.snapshotProvisioner()
+	if err != nil {
+		return nil, err
+	}
+	_, err = v.LoadSnapshot(name)
+	if err == nil {
+		return nil, ErrVolumeSnapshotAlreadyExists
+	}
+	if err != ErrVolumeSnapshotNotFound {
+		return nil, err
+	}
+	size, err := prov.SnapshotVolume(v.Name, name, v.Pool)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	snap := VolumeSnapshot{
+		ID:        VolumeSnapshotID{Volume: v.Name, Name: name},
+		Size:      size,
+		EventID:   eventID,
+		CreatedAt: now,
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+	err = conn.VolumeSnapshots().Insert(snap)
+	if err != nil {
+		if mgo.IsDup(err) {
+			return nil, ErrVolumeSnapshotAlreadyExists
+		}
+		return nil, errors.WithStack(err)
+	}
+	err = v.pruneSnapshots(prov)
+	if err != nil {
+		log.Errorf("[volume] unable to prune snapshots for volume %q: %v", v.Name, err)
+	}
+	return &snap, nil
+}
+
+func (v *Volume) pruneSnapshots(prov provision.SnapshotVolumeProvisioner) error {
+	retention := v.Plan.SnapshotRetention()
+	if retention <= 0 {
+		return nil
+	}
+	snapshots, err := v.ListSnapshots()
+	if err != nil {
+		return err
+	}
+	for len(snapshots) > retention {
+		err = v.removeSnapshot(prov, snapshots[0])
+		if err != nil {
+			return err
+		}
+		snapshots = snapshots[1:]
+	}
+	return nil
+}
+
+func (v *Volume) removeSnapshot(prov provision.SnapshotVolumeProvisioner, snap VolumeSnapshot) error {
+	err := prov.DeleteVolumeSnapshot(v.Name, snap.ID.Name, v.Pool)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer conn.Close()
+	err = conn.VolumeSnapshots().RemoveId(snap.ID)
+	if err != nil && err != mgo.ErrNotFound {
+		return errors.WithStack(err)
+	}
+	return nil
+}
+
+// ListSnapshots returns the volume snapshots, oldest first.
+func (v *Volume) ListSnapshots() ([]VolumeSnapshot, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+	var snapshots []VolumeSnapshot
+	err = conn.VolumeSnapshots().Find(bson.M{"_id.volume": v.Name}).Sort("createdat").All(&snapshots)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return snapshots, nil
+}
+
+func (v *Volume) LoadSnapshot(name string) (*VolumeSnapshot, error) {
+	conn, err := db.Conn()
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer conn.Close()
+	var snap VolumeSnapshot
+	err = conn.VolumeSnapshots().FindId(VolumeSnapshotID{Volume: v.Name, Name: name}).One(&snap)
+	if err == mgo.ErrNotFound {
+		return nil, ErrVolumeSnapshotNotFound
+	}
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	return &snap, nil
+}
+
+// RestoreSnapshot replaces the volume content with the content of the given
+// snapshot. The volume must not be bound to any app.
+func (v *Volume) RestoreSnapshot(name string) error {
+	_, err := v.LoadSnapshot(name)
+	if err != nil {
+		return err
+	}
+	binds, err := v.LoadBinds()
+	if err != nil {
+		return err
+	}
+	if len(binds) > 0 {
+		return ErrVolumeRestoreWithBinds
+	}
+	prov, err := v.snapshotProvisioner()
+	if err != nil {
+		return err
+	}
+	return errors.WithStack(prov.RestoreVolume(v.Name, name, v.Pool))
+}
+
+// DeleteSnapshot removes a single snapshot from the volume.
+func (v *Volume) DeleteSnapshot(name string) error {
+	snap, err := v.LoadSnapshot(name)
+	if err != nil {
+		return err
+	}
+	prov, err := v.snapshotProvisioner()
+	if err != nil {
+		return err
+	}
+	return v.removeSnapshot(prov, *snap)
+}
+
+func (v *Volume) deleteSnapshots() error {
+	snapshots, err := v.ListSnapshots()
+	if err != nil || len(snapshots) == 0 {
+		return err
+	}
+	prov, err := v.snapshotProvisioner()
+	if err != nil {
+		return err
+	}
+	for _, snap := range snapshots {
+		err = v.removeSnapshot(prov, snap)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package volume
+
+import (
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestVolumePlanSnapshotRetention(c *check.C) {
+	tests := []struct {
+		opts     map[string]interface{}
+		expected int
+	}{
+		{opts: nil, expected: 0},
+		{opts: map[string]interface{}{"snapshot-retention": 3}, expected: 3},
+		{opts: map[string]interface{}{"snapshot-retention": float64(2)}, expected: 2},
+		{opts: map[string]interface{}{"snapshot-retention": "5"}, expected: 5},
+		{opts: map[string]interface{}{"snapshot-retention": "abc"}, expected: 0},
+	}
+	for _, tt := range tests {
+		p := VolumePlan{Opts: tt.opts}
+		c.Assert(p.SnapshotRetention(), check.Equals, tt.expected)
+	}
+}
+
+func (s *S) TestVolumeCreateSnapshot(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	snap, err := v.CreateSnapshot("s1", "evt1")
+	c.Assert(err, check.IsNil)
+	c.Assert(snap.ID, check.Equals, VolumeSnapshotID{Volume: "v1", Name: "s1"})
+	c.Assert(snap.Size, check.Equals, int64(provisiontest.FakeSnapshotSize))
+	c.Assert(snap.EventID, check.Equals, "evt1")
+	c.Assert(provisiontest.ProvisionerInstance.VolumeSnapshots("v1"), check.DeepEquals, []string{"s1"})
+	dbSnap, err := v.LoadSnapshot("s1")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbSnap.Size, check.Equals, snap.Size)
+	c.Assert(dbSnap.EventID, check.Equals, "evt1")
+	_, err = v.CreateSnapshot("s1", "evt2")
+	c.Assert(err, check.Equals, ErrVolumeSnapshotAlreadyExists)
+}
+
+func (s *S) TestVolumeCreateSnapshotGeneratedName(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	snap, err := v.CreateSnapshot("", "")
+	c.Assert(err, check.IsNil)
+	c.Assert(snap.ID.Name, check.Matches, `snap-\d{14}`)
+}
+
+func (s *S) TestVolumeCreateSnapshotInvalidName(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	_, err = v.CreateSnapshot("_invalid", "")
+	c.Assert(err, check.ErrorMatches, "Invalid snapshot name.*")
+}
+
+func (s *S) TestVolumeCreateSnapshotRetention(c *check.C) {
+	updateConfig(`
+volume-plans:
+  p1:
+    fake:
+       driver: local
+       snapshot-retention: 2
+`)
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	for _, name := range []string{"s1", "s2", "s3"} {
+		_, err = v.CreateSnapshot(name, "")
+		c.Assert(err, check.IsNil)
+	}
+	snapshots, err := v.ListSnapshots()
+	c.Assert(err, check.IsNil)
+	c.Assert(snapshots, check.HasLen, 2)
+	c.Assert(snapshots[0].ID.Name, check.Equals, "s2")
+	c.Assert(snapshots[1].ID.Name, check.Equals, "s3")
+	c.Assert(provisiontest.ProvisionerInstance.VolumeSnapshots("v1"), check.DeepEquals, []string{"s2", "s3"})
+}
+
+func (s *S) TestVolumeRestoreSnapshot(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	_, err = v.CreateSnapshot("s1", "")
+	c.Assert(err, check.IsNil)
+	err = v.RestoreSnapshot("s1")
+	c.Assert(err, check.IsNil)
+	c.Assert(provisiontest.ProvisionerInstance.RestoredSnapshot("v1"), check.Equals, "s1")
+}
+
+func (s *S) TestVolumeRestoreSnapshotNotFound(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	err = v.RestoreSnapshot("s1")
+	c.Assert(err, check.Equals, ErrVolumeSnapshotNotFound)
+}
+
+func (s *S) TestVolumeRestoreSnapshotWithBinds(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	_, err = v.CreateSnapshot("s1", "")
+	c.Assert(err, check.IsNil)
+	err = v.BindApp("myapp", "/mnt", false)
+	c.Assert(err, check.IsNil)
+	err = v.RestoreSnapshot("s1")
+	c.Assert(err, check.Equals, ErrVolumeRestoreWithBinds)
+	c.Assert(provisiontest.ProvisionerInstance.RestoredSnapshot("v1"), check.Equals, "")
+}
+
+func (s *S) TestVolumeDeleteRemovesSnapshots(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	_, err = v.CreateSnapshot("s1", "")
+	c.Assert(err, check.IsNil)
+	err = v.Delete()
+	c.Assert(err, check.IsNil)
+	snapshots, err := v.ListSnapshots()
+	c.Assert(err, check.IsNil)
+	c.Assert(snapshots, check.HasLen, 0)
+	c.Assert(provisiontest.ProvisionerInstance.VolumeSnapshots("v1"), check.HasLen, 0)
+}
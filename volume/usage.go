@@ -0,0 +1,256 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package volume
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/globalsign/mgo"
+	"github.com/globalsign/mgo/bson"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/api/shutdown"
+	"github.com/tsuru/tsuru/db"
+	tsuruErrors "github.com/tsuru/tsuru/errors"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/log"
+	"github.com/tsuru/tsuru/permission"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/pool"
+	permTypes "github.com/tsuru/tsuru/types/permission"
+)
+
+const (
+	defaultUsageCollectInterval = 5 * time.Minute
+	defaultUsageThreshold       = 90.0
+
+	usageThresholdEventKind = "volume-usage-threshold"
+)
+
+var (
+	volumeUsedBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_volume_used_bytes",
+		Help: "The number of bytes used in the volume.",
+	}, []string{"volume", "pool"})
+
+	volumeAvailableBytes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "tsuru_volume_available_bytes",
+		Help: "The number of bytes available in the volume.",
+	}, []string{"volume", "pool"})
+
+	reportedMu      sync.Mutex
+	reportedVolumes = map[usageLabels]struct{}{}
+)
+
+type usageLabels struct {
+	volume string
+	pool   string
+}
+
+func init() {
+	prometheus.MustRegister(volumeUsedBytes, volumeAvailableBytes)
+}
+
+// VolumeUsage is the last known disk usage of a volume.
+type VolumeUsage struct {
+	Used      int64
+	Available int64
+	UpdatedAt time.Time
+}
+
+// Percent returns the percentage of the volume capacity currently in use.
+func (u *VolumeUsage) Percent() float64 {
+	total := u.Used + u.Available
+	if total <= 0 {
+		return 0
+	}
+	return float64(u.Used) * 100 / float64(total)
+}
+
+func usageThreshold() float64 {
+	threshold, err := config.GetFloat("volume-usage:threshold")
+	if err != nil || threshold <= 0 {
+		return defaultUsageThreshold
+	}
+	return threshold
+}
+
+// InitializeUsageCollector starts the periodic collection of volume usage
+// from provisioners implementing provision.VolumeUsageProvisioner.
+func InitializeUsageCollector() error {
+	interval, _ := config.GetDuration("volume-usage:interval")
+	if interval <= 0 {
+		interval = defaultUsageCollectInterval
+	}
+	collector := &usageCollector{interval: interval, once: &sync.Once{}}
+	collector.start()
+	shutdown.Register(collector)
+	return nil
+}
+
+type usageCollector struct {
+	interval time.Duration
+	once     *sync.Once
+	stopCh   chan struct{}
+}
+
+func (u *usageCollector) start() {
+	u.once.Do(func() {
+		u.stopCh = make(chan struct{})
+		go u.spin()
+	})
+}
+
+func (u *usageCollector) Shutdown(ctx context.Context) error {
+	if u.stopCh == nil {
+		return nil
+	}
+	u.stopCh <- struct{}{}
+	u.stopCh = nil
+	u.once = &sync.Once{}
+	return nil
+}
+
+func (u *usageCollector) spin() {
+	for {
+		err := CollectUsage()
+		if err != nil {
+			log.Errorf("[volume usage] errors collecting volume usage: %v", err)
+		}
+		select {
+		case <-u.stopCh:
+			return
+		case <-time.After(u.interval):
+		}
+	}
+}
+
+// CollectUsage updates the usage information of every volume whose
+// provisioner is able to report it.
+func CollectUsage() error {
+	volumes, err := ListByFilter(nil)
+	if err != nil {
+		return err
+	}
+	multi := tsuruErrors.NewMultiError()
+	existing := make(map[usageLabels]struct{}, len(volumes))
+	for i := range volumes {
+		existing[usageLabels{volume: volumes[i].Name, pool: volumes[i].Pool}] = struct{}{}
+		err = volumes[i].collectUsage()
+		if err != nil {
+			multi.Add(errors.Wrapf(err, "volume %q", volumes[i].Name))
+		}
+	}
+	removeStaleUsage(existing)
+	if multi.Len() > 0 {
+		return multi
+	}
+	return nil
+}
+
+func (v *Volume) collectUsage() error {
+	p, err := pool.GetPoolByName(v.Pool)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	prov, err := p.GetProvisioner()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	usageProv, ok := prov.(provision.VolumeUsageProvisioner)
+	if !ok {
+		return nil
+	}
+	provUsage, err := usageProv.VolumeUsage(v.Name, v.Pool)
+	if err != nil || provUsage == nil {
+		return err
+	}
+	usage := VolumeUsage{
+		Used:      provUsage.Used,
+		Available: provUsage.Available,
+		UpdatedAt: time.Now().UTC(),
+	}
+	volumeUsedBytes.WithLabelValues(v.Name, v.Pool).Set(float64(usage.Used))
+	volumeAvailableBytes.WithLabelValues(v.Name, v.Pool).Set(float64(usage.Available))
+	reportedMu.Lock()
+	reportedVolumes[usageLabels{volume: v.Name, pool: v.Pool}] = struct{}{}
+	reportedMu.Unlock()
+	conn, err := db.Conn()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer conn.Close()
+	err = conn.Volumes().UpdateId(v.Name, bson.M{"$set": bson.M{"usage": usage}})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	v.Usage = &usage
+	threshold := usageThreshold()
+	if usage.Percent() < threshold {
+		if !v.UsageNotified {
+			return nil
+		}
+		err = conn.Volumes().UpdateId(v.Name, bson.M{"$unset": bson.M{"usagenotified": ""}})
+		if err != nil {
+			return errors.WithStack(err)
+		}
+		v.UsageNotified = false
+		return nil
+	}
+	// Every tsurud replica runs the collector, only the one able to set the
+	// marker is responsible for notifying that the threshold was crossed.
+	err = conn.Volumes().Update(bson.M{
+		"_id":           v.Name,
+		"usagenotified": bson.M{"$ne": true},
+	}, bson.M{"$set": bson.M{"usagenotified": true}})
+	if err == mgo.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	v.UsageNotified = true
+	return v.notifyUsageThreshold(threshold)
+}
+
+// removeStaleUsage drops the usage metrics reported for volumes that no
+// longer exist.
+func removeStaleUsage(existing map[usageLabels]struct{}) {
+	reportedMu.Lock()
+	defer reportedMu.Unlock()
+	for labels := range reportedVolumes {
+		if _, ok := existing[labels]; ok {
+			continue
+		}
+		volumeUsedBytes.DeleteLabelValues(labels.volume, labels.pool)
+		volumeAvailableBytes.DeleteLabelValues(labels.volume, labels.pool)
+		delete(reportedVolumes, labels)
+	}
+}
+
+func (v *Volume) notifyUsageThreshold(threshold float64) error {
+	evt, err := event.NewInternal(&event.Opts{
+		Target:       event.Target{Type: event.TargetTypeVolume, Value: v.Name},
+		InternalKind: usageThresholdEventKind,
+		CustomData: map[string]interface{}{
+			"used":      v.Usage.Used,
+			"available": v.Usage.Available,
+			"threshold": threshold,
+		},
+		Allowed: event.Allowed(permission.PermVolumeReadEvents,
+			permission.Context(permTypes.CtxVolume, v.Name),
+			permission.Context(permTypes.CtxTeam, v.TeamOwner),
+			permission.Context(permTypes.CtxPool, v.Pool),
+		),
+	})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	log.Debugf("[volume usage] volume %q is %.1f%% full, above the %.1f%% threshold", v.Name, v.Usage.Percent(), threshold)
+	return evt.Done(nil)
+}
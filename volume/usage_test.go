@@ -0,0 +1,138 @@
+// Copyright 2018 tsuru authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package volume
+
+import (
+	"github.com/globalsign/mgo/bson"
+	"github.com/tsuru/config"
+	"github.com/tsuru/tsuru/db"
+	"github.com/tsuru/tsuru/event"
+	"github.com/tsuru/tsuru/provision"
+	"github.com/tsuru/tsuru/provision/provisiontest"
+	"gopkg.in/check.v1"
+)
+
+func (s *S) TestVolumeUsagePercent(c *check.C) {
+	c.Assert((&VolumeUsage{}).Percent(), check.Equals, 0.0)
+	c.Assert((&VolumeUsage{Used: 25, Available: 75}).Percent(), check.Equals, 25.0)
+}
+
+func (s *S) TestCollectUsage(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	v2 := Volume{Name: "v2", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err = v2.Create()
+	c.Assert(err, check.IsNil)
+	provisiontest.ProvisionerInstance.SetVolumeUsage("v1", provision.VolumeUsage{Used: 10, Available: 90})
+	err = CollectUsage()
+	c.Assert(err, check.IsNil)
+	dbVolume, err := Load("v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbVolume.Usage, check.NotNil)
+	c.Assert(dbVolume.Usage.Used, check.Equals, int64(10))
+	c.Assert(dbVolume.Usage.Available, check.Equals, int64(90))
+	c.Assert(dbVolume.Usage.UpdatedAt.IsZero(), check.Equals, false)
+	dbVolume, err = Load("v2")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbVolume.Usage, check.IsNil)
+	evts, err := event.List(&event.Filter{KindNames: []string{usageThresholdEventKind}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+}
+
+func (s *S) TestCollectUsageThresholdEvent(c *check.C) {
+	config.Set("volume-usage:threshold", 80)
+	defer config.Unset("volume-usage")
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	provisiontest.ProvisionerInstance.SetVolumeUsage("v1", provision.VolumeUsage{Used: 85, Available: 15})
+	err = CollectUsage()
+	c.Assert(err, check.IsNil)
+	evts, err := event.List(&event.Filter{KindNames: []string{usageThresholdEventKind}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+	c.Assert(evts[0].Target, check.Equals, event.Target{Type: event.TargetTypeVolume, Value: "v1"})
+	err = CollectUsage()
+	c.Assert(err, check.IsNil)
+	evts, err = event.List(&event.Filter{KindNames: []string{usageThresholdEventKind}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 1)
+}
+
+func (s *S) TestCollectUsageThresholdEventAfterUsageDrops(c *check.C) {
+	config.Set("volume-usage:threshold", 80)
+	defer config.Unset("volume-usage")
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	provisiontest.ProvisionerInstance.SetVolumeUsage("v1", provision.VolumeUsage{Used: 85, Available: 15})
+	err = CollectUsage()
+	c.Assert(err, check.IsNil)
+	dbVolume, err := Load("v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbVolume.UsageNotified, check.Equals, true)
+	provisiontest.ProvisionerInstance.SetVolumeUsage("v1", provision.VolumeUsage{Used: 50, Available: 50})
+	err = CollectUsage()
+	c.Assert(err, check.IsNil)
+	dbVolume, err = Load("v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbVolume.UsageNotified, check.Equals, false)
+	provisiontest.ProvisionerInstance.SetVolumeUsage("v1", provision.VolumeUsage{Used: 90, Available: 10})
+	err = CollectUsage()
+	c.Assert(err, check.IsNil)
+	evts, err := event.List(&event.Filter{KindNames: []string{usageThresholdEventKind}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 2)
+}
+
+func (s *S) TestCollectUsageThresholdEventAlreadyNotified(c *check.C) {
+	config.Set("volume-usage:threshold", 80)
+	defer config.Unset("volume-usage")
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	conn, err := db.Conn()
+	c.Assert(err, check.IsNil)
+	defer conn.Close()
+	err = conn.Volumes().UpdateId("v1", bson.M{"$set": bson.M{"usagenotified": true}})
+	c.Assert(err, check.IsNil)
+	provisiontest.ProvisionerInstance.SetVolumeUsage("v1", provision.VolumeUsage{Used: 85, Available: 15})
+	err = CollectUsage()
+	c.Assert(err, check.IsNil)
+	evts, err := event.List(&event.Filter{KindNames: []string{usageThresholdEventKind}})
+	c.Assert(err, check.IsNil)
+	c.Assert(evts, check.HasLen, 0)
+}
+
+func (s *S) TestCollectUsageRemovesStaleMetrics(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	provisiontest.ProvisionerInstance.SetVolumeUsage("v1", provision.VolumeUsage{Used: 10, Available: 90})
+	err = CollectUsage()
+	c.Assert(err, check.IsNil)
+	err = v.Delete()
+	c.Assert(err, check.IsNil)
+	err = CollectUsage()
+	c.Assert(err, check.IsNil)
+	c.Assert(volumeUsedBytes.DeleteLabelValues("v1", "mypool"), check.Equals, false)
+	c.Assert(volumeAvailableBytes.DeleteLabelValues("v1", "mypool"), check.Equals, false)
+}
+
+func (s *S) TestVolumeResize(c *check.C) {
+	v := Volume{Name: "v1", Plan: VolumePlan{Name: "p1"}, Pool: "mypool", TeamOwner: "myteam"}
+	err := v.Create()
+	c.Assert(err, check.IsNil)
+	err = v.Resize("")
+	c.Assert(err, check.ErrorMatches, "capacity is required")
+	err = v.Resize("30Gi")
+	c.Assert(err, check.IsNil)
+	c.Assert(provisiontest.ProvisionerInstance.ResizedVolume("v1"), check.Equals, "30Gi")
+	dbVolume, err := Load("v1")
+	c.Assert(err, check.IsNil)
+	c.Assert(dbVolume.Opts, check.DeepEquals, map[string]string{"capacity": "30Gi"})
+}
@@ -26,6 +26,7 @@ var (
 	ErrVolumeAlreadyBound       = errors.New("volume already bound in mountpoint")
 	ErrVolumeBindNotFound       = errors.New("volume bind not found")
 	ErrVolumeAlreadyProvisioned = errors.New("updating a volume already provisioned is not supported, a new volume must be created and the old one deleted if necessary")
+	ErrVolumeResizeNotSupported = errors.New("provisioner does not support resizing volumes")
 )
 
 type VolumePlan struct {
@@ -52,6 +53,11 @@ type Volume struct {
 	Status    string
 	Binds     []VolumeBind      `bson:"-"`
 	Opts      map[string]string `bson:",omitempty"`
+	Usage     *VolumeUsage      `bson:",omitempty"`
+
+	// UsageNotified is set once the usage threshold event is emitted and
+	// cleared when the usage drops below the threshold again.
+	UsageNotified bool `bson:",omitempty"`
 }
 
 func (v *Volume) UnmarshalPlan(result interface{}) error {
@@ -153,6 +159,44 @@ func (v *Volume) isProvisioned() (bool, error) {
 	return isProv, nil
 }
 
+// Resize expands the capacity of an already provisioned volume. The new
+// capacity is stored in the volume opts, overriding the plan capacity.
+func (v *Volume) Resize(capacity string) error {
+	if capacity == "" {
+		return errors.WithStack(&tsuruErrors.ValidationError{Message: "capacity is required"})
+	}
+	p, err := pool.GetPoolByName(v.Pool)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	prov, err := p.GetProvisioner()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	resizeProv, ok := prov.(provision.ResizableVolumeProvisioner)
+	if !ok {
+		return ErrVolumeResizeNotSupported
+	}
+	err = resizeProv.ResizeVolume(v.Name, v.Pool, capacity)
+	if err != nil {
+		return err
+	}
+	conn, err := db.Conn()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	defer conn.Close()
+	err = conn.Volumes().UpdateId(v.Name, bson.M{"$set": bson.M{"opts.capacity": capacity}})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	if v.Opts == nil {
+		v.Opts = map[string]string{}
+	}
+	v.Opts["capacity"] = capacity
+	return nil
+}
+
 func (v *Volume) BindApp(appName, mountPoint string, readOnly bool) error {
 	conn, err := db.Conn()
 	if err != nil {
@@ -231,6 +275,10 @@ func (v *Volume) Delete() error {
 	if len(binds) > 0 {
 		return errors.New("cannot delete volume with existing binds")
 	}
+	err = v.deleteSnapshots()
+	if err != nil {
+		return err
+	}
 	p, err := pool.GetPoolByName(v.Pool)
 	if err != nil {
 		return errors.WithStack(err)